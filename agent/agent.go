@@ -217,6 +217,12 @@ type MigrateParams struct {
 	DeleteValues []string
 	Values       map[string]string
 	Environment  names.EnvironTag
+
+	// APIAddresses and CACert, if set, replace the addresses and
+	// CA certificate of the API servers to which the agent connects,
+	// as when its environment is moved to another controller.
+	APIAddresses []string
+	CACert       string
 }
 
 // Ensure that the configInternal struct implements the Config interface.
@@ -470,6 +476,12 @@ func (config *configInternal) Migrate(newParams MigrateParams) error {
 	if newParams.Environment.Id() != "" {
 		config.environment = newParams.Environment
 	}
+	if len(newParams.APIAddresses) > 0 && config.apiDetails != nil {
+		config.apiDetails.addresses = append([]string{}, newParams.APIAddresses...)
+	}
+	if newParams.CACert != "" {
+		config.caCert = newParams.CACert
+	}
 	if err := config.check(); err != nil {
 		return fmt.Errorf("migrated agent config is invalid: %v", err)
 	}
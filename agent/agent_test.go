@@ -308,6 +308,13 @@ func (*suite) TestMigrate(c *gc.C) {
 		newParams: agent.MigrateParams{
 			Jobs: []multiwatcher.MachineJob{multiwatcher.JobHostUnits},
 		},
+	}, {
+		comment: "API servers can be moved",
+		fields:  []string{"APIAddresses", "CACert"},
+		newParams: agent.MigrateParams{
+			APIAddresses: []string{"10.0.0.1:17070"},
+			CACert:       "new ca cert",
+		},
 	}, {
 		comment:   "invalid/immutable field specified",
 		fields:    []string{"InvalidField"},
@@ -31,6 +31,10 @@ func PatchConfig(config Config, fieldName string, value interface{}) error {
 		for _, key := range value.([]string) {
 			delete(conf.values, key)
 		}
+	case "APIAddresses":
+		conf.apiDetails.addresses = value.([]string)[:]
+	case "CACert":
+		conf.caCert = value.(string)
 	case "Values":
 		for key, val := range value.(map[string]string) {
 			if conf.values == nil {
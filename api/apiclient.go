@@ -8,6 +8,7 @@ import (
 	"crypto/x509"
 	"io"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 
@@ -15,14 +16,19 @@ import (
 	"github.com/juju/loggo"
 	"github.com/juju/names"
 	"github.com/juju/utils"
+	"github.com/juju/utils/featureflag"
 	"github.com/juju/utils/parallel"
 	"golang.org/x/net/websocket"
 
 	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/cert"
+	"github.com/juju/juju/feature"
+	"github.com/juju/juju/juju/osenv"
 	"github.com/juju/juju/network"
 	"github.com/juju/juju/rpc"
+	"github.com/juju/juju/rpc/bsoncodec"
 	"github.com/juju/juju/rpc/jsoncodec"
+	"github.com/juju/juju/trace"
 	"github.com/juju/juju/version"
 )
 
@@ -32,6 +38,18 @@ var logger = loggo.GetLogger("juju.api")
 // will run. It's a variable so it can be changed in tests.
 var PingPeriod = 1 * time.Minute
 
+// RateLimitBackoff holds the delays between successive retries of an
+// API call rejected because the caller has exceeded the server's
+// request limits. Once the delays are exhausted, the error is
+// returned. It's a variable so it can be changed in tests.
+var RateLimitBackoff = []time.Duration{
+	100 * time.Millisecond,
+	200 * time.Millisecond,
+	400 * time.Millisecond,
+	800 * time.Millisecond,
+	1600 * time.Millisecond,
+}
+
 type State struct {
 	client *rpc.Conn
 	conn   *websocket.Conn
@@ -128,6 +146,16 @@ type DialOpts struct {
 	// RetryDelay is the amount of time to wait between
 	// unsucssful connection attempts.
 	RetryDelay time.Duration
+
+	// BinaryCodec specifies whether to offer the compact
+	// binary RPC codec to the API server. Servers that do
+	// not support it will continue to talk JSON.
+	BinaryCodec bool
+
+	// TraceId, if set, is sent with every API request made on
+	// the connection so that the resulting server-side log
+	// messages can be correlated with juju debug-log --trace.
+	TraceId string
 }
 
 // DefaultDialOpts returns a DialOpts representing the default
@@ -137,6 +165,8 @@ func DefaultDialOpts() DialOpts {
 		DialAddressInterval: 50 * time.Millisecond,
 		Timeout:             10 * time.Minute,
 		RetryDelay:          2 * time.Second,
+		BinaryCodec:         featureflag.Enabled(feature.BinaryRPC),
+		TraceId:             os.Getenv(osenv.JujuTraceIdEnvKey),
 	}
 }
 
@@ -153,12 +183,24 @@ func Open(info *Info, opts DialOpts) (*State, error) {
 // function, and also the OpenWithVersion function below to explicitly cause
 // the API server to think that the client is older than it really is.
 func open(info *Info, opts DialOpts, loginFunc func(st *State, tag, pwd, nonce string) error) (*State, error) {
+	if opts.TraceId != "" && !trace.IsValidId(opts.TraceId) {
+		return nil, errors.NotValidf("trace id %q", opts.TraceId)
+	}
 	conn, err := Connect(info, "", nil, opts)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
 
-	client := rpc.NewConn(jsoncodec.NewWebsocket(conn), nil)
+	var codec rpc.Codec = jsoncodec.NewWebsocket(conn)
+	if bsoncodec.Negotiated(conn) {
+		codec = bsoncodec.NewWebsocket(conn)
+	}
+	client := rpc.NewConn(codec, nil)
+	if opts.TraceId != "" {
+		// The trace id has already been validated.
+		client.SetTraceId(opts.TraceId)
+		logger.Debugf("tracing API requests with id %q", opts.TraceId)
+	}
 	client.Start()
 	st := &State{
 		client:            client,
@@ -208,7 +250,9 @@ func OpenWithVersion(info *Info, opts DialOpts, loginVersion int) (*State, error
 // concurrently - the first successful connection wins.
 //
 // The path tail may be blank, in which case the default value will be
-// used. Otherwise, it must start with a "/".
+// used. Otherwise, it must start with a "/". When the path tail is blank
+// and opts.BinaryCodec is set, the binary RPC codec is offered to the
+// server during the handshake.
 func Connect(info *Info, pathTail string, header http.Header, opts DialOpts) (*websocket.Conn, error) {
 	if len(info.Addrs) == 0 {
 		return nil, errors.New("no API addresses to connect to")
@@ -237,12 +281,20 @@ func Connect(info *Info, pathTail string, header http.Header, opts DialOpts) (*w
 	}
 
 	path := makeAPIPath(info.EnvironTag.Id(), pathTail)
+	var protocols []string
+	if pathTail == "" && opts.BinaryCodec {
+		// Always offer JSON alongside the binary codec: servers
+		// that predate codec negotiation refuse handshakes that
+		// offer more than one sub-protocol, which is how we
+		// know to fall back to plain JSON.
+		protocols = []string{bsoncodec.Protocol, jsoncodec.Protocol}
+	}
 
 	// Dial all addresses at reasonable intervals.
 	try := parallel.NewTry(0, nil)
 	defer try.Kill()
 	for _, addr := range addrs {
-		err := dialWebsocket(addr, path, header, opts, pool, try)
+		err := dialWebsocket(addr, path, header, protocols, opts, pool, try)
 		if err == parallel.ErrStopped {
 			break
 		}
@@ -287,11 +339,12 @@ func toString(tag names.Tag) string {
 	return tag.String()
 }
 
-func dialWebsocket(addr, path string, header http.Header, opts DialOpts, rootCAs *x509.CertPool, try *parallel.Try) error {
+func dialWebsocket(addr, path string, header http.Header, protocols []string, opts DialOpts, rootCAs *x509.CertPool, try *parallel.Try) error {
 	cfg, err := setUpWebsocket(addr, path, header, rootCAs)
 	if err != nil {
 		return err
 	}
+	cfg.Protocol = protocols
 	return try.Start(newWebsocketDialer(cfg, opts))
 }
 
@@ -330,6 +383,11 @@ func createWebsocketDialer(cfg *websocket.Config, opts DialOpts) func(<-chan str
 			}
 			logger.Infof("dialing %q", cfg.Location)
 			conn, err := websocket.DialConfig(cfg)
+			if err != nil && len(cfg.Protocol) > 1 && isHandshakeRefused(err) {
+				logger.Debugf("%q refused codec negotiation, falling back to JSON", cfg.Location)
+				cfg.Protocol = nil
+				conn, err = websocket.DialConfig(cfg)
+			}
 			if err == nil {
 				return conn, nil
 			}
@@ -344,6 +402,13 @@ func createWebsocketDialer(cfg *websocket.Config, opts DialOpts) func(<-chan str
 	}
 }
 
+// isHandshakeRefused reports whether the given websocket dial
+// error was caused by the server rejecting the handshake.
+func isHandshakeRefused(err error) bool {
+	dialErr, ok := err.(*websocket.DialError)
+	return ok && dialErr.Err == websocket.ErrBadStatus
+}
+
 func (s *State) heartbeatMonitor() {
 	for {
 		if err := s.Ping(); err != nil {
@@ -357,6 +422,18 @@ func (s *State) heartbeatMonitor() {
 	}
 }
 
+// SetTraceId sets the trace id sent with all subsequent API
+// requests made on the connection. An empty id disables tracing.
+// Trace ids may contain only letters, digits, '.', '_' and '-'.
+func (s *State) SetTraceId(id string) error {
+	return s.client.SetTraceId(id)
+}
+
+// TraceId returns the trace id sent with API requests.
+func (s *State) TraceId() string {
+	return s.client.TraceId()
+}
+
 func (s *State) Ping() error {
 	return s.APICall("Pinger", s.BestFacadeVersion("Pinger"), "", "Ping", nil, nil)
 }
@@ -366,14 +443,28 @@ func (s *State) Ping() error {
 // This fills out the rpc.Request on the given facade, version for a given
 // object id, and the specific RPC method. It marshalls the Arguments, and will
 // unmarshall the result into the response object that is supplied.
+//
+// Calls rejected because the caller has exceeded the server's
+// request limits are retried after the delays in RateLimitBackoff.
 func (s *State) APICall(facade string, version int, id, method string, args, response interface{}) error {
-	err := s.client.Call(rpc.Request{
+	req := rpc.Request{
 		Type:    facade,
 		Version: version,
 		Id:      id,
 		Action:  method,
-	}, args, response)
-	return params.ClientError(err)
+	}
+	for i := 0; ; i++ {
+		err := params.ClientError(s.client.Call(req, args, response))
+		if !params.IsCodeRateLimitExceeded(err) || i >= len(RateLimitBackoff) {
+			return err
+		}
+		logger.Debugf("%s.%s rate limited, retrying in %v", facade, method, RateLimitBackoff[i])
+		select {
+		case <-time.After(RateLimitBackoff[i]):
+		case <-s.closed:
+			return err
+		}
+	}
 }
 
 func (s *State) Close() error {
@@ -451,6 +542,50 @@ func (s *State) BestFacadeVersion(facade string) int {
 	return bestVersion(facadeVersions[facade], s.facadeVersions[facade])
 }
 
+// SupportsFacade reports whether the API server supports a version of
+// the given facade that is at least minVersion and is known to the
+// client.
+func (s *State) SupportsFacade(facade string, minVersion int) bool {
+	known, ok := facadeVersions[facade]
+	if !ok {
+		return false
+	}
+	for _, version := range s.facadeVersions[facade] {
+		if version >= minVersion && version <= known {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckFacadeSupport returns a *NotSupportedError if the API server
+// does not support the given facade at minVersion or later.
+func (s *State) CheckFacadeSupport(facade string, minVersion int) error {
+	if !s.SupportsFacade(facade, minVersion) {
+		return &NotSupportedError{
+			Facade:  facade,
+			Version: minVersion,
+		}
+	}
+	return nil
+}
+
+// CheckMethodSupport returns a *NotSupportedError if the API server
+// does not support the given facade method. Methods not recorded as
+// having been added in a later facade version are assumed to be
+// present in all versions of their facade.
+func (s *State) CheckMethodSupport(facade, method string) error {
+	minVersion := methodVersions[facade+"."+method]
+	if !s.SupportsFacade(facade, minVersion) {
+		return &NotSupportedError{
+			Facade:  facade,
+			Method:  method,
+			Version: minVersion,
+		}
+	}
+	return nil
+}
+
 // serverRoot returns the cached API server address and port used
 // to login, prefixed with "<URI scheme>://" (usually https).
 func (s *State) serverRoot() string {
@@ -21,6 +21,7 @@ import (
 	"github.com/juju/juju/api"
 	"github.com/juju/juju/apiserver/params"
 	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/rpc/bsoncodec"
 	"github.com/juju/juju/version"
 )
 
@@ -55,6 +56,23 @@ func (s *apiclientSuite) TestConnectToRoot(c *gc.C) {
 	assertConnAddrForRoot(c, conn, info.Addrs[0])
 }
 
+func (s *apiclientSuite) TestConnectOffersBinaryCodec(c *gc.C) {
+	info := s.APIInfo(c)
+	conn, err := api.Connect(info, "", nil, api.DialOpts{BinaryCodec: true})
+	c.Assert(err, jc.ErrorIsNil)
+	defer conn.Close()
+	c.Assert(conn.Config().Protocol, jc.DeepEquals, []string{bsoncodec.Protocol})
+	c.Assert(bsoncodec.Negotiated(conn), jc.IsTrue)
+}
+
+func (s *apiclientSuite) TestConnectWithPathTailDoesNotOfferBinaryCodec(c *gc.C) {
+	info := s.APIInfo(c)
+	conn, err := api.Connect(info, "/log", nil, api.DialOpts{BinaryCodec: true})
+	c.Assert(err, jc.ErrorIsNil)
+	defer conn.Close()
+	c.Assert(bsoncodec.Negotiated(conn), jc.IsFalse)
+}
+
 func (s *apiclientSuite) TestConnectWithHeader(c *gc.C) {
 	var seenCfg *websocket.Config
 	fakeNewDialer := func(cfg *websocket.Config, _ api.DialOpts) func(<-chan struct{}) (io.Closer, error) {
@@ -188,6 +206,34 @@ func (s *apiclientSuite) TestOpen(c *gc.C) {
 	c.Assert(remoteVersion, gc.Equals, version.Current.Number)
 }
 
+func (s *apiclientSuite) TestOpenWithBinaryCodec(c *gc.C) {
+	info := s.APIInfo(c)
+	st, err := api.Open(info, api.DialOpts{BinaryCodec: true})
+	c.Assert(err, jc.ErrorIsNil)
+	defer st.Close()
+
+	status, err := st.Client().Status(nil)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(status.EnvironmentName, gc.Equals, "dummyenv")
+}
+
+func (s *apiclientSuite) TestOpenWithTraceId(c *gc.C) {
+	info := s.APIInfo(c)
+	st, err := api.Open(info, api.DialOpts{TraceId: "deploy-1"})
+	c.Assert(err, jc.ErrorIsNil)
+	defer st.Close()
+	c.Assert(st.TraceId(), gc.Equals, "deploy-1")
+
+	_, err = st.Client().Status(nil)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *apiclientSuite) TestOpenWithInvalidTraceId(c *gc.C) {
+	info := s.APIInfo(c)
+	_, err := api.Open(info, api.DialOpts{TraceId: "deploy 1"})
+	c.Assert(err, gc.ErrorMatches, `trace id "deploy 1" not valid`)
+}
+
 func (s *apiclientSuite) TestOpenHonorsEnvironTag(c *gc.C) {
 	info := s.APIInfo(c)
 
@@ -250,6 +250,28 @@ func (c *Client) PrivateAddress(target string) (string, error) {
 	return results.PrivateAddress, err
 }
 
+// UnitHistory returns the recorded hook and action executions of
+// the named unit, oldest first.
+func (c *Client) UnitHistory(unitName string) ([]params.UnitHistoryEntry, error) {
+	if !names.IsValidUnit(unitName) {
+		return nil, errors.NotValidf("unit name %q", unitName)
+	}
+	var results params.UnitHistoryResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: names.NewUnitTag(unitName).String()}},
+	}
+	if err := c.facade.FacadeCall("UnitHistory", args, &results); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(results.Results) != 1 {
+		return nil, errors.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	if err := results.Results[0].Error; err != nil {
+		return nil, err
+	}
+	return results.Results[0].Entries, nil
+}
+
 // ServiceSetYAML sets configuration options on a service
 // given options in YAML format.
 func (c *Client) ServiceSetYAML(service string, yaml string) error {
@@ -337,6 +359,36 @@ func (c *Client) ForceDestroyMachines(machines ...string) error {
 	return c.facade.FacadeCall("DestroyMachines", params, nil)
 }
 
+// StartContainerMigration marks a container as being migrated to
+// another host machine, and returns the container's instance id.
+func (c *Client) StartContainerMigration(machineId, hostId string) (instance.Id, error) {
+	var result params.ContainerMigrationResult
+	args := params.ContainerMigration{MachineId: machineId, HostId: hostId}
+	if err := c.facade.FacadeCall("StartContainerMigration", args, &result); err != nil {
+		return "", err
+	}
+	return result.InstanceId, nil
+}
+
+// CompleteContainerMigration moves a container's units to a new
+// machine on the host it is being migrated to, and returns the new
+// machine's id.
+func (c *Client) CompleteContainerMigration(machineId string) (string, error) {
+	var result params.ContainerMigrationResult
+	args := params.ContainerMigration{MachineId: machineId}
+	if err := c.facade.FacadeCall("CompleteContainerMigration", args, &result); err != nil {
+		return "", err
+	}
+	return result.MachineId, nil
+}
+
+// AbortContainerMigration abandons the migration of a container that
+// has been started and not completed.
+func (c *Client) AbortContainerMigration(machineId string) error {
+	args := params.ContainerMigration{MachineId: machineId}
+	return c.facade.FacadeCall("AbortContainerMigration", args, nil)
+}
+
 // ServiceExpose changes the juju-managed firewall to expose any ports that
 // were also explicitly marked by units as open.
 func (c *Client) ServiceExpose(service string) error {
@@ -344,6 +396,20 @@ func (c *Client) ServiceExpose(service string) error {
 	return c.facade.FacadeCall("ServiceExpose", params, nil)
 }
 
+// ServiceExposeToCIDRs works like ServiceExpose, but restricts access
+// to the service to addresses within the given CIDRs. Older API servers
+// ignore the CIDRs, so the call is refused unless the server is known
+// to honour them.
+func (c *Client) ServiceExposeToCIDRs(service string, cidrs []string) error {
+	if len(cidrs) > 0 {
+		if err := c.st.CheckFacadeSupport("Client", 1); err != nil {
+			return errors.Annotate(err, "cannot expose service to CIDRs")
+		}
+	}
+	params := params.ServiceExpose{ServiceName: service, CIDRs: cidrs}
+	return c.facade.FacadeCall("ServiceExpose", params, nil)
+}
+
 // ServiceUnexpose changes the juju-managed firewall to unexpose any ports that
 // were also explicitly marked by units as open.
 func (c *Client) ServiceUnexpose(service string) error {
@@ -702,6 +768,20 @@ func (c *Client) Run(run params.RunParams) ([]params.RunResult, error) {
 	return results.Results, err
 }
 
+// DebugHooks performs an operation of a non-interactive debug-hooks
+// session on a unit, returning the output of that operation.
+func (c *Client) DebugHooks(args params.DebugHooksParams) (params.RunResult, error) {
+	var results params.RunResults
+	err := c.facade.FacadeCall("DebugHooks", args, &results)
+	if err != nil {
+		return params.RunResult{}, err
+	}
+	if len(results.Results) != 1 {
+		return params.RunResult{}, errors.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	return results.Results[0], nil
+}
+
 // DestroyEnvironment puts the environment into a "dying" state,
 // and removes all non-manager machine instances. DestroyEnvironment
 // will fail if there are any manually-provisioned non-manager machines
@@ -1004,6 +1084,9 @@ type DebugLogParams struct {
 	// Replay tells the server to start at the start of the log file rather
 	// than the end. If replay is true, backlog is ignored.
 	Replay bool
+	// TraceId, if set, restricts the response to log messages
+	// relating to API requests made with that trace id.
+	TraceId string
 }
 
 // WatchDebugLog returns a ReadCloser that the caller can read the log
@@ -1040,6 +1123,9 @@ func (c *Client) WatchDebugLog(args DebugLogParams) (io.ReadCloser, error) {
 	attrs["includeModule"] = args.IncludeModule
 	attrs["excludeEntity"] = args.ExcludeEntity
 	attrs["excludeModule"] = args.ExcludeModule
+	if args.TraceId != "" {
+		attrs.Set("traceId", args.TraceId)
+	}
 
 	path := "/log"
 	if _, ok := c.st.ServerVersion(); ok {
@@ -132,6 +132,15 @@ func (s *clientSuite) TestAddLocalCharmError(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, "charm upload failed: 405 \\(Method Not Allowed\\)")
 }
 
+func (s *clientSuite) TestServiceExposeToCIDRsOldServer(c *gc.C) {
+	// A server without version 1 of the Client facade would ignore
+	// the CIDRs and expose the service to everyone.
+	s.PatchValue(api.FacadeVersions, map[string]int{"Client": 0})
+	err := s.APIState.Client().ServiceExposeToCIDRs("wordpress", []string{"10.0.0.0/8"})
+	c.Assert(err, gc.ErrorMatches, `cannot expose service to CIDRs: Client is not supported by this server version \(needs Client facade version 1\)`)
+	c.Assert(err, jc.Satisfies, api.IsNotSupportedError)
+}
+
 func (s *clientSuite) TestClientEnvironmentUUID(c *gc.C) {
 	environ, err := s.State.Environment()
 	c.Assert(err, jc.ErrorIsNil)
@@ -0,0 +1,91 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package environmentmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/apiserver/params"
+)
+
+// httpClient represents the methods of api.State (see api/http.go)
+// needed to copy charm archives between controllers.
+type httpClient interface {
+	NewEnvironHTTPRequest(method, uuid, path string) (*http.Request, error)
+	NewHTTPClient() *http.Client
+}
+
+// CharmArchive returns the archive of the charm with the given URL in
+// the environment with the given UUID, so that it may be copied to
+// another controller with ImportCharmArchive. The caller must close
+// the archive.
+func (c *Client) CharmArchive(uuid, curl string) (io.ReadCloser, error) {
+	resp, err := c.sendCharmsRequest("GET", uuid, url.Values{
+		"url":  {curl},
+		"file": {"*"},
+	}, nil)
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot download charm %q", curl)
+	}
+	return resp.Body, nil
+}
+
+// ImportCharmArchive stores the archive of a charm in the environment
+// with the given UUID, imported from another controller. The archive
+// is streamed to the API server.
+func (c *Client) ImportCharmArchive(uuid, curl string, archive io.Reader) error {
+	if c.facade.BestAPIVersion() < 2 {
+		// Servers with earlier facade versions cannot import
+		// environments, or their charms.
+		return errors.NotImplementedf("ImportCharmArchive() (need V2+)")
+	}
+	resp, err := c.sendCharmsRequest("POST", uuid, url.Values{"url": {curl}}, archive)
+	if err != nil {
+		return errors.Annotatef(err, "cannot upload charm %q", curl)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// sendCharmsRequest sends a request to the charms endpoint of the
+// environment with the given UUID. The response body must be closed
+// if no error is returned.
+func (c *Client) sendCharmsRequest(method, uuid string, query url.Values, body io.Reader) (*http.Response, error) {
+	if c.http == nil {
+		return nil, errors.New("HTTP requests not supported by API connection")
+	}
+	req, err := c.http.NewEnvironHTTPRequest(method, uuid, "charms")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	req.URL.RawQuery = query.Encode()
+	if body != nil {
+		req.Body = ioutil.NopCloser(body)
+		req.Header.Set("Content-Type", "application/zip")
+	}
+	resp, err := c.http.NewHTTPClient().Do(req)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot read response")
+	}
+	var result params.CharmsResponse
+	if err := json.Unmarshal(data, &result); err != nil || result.Error == "" {
+		return nil, errors.Errorf("%s (%s)", resp.Status, bytes.TrimSpace(data))
+	}
+	return nil, errors.New(result.Error)
+}
@@ -21,6 +21,7 @@ var logger = loggo.GetLogger("juju.api.environmentmanager")
 type Client struct {
 	base.ClientFacade
 	facade base.FacadeCaller
+	http   httpClient
 }
 
 // NewClient creates a new `Client` based on an existing authenticated API
@@ -28,7 +29,11 @@ type Client struct {
 func NewClient(st base.APICallCloser) *Client {
 	frontend, backend := base.NewClientFacade(st, "EnvironmentManager")
 	logger.Debugf("%#v", frontend)
-	return &Client{ClientFacade: frontend, facade: backend}
+	client := &Client{ClientFacade: frontend, facade: backend}
+	if h, ok := st.(httpClient); ok {
+		client.http = h
+	}
+	return client
 }
 
 // ConfigSkeleton returns config values to be used as a starting point for the
@@ -84,3 +89,169 @@ func (c *Client) ListEnvironments(user string) ([]params.Environment, error) {
 	}
 	return result.Environments, nil
 }
+
+// facadeCallV2 calls a method added in version 2 of the
+// EnvironmentManager facade, failing if the API server does not
+// support it.
+func (c *Client) facadeCallV2(method string, args, response interface{}) error {
+	if c.facade.BestAPIVersion() < 2 {
+		return errors.NotImplementedf("%s() (need V2+)", method)
+	}
+	return c.facade.FacadeCall(method, args, response)
+}
+
+// BeginMigration stops the workers of the environment with the given
+// UUID, and blocks changes to it, so that it may be exported to
+// another controller. Only the state server owner may move
+// environments between controllers.
+func (c *Client) BeginMigration(uuid string) error {
+	entity := params.Entity{names.NewEnvironTag(uuid).String()}
+	return errors.Trace(c.facadeCallV2("BeginMigration", entity, nil))
+}
+
+// ExportEnvironment returns the documents of the environment with the
+// given UUID, and the URLs of the charms whose archives must be copied
+// with them, so that it may be imported into another controller.
+func (c *Client) ExportEnvironment(uuid string) (params.EnvironmentExport, error) {
+	var result params.EnvironmentExport
+	entity := params.Entity{names.NewEnvironTag(uuid).String()}
+	if err := c.facadeCallV2("ExportEnvironment", entity, &result); err != nil {
+		return result, errors.Trace(err)
+	}
+	return result, nil
+}
+
+// ValidateEnvironmentImport checks that an environment exported from
+// another controller can be imported into this one, without changing
+// anything.
+func (c *Client) ValidateEnvironmentImport(export params.EnvironmentExport) error {
+	return errors.Trace(c.facadeCallV2("ValidateEnvironmentImport", export, nil))
+}
+
+// ImportEnvironment recreates an environment exported from another
+// controller. The environment is not used until it is activated.
+func (c *Client) ImportEnvironment(export params.EnvironmentExport) (params.Environment, error) {
+	var result params.Environment
+	if err := c.facadeCallV2("ImportEnvironment", export, &result); err != nil {
+		return result, errors.Trace(err)
+	}
+	logger.Infof("imported environment %s (%s)", result.Name, result.UUID)
+	return result, nil
+}
+
+// SetMigrationTarget records that the environment with the given UUID
+// has been imported into the controller with the given API addresses
+// and CA certificate, so that its agents move there.
+func (c *Client) SetMigrationTarget(uuid string, apiAddrs []string, caCert string) error {
+	args := params.SetMigrationTargetArgs{
+		EnvironTag: names.NewEnvironTag(uuid).String(),
+		Target: params.MigrationTarget{
+			APIAddrs: apiAddrs,
+			CACert:   caCert,
+		},
+	}
+	return errors.Trace(c.facadeCallV2("SetMigrationTarget", args, nil))
+}
+
+// MigrationStatus returns the tags of the agents of the environment
+// with the given UUID which have yet to move to its migration target.
+func (c *Client) MigrationStatus(uuid string) ([]string, error) {
+	var result params.MigrationStatus
+	entity := params.Entity{names.NewEnvironTag(uuid).String()}
+	if err := c.facadeCallV2("MigrationStatus", entity, &result); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return result.PendingAgents, nil
+}
+
+// RemoveMigratedEnvironment removes the environment with the given
+// UUID once it has been migrated to another controller.
+func (c *Client) RemoveMigratedEnvironment(uuid string) error {
+	entity := params.Entity{names.NewEnvironTag(uuid).String()}
+	return errors.Trace(c.facadeCallV2("RemoveMigratedEnvironment", entity, nil))
+}
+
+// ActivateEnvironment starts using the environment with the given
+// UUID, imported from another controller, once its agents have moved.
+func (c *Client) ActivateEnvironment(uuid string) error {
+	entity := params.Entity{names.NewEnvironTag(uuid).String()}
+	return errors.Trace(c.facadeCallV2("ActivateEnvironment", entity, nil))
+}
+
+// AbortMigration stops migrating the environment with the given UUID,
+// so that it is managed by its original controller again. It fails
+// once any of the environment's agents have moved.
+func (c *Client) AbortMigration(uuid string) error {
+	entity := params.Entity{names.NewEnvironTag(uuid).String()}
+	return errors.Trace(c.facadeCallV2("AbortMigration", entity, nil))
+}
+
+// RemoveImportedEnvironment removes the environment with the given
+// UUID, imported from another controller, when its migration is
+// aborted.
+func (c *Client) RemoveImportedEnvironment(uuid string) error {
+	entity := params.Entity{names.NewEnvironTag(uuid).String()}
+	return errors.Trace(c.facadeCallV2("RemoveImportedEnvironment", entity, nil))
+}
+
+// AllEnvironments returns all the environments hosted by the state
+// server. Only the state server owner may list them.
+func (c *Client) AllEnvironments() ([]params.Environment, error) {
+	var result params.EnvironmentList
+	if err := c.facadeCallV2("AllEnvironments", nil, &result); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return result.Environments, nil
+}
+
+// EnvironmentStatus returns the owner, life, machine and unit counts
+// and last activity of each environment with the given UUIDs.
+func (c *Client) EnvironmentStatus(uuids ...string) ([]params.EnvironmentStatus, error) {
+	var results params.EnvironmentStatusResults
+	args := params.Entities{Entities: make([]params.Entity, len(uuids))}
+	for i, uuid := range uuids {
+		args.Entities[i].Tag = names.NewEnvironTag(uuid).String()
+	}
+	if err := c.facadeCallV2("EnvironmentStatus", args, &results); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(results.Results) != len(uuids) {
+		return nil, errors.Errorf("expected %d results, got %d", len(uuids), len(results.Results))
+	}
+	statuses := make([]params.EnvironmentStatus, len(uuids))
+	for i, result := range results.Results {
+		if result.Error != nil {
+			return nil, errors.Annotatef(result.Error, "environment %q", uuids[i])
+		}
+		statuses[i] = *result.Status
+	}
+	return statuses, nil
+}
+
+// DestroyEnvironment destroys the hosted environment with the given
+// UUID, along with its services and machine instances.
+func (c *Client) DestroyEnvironment(uuid string) error {
+	entity := params.Entity{names.NewEnvironTag(uuid).String()}
+	return errors.Trace(c.facadeCallV2("DestroyEnvironment", entity, nil))
+}
+
+// BlockEnvironment switches on a block of the given type, such as
+// "BlockDestroy", in the environment with the given UUID.
+func (c *Client) BlockEnvironment(uuid, blockType, message string) error {
+	args := params.EnvironmentBlockArgs{
+		EnvironTag: names.NewEnvironTag(uuid).String(),
+		Type:       blockType,
+		Message:    message,
+	}
+	return errors.Trace(c.facadeCallV2("BlockEnvironment", args, nil))
+}
+
+// UnblockEnvironment switches off a block of the given type in the
+// environment with the given UUID.
+func (c *Client) UnblockEnvironment(uuid, blockType string) error {
+	args := params.EnvironmentBlockArgs{
+		EnvironTag: names.NewEnvironTag(uuid).String(),
+		Type:       blockType,
+	}
+	return errors.Trace(c.facadeCallV2("UnblockEnvironment", args, nil))
+}
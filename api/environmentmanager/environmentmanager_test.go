@@ -4,12 +4,16 @@
 package environmentmanager_test
 
 import (
+	"strings"
+
+	"github.com/juju/errors"
 	"github.com/juju/names"
 	jc "github.com/juju/testing/checkers"
 	"github.com/juju/utils"
 	gc "gopkg.in/check.v1"
 
 	"github.com/juju/juju/api"
+	basetesting "github.com/juju/juju/api/base/testing"
 	"github.com/juju/juju/api/environmentmanager"
 	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/feature"
@@ -115,3 +119,114 @@ func (s *environmentmanagerSuite) TestListEnvironments(c *gc.C) {
 	envNames := []string{envs[0].Name, envs[1].Name}
 	c.Assert(envNames, jc.SameContents, []string{"first", "second"})
 }
+
+func (s *environmentmanagerSuite) TestV2MethodsNotSupported(c *gc.C) {
+	apiCaller := basetesting.APICallerFunc(
+		func(objType string, version int, id, request string, arg, result interface{}) error {
+			c.Errorf("unexpected call to %s.%s", objType, request)
+			return nil
+		})
+	envManager := environmentmanager.NewClient(apiCaller)
+	uuid := utils.MustNewUUID().String()
+	err := envManager.BeginMigration(uuid)
+	c.Assert(err, jc.Satisfies, errors.IsNotImplemented)
+	c.Assert(err, gc.ErrorMatches, `BeginMigration\(\) \(need V2\+\) not implemented`)
+	_, err = envManager.AllEnvironments()
+	c.Assert(err, jc.Satisfies, errors.IsNotImplemented)
+	err = envManager.ImportCharmArchive(uuid, "cs:quantal/mysql-1", strings.NewReader("archive"))
+	c.Assert(err, jc.Satisfies, errors.IsNotImplemented)
+}
+
+func (s *environmentmanagerSuite) TestMigrateEnvironment(c *gc.C) {
+	s.SetFeatureFlags(feature.JES)
+	st := s.Factory.MakeEnvironment(c, &factory.EnvParams{Name: "hosted"})
+	uuid := st.EnvironUUID()
+	st.Close()
+
+	envManager := s.OpenAPI(c)
+	err := envManager.BeginMigration(uuid)
+	c.Assert(err, jc.ErrorIsNil)
+	export, err := envManager.ExportEnvironment(uuid)
+	c.Assert(err, jc.ErrorIsNil)
+	err = envManager.ValidateEnvironmentImport(export)
+	c.Assert(err, gc.ErrorMatches, `environment ".*" already exists`)
+
+	err = envManager.SetMigrationTarget(uuid, []string{"10.0.0.1:17070"}, "cert")
+	c.Assert(err, jc.ErrorIsNil)
+	pending, err := envManager.MigrationStatus(uuid)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(pending, gc.HasLen, 0)
+	err = envManager.RemoveMigratedEnvironment(uuid)
+	c.Assert(err, jc.ErrorIsNil)
+
+	env, err := envManager.ImportEnvironment(export)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env.Name, gc.Equals, "hosted")
+	c.Assert(env.UUID, gc.Equals, uuid)
+	err = envManager.ActivateEnvironment(uuid)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *environmentmanagerSuite) TestAbortMigration(c *gc.C) {
+	s.SetFeatureFlags(feature.JES)
+	st := s.Factory.MakeEnvironment(c, &factory.EnvParams{Name: "hosted"})
+	uuid := st.EnvironUUID()
+	st.Close()
+
+	envManager := s.OpenAPI(c)
+	err := envManager.BeginMigration(uuid)
+	c.Assert(err, jc.ErrorIsNil)
+	err = envManager.AbortMigration(uuid)
+	c.Assert(err, jc.ErrorIsNil)
+	err = envManager.RemoveImportedEnvironment(uuid)
+	c.Assert(err, gc.ErrorMatches, `environment "hosted" is not being imported`)
+}
+
+func (s *environmentmanagerSuite) TestImportCharmArchiveNotImported(c *gc.C) {
+	s.SetFeatureFlags(feature.JES)
+	st := s.Factory.MakeEnvironment(c, &factory.EnvParams{Name: "hosted"})
+	uuid := st.EnvironUUID()
+	st.Close()
+
+	envManager := s.OpenAPI(c)
+	err := envManager.ImportCharmArchive(uuid, "cs:quantal/mysql-1", strings.NewReader("archive"))
+	c.Assert(err, gc.ErrorMatches, `.*environment "hosted" is not being imported`)
+}
+
+func (s *environmentmanagerSuite) TestAdministerEnvironment(c *gc.C) {
+	s.SetFeatureFlags(feature.JES)
+	owner := names.NewUserTag("user@remote")
+	st := s.Factory.MakeEnvironment(c, &factory.EnvParams{Name: "hosted", Owner: owner})
+	uuid := st.EnvironUUID()
+	factory.NewFactory(st).MakeMachine(c, nil)
+	st.Close()
+
+	envManager := s.OpenAPI(c)
+	envs, err := envManager.AllEnvironments()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(envs, gc.HasLen, 2)
+	envNames := []string{envs[0].Name, envs[1].Name}
+	c.Assert(envNames, jc.SameContents, []string{"dummyenv", "hosted"})
+
+	statuses, err := envManager.EnvironmentStatus(uuid)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(statuses, jc.DeepEquals, []params.EnvironmentStatus{{
+		EnvironTag:   names.NewEnvironTag(uuid).String(),
+		Name:         "hosted",
+		OwnerTag:     owner.String(),
+		Life:         params.Alive,
+		MachineCount: 1,
+	}})
+
+	err = envManager.BlockEnvironment(uuid, "BlockDestroy", "not yet")
+	c.Assert(err, jc.ErrorIsNil)
+	err = envManager.DestroyEnvironment(uuid)
+	c.Assert(err, gc.ErrorMatches, "not yet")
+	err = envManager.UnblockEnvironment(uuid, "BlockDestroy")
+	c.Assert(err, jc.ErrorIsNil)
+	err = envManager.DestroyEnvironment(uuid)
+	c.Assert(err, jc.ErrorIsNil)
+
+	_, err = envManager.EnvironmentStatus(uuid)
+	c.Assert(err, gc.ErrorMatches, `environment ".*": environment not found`)
+}
@@ -15,6 +15,7 @@ var (
 	SlideAddressToFront   = slideAddressToFront
 	BestVersion           = bestVersion
 	FacadeVersions        = &facadeVersions
+	MethodVersions        = &methodVersions
 	NewHTTPClient         = &newHTTPClient
 )
 
@@ -3,6 +3,12 @@
 
 package api
 
+import (
+	"fmt"
+
+	"github.com/juju/errors"
+)
+
 // facadeVersions lists the best version of facades that we know about. This
 // will be used to pick out a default version for communication, given the list
 // of known versions that the API server tells us it is capable of supporting.
@@ -19,13 +25,13 @@ var facadeVersions = map[string]int{
 	"Block":                        1,
 	"Charms":                       1,
 	"CharmRevisionUpdater":         0,
-	"Client":                       0,
+	"Client":                       1,
 	"Deployer":                     0,
 	"DiskManager":                  1,
 	"Environment":                  0,
-	"EnvironmentManager":           1,
+	"EnvironmentManager":           2,
 	"FilesystemAttachmentsWatcher": 1,
-	"Firewaller":                   1,
+	"Firewaller":                   2,
 	"HighAvailability":             1,
 	"ImageManager":                 1,
 	"KeyManager":                   0,
@@ -35,19 +41,23 @@ var facadeVersions = map[string]int{
 	"Machiner":                     0,
 	"MetricsManager":               0,
 	"MetricStorage":                1,
+	"MigrationMinion":              1,
 	"Networker":                    0,
 	"NotifyWatcher":                0,
+	"Offers":                       1,
 	"Pinger":                       0,
 	"Provisioner":                  0,
 	"Reboot":                       1,
 	"RelationUnitsWatcher":         0,
 	"Rsyslog":                      0,
+	"Schema":                       1,
 	"Service":                      1,
+	"Spaces":                       1,
 	"Storage":                      1,
 	"StorageProvisioner":           1,
 	"StringsWatcher":               0,
 	"Upgrader":                     0,
-	"Uniter":                       2,
+	"Uniter":                       3,
 	"UserManager":                  0,
 	"VolumeAttachmentsWatcher":     1,
 }
@@ -63,3 +73,67 @@ func bestVersion(desiredVersion int, versions []int) int {
 	}
 	return best
 }
+
+// methodVersions records the facade methods that were not present in
+// the first version of their facade, keyed by "Facade.Method", with
+// the facade version that introduced them. It is used by
+// State.CheckMethodSupport and should be updated whenever a new
+// facade version adds methods that clients depend on.
+var methodVersions = map[string]int{
+	"EnvironmentManager.AbortMigration":            2,
+	"EnvironmentManager.ActivateEnvironment":       2,
+	"EnvironmentManager.AllEnvironments":           2,
+	"EnvironmentManager.BeginMigration":            2,
+	"EnvironmentManager.BlockEnvironment":          2,
+	"EnvironmentManager.DestroyEnvironment":        2,
+	"EnvironmentManager.EnvironmentStatus":         2,
+	"EnvironmentManager.ExportEnvironment":         2,
+	"EnvironmentManager.ImportEnvironment":         2,
+	"EnvironmentManager.MigrationStatus":           2,
+	"EnvironmentManager.RemoveImportedEnvironment": 2,
+	"EnvironmentManager.RemoveMigratedEnvironment": 2,
+	"EnvironmentManager.SetMigrationTarget":        2,
+	"EnvironmentManager.UnblockEnvironment":        2,
+	"EnvironmentManager.ValidateEnvironmentImport": 2,
+	"Firewaller.GetExposedCIDRs":                   2,
+	"Firewaller.GetRelatedCIDRs":                   2,
+	"Firewaller.GetTraceIds":                       2,
+	"Firewaller.WatchRelatedAddresses":             2,
+	"Uniter.AddUnitHistory":                        3,
+	"Uniter.AllMachinePorts":                       1,
+	"Uniter.AssignedMachine":                       1,
+	"Uniter.ServiceOwner":                          1,
+	"Uniter.TraceIds":                              3,
+	"Uniter.UnitStorageAttachments":                2,
+	"Uniter.WatchUnitStorageAttachments":           2,
+}
+
+// NotSupportedError is returned when the API server does not support
+// a facade, or a facade method, needed by the client.
+type NotSupportedError struct {
+	// Facade holds the name of the facade.
+	Facade string
+
+	// Method holds the name of the facade method,
+	// if a specific method is needed.
+	Method string
+
+	// Version holds the facade version needed.
+	Version int
+}
+
+// Error implements error.
+func (e *NotSupportedError) Error() string {
+	what := e.Facade
+	if e.Method != "" {
+		what += "." + e.Method
+	}
+	return fmt.Sprintf("%s is not supported by this server version (needs %s facade version %d)", what, e.Facade, e.Version)
+}
+
+// IsNotSupportedError reports whether the cause
+// of the given error is a *NotSupportedError.
+func IsNotSupportedError(err error) bool {
+	_, ok := errors.Cause(err).(*NotSupportedError)
+	return ok
+}
@@ -6,6 +6,8 @@ package api_test
 import (
 	"strings"
 
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
 	"github.com/juju/utils/set"
 	gc "gopkg.in/check.v1"
 
@@ -115,3 +117,47 @@ func (s *facadeVersionSuite) TestBestFacadeVersionServerUnknown(c *gc.C) {
 		}})
 	c.Check(st.BestFacadeVersion("TestingAPI"), gc.Equals, 0)
 }
+
+func (s *facadeVersionSuite) TestSupportsFacade(c *gc.C) {
+	s.PatchValue(api.FacadeVersions, map[string]int{"Client": 2, "TestingAPI": 1})
+	st := api.NewTestingState(api.TestingStateParams{
+		FacadeVersions: map[string][]int{
+			"Client":  {0, 1, 3},
+			"Unknown": {1},
+		}})
+	c.Check(st.SupportsFacade("Client", 0), jc.IsTrue)
+	c.Check(st.SupportsFacade("Client", 1), jc.IsTrue)
+	// The server has version 3, but the client does not know it.
+	c.Check(st.SupportsFacade("Client", 2), jc.IsFalse)
+	c.Check(st.SupportsFacade("TestingAPI", 0), jc.IsFalse)
+	c.Check(st.SupportsFacade("Unknown", 0), jc.IsFalse)
+}
+
+func (s *facadeVersionSuite) TestCheckFacadeSupport(c *gc.C) {
+	s.PatchValue(api.FacadeVersions, map[string]int{"Client": 2})
+	st := api.NewTestingState(api.TestingStateParams{
+		FacadeVersions: map[string][]int{
+			"Client": {0, 1},
+		}})
+	c.Check(st.CheckFacadeSupport("Client", 1), jc.ErrorIsNil)
+	err := st.CheckFacadeSupport("Client", 2)
+	c.Check(err, gc.ErrorMatches, `Client is not supported by this server version \(needs Client facade version 2\)`)
+	c.Check(api.IsNotSupportedError(err), jc.IsTrue)
+	c.Check(api.IsNotSupportedError(errors.Annotate(err, "foo")), jc.IsTrue)
+	c.Check(api.IsNotSupportedError(errors.New("foo")), jc.IsFalse)
+}
+
+func (s *facadeVersionSuite) TestCheckMethodSupport(c *gc.C) {
+	s.PatchValue(api.FacadeVersions, map[string]int{"Uniter": 2})
+	s.PatchValue(api.MethodVersions, map[string]int{"Uniter.AddUnitHistory": 2})
+	st := api.NewTestingState(api.TestingStateParams{
+		FacadeVersions: map[string][]int{
+			"Uniter": {0, 1},
+		}})
+	c.Check(st.CheckMethodSupport("Uniter", "Life"), jc.ErrorIsNil)
+	err := st.CheckMethodSupport("Uniter", "AddUnitHistory")
+	c.Check(err, gc.ErrorMatches, `Uniter.AddUnitHistory is not supported by this server version \(needs Uniter facade version 2\)`)
+	c.Check(api.IsNotSupportedError(err), jc.IsTrue)
+	err = st.CheckMethodSupport("Client", "FullStatus")
+	c.Check(err, gc.ErrorMatches, `Client.FullStatus is not supported by this server version \(needs Client facade version 0\)`)
+}
@@ -75,3 +75,102 @@ func (s *Service) IsExposed() (bool, error) {
 	}
 	return result.Result, nil
 }
+
+// ExposedCIDRs returns the CIDRs to which access to this service is
+// restricted when it is exposed. If none are returned, an exposed
+// service may be accessed from any address.
+func (s *Service) ExposedCIDRs() ([]string, error) {
+	var results params.StringsResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: s.tag.String()}},
+	}
+	err := s.st.facade.FacadeCall("GetExposedCIDRs", args, &results)
+	if params.IsCodeNotImplemented(err) {
+		// Older state servers cannot restrict exposed services.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return result.Result, nil
+}
+
+// WatchRelatedAddresses returns a NotifyWatcher that notifies when
+// the addresses of the units of services related to s change.
+func (s *Service) WatchRelatedAddresses() (watcher.NotifyWatcher, error) {
+	var results params.NotifyWatchResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: s.tag.String()}},
+	}
+	err := s.st.facade.FacadeCall("WatchRelatedAddresses", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	w := watcher.NewNotifyWatcher(s.st.facade.RawAPICaller(), result)
+	return w, nil
+}
+
+// RelatedCIDRs returns the CIDRs covering the addresses of the units
+// of all services related to this service. The service's opened ports
+// are made accessible to these addresses even when it is not exposed.
+func (s *Service) RelatedCIDRs() ([]string, error) {
+	var results params.StringsResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: s.tag.String()}},
+	}
+	err := s.st.facade.FacadeCall("GetRelatedCIDRs", args, &results)
+	if params.IsCodeNotImplemented(err) {
+		// Older state servers cannot report related addresses.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return result.Result, nil
+}
+
+// TraceId returns the trace id of the last traced API request that
+// changed the service, or "" if there is none.
+func (s *Service) TraceId() (string, error) {
+	var results params.StringResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: s.tag.String()}},
+	}
+	err := s.st.facade.FacadeCall("GetTraceIds", args, &results)
+	if params.IsCodeNotImplemented(err) {
+		// Older state servers do not record trace ids.
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(results.Results) != 1 {
+		return "", fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return "", result.Error
+	}
+	return result.Result, nil
+}
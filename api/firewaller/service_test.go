@@ -10,6 +10,7 @@ import (
 
 	"github.com/juju/juju/api/firewaller"
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/network"
 	statetesting "github.com/juju/juju/state/testing"
 )
 
@@ -94,3 +95,87 @@ func (s *serviceSuite) TestIsExposed(c *gc.C) {
 	c.Assert(err, jc.ErrorIsNil)
 	c.Assert(isExposed, jc.IsFalse)
 }
+
+func (s *serviceSuite) TestExposedCIDRs(c *gc.C) {
+	err := s.service.SetExposedCIDRs([]string{"10.0.0.0/8", "192.168.1.0/24"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	cidrs, err := s.apiService.ExposedCIDRs()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cidrs, jc.DeepEquals, []string{"10.0.0.0/8", "192.168.1.0/24"})
+
+	err = s.service.SetExposed()
+	c.Assert(err, jc.ErrorIsNil)
+
+	cidrs, err = s.apiService.ExposedCIDRs()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cidrs, gc.HasLen, 0)
+}
+
+func (s *serviceSuite) TestTraceId(c *gc.C) {
+	traceId, err := s.apiService.TraceId()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(traceId, gc.Equals, "")
+
+	service, err := s.State.WithTraceId("expose-1").Service(s.service.Name())
+	c.Assert(err, jc.ErrorIsNil)
+	err = service.SetExposed()
+	c.Assert(err, jc.ErrorIsNil)
+
+	traceId, err = s.apiService.TraceId()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(traceId, gc.Equals, "expose-1")
+}
+
+func (s *serviceSuite) TestWatchRelatedAddresses(c *gc.C) {
+	w, err := s.apiService.WatchRelatedAddresses()
+	c.Assert(err, jc.ErrorIsNil)
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewNotifyWatcherC(c, s.BackingState, w)
+
+	// Initial event.
+	wc.AssertOneChange()
+
+	// Relate another service to wordpress; it has no units yet.
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	eps, err := s.State.InferEndpoints("wordpress", "mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AddRelation(eps...)
+	c.Assert(err, jc.ErrorIsNil)
+	wc.AssertNoChange()
+
+	// Give the related service an addressed unit, check it's detected.
+	unit, err := mysql.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	err = unit.AssignToMachine(s.machines[0])
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.machines[0].SetAddresses(network.NewScopedAddress("10.0.0.1", network.ScopeCloudLocal))
+	c.Assert(err, jc.ErrorIsNil)
+	wc.AssertOneChange()
+
+	statetesting.AssertStop(c, w)
+	wc.AssertClosed()
+}
+
+func (s *serviceSuite) TestRelatedCIDRs(c *gc.C) {
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	eps, err := s.State.InferEndpoints("wordpress", "mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AddRelation(eps...)
+	c.Assert(err, jc.ErrorIsNil)
+
+	cidrs, err := s.apiService.RelatedCIDRs()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cidrs, gc.HasLen, 0)
+
+	unit, err := mysql.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	err = unit.AssignToMachine(s.machines[0])
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.machines[0].SetAddresses(network.NewScopedAddress("10.0.0.1", network.ScopeCloudLocal))
+	c.Assert(err, jc.ErrorIsNil)
+
+	cidrs, err = s.apiService.RelatedCIDRs()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cidrs, jc.DeepEquals, []string{"10.0.0.1/32"})
+}
@@ -35,16 +35,21 @@ func (s *State) NewHTTPClient() *http.Client {
 
 // NewHTTPRequest returns a new API-supporting HTTP request based on State.
 func (s *State) NewHTTPRequest(method, path string) (*http.Request, error) {
-	baseURL, err := url.Parse(s.serverRoot())
+	tag, err := s.EnvironTag()
 	if err != nil {
-		return nil, errors.Annotatef(err, "while parsing base URL (%s)", s.serverRoot())
+		return nil, errors.Annotate(err, "while extracting environment UUID")
 	}
+	return s.NewEnvironHTTPRequest(method, tag.Id(), path)
+}
 
-	tag, err := s.EnvironTag()
+// NewEnvironHTTPRequest returns a new API-supporting HTTP request for
+// the environment with the given UUID, which need not be the one to
+// which the State is connected.
+func (s *State) NewEnvironHTTPRequest(method, uuid, path string) (*http.Request, error) {
+	baseURL, err := url.Parse(s.serverRoot())
 	if err != nil {
-		return nil, errors.Annotate(err, "while extracting environment UUID")
+		return nil, errors.Annotatef(err, "while parsing base URL (%s)", s.serverRoot())
 	}
-	uuid := tag.Id()
 
 	req, err := apiserverhttp.NewRequest(method, baseURL, path, uuid, s.tag, s.password)
 	return req, errors.Trace(err)
@@ -0,0 +1,65 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package migrationminion provides the client side of the
+// MigrationMinion API facade.
+package migrationminion
+
+import (
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/api/base"
+	"github.com/juju/juju/api/watcher"
+	"github.com/juju/juju/apiserver/params"
+)
+
+const migrationMinionFacade = "MigrationMinion"
+
+// Client provides access to the MigrationMinion API facade.
+type Client struct {
+	facade base.FacadeCaller
+}
+
+// NewClient returns a new Client using the supplied caller.
+func NewClient(caller base.APICaller) *Client {
+	return &Client{base.NewFacadeCaller(caller, migrationMinionFacade)}
+}
+
+// Watch returns a watcher which notifies when the agent's environment
+// changes, as when it is given a migration target.
+func (c *Client) Watch() (watcher.NotifyWatcher, error) {
+	var result params.NotifyWatchResult
+	if err := c.facade.FacadeCall("Watch", nil, &result); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return watcher.NewNotifyWatcher(c.facade.RawAPICaller(), result), nil
+}
+
+// Target returns the controller to which the agent's environment is
+// being moved, or nil if it is not being moved.
+func (c *Client) Target() (*params.MigrationTarget, error) {
+	var result params.MigrationTargetResult
+	if err := c.facade.FacadeCall("Target", nil, &result); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return result.Target, nil
+}
+
+// Ack records that the agent has been told of its environment's
+// migration target.
+func (c *Client) Ack() error {
+	var result params.ErrorResult
+	if err := c.facade.FacadeCall("Ack", nil, &result); err != nil {
+		return errors.Trace(err)
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
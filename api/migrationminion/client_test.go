@@ -0,0 +1,67 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package migrationminion_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/api/base/testing"
+	"github.com/juju/juju/api/migrationminion"
+	"github.com/juju/juju/apiserver/params"
+	coretesting "github.com/juju/juju/testing"
+)
+
+type clientSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&clientSuite{})
+
+func (s *clientSuite) TestTarget(c *gc.C) {
+	expect := &params.MigrationTarget{
+		APIAddrs: []string{"10.0.0.1:17070"},
+		CACert:   "cert",
+	}
+	apiCaller := testing.APICallerFunc(func(objType string, version int, id, request string, arg, result interface{}) error {
+		c.Check(objType, gc.Equals, "MigrationMinion")
+		c.Check(request, gc.Equals, "Target")
+		c.Check(arg, gc.IsNil)
+		c.Assert(result, gc.FitsTypeOf, &params.MigrationTargetResult{})
+		*(result.(*params.MigrationTargetResult)) = params.MigrationTargetResult{Target: expect}
+		return nil
+	})
+
+	client := migrationminion.NewClient(apiCaller)
+	target, err := client.Target()
+	c.Check(err, jc.ErrorIsNil)
+	c.Check(target, jc.DeepEquals, expect)
+}
+
+func (s *clientSuite) TestTargetNotMigrating(c *gc.C) {
+	apiCaller := testing.APICallerFunc(func(objType string, version int, id, request string, arg, result interface{}) error {
+		return nil
+	})
+
+	client := migrationminion.NewClient(apiCaller)
+	target, err := client.Target()
+	c.Check(err, jc.ErrorIsNil)
+	c.Check(target, gc.IsNil)
+}
+
+func (s *clientSuite) TestAck(c *gc.C) {
+	apiCaller := testing.APICallerFunc(func(objType string, version int, id, request string, arg, result interface{}) error {
+		c.Check(objType, gc.Equals, "MigrationMinion")
+		c.Check(request, gc.Equals, "Ack")
+		c.Assert(result, gc.FitsTypeOf, &params.ErrorResult{})
+		*(result.(*params.ErrorResult)) = params.ErrorResult{
+			Error: &params.Error{Message: "boom"},
+		}
+		return nil
+	})
+
+	client := migrationminion.NewClient(apiCaller)
+	err := client.Ack()
+	c.Check(err, gc.ErrorMatches, "boom")
+}
@@ -0,0 +1,69 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package offers
+
+import (
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/api/base"
+	"github.com/juju/juju/apiserver/params"
+)
+
+// Client provides access to the Offers facade, which publishes
+// services' endpoints to other environments and consumes the offers
+// of other environments.
+type Client struct {
+	base.ClientFacade
+	facade base.FacadeCaller
+}
+
+// NewClient returns a new offers client.
+func NewClient(st base.APICallCloser) *Client {
+	frontend, backend := base.NewClientFacade(st, "Offers")
+	return &Client{ClientFacade: frontend, facade: backend}
+}
+
+// Offer publishes the named endpoints of the service, or all of its
+// endpoints if none are named, under the given offer name.
+func (c *Client) Offer(offerName, service string, endpoints []string, description string) error {
+	args := params.AddServiceOffersParams{
+		Offers: []params.AddServiceOfferParams{{
+			OfferName:   offerName,
+			ServiceName: service,
+			Endpoints:   endpoints,
+			Description: description,
+		}},
+	}
+	var results params.ErrorResults
+	if err := c.facade.FacadeCall("Offer", args, &results); err != nil {
+		return errors.Trace(err)
+	}
+	return results.OneError()
+}
+
+// ListOffers returns all the offers in the environment.
+func (c *Client) ListOffers() ([]params.ServiceOffer, error) {
+	var result params.ListServiceOffersResults
+	if err := c.facade.FacadeCall("ListOffers", nil, &result); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return result.Results, nil
+}
+
+// Consume adds a remote service with the given name for the offer
+// identified by offerURL, of the form [<owner>/]<environment>.<offer>.
+// The owner defaults to the authenticated user.
+func (c *Client) Consume(offerURL, service string) error {
+	args := params.ConsumeServiceOffersParams{
+		Offers: []params.ConsumeServiceOfferParams{{
+			OfferURL:    offerURL,
+			ServiceName: service,
+		}},
+	}
+	var results params.ErrorResults
+	if err := c.facade.FacadeCall("Consume", args, &results); err != nil {
+		return errors.Trace(err)
+	}
+	return results.OneError()
+}
@@ -0,0 +1,104 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package offers_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/api/base/testing"
+	"github.com/juju/juju/api/offers"
+	"github.com/juju/juju/apiserver/params"
+	coretesting "github.com/juju/juju/testing"
+)
+
+type offersSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&offersSuite{})
+
+func (s *offersSuite) TestOffer(c *gc.C) {
+	var callCount int
+	apiCaller := testing.APICallerFunc(func(objType string, version int, id, request string, arg, result interface{}) error {
+		c.Check(objType, gc.Equals, "Offers")
+		c.Check(id, gc.Equals, "")
+		c.Check(request, gc.Equals, "Offer")
+		c.Check(arg, jc.DeepEquals, params.AddServiceOffersParams{
+			Offers: []params.AddServiceOfferParams{{
+				OfferName:   "db",
+				ServiceName: "mysql",
+				Endpoints:   []string{"server"},
+				Description: "shared",
+			}},
+		})
+		c.Assert(result, gc.FitsTypeOf, &params.ErrorResults{})
+		*(result.(*params.ErrorResults)) = params.ErrorResults{
+			Results: []params.ErrorResult{{
+				Error: &params.Error{Message: "boom"},
+			}},
+		}
+		callCount++
+		return nil
+	})
+
+	client := offers.NewClient(apiCaller)
+	err := client.Offer("db", "mysql", []string{"server"}, "shared")
+	c.Check(err, gc.ErrorMatches, "boom")
+	c.Check(callCount, gc.Equals, 1)
+}
+
+func (s *offersSuite) TestListOffers(c *gc.C) {
+	expect := []params.ServiceOffer{{
+		EnvironName: "shared",
+		OfferName:   "db",
+		ServiceName: "mysql",
+		Endpoints:   []string{"server"},
+	}}
+	apiCaller := testing.APICallerFunc(func(objType string, version int, id, request string, arg, result interface{}) error {
+		c.Check(objType, gc.Equals, "Offers")
+		c.Check(request, gc.Equals, "ListOffers")
+		c.Check(arg, gc.IsNil)
+		c.Assert(result, gc.FitsTypeOf, &params.ListServiceOffersResults{})
+		*(result.(*params.ListServiceOffersResults)) = params.ListServiceOffersResults{Results: expect}
+		return nil
+	})
+
+	client := offers.NewClient(apiCaller)
+	result, err := client.ListOffers()
+	c.Check(err, jc.ErrorIsNil)
+	c.Check(result, jc.DeepEquals, expect)
+}
+
+func (s *offersSuite) TestConsume(c *gc.C) {
+	apiCaller := testing.APICallerFunc(func(objType string, version int, id, request string, arg, result interface{}) error {
+		c.Check(objType, gc.Equals, "Offers")
+		c.Check(request, gc.Equals, "Consume")
+		c.Check(arg, jc.DeepEquals, params.ConsumeServiceOffersParams{
+			Offers: []params.ConsumeServiceOfferParams{{
+				OfferURL:    "shared.db",
+				ServiceName: "shareddb",
+			}},
+		})
+		*(result.(*params.ErrorResults)) = params.ErrorResults{
+			Results: []params.ErrorResult{{}},
+		}
+		return nil
+	})
+
+	client := offers.NewClient(apiCaller)
+	err := client.Consume("shared.db", "shareddb")
+	c.Check(err, jc.ErrorIsNil)
+}
+
+func (s *offersSuite) TestListOffersError(c *gc.C) {
+	apiCaller := testing.APICallerFunc(func(objType string, version int, id, request string, arg, result interface{}) error {
+		return errors.New("an error")
+	})
+
+	client := offers.NewClient(apiCaller)
+	_, err := client.ListOffers()
+	c.Check(err, gc.ErrorMatches, "an error")
+}
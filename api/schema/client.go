@@ -0,0 +1,33 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package schema
+
+import (
+	"github.com/juju/juju/api/base"
+	"github.com/juju/juju/apiserver/params"
+)
+
+// Client provides access to the Schema facade, which describes
+// the methods of the API facades as JSON schemas.
+type Client struct {
+	base.ClientFacade
+	facade base.FacadeCaller
+}
+
+// NewClient returns a new schema client.
+func NewClient(st base.APICallCloser) *Client {
+	frontend, backend := base.NewClientFacade(st, "Schema")
+	return &Client{ClientFacade: frontend, facade: backend}
+}
+
+// FacadeSchemas returns the schemas of all versions of the named
+// facades, or of all facades if no names are given.
+func (c *Client) FacadeSchemas(names ...string) ([]params.FacadeSchema, error) {
+	args := params.FacadeSchemaArgs{Names: names}
+	var result params.FacadeSchemaResults
+	if err := c.facade.FacadeCall("FacadeSchemas", args, &result); err != nil {
+		return nil, err
+	}
+	return result.Schemas, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package schema_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/api/base/testing"
+	"github.com/juju/juju/api/schema"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/rpc/jsonschema"
+	coretesting "github.com/juju/juju/testing"
+)
+
+type schemaSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&schemaSuite{})
+
+func (s *schemaSuite) TestFacadeSchemas(c *gc.C) {
+	expect := []params.FacadeSchema{{
+		Name:    "Foo",
+		Version: 1,
+		Schema:  &jsonschema.Schema{Type: "object"},
+	}}
+	var callCount int
+	apiCaller := testing.APICallerFunc(func(objType string, version int, id, request string, arg, result interface{}) error {
+		c.Check(objType, gc.Equals, "Schema")
+		c.Check(version, gc.Equals, 0)
+		c.Check(id, gc.Equals, "")
+		c.Check(request, gc.Equals, "FacadeSchemas")
+		c.Check(arg, gc.DeepEquals, params.FacadeSchemaArgs{
+			Names: []string{"Foo"},
+		})
+		c.Assert(result, gc.FitsTypeOf, &params.FacadeSchemaResults{})
+		*(result.(*params.FacadeSchemaResults)) = params.FacadeSchemaResults{
+			Schemas: expect,
+		}
+		callCount++
+		return nil
+	})
+
+	client := schema.NewClient(apiCaller)
+	schemas, err := client.FacadeSchemas("Foo")
+	c.Check(err, jc.ErrorIsNil)
+	c.Check(callCount, gc.Equals, 1)
+	c.Check(schemas, jc.DeepEquals, expect)
+}
+
+func (s *schemaSuite) TestFacadeSchemasError(c *gc.C) {
+	apiCaller := testing.APICallerFunc(func(objType string, version int, id, request string, arg, result interface{}) error {
+		return errors.New("an error")
+	})
+
+	client := schema.NewClient(apiCaller)
+	_, err := client.FacadeSchemas()
+	c.Check(err, gc.ErrorMatches, "an error")
+}
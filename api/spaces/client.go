@@ -0,0 +1,67 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package spaces
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/names"
+
+	"github.com/juju/juju/api/base"
+	"github.com/juju/juju/apiserver/params"
+)
+
+// Client provides access to the Spaces facade, which manages the
+// spaces of an environment and the bindings of services' endpoints
+// to them.
+type Client struct {
+	base.ClientFacade
+	facade base.FacadeCaller
+}
+
+// NewClient returns a new spaces client.
+func NewClient(st base.APICallCloser) *Client {
+	frontend, backend := base.NewClientFacade(st, "Spaces")
+	return &Client{ClientFacade: frontend, facade: backend}
+}
+
+// CreateSpace creates a space with the given name, containing the
+// subnets with the given CIDRs.
+func (c *Client) CreateSpace(name string, subnetCIDRs []string) error {
+	args := params.CreateSpacesParams{
+		Spaces: []params.CreateSpaceParams{{
+			Name:        name,
+			SubnetCIDRs: subnetCIDRs,
+		}},
+	}
+	var results params.ErrorResults
+	if err := c.facade.FacadeCall("CreateSpaces", args, &results); err != nil {
+		return errors.Trace(err)
+	}
+	return results.OneError()
+}
+
+// ListSpaces returns all the spaces in the environment.
+func (c *Client) ListSpaces() ([]params.Space, error) {
+	var result params.ListSpacesResults
+	if err := c.facade.FacadeCall("ListSpaces", nil, &result); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return result.Results, nil
+}
+
+// SetEndpointBindings binds the named endpoints of the service to
+// the named spaces, replacing any existing bindings.
+func (c *Client) SetEndpointBindings(service string, bindings map[string]string) error {
+	args := params.SetEndpointBindingsParams{
+		Services: []params.ServiceEndpointBindings{{
+			ServiceTag: names.NewServiceTag(service).String(),
+			Bindings:   bindings,
+		}},
+	}
+	var results params.ErrorResults
+	if err := c.facade.FacadeCall("SetEndpointBindings", args, &results); err != nil {
+		return errors.Trace(err)
+	}
+	return results.OneError()
+}
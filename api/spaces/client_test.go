@@ -0,0 +1,97 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package spaces_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/api/base/testing"
+	"github.com/juju/juju/api/spaces"
+	"github.com/juju/juju/apiserver/params"
+	coretesting "github.com/juju/juju/testing"
+)
+
+type spacesSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&spacesSuite{})
+
+func (s *spacesSuite) TestCreateSpace(c *gc.C) {
+	var callCount int
+	apiCaller := testing.APICallerFunc(func(objType string, version int, id, request string, arg, result interface{}) error {
+		c.Check(objType, gc.Equals, "Spaces")
+		c.Check(id, gc.Equals, "")
+		c.Check(request, gc.Equals, "CreateSpaces")
+		c.Check(arg, jc.DeepEquals, params.CreateSpacesParams{
+			Spaces: []params.CreateSpaceParams{{
+				Name:        "db",
+				SubnetCIDRs: []string{"10.0.1.0/24"},
+			}},
+		})
+		c.Assert(result, gc.FitsTypeOf, &params.ErrorResults{})
+		*(result.(*params.ErrorResults)) = params.ErrorResults{
+			Results: []params.ErrorResult{{
+				Error: &params.Error{Message: "boom"},
+			}},
+		}
+		callCount++
+		return nil
+	})
+
+	client := spaces.NewClient(apiCaller)
+	err := client.CreateSpace("db", []string{"10.0.1.0/24"})
+	c.Check(err, gc.ErrorMatches, "boom")
+	c.Check(callCount, gc.Equals, 1)
+}
+
+func (s *spacesSuite) TestListSpaces(c *gc.C) {
+	expect := []params.Space{{Name: "db", SubnetCIDRs: []string{"10.0.1.0/24"}}}
+	apiCaller := testing.APICallerFunc(func(objType string, version int, id, request string, arg, result interface{}) error {
+		c.Check(objType, gc.Equals, "Spaces")
+		c.Check(request, gc.Equals, "ListSpaces")
+		c.Check(arg, gc.IsNil)
+		c.Assert(result, gc.FitsTypeOf, &params.ListSpacesResults{})
+		*(result.(*params.ListSpacesResults)) = params.ListSpacesResults{Results: expect}
+		return nil
+	})
+
+	client := spaces.NewClient(apiCaller)
+	result, err := client.ListSpaces()
+	c.Check(err, jc.ErrorIsNil)
+	c.Check(result, jc.DeepEquals, expect)
+}
+
+func (s *spacesSuite) TestSetEndpointBindings(c *gc.C) {
+	apiCaller := testing.APICallerFunc(func(objType string, version int, id, request string, arg, result interface{}) error {
+		c.Check(objType, gc.Equals, "Spaces")
+		c.Check(request, gc.Equals, "SetEndpointBindings")
+		c.Check(arg, jc.DeepEquals, params.SetEndpointBindingsParams{
+			Services: []params.ServiceEndpointBindings{{
+				ServiceTag: "service-mysql",
+				Bindings:   map[string]string{"server": "db"},
+			}},
+		})
+		*(result.(*params.ErrorResults)) = params.ErrorResults{
+			Results: []params.ErrorResult{{}},
+		}
+		return nil
+	})
+
+	client := spaces.NewClient(apiCaller)
+	err := client.SetEndpointBindings("mysql", map[string]string{"server": "db"})
+	c.Check(err, jc.ErrorIsNil)
+}
+
+func (s *spacesSuite) TestListSpacesError(c *gc.C) {
+	apiCaller := testing.APICallerFunc(func(objType string, version int, id, request string, arg, result interface{}) error {
+		return errors.New("an error")
+	})
+
+	client := spaces.NewClient(apiCaller)
+	_, err := client.ListSpaces()
+	c.Check(err, gc.ErrorMatches, "an error")
+}
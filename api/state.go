@@ -21,6 +21,7 @@ import (
 	apileadership "github.com/juju/juju/api/leadership"
 	apilogger "github.com/juju/juju/api/logger"
 	"github.com/juju/juju/api/machiner"
+	"github.com/juju/juju/api/migrationminion"
 	"github.com/juju/juju/api/networker"
 	"github.com/juju/juju/api/provisioner"
 	"github.com/juju/juju/api/reboot"
@@ -239,6 +240,13 @@ func (st *State) Machiner() *machiner.State {
 	return machiner.NewState(st)
 }
 
+// MigrationMinion returns access to the MigrationMinion API, used by
+// agents to learn that their environment has moved to another
+// controller.
+func (st *State) MigrationMinion() *migrationminion.Client {
+	return migrationminion.NewClient(st)
+}
+
 // Networker returns a version of the state that provides functionality
 // required by the networker worker.
 func (st *State) Networker() networker.State {
@@ -77,9 +77,9 @@ func (s *stateSuite) TestAllMachinePortsV1(c *gc.C) {
 	portsMap, err := s.uniter.AllMachinePorts(s.wordpressMachine.Tag().(names.MachineTag))
 	c.Assert(err, jc.ErrorIsNil)
 	c.Assert(portsMap, jc.DeepEquals, map[network.PortRange]params.RelationUnit{
-		network.PortRange{100, 200, "tcp"}: {Unit: s.wordpressUnit.Tag().String()},
-		network.PortRange{10, 20, "udp"}:   {Unit: s.wordpressUnit.Tag().String()},
-		network.PortRange{201, 250, "tcp"}: {Unit: wordpressUnit1.Tag().String()},
-		network.PortRange{1, 8, "udp"}:     {Unit: wordpressUnit1.Tag().String()},
+		network.PortRange{FromPort: 100, ToPort: 200, Protocol: "tcp"}: {Unit: s.wordpressUnit.Tag().String()},
+		network.PortRange{FromPort: 10, ToPort: 20, Protocol: "udp"}:   {Unit: s.wordpressUnit.Tag().String()},
+		network.PortRange{FromPort: 201, ToPort: 250, Protocol: "tcp"}: {Unit: wordpressUnit1.Tag().String()},
+		network.PortRange{FromPort: 1, ToPort: 8, Protocol: "udp"}:     {Unit: wordpressUnit1.Tag().String()},
 	})
 }
@@ -130,6 +130,50 @@ func (u *Unit) AddMetrics(metrics []params.Metric) error {
 	return result.OneError()
 }
 
+// AddHistory records hook and action executions in the unit's history.
+func (u *Unit) AddHistory(entries ...params.UnitHistoryEntry) error {
+	if u.st.facade.BestAPIVersion() < 3 {
+		return errors.NotImplementedf("AddHistory")
+	}
+	var result params.ErrorResults
+	args := params.UnitHistoryParams{
+		Units: []params.UnitHistoryParam{{
+			Tag:     u.tag.String(),
+			Entries: entries,
+		}},
+	}
+	err := u.st.facade.FacadeCall("AddUnitHistory", args, &result)
+	if err != nil {
+		return errors.Annotate(err, "unable to add unit history")
+	}
+	return result.OneError()
+}
+
+// TraceId returns the trace id of the last traced API request that
+// changed the unit, or "" if there is none or the API server does not
+// record trace ids.
+func (u *Unit) TraceId() (string, error) {
+	if u.st.facade.BestAPIVersion() < 3 {
+		return "", nil
+	}
+	var results params.StringResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: u.tag.String()}},
+	}
+	err := u.st.facade.FacadeCall("TraceIds", args, &results)
+	if err != nil {
+		return "", err
+	}
+	if len(results.Results) != 1 {
+		return "", fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return "", result.Error
+	}
+	return result.Result, nil
+}
+
 // EnsureDead sets the unit lifecycle to Dead if it is Alive or
 // Dying. It does nothing otherwise.
 func (u *Unit) EnsureDead() error {
@@ -711,6 +711,51 @@ func (s *unitSuite) TestAddMetricsResultError(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, "error adding metrics")
 }
 
+func (s *unitSuite) TestAddHistory(c *gc.C) {
+	started := time.Now()
+	entry := params.UnitHistoryEntry{
+		Kind:     "hook",
+		Name:     "install",
+		Started:  started,
+		Finished: started.Add(time.Second),
+	}
+	err := s.apiUnit.AddHistory(entry)
+	c.Assert(err, jc.ErrorIsNil)
+
+	history, err := s.wordpressUnit.History()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 1)
+	c.Assert(history[0].Kind, gc.Equals, state.UnitHistoryHook)
+	c.Assert(history[0].Name, gc.Equals, "install")
+}
+
+func (s *unitSuite) TestAddHistoryError(c *gc.C) {
+	uniter.PatchUnitResponse(s, s.apiUnit, "AddUnitHistory",
+		func(results interface{}) error {
+			result := results.(*params.ErrorResults)
+			result.Results = make([]params.ErrorResult, 1)
+			return fmt.Errorf("test error")
+		},
+	)
+	err := s.apiUnit.AddHistory(params.UnitHistoryEntry{Kind: "hook", Name: "install"})
+	c.Assert(err, gc.ErrorMatches, "unable to add unit history: test error")
+}
+
+func (s *unitSuite) TestTraceId(c *gc.C) {
+	traceId, err := s.apiUnit.TraceId()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(traceId, gc.Equals, "")
+
+	unit, err := s.State.WithTraceId("upgrade-1").Unit(s.wordpressUnit.Name())
+	c.Assert(err, jc.ErrorIsNil)
+	err = unit.SetCharmURL(s.wordpressCharm.URL())
+	c.Assert(err, jc.ErrorIsNil)
+
+	traceId, err = s.apiUnit.TraceId()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(traceId, gc.Equals, "upgrade-1")
+}
+
 func (s *unitSuite) TestMeterStatus(c *gc.C) {
 	uniter.PatchUnitResponse(s, s.apiUnit, "GetMeterStatus",
 		func(results interface{}) error {
@@ -179,6 +179,16 @@ func (a *admin) doLogin(req params.LoginRequest, loginVersion int) (params.Login
 		loginResult.Facades = facades
 	}
 
+	// Agents may not change their environment while it is being
+	// migrated, as their changes would not be exported.
+	if !isUser && !serverOnlyLogin {
+		authedApi = newMigratingRoot(authedApi, environMigrating(a.root.state))
+	}
+
+	if limiter := a.srv.entityLimiters.get(entity.Tag().String()); limiter != nil {
+		authedApi = newRateLimitedRoot(authedApi, limiter)
+	}
+
 	a.root.rpcConn.ServeFinder(authedApi, serverError)
 
 	return loginResult, nil
@@ -24,14 +24,22 @@ import (
 
 	"github.com/juju/juju/apiserver/common"
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/feature"
 	"github.com/juju/juju/rpc"
+	"github.com/juju/juju/rpc/bsoncodec"
 	"github.com/juju/juju/rpc/jsoncodec"
 	"github.com/juju/juju/state"
+	"github.com/juju/juju/trace"
 )
 
 var logger = loggo.GetLogger("juju.apiserver")
 
+// traceLogger logs API requests and replies that carry a trace id.
+// Environments log its messages at INFO unless their logging-config
+// says otherwise, so that traced requests are always recorded.
+var traceLogger = loggo.GetLogger(config.TraceLoggingModule)
+
 // loginRateLimit defines how many concurrent Login requests we will
 // accept
 const loginRateLimit = 10
@@ -46,6 +54,8 @@ type Server struct {
 	dataDir           string
 	logDir            string
 	limiter           utils.Limiter
+	entityLimiters    *entityLimiters
+	restReadOnly      bool
 	validator         LoginValidator
 	adminApiFactories map[int]adminApiFactory
 
@@ -67,6 +77,15 @@ type ServerConfig struct {
 	LogDir      string
 	Validator   LoginValidator
 	CertChanged chan params.StateServingInfo
+
+	// RateLimit holds the limits applied to the API
+	// requests made by each authenticated entity.
+	RateLimit RateLimitConfig
+
+	// RESTReadOnly restricts the calls made through the
+	// REST gateway to those that do not change the state
+	// of the environment.
+	RESTReadOnly bool
 }
 
 // changeCertListener wraps a TLS net.Listener.
@@ -183,13 +202,15 @@ func NewServer(s *state.State, lis net.Listener, cfg ServerConfig) (*Server, err
 		return nil, err
 	}
 	srv := &Server{
-		state:     s,
-		addr:      net.JoinHostPort("localhost", listeningPort),
-		tag:       cfg.Tag,
-		dataDir:   cfg.DataDir,
-		logDir:    cfg.LogDir,
-		limiter:   utils.NewLimiter(loginRateLimit),
-		validator: cfg.Validator,
+		state:          s,
+		addr:           net.JoinHostPort("localhost", listeningPort),
+		tag:            cfg.Tag,
+		dataDir:        cfg.DataDir,
+		logDir:         cfg.LogDir,
+		limiter:        utils.NewLimiter(loginRateLimit),
+		entityLimiters: newEntityLimiters(cfg.RateLimit),
+		restReadOnly:   cfg.RESTReadOnly,
+		validator:      cfg.Validator,
 		adminApiFactories: map[int]adminApiFactory{
 			0: newAdminApiV0,
 			1: newAdminApiV1,
@@ -267,9 +288,18 @@ func (n *requestNotifier) ServerRequest(hdr *rpc.Header, body interface{}) {
 	// TODO(rog) 2013-10-11 remove secrets from some requests.
 	// Until secrets are removed, we only log the body of the requests at trace level
 	// which is below the default level of debug.
+	if hdr.TraceId != "" {
+		tlogger := trace.NewLogger(traceLogger, hdr.TraceId)
+		if tlogger.IsTraceEnabled() {
+			tlogger.Tracef("<- [%X] %s %s", n.id, n.tag(), jsoncodec.DumpRequest(hdr, body))
+		} else {
+			tlogger.Infof("<- [%X] %s %s", n.id, n.tag(), jsoncodec.DumpRequest(hdr, "'params redacted'"))
+		}
+		return
+	}
 	if logger.IsTraceEnabled() {
 		logger.Tracef("<- [%X] %s %s", n.id, n.tag(), jsoncodec.DumpRequest(hdr, body))
-	} else {
+	} else if logger.IsDebugEnabled() {
 		logger.Debugf("<- [%X] %s %s", n.id, n.tag(), jsoncodec.DumpRequest(hdr, "'params redacted'"))
 	}
 }
@@ -281,9 +311,18 @@ func (n *requestNotifier) ServerReply(req rpc.Request, hdr *rpc.Header, body int
 	// TODO(rog) 2013-10-11 remove secrets from some responses.
 	// Until secrets are removed, we only log the body of the requests at trace level
 	// which is below the default level of debug.
+	if hdr.TraceId != "" {
+		tlogger := trace.NewLogger(traceLogger, hdr.TraceId)
+		if tlogger.IsTraceEnabled() {
+			tlogger.Tracef("-> [%X] %s %s", n.id, n.tag(), jsoncodec.DumpRequest(hdr, body))
+		} else {
+			tlogger.Infof("-> [%X] %s %s %s %s[%q].%s", n.id, n.tag(), timeSpent, jsoncodec.DumpRequest(hdr, "'body redacted'"), req.Type, req.Id, req.Action)
+		}
+		return
+	}
 	if logger.IsTraceEnabled() {
 		logger.Tracef("-> [%X] %s %s", n.id, n.tag(), jsoncodec.DumpRequest(hdr, body))
-	} else {
+	} else if logger.IsDebugEnabled() {
 		logger.Debugf("-> [%X] %s %s %s %s[%q].%s", n.id, n.tag(), timeSpent, jsoncodec.DumpRequest(hdr, "'body redacted'"), req.Type, req.Id, req.Action)
 	}
 }
@@ -369,6 +408,9 @@ func (srv *Server) run(lis net.Listener) {
 			stateServerEnvOnly: true,
 		}},
 	)
+	handleAll(mux, "/environment/:envuuid/api/:facade/:version/:method",
+		&restHandler{srv: srv, readOnly: srv.restReadOnly},
+	)
 	handleAll(mux, "/environment/:envuuid/api", http.HandlerFunc(srv.apiHandler))
 	handleAll(mux, "/environment/:envuuid/images/:kind/:series/:arch/:filename",
 		&imagesDownloadHandler{httpHandler{ssState: srv.state}},
@@ -420,27 +462,61 @@ func (srv *Server) apiHandler(w http.ResponseWriter, req *http.Request) {
 				logger.Errorf("error serving RPCs: %v", err)
 			}
 		},
+		Handshake: selectProtocol,
 	}
 	wsServer.ServeHTTP(w, req)
 }
 
-// Addr returns the address that the server is listening on.
-func (srv *Server) Addr() string {
-	return srv.addr
+// selectProtocol is a websocket handshake function that chooses the
+// RPC codec for a connection from the sub-protocols offered by the
+// client, preferring the binary codec. Clients that offer no known
+// sub-protocol are served JSON.
+func selectProtocol(config *websocket.Config, req *http.Request) error {
+	for _, protocol := range []string{bsoncodec.Protocol, jsoncodec.Protocol} {
+		for _, offered := range config.Protocol {
+			if offered == protocol {
+				config.Protocol = []string{protocol}
+				return nil
+			}
+		}
+	}
+	config.Protocol = nil
+	return nil
 }
 
-func (srv *Server) serveConn(wsConn *websocket.Conn, reqNotifier *requestNotifier, envUUID string) error {
+// newCodec returns the rpc codec for the sub-protocol
+// negotiated on the given websocket connection.
+func newCodec(wsConn *websocket.Conn) rpc.Codec {
+	if bsoncodec.Negotiated(wsConn) {
+		codec := bsoncodec.NewWebsocket(wsConn)
+		if loggo.GetLogger("juju.rpc.bsoncodec").EffectiveLogLevel() <= loggo.TRACE {
+			codec.SetLogging(true)
+		}
+		return codec
+	}
 	codec := jsoncodec.NewWebsocket(wsConn)
 	if loggo.GetLogger("juju.rpc.jsoncodec").EffectiveLogLevel() <= loggo.TRACE {
 		codec.SetLogging(true)
 	}
-	var notifier rpc.RequestNotifier
-	if logger.EffectiveLogLevel() <= loggo.DEBUG {
-		// Incur request monitoring overhead only if we
-		// know we'll need it.
-		notifier = reqNotifier
-	}
-	conn := rpc.NewConn(codec, notifier)
+	return codec
+}
+
+// Addr returns the address that the server is listening on.
+func (srv *Server) Addr() string {
+	return srv.addr
+}
+
+func (srv *Server) serveConn(wsConn *websocket.Conn, reqNotifier *requestNotifier, envUUID string) error {
+	return srv.serveCodec(newCodec(wsConn), reqNotifier, envUUID)
+}
+
+// serveCodec serves the API for the given environment over the given
+// codec, returning when the codec has no more requests to read or the
+// server is stopped.
+func (srv *Server) serveCodec(codec rpc.Codec, reqNotifier *requestNotifier, envUUID string) error {
+	// The notifier is always installed, so that requests with a
+	// trace id are logged whatever the log level.
+	conn := rpc.NewConn(codec, reqNotifier)
 
 	var h *apiHandler
 	st, _, err := validateEnvironUUID(validateArgs{st: srv.state, envUUID: envUUID})
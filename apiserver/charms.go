@@ -26,6 +26,7 @@ import (
 	"gopkg.in/juju/charm.v5-unstable"
 
 	"github.com/juju/juju/apiserver/client"
+	"github.com/juju/juju/apiserver/common"
 	apihttp "github.com/juju/juju/apiserver/http"
 	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/state"
@@ -57,7 +58,9 @@ func (h *charmsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		// Add a local charm to the store provider.
-		// Requires a "series" query specifying the series to use for the charm.
+		// Requires a "series" query specifying the series to use for the charm,
+		// or a "url" query naming a charm imported with its environment
+		// from another controller.
 		charmURL, err := h.processPost(r, stateWrapper.state)
 		if err != nil {
 			h.sendError(w, http.StatusBadRequest, err.Error())
@@ -193,6 +196,9 @@ func (h *charmsHandler) sendError(w http.ResponseWriter, statusCode int, message
 // processPost handles a charm upload POST request after authentication.
 func (h *charmsHandler) processPost(r *http.Request, st *state.State) (*charm.URL, error) {
 	query := r.URL.Query()
+	if curlString := query.Get("url"); curlString != "" {
+		return h.processMigratedCharm(r, st, curlString)
+	}
 	series := query.Get("series")
 	if series == "" {
 		return nil, fmt.Errorf("expected series=URL argument")
@@ -240,6 +246,44 @@ func (h *charmsHandler) processPost(r *http.Request, st *state.State) (*charm.UR
 	return preparedURL, nil
 }
 
+// processMigratedCharm stores the uploaded archive of a charm imported,
+// with its environment, from another controller. Only the owner of the
+// state server environment may migrate environments.
+func (h *charmsHandler) processMigratedCharm(r *http.Request, st *state.State, curlString string) (*charm.URL, error) {
+	curl, err := charm.ParseURL(curlString)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot parse charm URL")
+	}
+	creds, err := parseBasicAuth(r)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	serverEnv, err := h.ssState.StateServerEnvironment()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if creds.AuthTag != serverEnv.Owner().String() {
+		return nil, common.ErrPerm
+	}
+	tempFile, err := ioutil.TempFile("", "charm")
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot create temp file")
+	}
+	defer tempFile.Close()
+	defer os.Remove(tempFile.Name())
+	size, err := io.Copy(tempFile, r.Body)
+	if err != nil {
+		return nil, errors.Annotate(err, "error processing file upload")
+	}
+	if _, err := tempFile.Seek(0, 0); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := st.ImportCharmArchive(curl, tempFile, size); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return curl, nil
+}
+
 // processUploadedArchive opens the given charm archive from path,
 // inspects it to see if it has all files at the root of the archive
 // or it has subdirs. It repackages the archive so it has all the
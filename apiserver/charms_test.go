@@ -110,6 +110,21 @@ func (s *charmsSuite) TestUploadFailsWithInvalidZip(c *gc.C) {
 	s.assertErrorResponse(c, resp, http.StatusBadRequest, "expected Content-Type: application/zip, got: application/octet-stream")
 }
 
+func (s *charmsSuite) TestUploadMigratedCharmRequiresStateServerOwner(c *gc.C) {
+	resp, err := s.authRequest(c, "POST", s.charmsURI(c, "?url=local:quantal/dummy-1"), "application/zip", bytes.NewBufferString("archive"))
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertErrorResponse(c, resp, http.StatusBadRequest, "permission denied")
+}
+
+func (s *charmsSuite) TestUploadMigratedCharmRequiresImportedEnvironment(c *gc.C) {
+	info := s.APIInfo(c)
+	resp, err := s.sendRequest(c, info.Tag.String(), info.Password, "POST",
+		s.charmsURI(c, "?url=local:quantal/dummy-1"), "application/zip", bytes.NewBufferString("archive"))
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertErrorResponse(c, resp, http.StatusBadRequest,
+		`cannot import archive of charm "local:quantal/dummy-1": environment ".*" is not being imported`)
+}
+
 func (s *charmsSuite) TestUploadBumpsRevision(c *gc.C) {
 	// Add the dummy charm with revision 1.
 	ch := testcharms.Repo.CharmArchive(c.MkDir(), "dummy")
@@ -25,6 +25,7 @@ import (
 	"github.com/juju/juju/apiserver/common"
 	"github.com/juju/juju/apiserver/highavailability"
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/environs"
 	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/environs/manual"
 	"github.com/juju/juju/feature"
@@ -41,6 +42,8 @@ import (
 
 func init() {
 	common.RegisterStandardFacade("Client", 0, NewClient)
+	// Version 1 accepts CIDRs in ServiceExpose.
+	common.RegisterStandardFacade("Client", 1, NewClient)
 }
 
 var (
@@ -242,6 +245,50 @@ func (c *Client) PrivateAddress(p params.PrivateAddress) (results params.Private
 	return results, fmt.Errorf("unknown unit or machine %q", p.Target)
 }
 
+// UnitHistory returns the recorded hook and action executions of
+// each given unit, oldest first.
+func (c *Client) UnitHistory(args params.Entities) (params.UnitHistoryResults, error) {
+	results := params.UnitHistoryResults{
+		Results: make([]params.UnitHistoryResult, len(args.Entities)),
+	}
+	for i, entity := range args.Entities {
+		entries, err := c.unitHistory(entity.Tag)
+		results.Results[i].Entries = entries
+		results.Results[i].Error = common.ServerError(err)
+	}
+	return results, nil
+}
+
+func (c *Client) unitHistory(tagString string) ([]params.UnitHistoryEntry, error) {
+	tag, err := names.ParseUnitTag(tagString)
+	if err != nil {
+		return nil, err
+	}
+	unit, err := c.api.state.Unit(tag.Id())
+	if err != nil {
+		return nil, err
+	}
+	history, err := unit.History()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]params.UnitHistoryEntry, len(history))
+	for i, entry := range history {
+		entries[i] = params.UnitHistoryEntry{
+			Kind:       string(entry.Kind),
+			Name:       entry.Name,
+			Relation:   entry.Relation,
+			RemoteUnit: entry.RemoteUnit,
+			Started:    entry.Started,
+			Finished:   entry.Finished,
+			ExitStatus: entry.ExitStatus,
+			Error:      entry.Error,
+			StderrTail: entry.StderrTail,
+		}
+	}
+	return entries, nil
+}
+
 // ServiceExpose changes the juju-managed firewall to expose any ports that
 // were also explicitly marked by units as open.
 // TODO(mattyw, all): This api call should be move to the new service facade. The client api version will then need bumping.
@@ -253,9 +300,37 @@ func (c *Client) ServiceExpose(args params.ServiceExpose) error {
 	if err != nil {
 		return err
 	}
+	if len(args.CIDRs) > 0 {
+		if err := c.checkSourceCIDRsSupported(); err != nil {
+			return errors.Trace(err)
+		}
+		return svc.SetExposedCIDRs(args.CIDRs)
+	}
 	return svc.SetExposed()
 }
 
+// supportsSourceCIDRs is patched by tests.
+var supportsSourceCIDRs = environs.SupportsSourceCIDRs
+
+// checkSourceCIDRsSupported returns an error satisfying
+// errors.IsNotSupported if the environment's provider cannot restrict
+// access to exposed services by source address. Such a restriction
+// would otherwise be ignored, and the service exposed to any address.
+func (c *Client) checkSourceCIDRsSupported() error {
+	cfg, err := c.api.state.EnvironConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	env, err := environs.New(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !supportsSourceCIDRs(env) {
+		return errors.NotSupportedf("exposing services to CIDRs in %q environments", cfg.Type())
+	}
+	return nil
+}
+
 // ServiceUnexpose changes the juju-managed firewall to unexpose any ports that
 // were also explicitly marked by units as open.
 // TODO(mattyw, all): This api call should be move to the new service facade. The client api version will then need bumping.
@@ -14,6 +14,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/juju/names"
@@ -39,6 +40,7 @@ import (
 	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/apiserver/testing"
 	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/environs"
 	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/environs/manual"
 	toolstesting "github.com/juju/juju/environs/tools/testing"
@@ -1234,6 +1236,31 @@ func (s *clientSuite) TestClientServiceExpose(c *gc.C) {
 	}
 }
 
+func (s *clientSuite) TestClientServiceExposeToCIDRs(c *gc.C) {
+	s.AddTestingService(c, "dummy-service", s.AddTestingCharm(c, "dummy"))
+
+	err := s.APIState.Client().ServiceExposeToCIDRs("dummy-service", []string{"10.0.0.0/8"})
+	c.Assert(err, jc.ErrorIsNil)
+	service, err := s.State.Service("dummy-service")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(service.IsExposed(), jc.IsTrue)
+	c.Assert(service.ExposedCIDRs(), jc.DeepEquals, []string{"10.0.0.0/8"})
+
+	err = s.APIState.Client().ServiceExposeToCIDRs("dummy-service", []string{"10.0.0.0"})
+	c.Assert(err, gc.ErrorMatches, `CIDR "10.0.0.0" not valid`)
+}
+
+func (s *clientSuite) TestClientServiceExposeToCIDRsNotSupported(c *gc.C) {
+	s.PatchValue(client.SupportsSourceCIDRs, func(environs.Environ) bool { return false })
+	s.AddTestingService(c, "dummy-service", s.AddTestingCharm(c, "dummy"))
+
+	err := s.APIState.Client().ServiceExposeToCIDRs("dummy-service", []string{"10.0.0.0/8"})
+	c.Assert(err, gc.ErrorMatches, `exposing services to CIDRs in "dummy" environments not supported`)
+	service, err := s.State.Service("dummy-service")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(service.IsExposed(), jc.IsFalse)
+}
+
 func (s *clientSuite) setupServiceExpose(c *gc.C) {
 	charm := s.AddTestingCharm(c, "dummy")
 	serviceNames := []string{"dummy-service", "exposed-service"}
@@ -2851,6 +2878,50 @@ func (s *clientSuite) TestClientPrivateAddressUnit(c *gc.C) {
 	c.Assert(addr, gc.Equals, "private")
 }
 
+func (s *clientSuite) TestClientUnitHistory(c *gc.C) {
+	s.setUpScenario(c)
+
+	unit, err := s.State.Unit("wordpress/0")
+	c.Assert(err, jc.ErrorIsNil)
+	started := time.Date(2015, 5, 1, 12, 0, 0, 0, time.UTC)
+	err = unit.AddHistory(state.UnitHistoryEntry{
+		Kind:       state.UnitHistoryHook,
+		Name:       "db-relation-joined",
+		Relation:   "db:0",
+		RemoteUnit: "mysql/0",
+		Started:    started,
+		Finished:   started.Add(time.Second),
+		ExitStatus: 1,
+		Error:      "exit status 1",
+		StderrTail: "oops\n",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+
+	history, err := s.APIState.Client().UnitHistory("wordpress/0")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 1)
+	c.Assert(history[0].Started.Equal(started), jc.IsTrue)
+	c.Assert(history[0].Finished.Equal(started.Add(time.Second)), jc.IsTrue)
+	history[0].Started, history[0].Finished = time.Time{}, time.Time{}
+	c.Assert(history[0], jc.DeepEquals, params.UnitHistoryEntry{
+		Kind:       "hook",
+		Name:       "db-relation-joined",
+		Relation:   "db:0",
+		RemoteUnit: "mysql/0",
+		ExitStatus: 1,
+		Error:      "exit status 1",
+		StderrTail: "oops\n",
+	})
+}
+
+func (s *clientSuite) TestClientUnitHistoryErrors(c *gc.C) {
+	s.setUpScenario(c)
+	_, err := s.APIState.Client().UnitHistory("wordpress")
+	c.Assert(err, gc.ErrorMatches, `unit name "wordpress" not valid`)
+	_, err = s.APIState.Client().UnitHistory("wordpress/42")
+	c.Assert(err, gc.ErrorMatches, `unit "wordpress/42" not found`)
+}
+
 func (s *serverSuite) TestClientEnvironmentGet(c *gc.C) {
 	envConfig, err := s.State.EnvironConfig()
 	c.Assert(err, jc.ErrorIsNil)
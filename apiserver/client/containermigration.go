@@ -0,0 +1,66 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/apiserver/params"
+)
+
+// StartContainerMigration marks a container as being migrated to
+// another host machine, and returns its instance id. The container's
+// root filesystem is then copied to the host by the client, which
+// calls CompleteContainerMigration or AbortContainerMigration.
+func (c *Client) StartContainerMigration(args params.ContainerMigration) (params.ContainerMigrationResult, error) {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return params.ContainerMigrationResult{}, errors.Trace(err)
+	}
+	machine, err := c.api.state.Machine(args.MachineId)
+	if err != nil {
+		return params.ContainerMigrationResult{}, errors.Trace(err)
+	}
+	if err := machine.StartMigration(args.HostId); err != nil {
+		return params.ContainerMigrationResult{}, errors.Trace(err)
+	}
+	instId, err := machine.InstanceId()
+	if err != nil {
+		return params.ContainerMigrationResult{}, errors.Trace(err)
+	}
+	return params.ContainerMigrationResult{InstanceId: instId}, nil
+}
+
+// CompleteContainerMigration moves a container's units to a new
+// machine on the host it is being migrated to, and returns the new
+// machine's id. The container's host removes the original container,
+// and the new host provisions the new machine from the container's
+// copied root filesystem.
+func (c *Client) CompleteContainerMigration(args params.ContainerMigration) (params.ContainerMigrationResult, error) {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return params.ContainerMigrationResult{}, errors.Trace(err)
+	}
+	machine, err := c.api.state.Machine(args.MachineId)
+	if err != nil {
+		return params.ContainerMigrationResult{}, errors.Trace(err)
+	}
+	migrated, err := machine.CompleteMigration()
+	if err != nil {
+		return params.ContainerMigrationResult{}, errors.Trace(err)
+	}
+	instId, _ := migrated.MigratedFrom()
+	return params.ContainerMigrationResult{
+		InstanceId: instId,
+		MachineId:  migrated.Id(),
+	}, nil
+}
+
+// AbortContainerMigration abandons the migration of a container that
+// has been started and not completed.
+func (c *Client) AbortContainerMigration(args params.ContainerMigration) error {
+	machine, err := c.api.state.Machine(args.MachineId)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(machine.AbortMigration())
+}
@@ -0,0 +1,75 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/state"
+)
+
+type containerMigrationSuite struct {
+	baseSuite
+	container *state.Machine
+}
+
+var _ = gc.Suite(&containerMigrationSuite{})
+
+func (s *containerMigrationSuite) SetUpTest(c *gc.C) {
+	s.baseSuite.SetUpTest(c)
+	for i := 0; i < 2; i++ {
+		_, err := s.State.AddMachine("quantal", state.JobHostUnits)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	var err error
+	s.container, err = s.State.AddMachineInsideMachine(state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}, "0", instance.LXC)
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.container.SetProvisioned("juju-machine-0-lxc-0", "fake_nonce", nil)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *containerMigrationSuite) TestMigrateContainer(c *gc.C) {
+	client := s.APIState.Client()
+	instId, err := client.StartContainerMigration("0/lxc/0", "1")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(instId, gc.Equals, instance.Id("juju-machine-0-lxc-0"))
+
+	machineId, err := client.CompleteContainerMigration("0/lxc/0")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(machineId, gc.Equals, "1/lxc/0")
+
+	err = s.container.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.container.Life(), gc.Equals, state.Dead)
+	migrated, err := s.State.Machine(machineId)
+	c.Assert(err, jc.ErrorIsNil)
+	migratedFrom, ok := migrated.MigratedFrom()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(migratedFrom, gc.Equals, instId)
+}
+
+func (s *containerMigrationSuite) TestAbortContainerMigration(c *gc.C) {
+	client := s.APIState.Client()
+	_, err := client.StartContainerMigration("0/lxc/0", "1")
+	c.Assert(err, jc.ErrorIsNil)
+	err = client.AbortContainerMigration("0/lxc/0")
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = s.container.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	_, ok := s.container.MigrationHost()
+	c.Assert(ok, jc.IsFalse)
+	c.Assert(s.container.Life(), gc.Equals, state.Alive)
+}
+
+func (s *containerMigrationSuite) TestStartContainerMigrationBlocked(c *gc.C) {
+	s.BlockAllChanges(c, "TestStartContainerMigrationBlocked")
+	_, err := s.APIState.Client().StartContainerMigration("0/lxc/0", "1")
+	s.AssertBlocked(c, err, "TestStartContainerMigrationBlocked")
+}
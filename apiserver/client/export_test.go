@@ -9,6 +9,7 @@ var (
 	GetAllUnitNames         = getAllUnitNames
 	NewStateStorage         = &newStateStorage
 	NewCharmStore           = &newCharmStore
+	SupportsSourceCIDRs     = &supportsSourceCIDRs
 )
 
 var MachineJobFromParams = machineJobFromParams
@@ -18,17 +18,17 @@ var _ = gc.Suite(&filteringUnitTests{})
 
 func (f *filteringUnitTests) TestMatchPortRanges(c *gc.C) {
 
-	match, ok, err := client.MatchPortRanges([]string{"80/tcp"}, network.PortRange{80, 80, "tcp"})
+	match, ok, err := client.MatchPortRanges([]string{"80/tcp"}, network.PortRange{FromPort: 80, ToPort: 80, Protocol: "tcp"})
 	c.Check(err, jc.ErrorIsNil)
 	c.Check(ok, jc.IsTrue)
 	c.Check(match, jc.IsTrue)
 
-	match, ok, err = client.MatchPortRanges([]string{"80-90/tcp"}, network.PortRange{80, 90, "tcp"})
+	match, ok, err = client.MatchPortRanges([]string{"80-90/tcp"}, network.PortRange{FromPort: 80, ToPort: 90, Protocol: "tcp"})
 	c.Check(err, jc.ErrorIsNil)
 	c.Check(ok, jc.IsTrue)
 	c.Check(match, jc.IsTrue)
 
-	match, ok, err = client.MatchPortRanges([]string{"90/tcp"}, network.PortRange{80, 90, "tcp"})
+	match, ok, err = client.MatchPortRanges([]string{"90/tcp"}, network.PortRange{FromPort: 80, ToPort: 90, Protocol: "tcp"})
 	c.Check(err, jc.ErrorIsNil)
 	c.Check(ok, jc.IsTrue)
 	c.Check(match, jc.IsFalse)
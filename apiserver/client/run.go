@@ -122,6 +122,40 @@ func (c *Client) Run(run params.RunParams) (results params.RunResults, err error
 	return ParallelExecute(c.getDataDir(), params), nil
 }
 
+// DebugHooks performs an operation of a non-interactive debug-hooks
+// session on the specified unit, by way of juju-run on the unit's
+// machine.
+func (c *Client) DebugHooks(args params.DebugHooksParams) (params.RunResults, error) {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return params.RunResults{}, errors.Trace(err)
+	}
+	units, err := getAllUnitNames(c.api.state, []string{args.Unit}, nil)
+	if err != nil {
+		return params.RunResults{}, err
+	}
+	unit := units[0]
+	machineId, err := unit.AssignedMachineId()
+	if err != nil {
+		return params.RunResults{}, err
+	}
+	machine, err := c.api.state.Machine(machineId)
+	if err != nil {
+		return params.RunResults{}, err
+	}
+	command := fmt.Sprintf("juju-run --debug-hooks=%s %s", utils.ShQuote(args.Operation), unit.Name())
+	switch args.Operation {
+	case "start":
+		for _, hook := range args.Hooks {
+			command += " " + utils.ShQuote(hook)
+		}
+	case "exec":
+		command += " " + utils.ShQuote(args.Commands)
+	}
+	execParam := remoteParamsForMachine(machine, command, args.Timeout)
+	execParam.UnitId = unit.Name()
+	return ParallelExecute(c.getDataDir(), []*RemoteExec{execParam}), nil
+}
+
 // RunOnAllMachines attempts to run the specified command on all the machines.
 func (c *Client) RunOnAllMachines(run params.RunParams) (params.RunResults, error) {
 	if err := c.check.ChangeAllowed(); err != nil {
@@ -346,3 +346,52 @@ func (s *runSuite) TestBlockRunMachineAndService(c *gc.C) {
 		})
 	s.AssertBlocked(c, err, "TestBlockRunMachineAndService")
 }
+
+func (s *runSuite) TestDebugHooks(c *gc.C) {
+	charm := s.AddTestingCharm(c, "dummy")
+	owner := s.Factory.MakeUser(c, nil).Tag()
+	magic, err := s.State.AddService("magic", owner.String(), charm, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	s.addUnit(c, magic)
+
+	s.mockSSH(c, echoInput)
+
+	client := s.APIState.Client()
+	for i, args := range []params.DebugHooksParams{{
+		Unit:      "magic/0",
+		Operation: "start",
+		Hooks:     []string{"install", "config-changed"},
+		Timeout:   testing.LongWait,
+	}, {
+		Unit:      "magic/0",
+		Operation: "exec",
+		Commands:  "config-get",
+		Timeout:   testing.LongWait,
+	}} {
+		c.Logf("test %d: %s", i, args.Operation)
+		result, err := client.DebugHooks(args)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(result, jc.DeepEquals, params.RunResult{
+			ExecResponse: exec.ExecResponse{Stdout: []byte(expectedDebugHooksCommand[i])},
+			MachineId:    "0",
+			UnitId:       "magic/0",
+		})
+	}
+}
+
+func (s *runSuite) TestDebugHooksUnknownUnit(c *gc.C) {
+	_, err := s.APIState.Client().DebugHooks(params.DebugHooksParams{
+		Unit:      "magic/0",
+		Operation: "step",
+	})
+	c.Assert(err, gc.ErrorMatches, `unit "magic/0" not found`)
+}
+
+func (s *runSuite) TestBlockDebugHooks(c *gc.C) {
+	s.BlockAllChanges(c, "TestBlockDebugHooks")
+	_, err := s.APIState.Client().DebugHooks(params.DebugHooksParams{
+		Unit:      "magic/0",
+		Operation: "step",
+	})
+	s.AssertBlocked(c, err, "TestBlockDebugHooks")
+}
@@ -11,6 +11,11 @@ var expectedCommand = []string{
 	"juju-run magic/1 'hostname'\n",
 }
 
+var expectedDebugHooksCommand = []string{
+	"juju-run --debug-hooks='start' magic/0 'install' 'config-changed'\n",
+	"juju-run --debug-hooks='exec' magic/0 'config-get'\n",
+}
+
 var echoInputShowArgs = `#!/bin/bash
 # Write the args to stderr
 echo "$*" >&2
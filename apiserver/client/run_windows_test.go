@@ -11,6 +11,11 @@ var expectedCommand = []string{
 	"juju-run magic/1 'hostname'\r\n",
 }
 
+var expectedDebugHooksCommand = []string{
+	"juju-run --debug-hooks='start' magic/0 'install' 'config-changed'\r\n",
+	"juju-run --debug-hooks='exec' magic/0 'config-get'\r\n",
+}
+
 var echoInputShowArgs = `@echo off
 echo %* 1>&2
 
@@ -4,6 +4,8 @@
 package common
 
 import (
+	"fmt"
+
 	"github.com/juju/errors"
 
 	"github.com/juju/juju/state"
@@ -58,6 +60,11 @@ func (c *BlockChecker) DestroyAllowed() error {
 // If it does, the method throws specific error that can be examined
 // to stop operation execution.
 func (c *BlockChecker) checkBlock(blockType state.BlockType) error {
+	if blockType == state.ChangeBlock {
+		if err := c.checkMigration(); err != nil {
+			return err
+		}
+	}
 	aBlock, isEnabled, err := c.getter.GetBlockForType(blockType)
 	if err != nil {
 		return errors.Trace(err)
@@ -67,3 +74,26 @@ func (c *BlockChecker) checkBlock(blockType state.BlockType) error {
 	}
 	return nil
 }
+
+// environGetter is implemented by BlockGetters, such as *state.State,
+// which can report whether their environment is being migrated.
+type environGetter interface {
+	Environment() (*state.Environment, error)
+}
+
+// checkMigration blocks all changes to an environment while it is
+// being migrated between controllers.
+func (c *BlockChecker) checkMigration() error {
+	getter, ok := c.getter.(environGetter)
+	if !ok {
+		return nil
+	}
+	env, err := getter.Environment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if env.MigrationMode() != state.MigrationModeNone {
+		return ErrOperationBlocked(fmt.Sprintf("environment %q is being migrated", env.Name()))
+	}
+	return nil
+}
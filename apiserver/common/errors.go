@@ -75,6 +75,7 @@ var (
 	ErrBadRequest         = stderrors.New("invalid request")
 	ErrTryAgain           = stderrors.New("try again")
 	ErrActionNotAvailable = stderrors.New("action no longer available")
+	ErrRateLimitExceeded  = stderrors.New("rate limit exceeded")
 
 	ErrOperationBlocked = func(msg string) *params.Error {
 		if msg == "" {
@@ -102,6 +103,7 @@ var singletonErrorCodes = map[error]string{
 	ErrStoppedWatcher:            params.CodeStopped,
 	ErrTryAgain:                  params.CodeTryAgain,
 	ErrActionNotAvailable:        params.CodeActionNotAvailable,
+	ErrRateLimitExceeded:         params.CodeRateLimitExceeded,
 }
 
 func singletonCode(err error) (string, bool) {
@@ -105,6 +105,10 @@ var errorTransformTests = []struct {
 	err:        common.ErrTryAgain,
 	code:       params.CodeTryAgain,
 	helperFunc: params.IsCodeTryAgain,
+}, {
+	err:        common.ErrRateLimitExceeded,
+	code:       params.CodeRateLimitExceeded,
+	helperFunc: params.IsCodeRateLimitExceeded,
 }, {
 	err:        state.UpgradeInProgressError,
 	code:       params.CodeUpgradeInProgress,
@@ -22,6 +22,7 @@ import (
 	"launchpad.net/tomb"
 
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/trace"
 )
 
 // debugLogHandler takes requests to watch the debug log.
@@ -48,6 +49,7 @@ var maxLinesReached = fmt.Errorf("max lines reached")
 //      - has no meaning if 'replay' is true
 //   level -> string one of [TRACE, DEBUG, INFO, WARNING, ERROR]
 //   replay -> string - one of [true, false], if true, start the file from the start
+//   traceId -> string - only include lines for API requests with this trace id
 func (h *debugLogHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	server := websocket.Server{
 		Handler: func(socket *websocket.Conn) {
@@ -152,6 +154,11 @@ func newLogStream(queryMap url.Values) (*logStream, error) {
 		}
 	}
 
+	traceId := queryMap.Get("traceId")
+	if traceId != "" && !trace.IsValidId(traceId) {
+		return nil, fmt.Errorf("traceId value %q is not a valid trace id", traceId)
+	}
+
 	return &logStream{
 		includeEntity: queryMap["includeEntity"],
 		includeModule: queryMap["includeModule"],
@@ -161,6 +168,7 @@ func newLogStream(queryMap url.Values) (*logStream, error) {
 		fromTheStart:  fromTheStart,
 		backlog:       backlog,
 		filterLevel:   level,
+		traceId:       traceId,
 	}, nil
 }
 
@@ -187,6 +195,7 @@ type logLine struct {
 	agentName string
 	level     loggo.Level
 	module    string
+	traceIds  []string
 }
 
 func parseLogLine(line string) *logLine {
@@ -233,6 +242,11 @@ func parseLogLine(line string) *logLine {
 			result.level = level
 			result.module = fields[moduleIndex]
 		}
+		for _, field := range fields[moduleIndex+1:] {
+			if strings.HasPrefix(field, trace.LabelPrefix) {
+				result.traceIds = append(result.traceIds, field[len(trace.LabelPrefix):])
+			}
+		}
 	}
 
 	return result
@@ -252,6 +266,7 @@ type logStream struct {
 	maxLines      uint
 	lineCount     uint
 	fromTheStart  bool
+	traceId       string
 }
 
 // positionLogFile will update the internal read position of the logFile to be
@@ -287,7 +302,8 @@ func (stream *logStream) filterLine(line []byte) bool {
 	return stream.checkIncludeEntity(log) &&
 		stream.checkIncludeModule(log) &&
 		!stream.exclude(log) &&
-		stream.checkLevel(log)
+		stream.checkLevel(log) &&
+		stream.checkTraceId(log)
 }
 
 // countedFilterLine checks the received line for one of the configured tags,
@@ -370,3 +386,15 @@ func (stream *logStream) exclude(line *logLine) bool {
 func (stream *logStream) checkLevel(line *logLine) bool {
 	return line.level >= stream.filterLevel
 }
+
+func (stream *logStream) checkTraceId(line *logLine) bool {
+	if stream.traceId == "" {
+		return true
+	}
+	for _, traceId := range line.traceIds {
+		if traceId == stream.traceId {
+			return true
+		}
+	}
+	return false
+}
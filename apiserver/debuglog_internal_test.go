@@ -177,6 +177,21 @@ func (s *debugInternalSuite) TestFilterLine(c *gc.C) {
 		"machine-0: date time WARNING juju.foo.bar")), jc.IsFalse)
 }
 
+func (s *debugInternalSuite) TestFilterLineTraceId(c *gc.C) {
+	stream := &logStream{
+		traceId: "deploy-1",
+	}
+	c.Check(stream.filterLine([]byte(
+		"machine-0: date time INFO juju.apiserver.trace trace:deploy-1 <- [1] user-admin {}")), jc.IsTrue)
+	c.Check(stream.filterLine([]byte(
+		"machine-0: date time INFO juju.apiserver.trace trace:deploy-2 <- [1] user-admin {}")), jc.IsFalse)
+	c.Check(stream.filterLine([]byte(
+		"machine-0: date time DEBUG juju.apiserver <- [1] user-admin {}")), jc.IsFalse)
+	// Trace labels are only recognised in the message.
+	c.Check(stream.filterLine([]byte(
+		"trace:deploy-1 date time DEBUG juju.apiserver")), jc.IsFalse)
+}
+
 func (s *debugInternalSuite) TestCountedFilterLineWithLimit(c *gc.C) {
 	stream := &logStream{
 		filterLevel: loggo.INFO,
@@ -305,6 +320,7 @@ func assertStreamParams(c *gc.C, obtained, expected *logStream) {
 	c.Check(obtained.fromTheStart, gc.Equals, expected.fromTheStart)
 	c.Check(obtained.filterLevel, gc.Equals, expected.filterLevel)
 	c.Check(obtained.backlog, gc.Equals, expected.backlog)
+	c.Check(obtained.traceId, gc.Equals, expected.traceId)
 }
 
 func (s *debugInternalSuite) TestNewLogStream(c *gc.C) {
@@ -320,6 +336,7 @@ func (s *debugInternalSuite) TestNewLogStream(c *gc.C) {
 		"maxLines":      []string{"300"},
 		"backlog":       []string{"100"},
 		"level":         []string{"INFO"},
+		"traceId":       []string{"deploy-1"},
 		// OK, just a little nonsense
 		"replay": []string{"true"},
 	}
@@ -332,6 +349,7 @@ func (s *debugInternalSuite) TestNewLogStream(c *gc.C) {
 		backlog:       100,
 		filterLevel:   loggo.INFO,
 		fromTheStart:  true,
+		traceId:       "deploy-1",
 	}
 	obtained, err = newLogStream(values)
 	c.Assert(err, jc.ErrorIsNil)
@@ -348,6 +366,9 @@ func (s *debugInternalSuite) TestNewLogStream(c *gc.C) {
 
 	_, err = newLogStream(url.Values{"level": []string{"foo"}})
 	c.Assert(err, gc.ErrorMatches, `level value "foo" is not one of "TRACE", "DEBUG", "INFO", "WARNING", "ERROR"`)
+
+	_, err = newLogStream(url.Values{"traceId": []string{"deploy 1"}})
+	c.Assert(err, gc.ErrorMatches, `traceId value "deploy 1" is not a valid trace id`)
 }
 
 type agentMatchTest struct {
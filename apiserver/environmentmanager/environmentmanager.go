@@ -82,15 +82,7 @@ type ConfigSource interface {
 	Config() (*config.Config, error)
 }
 
-var configValuesFromStateServer = []string{
-	"type",
-	"ca-cert",
-	"state-port",
-	"api-port",
-	"syslog-port",
-	"rsyslog-ca-cert",
-	"rsyslog-ca-key",
-}
+var configValuesFromStateServer = append([]string{"type"}, config.StateServerAttributes...)
 
 // ConfigSkeleton returns config values to be used as a starting point for the
 // API caller to construct a valid environment specific config.  The provider
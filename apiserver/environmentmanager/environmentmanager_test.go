@@ -4,6 +4,7 @@
 package environmentmanager_test
 
 import (
+	"github.com/juju/errors"
 	"github.com/juju/loggo"
 	"github.com/juju/names"
 	jc "github.com/juju/testing/checkers"
@@ -25,13 +26,14 @@ import (
 	_ "github.com/juju/juju/provider/openstack"
 	"github.com/juju/juju/state"
 	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/testing/factory"
 	"github.com/juju/juju/version"
 )
 
 type envManagerSuite struct {
 	jujutesting.JujuConnSuite
 
-	envmanager *environmentmanager.EnvironmentManagerAPI
+	envmanager *environmentmanager.EnvironmentManagerAPIV2
 	resources  *common.Resources
 	authoriser apiservertesting.FakeAuthorizer
 }
@@ -87,7 +89,7 @@ func (s *envManagerSuite) createArgsForVersion(c *gc.C, owner names.UserTag, ver
 
 func (s *envManagerSuite) setAPIUser(c *gc.C, user names.UserTag) {
 	s.authoriser.Tag = user
-	envmanager, err := environmentmanager.NewEnvironmentManagerAPI(s.State, s.resources, s.authoriser)
+	envmanager, err := environmentmanager.NewEnvironmentManagerAPIV2(s.State, s.resources, s.authoriser)
 	c.Assert(err, jc.ErrorIsNil)
 	s.envmanager = envmanager
 }
@@ -154,7 +156,7 @@ func (s *envManagerSuite) TestRestrictedProviderFields(c *gc.C) {
 		},
 	} {
 		c.Logf("%d: %s provider", i, test.provider)
-		fields, err := environmentmanager.RestrictedProviderFields(s.envmanager, test.provider)
+		fields, err := environmentmanager.RestrictedProviderFields(&s.envmanager.EnvironmentManagerAPI, test.provider)
 		c.Check(err, jc.ErrorIsNil)
 		c.Check(fields, jc.SameContents, test.expected)
 	}
@@ -332,6 +334,211 @@ func (s *envManagerSuite) TestListEnvironmentsDenied(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, "permission denied")
 }
 
+func (s *envManagerSuite) TestMigrationDenied(c *gc.C) {
+	s.setAPIUser(c, names.NewUserTag("external@remote"))
+	envTag := names.NewEnvironTag(s.State.EnvironUUID()).String()
+	_, err := s.envmanager.ExportEnvironment(params.Entity{envTag})
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+	err = s.envmanager.ValidateEnvironmentImport(params.EnvironmentExport{EnvironTag: envTag})
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+	err = s.envmanager.RemoveMigratedEnvironment(params.Entity{envTag})
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+	err = s.envmanager.BeginMigration(params.Entity{envTag})
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+	err = s.envmanager.ActivateEnvironment(params.Entity{envTag})
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+	err = s.envmanager.AbortMigration(params.Entity{envTag})
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+	err = s.envmanager.RemoveImportedEnvironment(params.Entity{envTag})
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
+
+func (s *envManagerSuite) TestMigrateEnvironment(c *gc.C) {
+	s.setAPIUser(c, s.AdminUserTag(c))
+	st := s.Factory.MakeEnvironment(c, &factory.EnvParams{Name: "hosted"})
+	defer st.Close()
+	factory.NewFactory(st).MakeMachine(c, nil)
+	envTag := names.NewEnvironTag(st.EnvironUUID())
+	entity := params.Entity{envTag.String()}
+
+	err := s.envmanager.BeginMigration(entity)
+	c.Assert(err, jc.ErrorIsNil)
+	export, err := s.envmanager.ExportEnvironment(entity)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(export.EnvironTag, gc.Equals, envTag.String())
+	err = s.envmanager.ValidateEnvironmentImport(export)
+	c.Assert(err, gc.ErrorMatches, `environment ".*" already exists`)
+
+	err = s.envmanager.SetMigrationTarget(params.SetMigrationTargetArgs{
+		EnvironTag: envTag.String(),
+		Target: params.MigrationTarget{
+			APIAddrs: []string{"10.0.0.1:17070"},
+			CACert:   "cert",
+		},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	status, err := s.envmanager.MigrationStatus(entity)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(status.PendingAgents, jc.DeepEquals, []string{"machine-0"})
+	env, err := st.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	err = env.AckMigration(names.NewMachineTag("0"))
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.envmanager.RemoveMigratedEnvironment(entity)
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = s.envmanager.ValidateEnvironmentImport(export)
+	c.Assert(err, jc.ErrorIsNil)
+	imported, err := s.envmanager.ImportEnvironment(export)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(imported, jc.DeepEquals, params.Environment{
+		Name:     "hosted",
+		UUID:     envTag.Id(),
+		OwnerTag: s.AdminUserTag(c).String(),
+	})
+	err = s.envmanager.ActivateEnvironment(entity)
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.envmanager.RemoveImportedEnvironment(entity)
+	c.Assert(err, gc.ErrorMatches, `environment "hosted" is not being imported`)
+}
+
+func (s *envManagerSuite) TestAbortMigration(c *gc.C) {
+	s.setAPIUser(c, s.AdminUserTag(c))
+	st := s.Factory.MakeEnvironment(c, &factory.EnvParams{Name: "hosted"})
+	defer st.Close()
+	entity := params.Entity{names.NewEnvironTag(st.EnvironUUID()).String()}
+
+	err := s.envmanager.BeginMigration(entity)
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.envmanager.AbortMigration(entity)
+	c.Assert(err, jc.ErrorIsNil)
+	env, err := st.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env.MigrationMode(), gc.Equals, state.MigrationModeNone)
+
+	err = s.envmanager.AbortMigration(entity)
+	c.Assert(err, gc.ErrorMatches, `.*: environment is not being migrated`)
+}
+
+func (s *envManagerSuite) TestAdminOperationsDenied(c *gc.C) {
+	s.setAPIUser(c, names.NewUserTag("external@remote"))
+	envTag := names.NewEnvironTag(s.State.EnvironUUID()).String()
+	_, err := s.envmanager.AllEnvironments()
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+	_, err = s.envmanager.EnvironmentStatus(params.Entities{[]params.Entity{{envTag}}})
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+	err = s.envmanager.DestroyEnvironment(params.Entity{envTag})
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+	err = s.envmanager.BlockEnvironment(params.EnvironmentBlockArgs{EnvironTag: envTag, Type: "BlockChange"})
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
+
+func (s *envManagerSuite) TestAllEnvironments(c *gc.C) {
+	s.setAPIUser(c, s.AdminUserTag(c))
+	owner := names.NewUserTag("external@remote")
+	st := s.Factory.MakeEnvironment(c, &factory.EnvParams{Name: "hosted", Owner: owner})
+	defer st.Close()
+
+	result, err := s.envmanager.AllEnvironments()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Environments, gc.HasLen, 2)
+	stateServer, err := s.State.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	s.checkEnvironmentMatches(c, result.Environments[0], stateServer)
+	hosted, err := st.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	s.checkEnvironmentMatches(c, result.Environments[1], hosted)
+	c.Check(result.Environments[1].ServerUUID, gc.Equals, s.State.EnvironUUID())
+}
+
+func (s *envManagerSuite) TestEnvironmentStatus(c *gc.C) {
+	s.setAPIUser(c, s.AdminUserTag(c))
+	owner := names.NewUserTag("external@remote")
+	st := s.Factory.MakeEnvironment(c, &factory.EnvParams{Name: "hosted", Owner: owner})
+	defer st.Close()
+	factory.NewFactory(st).MakeUnit(c, nil)
+	envUser, err := st.EnvironmentUser(owner)
+	c.Assert(err, jc.ErrorIsNil)
+	err = envUser.UpdateLastConnection()
+	c.Assert(err, jc.ErrorIsNil)
+	envTag := names.NewEnvironTag(st.EnvironUUID())
+
+	results, err := s.envmanager.EnvironmentStatus(params.Entities{[]params.Entity{
+		{envTag.String()},
+		{"environment-deadbeef-0bad-400d-8000-4b1d0d06f00d"},
+		{"machine-0"},
+	}})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(results.Results, gc.HasLen, 3)
+	c.Assert(results.Results[0].Error, gc.IsNil)
+	c.Assert(results.Results[0].Status, jc.DeepEquals, &params.EnvironmentStatus{
+		EnvironTag:   envTag.String(),
+		Name:         "hosted",
+		OwnerTag:     owner.String(),
+		Life:         params.Alive,
+		MachineCount: 1,
+		UnitCount:    1,
+		LastActivity: envUser.LastConnection(),
+	})
+	c.Assert(results.Results[1].Error, gc.ErrorMatches, "environment not found")
+	c.Assert(results.Results[2].Error, gc.ErrorMatches, `"machine-0" is not a valid environment tag`)
+}
+
+func (s *envManagerSuite) TestBlockEnvironment(c *gc.C) {
+	s.setAPIUser(c, s.AdminUserTag(c))
+	st := s.Factory.MakeEnvironment(c, &factory.EnvParams{Name: "hosted"})
+	defer st.Close()
+	envTag := names.NewEnvironTag(st.EnvironUUID()).String()
+
+	err := s.envmanager.BlockEnvironment(params.EnvironmentBlockArgs{
+		EnvironTag: envTag,
+		Type:       "BlockDestroy",
+		Message:    "keep it",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	block, found, err := st.GetBlockForType(state.DestroyBlock)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(found, jc.IsTrue)
+	c.Assert(block.Message(), gc.Equals, "keep it")
+
+	err = s.envmanager.DestroyEnvironment(params.Entity{envTag})
+	c.Assert(err, gc.ErrorMatches, "keep it")
+
+	err = s.envmanager.UnblockEnvironment(params.EnvironmentBlockArgs{
+		EnvironTag: envTag,
+		Type:       "BlockDestroy",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	_, found, err = st.GetBlockForType(state.DestroyBlock)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(found, jc.IsFalse)
+
+	err = s.envmanager.BlockEnvironment(params.EnvironmentBlockArgs{
+		EnvironTag: envTag,
+		Type:       "BlockEverything",
+	})
+	c.Assert(err, gc.ErrorMatches, `block type "BlockEverything" not valid`)
+}
+
+func (s *envManagerSuite) TestDestroyEnvironment(c *gc.C) {
+	s.setAPIUser(c, s.AdminUserTag(c))
+	st := s.Factory.MakeEnvironment(c, &factory.EnvParams{Name: "hosted"})
+	defer st.Close()
+	envTag := names.NewEnvironTag(st.EnvironUUID())
+
+	err := s.envmanager.DestroyEnvironment(params.Entity{envTag.String()})
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.GetEnvironment(envTag)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *envManagerSuite) TestDestroyStateServerEnvironment(c *gc.C) {
+	s.setAPIUser(c, s.AdminUserTag(c))
+	envTag := names.NewEnvironTag(s.State.EnvironUUID())
+	err := s.envmanager.DestroyEnvironment(params.Entity{envTag.String()})
+	c.Assert(err, gc.ErrorMatches, "cannot destroy the state server environment")
+}
+
 type fakeProvider struct {
 	environs.EnvironProvider
 }
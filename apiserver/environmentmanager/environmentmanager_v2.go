@@ -0,0 +1,428 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package environmentmanager
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/feature"
+	"github.com/juju/juju/state"
+)
+
+func init() {
+	common.RegisterStandardFacadeForFeature("EnvironmentManager", 2, NewEnvironmentManagerAPIV2, feature.JES)
+}
+
+// EnvironmentManagerV2 defines the methods on version 2 of the
+// environmentmanager API end point, which adds the migration of
+// environments between controllers and the administration of all the
+// environments hosted by a controller.
+type EnvironmentManagerV2 interface {
+	EnvironmentManager
+	BeginMigration(env params.Entity) error
+	ExportEnvironment(env params.Entity) (params.EnvironmentExport, error)
+	ValidateEnvironmentImport(args params.EnvironmentExport) error
+	ImportEnvironment(args params.EnvironmentExport) (params.Environment, error)
+	SetMigrationTarget(args params.SetMigrationTargetArgs) error
+	MigrationStatus(env params.Entity) (params.MigrationStatus, error)
+	ActivateEnvironment(env params.Entity) error
+	RemoveMigratedEnvironment(env params.Entity) error
+	AbortMigration(env params.Entity) error
+	RemoveImportedEnvironment(env params.Entity) error
+	AllEnvironments() (params.EnvironmentList, error)
+	EnvironmentStatus(args params.Entities) (params.EnvironmentStatusResults, error)
+	DestroyEnvironment(env params.Entity) error
+	BlockEnvironment(args params.EnvironmentBlockArgs) error
+	UnblockEnvironment(args params.EnvironmentBlockArgs) error
+}
+
+// EnvironmentManagerAPIV2 implements version 2 of the environment
+// manager API.
+type EnvironmentManagerAPIV2 struct {
+	EnvironmentManagerAPI
+}
+
+var _ EnvironmentManagerV2 = (*EnvironmentManagerAPIV2)(nil)
+
+// NewEnvironmentManagerAPIV2 creates a new api server endpoint for
+// managing environments, version 2.
+func NewEnvironmentManagerAPIV2(
+	st *state.State,
+	resources *common.Resources,
+	authorizer common.Authorizer,
+) (*EnvironmentManagerAPIV2, error) {
+	baseAPI, err := NewEnvironmentManagerAPI(st, resources, authorizer)
+	if err != nil {
+		return nil, err
+	}
+	return &EnvironmentManagerAPIV2{*baseAPI}, nil
+}
+
+// authStateServerAdmin checks that the API user is the owner of the
+// state server environment. Only they may move environments between
+// controllers.
+func (em *EnvironmentManagerAPIV2) authStateServerAdmin() error {
+	stateServerEnv, err := em.state.StateServerEnvironment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	adminUser := stateServerEnv.Owner()
+	return em.authCheck(adminUser, adminUser)
+}
+
+// environState returns a State for the environment with the given tag.
+// The caller is responsible for closing it.
+func (em *EnvironmentManagerAPIV2) environState(tag string) (*state.State, error) {
+	if err := em.authStateServerAdmin(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	envTag, err := names.ParseEnvironTag(tag)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return em.state.ForEnviron(envTag)
+}
+
+// BeginMigration stops the workers of the specified environment, and
+// blocks changes to it, so that it may be exported to another
+// controller.
+func (em *EnvironmentManagerAPIV2) BeginMigration(env params.Entity) error {
+	st, err := em.environState(env.Tag)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer st.Close()
+
+	environ, err := st.Environment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(environ.BeginMigration())
+}
+
+// ExportEnvironment returns the documents of the specified environment,
+// and the URLs of the charms whose archives must be copied with them,
+// so that it may be imported into another controller.
+func (em *EnvironmentManagerAPIV2) ExportEnvironment(env params.Entity) (params.EnvironmentExport, error) {
+	result := params.EnvironmentExport{}
+	st, err := em.environState(env.Tag)
+	if err != nil {
+		return result, errors.Trace(err)
+	}
+	defer st.Close()
+
+	docs, err := st.ExportEnvironmentDocs()
+	if err != nil {
+		return result, errors.Trace(err)
+	}
+	data, err := bson.Marshal(docs)
+	if err != nil {
+		return result, errors.Annotate(err, "cannot encode environment")
+	}
+	result.EnvironTag = names.NewEnvironTag(docs.EnvUUID).String()
+	result.Documents = data
+	result.Charms = docs.CharmURLs()
+	return result, nil
+}
+
+func decodeEnvironmentExport(args params.EnvironmentExport) (*state.EnvironmentDocs, error) {
+	var docs state.EnvironmentDocs
+	if err := bson.Unmarshal(args.Documents, &docs); err != nil {
+		return nil, errors.Annotate(err, "cannot decode environment")
+	}
+	envTag, err := names.ParseEnvironTag(args.EnvironTag)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if docs.EnvUUID != envTag.Id() {
+		return nil, errors.Errorf("exported documents are for environment %q, not %q", docs.EnvUUID, envTag.Id())
+	}
+	return &docs, nil
+}
+
+// ValidateEnvironmentImport checks that an environment exported from
+// another controller can be imported into this one, without changing
+// anything.
+func (em *EnvironmentManagerAPIV2) ValidateEnvironmentImport(args params.EnvironmentExport) error {
+	if err := em.authStateServerAdmin(); err != nil {
+		return errors.Trace(err)
+	}
+	docs, err := decodeEnvironmentExport(args)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(em.state.ValidateEnvironmentImport(docs))
+}
+
+// ImportEnvironment recreates an environment exported from another
+// controller. The environment is not used until it is activated with
+// ActivateEnvironment.
+func (em *EnvironmentManagerAPIV2) ImportEnvironment(args params.EnvironmentExport) (params.Environment, error) {
+	result := params.Environment{}
+	if err := em.authStateServerAdmin(); err != nil {
+		return result, errors.Trace(err)
+	}
+	docs, err := decodeEnvironmentExport(args)
+	if err != nil {
+		return result, errors.Trace(err)
+	}
+	env, st, err := em.state.ImportEnvironmentDocs(docs)
+	if err != nil {
+		return result, errors.Annotate(err, "failed to import environment")
+	}
+	defer st.Close()
+
+	result.Name = env.Name()
+	result.UUID = env.UUID()
+	result.OwnerTag = env.Owner().String()
+	return result, nil
+}
+
+// SetMigrationTarget records that the specified environment has been
+// imported into another controller, so that its agents move there.
+func (em *EnvironmentManagerAPIV2) SetMigrationTarget(args params.SetMigrationTargetArgs) error {
+	st, err := em.environState(args.EnvironTag)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer st.Close()
+
+	env, err := st.Environment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return env.SetMigrationTarget(state.MigrationTarget{
+		APIAddrs: args.Target.APIAddrs,
+		CACert:   args.Target.CACert,
+	})
+}
+
+// MigrationStatus reports which of the specified environment's agents
+// have yet to move to its migration target.
+func (em *EnvironmentManagerAPIV2) MigrationStatus(env params.Entity) (params.MigrationStatus, error) {
+	result := params.MigrationStatus{}
+	st, err := em.environState(env.Tag)
+	if err != nil {
+		return result, errors.Trace(err)
+	}
+	defer st.Close()
+
+	pending, err := st.PendingMigrationAgents()
+	if err != nil {
+		return result, errors.Trace(err)
+	}
+	for _, tag := range pending {
+		result.PendingAgents = append(result.PendingAgents, tag.String())
+	}
+	return result, nil
+}
+
+// ActivateEnvironment starts using the specified environment, imported
+// from another controller, once its agents have moved to this one.
+func (em *EnvironmentManagerAPIV2) ActivateEnvironment(env params.Entity) error {
+	st, err := em.environState(env.Tag)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer st.Close()
+	return errors.Trace(st.ActivateImportedEnvironment())
+}
+
+// RemoveMigratedEnvironment removes the specified environment from
+// this controller, once it has been migrated to another.
+func (em *EnvironmentManagerAPIV2) RemoveMigratedEnvironment(env params.Entity) error {
+	st, err := em.environState(env.Tag)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer st.Close()
+	return errors.Trace(st.RemoveMigratedEnvironment())
+}
+
+// AbortMigration stops migrating the specified environment, so that it
+// is managed by this controller again.
+func (em *EnvironmentManagerAPIV2) AbortMigration(env params.Entity) error {
+	st, err := em.environState(env.Tag)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer st.Close()
+
+	environ, err := st.Environment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(environ.AbortMigration())
+}
+
+// RemoveImportedEnvironment removes the specified environment, imported
+// from another controller, when its migration is aborted.
+func (em *EnvironmentManagerAPIV2) RemoveImportedEnvironment(env params.Entity) error {
+	st, err := em.environState(env.Tag)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer st.Close()
+	return errors.Trace(st.RemoveImportedEnvironment())
+}
+
+// AllEnvironments returns all the environments hosted by the state
+// server. Only the state server administrator may list them.
+func (em *EnvironmentManagerAPIV2) AllEnvironments() (params.EnvironmentList, error) {
+	result := params.EnvironmentList{}
+	if err := em.authStateServerAdmin(); err != nil {
+		return result, errors.Trace(err)
+	}
+	environments, err := em.state.AllEnvironments()
+	if err != nil {
+		return result, errors.Trace(err)
+	}
+	for _, env := range environments {
+		result.Environments = append(result.Environments, params.Environment{
+			Name:       env.Name(),
+			UUID:       env.UUID(),
+			OwnerTag:   env.Owner().String(),
+			ServerUUID: env.ServerTag().Id(),
+		})
+	}
+	return result, nil
+}
+
+// EnvironmentStatus returns the owner, life, machine and unit counts
+// and last activity of each of the specified environments. Only the
+// state server administrator may inspect environments this way.
+func (em *EnvironmentManagerAPIV2) EnvironmentStatus(args params.Entities) (params.EnvironmentStatusResults, error) {
+	results := params.EnvironmentStatusResults{
+		Results: make([]params.EnvironmentStatusResult, len(args.Entities)),
+	}
+	if err := em.authStateServerAdmin(); err != nil {
+		return results, errors.Trace(err)
+	}
+	for i, entity := range args.Entities {
+		status, err := em.environStatus(entity.Tag)
+		if err != nil {
+			results.Results[i].Error = common.ServerError(err)
+			continue
+		}
+		results.Results[i].Status = status
+	}
+	return results, nil
+}
+
+func (em *EnvironmentManagerAPIV2) environStatus(tag string) (*params.EnvironmentStatus, error) {
+	st, err := em.environState(tag)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer st.Close()
+
+	env, err := st.Environment()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	machines, err := st.AllMachines()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	services, err := st.AllServices()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var unitCount int
+	for _, svc := range services {
+		units, err := svc.AllUnits()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		unitCount += len(units)
+	}
+	status := &params.EnvironmentStatus{
+		EnvironTag:   env.Tag().String(),
+		Name:         env.Name(),
+		OwnerTag:     env.Owner().String(),
+		Life:         params.Life(env.Life().String()),
+		MachineCount: len(machines),
+		UnitCount:    unitCount,
+	}
+
+	// The environment's last activity is the most recent connection
+	// made to it by any of its users.
+	users, err := env.Users()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, user := range users {
+		when := user.LastConnection()
+		if when != nil && (status.LastActivity == nil || when.After(*status.LastActivity)) {
+			status.LastActivity = when
+		}
+	}
+	return status, nil
+}
+
+// DestroyEnvironment destroys the specified hosted environment, along
+// with its services and machine instances. Only the state server
+// administrator may destroy other users' environments this way; the
+// state server environment itself must be destroyed from the client.
+func (em *EnvironmentManagerAPIV2) DestroyEnvironment(env params.Entity) error {
+	st, err := em.environState(env.Tag)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer st.Close()
+
+	stateServerEnv, err := em.state.StateServerEnvironment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if st.EnvironUUID() == stateServerEnv.UUID() {
+		return errors.New("cannot destroy the state server environment")
+	}
+	return errors.Trace(common.DestroyEnvironment(st))
+}
+
+// BlockEnvironment switches on a block of the given type in the
+// specified environment.
+func (em *EnvironmentManagerAPIV2) BlockEnvironment(args params.EnvironmentBlockArgs) error {
+	blockType, err := parseBlockType(args.Type)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	st, err := em.environState(args.EnvironTag)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer st.Close()
+	return errors.Trace(st.SwitchBlockOn(blockType, args.Message))
+}
+
+// UnblockEnvironment switches off a block of the given type in the
+// specified environment.
+func (em *EnvironmentManagerAPIV2) UnblockEnvironment(args params.EnvironmentBlockArgs) error {
+	blockType, err := parseBlockType(args.Type)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	st, err := em.environState(args.EnvironTag)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer st.Close()
+	return errors.Trace(st.SwitchBlockOff(blockType))
+}
+
+// parseBlockType returns the block type with the given name. Unlike
+// state.ParseBlockType, it returns an error for unknown names.
+func parseBlockType(name string) (state.BlockType, error) {
+	for _, t := range state.AllTypes() {
+		if t.String() == name {
+			return t, nil
+		}
+	}
+	return 0, errors.NotValidf("block type %q", name)
+}
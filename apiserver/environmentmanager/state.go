@@ -18,6 +18,10 @@ type stateInterface interface {
 	StateServerEnvironment() (*state.Environment, error)
 	NewEnvironment(*config.Config, names.UserTag) (*state.Environment, *state.State, error)
 	EnvironmentsForUser(names.UserTag) ([]*state.Environment, error)
+	AllEnvironments() ([]*state.Environment, error)
+	ForEnviron(names.EnvironTag) (*state.State, error)
+	ValidateEnvironmentImport(*state.EnvironmentDocs) error
+	ImportEnvironmentDocs(*state.EnvironmentDocs) (*state.Environment, *state.State, error)
 }
 
 type stateShim struct {
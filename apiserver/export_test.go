@@ -91,6 +91,13 @@ func TestingUpgradingRoot(st *state.State) rpc.MethodFinder {
 	return newUpgradingRoot(r)
 }
 
+// TestingMigratingRoot returns a migratingRoot which reports that
+// the environment is being migrated if migrating is true.
+func TestingMigratingRoot(st *state.State, migrating bool) rpc.MethodFinder {
+	r := TestingApiRoot(st)
+	return newMigratingRoot(r, func() (bool, error) { return migrating, nil })
+}
+
 // TestingRestrictedApiHandler returns a restricted srvRoot as if accessed
 // from the root of the API path with a recent (verison > 1) login.
 func TestingRestrictedApiHandler(st *state.State) rpc.MethodFinder {
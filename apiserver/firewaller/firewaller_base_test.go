@@ -365,6 +365,46 @@ func (s *firewallerBaseSuite) testGetExposed(
 	})
 }
 
+func (s *firewallerBaseSuite) testGetExposedCIDRs(
+	c *gc.C,
+	facade interface {
+		GetExposedCIDRs(args params.Entities) (params.StringsResults, error)
+	},
+) {
+	err := s.service.SetExposedCIDRs([]string{"10.0.0.0/8"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	args := addFakeEntities(params.Entities{Entities: []params.Entity{
+		{Tag: s.service.Tag().String()},
+	}})
+	result, err := facade.GetExposedCIDRs(args)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, params.StringsResults{
+		Results: []params.StringsResult{
+			{Result: []string{"10.0.0.0/8"}},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.NotFoundError(`service "bar"`)},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	// Exposing the service to any address lifts the restriction.
+	err = s.service.SetExposed()
+	c.Assert(err, jc.ErrorIsNil)
+
+	args = params.Entities{Entities: []params.Entity{
+		{Tag: s.service.Tag().String()},
+	}}
+	result, err = facade.GetExposedCIDRs(args)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, params.StringsResults{
+		Results: []params.StringsResult{{}},
+	})
+}
+
 func (s *firewallerBaseSuite) testGetAssignedMachine(
 	c *gc.C,
 	facade interface {
@@ -79,6 +79,13 @@ func (s *firewallerSuite) TestOpenedPortsNotImplemented(c *gc.C) {
 	apiservertesting.AssertNotImplemented(c, s.firewaller, "OpenedPorts")
 }
 
+func (s *firewallerSuite) TestSourceAddressesNotImplemented(c *gc.C) {
+	// Source address restrictions arrived in version 2.
+	apiservertesting.AssertNotImplemented(c, s.firewaller, "GetExposedCIDRs")
+	apiservertesting.AssertNotImplemented(c, s.firewaller, "WatchRelatedAddresses")
+	apiservertesting.AssertNotImplemented(c, s.firewaller, "GetRelatedCIDRs")
+}
+
 func (s *firewallerSuite) TestGetAssignedMachine(c *gc.C) {
 	s.testGetAssignedMachine(c, s.firewaller)
 }
@@ -0,0 +1,160 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package firewaller
+
+import (
+	"net"
+
+	"github.com/juju/names"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/watcher"
+)
+
+func init() {
+	common.RegisterStandardFacade("Firewaller", 2, NewFirewallerAPIV2)
+}
+
+// FirewallerAPIV2 implements version 2 of the Firewaller API, which
+// adds access to the source addresses a service's ports are opened to,
+// and to the trace ids of the requests that changed services.
+type FirewallerAPIV2 struct {
+	FirewallerAPI
+}
+
+// NewFirewallerAPIV2 creates a new server-side Firewaller API facade,
+// version 2.
+func NewFirewallerAPIV2(
+	st *state.State,
+	resources *common.Resources,
+	authorizer common.Authorizer,
+) (*FirewallerAPIV2, error) {
+	baseAPI, err := NewFirewallerAPI(st, resources, authorizer)
+	if err != nil {
+		return nil, err
+	}
+	return &FirewallerAPIV2{*baseAPI}, nil
+}
+
+// GetExposedCIDRs returns the CIDRs to which access is restricted for
+// each given exposed service. An empty result means access is not
+// restricted.
+func (f *FirewallerAPIV2) GetExposedCIDRs(args params.Entities) (params.StringsResults, error) {
+	result := params.StringsResults{
+		Results: make([]params.StringsResult, len(args.Entities)),
+	}
+	canAccess, err := f.accessService()
+	if err != nil {
+		return params.StringsResults{}, err
+	}
+	for i, entity := range args.Entities {
+		tag, err := names.ParseServiceTag(entity.Tag)
+		if err != nil {
+			result.Results[i].Error = common.ServerError(common.ErrPerm)
+			continue
+		}
+		service, err := f.getService(canAccess, tag)
+		if err == nil {
+			result.Results[i].Result = service.ExposedCIDRs()
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// WatchRelatedAddresses returns a NotifyWatcher, for each given service,
+// that notifies of changes to the result of GetRelatedCIDRs.
+func (f *FirewallerAPIV2) WatchRelatedAddresses(args params.Entities) (params.NotifyWatchResults, error) {
+	result := params.NotifyWatchResults{
+		Results: make([]params.NotifyWatchResult, len(args.Entities)),
+	}
+	canAccess, err := f.accessService()
+	if err != nil {
+		return params.NotifyWatchResults{}, err
+	}
+	for i, entity := range args.Entities {
+		tag, err := names.ParseServiceTag(entity.Tag)
+		if err != nil {
+			result.Results[i].Error = common.ServerError(common.ErrPerm)
+			continue
+		}
+		service, err := f.getService(canAccess, tag)
+		if err == nil {
+			watch := service.WatchRelatedAddresses()
+			// Consume the initial event.
+			if _, ok := <-watch.Changes(); ok {
+				result.Results[i].NotifyWatcherId = f.resources.Register(watch)
+			} else {
+				err = watcher.EnsureErr(watch)
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// GetRelatedCIDRs returns, for each given service, the CIDRs covering
+// the private addresses of the units of all services related to it,
+// to which the service's opened ports should be accessible.
+func (f *FirewallerAPIV2) GetRelatedCIDRs(args params.Entities) (params.StringsResults, error) {
+	result := params.StringsResults{
+		Results: make([]params.StringsResult, len(args.Entities)),
+	}
+	canAccess, err := f.accessService()
+	if err != nil {
+		return params.StringsResults{}, err
+	}
+	for i, entity := range args.Entities {
+		tag, err := names.ParseServiceTag(entity.Tag)
+		if err != nil {
+			result.Results[i].Error = common.ServerError(common.ErrPerm)
+			continue
+		}
+		service, err := f.getService(canAccess, tag)
+		if err == nil {
+			var addresses []string
+			addresses, err = service.RelatedPrivateAddresses()
+			for _, address := range addresses {
+				result.Results[i].Result = append(result.Results[i].Result, hostCIDR(address))
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// GetTraceIds returns, for each given service, the trace id of the
+// last traced API request that changed it, if any.
+func (f *FirewallerAPIV2) GetTraceIds(args params.Entities) (params.StringResults, error) {
+	result := params.StringResults{
+		Results: make([]params.StringResult, len(args.Entities)),
+	}
+	canAccess, err := f.accessService()
+	if err != nil {
+		return params.StringResults{}, err
+	}
+	for i, entity := range args.Entities {
+		tag, err := names.ParseServiceTag(entity.Tag)
+		if err != nil {
+			result.Results[i].Error = common.ServerError(common.ErrPerm)
+			continue
+		}
+		service, err := f.getService(canAccess, tag)
+		if err == nil {
+			result.Results[i].Result = service.TraceId()
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// hostCIDR returns the CIDR covering just the given address.
+func hostCIDR(address string) string {
+	if ip := net.ParseIP(address); ip != nil && ip.To4() == nil {
+		return address + "/128"
+	}
+	return address + "/32"
+}
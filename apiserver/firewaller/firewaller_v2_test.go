@@ -0,0 +1,153 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package firewaller_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/firewaller"
+	"github.com/juju/juju/apiserver/params"
+	apiservertesting "github.com/juju/juju/apiserver/testing"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state"
+	statetesting "github.com/juju/juju/state/testing"
+)
+
+type firewallerV2Suite struct {
+	firewallerBaseSuite
+
+	firewaller *firewaller.FirewallerAPIV2
+}
+
+var _ = gc.Suite(&firewallerV2Suite{})
+
+func (s *firewallerV2Suite) SetUpTest(c *gc.C) {
+	s.firewallerBaseSuite.setUpTest(c)
+
+	firewallerAPI, err := firewaller.NewFirewallerAPIV2(
+		s.State,
+		s.resources,
+		s.authorizer,
+	)
+	c.Assert(err, jc.ErrorIsNil)
+	s.firewaller = firewallerAPI
+}
+
+func (s *firewallerV2Suite) TestFirewallerFailsWithNonEnvironManagerUser(c *gc.C) {
+	constructor := func(st *state.State, res *common.Resources, auth common.Authorizer) error {
+		_, err := firewaller.NewFirewallerAPIV2(st, res, auth)
+		return err
+	}
+	s.testFirewallerFailsWithNonEnvironManagerUser(c, constructor)
+}
+
+func (s *firewallerV2Suite) TestGetExposed(c *gc.C) {
+	s.testGetExposed(c, s.firewaller)
+}
+
+func (s *firewallerV2Suite) TestGetExposedCIDRs(c *gc.C) {
+	s.testGetExposedCIDRs(c, s.firewaller)
+}
+
+func (s *firewallerV2Suite) TestWatchRelatedAddresses(c *gc.C) {
+	c.Assert(s.resources.Count(), gc.Equals, 0)
+
+	args := addFakeEntities(params.Entities{Entities: []params.Entity{
+		{Tag: s.service.Tag().String()},
+	}})
+	result, err := s.firewaller.WatchRelatedAddresses(args)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, params.NotifyWatchResults{
+		Results: []params.NotifyWatchResult{
+			{NotifyWatcherId: "1"},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.NotFoundError(`service "bar"`)},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	// Verify the resource was registered and stop when done
+	c.Assert(s.resources.Count(), gc.Equals, 1)
+	resource := s.resources.Get("1")
+	defer statetesting.AssertStop(c, resource)
+
+	// Check that the Watch has consumed the initial event ("returned" in
+	// the Watch call)
+	wc := statetesting.NewNotifyWatcherC(c, s.State, resource.(state.NotifyWatcher))
+	wc.AssertNoChange()
+}
+
+func (s *firewallerV2Suite) TestGetRelatedCIDRs(c *gc.C) {
+	// Give the wordpress units' machines addresses; the last one
+	// has only a machine-local address, which is not used.
+	err := s.machines[0].SetAddresses(network.NewScopedAddress("10.0.0.1", network.ScopeCloudLocal))
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.machines[1].SetAddresses(network.NewScopedAddress("2001:db8::1", network.ScopeCloudLocal))
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.machines[2].SetAddresses(network.NewScopedAddress("127.0.0.1", network.ScopeMachineLocal))
+	c.Assert(err, jc.ErrorIsNil)
+
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	args := addFakeEntities(params.Entities{Entities: []params.Entity{
+		{Tag: mysql.Tag().String()},
+	}})
+	result, err := s.firewaller.GetRelatedCIDRs(args)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Results[0], jc.DeepEquals, params.StringsResult{})
+
+	eps, err := s.State.InferEndpoints("wordpress", "mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AddRelation(eps...)
+	c.Assert(err, jc.ErrorIsNil)
+
+	result, err = s.firewaller.GetRelatedCIDRs(args)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, params.StringsResults{
+		Results: []params.StringsResult{
+			{Result: []string{"10.0.0.1/32", "2001:db8::1/128"}},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.NotFoundError(`service "bar"`)},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+}
+
+func (s *firewallerV2Suite) TestGetTraceIds(c *gc.C) {
+	args := addFakeEntities(params.Entities{Entities: []params.Entity{
+		{Tag: s.service.Tag().String()},
+	}})
+	expectErrors := []params.StringResult{
+		{Error: apiservertesting.ErrUnauthorized},
+		{Error: apiservertesting.ErrUnauthorized},
+		{Error: apiservertesting.NotFoundError(`service "bar"`)},
+		{Error: apiservertesting.ErrUnauthorized},
+		{Error: apiservertesting.ErrUnauthorized},
+		{Error: apiservertesting.ErrUnauthorized},
+	}
+
+	result, err := s.firewaller.GetTraceIds(args)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, params.StringResults{
+		Results: append([]params.StringResult{{Result: ""}}, expectErrors...),
+	})
+
+	service, err := s.State.WithTraceId("expose-1").Service(s.service.Name())
+	c.Assert(err, jc.ErrorIsNil)
+	err = service.SetExposed()
+	c.Assert(err, jc.ErrorIsNil)
+
+	result, err = s.firewaller.GetTraceIds(args)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, params.StringResults{
+		Results: append([]params.StringResult{{Result: "expose-1"}}, expectErrors...),
+	})
+}
@@ -72,32 +72,42 @@ func (h *httpHandler) validateEnvironUUID(r *http.Request) (*httpStateWrapper, e
 // authenticate parses HTTP basic authentication and authorizes the
 // request by looking up the provided tag and password against state.
 func (h *httpStateWrapper) authenticate(r *http.Request) (names.Tag, error) {
+	req, err := parseBasicAuth(r)
+	if err != nil {
+		return nil, err
+	}
+	// Ensure that a sensible tag was passed.
+	tag, err := names.ParseTag(req.AuthTag)
+	if err != nil {
+		return nil, common.ErrBadCreds
+	}
+	_, _, err = checkCreds(h.state, req, true)
+	return tag, err
+}
+
+// parseBasicAuth returns the login request held in the
+// HTTP basic authentication header of the given request.
+func parseBasicAuth(r *http.Request) (params.LoginRequest, error) {
 	parts := strings.Fields(r.Header.Get("Authorization"))
 	if len(parts) != 2 || parts[0] != "Basic" {
 		// Invalid header format or no header provided.
-		return nil, errors.New("invalid request format")
+		return params.LoginRequest{}, errors.New("invalid request format")
 	}
 	// Challenge is a base64-encoded "tag:pass" string.
 	// See RFC 2617, Section 2.
 	challenge, err := base64.StdEncoding.DecodeString(parts[1])
 	if err != nil {
-		return nil, errors.New("invalid request format")
+		return params.LoginRequest{}, errors.New("invalid request format")
 	}
 	tagPass := strings.SplitN(string(challenge), ":", 2)
 	if len(tagPass) != 2 {
-		return nil, errors.New("invalid request format")
+		return params.LoginRequest{}, errors.New("invalid request format")
 	}
-	// Ensure that a sensible tag was passed.
-	tag, err := names.ParseTag(tagPass[0])
-	if err != nil {
-		return nil, common.ErrBadCreds
-	}
-	_, _, err = checkCreds(h.state, params.LoginRequest{
+	return params.LoginRequest{
 		AuthTag:     tagPass[0],
 		Credentials: tagPass[1],
 		Nonce:       r.Header.Get("X-Juju-Nonce"),
-	}, true)
-	return tag, err
+	}, nil
 }
 
 func (h *httpStateWrapper) authenticateUser(r *http.Request) error {
@@ -95,7 +95,7 @@ func (s *loggerSuite) TestWatchLoggingConfig(c *gc.C) {
 	wc := statetesting.NewNotifyWatcherC(c, s.State, w)
 	wc.AssertNoChange()
 
-	newLoggingConfig := "<root>=WARN;juju.log.test=DEBUG;unit=INFO"
+	newLoggingConfig := "<root>=WARN;juju.log.test=DEBUG;unit=INFO;juju.apiserver.trace=INFO"
 	s.setLoggingConfig(c, newLoggingConfig)
 
 	wc.AssertOneChange()
@@ -132,7 +132,7 @@ func (s *loggerSuite) TestLoggingConfigRefusesWrongAgent(c *gc.C) {
 }
 
 func (s *loggerSuite) TestLoggingConfigForAgent(c *gc.C) {
-	newLoggingConfig := "<root>=WARN;juju.log.test=DEBUG;unit=INFO"
+	newLoggingConfig := "<root>=WARN;juju.log.test=DEBUG;unit=INFO;juju.apiserver.trace=INFO"
 	s.setLoggingConfig(c, newLoggingConfig)
 
 	args := params.Entities{
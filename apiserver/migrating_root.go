@@ -0,0 +1,68 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"strings"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/rpc"
+	"github.com/juju/juju/rpc/rpcreflect"
+	"github.com/juju/juju/state"
+)
+
+// migratingRoot restricts the API calls of agents while their
+// environment is being migrated between controllers, so that no
+// change they make is lost when the environment is exported.
+type migratingRoot struct {
+	rpc.MethodFinder
+	migrating func() (bool, error)
+}
+
+// newMigratingRoot returns a new migratingRoot, which calls migrating
+// to learn whether the environment is being migrated.
+func newMigratingRoot(finder rpc.MethodFinder, migrating func() (bool, error)) *migratingRoot {
+	return &migratingRoot{finder, migrating}
+}
+
+// environMigrating returns a function reporting whether the
+// environment of st is being migrated.
+func environMigrating(st *state.State) func() (bool, error) {
+	return func() (bool, error) {
+		env, err := st.Environment()
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		return env.MigrationMode() != state.MigrationModeNone, nil
+	}
+}
+
+var inMigrationError = errors.New("environment migration in progress - agent functionality is limited")
+
+// IsRootAllowedDuringMigration returns whether agents may use the
+// named facade while their environment is being migrated. The
+// MigrationMinion facade, and the watchers it uses, are needed for
+// agents to follow their environment to its new controller.
+func IsRootAllowedDuringMigration(rootName string) bool {
+	return rootName == "MigrationMinion" || rootName == "Pinger" || strings.HasSuffix(rootName, "Watcher")
+}
+
+// FindMethod returns inMigrationError for API calls that may change
+// the environment, if it is being migrated.
+func (r *migratingRoot) FindMethod(rootName string, version int, methodName string) (rpcreflect.MethodCaller, error) {
+	caller, err := r.MethodFinder.FindMethod(rootName, version, methodName)
+	if err != nil {
+		return nil, err
+	}
+	if IsRootAllowedDuringMigration(rootName) {
+		return caller, nil
+	}
+	if migrating, err := r.migrating(); err != nil {
+		return nil, errors.Annotate(err, "cannot check for environment migration")
+	} else if migrating {
+		return nil, inMigrationError
+	}
+	return caller, nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver"
+	"github.com/juju/juju/testing"
+)
+
+type migratingRootSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&migratingRootSuite{})
+
+func (r *migratingRootSuite) TestFindAllowedMethod(c *gc.C) {
+	root := apiserver.TestingMigratingRoot(nil, true)
+
+	caller, err := root.FindMethod("MigrationMinion", 1, "Watch")
+
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(caller, gc.NotNil)
+}
+
+func (r *migratingRootSuite) TestFindAllowedWatcherMethod(c *gc.C) {
+	root := apiserver.TestingMigratingRoot(nil, true)
+
+	caller, err := root.FindMethod("NotifyWatcher", 0, "Next")
+
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(caller, gc.NotNil)
+}
+
+func (r *migratingRootSuite) TestFindDisallowedMethod(c *gc.C) {
+	root := apiserver.TestingMigratingRoot(nil, true)
+
+	caller, err := root.FindMethod("Uniter", 3, "SetStatus")
+
+	c.Assert(err, gc.ErrorMatches, "environment migration in progress - agent functionality is limited")
+	c.Assert(caller, gc.IsNil)
+}
+
+func (r *migratingRootSuite) TestFindMethodNotMigrating(c *gc.C) {
+	root := apiserver.TestingMigratingRoot(nil, false)
+
+	caller, err := root.FindMethod("Uniter", 3, "SetStatus")
+
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(caller, gc.NotNil)
+}
+
+func (r *migratingRootSuite) TestFindNonExistentMethod(c *gc.C) {
+	root := apiserver.TestingMigratingRoot(nil, true)
+
+	caller, err := root.FindMethod("Foo", 0, "Bar")
+
+	c.Assert(err, gc.ErrorMatches, "unknown object type \"Foo\"")
+	c.Assert(caller, gc.IsNil)
+}
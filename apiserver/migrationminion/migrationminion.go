@@ -0,0 +1,89 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package migrationminion defines the API facade used by machine and
+// unit agents to learn that their environment has been moved to
+// another controller.
+package migrationminion
+
+import (
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/watcher"
+)
+
+func init() {
+	common.RegisterStandardFacade("MigrationMinion", 1, NewAPI)
+}
+
+// API implements the MigrationMinion facade.
+type API struct {
+	st         *state.State
+	resources  *common.Resources
+	authorizer common.Authorizer
+}
+
+// NewAPI creates a new server-side MigrationMinion facade.
+func NewAPI(st *state.State, resources *common.Resources, authorizer common.Authorizer) (*API, error) {
+	if !authorizer.AuthMachineAgent() && !authorizer.AuthUnitAgent() {
+		return nil, common.ErrPerm
+	}
+	return &API{
+		st:         st,
+		resources:  resources,
+		authorizer: authorizer,
+	}, nil
+}
+
+// Watch starts a watcher which notifies when the agent's environment
+// changes, as when it is given a migration target.
+func (api *API) Watch() (params.NotifyWatchResult, error) {
+	var result params.NotifyWatchResult
+	env, err := api.st.Environment()
+	if err != nil {
+		return result, errors.Trace(err)
+	}
+	w := env.Watch()
+	// Consume the initial event; NotifyWatchers have no state to
+	// transmit in the Watch response.
+	if _, ok := <-w.Changes(); ok {
+		result.NotifyWatcherId = api.resources.Register(w)
+	} else {
+		result.Error = common.ServerError(watcher.EnsureErr(w))
+	}
+	return result, nil
+}
+
+// Target returns the controller to which the agent's environment is
+// being moved, if any.
+func (api *API) Target() (params.MigrationTargetResult, error) {
+	var result params.MigrationTargetResult
+	env, err := api.st.Environment()
+	if err != nil {
+		return result, errors.Trace(err)
+	}
+	if target, ok := env.MigrationTarget(); ok {
+		result.Target = &params.MigrationTarget{
+			APIAddrs: target.APIAddrs,
+			CACert:   target.CACert,
+		}
+	}
+	return result, nil
+}
+
+// Ack records that the agent has been told of its environment's
+// migration target.
+func (api *API) Ack() (params.ErrorResult, error) {
+	var result params.ErrorResult
+	env, err := api.st.Environment()
+	if err != nil {
+		return result, errors.Trace(err)
+	}
+	if err := env.AckMigration(api.authorizer.GetAuthTag()); err != nil {
+		result.Error = common.ServerError(err)
+	}
+	return result, nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package migrationminion_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/migrationminion"
+	"github.com/juju/juju/apiserver/params"
+	apiservertesting "github.com/juju/juju/apiserver/testing"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	statetesting "github.com/juju/juju/state/testing"
+	"github.com/juju/juju/testing/factory"
+)
+
+type minionSuite struct {
+	jujutesting.JujuConnSuite
+
+	envState   *state.State
+	machine    *state.Machine
+	resources  *common.Resources
+	authorizer apiservertesting.FakeAuthorizer
+	api        *migrationminion.API
+}
+
+var _ = gc.Suite(&minionSuite{})
+
+func (s *minionSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.envState = s.Factory.MakeEnvironment(c, &factory.EnvParams{Name: "hosted"})
+	s.AddCleanup(func(*gc.C) { s.envState.Close() })
+	s.machine = factory.NewFactory(s.envState).MakeMachine(c, nil)
+
+	s.resources = common.NewResources()
+	s.AddCleanup(func(*gc.C) { s.resources.StopAll() })
+	s.authorizer = apiservertesting.FakeAuthorizer{Tag: s.machine.Tag()}
+	var err error
+	s.api, err = migrationminion.NewAPI(s.envState, s.resources, s.authorizer)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *minionSuite) setTarget(c *gc.C) {
+	env, err := s.envState.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	err = env.SetMigrationTarget(state.MigrationTarget{
+		APIAddrs: []string{"10.0.0.1:17070"},
+		CACert:   "cert",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *minionSuite) TestNewAPIRefusesClient(c *gc.C) {
+	authorizer := s.authorizer
+	authorizer.Tag = s.AdminUserTag(c)
+	api, err := migrationminion.NewAPI(s.envState, s.resources, authorizer)
+	c.Assert(api, gc.IsNil)
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
+
+func (s *minionSuite) TestWatch(c *gc.C) {
+	result, err := s.api.Watch()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Error, gc.IsNil)
+	c.Assert(s.resources.Count(), gc.Equals, 1)
+
+	w := s.resources.Get(result.NotifyWatcherId).(state.NotifyWatcher)
+	wc := statetesting.NewNotifyWatcherC(c, s.envState, w)
+	wc.AssertNoChange()
+	s.setTarget(c)
+	wc.AssertOneChange()
+}
+
+func (s *minionSuite) TestTarget(c *gc.C) {
+	result, err := s.api.Target()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, params.MigrationTargetResult{})
+
+	s.setTarget(c)
+	result, err = s.api.Target()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Target, jc.DeepEquals, &params.MigrationTarget{
+		APIAddrs: []string{"10.0.0.1:17070"},
+		CACert:   "cert",
+	})
+}
+
+func (s *minionSuite) TestAck(c *gc.C) {
+	result, err := s.api.Ack()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Error, gc.ErrorMatches, `environment "hosted" is not being migrated`)
+
+	s.setTarget(c)
+	result, err = s.api.Ack()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Error, gc.IsNil)
+	pending, err := s.envState.PendingMigrationAgents()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(pending, gc.HasLen, 0)
+}
@@ -0,0 +1,206 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The offers package implements the Offers facade, which publishes
+// services' endpoints to the other environments on the controller,
+// and consumes the offers of other environments as remote services.
+package offers
+
+import (
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"gopkg.in/juju/charm.v5-unstable"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+)
+
+func init() {
+	common.RegisterStandardFacade("Offers", 1, NewOffersAPI)
+}
+
+// Offers defines the methods on the Offers API end point.
+type Offers interface {
+	Offer(args params.AddServiceOffersParams) (params.ErrorResults, error)
+	ListOffers() (params.ListServiceOffersResults, error)
+	Consume(args params.ConsumeServiceOffersParams) (params.ErrorResults, error)
+}
+
+// OffersAPI implements the Offers interface and is the concrete
+// implementation of the api end point.
+type OffersAPI struct {
+	st         *state.State
+	authorizer common.Authorizer
+	check      *common.BlockChecker
+}
+
+var _ Offers = (*OffersAPI)(nil)
+
+// NewOffersAPI creates a new server-side Offers API end point.
+func NewOffersAPI(st *state.State, resources *common.Resources, authorizer common.Authorizer) (*OffersAPI, error) {
+	if !authorizer.AuthClient() {
+		return nil, common.ErrPerm
+	}
+	return &OffersAPI{
+		st:         st,
+		authorizer: authorizer,
+		check:      common.NewBlockChecker(st),
+	}, nil
+}
+
+// Offer publishes the given endpoints of services so that they may be
+// consumed by other environments.
+func (api *OffersAPI) Offer(args params.AddServiceOffersParams) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Offers)),
+	}
+	if err := api.check.ChangeAllowed(); err != nil {
+		return result, errors.Trace(err)
+	}
+	for i, arg := range args.Offers {
+		_, err := api.st.AddServiceOffer(state.AddServiceOfferArgs{
+			Name:        arg.OfferName,
+			ServiceName: arg.ServiceName,
+			Endpoints:   arg.Endpoints,
+			Description: arg.Description,
+		})
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// ListOffers returns all the offers in the environment.
+func (api *OffersAPI) ListOffers() (params.ListServiceOffersResults, error) {
+	env, err := api.st.Environment()
+	if err != nil {
+		return params.ListServiceOffersResults{}, errors.Trace(err)
+	}
+	offers, err := api.st.AllServiceOffers()
+	if err != nil {
+		return params.ListServiceOffersResults{}, errors.Trace(err)
+	}
+	result := params.ListServiceOffersResults{
+		Results: make([]params.ServiceOffer, len(offers)),
+	}
+	for i, offer := range offers {
+		eps, err := offer.Endpoints()
+		if err != nil {
+			return params.ListServiceOffersResults{}, errors.Trace(err)
+		}
+		epNames := make([]string, len(eps))
+		for j, ep := range eps {
+			epNames[j] = ep.Name
+		}
+		result.Results[i] = params.ServiceOffer{
+			OfferURL:    offerURL(env.Owner(), env.Name(), offer.Name()),
+			EnvironName: env.Name(),
+			OfferName:   offer.Name(),
+			ServiceName: offer.ServiceName(),
+			Endpoints:   epNames,
+			Description: offer.Description(),
+		}
+	}
+	return result, nil
+}
+
+// Consume adds a remote service for each of the given offers, which
+// may be related to the environment's services. Only the offers of
+// environments which the authenticated user may access can be
+// consumed.
+func (api *OffersAPI) Consume(args params.ConsumeServiceOffersParams) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Offers)),
+	}
+	if err := api.check.ChangeAllowed(); err != nil {
+		return result, errors.Trace(err)
+	}
+	for i, arg := range args.Offers {
+		err := api.consume(arg)
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+func (api *OffersAPI) consume(arg params.ConsumeServiceOfferParams) error {
+	user, ok := api.authorizer.GetAuthTag().(names.UserTag)
+	if !ok {
+		return common.ErrPerm
+	}
+	owner, envName, offerName, err := parseOfferURL(arg.OfferURL, user)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	sourceSt, err := api.environ(user, owner, envName)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer sourceSt.Close()
+
+	offer, err := sourceSt.ServiceOffer(offerName)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	eps, err := offer.Endpoints()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	relations := make([]charm.Relation, len(eps))
+	for i, ep := range eps {
+		relations[i] = ep.Relation
+	}
+	serviceName := arg.ServiceName
+	if serviceName == "" {
+		serviceName = offerName
+	}
+	_, err = api.st.AddRemoteService(state.AddRemoteServiceArgs{
+		Name:          serviceName,
+		SourceEnvUUID: sourceSt.EnvironUUID(),
+		OfferName:     offerName,
+		Endpoints:     relations,
+	})
+	return errors.Trace(err)
+}
+
+// offerURL returns the URL by which other environments refer to the
+// named offer of the given owner's named environment.
+func offerURL(owner names.UserTag, envName, offerName string) string {
+	return owner.Username() + "/" + envName + "." + offerName
+}
+
+// parseOfferURL returns the owner, environment name and offer name in
+// an offer URL of the form [<owner>/]<environment>.<offer>. Offers
+// of environments owned by the given user need not name the owner.
+func parseOfferURL(url string, user names.UserTag) (owner names.UserTag, envName, offerName string, err error) {
+	owner = user
+	rest := url
+	if i := strings.Index(url, "/"); i != -1 {
+		if !names.IsValidUser(url[:i]) {
+			return owner, "", "", errors.NotValidf("offer URL %q", url)
+		}
+		owner, rest = names.NewUserTag(url[:i]), url[i+1:]
+	}
+	i := strings.LastIndex(rest, ".")
+	if i <= 0 || i == len(rest)-1 {
+		return owner, "", "", errors.NotValidf("offer URL %q", url)
+	}
+	return owner, rest[:i], rest[i+1:], nil
+}
+
+// environ returns a State for the given owner's named environment,
+// provided the given user has access to it. Environment names are
+// only unique for each owner.
+func (api *OffersAPI) environ(user, owner names.UserTag, name string) (*state.State, error) {
+	envs, err := api.st.EnvironmentsForUser(user)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, env := range envs {
+		if env.Name() == name && env.Owner().Username() == owner.Username() {
+			return api.st.ForEnviron(env.EnvironTag())
+		}
+	}
+	return nil, errors.NotFoundf("environment %q owned by %q", name, owner.Username())
+}
@@ -0,0 +1,160 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package offers_test
+
+import (
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	commontesting "github.com/juju/juju/apiserver/common/testing"
+	"github.com/juju/juju/apiserver/offers"
+	"github.com/juju/juju/apiserver/params"
+	apiservertesting "github.com/juju/juju/apiserver/testing"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/testing/factory"
+)
+
+type offersSuite struct {
+	jujutesting.JujuConnSuite
+	commontesting.BlockHelper
+
+	api        *offers.OffersAPI
+	authorizer apiservertesting.FakeAuthorizer
+}
+
+var _ = gc.Suite(&offersSuite{})
+
+func (s *offersSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.authorizer = apiservertesting.FakeAuthorizer{
+		Tag: s.AdminUserTag(c),
+	}
+	var err error
+	s.api, err = offers.NewOffersAPI(s.State, nil, s.authorizer)
+	c.Assert(err, jc.ErrorIsNil)
+
+	s.BlockHelper = commontesting.NewBlockHelper(s.APIState)
+	s.AddCleanup(func(*gc.C) { s.BlockHelper.Close() })
+}
+
+func (s *offersSuite) TestNewOffersAPIRefusesNonClient(c *gc.C) {
+	anAuthorizer := s.authorizer
+	anAuthorizer.Tag = names.NewMachineTag("1")
+	api, err := offers.NewOffersAPI(s.State, nil, anAuthorizer)
+	c.Assert(api, gc.IsNil)
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
+
+func (s *offersSuite) TestOfferAndListOffers(c *gc.C) {
+	s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	result, err := s.api.Offer(params.AddServiceOffersParams{
+		Offers: []params.AddServiceOfferParams{
+			{OfferName: "db", ServiceName: "mysql", Description: "shared"},
+			{ServiceName: "wordpress"},
+		},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Results, gc.HasLen, 2)
+	c.Assert(result.Results[0].Error, gc.IsNil)
+	c.Assert(result.Results[1].Error, gc.ErrorMatches, `cannot add offer "wordpress": service "wordpress" not found`)
+
+	list, err := s.api.ListOffers()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(list, jc.DeepEquals, params.ListServiceOffersResults{
+		Results: []params.ServiceOffer{{
+			OfferURL:    "admin@local/dummyenv.db",
+			EnvironName: "dummyenv",
+			OfferName:   "db",
+			ServiceName: "mysql",
+			Endpoints:   []string{"server"},
+			Description: "shared",
+		}},
+	})
+}
+
+func (s *offersSuite) TestBlockOffer(c *gc.C) {
+	s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	s.BlockAllChanges(c, "TestBlockOffer")
+	_, err := s.api.Offer(params.AddServiceOffersParams{
+		Offers: []params.AddServiceOfferParams{{ServiceName: "mysql"}},
+	})
+	s.AssertBlocked(c, err, "TestBlockOffer")
+}
+
+func (s *offersSuite) TestConsume(c *gc.C) {
+	sourceSt := s.Factory.MakeEnvironment(c, &factory.EnvParams{Name: "shared"})
+	defer sourceSt.Close()
+	sourceFactory := factory.NewFactory(sourceSt)
+	sourceFactory.MakeService(c, &factory.ServiceParams{
+		Name:  "mysql",
+		Charm: sourceFactory.MakeCharm(c, &factory.CharmParams{Name: "mysql"}),
+	})
+	_, err := sourceSt.AddServiceOffer(state.AddServiceOfferArgs{Name: "db", ServiceName: "mysql"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	result, err := s.api.Consume(params.ConsumeServiceOffersParams{
+		Offers: []params.ConsumeServiceOfferParams{
+			{OfferURL: "admin@local/shared.db", ServiceName: "shareddb"},
+			{OfferURL: "shared.nope"},
+			{OfferURL: "elsewhere.db"},
+			{OfferURL: "bob@local/shared.db"},
+			{OfferURL: "db"},
+			{OfferURL: "no body/shared.db"},
+		},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Results, gc.HasLen, 6)
+	c.Assert(result.Results[0].Error, gc.IsNil)
+	c.Assert(result.Results[1].Error, gc.ErrorMatches, `offer "nope" not found`)
+	c.Assert(result.Results[2].Error, gc.ErrorMatches, `environment "elsewhere" owned by "admin@local" not found`)
+	c.Assert(result.Results[3].Error, gc.ErrorMatches, `environment "shared" owned by "bob@local" not found`)
+	c.Assert(result.Results[4].Error, gc.ErrorMatches, `offer URL "db" not valid`)
+	c.Assert(result.Results[5].Error, gc.ErrorMatches, `offer URL "no body/shared.db" not valid`)
+
+	remote, err := s.State.RemoteService("shareddb")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(remote.SourceEnvUUID(), gc.Equals, sourceSt.EnvironUUID())
+	c.Assert(remote.OfferName(), gc.Equals, "db")
+	c.Assert(remote.Endpoints(), gc.HasLen, 1)
+	c.Assert(remote.Endpoints()[0].Name, gc.Equals, "server")
+}
+
+func (s *offersSuite) TestConsumeOtherOwnersEnvironment(c *gc.C) {
+	// Environment names are only unique for each owner, so the
+	// owner picks which of the environments named "shared" is meant.
+	mine := s.Factory.MakeEnvironment(c, &factory.EnvParams{Name: "shared"})
+	defer mine.Close()
+	bob := s.Factory.MakeUser(c, &factory.UserParams{Name: "bob", NoEnvUser: true})
+	bobs := s.Factory.MakeEnvironment(c, &factory.EnvParams{Name: "shared", Owner: bob.UserTag()})
+	defer bobs.Close()
+	bobsFactory := factory.NewFactory(bobs)
+	bobsFactory.MakeEnvUser(c, &factory.EnvUserParams{User: s.AdminUserTag(c).Username()})
+	for _, st := range []*state.State{mine, bobs} {
+		stFactory := factory.NewFactory(st)
+		stFactory.MakeService(c, &factory.ServiceParams{
+			Name:  "mysql",
+			Charm: stFactory.MakeCharm(c, &factory.CharmParams{Name: "mysql"}),
+		})
+		_, err := st.AddServiceOffer(state.AddServiceOfferArgs{Name: "db", ServiceName: "mysql"})
+		c.Assert(err, jc.ErrorIsNil)
+	}
+
+	result, err := s.api.Consume(params.ConsumeServiceOffersParams{
+		Offers: []params.ConsumeServiceOfferParams{
+			{OfferURL: "shared.db", ServiceName: "minedb"},
+			{OfferURL: "bob@local/shared.db", ServiceName: "bobsdb"},
+		},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Results, jc.DeepEquals, []params.ErrorResult{{}, {}})
+
+	remote, err := s.State.RemoteService("minedb")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(remote.SourceEnvUUID(), gc.Equals, mine.EnvironUUID())
+	remote, err = s.State.RemoteService("bobsdb")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(remote.SourceEnvUUID(), gc.Equals, bobs.EnvironUUID())
+}
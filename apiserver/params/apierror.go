@@ -54,6 +54,7 @@ const (
 	CodeActionNotAvailable    = "action no longer available"
 	CodeOperationBlocked      = "operation is blocked"
 	CodeLeadershipClaimDenied = "leadership claim denied"
+	CodeRateLimitExceeded     = "rate limit exceeded"
 )
 
 // ErrCode returns the error code associated with
@@ -168,3 +169,10 @@ func IsCodeOperationBlocked(err error) bool {
 func IsCodeLeadershipClaimDenied(err error) bool {
 	return ErrCode(err) == CodeLeadershipClaimDenied
 }
+
+// IsCodeRateLimitExceeded returns whether err was caused by the API
+// server rejecting a request because the caller has exceeded its
+// request limits. Such requests may be retried after a delay.
+func IsCodeRateLimitExceeded(err error) bool {
+	return ErrCode(err) == CodeRateLimitExceeded
+}
@@ -0,0 +1,43 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package params
+
+import (
+	"time"
+)
+
+// EnvironmentStatus holds information about an environment hosted by
+// a controller, as reported to the controller's administrator.
+type EnvironmentStatus struct {
+	EnvironTag   string     `json:"EnvironTag"`
+	Name         string     `json:"Name"`
+	OwnerTag     string     `json:"OwnerTag"`
+	Life         Life       `json:"Life"`
+	MachineCount int        `json:"MachineCount"`
+	UnitCount    int        `json:"UnitCount"`
+	LastActivity *time.Time `json:"LastActivity,omitempty"`
+}
+
+// EnvironmentStatusResult holds the result of an
+// EnvironmentManager.EnvironmentStatus call for one environment.
+type EnvironmentStatusResult struct {
+	Status *EnvironmentStatus `json:"Status,omitempty"`
+	Error  *Error             `json:"Error,omitempty"`
+}
+
+// EnvironmentStatusResults holds the results of an
+// EnvironmentManager.EnvironmentStatus call.
+type EnvironmentStatusResults struct {
+	Results []EnvironmentStatusResult `json:"Results"`
+}
+
+// EnvironmentBlockArgs holds the arguments of the
+// EnvironmentManager.BlockEnvironment and UnblockEnvironment calls.
+type EnvironmentBlockArgs struct {
+	EnvironTag string `json:"EnvironTag"`
+
+	// Type is the block type, as in BlockSwitchParams.
+	Type    string `json:"Type"`
+	Message string `json:"Message,omitempty"`
+}
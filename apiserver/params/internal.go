@@ -509,6 +509,17 @@ type RunParams struct {
 	Units    []string
 }
 
+// DebugHooksParams is used to drive a non-interactive debug-hooks
+// session on a unit. Operation is one of "start", "exec", "step" or
+// "resume"; Hooks is only used by "start", and Commands by "exec".
+type DebugHooksParams struct {
+	Unit      string
+	Operation string
+	Hooks     []string
+	Commands  string
+	Timeout   time.Duration
+}
+
 // RunResult contains the result from an individual run call on a machine.
 // UnitId is populated if the command was run inside the unit context.
 type RunResult struct {
@@ -538,6 +549,19 @@ type ProvisioningInfo struct {
 	Networks    []string
 	Jobs        []multiwatcher.MachineJob
 	Volumes     []VolumeParams
+
+	// SubnetsToZones maps the provider ids of the subnets in the
+	// spaces the machine is constrained to, to the availability zones
+	// those subnets are in.
+	SubnetsToZones map[string][]string
+
+	// TraceId holds the trace id of the last traced API request
+	// that changed the machine, if any.
+	TraceId string `json:",omitempty"`
+
+	// MigratedFrom holds the instance id of the container the
+	// machine was migrated from, if any.
+	MigratedFrom instance.Id `json:",omitempty"`
 }
 
 // ProvisioningInfoResult holds machine provisioning info or an error.
@@ -569,6 +593,42 @@ type MetricsParams struct {
 	Metrics []MetricsParam
 }
 
+// UnitHistoryEntry describes a single hook or action execution
+// by a unit.
+type UnitHistoryEntry struct {
+	Kind       string
+	Name       string
+	Relation   string
+	RemoteUnit string
+	Started    time.Time
+	Finished   time.Time
+	ExitStatus int
+	Error      string
+	StderrTail string
+}
+
+// UnitHistoryParam holds history entries to record for a single unit.
+type UnitHistoryParam struct {
+	Tag     string
+	Entries []UnitHistoryEntry
+}
+
+// UnitHistoryParams holds history entries to record for multiple units.
+type UnitHistoryParams struct {
+	Units []UnitHistoryParam
+}
+
+// UnitHistoryResult holds the history of a single unit, or an error.
+type UnitHistoryResult struct {
+	Entries []UnitHistoryEntry
+	Error   *Error
+}
+
+// UnitHistoryResults holds the history of multiple units.
+type UnitHistoryResults struct {
+	Results []UnitHistoryResult
+}
+
 // MetricBatch is a list of metrics with metadata.
 type MetricBatch struct {
 	UUID     string
@@ -0,0 +1,42 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package params
+
+// MigrationTarget describes the controller to which an environment's
+// agents are being moved.
+type MigrationTarget struct {
+	APIAddrs []string `json:"APIAddrs"`
+	CACert   string   `json:"CACert"`
+}
+
+// MigrationTargetResult holds the result of the MigrationMinion.Target
+// API call. Target is nil if the environment is not being migrated.
+type MigrationTargetResult struct {
+	Target *MigrationTarget `json:"Target,omitempty"`
+	Error  *Error           `json:"Error,omitempty"`
+}
+
+// EnvironmentExport holds the documents of an environment exported
+// from one controller for import into another. The documents are
+// opaque to clients. Charms holds the URLs of the charms whose
+// archives must be copied separately.
+type EnvironmentExport struct {
+	EnvironTag string   `json:"EnvironTag"`
+	Documents  []byte   `json:"Documents"`
+	Charms     []string `json:"Charms,omitempty"`
+}
+
+// SetMigrationTargetArgs holds the arguments of the
+// EnvironmentManager.SetMigrationTarget API call.
+type SetMigrationTargetArgs struct {
+	EnvironTag string          `json:"EnvironTag"`
+	Target     MigrationTarget `json:"Target"`
+}
+
+// MigrationStatus holds the result of the
+// EnvironmentManager.MigrationStatus API call. PendingAgents holds the
+// tags of the agents yet to move to the target controller.
+type MigrationStatus struct {
+	PendingAgents []string `json:"PendingAgents"`
+}
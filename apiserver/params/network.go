@@ -412,3 +412,41 @@ type APIHostPortsResult struct {
 func (r APIHostPortsResult) NetworkHostsPorts() [][]network.HostPort {
 	return NetworkHostsPorts(r.Servers)
 }
+
+// CreateSpaceParams holds the name of a space to create and the
+// CIDRs of the subnets it contains.
+type CreateSpaceParams struct {
+	Name        string   `json:"Name"`
+	SubnetCIDRs []string `json:"SubnetCIDRs"`
+}
+
+// CreateSpacesParams holds the arguments of the
+// Spaces.CreateSpaces API call.
+type CreateSpacesParams struct {
+	Spaces []CreateSpaceParams `json:"Spaces"`
+}
+
+// Space describes a space and the CIDRs of its subnets.
+type Space struct {
+	Name        string   `json:"Name"`
+	SubnetCIDRs []string `json:"SubnetCIDRs"`
+}
+
+// ListSpacesResults holds the result of the
+// Spaces.ListSpaces API call.
+type ListSpacesResults struct {
+	Results []Space `json:"Results"`
+}
+
+// ServiceEndpointBindings holds the names of the spaces a
+// service's endpoints are bound to, keyed by endpoint name.
+type ServiceEndpointBindings struct {
+	ServiceTag string            `json:"ServiceTag"`
+	Bindings   map[string]string `json:"Bindings"`
+}
+
+// SetEndpointBindingsParams holds the arguments of the
+// Spaces.SetEndpointBindings API call.
+type SetEndpointBindingsParams struct {
+	Services []ServiceEndpointBindings `json:"Services"`
+}
@@ -0,0 +1,50 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package params
+
+// AddServiceOfferParams holds the details of an offer of a service's
+// endpoints to other environments.
+type AddServiceOfferParams struct {
+	OfferName   string   `json:"OfferName"`
+	ServiceName string   `json:"ServiceName"`
+	Endpoints   []string `json:"Endpoints"`
+	Description string   `json:"Description"`
+}
+
+// AddServiceOffersParams holds the arguments of the Offers.Offer API
+// call.
+type AddServiceOffersParams struct {
+	Offers []AddServiceOfferParams `json:"Offers"`
+}
+
+// ServiceOffer describes an offer of a service's endpoints. OfferURL
+// is the offer's URL, of the form <owner>/<environment>.<offer>.
+type ServiceOffer struct {
+	OfferURL    string   `json:"OfferURL"`
+	EnvironName string   `json:"EnvironName"`
+	OfferName   string   `json:"OfferName"`
+	ServiceName string   `json:"ServiceName"`
+	Endpoints   []string `json:"Endpoints"`
+	Description string   `json:"Description"`
+}
+
+// ListServiceOffersResults holds the result of the Offers.ListOffers
+// API call.
+type ListServiceOffersResults struct {
+	Results []ServiceOffer `json:"Results"`
+}
+
+// ConsumeServiceOfferParams identifies an offer to consume, in the
+// form [<owner>/]<environment>.<offer>, and the name of the remote service
+// which stands for the offered service in the consuming environment.
+type ConsumeServiceOfferParams struct {
+	OfferURL    string `json:"OfferURL"`
+	ServiceName string `json:"ServiceName"`
+}
+
+// ConsumeServiceOffersParams holds the arguments of the
+// Offers.Consume API call.
+type ConsumeServiceOffersParams struct {
+	Offers []ConsumeServiceOfferParams `json:"Offers"`
+}
@@ -188,6 +188,23 @@ type DestroyMachines struct {
 	Force        bool
 }
 
+// ContainerMigration holds the parameters for the calls that migrate
+// a container to another host machine. HostId is only used by
+// StartContainerMigration.
+type ContainerMigration struct {
+	MachineId string
+	HostId    string
+}
+
+// ContainerMigrationResult holds the result of the calls that migrate
+// a container to another host machine. InstanceId is the migrating
+// container's instance id, and MachineId the id of the machine the
+// container becomes on its new host once the migration is complete.
+type ContainerMigrationResult struct {
+	InstanceId instance.Id
+	MachineId  string
+}
+
 // ServiceDeploy holds the parameters for making the ServiceDeploy call.
 type ServiceDeploy struct {
 	ServiceName   string
@@ -222,6 +239,10 @@ type ServiceSetCharm struct {
 // ServiceExpose holds the parameters for making the ServiceExpose call.
 type ServiceExpose struct {
 	ServiceName string
+
+	// CIDRs, if given, restricts access to the exposed service
+	// to addresses within them.
+	CIDRs []string
 }
 
 // ServiceSet holds the parameters for a ServiceSet
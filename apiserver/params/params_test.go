@@ -10,6 +10,7 @@ import (
 	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
 	"gopkg.in/juju/charm.v5-unstable"
+	"gopkg.in/mgo.v2/bson"
 
 	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/constraints"
@@ -173,6 +174,31 @@ func (s *MarshalSuite) TestDeltaMarshalJSONUnknownEntity(c *gc.C) {
 	c.Check(err, gc.ErrorMatches, `Unexpected entity name "qwan"`)
 }
 
+func (s *MarshalSuite) TestDeltaBSONRoundTrip(c *gc.C) {
+	type doc struct {
+		Delta multiwatcher.Delta
+	}
+	for i, t := range marshalTestCases {
+		c.Logf("test %d. %s", i, t.about)
+		data, err := bson.Marshal(doc{t.value})
+		c.Assert(err, jc.ErrorIsNil)
+		var unmarshalled doc
+		err = bson.Unmarshal(data, &unmarshalled)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(unmarshalled.Delta, jc.DeepEquals, t.value)
+	}
+}
+
+func (s *MarshalSuite) TestDeltaUnmarshalBSONUnknownEntity(c *gc.C) {
+	data, err := bson.Marshal(bson.M{"delta": []interface{}{"qwan", "change", bson.M{}}})
+	c.Assert(err, jc.ErrorIsNil)
+	var unmarshalled struct {
+		Delta multiwatcher.Delta
+	}
+	err = bson.Unmarshal(data, &unmarshalled)
+	c.Check(err, gc.ErrorMatches, `Unexpected entity name "qwan"`)
+}
+
 type ErrorResultsSuite struct{}
 
 var _ = gc.Suite(&ErrorResultsSuite{})
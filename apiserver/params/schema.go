@@ -0,0 +1,28 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package params
+
+import (
+	"github.com/juju/juju/rpc/jsonschema"
+)
+
+// FacadeSchemaArgs holds the names of the facades whose schemas are
+// requested. If Names is empty, the schemas of all facades are
+// returned.
+type FacadeSchemaArgs struct {
+	Names []string
+}
+
+// FacadeSchema holds the JSON schema describing the methods of one
+// version of a facade.
+type FacadeSchema struct {
+	Name    string
+	Version int
+	Schema  *jsonschema.Schema
+}
+
+// FacadeSchemaResults holds the result of a Schema.FacadeSchemas call.
+type FacadeSchemaResults struct {
+	Schemas []FacadeSchema
+}
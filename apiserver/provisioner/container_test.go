@@ -595,17 +595,26 @@ func (s *prepareSuite) TestSuccessWithSingleContainer(c *gc.C) {
 	}})
 }
 
-func (s *prepareSuite) TestSuccessWhenFirstSubnetNotAllocatable(c *gc.C) {
-	// Using "i-no-alloc-0" for the host instance id will cause the
-	// dummy provider to change the Subnets() results to return no
-	// allocatable range for the first subnet (dummy-private), and
-	// also change its ProviderId to "noalloc-private", which in turn
-	// will cause SupportsAddressAllocation() to return false for it.
-	// We test here that we keep looking for other allocatable
-	// subnets.
-	container := s.newCustomAPI(c, "i-no-alloc-0", true, false)
+func (s *prepareSuite) TestSuccessWithMultipleNICs(c *gc.C) {
+	// Using the "i-all-nics-" prefix for the host instance id will
+	// cause the dummy provider to return both NICs enabled, so the
+	// container gets an address on each of their subnets.
+	container := s.newCustomAPI(c, "i-all-nics-host", true, false)
 	args := s.makeArgs(container)
 	_, testLog := s.assertCall(c, args, s.makeResults([]params.NetworkConfig{{
+		ProviderId:       "dummy-eth0",
+		ProviderSubnetId: "dummy-private",
+		NetworkName:      "juju-private",
+		CIDR:             "0.10.0.0/24",
+		DeviceIndex:      0,
+		InterfaceName:    "eth0",
+		VLANTag:          0,
+		MACAddress:       "aa:bb:cc:dd:ee:f0",
+		ConfigType:       "static",
+		Address:          "regex:0.10.0.[0-9]{1,3}", // we don't care about the actual value.
+		DNSServers:       []string{"ns1.dummy", "ns2.dummy"},
+		GatewayAddress:   "0.10.0.2",
+	}, {
 		ProviderId:       "dummy-eth1",
 		ProviderSubnetId: "dummy-public",
 		NetworkName:      "juju-public",
@@ -614,25 +623,96 @@ func (s *prepareSuite) TestSuccessWhenFirstSubnetNotAllocatable(c *gc.C) {
 		InterfaceName:    "eth1",
 		VLANTag:          1,
 		MACAddress:       "aa:bb:cc:dd:ee:f1",
-		Disabled:         true,
-		NoAutoStart:      true,
 		ConfigType:       "static",
 		Address:          "regex:0.20.0.[0-9]{1,3}", // we don't care about the actual value.
 		DNSServers:       []string{"ns1.dummy", "ns2.dummy"},
 		GatewayAddress:   "0.20.0.2",
-		ExtraConfig:      nil,
 	}}), "")
 
 	c.Assert(testLog, jc.LogMatches, jc.SimpleMessages{{
-		loggo.TRACE,
-		`ignoring subnet "noalloc-private" - no allocatable range set`,
+		loggo.INFO,
+		`allocated address ".+" on instance "i-all-nics-host" and subnet "dummy-private"`,
 	}, {
 		loggo.INFO,
-		`allocated address ".+" on instance "i-no-alloc-0" and subnet "dummy-public"`,
+		`assigned address ".+" to container "0/lxc/0"`,
+	}, {
+		loggo.INFO,
+		`allocated address ".+" on instance "i-all-nics-host" and subnet "dummy-public"`,
 	}, {
 		loggo.INFO,
 		`assigned address ".+" to container "0/lxc/0"`,
 	}})
+	err := container.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(container.Addresses(), gc.HasLen, 2)
+}
+
+func (s *prepareSuite) TestReleaseAllocatedWhenSecondNICFails(c *gc.C) {
+	// Exhaust the second subnet (dummy-public) in state, so allocation
+	// on it fails after an address was allocated on the first.
+	sub, err := s.BackingState.AddSubnet(state.SubnetInfo{
+		ProviderId:        "dummy-public",
+		CIDR:              "0.20.0.0/24",
+		VLANTag:           1,
+		AllocatableIPLow:  "0.20.0.0",
+		AllocatableIPHigh: "0.20.0.1",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	for _, value := range []string{"0.20.0.0", "0.20.0.1"} {
+		ipaddr, err := s.BackingState.AddIPAddress(network.NewAddress(value), sub.ID())
+		c.Assert(err, jc.ErrorIsNil)
+		err = ipaddr.SetState(state.AddressStateAllocated)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+
+	container := s.newCustomAPI(c, "i-all-nics-host", true, false)
+	args := s.makeArgs(container)
+	_, testLog := s.assertCall(c, args, s.makeErrors(apiservertesting.ServerError(
+		`failed to allocate an address for "0/lxc/0": `+
+			`allocatable IP addresses exhausted for subnet "0.20.0.0/24"`,
+	)), "")
+
+	c.Assert(testLog, jc.LogMatches, jc.SimpleMessages{{
+		loggo.INFO,
+		`allocated address ".+" on instance "i-all-nics-host" and subnet "dummy-private"`,
+	}, {
+		loggo.INFO,
+		`assigned address ".+" to container "0/lxc/0"`,
+	}, {
+		loggo.INFO,
+		`released address ".+" on instance "i-all-nics-host" and subnet "dummy-private"`,
+	}})
+
+	// The address on the first subnet is gone from both the container
+	// and state.
+	allocated, err := s.BackingState.AllocatedIPAddresses(container.Id())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(allocated, gc.HasLen, 0)
+	err = container.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(container.Addresses(), gc.HasLen, 0)
+}
+
+func (s *prepareSuite) TestErrorWhenOnlyDisabledNICHasAllocatableSubnet(c *gc.C) {
+	// Using "i-no-alloc-0" for the host instance id will cause the
+	// dummy provider to change the Subnets() results to return no
+	// allocatable range for the first subnet (dummy-private). The
+	// other subnet is only attached to a disabled NIC, which cannot
+	// be used either.
+	container := s.newCustomAPI(c, "i-no-alloc-0", true, false)
+	args := s.makeArgs(container)
+	err, testLog := s.assertCall(c, args, nil,
+		"cannot allocate addresses: address allocation on any available subnets is not supported",
+	)
+	c.Assert(err, jc.Satisfies, errors.IsNotSupported)
+
+	c.Assert(testLog, jc.LogMatches, jc.SimpleMessages{{
+		loggo.DEBUG,
+		`interface "eth1" is disabled \(skipping\)`,
+	}, {
+		loggo.TRACE,
+		`ignoring subnet "noalloc-private" - no allocatable range set`,
+	}})
 }
 
 // releaseSuite contains only tests around
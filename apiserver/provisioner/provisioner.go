@@ -5,6 +5,8 @@ package provisioner
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/juju/errors"
 	"github.com/juju/loggo"
@@ -392,16 +394,61 @@ func (p *ProvisionerAPI) getProvisioningInfo(m *state.Machine) (*params.Provisio
 	for _, job := range m.Jobs() {
 		jobs = append(jobs, job.ToParams())
 	}
+	subnetsToZones, err := p.machineSubnetsToZones(cons)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	migratedFrom, _ := m.MigratedFrom()
 	return &params.ProvisioningInfo{
-		Constraints: cons,
-		Series:      m.Series(),
-		Placement:   m.Placement(),
-		Networks:    networks,
-		Jobs:        jobs,
-		Volumes:     volumes,
+		Constraints:    cons,
+		Series:         m.Series(),
+		Placement:      m.Placement(),
+		Networks:       networks,
+		Jobs:           jobs,
+		Volumes:        volumes,
+		SubnetsToZones: subnetsToZones,
+		TraceId:        m.TraceId(),
+		MigratedFrom:   migratedFrom,
 	}, nil
 }
 
+// machineSubnetsToZones returns a map of the provider ids of the
+// subnets in the spaces included by the given constraints to the
+// availability zones of those subnets, or nil if the constraints
+// include no spaces.
+func (p *ProvisionerAPI) machineSubnetsToZones(cons constraints.Value) (map[string][]string, error) {
+	spaces := cons.IncludeSpaces()
+	if len(spaces) == 0 {
+		return nil, nil
+	}
+	subnetsToZones := make(map[string][]string)
+	for _, spaceName := range spaces {
+		space, err := p.st.Space(spaceName)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		subnets, err := space.Subnets()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, subnet := range subnets {
+			if subnet.ProviderId() == "" {
+				logger.Debugf("ignoring subnet %q in space %q: no provider id", subnet.CIDR(), spaceName)
+				continue
+			}
+			var zones []string
+			if zone := subnet.AvailabilityZone(); zone != "" {
+				zones = append(zones, zone)
+			}
+			subnetsToZones[subnet.ProviderId()] = zones
+		}
+	}
+	if len(subnetsToZones) == 0 {
+		return nil, errors.Errorf("no provider subnets in spaces %s", strings.Join(spaces, ", "))
+	}
+	return subnetsToZones, nil
+}
+
 // DistributionGroup returns, for each given machine entity,
 // a slice of instance.Ids that belong to the same distribution
 // group as that machine. This information may be used to
@@ -849,7 +896,7 @@ func (p *ProvisionerAPI) PrepareContainerInterfaceInfo(args params.Entities) (pa
 		err = errors.NotProvisionedf("cannot allocate addresses: host machine %q", host)
 		return result, err
 	}
-	subnet, subnetInfo, interfaceInfo, err := p.prepareAllocationNetwork(environ, host, instId)
+	targets, err := p.prepareAllocationNetwork(environ, host, instId)
 	if err != nil {
 		return result, errors.Annotate(err, "cannot allocate addresses")
 	}
@@ -885,45 +932,64 @@ func (p *ProvisionerAPI) PrepareContainerInterfaceInfo(args params.Entities) (pa
 			continue
 		}
 
-		// Allocate and set address.
-		addr, err := p.allocateAddress(environ, subnet, host, container, instId)
-		if err != nil {
-			err = errors.Annotatef(err, "failed to allocate an address for %q", container)
-			result.Results[i].Error = common.ServerError(err)
-			continue
-		}
-		// Store it on the machine, construct and set an interface result.
-		dnsServers := make([]string, len(interfaceInfo.DNSServers))
-		for i, dns := range interfaceInfo.DNSServers {
-			dnsServers[i] = dns.Value
-		}
-		// TODO(dimitern): Support allocating one address per NIC on
-		// the host, effectively creating the same number of NICs in
-		// the container.
-		result.Results[i] = params.MachineNetworkConfigResult{
-			Config: []params.NetworkConfig{{
-				DeviceIndex:      interfaceInfo.DeviceIndex,
-				MACAddress:       interfaceInfo.MACAddress,
-				CIDR:             subnetInfo.CIDR,
-				NetworkName:      interfaceInfo.NetworkName,
-				ProviderId:       string(interfaceInfo.ProviderId),
-				ProviderSubnetId: string(subnetInfo.ProviderId),
-				VLANTag:          interfaceInfo.VLANTag,
-				InterfaceName:    interfaceInfo.InterfaceName,
-				Disabled:         interfaceInfo.Disabled,
-				NoAutoStart:      interfaceInfo.NoAutoStart,
-				DNSServers:       dnsServers,
-				ConfigType:       string(network.ConfigStatic),
-				Address:          addr.Value(),
-				// container's gateway is the host's primary NIC's IP.
-				GatewayAddress: interfaceInfo.Address.Value,
-				ExtraConfig:    interfaceInfo.ExtraConfig,
-			}},
+		// Allocate and set one address per host NIC, giving the
+		// container a NIC on each of the host's subnets.
+		config := make([]params.NetworkConfig, len(targets))
+		allocated := make([]*state.IPAddress, 0, len(targets))
+		for j, target := range targets {
+			addr, err := p.allocateAddress(environ, target.subnet, host, container, instId)
+			if err != nil {
+				err = errors.Annotatef(err, "failed to allocate an address for %q", container)
+				result.Results[i].Error = common.ServerError(err)
+				// Don't leave the container with only some of its
+				// NICs configured.
+				p.releaseAllocated(environ, instId, container, targets[:j], allocated)
+				config = nil
+				break
+			}
+			allocated = append(allocated, addr)
+			config[j] = target.networkConfig(addr)
 		}
+		result.Results[i].Config = config
 	}
 	return result, nil
 }
 
+// allocationTarget is a host NIC, and the subnet it is attached to,
+// on which addresses can be allocated for the host's containers.
+type allocationTarget struct {
+	subnet        *state.Subnet
+	subnetInfo    network.SubnetInfo
+	interfaceInfo network.InterfaceInfo
+}
+
+// networkConfig returns the configuration of a container NIC using
+// addr, allocated on the target's subnet.
+func (t allocationTarget) networkConfig(addr *state.IPAddress) params.NetworkConfig {
+	dnsServers := make([]string, len(t.interfaceInfo.DNSServers))
+	for i, dns := range t.interfaceInfo.DNSServers {
+		dnsServers[i] = dns.Value
+	}
+	return params.NetworkConfig{
+		DeviceIndex:      t.interfaceInfo.DeviceIndex,
+		MACAddress:       t.interfaceInfo.MACAddress,
+		CIDR:             t.subnetInfo.CIDR,
+		NetworkName:      t.interfaceInfo.NetworkName,
+		ProviderId:       string(t.interfaceInfo.ProviderId),
+		ProviderSubnetId: string(t.subnetInfo.ProviderId),
+		VLANTag:          t.interfaceInfo.VLANTag,
+		InterfaceName:    t.interfaceInfo.InterfaceName,
+		Disabled:         t.interfaceInfo.Disabled,
+		NoAutoStart:      t.interfaceInfo.NoAutoStart,
+		DNSServers:       dnsServers,
+		ConfigType:       string(network.ConfigStatic),
+		Address:          addr.Value(),
+		// container's gateway is the host NIC's IP.
+		GatewayAddress: t.interfaceInfo.Address.Value,
+		ExtraConfig:    t.interfaceInfo.ExtraConfig,
+	}
+}
+
 // prepareContainerAccessEnvironment retrieves the environment, host machine, and access
 // for working with containers.
 func (p *ProvisionerAPI) prepareContainerAccessEnvironment() (environs.NetworkingEnviron, *state.Machine, common.AuthFunc, error) {
@@ -960,29 +1026,34 @@ func (p *ProvisionerAPI) prepareContainerAccessEnvironment() (environs.Networkin
 	return netEnviron, host, canAccess, nil
 }
 
-// prepareAllocationNetwork retrieves the subnet, its info, and the interface info
-// for the allocations.
+// prepareAllocationNetwork returns the host's enabled NICs whose
+// subnets support address allocation, in device index order, along
+// with their subnets.
 func (p *ProvisionerAPI) prepareAllocationNetwork(
 	environ environs.NetworkingEnviron,
 	host *state.Machine,
 	instId instance.Id,
-) (
-	*state.Subnet,
-	network.SubnetInfo,
-	network.InterfaceInfo,
-	error,
-) {
-	var subnetInfo network.SubnetInfo
-	var interfaceInfo network.InterfaceInfo
-
+) ([]allocationTarget, error) {
 	interfaces, err := environ.NetworkInterfaces(instId)
 	if err != nil {
-		return nil, subnetInfo, interfaceInfo, errors.Trace(err)
+		return nil, errors.Trace(err)
 	} else if len(interfaces) == 0 {
-		return nil, subnetInfo, interfaceInfo, errors.Errorf("no interfaces available")
+		return nil, errors.Errorf("no interfaces available")
 	}
 	logger.Tracef("interfaces for instance %q: %v", instId, interfaces)
 
+	// NICs disabled on the host in state are not used either.
+	hostInterfaces, err := host.NetworkInterfaces()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	disabledMACs := set.NewStrings()
+	for _, iface := range hostInterfaces {
+		if iface.IsDisabled() {
+			disabledMACs.Add(iface.MACAddress())
+		}
+	}
+
 	subnetIds := []network.Id{}
 	subnetIdToInterface := make(map[network.Id]network.InterfaceInfo)
 	for _, iface := range interfaces {
@@ -990,24 +1061,26 @@ func (p *ProvisionerAPI) prepareAllocationNetwork(
 			logger.Debugf("no subnet associated with interface %#v (skipping)", iface)
 			continue
 		}
+		if iface.Disabled || disabledMACs.Contains(iface.MACAddress) {
+			logger.Debugf("interface %q is disabled (skipping)", iface.InterfaceName)
+			continue
+		}
+		if _, ok := subnetIdToInterface[iface.ProviderSubnetId]; ok {
+			logger.Debugf("subnet %q already has an interface (skipping %q)", iface.ProviderSubnetId, iface.InterfaceName)
+			continue
+		}
 		subnetIds = append(subnetIds, iface.ProviderSubnetId)
 		subnetIdToInterface[iface.ProviderSubnetId] = iface
 	}
 	subnets, err := environ.Subnets(instId, subnetIds)
 	if err != nil {
-		return nil, subnetInfo, interfaceInfo, errors.Trace(err)
+		return nil, errors.Trace(err)
 	} else if len(subnets) == 0 {
-		return nil, subnetInfo, interfaceInfo, errors.Errorf("no subnets available")
+		return nil, errors.Errorf("no subnets available")
 	}
 	logger.Tracef("subnets for instance %q: %v", instId, subnets)
 
-	// TODO(mfoord): we need a better strategy for picking a subnet to
-	// allocate an address on. (dimitern): Right now we just pick the
-	// first subnet with allocatable range set. Instead, we should
-	// allocate an address per interface, assuming each interface is
-	// on a subnet with allocatable range set, and skipping those
-	// which do not have a range set.
-	var success bool
+	var targets []allocationTarget
 	for _, sub := range subnets {
 		logger.Tracef("trying to allocate a static IP on subnet %q", sub.ProviderId)
 		if sub.AllocatableIPHigh == nil {
@@ -1015,27 +1088,47 @@ func (p *ProvisionerAPI) prepareAllocationNetwork(
 			// this subnet has no allocatable IPs
 			continue
 		}
+		iface, ok := subnetIdToInterface[sub.ProviderId]
+		if !ok {
+			logger.Tracef("ignoring subnet %q - no usable interface", sub.ProviderId)
+			continue
+		}
 		ok, err := environ.SupportsAddressAllocation(sub.ProviderId)
-		if err == nil && ok {
-			subnetInfo = sub
-			interfaceInfo = subnetIdToInterface[sub.ProviderId]
-			success = true
-			break
-		}
-		logger.Tracef(
-			"subnet %q supports address allocation: %v (error: %v)",
-			sub.ProviderId, ok, err,
-		)
+		if err != nil || !ok {
+			logger.Tracef(
+				"subnet %q supports address allocation: %v (error: %v)",
+				sub.ProviderId, ok, err,
+			)
+			continue
+		}
+		subnet, err := p.createOrFetchStateSubnet(sub)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		targets = append(targets, allocationTarget{
+			subnet:        subnet,
+			subnetInfo:    sub,
+			interfaceInfo: iface,
+		})
 	}
-	if !success {
+	if len(targets) == 0 {
 		// " not supported" will be appended to the message below.
-		return nil, subnetInfo, interfaceInfo, errors.NotSupportedf(
+		return nil, errors.NotSupportedf(
 			"address allocation on any available subnets is",
 		)
 	}
-	subnet, err := p.createOrFetchStateSubnet(subnetInfo)
+	sort.Sort(byDeviceIndex(targets))
+	return targets, nil
+}
 
-	return subnet, subnetInfo, interfaceInfo, nil
+// byDeviceIndex sorts allocation targets by their
+// NIC's device index on the host.
+type byDeviceIndex []allocationTarget
+
+func (b byDeviceIndex) Len() int      { return len(b) }
+func (b byDeviceIndex) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byDeviceIndex) Less(i, j int) bool {
+	return b[i].interfaceInfo.DeviceIndex < b[j].interfaceInfo.DeviceIndex
 }
 
 // These are defined like this to allow mocking in tests.
@@ -1045,7 +1138,9 @@ var (
 		return a.AllocateTo(m.Id(), "")
 	}
 	setAddrsTo = func(a *state.IPAddress, m *state.Machine) error {
-		return m.SetAddresses(a.Address())
+		// A container has one address per NIC,
+		// so keep any already set.
+		return m.SetAddresses(append(m.Addresses(), a.Address())...)
 	}
 	setAddrState = func(a *state.IPAddress, st state.AddressState) error {
 		return a.SetState(st)
@@ -1156,6 +1251,51 @@ func (p *ProvisionerAPI) setAllocatedOrRelease(
 	return nil
 }
 
+// releaseAllocated undoes the allocation of addrs to the container,
+// each on the subnet of the corresponding target: the addresses are
+// released with the provider, removed from the container's addresses
+// and from state. An address the provider fails to release is left
+// dead, for the addresser worker to retry. Errors are only logged, as
+// the caller is already reporting the failed allocation.
+func (p *ProvisionerAPI) releaseAllocated(
+	environ environs.NetworkingEnviron,
+	instId instance.Id,
+	container *state.Machine,
+	targets []allocationTarget,
+	addrs []*state.IPAddress,
+) {
+	released := make(map[string]bool)
+	for j, addr := range addrs {
+		released[addr.Value()] = true
+		if err := addr.EnsureDead(); err != nil {
+			logger.Warningf("cannot release address %q: %v", addr.String(), err)
+			continue
+		}
+		subnetId := network.Id(targets[j].subnet.ProviderId())
+		if err := environ.ReleaseAddress(instId, subnetId, addr.Address()); err != nil {
+			logger.Warningf(
+				"failed to release address %q on instance %q and subnet %q: %v (leaving it to the addresser)",
+				addr.String(), instId, subnetId, err,
+			)
+			continue
+		}
+		if err := addr.Remove(); err != nil {
+			logger.Warningf("cannot remove released address %q: %v", addr.String(), err)
+			continue
+		}
+		logger.Infof("released address %q on instance %q and subnet %q", addr.String(), instId, subnetId)
+	}
+	var remaining []network.Address
+	for _, addr := range container.Addresses() {
+		if !released[addr.Value] {
+			remaining = append(remaining, addr)
+		}
+	}
+	if err := container.SetAddresses(remaining...); err != nil {
+		logger.Warningf("cannot remove released addresses from container %q: %v", container, err)
+	}
+}
+
 func (p *ProvisionerAPI) createOrFetchStateSubnet(subnetInfo network.SubnetInfo) (*state.Subnet, error) {
 	stateSubnetInfo := state.SubnetInfo{
 		ProviderId:        string(subnetInfo.ProviderId),
@@ -818,6 +818,91 @@ func (s *withoutStateServerSuite) TestProvisioningInfo(c *gc.C) {
 	c.Assert(result, jc.DeepEquals, expected)
 }
 
+func (s *withoutStateServerSuite) TestProvisioningInfoTraceId(c *gc.C) {
+	machine, err := s.State.WithTraceId("deploy-1").AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: s.machines[0].Tag().String()},
+		{Tag: machine.Tag().String()},
+	}}
+	result, err := s.provisioner.ProvisioningInfo(args)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Results, gc.HasLen, 2)
+	c.Assert(result.Results[0].Error, gc.IsNil)
+	c.Assert(result.Results[0].Result.TraceId, gc.Equals, "")
+	c.Assert(result.Results[1].Error, gc.IsNil)
+	c.Assert(result.Results[1].Result.TraceId, gc.Equals, "deploy-1")
+}
+
+func (s *withoutStateServerSuite) TestProvisioningInfoMigratedFrom(c *gc.C) {
+	container, err := s.State.AddMachineInsideMachine(state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}, s.machines[0].Id(), instance.LXC)
+	c.Assert(err, jc.ErrorIsNil)
+	err = container.SetProvisioned("juju-machine-0-lxc-0", "fake_nonce", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	err = container.StartMigration(s.machines[1].Id())
+	c.Assert(err, jc.ErrorIsNil)
+	migrated, err := container.CompleteMigration()
+	c.Assert(err, jc.ErrorIsNil)
+
+	// Login as the machine agent of the container's new host.
+	anAuthorizer := s.authorizer
+	anAuthorizer.EnvironManager = false
+	anAuthorizer.Tag = s.machines[1].Tag()
+	aProvisioner, err := provisioner.NewProvisionerAPI(s.State, s.resources, anAuthorizer)
+	c.Assert(err, jc.ErrorIsNil)
+
+	args := params.Entities{Entities: []params.Entity{{Tag: migrated.Tag().String()}}}
+	result, err := aProvisioner.ProvisioningInfo(args)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Results, gc.HasLen, 1)
+	c.Assert(result.Results[0].Error, gc.IsNil)
+	c.Assert(result.Results[0].Result.MigratedFrom, gc.Equals, instance.Id("juju-machine-0-lxc-0"))
+}
+
+func (s *withoutStateServerSuite) TestProvisioningInfoWithSpaces(c *gc.C) {
+	for _, info := range []state.SubnetInfo{
+		{CIDR: "10.0.1.0/24", ProviderId: "subnet-1", AvailabilityZone: "zone1"},
+		{CIDR: "10.0.2.0/24", ProviderId: "subnet-2", AvailabilityZone: "zone2"},
+		{CIDR: "10.0.3.0/24"},
+	} {
+		_, err := s.State.AddSubnet(info)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	_, err := s.State.AddSpace("db", []string{"10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24"})
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AddSpace("empty", nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	template := state.MachineTemplate{
+		Series:      "quantal",
+		Jobs:        []state.MachineJob{state.JobHostUnits},
+		Constraints: constraints.MustParse("spaces=db,^dmz"),
+	}
+	dbMachine, err := s.State.AddOneMachine(template)
+	c.Assert(err, jc.ErrorIsNil)
+	template.Constraints = constraints.MustParse("spaces=empty")
+	emptyMachine, err := s.State.AddOneMachine(template)
+	c.Assert(err, jc.ErrorIsNil)
+
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: dbMachine.Tag().String()},
+		{Tag: emptyMachine.Tag().String()},
+	}}
+	result, err := s.provisioner.ProvisioningInfo(args)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Results, gc.HasLen, 2)
+	c.Assert(result.Results[0].Error, gc.IsNil)
+	c.Assert(result.Results[0].Result.SubnetsToZones, jc.DeepEquals, map[string][]string{
+		"subnet-1": {"zone1"},
+		"subnet-2": {"zone2"},
+	})
+	c.Assert(result.Results[1].Error, gc.ErrorMatches, "no provider subnets in spaces empty")
+}
+
 func (s *withoutStateServerSuite) TestStorageProviderFallbackToType(c *gc.C) {
 	registry.RegisterProvider("dynamic", &dummy.StorageProvider{IsDynamic: true})
 	defer registry.RegisterProvider("dynamic", nil)
@@ -0,0 +1,168 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/juju/ratelimit"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/rpc"
+	"github.com/juju/juju/rpc/rpcreflect"
+)
+
+// RateLimitConfig holds the limits applied to the API requests made
+// by each authenticated entity, across all of its connections to the
+// API server. Zero values mean no limit.
+type RateLimitConfig struct {
+	// RequestsPerSecond holds the sustained number of requests
+	// per second allowed for each entity.
+	RequestsPerSecond float64
+
+	// Burst holds the number of requests an entity may make in
+	// quick succession before RequestsPerSecond applies. If it
+	// is zero, RequestsPerSecond rounded up is used.
+	Burst int64
+
+	// MaxConcurrentRequests holds the maximum number of requests
+	// each entity may have in progress at once.
+	MaxConcurrentRequests int
+}
+
+// isUnlimited reports whether the configuration imposes no limits.
+func (cfg RateLimitConfig) isUnlimited() bool {
+	return cfg.RequestsPerSecond <= 0 && cfg.MaxConcurrentRequests <= 0
+}
+
+// entityLimiters holds the request limiters for all
+// entities that have logged in to the API server.
+type entityLimiters struct {
+	config RateLimitConfig
+
+	mu       sync.Mutex
+	limiters map[string]*entityLimiter
+}
+
+func newEntityLimiters(config RateLimitConfig) *entityLimiters {
+	return &entityLimiters{
+		config:   config,
+		limiters: make(map[string]*entityLimiter),
+	}
+}
+
+// get returns the limiter for the entity with the given tag,
+// or nil if no limits are configured.
+func (l *entityLimiters) get(tag string) *entityLimiter {
+	if l == nil || l.config.isUnlimited() {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limiter, ok := l.limiters[tag]
+	if !ok {
+		limiter = newEntityLimiter(l.config)
+		l.limiters[tag] = limiter
+	}
+	return limiter
+}
+
+// entityLimiter limits the requests made by a single entity.
+type entityLimiter struct {
+	// bucket is nil if the request rate is not limited.
+	bucket        *ratelimit.Bucket
+	maxConcurrent int
+
+	mu         sync.Mutex
+	inProgress int
+}
+
+func newEntityLimiter(config RateLimitConfig) *entityLimiter {
+	limiter := &entityLimiter{
+		maxConcurrent: config.MaxConcurrentRequests,
+	}
+	if config.RequestsPerSecond > 0 {
+		burst := config.Burst
+		if burst <= 0 {
+			burst = int64(math.Ceil(config.RequestsPerSecond))
+		}
+		limiter.bucket = ratelimit.NewBucketWithRate(config.RequestsPerSecond, burst)
+	}
+	return limiter
+}
+
+// acquire reserves room for a single request, returning
+// common.ErrRateLimitExceeded if the entity has exceeded its limits.
+// On success, release must be called when the request has completed.
+func (l *entityLimiter) acquire() (release func(), err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.maxConcurrent > 0 && l.inProgress >= l.maxConcurrent {
+		return nil, common.ErrRateLimitExceeded
+	}
+	if l.bucket != nil && l.bucket.TakeAvailable(1) == 0 {
+		return nil, common.ErrRateLimitExceeded
+	}
+	l.inProgress++
+	return l.release, nil
+}
+
+func (l *entityLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inProgress--
+}
+
+// rateLimitedRoot applies an entity's request limits to the
+// API calls it makes.
+type rateLimitedRoot struct {
+	rpc.MethodFinder
+	limiter *entityLimiter
+}
+
+// newRateLimitedRoot returns a new rateLimitedRoot.
+func newRateLimitedRoot(finder rpc.MethodFinder, limiter *entityLimiter) *rateLimitedRoot {
+	return &rateLimitedRoot{
+		MethodFinder: finder,
+		limiter:      limiter,
+	}
+}
+
+// FindMethod returns a caller that applies the entity's limits to the
+// method, unless it is exempt. Pings and watcher calls are exempt:
+// pings keep the connection alive, and watcher calls block for long
+// periods waiting for changes.
+func (r *rateLimitedRoot) FindMethod(rootName string, version int, methodName string) (rpcreflect.MethodCaller, error) {
+	caller, err := r.MethodFinder.FindMethod(rootName, version, methodName)
+	if err != nil {
+		return nil, err
+	}
+	if rootName == "Pinger" || strings.HasSuffix(rootName, "Watcher") {
+		return caller, nil
+	}
+	return &rateLimitedCaller{
+		MethodCaller: caller,
+		limiter:      r.limiter,
+	}, nil
+}
+
+// rateLimitedCaller is an rpcreflect.MethodCaller that
+// applies an entity's limits to each call.
+type rateLimitedCaller struct {
+	rpcreflect.MethodCaller
+	limiter *entityLimiter
+}
+
+// Call is part of the rpcreflect.MethodCaller interface.
+func (c *rateLimitedCaller) Call(objId string, arg reflect.Value) (reflect.Value, error) {
+	release, err := c.limiter.acquire()
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	defer release()
+	return c.MethodCaller.Call(objId, arg)
+}
@@ -0,0 +1,142 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// This is an internal package test.
+
+package apiserver
+
+import (
+	"reflect"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/rpc/rpcreflect"
+	"github.com/juju/juju/testing"
+)
+
+type rateLimitSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&rateLimitSuite{})
+
+func (s *rateLimitSuite) TestUnlimited(c *gc.C) {
+	limiters := newEntityLimiters(RateLimitConfig{})
+	c.Assert(limiters.get("machine-0"), gc.IsNil)
+
+	var nilLimiters *entityLimiters
+	c.Assert(nilLimiters.get("machine-0"), gc.IsNil)
+}
+
+func (s *rateLimitSuite) TestLimitersArePerEntity(c *gc.C) {
+	limiters := newEntityLimiters(RateLimitConfig{MaxConcurrentRequests: 1})
+	limiter := limiters.get("machine-0")
+	c.Assert(limiter, gc.NotNil)
+	c.Assert(limiters.get("machine-0"), gc.Equals, limiter)
+	c.Assert(limiters.get("machine-1"), gc.Not(gc.Equals), limiter)
+}
+
+func (s *rateLimitSuite) TestMaxConcurrentRequests(c *gc.C) {
+	limiter := newEntityLimiter(RateLimitConfig{MaxConcurrentRequests: 2})
+	release1, err := limiter.acquire()
+	c.Assert(err, jc.ErrorIsNil)
+	release2, err := limiter.acquire()
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = limiter.acquire()
+	c.Assert(err, gc.Equals, common.ErrRateLimitExceeded)
+
+	release1()
+	release3, err := limiter.acquire()
+	c.Assert(err, jc.ErrorIsNil)
+	release2()
+	release3()
+}
+
+func (s *rateLimitSuite) TestRequestsPerSecond(c *gc.C) {
+	limiter := newEntityLimiter(RateLimitConfig{
+		RequestsPerSecond: 0.001,
+		Burst:             3,
+	})
+	for i := 0; i < 3; i++ {
+		release, err := limiter.acquire()
+		c.Assert(err, jc.ErrorIsNil)
+		release()
+	}
+	_, err := limiter.acquire()
+	c.Assert(err, gc.Equals, common.ErrRateLimitExceeded)
+}
+
+func (s *rateLimitSuite) TestBurstDefaultsToRate(c *gc.C) {
+	limiter := newEntityLimiter(RateLimitConfig{RequestsPerSecond: 0.01})
+	release, err := limiter.acquire()
+	c.Assert(err, jc.ErrorIsNil)
+	release()
+	_, err = limiter.acquire()
+	c.Assert(err, gc.Equals, common.ErrRateLimitExceeded)
+}
+
+func (s *rateLimitSuite) TestRateLimitedRoot(c *gc.C) {
+	limiter := newEntityLimiter(RateLimitConfig{MaxConcurrentRequests: 1})
+	finder := &blockingFinder{
+		unblock: make(chan struct{}),
+		called:  make(chan struct{}, 10),
+	}
+	root := newRateLimitedRoot(finder, limiter)
+
+	caller, err := root.FindMethod("Client", 0, "FullStatus")
+	c.Assert(err, jc.ErrorIsNil)
+	done := make(chan error)
+	go func() {
+		_, err := caller.Call("", reflect.Value{})
+		done <- err
+	}()
+	<-finder.called
+
+	// A second request is rejected while the first is in progress.
+	_, err = caller.Call("", reflect.Value{})
+	c.Assert(err, gc.Equals, common.ErrRateLimitExceeded)
+
+	// Pings and watchers are exempt.
+	for _, rootName := range []string{"Pinger", "NotifyWatcher", "AllWatcher"} {
+		caller, err := root.FindMethod(rootName, 0, "Next")
+		c.Assert(err, jc.ErrorIsNil)
+		_, ok := caller.(*rateLimitedCaller)
+		c.Check(ok, jc.IsFalse, gc.Commentf("%s", rootName))
+	}
+
+	close(finder.unblock)
+	c.Assert(<-done, jc.ErrorIsNil)
+	_, err = caller.Call("", reflect.Value{})
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+// blockingFinder is an rpc.MethodFinder whose methods block
+// until unblock is closed.
+type blockingFinder struct {
+	unblock chan struct{}
+	called  chan struct{}
+}
+
+func (f *blockingFinder) FindMethod(rootName string, version int, methodName string) (rpcreflect.MethodCaller, error) {
+	return blockingCaller{f}, nil
+}
+
+type blockingCaller struct {
+	finder *blockingFinder
+}
+
+func (c blockingCaller) ParamsType() reflect.Type {
+	return nil
+}
+
+func (c blockingCaller) ResultType() reflect.Type {
+	return nil
+}
+
+func (c blockingCaller) Call(objId string, arg reflect.Value) (reflect.Value, error) {
+	c.finder.called <- struct{}{}
+	<-c.finder.unblock
+	return reflect.Value{}, nil
+}
@@ -0,0 +1,337 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/juju/utils/set"
+	"launchpad.net/tomb"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/rpc"
+)
+
+// maxRESTBodySize holds the largest request body
+// accepted by the REST gateway.
+const maxRESTBodySize = 1 << 20
+
+// readOnlyCalls holds the API methods, as "Facade.Method", that do
+// not change the state of the environment. When the REST gateway is
+// read-only, only these methods may be called through it.
+var readOnlyCalls = set.NewStrings(
+	"Action.Actions",
+	"Action.FindActionTagsByPrefix",
+	"Action.ListAll",
+	"Action.ListCompleted",
+	"Action.ListPending",
+	"Action.ListRunning",
+	"Action.ServicesCharmActions",
+	"Annotations.Get",
+	"Backups.Info",
+	"Backups.List",
+	"Block.List",
+	"Charms.CharmInfo",
+	"Charms.List",
+	"Client.APIHostPorts",
+	"Client.AgentVersion",
+	"Client.CharmInfo",
+	"Client.EnvUserInfo",
+	"Client.EnvironmentGet",
+	"Client.EnvironmentInfo",
+	"Client.FullStatus",
+	"Client.GetAnnotations",
+	"Client.GetEnvironmentConstraints",
+	"Client.GetServiceConstraints",
+	"Client.PrivateAddress",
+	"Client.PublicAddress",
+	"Client.ServiceCharmRelations",
+	"Client.ServiceGet",
+	"Client.ServiceGetCharmURL",
+	"Client.Status",
+	"Client.UnitHistory",
+	"EnvironmentManager.ListEnvironments",
+	"ImageManager.ListImages",
+	"KeyManager.ListKeys",
+	"Pinger.Ping",
+	"Schema.FacadeSchemas",
+	"Storage.List",
+	"Storage.ListPools",
+	"Storage.ListVolumes",
+	"Storage.Show",
+	"UserManager.UserInfo",
+)
+
+// isCallReadOnly reports whether the given API method
+// does not change the state of the environment.
+func isCallReadOnly(facade, method string) bool {
+	return readOnlyCalls.Contains(facade + "." + method)
+}
+
+// restHandler serves single API calls made with plain HTTP requests,
+// for clients that cannot speak the websocket RPC protocol. A request
+// of the form
+//
+//	GET /environment/:envuuid/api/:facade/:version/:method[?id=:id]
+//
+// with a JSON-encoded body holding the call's parameters is served
+// as if it were made on a new websocket connection, logged in with
+// the credentials held in the request's basic authentication header.
+// The call's JSON-encoded result is returned as the response body.
+// On failure, the response body holds a params.Error.
+type restHandler struct {
+	srv *Server
+
+	// readOnly restricts the calls that may be made to
+	// those in readOnlyCalls.
+	readOnly bool
+}
+
+func (h *restHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		h.sendError(w, http.StatusMethodNotAllowed, errors.Errorf("unsupported method: %q", r.Method))
+		return
+	}
+	query := r.URL.Query()
+	facade := query.Get(":facade")
+	method := query.Get(":method")
+	version, err := strconv.Atoi(query.Get(":version"))
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, errors.Errorf("invalid facade version %q", query.Get(":version")))
+		return
+	}
+	if h.readOnly && !isCallReadOnly(facade, method) {
+		h.sendError(w, http.StatusForbidden, errors.Errorf("%s.%s is not a read-only call", facade, method))
+		return
+	}
+	loginRequest, err := parseBasicAuth(r)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Basic realm="juju"`)
+		h.sendError(w, http.StatusUnauthorized, err)
+		return
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxRESTBodySize+1))
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, errors.Annotate(err, "cannot read request body"))
+		return
+	}
+	if len(body) > maxRESTBodySize {
+		h.sendError(w, http.StatusRequestEntityTooLarge, errors.New("request body too large"))
+		return
+	}
+
+	codec, err := newRESTCodec(loginRequest, rpc.Request{
+		Type:    facade,
+		Version: version,
+		Id:      query.Get("id"),
+		Action:  method,
+	}, body)
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !h.serve(r, codec) {
+		h.sendError(w, http.StatusServiceUnavailable, errors.New("API server is stopping"))
+		return
+	}
+	if err := codec.loginError(); err != nil {
+		w.Header().Set("WWW-Authenticate", `Basic realm="juju"`)
+		h.sendError(w, http.StatusUnauthorized, err)
+		return
+	}
+	result, err := codec.result()
+	if err != nil {
+		h.sendError(w, restStatusCode(err), err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(result)
+}
+
+// serve serves the requests held by the given codec, as for a
+// websocket connection. It returns false if the server is stopping.
+func (h *restHandler) serve(r *http.Request, codec *restCodec) bool {
+	srv := h.srv
+	srv.wg.Add(1)
+	defer srv.wg.Done()
+	// See apiHandler for why the tomb is checked after wg.Add.
+	if srv.tomb.Err() != tomb.ErrStillAlive {
+		return false
+	}
+	reqNotifier := newRequestNotifier()
+	reqNotifier.join(r)
+	defer reqNotifier.leave()
+	envUUID := r.URL.Query().Get(":envuuid")
+	if err := srv.serveCodec(codec, reqNotifier, envUUID); err != nil {
+		logger.Errorf("error serving REST request: %v", err)
+	}
+	return true
+}
+
+// sendError sends a JSON-encoded params.Error response.
+func (h *restHandler) sendError(w http.ResponseWriter, statusCode int, err error) {
+	logger.Debugf("sending error: %v %v", statusCode, err)
+	body, err := json.Marshal(common.ServerError(err))
+	if err != nil {
+		logger.Errorf("failed to send error: %v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(body)
+}
+
+// restStatusCode returns the HTTP status code
+// corresponding to the given API error.
+func restStatusCode(err error) int {
+	switch params.ErrCode(err) {
+	case params.CodeUnauthorized:
+		return http.StatusForbidden
+	case params.CodeNotFound, params.CodeNotImplemented:
+		return http.StatusNotFound
+	case params.CodeRateLimitExceeded, params.CodeTryAgain, params.CodeUpgradeInProgress:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// restCodec is an rpc.Codec that feeds a login request followed by a
+// single API call to the rpc package, and records their replies. Each
+// request is only read once the reply to the one before it has been
+// written, so that the call is made as the logged in entity.
+type restCodec struct {
+	requests []restMessage
+	replies  []restMessage
+
+	// next holds the index of the next request to be read.
+	next int
+
+	// replied receives a value for each reply written.
+	replied chan struct{}
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// restMessage holds a request or reply with its JSON-encoded body.
+type restMessage struct {
+	hdr  rpc.Header
+	body []byte
+}
+
+func newRESTCodec(login params.LoginRequest, call rpc.Request, callParams []byte) (*restCodec, error) {
+	loginParams, err := json.Marshal(login)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	requests := []restMessage{{
+		hdr: rpc.Header{
+			RequestId: 1,
+			Request: rpc.Request{
+				Type:    "Admin",
+				Version: 2,
+				Action:  "Login",
+			},
+		},
+		body: loginParams,
+	}, {
+		hdr: rpc.Header{
+			RequestId: 2,
+			Request:   call,
+		},
+		body: callParams,
+	}}
+	return &restCodec{
+		requests: requests,
+		replied:  make(chan struct{}, len(requests)),
+		closed:   make(chan struct{}),
+	}, nil
+}
+
+// ReadHeader is part of the rpc.Codec interface.
+func (c *restCodec) ReadHeader(hdr *rpc.Header) error {
+	if c.next > 0 {
+		select {
+		case <-c.replied:
+		case <-c.closed:
+			return io.EOF
+		}
+		if c.next == len(c.requests) || c.replies[c.next-1].hdr.Error != "" {
+			return io.EOF
+		}
+	}
+	*hdr = c.requests[c.next].hdr
+	c.next++
+	return nil
+}
+
+// ReadBody is part of the rpc.Codec interface.
+func (c *restCodec) ReadBody(body interface{}, isRequest bool) error {
+	data := c.requests[c.next-1].body
+	if body == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, body)
+}
+
+// WriteMessage is part of the rpc.Codec interface.
+func (c *restCodec) WriteMessage(hdr *rpc.Header, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	c.replies = append(c.replies, restMessage{hdr: *hdr, body: data})
+	c.replied <- struct{}{}
+	return nil
+}
+
+// Close is part of the rpc.Codec interface.
+func (c *restCodec) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+	return nil
+}
+
+// loginError returns the error returned by the login request, if any.
+// It must only be called once the codec's requests have been served.
+func (c *restCodec) loginError() error {
+	if len(c.replies) == 0 {
+		return errors.New("no reply to login request")
+	}
+	return replyError(c.replies[0].hdr)
+}
+
+// result returns the JSON-encoded result of the API call, or the
+// error it returned. It must only be called once the codec's
+// requests have been served and the login has succeeded.
+func (c *restCodec) result() ([]byte, error) {
+	if len(c.replies) < 2 {
+		return nil, errors.New("no reply to API call")
+	}
+	reply := c.replies[1]
+	if err := replyError(reply.hdr); err != nil {
+		return nil, err
+	}
+	return reply.body, nil
+}
+
+// replyError returns the error held in the header of a reply, if any.
+func replyError(hdr rpc.Header) error {
+	if hdr.Error == "" {
+		return nil
+	}
+	return &params.Error{
+		Message: hdr.Error,
+		Code:    hdr.ErrorCode,
+	}
+}
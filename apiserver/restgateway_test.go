@@ -0,0 +1,140 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/api"
+	"github.com/juju/juju/apiserver"
+	apihttp "github.com/juju/juju/apiserver/http"
+	"github.com/juju/juju/apiserver/params"
+	coretesting "github.com/juju/juju/testing"
+)
+
+type restSuite struct {
+	userAuthHttpSuite
+}
+
+var _ = gc.Suite(&restSuite{})
+
+func (s *restSuite) restURL(c *gc.C, call string) string {
+	return s.makeURL(c, "https", fmt.Sprintf("/environment/%s/api/%s", s.envUUID, call), nil).String()
+}
+
+func (s *restSuite) assertRESTError(c *gc.C, resp *http.Response, expCode int, expError string) *params.Error {
+	body := assertResponse(c, resp, expCode, apihttp.CTypeJSON)
+	var result params.Error
+	err := json.Unmarshal(body, &result)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(result.Message, gc.Matches, expError)
+	return &result
+}
+
+func (s *restSuite) TestCall(c *gc.C) {
+	resp, err := s.authRequest(c, "GET", s.restURL(c, "Client/0/EnvironmentInfo"), apihttp.CTypeJSON, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	body := assertResponse(c, resp, http.StatusOK, apihttp.CTypeJSON)
+	var info api.EnvironmentInfo
+	err = json.Unmarshal(body, &info)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(info.UUID, gc.Equals, s.State.EnvironUUID())
+}
+
+func (s *restSuite) TestCallWithParams(c *gc.C) {
+	s.Factory.MakeService(c, nil)
+	resp, err := s.authRequest(c, "GET", s.restURL(c, "Client/0/ServiceGetCharmURL"),
+		apihttp.CTypeJSON, strings.NewReader(`{"ServiceName": "mysql"}`))
+	c.Assert(err, jc.ErrorIsNil)
+	body := assertResponse(c, resp, http.StatusOK, apihttp.CTypeJSON)
+	var result params.StringResult
+	err = json.Unmarshal(body, &result)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Result, gc.Matches, "cs:quantal/mysql-.*")
+}
+
+func (s *restSuite) TestCallError(c *gc.C) {
+	resp, err := s.authRequest(c, "GET", s.restURL(c, "Client/0/ServiceGet"),
+		apihttp.CTypeJSON, strings.NewReader(`{"ServiceName": "nope"}`))
+	c.Assert(err, jc.ErrorIsNil)
+	result := s.assertRESTError(c, resp, http.StatusNotFound, `service "nope" not found`)
+	c.Assert(result.Code, gc.Equals, params.CodeNotFound)
+}
+
+func (s *restSuite) TestUnknownMethod(c *gc.C) {
+	resp, err := s.authRequest(c, "GET", s.restURL(c, "Client/0/Frob"), apihttp.CTypeJSON, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertRESTError(c, resp, http.StatusNotFound, `no such request - method Client\(0\).Frob is not implemented`)
+}
+
+func (s *restSuite) TestInvalidVersion(c *gc.C) {
+	resp, err := s.authRequest(c, "GET", s.restURL(c, "Client/zero/FullStatus"), apihttp.CTypeJSON, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertRESTError(c, resp, http.StatusBadRequest, `invalid facade version "zero"`)
+}
+
+func (s *restSuite) TestRequiresGET(c *gc.C) {
+	resp, err := s.authRequest(c, "POST", s.restURL(c, "Client/0/FullStatus"), apihttp.CTypeJSON, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertRESTError(c, resp, http.StatusMethodNotAllowed, `unsupported method: "POST"`)
+}
+
+func (s *restSuite) TestRequiresAuth(c *gc.C) {
+	resp, err := s.sendRequest(c, "", "", "GET", s.restURL(c, "Client/0/FullStatus"), apihttp.CTypeJSON, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertRESTError(c, resp, http.StatusUnauthorized, "invalid request format")
+}
+
+func (s *restSuite) TestBadCredentials(c *gc.C) {
+	resp, err := s.sendRequest(c, s.userTag.String(), "wrong", "GET", s.restURL(c, "Client/0/FullStatus"), apihttp.CTypeJSON, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertRESTError(c, resp, http.StatusUnauthorized, "invalid entity name or password")
+	c.Assert(resp.Header.Get("WWW-Authenticate"), gc.Equals, `Basic realm="juju"`)
+}
+
+func (s *restSuite) TestAgentsAreAuthorizedAsOverWebsockets(c *gc.C) {
+	unit, password := s.Factory.MakeUnitReturningPassword(c, nil)
+	resp, err := s.sendRequest(c, unit.Tag().String(), password, "GET", s.restURL(c, "Client/0/FullStatus"), apihttp.CTypeJSON, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertRESTError(c, resp, http.StatusForbidden, "permission denied")
+}
+
+func (s *restSuite) TestReadOnly(c *gc.C) {
+	listener, err := net.Listen("tcp", ":0")
+	c.Assert(err, jc.ErrorIsNil)
+	srv, err := apiserver.NewServer(s.State, listener, apiserver.ServerConfig{
+		Cert:         []byte(coretesting.ServerCert),
+		Key:          []byte(coretesting.ServerKey),
+		Tag:          names.NewMachineTag("0"),
+		RESTReadOnly: true,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	defer srv.Stop()
+	restURL := func(call string) string {
+		u := &url.URL{
+			Scheme: "https",
+			Host:   srv.Addr(),
+			Path:   fmt.Sprintf("/environment/%s/api/%s", s.envUUID, call),
+		}
+		return u.String()
+	}
+
+	resp, err := s.authRequest(c, "GET", restURL("Client/0/AddMachines"),
+		apihttp.CTypeJSON, strings.NewReader(`{"MachineParams": [{"Jobs": ["JobHostUnits"]}]}`))
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertRESTError(c, resp, http.StatusForbidden, "Client.AddMachines is not a read-only call")
+
+	resp, err = s.authRequest(c, "GET", restURL("Client/0/EnvironmentInfo"), apihttp.CTypeJSON, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	assertResponse(c, resp, http.StatusOK, apihttp.CTypeJSON)
+}
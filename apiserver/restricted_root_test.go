@@ -41,6 +41,8 @@ func (r *restrictedRootSuite) TestFindAllowedMethod(c *gc.C) {
 	r.assertMethodAllowed(c, "UserManager", 0, "AddUser")
 	r.assertMethodAllowed(c, "UserManager", 0, "SetPassword")
 	r.assertMethodAllowed(c, "UserManager", 0, "UserInfo")
+
+	r.assertMethodAllowed(c, "Schema", 1, "FacadeSchemas")
 }
 
 func (r *restrictedRootSuite) TestFindDisallowedMethod(c *gc.C) {
@@ -36,6 +36,7 @@ type objectKey struct {
 	name    string
 	version int
 	objId   string
+	traceId string
 }
 
 // apiHandler represents a single client's connection to the state
@@ -173,13 +174,24 @@ func (r *apiRoot) Cleanup() {
 // For more information about how FindMethod should work, see rpc/server.go and
 // rpc/rpcreflect/value.go
 func (r *apiRoot) FindMethod(rootName string, version int, methodName string) (rpcreflect.MethodCaller, error) {
+	return r.findMethod(rootName, version, methodName, "")
+}
+
+// FindTracedMethod implements rpc.TracedMethodFinder. It is like
+// FindMethod, but the facade it calls acts on a State that records the
+// given trace id with the changes it makes.
+func (r *apiRoot) FindTracedMethod(rootName string, version int, methodName, traceId string) (rpcreflect.MethodCaller, error) {
+	return r.findMethod(rootName, version, methodName, traceId)
+}
+
+func (r *apiRoot) findMethod(rootName string, version int, methodName, traceId string) (rpcreflect.MethodCaller, error) {
 	goType, objMethod, err := r.lookupMethod(rootName, version, methodName)
 	if err != nil {
 		return nil, err
 	}
 
 	creator := func(id string) (reflect.Value, error) {
-		objKey := objectKey{name: rootName, version: version, objId: id}
+		objKey := objectKey{name: rootName, version: version, objId: id, traceId: traceId}
 		r.objectMutex.RLock()
 		objValue, ok := r.objectCache[objKey]
 		r.objectMutex.RUnlock()
@@ -200,7 +212,7 @@ func (r *apiRoot) FindMethod(rootName string, version int, methodName string) (r
 			// check.
 			return reflect.Value{}, err
 		}
-		obj, err := factory(r.state, r.resources, r.authorizer, id)
+		obj, err := factory(r.state.WithTraceId(traceId), r.resources, r.authorizer, id)
 		if err != nil {
 			return reflect.Value{}, err
 		}
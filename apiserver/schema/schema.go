@@ -0,0 +1,106 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The schema package implements the Schema facade, which describes
+// the parameters and results of every method of the registered API
+// facades as JSON schemas, so that third-party clients need not
+// reverse-engineer them from the Go source.
+package schema
+
+import (
+	"reflect"
+
+	"github.com/juju/errors"
+	"gopkg.in/juju/charm.v5-unstable"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/rpc/jsonschema"
+	"github.com/juju/juju/rpc/rpcreflect"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/multiwatcher"
+	"github.com/juju/juju/version"
+)
+
+func init() {
+	common.RegisterStandardFacade("Schema", 1, NewSchemaAPI)
+}
+
+// Schema defines the methods on the Schema API end point.
+type Schema interface {
+	FacadeSchemas(args params.FacadeSchemaArgs) (params.FacadeSchemaResults, error)
+}
+
+// SchemaAPI implements the Schema interface and is the concrete
+// implementation of the api end point.
+type SchemaAPI struct {
+	registry *common.FacadeRegistry
+}
+
+var _ Schema = (*SchemaAPI)(nil)
+
+// NewSchemaAPI creates a new server-side Schema API end point. The
+// schemas hold no environment data, so any authenticated entity may
+// access them.
+func NewSchemaAPI(st *state.State, resources *common.Resources, authorizer common.Authorizer) (*SchemaAPI, error) {
+	return &SchemaAPI{registry: common.Facades}, nil
+}
+
+// FacadeSchemas returns the schemas of all versions of the
+// requested facades.
+func (api *SchemaAPI) FacadeSchemas(args params.FacadeSchemaArgs) (params.FacadeSchemaResults, error) {
+	schemas, err := Generate(api.registry, args.Names...)
+	if err != nil {
+		return params.FacadeSchemaResults{}, err
+	}
+	return params.FacadeSchemaResults{Schemas: schemas}, nil
+}
+
+// overrides holds the schemas of the types sent over the API that
+// have a custom JSON encoding.
+var overrides = map[reflect.Type]*jsonschema.Schema{
+	reflect.TypeOf(version.Number{}): {Type: "string"},
+	reflect.TypeOf(version.Binary{}): {Type: "string"},
+	reflect.TypeOf(charm.URL{}):      {Type: "string"},
+	// A delta is encoded as a [kind, operation, entity] array.
+	reflect.TypeOf(multiwatcher.Delta{}): {
+		Type:  "array",
+		Items: &jsonschema.Schema{},
+	},
+}
+
+// Generate returns the schemas of all versions of the named facades
+// in the given registry, or of all its facades if no names are given.
+// Facades whose feature flags are not enabled are not included.
+func Generate(registry *common.FacadeRegistry, names ...string) ([]params.FacadeSchema, error) {
+	descriptions := registry.List()
+	if len(names) > 0 {
+		byName := make(map[string]common.FacadeDescription)
+		for _, description := range descriptions {
+			byName[description.Name] = description
+		}
+		descriptions = make([]common.FacadeDescription, len(names))
+		for i, name := range names {
+			description, ok := byName[name]
+			if !ok {
+				return nil, errors.NotFoundf("facade %q", name)
+			}
+			descriptions[i] = description
+		}
+	}
+	var schemas []params.FacadeSchema
+	for _, description := range descriptions {
+		for _, version := range description.Versions {
+			facadeType, err := registry.GetType(description.Name, version)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			schemas = append(schemas, params.FacadeSchema{
+				Name:    description.Name,
+				Version: version,
+				Schema:  jsonschema.ForObjType(rpcreflect.ObjTypeOf(facadeType), overrides),
+			})
+		}
+	}
+	return schemas, nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package schema_test
+
+import (
+	"reflect"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/apiserver/schema"
+	"github.com/juju/juju/rpc/jsonschema"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/testing"
+)
+
+type schemaSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&schemaSuite{})
+
+type fooAPI struct{}
+
+func (fooAPI) Frob(args params.Entities) (params.ErrorResults, error) {
+	return params.ErrorResults{}, nil
+}
+
+type barAPI struct{}
+
+func (barAPI) Version() (params.Version, error) {
+	return params.Version{}, nil
+}
+
+func newRegistry(c *gc.C) *common.FacadeRegistry {
+	registry := &common.FacadeRegistry{}
+	factory := func(*state.State, *common.Resources, common.Authorizer, string) (interface{}, error) {
+		return nil, nil
+	}
+	err := registry.Register("Foo", 1, factory, reflect.TypeOf(fooAPI{}), "")
+	c.Assert(err, jc.ErrorIsNil)
+	err = registry.Register("Foo", 2, factory, reflect.TypeOf(fooAPI{}), "")
+	c.Assert(err, jc.ErrorIsNil)
+	err = registry.Register("Bar", 0, factory, reflect.TypeOf(barAPI{}), "")
+	c.Assert(err, jc.ErrorIsNil)
+	err = registry.Register("Baz", 1, factory, reflect.TypeOf(barAPI{}), "no-such-feature")
+	c.Assert(err, jc.ErrorIsNil)
+	return registry
+}
+
+func (s *schemaSuite) TestGenerate(c *gc.C) {
+	schemas, err := schema.Generate(newRegistry(c))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(schemas, gc.HasLen, 3)
+	for i, expect := range []struct {
+		name    string
+		version int
+	}{{"Bar", 0}, {"Foo", 1}, {"Foo", 2}} {
+		c.Check(schemas[i].Name, gc.Equals, expect.name)
+		c.Check(schemas[i].Version, gc.Equals, expect.version)
+	}
+
+	foo := schemas[1].Schema
+	c.Assert(foo.Properties["Frob"], jc.DeepEquals, &jsonschema.Schema{
+		Type: "object",
+		Properties: map[string]*jsonschema.Schema{
+			"Params": {Ref: "#/definitions/Entities"},
+			"Result": {Ref: "#/definitions/ErrorResults"},
+		},
+	})
+	c.Assert(foo.Definitions["Entity"], jc.DeepEquals, &jsonschema.Schema{
+		Type: "object",
+		Properties: map[string]*jsonschema.Schema{
+			"Tag": {Type: "string"},
+		},
+	})
+}
+
+func (s *schemaSuite) TestGenerateUsesOverrides(c *gc.C) {
+	schemas, err := schema.Generate(newRegistry(c), "Bar")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(schemas, gc.HasLen, 1)
+	// params.Version holds a version.Binary, which
+	// is encoded as a string.
+	c.Assert(schemas[0].Schema.Definitions["Version"].Properties["Version"], jc.DeepEquals, &jsonschema.Schema{
+		Type: "string",
+	})
+}
+
+func (s *schemaSuite) TestGenerateNamedFacades(c *gc.C) {
+	schemas, err := schema.Generate(newRegistry(c), "Foo")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(schemas, gc.HasLen, 2)
+	c.Assert(schemas[0].Name, gc.Equals, "Foo")
+	c.Assert(schemas[1].Name, gc.Equals, "Foo")
+
+	_, err = schema.Generate(newRegistry(c), "Foo", "Baz")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	c.Assert(err, gc.ErrorMatches, `facade "Baz" not found`)
+}
+
+func (s *schemaSuite) TestFacadeSchemas(c *gc.C) {
+	api, err := schema.NewSchemaAPI(nil, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	results, err := api.FacadeSchemas(params.FacadeSchemaArgs{
+		Names: []string{"Schema"},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(results.Schemas, gc.HasLen, 1)
+	c.Assert(results.Schemas[0].Name, gc.Equals, "Schema")
+	c.Assert(results.Schemas[0].Version, gc.Equals, 1)
+	c.Assert(results.Schemas[0].Schema.Properties["FacadeSchemas"].Properties["Params"], jc.DeepEquals, &jsonschema.Schema{
+		Ref: "#/definitions/FacadeSchemaArgs",
+	})
+}
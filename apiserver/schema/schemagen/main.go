@@ -0,0 +1,39 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The schemagen command writes the JSON schemas of all the API
+// facades to its standard output. Facades only available when a
+// feature flag is enabled are included if the flag is set in the
+// JUJU_DEV_FEATURE_FLAGS environment variable. Run with:
+//
+//	go run apiserver/schema/schemagen/main.go > schema.json
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/juju/utils/featureflag"
+
+	// Import the API server so that all facades are registered.
+	_ "github.com/juju/juju/apiserver"
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/schema"
+	"github.com/juju/juju/juju/osenv"
+)
+
+func main() {
+	featureflag.SetFlagsFromEnvironment(osenv.JujuFeatureFlagEnvKey)
+	schemas, err := schema.Generate(common.Facades)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot generate schemas: %v\n", err)
+		os.Exit(1)
+	}
+	data, err := json.MarshalIndent(schemas, "", "    ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot marshal schemas: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s\n", data)
+}
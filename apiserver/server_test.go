@@ -26,6 +26,8 @@ import (
 	jujutesting "github.com/juju/juju/juju/testing"
 	"github.com/juju/juju/network"
 	"github.com/juju/juju/rpc"
+	"github.com/juju/juju/rpc/bsoncodec"
+	"github.com/juju/juju/rpc/jsoncodec"
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/presence"
 	coretesting "github.com/juju/juju/testing"
@@ -260,11 +262,12 @@ func (s *serverSuite) assertAlive(c *gc.C, entity presence.Presencer, isAlive bo
 	c.Assert(alive, gc.Equals, isAlive)
 }
 
-func dialWebsocket(c *gc.C, addr, path string) (*websocket.Conn, error) {
+func dialWebsocket(c *gc.C, addr, path string, protocols ...string) (*websocket.Conn, error) {
 	origin := "http://localhost/"
 	url := fmt.Sprintf("wss://%s%s", addr, path)
 	config, err := websocket.NewConfig(url, origin)
 	c.Assert(err, jc.ErrorIsNil)
+	config.Protocol = protocols
 	pool := x509.NewCertPool()
 	xcert, err := cert.ParseCert(coretesting.CACert)
 	c.Assert(err, jc.ErrorIsNil)
@@ -310,6 +313,77 @@ func (s *serverSuite) TestNonCompatiblePathsAre404(c *gc.C) {
 	c.Assert(conn, gc.IsNil)
 }
 
+func (s *serverSuite) TestCodecNegotiation(c *gc.C) {
+	listener, err := net.Listen("tcp", ":0")
+	c.Assert(err, jc.ErrorIsNil)
+	srv, err := apiserver.NewServer(s.State, listener, apiserver.ServerConfig{
+		Cert: []byte(coretesting.ServerCert),
+		Key:  []byte(coretesting.ServerKey),
+		Tag:  names.NewMachineTag("0"),
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	defer srv.Stop()
+	_, portString, err := net.SplitHostPort(srv.Addr())
+	c.Assert(err, jc.ErrorIsNil)
+	addr := "localhost:" + portString
+
+	for i, test := range []struct {
+		offered []string
+		expect  []string
+	}{{
+		offered: nil,
+		expect:  nil,
+	}, {
+		offered: []string{jsoncodec.Protocol},
+		expect:  []string{jsoncodec.Protocol},
+	}, {
+		offered: []string{jsoncodec.Protocol, bsoncodec.Protocol},
+		expect:  []string{bsoncodec.Protocol},
+	}, {
+		offered: []string{"unknown"},
+		expect:  nil,
+	}} {
+		c.Logf("test %d: %v", i, test.offered)
+		conn, err := dialWebsocket(c, addr, "/", test.offered...)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(conn.Config().Protocol, jc.DeepEquals, test.expect)
+		conn.Close()
+	}
+}
+
+func (s *serverSuite) TestTracedRequestsAlwaysLogged(c *gc.C) {
+	loggo.GetLogger("juju.apiserver").SetLogLevel(loggo.WARNING)
+	loggo.GetLogger("juju.apiserver.trace").SetLogLevel(loggo.INFO)
+	var tw loggo.TestWriter
+	c.Assert(loggo.RegisterWriter("traced-requests", &tw, loggo.INFO), jc.ErrorIsNil)
+	defer loggo.RemoveWriter("traced-requests")
+
+	st, err := api.Open(s.APIInfo(c), api.DialOpts{TraceId: "deploy-1"})
+	c.Assert(err, jc.ErrorIsNil)
+	defer st.Close()
+	_, err = st.Client().Status(nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	c.Assert(tw.Log(), jc.LogMatches, []jc.SimpleMessage{
+		{loggo.INFO, `trace:deploy-1 <- \[[0-9A-F]+\] user-admin .*FullStatus.*`},
+		{loggo.INFO, `trace:deploy-1 -> \[[0-9A-F]+\] user-admin .*FullStatus`},
+	})
+}
+
+func (s *serverSuite) TestTracedRequestsRecordTraceId(c *gc.C) {
+	svc := s.Factory.MakeService(c, nil)
+
+	st, err := api.Open(s.APIInfo(c), api.DialOpts{TraceId: "deploy-1"})
+	c.Assert(err, jc.ErrorIsNil)
+	defer st.Close()
+	err = st.Client().ServiceExpose(svc.Name())
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = svc.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(svc.TraceId(), gc.Equals, "deploy-1")
+}
+
 type fakeResource struct {
 	stopped bool
 }
@@ -319,6 +393,83 @@ func (r *fakeResource) Stop() error {
 	return nil
 }
 
+func (s *serverSuite) TestRateLimit(c *gc.C) {
+	s.PatchValue(&api.RateLimitBackoff, nil)
+	srv, apiInfo, machine := s.startRateLimitedServer(c, apiserver.RateLimitConfig{
+		RequestsPerSecond: 0.001,
+		Burst:             1,
+	})
+	defer srv.Stop()
+	st, err := api.Open(apiInfo, fastDialOpts)
+	c.Assert(err, jc.ErrorIsNil)
+	defer st.Close()
+
+	_, err = st.Machiner().Machine(machine.MachineTag())
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = st.Machiner().Machine(machine.MachineTag())
+	c.Assert(err, gc.ErrorMatches, "rate limit exceeded")
+	c.Assert(params.IsCodeRateLimitExceeded(err), jc.IsTrue)
+
+	// Pings are not limited.
+	err = st.Ping()
+	c.Assert(err, jc.ErrorIsNil)
+
+	// The limits apply across all of an entity's connections.
+	st2, err := api.Open(apiInfo, fastDialOpts)
+	c.Assert(err, jc.ErrorIsNil)
+	defer st2.Close()
+	_, err = st2.Machiner().Machine(machine.MachineTag())
+	c.Assert(params.IsCodeRateLimitExceeded(err), jc.IsTrue)
+}
+
+func (s *serverSuite) TestRateLimitedCallsAreRetried(c *gc.C) {
+	s.PatchValue(&api.RateLimitBackoff, []time.Duration{
+		50 * time.Millisecond,
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+	})
+	srv, apiInfo, machine := s.startRateLimitedServer(c, apiserver.RateLimitConfig{
+		RequestsPerSecond: 10,
+		Burst:             1,
+	})
+	defer srv.Stop()
+	st, err := api.Open(apiInfo, fastDialOpts)
+	c.Assert(err, jc.ErrorIsNil)
+	defer st.Close()
+
+	for i := 0; i < 3; i++ {
+		_, err = st.Machiner().Machine(machine.MachineTag())
+		c.Assert(err, jc.ErrorIsNil)
+	}
+}
+
+// startRateLimitedServer starts an API server with the given request
+// limits, and returns it along with the details needed to connect to
+// it as a new machine.
+func (s *serverSuite) startRateLimitedServer(c *gc.C, rateLimit apiserver.RateLimitConfig) (*apiserver.Server, *api.Info, *state.Machine) {
+	listener, err := net.Listen("tcp", ":0")
+	c.Assert(err, jc.ErrorIsNil)
+	srv, err := apiserver.NewServer(s.State, listener, apiserver.ServerConfig{
+		Cert:      []byte(coretesting.ServerCert),
+		Key:       []byte(coretesting.ServerKey),
+		Tag:       names.NewMachineTag("0"),
+		RateLimit: rateLimit,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+
+	machine, password := s.Factory.MakeMachineReturningPassword(
+		c, &factory.MachineParams{Nonce: "fake_nonce"})
+	apiInfo := &api.Info{
+		Tag:        machine.Tag(),
+		Password:   password,
+		Nonce:      "fake_nonce",
+		Addrs:      []string{srv.Addr()},
+		CACert:     coretesting.CACert,
+		EnvironTag: s.State.EnvironTag(),
+	}
+	return srv, apiInfo, machine
+}
+
 func (s *serverSuite) TestRootTeardown(c *gc.C) {
 	s.checkRootTeardown(c, false)
 }
@@ -0,0 +1,116 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The spaces package implements the Spaces facade, which manages the
+// named sets of subnets ("spaces") of an environment and the bindings
+// of services' endpoints to them.
+package spaces
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/names"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+)
+
+func init() {
+	common.RegisterStandardFacade("Spaces", 1, NewSpacesAPI)
+}
+
+// Spaces defines the methods on the Spaces API end point.
+type Spaces interface {
+	CreateSpaces(args params.CreateSpacesParams) (params.ErrorResults, error)
+	ListSpaces() (params.ListSpacesResults, error)
+	SetEndpointBindings(args params.SetEndpointBindingsParams) (params.ErrorResults, error)
+}
+
+// SpacesAPI implements the Spaces interface and is the concrete
+// implementation of the api end point.
+type SpacesAPI struct {
+	st         *state.State
+	authorizer common.Authorizer
+	check      *common.BlockChecker
+}
+
+var _ Spaces = (*SpacesAPI)(nil)
+
+// NewSpacesAPI creates a new server-side Spaces API end point.
+func NewSpacesAPI(st *state.State, resources *common.Resources, authorizer common.Authorizer) (*SpacesAPI, error) {
+	if !authorizer.AuthClient() {
+		return nil, common.ErrPerm
+	}
+	return &SpacesAPI{
+		st:         st,
+		authorizer: authorizer,
+		check:      common.NewBlockChecker(st),
+	}, nil
+}
+
+// CreateSpaces creates the given spaces, each containing the
+// subnets with the given CIDRs.
+func (api *SpacesAPI) CreateSpaces(args params.CreateSpacesParams) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Spaces)),
+	}
+	if err := api.check.ChangeAllowed(); err != nil {
+		return result, errors.Trace(err)
+	}
+	for i, arg := range args.Spaces {
+		_, err := api.st.AddSpace(arg.Name, arg.SubnetCIDRs)
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// ListSpaces returns all the spaces in the environment, along with
+// the CIDRs of their subnets.
+func (api *SpacesAPI) ListSpaces() (params.ListSpacesResults, error) {
+	spaces, err := api.st.AllSpaces()
+	if err != nil {
+		return params.ListSpacesResults{}, errors.Trace(err)
+	}
+	result := params.ListSpacesResults{
+		Results: make([]params.Space, len(spaces)),
+	}
+	for i, space := range spaces {
+		cidrs, err := space.SubnetCIDRs()
+		if err != nil {
+			return params.ListSpacesResults{}, errors.Trace(err)
+		}
+		result.Results[i] = params.Space{
+			Name:        space.Name(),
+			SubnetCIDRs: cidrs,
+		}
+	}
+	return result, nil
+}
+
+// SetEndpointBindings binds the endpoints of the given services to
+// spaces, replacing any existing bindings of those services.
+func (api *SpacesAPI) SetEndpointBindings(args params.SetEndpointBindingsParams) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Services)),
+	}
+	if err := api.check.ChangeAllowed(); err != nil {
+		return result, errors.Trace(err)
+	}
+	for i, arg := range args.Services {
+		err := api.setEndpointBindings(arg)
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+func (api *SpacesAPI) setEndpointBindings(arg params.ServiceEndpointBindings) error {
+	tag, err := names.ParseServiceTag(arg.ServiceTag)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	service, err := api.st.Service(tag.Id())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return service.SetEndpointBindings(arg.Bindings)
+}
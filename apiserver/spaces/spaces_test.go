@@ -0,0 +1,124 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package spaces_test
+
+import (
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	commontesting "github.com/juju/juju/apiserver/common/testing"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/apiserver/spaces"
+	apiservertesting "github.com/juju/juju/apiserver/testing"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+)
+
+type spacesSuite struct {
+	jujutesting.JujuConnSuite
+	commontesting.BlockHelper
+
+	api        *spaces.SpacesAPI
+	authorizer apiservertesting.FakeAuthorizer
+}
+
+var _ = gc.Suite(&spacesSuite{})
+
+func (s *spacesSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.authorizer = apiservertesting.FakeAuthorizer{
+		Tag: s.AdminUserTag(c),
+	}
+	var err error
+	s.api, err = spaces.NewSpacesAPI(s.State, nil, s.authorizer)
+	c.Assert(err, jc.ErrorIsNil)
+
+	s.BlockHelper = commontesting.NewBlockHelper(s.APIState)
+	s.AddCleanup(func(*gc.C) { s.BlockHelper.Close() })
+
+	for _, cidr := range []string{"10.0.1.0/24", "10.0.2.0/24"} {
+		_, err := s.State.AddSubnet(state.SubnetInfo{CIDR: cidr})
+		c.Assert(err, jc.ErrorIsNil)
+	}
+}
+
+func (s *spacesSuite) TestNewSpacesAPIRefusesNonClient(c *gc.C) {
+	anAuthorizer := s.authorizer
+	anAuthorizer.Tag = names.NewMachineTag("1")
+	api, err := spaces.NewSpacesAPI(s.State, nil, anAuthorizer)
+	c.Assert(api, gc.IsNil)
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
+
+func (s *spacesSuite) TestCreateAndListSpaces(c *gc.C) {
+	result, err := s.api.CreateSpaces(params.CreateSpacesParams{
+		Spaces: []params.CreateSpaceParams{
+			{Name: "db", SubnetCIDRs: []string{"10.0.1.0/24"}},
+			{Name: "internal"},
+			{Name: "bad", SubnetCIDRs: []string{"10.0.9.0/24"}},
+			{Name: "Bad"},
+		},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{},
+			{},
+			{Error: &params.Error{
+				Message: `cannot add space "bad": subnet "10.0.9.0/24" not found`,
+				Code:    params.CodeNotFound,
+			}},
+			{Error: &params.Error{
+				Message: `cannot add space "Bad": space name "Bad" not valid`,
+			}},
+		},
+	})
+
+	list, err := s.api.ListSpaces()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(list, jc.DeepEquals, params.ListSpacesResults{
+		Results: []params.Space{
+			{Name: "db", SubnetCIDRs: []string{"10.0.1.0/24"}},
+			{Name: "internal", SubnetCIDRs: []string{}},
+		},
+	})
+}
+
+func (s *spacesSuite) TestBlockCreateSpaces(c *gc.C) {
+	s.BlockAllChanges(c, "TestBlockCreateSpaces")
+	_, err := s.api.CreateSpaces(params.CreateSpacesParams{
+		Spaces: []params.CreateSpaceParams{{Name: "db"}},
+	})
+	s.AssertBlocked(c, err, "TestBlockCreateSpaces")
+	_, err = s.State.Space("db")
+	c.Assert(err, gc.ErrorMatches, `space "db" not found`)
+}
+
+func (s *spacesSuite) TestSetEndpointBindings(c *gc.C) {
+	s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	_, err := s.State.AddSpace("db", []string{"10.0.1.0/24"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	result, err := s.api.SetEndpointBindings(params.SetEndpointBindingsParams{
+		Services: []params.ServiceEndpointBindings{{
+			ServiceTag: "service-mysql",
+			Bindings:   map[string]string{"server": "db"},
+		}, {
+			ServiceTag: "service-wordpress",
+			Bindings:   map[string]string{"db": "db"},
+		}, {
+			ServiceTag: "unit-mysql-0",
+		}},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Results, gc.HasLen, 3)
+	c.Assert(result.Results[0].Error, gc.IsNil)
+	c.Assert(result.Results[1].Error, gc.ErrorMatches, `service "wordpress" not found`)
+	c.Assert(result.Results[2].Error, gc.ErrorMatches, `"unit-mysql-0" is not a valid service tag`)
+
+	service, err := s.State.Service("mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(service.EndpointBindings(), jc.DeepEquals, map[string]string{"server": "db"})
+}
@@ -0,0 +1,111 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The uniter package implements the API interface used by the uniter
+// worker. This file contains the API facade version 3.
+
+package uniter
+
+import (
+	"github.com/juju/names"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+)
+
+func init() {
+	common.RegisterStandardFacade("Uniter", 3, NewUniterAPIV3)
+}
+
+// UniterAPIV3 implements the API version 3, used by the uniter worker.
+type UniterAPIV3 struct {
+	UniterAPIV2
+}
+
+// NewUniterAPIV3 creates a new instance of the Uniter API, version 3.
+func NewUniterAPIV3(st *state.State, resources *common.Resources, authorizer common.Authorizer) (*UniterAPIV3, error) {
+	baseAPI, err := NewUniterAPIV2(st, resources, authorizer)
+	if err != nil {
+		return nil, err
+	}
+	return &UniterAPIV3{
+		UniterAPIV2: *baseAPI,
+	}, nil
+}
+
+// TraceIds returns, for each given unit or service, the trace id of
+// the last traced API request that changed it, if any.
+func (u *UniterAPIV3) TraceIds(args params.Entities) (params.StringResults, error) {
+	result := params.StringResults{
+		Results: make([]params.StringResult, len(args.Entities)),
+	}
+	accessUnitOrService := common.AuthEither(u.accessUnit, u.accessService)
+	canAccess, err := accessUnitOrService()
+	if err != nil {
+		return params.StringResults{}, err
+	}
+	for i, entity := range args.Entities {
+		tag, err := names.ParseTag(entity.Tag)
+		if err != nil {
+			result.Results[i].Error = common.ServerError(common.ErrPerm)
+			continue
+		}
+		err = common.ErrPerm
+		if canAccess(tag) {
+			var unitOrService state.Entity
+			unitOrService, err = u.st.FindEntity(tag)
+			if err == nil {
+				tracer := unitOrService.(interface {
+					TraceId() string
+				})
+				result.Results[i].Result = tracer.TraceId()
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// AddUnitHistory records hook and action executions in the history
+// of each given unit.
+func (u *UniterAPIV3) AddUnitHistory(args params.UnitHistoryParams) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Units)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.ErrorResults{}, common.ErrPerm
+	}
+	for i, unitHistory := range args.Units {
+		tag, err := names.ParseUnitTag(unitHistory.Tag)
+		if err != nil {
+			result.Results[i].Error = common.ServerError(common.ErrPerm)
+			continue
+		}
+		err = common.ErrPerm
+		if canAccess(tag) {
+			var unit *state.Unit
+			unit, err = u.getUnit(tag)
+			if err == nil {
+				entries := make([]state.UnitHistoryEntry, len(unitHistory.Entries))
+				for j, entry := range unitHistory.Entries {
+					entries[j] = state.UnitHistoryEntry{
+						Kind:       state.UnitHistoryKind(entry.Kind),
+						Name:       entry.Name,
+						Relation:   entry.Relation,
+						RemoteUnit: entry.RemoteUnit,
+						Started:    entry.Started,
+						Finished:   entry.Finished,
+						ExitStatus: entry.ExitStatus,
+						Error:      entry.Error,
+						StderrTail: entry.StderrTail,
+					}
+				}
+				err = unit.AddHistory(entries...)
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package uniter_test
+
+import (
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/params"
+	apiservertesting "github.com/juju/juju/apiserver/testing"
+	"github.com/juju/juju/apiserver/uniter"
+	"github.com/juju/juju/state"
+)
+
+type uniterV3Suite struct {
+	uniterBaseSuite
+	uniter *uniter.UniterAPIV3
+}
+
+var _ = gc.Suite(&uniterV3Suite{})
+
+func (s *uniterV3Suite) SetUpTest(c *gc.C) {
+	s.uniterBaseSuite.setUpTest(c)
+
+	uniterAPIV3, err := uniter.NewUniterAPIV3(
+		s.State,
+		s.resources,
+		s.authorizer,
+	)
+	c.Assert(err, jc.ErrorIsNil)
+	s.uniter = uniterAPIV3
+}
+
+func (s *uniterV3Suite) TestTraceIds(c *gc.C) {
+	unit, err := s.State.WithTraceId("upgrade-1").Unit(s.wordpressUnit.Name())
+	c.Assert(err, jc.ErrorIsNil)
+	err = unit.SetCharmURL(s.wpCharm.URL())
+	c.Assert(err, jc.ErrorIsNil)
+
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: "unit-mysql-0"},
+		{Tag: "unit-wordpress-0"},
+		{Tag: "unit-foo-42"},
+		{Tag: "service-mysql"},
+		{Tag: "service-wordpress"},
+		{Tag: "service-foo"},
+		{Tag: "just-foo"},
+	}}
+	result, err := s.uniter.TraceIds(args)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, params.StringResults{
+		Results: []params.StringResult{
+			{Error: apiservertesting.ErrUnauthorized},
+			{Result: "upgrade-1"},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Result: ""},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+}
+
+func (s *uniterV3Suite) TestAddUnitHistory(c *gc.C) {
+	started := time.Date(2015, 5, 1, 12, 0, 0, 0, time.UTC)
+	entries := []params.UnitHistoryEntry{{
+		Kind:       "hook",
+		Name:       "config-changed",
+		Started:    started,
+		Finished:   started.Add(time.Second),
+		ExitStatus: 1,
+		Error:      "exit status 1",
+		StderrTail: "oops\n",
+	}}
+	args := params.UnitHistoryParams{
+		Units: []params.UnitHistoryParam{
+			{Tag: "unit-mysql-0", Entries: entries},
+			{Tag: "unit-wordpress-0", Entries: entries},
+			{Tag: "unit-wordpress-0", Entries: []params.UnitHistoryEntry{{Kind: "magic"}}},
+			{Tag: "unit-foo-42", Entries: entries},
+			{Tag: "machine-1", Entries: entries},
+			{Tag: "invalid", Entries: entries},
+		}}
+	result, err := s.uniter.AddUnitHistory(args)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{apiservertesting.ErrUnauthorized},
+			{nil},
+			{&params.Error{Message: `history entry kind "magic" not valid`}},
+			{apiservertesting.ErrUnauthorized},
+			{apiservertesting.ErrUnauthorized},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	history, err := s.wordpressUnit.History()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, jc.DeepEquals, []state.UnitHistoryEntry{{
+		Kind:       state.UnitHistoryHook,
+		Name:       "config-changed",
+		Started:    started,
+		Finished:   started.Add(time.Second),
+		ExitStatus: 1,
+		Error:      "exit status 1",
+		StderrTail: "oops\n",
+	}})
+	history, err = s.mysqlUnit.History()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 0)
+}
@@ -127,6 +127,24 @@ func (c *EnvCommandBase) NewAPIRoot() (*api.State, error) {
 	return juju.NewAPIFromName(c.envName)
 }
 
+// NewAPIRootForFacade returns a new connection to the API server for
+// the environment, having checked that the server supports at least
+// minVersion of the given facade. If it does not, the connection is
+// closed and an error satisfying api.IsNotSupportedError is returned,
+// so that commands report the server version needed rather than
+// failing on their first API call.
+func (c *EnvCommandBase) NewAPIRootForFacade(facade string, minVersion int) (*api.State, error) {
+	root, err := c.NewAPIRoot()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := root.CheckFacadeSupport(facade, minVersion); err != nil {
+		root.Close()
+		return nil, errors.Trace(err)
+	}
+	return root, nil
+}
+
 func (c *EnvCommandBase) Config(store configstore.Storage) (*config.Config, error) {
 	if c.envName == "" {
 		return nil, errors.Trace(ErrNoEnvironmentSpecified)
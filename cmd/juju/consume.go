@@ -0,0 +1,92 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juju/cmd"
+	"github.com/juju/names"
+
+	"github.com/juju/juju/api/offers"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/cmd/juju/block"
+)
+
+// ConsumeCommand adds a remote service for an offer made by another
+// environment on the controller.
+type ConsumeCommand struct {
+	envcmd.EnvCommandBase
+	OfferURL    string
+	ServiceName string
+}
+
+const consumeDoc = `
+Adds a remote service to the environment for a service offered by another
+environment on the same controller (see "juju offer"). The remote service
+may then be related to the environment's services with "juju add-relation",
+and the units of each side see the units of the other as they would units
+in their own environment.
+
+The offer is identified as <owner>/<environment>.<offer>; the owner may be
+left out for offers of your own environments. The remote service is named
+after the offer unless a service name is given.
+
+Examples:
+   juju consume shared.mysql
+   juju consume bob@local/shared.shared-db db
+   juju add-relation wordpress db
+`
+
+func (c *ConsumeCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "consume",
+		Args:    "[<owner>/]<environment>.<offer> [<service name>]",
+		Purpose: "add a remote service for another environment's offer",
+		Doc:     consumeDoc,
+	}
+}
+
+func (c *ConsumeCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no offer specified")
+	}
+	if !validOfferURL(args[0]) {
+		return fmt.Errorf("invalid offer %q, expected [<owner>/]<environment>.<offer>", args[0])
+	}
+	c.OfferURL = args[0]
+	if len(args) > 1 {
+		if !names.IsValidService(args[1]) {
+			return fmt.Errorf("invalid service name %q", args[1])
+		}
+		c.ServiceName = args[1]
+		args = args[1:]
+	}
+	return cmd.CheckEmpty(args[1:])
+}
+
+// validOfferURL reports whether the given offer URL is of the form
+// [<owner>/]<environment>.<offer>.
+func validOfferURL(url string) bool {
+	if i := strings.Index(url, "/"); i != -1 {
+		if !names.IsValidUser(url[:i]) {
+			return false
+		}
+		url = url[i+1:]
+	}
+	i := strings.LastIndex(url, ".")
+	return i > 0 && i < len(url)-1
+}
+
+func (c *ConsumeCommand) Run(_ *cmd.Context) error {
+	root, err := c.NewAPIRootForFacade("Offers", 1)
+	if err != nil {
+		return err
+	}
+	client := offers.NewClient(root)
+	defer client.Close()
+	err = client.Consume(c.OfferURL, c.ServiceName)
+	return block.ProcessBlockedError(err, block.BlockChange)
+}
@@ -7,22 +7,47 @@ import (
 	"encoding/base64"
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/juju/cmd"
 	"github.com/juju/names"
 	"gopkg.in/juju/charm.v5-unstable/hooks"
+	"launchpad.net/gnuflag"
 
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/juju/block"
 	unitdebug "github.com/juju/juju/worker/uniter/runner/debug"
 )
 
 // DebugHooksCommand is responsible for launching a ssh shell on a given unit or machine.
 type DebugHooksCommand struct {
 	SSHCommand
-	hooks []string
+	hooks   []string
+	dap     bool
+	exec    string
+	step    bool
+	resume  bool
+	timeout time.Duration
 }
 
 const debugHooksDoc = `
 Interactively debug a hook remotely on a service unit.
+
+With --dap, no tmux session is started. Instead, the unit pauses the
+first matching hook, and the hook's name and environment are printed.
+While the hook is paused, subsequent invocations may run commands in
+its context (with its environment variables and hook tools), let it
+run and pause at the next matching hook, or let it run and end the
+session. This does not require a terminal, and is suitable for use
+from scripts. If no invocation is made for 30 minutes, or the unit
+agent stops, the paused hook runs and the session ends.
+
+Examples:
+
+    juju debug-hooks --dap mysql/0 config-changed
+    juju debug-hooks --dap --exec 'config-get' mysql/0
+    juju debug-hooks --dap --step mysql/0
+    juju debug-hooks --dap --resume mysql/0
 `
 
 func (c *DebugHooksCommand) Info() *cmd.Info {
@@ -34,6 +59,15 @@ func (c *DebugHooksCommand) Info() *cmd.Info {
 	}
 }
 
+func (c *DebugHooksCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.SSHCommand.SetFlags(f)
+	f.BoolVar(&c.dap, "dap", false, "pause hooks for non-interactive debugging instead of starting tmux")
+	f.StringVar(&c.exec, "exec", "", "with --dap, run commands in the context of the paused hook")
+	f.BoolVar(&c.step, "step", false, "with --dap, run the paused hook and pause at the next matching hook")
+	f.BoolVar(&c.resume, "resume", false, "with --dap, run the paused hook and end the session")
+	f.DurationVar(&c.timeout, "timeout", 10*time.Minute, "with --dap, how long to wait for the unit to respond")
+}
+
 func (c *DebugHooksCommand) Init(args []string) error {
 	if len(args) < 1 {
 		return fmt.Errorf("no unit name specified")
@@ -42,6 +76,9 @@ func (c *DebugHooksCommand) Init(args []string) error {
 	if !names.IsValidUnit(c.Target) {
 		return fmt.Errorf("%q is not a valid unit name", c.Target)
 	}
+	if err := c.checkDAPFlags(len(args) > 1); err != nil {
+		return err
+	}
 
 	// If any of the hooks is "*", then debug all hooks.
 	c.hooks = append([]string{}, args[1:]...)
@@ -54,6 +91,40 @@ func (c *DebugHooksCommand) Init(args []string) error {
 	return nil
 }
 
+// checkDAPFlags verifies that the flags controlling a --dap session
+// are used consistently.
+func (c *DebugHooksCommand) checkDAPFlags(haveHooks bool) error {
+	var ops int
+	for _, set := range []bool{c.exec != "", c.step, c.resume} {
+		if set {
+			ops++
+		}
+	}
+	switch {
+	case ops > 0 && !c.dap:
+		return fmt.Errorf("--exec, --step and --resume require --dap")
+	case ops > 1:
+		return fmt.Errorf("only one of --exec, --step and --resume may be specified")
+	case ops > 0 && haveHooks:
+		return fmt.Errorf("hook names may only be specified when starting a session")
+	}
+	return nil
+}
+
+// dapOperation returns the debug-hooks session operation
+// selected by the command's flags.
+func (c *DebugHooksCommand) dapOperation() string {
+	switch {
+	case c.exec != "":
+		return unitdebug.RemoteExec
+	case c.step:
+		return unitdebug.RemoteStep
+	case c.resume:
+		return unitdebug.RemoteResume
+	}
+	return unitdebug.RemoteStart
+}
+
 func (c *DebugHooksCommand) validateHooks() error {
 	if len(c.hooks) == 0 {
 		return nil
@@ -105,6 +176,9 @@ func (c *DebugHooksCommand) Run(ctx *cmd.Context) error {
 	if err != nil {
 		return err
 	}
+	if c.dap {
+		return c.runDAP(ctx)
+	}
 	debugctx := unitdebug.NewHooksContext(c.Target)
 	script := base64.StdEncoding.EncodeToString([]byte(unitdebug.ClientScript(debugctx, c.hooks)))
 	innercmd := fmt.Sprintf(`F=$(mktemp); echo %s | base64 -d > $F; . $F`, script)
@@ -112,3 +186,28 @@ func (c *DebugHooksCommand) Run(ctx *cmd.Context) error {
 	c.Args = args
 	return c.SSHCommand.Run(ctx)
 }
+
+// runDAP performs a single operation of a non-interactive debug-hooks
+// session through the API, and reports its output as if it had been
+// run locally.
+func (c *DebugHooksCommand) runDAP(ctx *cmd.Context) error {
+	result, err := c.apiClient.DebugHooks(params.DebugHooksParams{
+		Unit:      c.Target,
+		Operation: c.dapOperation(),
+		Hooks:     c.hooks,
+		Commands:  c.exec,
+		Timeout:   c.timeout,
+	})
+	if err != nil {
+		return block.ProcessBlockedError(err, block.BlockChange)
+	}
+	ctx.Stdout.Write(result.Stdout)
+	ctx.Stderr.Write(result.Stderr)
+	if result.Error != "" {
+		return fmt.Errorf("%s", result.Error)
+	}
+	if result.Code != 0 {
+		return cmd.NewRcPassthroughError(result.Code)
+	}
+	return nil
+}
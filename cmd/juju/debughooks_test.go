@@ -6,10 +6,13 @@ package main
 import (
 	"regexp"
 	"runtime"
+	"time"
 
 	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils/exec"
 	gc "gopkg.in/check.v1"
 
+	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/cmd/envcmd"
 	coretesting "github.com/juju/juju/testing"
 )
@@ -100,3 +103,90 @@ func (s *DebugHooksSuite) TestDebugHooksCommand(c *gc.C) {
 		}
 	}
 }
+
+var debugHooksDAPInitTests = []struct {
+	info  string
+	args  []string
+	error string
+	op    string
+}{{
+	info: "start a session",
+	args: []string{"--dap", "mysql/0", "install"},
+	op:   "start",
+}, {
+	info: "exec in the paused hook",
+	args: []string{"--dap", "--exec", "config-get", "mysql/0"},
+	op:   "exec",
+}, {
+	info: "step to the next hook",
+	args: []string{"--dap", "--step", "mysql/0"},
+	op:   "step",
+}, {
+	info: "resume",
+	args: []string{"--dap", "--resume", "mysql/0"},
+	op:   "resume",
+}, {
+	info:  "operations need --dap",
+	args:  []string{"--step", "mysql/0"},
+	error: "--exec, --step and --resume require --dap",
+}, {
+	info:  "only one operation at a time",
+	args:  []string{"--dap", "--step", "--resume", "mysql/0"},
+	error: "only one of --exec, --step and --resume may be specified",
+}, {
+	info:  "hooks only when starting",
+	args:  []string{"--dap", "--step", "mysql/0", "install"},
+	error: "hook names may only be specified when starting a session",
+}}
+
+func (s *DebugHooksSuite) TestDebugHooksDAPInit(c *gc.C) {
+	for i, t := range debugHooksDAPInitTests {
+		c.Logf("test %d: %s\n\t%s\n", i, t.info, t.args)
+		debugHooksCmd := &DebugHooksCommand{}
+		err := coretesting.InitCommand(envcmd.Wrap(debugHooksCmd), t.args)
+		if t.error != "" {
+			c.Check(err, gc.ErrorMatches, t.error)
+			continue
+		}
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(debugHooksCmd.dapOperation(), gc.Equals, t.op)
+	}
+}
+
+type fakeDebugHooksClient struct {
+	sshAPIClient
+	args   params.DebugHooksParams
+	result params.RunResult
+}
+
+func (f *fakeDebugHooksClient) DebugHooks(args params.DebugHooksParams) (params.RunResult, error) {
+	f.args = args
+	return f.result, nil
+}
+
+func (s *DebugHooksSuite) TestDebugHooksDAPRun(c *gc.C) {
+	client := &fakeDebugHooksClient{
+		result: params.RunResult{
+			ExecResponse: exec.ExecResponse{
+				Code:   1,
+				Stdout: []byte("hook: install\n"),
+				Stderr: []byte("hook \"start\" failed: blam\n"),
+			},
+		},
+	}
+	debugHooksCmd := &DebugHooksCommand{}
+	err := coretesting.InitCommand(debugHooksCmd, []string{"--dap", "--step", "mysql/0"})
+	c.Assert(err, jc.ErrorIsNil)
+	debugHooksCmd.apiClient = client
+
+	ctx := coretesting.Context(c)
+	err = debugHooksCmd.runDAP(ctx)
+	c.Assert(err, gc.ErrorMatches, "subprocess encountered error code 1")
+	c.Assert(coretesting.Stdout(ctx), gc.Equals, "hook: install\n")
+	c.Assert(coretesting.Stderr(ctx), gc.Equals, "hook \"start\" failed: blam\n")
+	c.Assert(client.args, jc.DeepEquals, params.DebugHooksParams{
+		Unit:      "mysql/0",
+		Operation: "step",
+		Timeout:   10 * time.Minute,
+	})
+}
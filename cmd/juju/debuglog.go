@@ -13,6 +13,7 @@ import (
 
 	"github.com/juju/juju/api"
 	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/trace"
 )
 
 type DebugLogCommand struct {
@@ -31,6 +32,15 @@ const defaultLineCount = 10
 const debuglogDoc = `
 Stream the consolidated debug log file. This file contains the log messages
 from all nodes in the environment.
+
+API requests made with the JUJU_TRACE_ID environment variable set carry
+that id, and the API server and the agents that act on those requests
+mark their log messages with it. Trace ids may contain only letters,
+digits, '.', '_' and '-'. Use --trace to show only those messages, for
+example:
+
+    JUJU_TRACE_ID=deploy-1 juju deploy mysql
+    juju debug-log --replay --trace deploy-1
 `
 
 func (c *DebugLogCommand) Info() *cmd.Info {
@@ -56,6 +66,7 @@ func (c *DebugLogCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.UintVar(&c.params.Backlog, "lines", defaultLineCount, "")
 	f.UintVar(&c.params.Limit, "limit", 0, "show at most this many lines")
 	f.BoolVar(&c.params.Replay, "replay", false, "start filtering from the start")
+	f.StringVar(&c.params.TraceId, "trace", "", "only show log messages for API requests with this trace id")
 }
 
 func (c *DebugLogCommand) Init(args []string) error {
@@ -67,6 +78,9 @@ func (c *DebugLogCommand) Init(args []string) error {
 		}
 		c.params.Level = level
 	}
+	if c.params.TraceId != "" && !trace.IsValidId(c.params.TraceId) {
+		return fmt.Errorf("invalid trace id %q", c.params.TraceId)
+	}
 	return cmd.CheckEmpty(args)
 }
 
@@ -85,6 +85,15 @@ func (s *DebugLogSuite) TestArgParsing(c *gc.C) {
 				Backlog: 10,
 				Limit:   100,
 			},
+		}, {
+			args: []string{"--trace", "deploy-1"},
+			expected: api.DebugLogParams{
+				Backlog: 10,
+				TraceId: "deploy-1",
+			},
+		}, {
+			args:     []string{"--trace", "deploy 1"},
+			errMatch: `invalid trace id "deploy 1"`,
 		},
 	} {
 		c.Logf("test %v", i)
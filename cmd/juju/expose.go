@@ -5,8 +5,12 @@ package main
 
 import (
 	"errors"
+	"fmt"
+	"net"
+	"strings"
 
 	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
 
 	"github.com/juju/juju/cmd/envcmd"
 	"github.com/juju/juju/cmd/juju/block"
@@ -16,12 +20,22 @@ import (
 type ExposeCommand struct {
 	envcmd.EnvCommandBase
 	ServiceName string
+	ToCIDRs     []string
+	toCIDRs     string
 }
 
 var jujuExposeHelp = `
 Adjusts firewall rules and similar security mechanisms of the provider, to
 allow the service to be accessed on its public address.
 
+By default the service may be accessed from any address. The --to-cidrs
+argument takes a comma-delimited list of CIDRs, and restricts access to
+addresses within them. Providers which cannot restrict access by source
+address refuse --to-cidrs.
+
+Example:
+   juju expose wordpress --to-cidrs 10.0.0.0/8,192.168.1.0/24
+
 `
 
 func (c *ExposeCommand) Info() *cmd.Info {
@@ -33,11 +47,25 @@ func (c *ExposeCommand) Info() *cmd.Info {
 	}
 }
 
+func (c *ExposeCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.StringVar(&c.toCIDRs, "to-cidrs", "", "restrict access to addresses within the given CIDRs")
+}
+
 func (c *ExposeCommand) Init(args []string) error {
 	if len(args) == 0 {
 		return errors.New("no service name specified")
 	}
 	c.ServiceName = args[0]
+	for _, part := range strings.Split(c.toCIDRs, ",") {
+		cidr := strings.TrimSpace(part)
+		if cidr == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("invalid CIDR %q", cidr)
+		}
+		c.ToCIDRs = append(c.ToCIDRs, cidr)
+	}
 	return cmd.CheckEmpty(args[1:])
 }
 
@@ -49,5 +77,10 @@ func (c *ExposeCommand) Run(_ *cmd.Context) error {
 		return err
 	}
 	defer client.Close()
-	return block.ProcessBlockedError(client.ServiceExpose(c.ServiceName), block.BlockChange)
+	if len(c.ToCIDRs) > 0 {
+		err = client.ServiceExposeToCIDRs(c.ServiceName, c.ToCIDRs)
+	} else {
+		err = client.ServiceExpose(c.ServiceName)
+	}
+	return block.ProcessBlockedError(err, block.BlockChange)
 }
@@ -55,6 +55,31 @@ func (s *ExposeSuite) TestExpose(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, `service "nonexistent-service" not found`)
 }
 
+func (s *ExposeSuite) TestExposeToCIDRs(c *gc.C) {
+	testcharms.Repo.CharmArchivePath(s.SeriesPath, "dummy")
+	err := runDeploy(c, "local:dummy", "some-service-name")
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = runExpose(c, "some-service-name", "--to-cidrs", "10.0.0.0/8, 192.168.1.0/24")
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertExposed(c, "some-service-name")
+	svc, err := s.State.Service("some-service-name")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(svc.ExposedCIDRs(), jc.DeepEquals, []string{"10.0.0.0/8", "192.168.1.0/24"})
+
+	// Exposing again without CIDRs lifts the restriction.
+	err = runExpose(c, "some-service-name")
+	c.Assert(err, jc.ErrorIsNil)
+	err = svc.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(svc.ExposedCIDRs(), gc.HasLen, 0)
+}
+
+func (s *ExposeSuite) TestExposeInvalidCIDR(c *gc.C) {
+	err := runExpose(c, "some-service-name", "--to-cidrs", "10.0.0.0")
+	c.Assert(err, gc.ErrorMatches, `invalid CIDR "10.0.0.0"`)
+}
+
 func (s *ExposeSuite) TestBlockExpose(c *gc.C) {
 	testcharms.Repo.CharmArchivePath(s.SeriesPath, "dummy")
 	err := runDeploy(c, "local:dummy", "some-service-name")
@@ -394,6 +394,14 @@ networks
    network. Positive network constraints do not imply the networks will be enabled,
    use the --networks argument for that, just that they could be enabled.
 
+spaces
+   Spaces defines the list of spaces (see "juju space") in which the machine
+   must have an address. Both positive and negative space constraints can be
+   specified, the latter have a "^" prefix to the name. Multiple spaces must be
+   delimited by a comma. Example: spaces=db,^dmz. Currently only the EC2
+   environment honours positive space constraints, by starting machines in a
+   subnet of one of the spaces.
+
 instance-type
    Instance-type is the provider-specific name of a type of machine to deploy,
    for example m1.small on EC2 or A4 on Azure.  Specifying this constraint may
@@ -0,0 +1,222 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/api/environmentmanager"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+)
+
+// ListEnvironmentsCommand lists the environments hosted by the
+// current environment's controller.
+type ListEnvironmentsCommand struct {
+	envcmd.EnvCommandBase
+	out  cmd.Output
+	all  bool
+	user string
+
+	// api is set by tests.
+	api ListEnvironmentsAPI
+}
+
+const listEnvironmentsDoc = `
+Lists the environments hosted by the controller of the current
+environment that the current user can access, or those of the user
+given with --user.
+
+With --all, every environment hosted by the controller is listed,
+along with its owner, life, machine and unit counts and the time its
+users last connected to it. Only the owner of the state server
+environment may list all environments.
+
+Examples:
+   juju list-environments
+   juju list-environments --all --format yaml
+`
+
+func (c *ListEnvironmentsCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "list-environments",
+		Purpose: "list the environments hosted by the controller",
+		Doc:     listEnvironmentsDoc,
+	}
+}
+
+func (c *ListEnvironmentsCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.BoolVar(&c.all, "all", false, "list all environments hosted by the controller")
+	f.StringVar(&c.user, "user", "", "list the environments of this user instead of the current user")
+	c.out.AddFlags(f, "tabular", map[string]cmd.Formatter{
+		"yaml":    cmd.FormatYaml,
+		"json":    cmd.FormatJson,
+		"tabular": c.formatTabular,
+	})
+}
+
+func (c *ListEnvironmentsCommand) Init(args []string) error {
+	if c.all && c.user != "" {
+		return errors.New("cannot specify both --all and --user")
+	}
+	if c.user != "" && !names.IsValidUser(c.user) {
+		return errors.Errorf("invalid user name %q", c.user)
+	}
+	return cmd.CheckEmpty(args)
+}
+
+// ListEnvironmentsAPI holds the EnvironmentManager methods used to
+// list environments.
+type ListEnvironmentsAPI interface {
+	Close() error
+	ListEnvironments(user string) ([]params.Environment, error)
+	AllEnvironments() ([]params.Environment, error)
+	EnvironmentStatus(uuids ...string) ([]params.EnvironmentStatus, error)
+}
+
+func (c *ListEnvironmentsCommand) getAPI() (ListEnvironmentsAPI, error) {
+	if c.api != nil {
+		return c.api, nil
+	}
+	// Listing all environments needs version 2 of the facade.
+	minVersion := 1
+	if c.all {
+		minVersion = 2
+	}
+	root, err := c.NewAPIRootForFacade("EnvironmentManager", minVersion)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return environmentmanager.NewClient(root), nil
+}
+
+// EnvironmentInfo holds the details of an environment reported by
+// list-environments.
+type EnvironmentInfo struct {
+	Name   string               `yaml:"name" json:"name"`
+	UUID   string               `yaml:"uuid" json:"uuid"`
+	Owner  string               `yaml:"owner" json:"owner"`
+	Status *EnvironmentActivity `yaml:"status,omitempty" json:"status,omitempty"`
+}
+
+// EnvironmentActivity holds the details of an environment which only
+// the controller's administrator may see.
+type EnvironmentActivity struct {
+	Life         string `yaml:"life" json:"life"`
+	Machines     int    `yaml:"machines" json:"machines"`
+	Units        int    `yaml:"units" json:"units"`
+	LastActivity string `yaml:"last-activity" json:"last-activity"`
+}
+
+func (c *ListEnvironmentsCommand) Run(ctx *cmd.Context) error {
+	client, err := c.getAPI()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer client.Close()
+
+	var envs []params.Environment
+	if c.all {
+		envs, err = client.AllEnvironments()
+	} else {
+		user := c.user
+		if user == "" {
+			creds, err := c.ConnectionCredentials()
+			if err != nil {
+				return errors.Trace(err)
+			}
+			user = creds.User
+		}
+		envs, err = client.ListEnvironments(user)
+	}
+	if err != nil {
+		return errors.Annotate(err, "cannot list environments")
+	}
+
+	infos := make([]EnvironmentInfo, len(envs))
+	uuids := make([]string, len(envs))
+	for i, env := range envs {
+		infos[i] = EnvironmentInfo{
+			Name:  env.Name,
+			UUID:  env.UUID,
+			Owner: ownerName(env.OwnerTag),
+		}
+		uuids[i] = env.UUID
+	}
+	if c.all && len(uuids) > 0 {
+		statuses, err := client.EnvironmentStatus(uuids...)
+		if err != nil {
+			return errors.Annotate(err, "cannot get environment status")
+		}
+		for i, status := range statuses {
+			infos[i].Status = &EnvironmentActivity{
+				Life:         string(status.Life),
+				Machines:     status.MachineCount,
+				Units:        status.UnitCount,
+				LastActivity: formatLastActivity(status.LastActivity),
+			}
+		}
+	}
+	return c.out.Write(ctx, infos)
+}
+
+// ownerName returns the name of the user with the given tag, or the
+// tag itself if it is not a user tag.
+func ownerName(tag string) string {
+	userTag, err := names.ParseUserTag(tag)
+	if err != nil {
+		return tag
+	}
+	return userTag.Username()
+}
+
+func formatLastActivity(when *time.Time) string {
+	if when == nil {
+		return "never"
+	}
+	return when.UTC().Format("2006-01-02 15:04:05")
+}
+
+func (c *ListEnvironmentsCommand) formatTabular(value interface{}) ([]byte, error) {
+	envs, ok := value.([]EnvironmentInfo)
+	if !ok {
+		return nil, errors.Errorf("expected value of type %T, got %T", envs, value)
+	}
+	var out bytes.Buffer
+	const (
+		// To format things into columns.
+		minwidth = 0
+		tabwidth = 1
+		padding  = 2
+		padchar  = ' '
+		flags    = 0
+	)
+	tw := tabwriter.NewWriter(&out, minwidth, tabwidth, padding, padchar, flags)
+	if c.all {
+		fmt.Fprintf(tw, "NAME\tOWNER\tLIFE\tMACHINES\tUNITS\tLAST ACTIVITY\n")
+		for _, env := range envs {
+			status := env.Status
+			if status == nil {
+				status = &EnvironmentActivity{}
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%s\n",
+				env.Name, env.Owner, status.Life, status.Machines, status.Units, status.LastActivity)
+		}
+	} else {
+		fmt.Fprintf(tw, "NAME\tOWNER\tUUID\n")
+		for _, env := range envs {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", env.Name, env.Owner, env.UUID)
+		}
+	}
+	tw.Flush()
+	return out.Bytes(), nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/environs/configstore"
+	"github.com/juju/juju/testing"
+)
+
+type ListEnvironmentsSuite struct {
+	testing.FakeJujuHomeSuite
+	api *fakeListEnvironmentsAPI
+}
+
+var _ = gc.Suite(&ListEnvironmentsSuite{})
+
+func (s *ListEnvironmentsSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	store := configstore.NewMem()
+	s.PatchValue(&configstore.Default, func() (configstore.Storage, error) {
+		return store, nil
+	})
+	info := store.CreateInfo("ctrl")
+	info.SetAPIEndpoint(configstore.APIEndpoint{
+		Addresses:   []string{"10.0.0.1:17070"},
+		EnvironUUID: "ctrl-uuid",
+	})
+	info.SetAPICredentials(configstore.APICredentials{User: "admin", Password: "sekrit"})
+	c.Assert(info.Write(), jc.ErrorIsNil)
+
+	lastActivity := time.Date(2015, 6, 1, 12, 30, 0, 0, time.UTC)
+	s.api = &fakeListEnvironmentsAPI{
+		envs: []params.Environment{{
+			Name:     "ctrl",
+			UUID:     "ctrl-uuid",
+			OwnerTag: "user-admin@local",
+		}, {
+			Name:     "hosted",
+			UUID:     "hosted-uuid",
+			OwnerTag: "user-bob@local",
+		}},
+		statuses: map[string]params.EnvironmentStatus{
+			"ctrl-uuid": {
+				Life:         params.Alive,
+				MachineCount: 1,
+				LastActivity: &lastActivity,
+			},
+			"hosted-uuid": {
+				Life:         params.Dying,
+				MachineCount: 2,
+				UnitCount:    3,
+			},
+		},
+	}
+}
+
+func (s *ListEnvironmentsSuite) run(c *gc.C, args ...string) (*cmd.Context, error) {
+	command := &ListEnvironmentsCommand{api: s.api}
+	return testing.RunCommand(c, envcmd.Wrap(command), append([]string{"-e", "ctrl"}, args...)...)
+}
+
+func (s *ListEnvironmentsSuite) TestInit(c *gc.C) {
+	_, err := s.run(c, "--all", "--user", "bob")
+	c.Assert(err, gc.ErrorMatches, "cannot specify both --all and --user")
+	_, err = s.run(c, "--user", "not a user")
+	c.Assert(err, gc.ErrorMatches, `invalid user name "not a user"`)
+	_, err = s.run(c, "extra")
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["extra"\]`)
+}
+
+func (s *ListEnvironmentsSuite) TestListForCurrentUser(c *gc.C) {
+	ctx, err := s.run(c)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.api.calls, jc.DeepEquals, []string{"ListEnvironments admin"})
+	c.Assert(testing.Stdout(ctx), gc.Equals, ""+
+		"NAME    OWNER        UUID\n"+
+		"ctrl    admin@local  ctrl-uuid\n"+
+		"hosted  bob@local    hosted-uuid\n")
+}
+
+func (s *ListEnvironmentsSuite) TestListForUser(c *gc.C) {
+	_, err := s.run(c, "--user", "bob")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.api.calls, jc.DeepEquals, []string{"ListEnvironments bob"})
+}
+
+func (s *ListEnvironmentsSuite) TestListAll(c *gc.C) {
+	ctx, err := s.run(c, "--all")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.api.calls, jc.DeepEquals, []string{
+		"AllEnvironments",
+		"EnvironmentStatus [ctrl-uuid hosted-uuid]",
+	})
+	c.Assert(testing.Stdout(ctx), gc.Equals, ""+
+		"NAME    OWNER        LIFE   MACHINES  UNITS  LAST ACTIVITY\n"+
+		"ctrl    admin@local  alive  1         0      2015-06-01 12:30:00\n"+
+		"hosted  bob@local    dying  2         3      never\n")
+}
+
+func (s *ListEnvironmentsSuite) TestListAllJSON(c *gc.C) {
+	ctx, err := s.run(c, "--all", "--format", "json")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, `[`+
+		`{"name":"ctrl","uuid":"ctrl-uuid","owner":"admin@local",`+
+		`"status":{"life":"alive","machines":1,"units":0,"last-activity":"2015-06-01 12:30:00"}},`+
+		`{"name":"hosted","uuid":"hosted-uuid","owner":"bob@local",`+
+		`"status":{"life":"dying","machines":2,"units":3,"last-activity":"never"}}`+
+		"]\n")
+}
+
+func (s *ListEnvironmentsSuite) TestListAllError(c *gc.C) {
+	s.api.err = errors.New("permission denied")
+	_, err := s.run(c, "--all")
+	c.Assert(err, gc.ErrorMatches, "cannot list environments: permission denied")
+}
+
+type fakeListEnvironmentsAPI struct {
+	envs     []params.Environment
+	statuses map[string]params.EnvironmentStatus
+	err      error
+	calls    []string
+}
+
+func (f *fakeListEnvironmentsAPI) Close() error {
+	return nil
+}
+
+func (f *fakeListEnvironmentsAPI) ListEnvironments(user string) ([]params.Environment, error) {
+	f.calls = append(f.calls, "ListEnvironments "+user)
+	return f.envs, f.err
+}
+
+func (f *fakeListEnvironmentsAPI) AllEnvironments() ([]params.Environment, error) {
+	f.calls = append(f.calls, "AllEnvironments")
+	return f.envs, f.err
+}
+
+func (f *fakeListEnvironmentsAPI) EnvironmentStatus(uuids ...string) ([]params.EnvironmentStatus, error) {
+	f.calls = append(f.calls, fmt.Sprintf("EnvironmentStatus %v", uuids))
+	var result []params.EnvironmentStatus
+	for _, uuid := range uuids {
+		result = append(result, f.statuses[uuid])
+	}
+	return result, f.err
+}
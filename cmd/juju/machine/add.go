@@ -63,6 +63,10 @@ information about how to allocate the machine. For example, one can direct the
 MAAS provider to acquire a particular node by specifying its hostname with
 "--to". For more information on placement directives, see "juju help placement".
 
+Manually provisioned machines may be assigned an availability zone, such as
+the rack they are in, with "--zone". The units of a service are spread across
+the availability zones of the machines they are assigned to.
+
 Examples:
    juju machine add                      (starts a new machine)
    juju machine add -n 2                 (starts 2 new machines)
@@ -71,6 +75,8 @@ Examples:
    juju machine add lxc:4                (starts a new lxc container on machine 4)
    juju machine add --constraints mem=8G (starts a machine with at least 8GB RAM)
    juju machine add ssh:user@10.10.0.3   (manually provisions a machine with ssh)
+   juju machine add ssh:10.10.0.4 --zone=rack1
+                                         (manually provisions a machine in zone rack1)
    juju machine add zone=us-east-1a
 
 See Also:
@@ -105,6 +111,8 @@ type AddCommand struct {
 	NumMachines int
 	// Disks describes disks that are to be attached to the machine.
 	Disks []storage.Constraints
+	// Zone is the availability zone recorded for a manually provisioned machine.
+	Zone string
 }
 
 func (c *AddCommand) Info() *cmd.Info {
@@ -120,6 +128,7 @@ func (c *AddCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.StringVar(&c.Series, "series", "", "the charm series")
 	f.IntVar(&c.NumMachines, "n", 1, "The number of machines to add")
 	f.Var(constraints.ConstraintsValue{Target: &c.Constraints}, "constraints", "additional machine constraints")
+	f.StringVar(&c.Zone, "zone", "", "the availability zone of a manually provisioned machine")
 	if featureflag.Enabled(feature.Storage) {
 		// NOTE: if/when the feature flag is removed, bump the client
 		// facade and check that the AddMachines facade version supports
@@ -147,6 +156,9 @@ func (c *AddCommand) Init(args []string) error {
 	if c.NumMachines > 1 && c.Placement != nil && c.Placement.Directive != "" {
 		return fmt.Errorf("cannot use -n when specifying a placement directive")
 	}
+	if c.Zone != "" && (c.Placement == nil || c.Placement.Scope != "ssh") {
+		return fmt.Errorf(`--zone can only be used when manually provisioning a machine; use "zone=%s" placement instead`, c.Zone)
+	}
 	return nil
 }
 
@@ -192,6 +204,7 @@ func (c *AddCommand) Run(ctx *cmd.Context) error {
 			Stdin:  ctx.Stdin,
 			Stdout: ctx.Stdout,
 			Stderr: ctx.Stderr,
+			Zone:   c.Zone,
 			UpdateBehavior: &params.UpdateBehavior{
 				config.EnableOSRefreshUpdate(),
 				config.EnableOSUpgrade(),
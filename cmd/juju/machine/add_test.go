@@ -79,6 +79,13 @@ func (s *AddMachineSuite) TestInit(c *gc.C) {
 			args:      []string{"ssh:user@10.10.0.3"},
 			count:     1,
 			placement: "ssh:user@10.10.0.3",
+		}, {
+			args:      []string{"ssh:user@10.10.0.3", "--zone", "rack1"},
+			count:     1,
+			placement: "ssh:user@10.10.0.3",
+		}, {
+			args:        []string{"--zone", "rack1"},
+			errorString: `--zone can only be used when manually provisioning a machine; use "zone=rack1" placement instead`,
 		}, {
 			args:      []string{"zone=us-east-1a"},
 			count:     1,
@@ -142,6 +149,17 @@ func (s *AddMachineSuite) TestSSHPlacement(c *gc.C) {
 	c.Assert(testing.Stderr(context), gc.Equals, "created machine 42\n")
 }
 
+func (s *AddMachineSuite) TestSSHPlacementWithZone(c *gc.C) {
+	var zone string
+	s.PatchValue(machine.ManualProvisioner, func(args manual.ProvisionMachineArgs) (string, error) {
+		zone = args.Zone
+		return "42", nil
+	})
+	_, err := s.run(c, "ssh:10.1.2.3", "--zone=rack1")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(zone, gc.Equals, "rack1")
+}
+
 func (s *AddMachineSuite) TestSSHPlacementError(c *gc.C) {
 	s.PatchValue(machine.ManualProvisioner, func(args manual.ProvisionMachineArgs) (string, error) {
 		return "", errors.New("failed to initialize warp core")
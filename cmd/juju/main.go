@@ -21,6 +21,7 @@ import (
 	"github.com/juju/juju/cmd/juju/environment"
 	"github.com/juju/juju/cmd/juju/machine"
 	"github.com/juju/juju/cmd/juju/service"
+	"github.com/juju/juju/cmd/juju/space"
 	"github.com/juju/juju/cmd/juju/storage"
 	"github.com/juju/juju/cmd/juju/user"
 	"github.com/juju/juju/environs"
@@ -119,6 +120,10 @@ func registerCommands(r commandRegistry, ctx *cmd.Context) {
 	r.Register(wrapEnvCommand(&BootstrapCommand{}))
 	r.Register(wrapEnvCommand(&DeployCommand{}))
 	r.Register(wrapEnvCommand(&AddRelationCommand{}))
+	r.Register(wrapEnvCommand(&OfferCommand{}))
+	r.Register(wrapEnvCommand(&ConsumeCommand{}))
+	r.Register(wrapEnvCommand(&MigrateCommand{}))
+	r.Register(wrapEnvCommand(&MigrateMachineCommand{}))
 
 	// Destruction commands.
 	r.Register(wrapEnvCommand(&RemoveRelationCommand{}))
@@ -129,6 +134,7 @@ func registerCommands(r commandRegistry, ctx *cmd.Context) {
 	// Reporting commands.
 	r.Register(wrapEnvCommand(&StatusCommand{}))
 	r.Register(&SwitchCommand{})
+	r.Register(wrapEnvCommand(&ListEnvironmentsCommand{}))
 	r.Register(wrapEnvCommand(&EndpointCommand{}))
 	r.Register(wrapEnvCommand(&APIInfoCommand{}))
 
@@ -139,6 +145,7 @@ func registerCommands(r commandRegistry, ctx *cmd.Context) {
 	r.Register(wrapEnvCommand(&ResolvedCommand{}))
 	r.Register(wrapEnvCommand(&DebugLogCommand{}))
 	r.Register(wrapEnvCommand(&DebugHooksCommand{}))
+	r.Register(wrapEnvCommand(&UnitHistoryCommand{}))
 
 	// Configuration commands.
 	r.Register(&InitCommand{})
@@ -204,6 +211,9 @@ func registerCommands(r commandRegistry, ctx *cmd.Context) {
 	r.Register(block.NewSuperBlockCommand())
 	r.Register(wrapEnvCommand(&block.UnblockCommand{}))
 
+	// Manage network spaces
+	r.Register(space.NewSuperCommand())
+
 	// Manage storage
 	if featureflag.Enabled(feature.Storage) {
 		r.Register(storage.NewSuperCommand())
@@ -201,6 +201,7 @@ var commandNames = []string{
 	"block",
 	"bootstrap",
 	"cached-images",
+	"consume",
 	"debug-hooks",
 	"debug-log",
 	"deploy",
@@ -221,7 +222,11 @@ var commandNames = []string{
 	"help",
 	"help-tool",
 	"init",
+	"list-environments",
 	"machine",
+	"migrate",
+	"migrate-machine",
+	"offer",
 	"publish",
 	"remove-machine",  // alias for destroy-machine
 	"remove-relation", // alias for destroy-relation
@@ -236,6 +241,7 @@ var commandNames = []string{
 	"set-constraints",
 	"set-env", // alias for set-environment
 	"set-environment",
+	"space",
 	"ssh",
 	"stat", // alias for status
 	"status",
@@ -245,6 +251,7 @@ var commandNames = []string{
 	"terminate-machine", // alias for destroy-machine
 	"unblock",
 	"unexpose",
+	"unit-history",
 	"unset",
 	"unset-env", // alias for unset-environment
 	"unset-environment",
@@ -0,0 +1,324 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/api/environmentmanager"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/environs/configstore"
+	"github.com/juju/juju/juju"
+)
+
+// MigrateCommand moves an environment to another controller.
+type MigrateCommand struct {
+	envcmd.EnvCommandBase
+	Target  string
+	DryRun  bool
+	Abort   bool
+	Resume  bool
+	Timeout time.Duration
+
+	// sourceAPI and targetAPI are set by tests.
+	sourceAPI MigrateAPI
+	targetAPI MigrateAPI
+}
+
+const migrateDoc = `
+Moves the current environment to the controller of the named target
+environment, without redeploying it.
+
+The environment's workers are first stopped, and changes to it blocked,
+on its original controller. Its state and charms are then copied to the
+target controller, where it is not used until its agents have moved,
+and its agents are told to connect to the target controller's API
+servers. Once all of them have moved, the environment is activated on
+the target controller and removed from its original controller. Only
+the owner of both controllers' state server environments may migrate
+environments between them.
+
+If the migration fails before any of the environment's agents have
+moved, it is aborted: the copy on the target controller is removed and
+the environment is managed by its original controller again. Once an
+agent has moved the migration can no longer be aborted; if the agents
+do not all move before the timeout, run the command again with
+--resume to continue waiting for them. A migration left incomplete
+before any agents moved may be aborted with --abort.
+
+With --dry-run, the environment is checked for import into the target
+controller but nothing is changed.
+
+Examples:
+   juju migrate -e hosted other-controller --dry-run
+   juju migrate -e hosted other-controller
+   juju migrate -e hosted other-controller --resume
+   juju migrate -e hosted other-controller --abort
+`
+
+func (c *MigrateCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "migrate",
+		Args:    "<target environment name>",
+		Purpose: "move an environment to another controller",
+		Doc:     migrateDoc,
+	}
+}
+
+func (c *MigrateCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.BoolVar(&c.DryRun, "dry-run", false, "check that the environment can be migrated, without migrating it")
+	f.BoolVar(&c.Abort, "abort", false, "abort a migration before any agents have moved")
+	f.BoolVar(&c.Resume, "resume", false, "continue waiting for the agents of a migration to move")
+	f.DurationVar(&c.Timeout, "timeout", 10*time.Minute, "how long to wait for the environment's agents to move")
+}
+
+func (c *MigrateCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no target environment specified")
+	}
+	c.Target = args[0]
+	if c.Target == c.ConnectionName() {
+		return fmt.Errorf("cannot migrate an environment to itself")
+	}
+	modes := 0
+	for _, flag := range []bool{c.DryRun, c.Abort, c.Resume} {
+		if flag {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return fmt.Errorf("only one of --dry-run, --abort and --resume may be specified")
+	}
+	return cmd.CheckEmpty(args[1:])
+}
+
+// MigrateAPI holds the EnvironmentManager methods used to migrate an
+// environment.
+type MigrateAPI interface {
+	Close() error
+	BeginMigration(uuid string) error
+	ExportEnvironment(uuid string) (params.EnvironmentExport, error)
+	ValidateEnvironmentImport(export params.EnvironmentExport) error
+	ImportEnvironment(export params.EnvironmentExport) (params.Environment, error)
+	CharmArchive(uuid, curl string) (io.ReadCloser, error)
+	ImportCharmArchive(uuid, curl string, archive io.Reader) error
+	SetMigrationTarget(uuid string, apiAddrs []string, caCert string) error
+	MigrationStatus(uuid string) ([]string, error)
+	ActivateEnvironment(uuid string) error
+	RemoveMigratedEnvironment(uuid string) error
+	AbortMigration(uuid string) error
+	RemoveImportedEnvironment(uuid string) error
+}
+
+func (c *MigrateCommand) getSourceAPI() (MigrateAPI, error) {
+	if c.sourceAPI != nil {
+		return c.sourceAPI, nil
+	}
+	root, err := c.NewAPIRootForFacade("EnvironmentManager", 2)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return environmentmanager.NewClient(root), nil
+}
+
+func (c *MigrateCommand) getTargetAPI() (MigrateAPI, error) {
+	if c.targetAPI != nil {
+		return c.targetAPI, nil
+	}
+	root, err := juju.NewAPIFromName(c.Target)
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot connect to %q", c.Target)
+	}
+	return environmentmanager.NewClient(root), nil
+}
+
+// migrationPollInterval is how often the source controller is asked
+// which agents have yet to move.
+var migrationPollInterval = 5 * time.Second
+
+func (c *MigrateCommand) Run(ctx *cmd.Context) error {
+	store, err := configstore.Default()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	sourceInfo, err := store.ReadInfo(c.ConnectionName())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	targetInfo, err := store.ReadInfo(c.Target)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	uuid := sourceInfo.APIEndpoint().EnvironUUID
+	if uuid == "" {
+		return errors.Errorf("environment %q has no UUID", c.ConnectionName())
+	}
+	targetEndpoint := targetInfo.APIEndpoint()
+	if len(targetEndpoint.Addresses) == 0 {
+		return errors.Errorf("no API addresses known for %q", c.Target)
+	}
+
+	source, err := c.getSourceAPI()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer source.Close()
+	target, err := c.getTargetAPI()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer target.Close()
+
+	switch {
+	case c.DryRun:
+		export, err := source.ExportEnvironment(uuid)
+		if err != nil {
+			return errors.Annotate(err, "cannot export environment")
+		}
+		if err := target.ValidateEnvironmentImport(export); err != nil {
+			return errors.Annotatef(err, "cannot migrate environment to %q", c.Target)
+		}
+		ctx.Infof("environment %q can be migrated to %q", c.ConnectionName(), c.Target)
+		return nil
+	case c.Abort:
+		if err := abortMigration(source, target, uuid, true); err != nil {
+			return errors.Trace(err)
+		}
+		ctx.Infof("migration of environment %q aborted", c.ConnectionName())
+		return nil
+	case !c.Resume:
+		if err := c.startMigration(ctx, source, target, uuid, targetEndpoint); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	ctx.Infof("waiting for agents to move to %q", c.Target)
+	if err := c.waitForAgents(source, uuid); err != nil {
+		if c.Resume {
+			return errors.Trace(err)
+		}
+		return abortAfter(ctx, err, source, target, uuid, true)
+	}
+	if err := target.ActivateEnvironment(uuid); err != nil {
+		return errors.Annotate(err, "cannot activate migrated environment")
+	}
+	if err := source.RemoveMigratedEnvironment(uuid); err != nil {
+		return errors.Annotate(err, "cannot remove migrated environment")
+	}
+
+	// Connect to the environment on its new controller from now on.
+	endpoint := sourceInfo.APIEndpoint()
+	endpoint.Addresses = targetEndpoint.Addresses
+	endpoint.Hostnames = targetEndpoint.Hostnames
+	endpoint.CACert = targetEndpoint.CACert
+	endpoint.ServerUUID = targetEndpoint.ServerUUID
+	sourceInfo.SetAPIEndpoint(endpoint)
+	if err := sourceInfo.Write(); err != nil {
+		return errors.Trace(err)
+	}
+	ctx.Infof("environment %q migrated to %q", c.ConnectionName(), c.Target)
+	return nil
+}
+
+// startMigration stops the environment's workers on the source
+// controller, copies the environment and its charms to the target
+// controller, and tells its agents to move there. If any step fails,
+// the migration is aborted.
+func (c *MigrateCommand) startMigration(
+	ctx *cmd.Context, source, target MigrateAPI, uuid string, targetEndpoint configstore.APIEndpoint,
+) error {
+	if err := source.BeginMigration(uuid); err != nil {
+		return errors.Annotate(err, "cannot begin migration")
+	}
+	export, err := source.ExportEnvironment(uuid)
+	if err != nil {
+		return abortAfter(ctx, errors.Annotate(err, "cannot export environment"), source, target, uuid, false)
+	}
+	if err := target.ValidateEnvironmentImport(export); err != nil {
+		err = errors.Annotatef(err, "cannot migrate environment to %q", c.Target)
+		return abortAfter(ctx, err, source, target, uuid, false)
+	}
+	// A failed import is removed by the target controller.
+	if _, err := target.ImportEnvironment(export); err != nil {
+		err = errors.Annotatef(err, "cannot migrate environment to %q", c.Target)
+		return abortAfter(ctx, err, source, target, uuid, false)
+	}
+	for _, curl := range export.Charms {
+		if err := copyCharm(source, target, uuid, curl); err != nil {
+			return abortAfter(ctx, err, source, target, uuid, true)
+		}
+	}
+	err = source.SetMigrationTarget(uuid, targetEndpoint.Addresses, targetEndpoint.CACert)
+	if err != nil {
+		return abortAfter(ctx, errors.Trace(err), source, target, uuid, true)
+	}
+	ctx.Infof("environment imported into %q", c.Target)
+	return nil
+}
+
+// copyCharm streams the archive of one of the environment's charms from
+// the source controller to the target controller.
+func copyCharm(source, target MigrateAPI, uuid, curl string) error {
+	archive, err := source.CharmArchive(uuid, curl)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer archive.Close()
+	return errors.Trace(target.ImportCharmArchive(uuid, curl, archive))
+}
+
+// abortAfter aborts the migration after it failed with the given
+// error, which it returns.
+func abortAfter(ctx *cmd.Context, err error, source, target MigrateAPI, uuid string, imported bool) error {
+	ctx.Infof("aborting migration: %v", err)
+	if abortErr := abortMigration(source, target, uuid, imported); abortErr != nil {
+		return errors.Annotate(abortErr, err.Error())
+	}
+	return err
+}
+
+// abortMigration returns the environment to its original controller,
+// removing any copy imported into the target controller. It fails once
+// any of the environment's agents have moved.
+func abortMigration(source, target MigrateAPI, uuid string, imported bool) error {
+	if err := source.AbortMigration(uuid); err != nil {
+		return errors.Trace(err)
+	}
+	if !imported {
+		return nil
+	}
+	err := target.RemoveImportedEnvironment(uuid)
+	if err != nil && !params.IsCodeNotFound(err) {
+		return errors.Annotate(err, "cannot remove imported environment")
+	}
+	return nil
+}
+
+// waitForAgents waits until all the environment's agents have moved to
+// the target controller.
+func (c *MigrateCommand) waitForAgents(source MigrateAPI, uuid string) error {
+	timeout := time.After(c.Timeout)
+	for {
+		pending, err := source.MigrationStatus(uuid)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		select {
+		case <-timeout:
+			return errors.Errorf("timed out waiting for agents to move: %s", strings.Join(pending, ", "))
+		case <-time.After(migrationPollInterval):
+		}
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/environs/configstore"
+	"github.com/juju/juju/testing"
+)
+
+type MigrateSuite struct {
+	testing.FakeJujuHomeSuite
+	store  configstore.Storage
+	source *fakeMigrateAPI
+	target *fakeMigrateAPI
+}
+
+var _ = gc.Suite(&MigrateSuite{})
+
+func (s *MigrateSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.store = configstore.NewMem()
+	s.PatchValue(&configstore.Default, func() (configstore.Storage, error) {
+		return s.store, nil
+	})
+	s.PatchValue(&migrationPollInterval, time.Millisecond)
+	s.writeInfo(c, "hosted", configstore.APIEndpoint{
+		Addresses:   []string{"10.0.0.1:17070"},
+		CACert:      "source-cert",
+		EnvironUUID: "hosted-uuid",
+		ServerUUID:  "source-server-uuid",
+	})
+	s.writeInfo(c, "other", configstore.APIEndpoint{
+		Addresses:   []string{"10.0.0.2:17070"},
+		CACert:      "target-cert",
+		EnvironUUID: "target-server-uuid",
+		ServerUUID:  "target-server-uuid",
+	})
+	s.source = &fakeMigrateAPI{}
+	s.target = &fakeMigrateAPI{}
+}
+
+func (s *MigrateSuite) writeInfo(c *gc.C, name string, endpoint configstore.APIEndpoint) {
+	info := s.store.CreateInfo(name)
+	info.SetAPIEndpoint(endpoint)
+	info.SetAPICredentials(configstore.APICredentials{User: "admin", Password: "sekrit"})
+	c.Assert(info.Write(), jc.ErrorIsNil)
+}
+
+func (s *MigrateSuite) run(c *gc.C, args ...string) error {
+	command := &MigrateCommand{
+		sourceAPI: s.source,
+		targetAPI: s.target,
+	}
+	_, err := testing.RunCommand(c, envcmd.Wrap(command), append([]string{"-e", "hosted"}, args...)...)
+	return err
+}
+
+func (s *MigrateSuite) TestInit(c *gc.C) {
+	err := s.run(c)
+	c.Assert(err, gc.ErrorMatches, "no target environment specified")
+	err = s.run(c, "other", "extra")
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["extra"\]`)
+}
+
+func (s *MigrateSuite) TestInitExclusiveFlags(c *gc.C) {
+	err := s.run(c, "other", "--dry-run", "--abort")
+	c.Assert(err, gc.ErrorMatches, "only one of --dry-run, --abort and --resume may be specified")
+}
+
+func (s *MigrateSuite) TestDryRun(c *gc.C) {
+	err := s.run(c, "other", "--dry-run")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.source.calls, jc.DeepEquals, []string{"ExportEnvironment hosted-uuid"})
+	c.Assert(s.target.calls, jc.DeepEquals, []string{"ValidateEnvironmentImport"})
+}
+
+func (s *MigrateSuite) TestMigrate(c *gc.C) {
+	s.source.charms = []string{"cs:trusty/mysql-1"}
+	s.source.pending = [][]string{{"machine-0"}, nil}
+	err := s.run(c, "other")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.source.calls, jc.DeepEquals, []string{
+		"BeginMigration hosted-uuid",
+		"ExportEnvironment hosted-uuid",
+		"CharmArchive hosted-uuid cs:trusty/mysql-1",
+		"SetMigrationTarget hosted-uuid [10.0.0.2:17070] target-cert",
+		"MigrationStatus hosted-uuid",
+		"MigrationStatus hosted-uuid",
+		"RemoveMigratedEnvironment hosted-uuid",
+	})
+	c.Assert(s.target.calls, jc.DeepEquals, []string{
+		"ValidateEnvironmentImport",
+		"ImportEnvironment",
+		"ImportCharmArchive hosted-uuid cs:trusty/mysql-1 archive of cs:trusty/mysql-1",
+		"ActivateEnvironment hosted-uuid",
+	})
+
+	info, err := s.store.ReadInfo("hosted")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(info.APIEndpoint(), jc.DeepEquals, configstore.APIEndpoint{
+		Addresses:   []string{"10.0.0.2:17070"},
+		CACert:      "target-cert",
+		EnvironUUID: "hosted-uuid",
+		ServerUUID:  "target-server-uuid",
+	})
+}
+
+func (s *MigrateSuite) TestMigrateValidationFailureAborts(c *gc.C) {
+	s.target.validateErr = errors.New("no way")
+	err := s.run(c, "other")
+	c.Assert(err, gc.ErrorMatches, `cannot migrate environment to "other": no way`)
+	c.Assert(s.source.calls, jc.DeepEquals, []string{
+		"BeginMigration hosted-uuid",
+		"ExportEnvironment hosted-uuid",
+		"AbortMigration hosted-uuid",
+	})
+	c.Assert(s.target.calls, jc.DeepEquals, []string{"ValidateEnvironmentImport"})
+}
+
+func (s *MigrateSuite) TestMigrateCharmFailureAborts(c *gc.C) {
+	s.source.charms = []string{"cs:trusty/mysql-1"}
+	s.target.charmErr = errors.New("disk full")
+	err := s.run(c, "other")
+	c.Assert(err, gc.ErrorMatches, "disk full")
+	c.Assert(s.source.calls, jc.DeepEquals, []string{
+		"BeginMigration hosted-uuid",
+		"ExportEnvironment hosted-uuid",
+		"CharmArchive hosted-uuid cs:trusty/mysql-1",
+		"AbortMigration hosted-uuid",
+	})
+	c.Assert(s.target.calls, jc.DeepEquals, []string{
+		"ValidateEnvironmentImport",
+		"ImportEnvironment",
+		"ImportCharmArchive hosted-uuid cs:trusty/mysql-1 archive of cs:trusty/mysql-1",
+		"RemoveImportedEnvironment hosted-uuid",
+	})
+}
+
+func (s *MigrateSuite) TestMigrateTimeoutAborts(c *gc.C) {
+	s.source.pending = [][]string{{"machine-0", "unit-mysql-0"}}
+	err := s.run(c, "other", "--timeout", "10ms")
+	c.Assert(err, gc.ErrorMatches, "timed out waiting for agents to move: machine-0, unit-mysql-0")
+	c.Assert(s.source.calls[len(s.source.calls)-1], gc.Equals, "AbortMigration hosted-uuid")
+	c.Assert(s.target.calls[len(s.target.calls)-1], gc.Equals, "RemoveImportedEnvironment hosted-uuid")
+}
+
+func (s *MigrateSuite) TestMigrateTimeoutAfterAgentsMoved(c *gc.C) {
+	s.source.pending = [][]string{{"unit-mysql-0"}}
+	s.source.abortErr = errors.New("agents have already moved: machine-0")
+	err := s.run(c, "other", "--timeout", "10ms")
+	c.Assert(err, gc.ErrorMatches, "timed out waiting for agents to move: unit-mysql-0: "+
+		"agents have already moved: machine-0")
+	c.Assert(s.target.calls, jc.DeepEquals, []string{
+		"ValidateEnvironmentImport",
+		"ImportEnvironment",
+	})
+	info, err := s.store.ReadInfo("hosted")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(info.APIEndpoint().ServerUUID, gc.Equals, "source-server-uuid")
+}
+
+func (s *MigrateSuite) TestResume(c *gc.C) {
+	err := s.run(c, "other", "--resume")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.source.calls, jc.DeepEquals, []string{
+		"MigrationStatus hosted-uuid",
+		"RemoveMigratedEnvironment hosted-uuid",
+	})
+	c.Assert(s.target.calls, jc.DeepEquals, []string{"ActivateEnvironment hosted-uuid"})
+	info, err := s.store.ReadInfo("hosted")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(info.APIEndpoint().ServerUUID, gc.Equals, "target-server-uuid")
+}
+
+func (s *MigrateSuite) TestAbort(c *gc.C) {
+	err := s.run(c, "other", "--abort")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.source.calls, jc.DeepEquals, []string{"AbortMigration hosted-uuid"})
+	c.Assert(s.target.calls, jc.DeepEquals, []string{"RemoveImportedEnvironment hosted-uuid"})
+}
+
+func (s *MigrateSuite) TestAbortNotImported(c *gc.C) {
+	s.target.removeErr = &params.Error{Code: params.CodeNotFound, Message: "environment not found"}
+	err := s.run(c, "other", "--abort")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.target.calls, jc.DeepEquals, []string{"RemoveImportedEnvironment hosted-uuid"})
+}
+
+type fakeMigrateAPI struct {
+	calls       []string
+	pending     [][]string
+	charms      []string
+	validateErr error
+	charmErr    error
+	abortErr    error
+	removeErr   error
+}
+
+func (f *fakeMigrateAPI) addCall(args ...interface{}) {
+	f.calls = append(f.calls, strings.TrimSuffix(fmt.Sprintln(args...), "\n"))
+}
+
+func (f *fakeMigrateAPI) Close() error {
+	return nil
+}
+
+func (f *fakeMigrateAPI) BeginMigration(uuid string) error {
+	f.addCall("BeginMigration", uuid)
+	return nil
+}
+
+func (f *fakeMigrateAPI) ExportEnvironment(uuid string) (params.EnvironmentExport, error) {
+	f.addCall("ExportEnvironment", uuid)
+	return params.EnvironmentExport{
+		EnvironTag: "environment-" + uuid,
+		Charms:     f.charms,
+	}, nil
+}
+
+func (f *fakeMigrateAPI) ValidateEnvironmentImport(export params.EnvironmentExport) error {
+	f.addCall("ValidateEnvironmentImport")
+	return f.validateErr
+}
+
+func (f *fakeMigrateAPI) ImportEnvironment(export params.EnvironmentExport) (params.Environment, error) {
+	f.addCall("ImportEnvironment")
+	return params.Environment{}, nil
+}
+
+func (f *fakeMigrateAPI) CharmArchive(uuid, curl string) (io.ReadCloser, error) {
+	f.addCall("CharmArchive", uuid, curl)
+	return ioutil.NopCloser(strings.NewReader("archive of " + curl)), nil
+}
+
+func (f *fakeMigrateAPI) ImportCharmArchive(uuid, curl string, archive io.Reader) error {
+	data, err := ioutil.ReadAll(archive)
+	if err != nil {
+		return err
+	}
+	f.addCall("ImportCharmArchive", uuid, curl, string(data))
+	return f.charmErr
+}
+
+func (f *fakeMigrateAPI) SetMigrationTarget(uuid string, apiAddrs []string, caCert string) error {
+	f.addCall("SetMigrationTarget", uuid, apiAddrs, caCert)
+	return nil
+}
+
+func (f *fakeMigrateAPI) MigrationStatus(uuid string) ([]string, error) {
+	f.addCall("MigrationStatus", uuid)
+	if len(f.pending) == 0 {
+		return nil, nil
+	}
+	pending := f.pending[0]
+	if len(f.pending) > 1 {
+		f.pending = f.pending[1:]
+	}
+	return pending, nil
+}
+
+func (f *fakeMigrateAPI) ActivateEnvironment(uuid string) error {
+	f.addCall("ActivateEnvironment", uuid)
+	return nil
+}
+
+func (f *fakeMigrateAPI) RemoveMigratedEnvironment(uuid string) error {
+	f.addCall("RemoveMigratedEnvironment", uuid)
+	return nil
+}
+
+func (f *fakeMigrateAPI) AbortMigration(uuid string) error {
+	f.addCall("AbortMigration", uuid)
+	return f.abortErr
+}
+
+func (f *fakeMigrateAPI) RemoveImportedEnvironment(uuid string) error {
+	f.addCall("RemoveImportedEnvironment", uuid)
+	return f.removeErr
+}
@@ -0,0 +1,280 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"github.com/juju/utils"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/cmd/juju/block"
+	"github.com/juju/juju/container"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/utils/ssh"
+)
+
+const migrateMachineDoc = `
+Move an lxc container to another host machine in the same environment.
+
+The container is stopped, its root filesystem is copied over ssh from its
+current host to the target host, and a new container machine is created
+on the target host from that copy. The units assigned to the container
+move with it, keeping their state; the old container is destroyed once
+the copy is complete.
+
+The migrated container gets a new machine id and new addresses. A
+container's machine id names its host (0/lxc/3 is a container on machine
+0), so it cannot be kept when the host changes, and the old addresses
+belong to the old host's network. Anything referring to the container by
+machine id or address must be updated after the move.
+
+Containers hosting other containers, and containers with storage attached,
+cannot be migrated.
+
+Examples:
+
+    juju migrate-machine 0/lxc/3 --to 2
+`
+
+// MigrateMachineCommand moves a container to another host machine.
+type MigrateMachineCommand struct {
+	SSHCommon
+	MachineId string
+	HostId    string
+
+	// api and runRemote are set by tests.
+	api       MigrateMachineAPI
+	runRemote func(target, script string, stdin io.Reader, stdout io.Writer) error
+
+	stderr io.Writer
+}
+
+func (c *MigrateMachineCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "migrate-machine",
+		Args:    "<container machine id> --to <machine id>",
+		Purpose: "move a container to another host machine",
+		Doc:     migrateMachineDoc,
+	}
+}
+
+func (c *MigrateMachineCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.StringVar(&c.HostId, "to", "", "the machine to move the container to")
+	f.BoolVar(&c.proxy, "proxy", true, "proxy through the API server")
+}
+
+// AllowInterspersedFlags is overridden so that --to may follow the
+// machine id.
+func (c *MigrateMachineCommand) AllowInterspersedFlags() bool {
+	return true
+}
+
+func (c *MigrateMachineCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no machine specified")
+	}
+	c.MachineId, args = args[0], args[1:]
+	if !names.IsValidMachine(c.MachineId) {
+		return errors.Errorf("invalid machine id %q", c.MachineId)
+	}
+	if containerParentId(c.MachineId) == "" {
+		return errors.Errorf("machine %s is not a container", c.MachineId)
+	}
+	if c.HostId == "" {
+		return errors.New("no target machine specified; use --to")
+	}
+	if !names.IsValidMachine(c.HostId) {
+		return errors.Errorf("invalid machine id %q", c.HostId)
+	}
+	return cmd.CheckEmpty(args)
+}
+
+// containerParentId returns the id of the machine hosting the container
+// with the given id, or "" if the id is not a container's.
+func containerParentId(machineId string) string {
+	parts := strings.Split(machineId, "/")
+	if len(parts) < 3 {
+		return ""
+	}
+	return strings.Join(parts[:len(parts)-2], "/")
+}
+
+// MigrateMachineAPI defines the API methods used by the migrate-machine
+// command.
+type MigrateMachineAPI interface {
+	Close() error
+	StartContainerMigration(machineId, hostId string) (instance.Id, error)
+	CompleteContainerMigration(machineId string) (string, error)
+	AbortContainerMigration(machineId string) error
+}
+
+func (c *MigrateMachineCommand) getAPI() (MigrateMachineAPI, error) {
+	if c.api != nil {
+		return c.api, nil
+	}
+	return c.NewAPIClient()
+}
+
+// lxcContainerDir is where the lxc tools keep containers on a host.
+const lxcContainerDir = "/var/lib/lxc"
+
+// migrationExcludes lists the paths within a container's root filesystem
+// that belong to its machine agent. They are left behind so that the new
+// machine's agent is installed from scratch, and so that uninstalling the
+// old agent cannot remove the new one's files.
+var migrationExcludes = []string{
+	"./var/lib/juju/agents/machine-*",
+	"./var/lib/juju/tools/machine-*",
+	"./var/lib/juju/nonce.txt",
+	"./etc/init/jujud-machine-*",
+	"./etc/systemd/system/jujud-machine-*",
+	"./lib/systemd/system/jujud-machine-*",
+}
+
+// exportScript returns a script that stops the container and writes a
+// compressed archive of its root filesystem to stdout.
+func exportScript(instId instance.Id) string {
+	name := utils.ShQuote(string(instId))
+	rootfs := utils.ShQuote(path.Join(lxcContainerDir, string(instId), "rootfs"))
+	excludes := make([]string, len(migrationExcludes))
+	for i, exclude := range migrationExcludes {
+		excludes[i] = "--exclude=" + utils.ShQuote(exclude)
+	}
+	return fmt.Sprintf(
+		"set -e\n"+
+			"if lxc-info -n %s -s | grep -q RUNNING; then lxc-stop -n %s; fi\n"+
+			"tar --numeric-owner %s -C %s -czf - .\n",
+		name, name, strings.Join(excludes, " "), rootfs,
+	)
+}
+
+// importScript returns a script that unpacks the archive written by
+// exportScript, read from stdin, where the lxc broker expects to find
+// the staged root filesystem of a migrated container.
+func importScript(instId instance.Id) string {
+	rootfs := utils.ShQuote(path.Join(lxcContainerDir, container.MigrationStagingName(instId), "rootfs"))
+	return fmt.Sprintf(
+		"set -e\n"+
+			"mkdir -p %s\n"+
+			"tar --numeric-owner -C %s -xzpf -\n",
+		rootfs, rootfs,
+	)
+}
+
+// cleanupScript returns a script that removes a partially staged copy.
+func cleanupScript(instId instance.Id) string {
+	return "rm -rf " + utils.ShQuote(path.Join(lxcContainerDir, container.MigrationStagingName(instId)))
+}
+
+// restartScript returns a script that starts the source container again
+// after a failed migration.
+func restartScript(instId instance.Id) string {
+	return "lxc-start -d -n " + utils.ShQuote(string(instId))
+}
+
+// remote runs a script as root on the given machine.
+func (c *MigrateMachineCommand) remote(target, script string, stdin io.Reader, stdout io.Writer) error {
+	if c.runRemote != nil {
+		return c.runRemote(target, script, stdin, stdout)
+	}
+	options, err := c.getSSHOptions(false)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	user, host, err := c.userHostFromTarget(target)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	sshCmd := ssh.Command(user+"@"+host, []string{"sudo", "/bin/bash", "-c", utils.ShQuote(script)}, options)
+	sshCmd.Stdin = stdin
+	sshCmd.Stdout = stdout
+	sshCmd.Stderr = c.stderr
+	return sshCmd.Run()
+}
+
+// copyRootfs streams the container's root filesystem from its current
+// host to the target host.
+func (c *MigrateMachineCommand) copyRootfs(sourceId string, instId instance.Id) error {
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := c.remote(c.HostId, importScript(instId), pr, nil)
+		// Closing the reader stops the export if the import fails.
+		pr.Close()
+		done <- err
+	}()
+	exportErr := c.remote(sourceId, exportScript(instId), nil, pw)
+	pw.CloseWithError(exportErr)
+	importErr := <-done
+	// A failure at either end of the pipe usually breaks the other end
+	// too, so report both; the remote commands' own errors have already
+	// been written to stderr.
+	switch {
+	case exportErr != nil && importErr != nil:
+		return errors.Errorf(
+			"cannot copy container from machine %s to machine %s: export failed: %v; import failed: %v",
+			sourceId, c.HostId, exportErr, importErr,
+		)
+	case exportErr != nil:
+		return errors.Annotatef(exportErr, "cannot copy container from machine %s", sourceId)
+	case importErr != nil:
+		return errors.Annotatef(importErr, "cannot copy container to machine %s", c.HostId)
+	}
+	return nil
+}
+
+func (c *MigrateMachineCommand) Run(ctx *cmd.Context) (err error) {
+	c.stderr = ctx.Stderr
+	client, err := c.getAPI()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer client.Close()
+	if c.runRemote == nil {
+		if _, err := c.ensureAPIClient(); err != nil {
+			return errors.Trace(err)
+		}
+		defer c.apiClient.Close()
+	}
+
+	instId, err := client.StartContainerMigration(c.MachineId, c.HostId)
+	if err != nil {
+		return block.ProcessBlockedError(err, block.BlockChange)
+	}
+	sourceId := containerParentId(c.MachineId)
+	defer func() {
+		if err == nil {
+			return
+		}
+		// Put everything back as it was: the source container is only
+		// destroyed once the migration is complete.
+		if cleanupErr := c.remote(c.HostId, cleanupScript(instId), nil, nil); cleanupErr != nil {
+			logger.Errorf("cannot remove staged copy of container from machine %s: %v", c.HostId, cleanupErr)
+		}
+		if restartErr := c.remote(sourceId, restartScript(instId), nil, nil); restartErr != nil {
+			logger.Errorf("cannot restart container on machine %s: %v", sourceId, restartErr)
+		}
+		if abortErr := client.AbortContainerMigration(c.MachineId); abortErr != nil {
+			logger.Errorf("cannot abort migration of machine %s: %v", c.MachineId, abortErr)
+		}
+	}()
+
+	ctx.Infof("copying container %s from machine %s to machine %s", instId, sourceId, c.HostId)
+	if err := c.copyRootfs(sourceId, instId); err != nil {
+		return errors.Trace(err)
+	}
+	newId, err := client.CompleteContainerMigration(c.MachineId)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ctx.Infof("machine %s migrated to machine %s as machine %s", c.MachineId, c.HostId, newId)
+	return nil
+}
@@ -0,0 +1,170 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/testing"
+)
+
+type MigrateMachineSuite struct {
+	testing.FakeJujuHomeSuite
+	api       *fakeMigrateMachineAPI
+	mu        sync.Mutex
+	scripts   []string
+	staged    string
+	importErr error
+}
+
+var _ = gc.Suite(&MigrateMachineSuite{})
+
+func (s *MigrateMachineSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.api = &fakeMigrateMachineAPI{}
+	s.scripts = nil
+	s.staged = ""
+	s.importErr = nil
+}
+
+// runRemote records the scripts run on each machine, and passes the
+// rootfs "archive" from the export to the import.
+func (s *MigrateMachineSuite) runRemote(target, script string, stdin io.Reader, stdout io.Writer) error {
+	s.mu.Lock()
+	s.scripts = append(s.scripts, target+": "+strings.SplitN(strings.TrimPrefix(script, "set -e\n"), " ", 2)[0])
+	s.mu.Unlock()
+	if stdout != nil {
+		if _, err := io.WriteString(stdout, "rootfs"); err != nil {
+			return err
+		}
+	}
+	if stdin != nil {
+		if s.importErr != nil {
+			return s.importErr
+		}
+		data, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		s.staged = string(data)
+	}
+	return nil
+}
+
+func (s *MigrateMachineSuite) run(c *gc.C, args ...string) (string, error) {
+	command := &MigrateMachineCommand{
+		api:       s.api,
+		runRemote: s.runRemote,
+	}
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(command), append([]string{"-e", "dummyenv"}, args...)...)
+	return testing.Stderr(ctx), err
+}
+
+func (s *MigrateMachineSuite) TestInit(c *gc.C) {
+	for i, t := range []struct {
+		args []string
+		err  string
+	}{{
+		err: "no machine specified",
+	}, {
+		args: []string{"0/lxc/3"},
+		err:  "no target machine specified; use --to",
+	}, {
+		args: []string{"3", "--to", "2"},
+		err:  "machine 3 is not a container",
+	}, {
+		args: []string{"foo", "--to", "2"},
+		err:  `invalid machine id "foo"`,
+	}, {
+		args: []string{"0/lxc/3", "--to", "bar"},
+		err:  `invalid machine id "bar"`,
+	}, {
+		args: []string{"0/lxc/3", "--to", "2", "extra"},
+		err:  `unrecognized args: \["extra"\]`,
+	}} {
+		c.Logf("test %d: %v", i, t.args)
+		_, err := s.run(c, t.args...)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+	c.Assert(s.api.calls, gc.HasLen, 0)
+}
+
+func (s *MigrateMachineSuite) TestMigrate(c *gc.C) {
+	stderr, err := s.run(c, "0/lxc/3", "--to", "2")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.api.calls, jc.DeepEquals, []string{
+		"StartContainerMigration 0/lxc/3 2",
+		"CompleteContainerMigration 0/lxc/3",
+	})
+	c.Assert(s.staged, gc.Equals, "rootfs")
+	c.Assert(s.scripts, jc.SameContents, []string{"2: mkdir", "0: if"})
+	c.Assert(stderr, gc.Matches, "(?s).*machine 0/lxc/3 migrated to machine 2 as machine 2/lxc/0\n")
+}
+
+func (s *MigrateMachineSuite) TestMigrateCopyFails(c *gc.C) {
+	s.importErr = errors.New("no space left on device")
+	_, err := s.run(c, "0/lxc/3", "--to", "2")
+	c.Assert(err, gc.ErrorMatches, "cannot copy container from machine 0 to machine 2: "+
+		"export failed: io: read/write on closed pipe; import failed: no space left on device")
+	c.Assert(s.api.calls, jc.DeepEquals, []string{
+		"StartContainerMigration 0/lxc/3 2",
+		"AbortContainerMigration 0/lxc/3",
+	})
+	// The staged copy is removed, and the source container restarted.
+	c.Assert(s.scripts[2:], jc.DeepEquals, []string{"2: rm", "0: lxc-start"})
+}
+
+func (s *MigrateMachineSuite) TestMigrateStartFails(c *gc.C) {
+	s.api.startErr = errors.New("machine 0/lxc/3 has storage attached")
+	_, err := s.run(c, "0/lxc/3", "--to", "2")
+	c.Assert(err, gc.ErrorMatches, "machine 0/lxc/3 has storage attached")
+	c.Assert(s.api.calls, jc.DeepEquals, []string{"StartContainerMigration 0/lxc/3 2"})
+	c.Assert(s.scripts, gc.HasLen, 0)
+}
+
+func (s *MigrateMachineSuite) TestScripts(c *gc.C) {
+	instId := instance.Id("juju-machine-0-lxc-3")
+	export := exportScript(instId)
+	c.Assert(export, jc.Contains, "lxc-stop -n 'juju-machine-0-lxc-3'")
+	c.Assert(export, jc.Contains, "--exclude='./var/lib/juju/agents/machine-*'")
+	c.Assert(export, jc.Contains, "-C '/var/lib/lxc/juju-machine-0-lxc-3/rootfs' -czf - .")
+	c.Assert(importScript(instId), jc.Contains, "-C '/var/lib/lxc/juju-machine-0-lxc-3-migrating/rootfs' -xzpf -")
+	c.Assert(cleanupScript(instId), gc.Equals, "rm -rf '/var/lib/lxc/juju-machine-0-lxc-3-migrating'")
+}
+
+type fakeMigrateMachineAPI struct {
+	calls    []string
+	startErr error
+}
+
+func (f *fakeMigrateMachineAPI) Close() error {
+	return nil
+}
+
+func (f *fakeMigrateMachineAPI) StartContainerMigration(machineId, hostId string) (instance.Id, error) {
+	f.calls = append(f.calls, "StartContainerMigration "+machineId+" "+hostId)
+	if f.startErr != nil {
+		return "", f.startErr
+	}
+	return "juju-machine-0-lxc-3", nil
+}
+
+func (f *fakeMigrateMachineAPI) CompleteContainerMigration(machineId string) (string, error) {
+	f.calls = append(f.calls, "CompleteContainerMigration "+machineId)
+	return "2/lxc/0", nil
+}
+
+func (f *fakeMigrateMachineAPI) AbortContainerMigration(machineId string) error {
+	f.calls = append(f.calls, "AbortContainerMigration "+machineId)
+	return nil
+}
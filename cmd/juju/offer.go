@@ -0,0 +1,94 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juju/cmd"
+	"github.com/juju/names"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/api/offers"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/cmd/juju/block"
+)
+
+// OfferCommand publishes a service's endpoints to the other
+// environments on the controller.
+type OfferCommand struct {
+	envcmd.EnvCommandBase
+	ServiceName string
+	Endpoints   []string
+	OfferName   string
+	Description string
+}
+
+const offerDoc = `
+Publishes some or all of a service's relations, so that services in other
+environments on the same controller may be related to it. If no relations
+are named, all of the service's relations other than peer relations,
+container scoped relations and juju-info are offered.
+
+The offer is named after the service unless an offer name is given. Other
+environments refer to it as <owner>/<environment>.<offer>, where <owner> is
+the owner of this environment; see "juju consume".
+
+Examples:
+   juju offer mysql
+   juju offer mysql:server shared-db --description "the shared database"
+`
+
+func (c *OfferCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "offer",
+		Args:    "<service>[:<relation>[,<relation>...]] [<offer name>]",
+		Purpose: "offer a service's relations to other environments",
+		Doc:     offerDoc,
+	}
+}
+
+func (c *OfferCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.StringVar(&c.Description, "description", "", "describe the offer to its consumers")
+}
+
+func (c *OfferCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no service specified")
+	}
+	spec := args[0]
+	if i := strings.Index(spec, ":"); i != -1 {
+		for _, ep := range strings.Split(spec[i+1:], ",") {
+			if ep == "" {
+				return fmt.Errorf("invalid relation list %q", spec[i+1:])
+			}
+			c.Endpoints = append(c.Endpoints, ep)
+		}
+		spec = spec[:i]
+	}
+	if !names.IsValidService(spec) {
+		return fmt.Errorf("invalid service name %q", spec)
+	}
+	c.ServiceName = spec
+	if len(args) > 1 {
+		if !names.IsValidService(args[1]) {
+			return fmt.Errorf("invalid offer name %q", args[1])
+		}
+		c.OfferName = args[1]
+		args = args[1:]
+	}
+	return cmd.CheckEmpty(args[1:])
+}
+
+func (c *OfferCommand) Run(_ *cmd.Context) error {
+	root, err := c.NewAPIRootForFacade("Offers", 1)
+	if err != nil {
+		return err
+	}
+	client := offers.NewClient(root)
+	defer client.Close()
+	err = client.Offer(c.OfferName, c.ServiceName, c.Endpoints, c.Description)
+	return block.ProcessBlockedError(err, block.BlockChange)
+}
@@ -0,0 +1,165 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/cmd/envcmd"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/testing/factory"
+)
+
+type OfferSuite struct {
+	jujutesting.RepoSuite
+	CmdBlockHelper
+}
+
+func (s *OfferSuite) SetUpTest(c *gc.C) {
+	s.RepoSuite.SetUpTest(c)
+	s.CmdBlockHelper = NewCmdBlockHelper(s.APIState)
+	c.Assert(s.CmdBlockHelper, gc.NotNil)
+	s.AddCleanup(func(*gc.C) { s.CmdBlockHelper.Close() })
+}
+
+var _ = gc.Suite(&OfferSuite{})
+
+func runOffer(c *gc.C, args ...string) error {
+	_, err := testing.RunCommand(c, envcmd.Wrap(&OfferCommand{}), args...)
+	return err
+}
+
+func runConsume(c *gc.C, args ...string) error {
+	_, err := testing.RunCommand(c, envcmd.Wrap(&ConsumeCommand{}), args...)
+	return err
+}
+
+func (s *OfferSuite) TestOfferInit(c *gc.C) {
+	for i, test := range []struct {
+		args        []string
+		service     string
+		endpoints   []string
+		offerName   string
+		description string
+		err         string
+	}{{
+		err: "no service specified",
+	}, {
+		args:    []string{"mysql"},
+		service: "mysql",
+	}, {
+		args:        []string{"mysql:server,admin", "shared-db", "--description", "shared"},
+		service:     "mysql",
+		endpoints:   []string{"server", "admin"},
+		offerName:   "shared-db",
+		description: "shared",
+	}, {
+		args: []string{"mysql:"},
+		err:  `invalid relation list ""`,
+	}, {
+		args: []string{"My-SQL"},
+		err:  `invalid service name "My-SQL"`,
+	}, {
+		args: []string{"mysql", "Shared"},
+		err:  `invalid offer name "Shared"`,
+	}, {
+		args: []string{"mysql", "db", "extra"},
+		err:  `unrecognized args: \["extra"\]`,
+	}} {
+		c.Logf("test %d: %v", i, test.args)
+		command := &OfferCommand{}
+		err := testing.InitCommand(envcmd.Wrap(command), test.args)
+		if test.err != "" {
+			c.Check(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(command.ServiceName, gc.Equals, test.service)
+		c.Check(command.Endpoints, jc.DeepEquals, test.endpoints)
+		c.Check(command.OfferName, gc.Equals, test.offerName)
+		c.Check(command.Description, gc.Equals, test.description)
+	}
+}
+
+func (s *OfferSuite) TestOffer(c *gc.C) {
+	s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	err := runOffer(c, "mysql:server", "db", "--description", "the database")
+	c.Assert(err, jc.ErrorIsNil)
+
+	offer, err := s.State.ServiceOffer("db")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(offer.ServiceName(), gc.Equals, "mysql")
+	c.Assert(offer.Description(), gc.Equals, "the database")
+}
+
+func (s *OfferSuite) TestBlockOffer(c *gc.C) {
+	s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	s.BlockAllChanges(c, "TestBlockOffer")
+	err := runOffer(c, "mysql")
+	s.AssertBlocked(c, err, ".*TestBlockOffer.*")
+	_, err = s.State.ServiceOffer("mysql")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *OfferSuite) TestConsumeInit(c *gc.C) {
+	for i, test := range []struct {
+		args []string
+		err  string
+	}{{
+		err: "no offer specified",
+	}, {
+		args: []string{"mysql"},
+		err:  `invalid offer "mysql", expected \[<owner>/\]<environment>.<offer>`,
+	}, {
+		args: []string{"shared."},
+		err:  `invalid offer "shared.", expected \[<owner>/\]<environment>.<offer>`,
+	}, {
+		args: []string{"no body/shared.db"},
+		err:  `invalid offer "no body/shared.db", expected \[<owner>/\]<environment>.<offer>`,
+	}, {
+		args: []string{"bob@local/shared"},
+		err:  `invalid offer "bob@local/shared", expected \[<owner>/\]<environment>.<offer>`,
+	}, {
+		args: []string{"shared.db", "Bad"},
+		err:  `invalid service name "Bad"`,
+	}, {
+		args: []string{"shared.db", "db", "extra"},
+		err:  `unrecognized args: \["extra"\]`,
+	}} {
+		c.Logf("test %d: %v", i, test.args)
+		err := testing.InitCommand(envcmd.Wrap(&ConsumeCommand{}), test.args)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *OfferSuite) TestConsume(c *gc.C) {
+	sourceSt := s.Factory.MakeEnvironment(c, &factory.EnvParams{Name: "shared"})
+	defer sourceSt.Close()
+	sourceFactory := factory.NewFactory(sourceSt)
+	sourceFactory.MakeService(c, &factory.ServiceParams{
+		Name:  "mysql",
+		Charm: sourceFactory.MakeCharm(c, &factory.CharmParams{Name: "mysql"}),
+	})
+	_, err := sourceSt.AddServiceOffer(state.AddServiceOfferArgs{ServiceName: "mysql"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = runConsume(c, "admin@local/shared.mysql", "db")
+	c.Assert(err, jc.ErrorIsNil)
+	remote, err := s.State.RemoteService("db")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(remote.SourceEnvUUID(), gc.Equals, sourceSt.EnvironUUID())
+	c.Assert(remote.OfferName(), gc.Equals, "mysql")
+
+	// The remote service can be related to.
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	err = runAddRelation(c, "wordpress", "db")
+	c.Assert(err, jc.ErrorIsNil)
+	rels, err := remote.Relations()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(rels, gc.HasLen, 1)
+}
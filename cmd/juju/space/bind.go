@@ -0,0 +1,78 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package space
+
+import (
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"github.com/juju/utils/keyvalues"
+)
+
+const bindCommandDoc = `
+Binds endpoints of a service to spaces, replacing the service's
+existing bindings. In the relations of a bound endpoint, units of the
+service use their addresses in the subnets of the space. Giving no
+bindings removes all of the service's existing bindings.
+
+Example:
+    juju space bind mysql server=db
+`
+
+// BindCommand binds a service's endpoints to spaces.
+type BindCommand struct {
+	SpaceCommandBase
+	service  string
+	bindings map[string]string
+}
+
+// Init implements Command.Init.
+func (c *BindCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("service name is required")
+	}
+	c.service = args[0]
+	if !names.IsValidService(c.service) {
+		return errors.Errorf("%q is not a valid service name", c.service)
+	}
+	bindings, err := keyvalues.Parse(args[1:], false)
+	if err != nil {
+		return err
+	}
+	c.bindings = bindings
+	return nil
+}
+
+// Info implements Command.Info.
+func (c *BindCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "bind",
+		Args:    "<service> [<endpoint>=<space> ...]",
+		Purpose: "bind service endpoints to spaces",
+		Doc:     bindCommandDoc,
+	}
+}
+
+// Run implements Command.Run.
+func (c *BindCommand) Run(ctx *cmd.Context) error {
+	api, err := getBindAPI(c)
+	if err != nil {
+		return err
+	}
+	defer api.Close()
+
+	return api.SetEndpointBindings(c.service, c.bindings)
+}
+
+var getBindAPI = (*BindCommand).getBindAPI
+
+// BindAPI defines the API methods that the space bind command uses.
+type BindAPI interface {
+	Close() error
+	SetEndpointBindings(service string, bindings map[string]string) error
+}
+
+func (c *BindCommand) getBindAPI() (BindAPI, error) {
+	return c.NewSpacesAPI()
+}
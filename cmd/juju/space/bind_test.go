@@ -0,0 +1,75 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package space_test
+
+import (
+	"github.com/juju/cmd"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/cmd/juju/space"
+	"github.com/juju/juju/testing"
+)
+
+type BindSuite struct {
+	SubSpaceSuite
+	mockAPI *mockBindAPI
+}
+
+var _ = gc.Suite(&BindSuite{})
+
+func (s *BindSuite) SetUpTest(c *gc.C) {
+	s.SubSpaceSuite.SetUpTest(c)
+
+	s.mockAPI = &mockBindAPI{}
+	s.PatchValue(space.GetBindAPI, func(c *space.BindCommand) (space.BindAPI, error) {
+		return s.mockAPI, nil
+	})
+}
+
+func runBind(c *gc.C, args ...string) (*cmd.Context, error) {
+	return testing.RunCommand(c, envcmd.Wrap(&space.BindCommand{}), args...)
+}
+
+func (s *BindSuite) TestBind(c *gc.C) {
+	_, err := runBind(c, "mysql", "server=db", "admin=internal")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.mockAPI.service, gc.Equals, "mysql")
+	c.Assert(s.mockAPI.bindings, jc.DeepEquals, map[string]string{
+		"server": "db",
+		"admin":  "internal",
+	})
+}
+
+func (s *BindSuite) TestBindNoBindings(c *gc.C) {
+	_, err := runBind(c, "mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.mockAPI.service, gc.Equals, "mysql")
+	c.Assert(s.mockAPI.bindings, gc.HasLen, 0)
+}
+
+func (s *BindSuite) TestBindInitErrors(c *gc.C) {
+	_, err := runBind(c)
+	c.Check(err, gc.ErrorMatches, "service name is required")
+	_, err = runBind(c, "mysql/0", "server=db")
+	c.Check(err, gc.ErrorMatches, `"mysql/0" is not a valid service name`)
+	_, err = runBind(c, "mysql", "server")
+	c.Check(err, gc.ErrorMatches, `expected "key=value", got "server"`)
+	c.Assert(s.mockAPI.service, gc.Equals, "")
+}
+
+type mockBindAPI struct {
+	service  string
+	bindings map[string]string
+}
+
+func (s *mockBindAPI) Close() error {
+	return nil
+}
+
+func (s *mockBindAPI) SetEndpointBindings(service string, bindings map[string]string) error {
+	s.service, s.bindings = service, bindings
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package space
+
+import (
+	"net"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/network"
+)
+
+const createCommandDoc = `
+Creates a space containing the given subnets. The subnets must already
+be known to Juju, and may not be part of another space.
+
+Space names consist of lower case letters and digits, optionally
+separated by single hyphens.
+
+Example:
+    juju space create db 10.0.1.0/24 10.0.2.0/24
+`
+
+// CreateCommand creates a space.
+type CreateCommand struct {
+	SpaceCommandBase
+	name  string
+	cidrs []string
+}
+
+// Init implements Command.Init.
+func (c *CreateCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("space name is required")
+	}
+	c.name, c.cidrs = args[0], args[1:]
+	if !network.IsValidSpaceName(c.name) {
+		return errors.Errorf("%q is not a valid space name", c.name)
+	}
+	for _, cidr := range c.cidrs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return errors.Errorf("%q is not a valid CIDR", cidr)
+		}
+	}
+	return nil
+}
+
+// Info implements Command.Info.
+func (c *CreateCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "create",
+		Args:    "<name> [<CIDR> ...]",
+		Purpose: "create a space containing subnets",
+		Doc:     createCommandDoc,
+	}
+}
+
+// Run implements Command.Run.
+func (c *CreateCommand) Run(ctx *cmd.Context) error {
+	api, err := getCreateAPI(c)
+	if err != nil {
+		return err
+	}
+	defer api.Close()
+
+	return api.CreateSpace(c.name, c.cidrs)
+}
+
+var getCreateAPI = (*CreateCommand).getCreateAPI
+
+// CreateAPI defines the API methods that the space create command uses.
+type CreateAPI interface {
+	Close() error
+	CreateSpace(name string, subnetCIDRs []string) error
+}
+
+func (c *CreateCommand) getCreateAPI() (CreateAPI, error) {
+	return c.NewSpacesAPI()
+}
@@ -0,0 +1,84 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package space_test
+
+import (
+	"github.com/juju/cmd"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/cmd/juju/space"
+	_ "github.com/juju/juju/provider/dummy"
+	"github.com/juju/juju/testing"
+)
+
+type CreateSuite struct {
+	SubSpaceSuite
+	mockAPI *mockCreateAPI
+}
+
+var _ = gc.Suite(&CreateSuite{})
+
+func (s *CreateSuite) SetUpTest(c *gc.C) {
+	s.SubSpaceSuite.SetUpTest(c)
+
+	s.mockAPI = &mockCreateAPI{}
+	s.PatchValue(space.GetCreateAPI, func(c *space.CreateCommand) (space.CreateAPI, error) {
+		return s.mockAPI, nil
+	})
+}
+
+func runCreate(c *gc.C, args ...string) (*cmd.Context, error) {
+	return testing.RunCommand(c, envcmd.Wrap(&space.CreateCommand{}), args...)
+}
+
+func (s *CreateSuite) TestCreate(c *gc.C) {
+	_, err := runCreate(c, "db", "10.0.1.0/24", "10.0.2.0/24")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.mockAPI.name, gc.Equals, "db")
+	c.Assert(s.mockAPI.cidrs, jc.DeepEquals, []string{"10.0.1.0/24", "10.0.2.0/24"})
+}
+
+func (s *CreateSuite) TestCreateNoSubnets(c *gc.C) {
+	_, err := runCreate(c, "db")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.mockAPI.name, gc.Equals, "db")
+	c.Assert(s.mockAPI.cidrs, gc.HasLen, 0)
+}
+
+func (s *CreateSuite) TestCreateInitErrors(c *gc.C) {
+	for i, test := range []struct {
+		args []string
+		err  string
+	}{{
+		args: nil,
+		err:  "space name is required",
+	}, {
+		args: []string{"DB"},
+		err:  `"DB" is not a valid space name`,
+	}, {
+		args: []string{"db", "10.0.1.0/24", "nonsense"},
+		err:  `"nonsense" is not a valid CIDR`,
+	}} {
+		c.Logf("test %d: %v", i, test.args)
+		_, err := runCreate(c, test.args...)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+	c.Assert(s.mockAPI.name, gc.Equals, "")
+}
+
+type mockCreateAPI struct {
+	name  string
+	cidrs []string
+}
+
+func (s *mockCreateAPI) Close() error {
+	return nil
+}
+
+func (s *mockCreateAPI) CreateSpace(name string, subnetCIDRs []string) error {
+	s.name, s.cidrs = name, subnetCIDRs
+	return nil
+}
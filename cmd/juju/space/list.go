@@ -0,0 +1,83 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package space
+
+import (
+	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/apiserver/params"
+)
+
+const listCommandDoc = `
+Lists the spaces of the environment, along with the CIDRs of their
+subnets.
+`
+
+// ListCommand lists spaces.
+type ListCommand struct {
+	SpaceCommandBase
+	out cmd.Output
+}
+
+// SpaceInfo defines the serialization behaviour of a space.
+type SpaceInfo struct {
+	Subnets []string `yaml:"subnets" json:"subnets"`
+}
+
+// Info implements Command.Info.
+func (c *ListCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "list",
+		Purpose: "list spaces",
+		Doc:     listCommandDoc,
+	}
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *ListCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.SpaceCommandBase.SetFlags(f)
+	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
+		"yaml": cmd.FormatYaml,
+		"json": cmd.FormatJson,
+	})
+}
+
+// Run implements Command.Run.
+func (c *ListCommand) Run(ctx *cmd.Context) error {
+	api, err := getListAPI(c)
+	if err != nil {
+		return err
+	}
+	defer api.Close()
+
+	spaces, err := api.ListSpaces()
+	if err != nil {
+		return err
+	}
+	if len(spaces) == 0 {
+		return nil
+	}
+	output := make(map[string]SpaceInfo)
+	for _, space := range spaces {
+		subnets := space.SubnetCIDRs
+		if subnets == nil {
+			subnets = []string{}
+		}
+		output[space.Name] = SpaceInfo{Subnets: subnets}
+	}
+	return c.out.Write(ctx, output)
+}
+
+var getListAPI = (*ListCommand).getListAPI
+
+// ListAPI defines the API methods that the space list command uses.
+type ListAPI interface {
+	Close() error
+	ListSpaces() ([]params.Space, error)
+}
+
+func (c *ListCommand) getListAPI() (ListAPI, error) {
+	return c.NewSpacesAPI()
+}
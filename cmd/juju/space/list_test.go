@@ -0,0 +1,72 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package space_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/cmd/juju/space"
+	"github.com/juju/juju/testing"
+)
+
+type ListSuite struct {
+	SubSpaceSuite
+	mockAPI *mockListAPI
+}
+
+var _ = gc.Suite(&ListSuite{})
+
+func (s *ListSuite) SetUpTest(c *gc.C) {
+	s.SubSpaceSuite.SetUpTest(c)
+
+	s.mockAPI = &mockListAPI{}
+	s.PatchValue(space.GetListAPI, func(c *space.ListCommand) (space.ListAPI, error) {
+		return s.mockAPI, nil
+	})
+}
+
+func (s *ListSuite) TestList(c *gc.C) {
+	s.mockAPI.spaces = []params.Space{
+		{Name: "db", SubnetCIDRs: []string{"10.0.1.0/24", "10.0.2.0/24"}},
+		{Name: "internal"},
+	}
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&space.ListCommand{}))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, `
+db:
+  subnets:
+  - 10.0.1.0/24
+  - 10.0.2.0/24
+internal:
+  subnets: []
+`[1:])
+}
+
+func (s *ListSuite) TestListJSON(c *gc.C) {
+	s.mockAPI.spaces = []params.Space{{Name: "db", SubnetCIDRs: []string{"10.0.1.0/24"}}}
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&space.ListCommand{}), "--format", "json")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, `{"db":{"subnets":["10.0.1.0/24"]}}`+"\n")
+}
+
+func (s *ListSuite) TestListEmpty(c *gc.C) {
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&space.ListCommand{}))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, "")
+}
+
+type mockListAPI struct {
+	spaces []params.Space
+}
+
+func (s *mockListAPI) Close() error {
+	return nil
+}
+
+func (s *mockListAPI) ListSpaces() ([]params.Space, error) {
+	return s.spaces, nil
+}
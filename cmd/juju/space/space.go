@@ -0,0 +1,62 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package space
+
+import (
+	"github.com/juju/cmd"
+
+	"github.com/juju/juju/api/spaces"
+	"github.com/juju/juju/cmd/envcmd"
+)
+
+const spaceCmdDoc = `
+"juju space" is used to manage the spaces of the Juju environment.
+
+A space is a named set of subnets. Machines can be constrained to have
+addresses in particular spaces with the "spaces" constraint, and a
+service's endpoints can be bound to spaces, so that its units use
+their addresses in those spaces in the endpoints' relations.
+`
+
+const spaceCmdPurpose = "manage network spaces"
+
+// Command is the top-level command wrapping all space functionality.
+type Command struct {
+	cmd.SuperCommand
+}
+
+// NewSuperCommand creates the space supercommand and
+// registers the subcommands that it supports.
+func NewSuperCommand() cmd.Command {
+	spacecmd := Command{
+		SuperCommand: *cmd.NewSuperCommand(
+			cmd.SuperCommandParams{
+				Name:        "space",
+				Doc:         spaceCmdDoc,
+				UsagePrefix: "juju",
+				Purpose:     spaceCmdPurpose,
+			})}
+	spacecmd.Register(envcmd.Wrap(&CreateCommand{}))
+	spacecmd.Register(envcmd.Wrap(&ListCommand{}))
+	spacecmd.Register(envcmd.Wrap(&BindCommand{}))
+	return &spacecmd
+}
+
+// SpaceCommandBase is a helper base structure that has a method
+// to get the spaces client.
+type SpaceCommandBase struct {
+	envcmd.EnvCommandBase
+}
+
+// NewSpacesAPI returns a spaces api for the root api endpoint that
+// the environment command returns. An error satisfying
+// api.IsNotSupportedError is returned if the API server does not
+// support the Spaces facade.
+func (c *SpaceCommandBase) NewSpacesAPI() (*spaces.Client, error) {
+	root, err := c.NewAPIRootForFacade("Spaces", 1)
+	if err != nil {
+		return nil, err
+	}
+	return spaces.NewClient(root), nil
+}
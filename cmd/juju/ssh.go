@@ -16,6 +16,7 @@ import (
 	"github.com/juju/utils"
 	"launchpad.net/gnuflag"
 
+	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/cmd/envcmd"
 	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/utils/ssh"
@@ -204,6 +205,7 @@ type sshAPIClient interface {
 	PublicAddress(target string) (string, error)
 	PrivateAddress(target string) (string, error)
 	ServiceCharmRelations(service string) ([]string, error)
+	DebugHooks(args params.DebugHooksParams) (params.RunResult, error)
 	Close() error
 }
 
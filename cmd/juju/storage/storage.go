@@ -53,9 +53,11 @@ type StorageCommandBase struct {
 }
 
 // NewStorageAPI returns a storage api for the root api endpoint
-// that the environment command returns.
+// that the environment command returns. An error satisfying
+// api.IsNotSupportedError is returned if the API server does
+// not support the Storage facade.
 func (c *StorageCommandBase) NewStorageAPI() (*storage.Client, error) {
-	root, err := c.NewAPIRoot()
+	root, err := c.NewAPIRootForFacade("Storage", 1)
 	if err != nil {
 		return nil, err
 	}
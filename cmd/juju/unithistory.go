@@ -0,0 +1,150 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+)
+
+// UnitHistoryCommand shows the recent hook and action executions
+// of a unit.
+type UnitHistoryCommand struct {
+	envcmd.EnvCommandBase
+	out      cmd.Output
+	unitName string
+}
+
+const unitHistoryDoc = `
+Show the most recent hook and action executions of a unit, oldest first.
+
+For each execution the start time, duration, exit status and any error
+are shown, along with the relation and remote unit for relation hooks.
+The yaml and json formats also include the last part of the standard
+error output of each execution.
+
+Examples:
+
+    juju unit-history wordpress/0
+    juju unit-history --format yaml mysql/1
+`
+
+func (c *UnitHistoryCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "unit-history",
+		Args:    "<unit>",
+		Purpose: "show the recent hook and action executions of a unit",
+		Doc:     unitHistoryDoc,
+	}
+}
+
+func (c *UnitHistoryCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "tabular", map[string]cmd.Formatter{
+		"yaml":    cmd.FormatYaml,
+		"json":    cmd.FormatJson,
+		"tabular": formatUnitHistoryTabular,
+	})
+}
+
+func (c *UnitHistoryCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no unit name specified")
+	}
+	c.unitName = args[0]
+	if !names.IsValidUnit(c.unitName) {
+		return errors.Errorf("invalid unit name %q", c.unitName)
+	}
+	return cmd.CheckEmpty(args[1:])
+}
+
+type unitHistoryAPI interface {
+	UnitHistory(unitName string) ([]params.UnitHistoryEntry, error)
+	Close() error
+}
+
+var getUnitHistoryAPI = func(c *UnitHistoryCommand) (unitHistoryAPI, error) {
+	return c.NewAPIClient()
+}
+
+// unitHistoryEntry is the serialisation format of a history entry.
+type unitHistoryEntry struct {
+	Kind       string `yaml:"kind" json:"kind"`
+	Name       string `yaml:"name" json:"name"`
+	Relation   string `yaml:"relation,omitempty" json:"relation,omitempty"`
+	RemoteUnit string `yaml:"remote-unit,omitempty" json:"remote-unit,omitempty"`
+	Started    string `yaml:"started" json:"started"`
+	Duration   string `yaml:"duration" json:"duration"`
+	ExitStatus int    `yaml:"exit-status" json:"exit-status"`
+	Error      string `yaml:"error,omitempty" json:"error,omitempty"`
+	StderrTail string `yaml:"stderr,omitempty" json:"stderr,omitempty"`
+}
+
+func (c *UnitHistoryCommand) Run(ctx *cmd.Context) error {
+	client, err := getUnitHistoryAPI(c)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer client.Close()
+
+	history, err := client.UnitHistory(c.unitName)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	entries := make([]unitHistoryEntry, len(history))
+	for i, entry := range history {
+		entries[i] = unitHistoryEntry{
+			Kind:       entry.Kind,
+			Name:       entry.Name,
+			Relation:   entry.Relation,
+			RemoteUnit: entry.RemoteUnit,
+			Started:    entry.Started.UTC().Format(time.RFC3339),
+			Duration:   entry.Finished.Sub(entry.Started).String(),
+			ExitStatus: entry.ExitStatus,
+			Error:      entry.Error,
+			StderrTail: entry.StderrTail,
+		}
+	}
+	return c.out.Write(ctx, entries)
+}
+
+func formatUnitHistoryTabular(value interface{}) ([]byte, error) {
+	entries, ok := value.([]unitHistoryEntry)
+	if !ok {
+		return nil, errors.Errorf("expected value of type %T, got %T", entries, value)
+	}
+	var out bytes.Buffer
+	tw := tabwriter.NewWriter(&out, 0, 1, 1, ' ', 0)
+	p := func(values ...interface{}) {
+		for _, v := range values {
+			fmt.Fprintf(tw, "%v\t", v)
+		}
+		fmt.Fprintln(tw)
+	}
+	p("STARTED\tDURATION\tKIND\tNAME\tRELATION\tREMOTE-UNIT\tEXIT\tERROR")
+	for _, e := range entries {
+		p(
+			e.Started,
+			e.Duration,
+			e.Kind,
+			e.Name,
+			e.Relation,
+			e.RemoteUnit,
+			e.ExitStatus,
+			strings.Replace(e.Error, "\n", " ", -1),
+		)
+	}
+	tw.Flush()
+	return out.Bytes(), nil
+}
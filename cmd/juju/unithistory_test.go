@@ -0,0 +1,125 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"bytes"
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	goyaml "gopkg.in/yaml.v1"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	coretesting "github.com/juju/juju/testing"
+)
+
+type UnitHistorySuite struct {
+	coretesting.FakeJujuHomeSuite
+	client *fakeUnitHistoryClient
+}
+
+var _ = gc.Suite(&UnitHistorySuite{})
+
+func (s *UnitHistorySuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	started := time.Date(2015, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.client = &fakeUnitHistoryClient{
+		history: []params.UnitHistoryEntry{{
+			Kind:     "hook",
+			Name:     "install",
+			Started:  started,
+			Finished: started.Add(2 * time.Second),
+		}, {
+			Kind:       "hook",
+			Name:       "db-relation-joined",
+			Relation:   "db:2",
+			RemoteUnit: "mysql/0",
+			Started:    started.Add(time.Minute),
+			Finished:   started.Add(time.Minute + time.Second),
+			ExitStatus: 1,
+			Error:      "exit status 1",
+			StderrTail: "oops\n",
+		}},
+	}
+	s.PatchValue(&getUnitHistoryAPI, func(*UnitHistoryCommand) (unitHistoryAPI, error) {
+		return s.client, nil
+	})
+}
+
+type fakeUnitHistoryClient struct {
+	unitName string
+	history  []params.UnitHistoryEntry
+}
+
+func (f *fakeUnitHistoryClient) UnitHistory(unitName string) ([]params.UnitHistoryEntry, error) {
+	f.unitName = unitName
+	return f.history, nil
+}
+
+func (f *fakeUnitHistoryClient) Close() error {
+	return nil
+}
+
+func (s *UnitHistorySuite) TestInit(c *gc.C) {
+	for i, t := range []struct {
+		args []string
+		err  string
+	}{{
+		err: "no unit name specified",
+	}, {
+		args: []string{"wordpress"},
+		err:  `invalid unit name "wordpress"`,
+	}, {
+		args: []string{"wordpress/0", "mysql/0"},
+		err:  `unrecognized args: \["mysql/0"\]`,
+	}, {
+		args: []string{"wordpress/0"},
+	}} {
+		c.Logf("test %d: %v", i, t.args)
+		err := coretesting.InitCommand(&UnitHistoryCommand{}, t.args)
+		if t.err == "" {
+			c.Check(err, jc.ErrorIsNil)
+		} else {
+			c.Check(err, gc.ErrorMatches, t.err)
+		}
+	}
+}
+
+func (s *UnitHistorySuite) TestRunTabular(c *gc.C) {
+	ctx, err := coretesting.RunCommand(c, envcmd.Wrap(&UnitHistoryCommand{}), "wordpress/0")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.client.unitName, gc.Equals, "wordpress/0")
+	c.Assert(coretesting.Stdout(ctx), gc.Equals, ""+
+		"STARTED              DURATION KIND NAME               RELATION REMOTE-UNIT EXIT ERROR         \n"+
+		"2015-05-01T12:00:00Z 2s       hook install                                 0                  \n"+
+		"2015-05-01T12:01:00Z 1s       hook db-relation-joined db:2     mysql/0     1    exit status 1 \n",
+	)
+}
+
+func (s *UnitHistorySuite) TestRunYAML(c *gc.C) {
+	ctx, err := coretesting.RunCommand(c, envcmd.Wrap(&UnitHistoryCommand{}), "--format", "yaml", "wordpress/0")
+	c.Assert(err, jc.ErrorIsNil)
+	var history []map[string]interface{}
+	err = goyaml.Unmarshal(ctx.Stdout.(*bytes.Buffer).Bytes(), &history)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, jc.DeepEquals, []map[string]interface{}{{
+		"kind":        "hook",
+		"name":        "install",
+		"started":     "2015-05-01T12:00:00Z",
+		"duration":    "2s",
+		"exit-status": 0,
+	}, {
+		"kind":        "hook",
+		"name":        "db-relation-joined",
+		"relation":    "db:2",
+		"remote-unit": "mysql/0",
+		"started":     "2015-05-01T12:01:00Z",
+		"duration":    "1s",
+		"exit-status": 1,
+		"error":       "exit status 1",
+		"stderr":      "oops\n",
+	}})
+}
@@ -115,6 +115,22 @@ func (a *AgentConf) SetAPIHostPorts(servers [][]network.HostPort) error {
 	})
 }
 
+// SetMigrationTarget satisfies worker/migrationminion/TargetSetter.
+func (a *AgentConf) SetMigrationTarget(apiAddrs []string, caCert string) error {
+	return a.ChangeConfig(migrateAPIServers(apiAddrs, caCert))
+}
+
+// migrateAPIServers returns an AgentConfigMutator which points the
+// agent at the API servers of another controller.
+func migrateAPIServers(apiAddrs []string, caCert string) AgentConfigMutator {
+	return func(c agent.ConfigSetter) error {
+		return c.Migrate(agent.MigrateParams{
+			APIAddresses: apiAddrs,
+			CACert:       caCert,
+		})
+	}
+}
+
 // SetStateServingInfo satisfies worker/certupdater/SetStateServingInfo.
 func (a *AgentConf) SetStateServingInfo(info params.StateServingInfo) error {
 	return a.ChangeConfig(func(c agent.ConfigSetter) error {
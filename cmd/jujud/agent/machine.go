@@ -42,6 +42,7 @@ import (
 	"github.com/juju/juju/container"
 	"github.com/juju/juju/container/kvm"
 	"github.com/juju/juju/container/lxc"
+	"github.com/juju/juju/container/lxd"
 	"github.com/juju/juju/environs"
 	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/feature"
@@ -69,6 +70,7 @@ import (
 	"github.com/juju/juju/worker/dblogpruner"
 	"github.com/juju/juju/worker/deployer"
 	"github.com/juju/juju/worker/diskmanager"
+	"github.com/juju/juju/worker/dnspublisher"
 	"github.com/juju/juju/worker/envworkermanager"
 	"github.com/juju/juju/worker/firewaller"
 	"github.com/juju/juju/worker/instancepoller"
@@ -76,12 +78,14 @@ import (
 	workerlogger "github.com/juju/juju/worker/logger"
 	"github.com/juju/juju/worker/machiner"
 	"github.com/juju/juju/worker/metricworker"
+	"github.com/juju/juju/worker/migrationminion"
 	"github.com/juju/juju/worker/minunitsworker"
 	"github.com/juju/juju/worker/networker"
 	"github.com/juju/juju/worker/peergrouper"
 	"github.com/juju/juju/worker/provisioner"
 	"github.com/juju/juju/worker/proxyupdater"
 	rebootworker "github.com/juju/juju/worker/reboot"
+	"github.com/juju/juju/worker/remoterelations"
 	"github.com/juju/juju/worker/resumer"
 	"github.com/juju/juju/worker/rsyslog"
 	"github.com/juju/juju/worker/singular"
@@ -310,6 +314,11 @@ func (a *MachineAgent) IsRestoreRunning() bool {
 	return a.restoring
 }
 
+// SetMigrationTarget satisfies worker/migrationminion/TargetSetter.
+func (a *MachineAgent) SetMigrationTarget(apiAddrs []string, caCert string) error {
+	return a.ChangeConfig(migrateAPIServers(apiAddrs, caCert))
+}
+
 // Wait waits for the machine agent to finish.
 func (a *MachineAgent) Wait() error {
 	return a.tomb.Wait()
@@ -693,6 +702,9 @@ func (a *MachineAgent) postUpgradeAPIWorker(
 	runner.StartWorker("apiaddressupdater", func() (worker.Worker, error) {
 		return apiaddressupdater.NewAPIAddressUpdater(st.Machiner(), a.apiAddressSetter), nil
 	})
+	runner.StartWorker("migrationminion", func() (worker.Worker, error) {
+		return migrationminion.New(st.MigrationMinion(), a), nil
+	})
 	runner.StartWorker("logger", func() (worker.Worker, error) {
 		return workerlogger.NewLogger(st.Logger(), agentConfig), nil
 	})
@@ -822,6 +834,14 @@ func (a *MachineAgent) setupContainerSupport(runner worker.Runner, st *api.State
 	if err == nil && supportsKvm {
 		supportedContainers = append(supportedContainers, instance.KVM)
 	}
+
+	supportsLXD, err := lxd.IsLXDSupported()
+	if err != nil {
+		logger.Warningf("determining lxd support: %v\nno lxd containers possible", err)
+	}
+	if err == nil && supportsLXD {
+		supportedContainers = append(supportedContainers, instance.LXD)
+	}
 	return a.updateSupportedContainers(runner, st, entity.Tag(), supportedContainers, agentConfig)
 }
 
@@ -906,6 +926,15 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 	}
 	reportOpenedState(st)
 
+	// The agent configuration only records whether IPv6 is
+	// preferred, so take the complete address policy from the
+	// environment now that it is available.
+	if envConfig, err := st.EnvironConfig(); err != nil {
+		logger.Errorf("cannot get environment config: %v", err)
+	} else {
+		network.InitializeFromConfig(envConfig)
+	}
+
 	stor := statestorage.NewStorage(st.EnvironUUID(), st.MongoSession())
 	registerSimplestreamsDataSource(stor)
 
@@ -1047,6 +1076,12 @@ func (a *MachineAgent) startEnvWorkers(
 	singularRunner.StartWorker("addresserworker", func() (worker.Worker, error) {
 		return addresser.NewWorker(st)
 	})
+	singularRunner.StartWorker("dnspublisher", func() (worker.Worker, error) {
+		return dnspublisher.New(st), nil
+	})
+	singularRunner.StartWorker("remoterelations", func() (worker.Worker, error) {
+		return remoterelations.New(st), nil
+	})
 
 	// Start workers that use an API connection.
 	singularRunner.StartWorker("environ-provisioner", func() (worker.Worker, error) {
@@ -1126,19 +1161,33 @@ func (a *MachineAgent) newApiserverWorker(st *state.State, certChanged chan para
 	dataDir := agentConfig.DataDir()
 	logDir := agentConfig.LogDir()
 
+	// The request limits and REST gateway restrictions are read
+	// once, so changes to them take effect when the API server
+	// restarts.
+	envConfig, err := st.EnvironConfig()
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot read environment config")
+	}
+	rateLimit := apiserver.RateLimitConfig{
+		RequestsPerSecond:     float64(envConfig.APIRequestRate()),
+		MaxConcurrentRequests: envConfig.APIMaxConcurrentRequests(),
+	}
+
 	endpoint := net.JoinHostPort("", strconv.Itoa(info.APIPort))
 	listener, err := net.Listen("tcp", endpoint)
 	if err != nil {
 		return nil, err
 	}
 	return apiserver.NewServer(st, listener, apiserver.ServerConfig{
-		Cert:        cert,
-		Key:         key,
-		Tag:         tag,
-		DataDir:     dataDir,
-		LogDir:      logDir,
-		Validator:   a.limitLogins,
-		CertChanged: certChanged,
+		Cert:         cert,
+		Key:          key,
+		Tag:          tag,
+		DataDir:      dataDir,
+		LogDir:       logDir,
+		Validator:    a.limitLogins,
+		CertChanged:  certChanged,
+		RateLimit:    rateLimit,
+		RESTReadOnly: envConfig.RESTReadOnly(),
 	})
 }
 
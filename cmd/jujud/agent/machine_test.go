@@ -242,6 +242,8 @@ var perEnvSingularWorkers = []string{
 	"cleaner",
 	"minunitsworker",
 	"addresserworker",
+	"dnspublisher",
+	"remoterelations",
 	"environ-provisioner",
 	"charm-revision-updater",
 	"firewaller",
@@ -8,11 +8,14 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/juju/cmd"
 	"github.com/juju/errors"
 	"github.com/juju/names"
+	"github.com/juju/utils"
 	"github.com/juju/utils/exec"
+	goyaml "gopkg.in/yaml.v1"
 	"launchpad.net/gnuflag"
 
 	"github.com/juju/juju/agent"
@@ -20,6 +23,7 @@ import (
 	"github.com/juju/juju/juju/sockets"
 	"github.com/juju/juju/version"
 	"github.com/juju/juju/worker/uniter"
+	"github.com/juju/juju/worker/uniter/runner/debug"
 )
 
 type RunCommand struct {
@@ -31,6 +35,8 @@ type RunCommand struct {
 	forceRemoteUnit bool
 	relationId      string
 	remoteUnitName  string
+	debugHooks      string
+	hooks           []string
 }
 
 const runCommandDoc = `
@@ -45,6 +51,15 @@ If --no-context is specified, the <unit-name> positional
 argument is not needed.
 
 The commands are executed with '/bin/bash -s', and the output returned.
+
+If --debug-hooks is specified, juju-run drives a non-interactive
+debug-hooks session for the unit instead, as used by
+"juju debug-hooks --dap". The operation is one of:
+
+  start [hook names]  enable the session and wait for a matching hook
+  exec <commands>     run commands in the paused hook's context
+  step                run the paused hook and wait for the next one
+  resume              run the paused hook, if any, and end the session
 `
 
 // Info returns usage information for the command.
@@ -63,6 +78,7 @@ func (c *RunCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.StringVar(&c.relationId, "relation", "", "")
 	f.StringVar(&c.remoteUnitName, "remote-unit", "", "run the commands for a specific remote unit in a relation context on a unit")
 	f.BoolVar(&c.forceRemoteUnit, "force-remote-unit", false, "run the commands for a specific relation context, bypassing the remote unit check")
+	f.StringVar(&c.debugHooks, "debug-hooks", "", "perform a debug-hooks session operation for the unit")
 }
 
 func (c *RunCommand) Init(args []string) error {
@@ -70,6 +86,9 @@ func (c *RunCommand) Init(args []string) error {
 	if contextId, err := getenv("JUJU_CONTEXT_ID"); err == nil && contextId != "" {
 		return fmt.Errorf("juju-run cannot be called from within a hook, have context %q", contextId)
 	}
+	if c.debugHooks != "" && c.noContext {
+		return fmt.Errorf("--debug-hooks cannot be used with --no-context")
+	}
 	if !c.noContext {
 		if len(args) < 1 {
 			return fmt.Errorf("missing unit-name")
@@ -89,6 +108,9 @@ func (c *RunCommand) Init(args []string) error {
 			}
 		}
 	}
+	if c.debugHooks != "" {
+		return c.initDebugHooks(args)
+	}
 	if len(args) < 1 {
 		return fmt.Errorf("missing commands")
 	}
@@ -96,11 +118,30 @@ func (c *RunCommand) Init(args []string) error {
 	return cmd.CheckEmpty(args)
 }
 
+func (c *RunCommand) initDebugHooks(args []string) error {
+	switch c.debugHooks {
+	case debug.RemoteStart:
+		c.hooks = args
+		return nil
+	case debug.RemoteExec:
+		if len(args) < 1 {
+			return fmt.Errorf("missing commands")
+		}
+		c.commands, args = args[0], args[1:]
+	case debug.RemoteStep, debug.RemoteResume:
+	default:
+		return fmt.Errorf("unknown debug-hooks operation %q", c.debugHooks)
+	}
+	return cmd.CheckEmpty(args)
+}
+
 func (c *RunCommand) Run(ctx *cmd.Context) error {
 	var result *exec.ExecResponse
 	var err error
 	if c.noContext {
 		result, err = c.executeNoContext()
+	} else if c.debugHooks != "" {
+		result, err = c.executeDebugHooks()
 	} else {
 		result, err = c.executeInUnitContext()
 	}
@@ -118,15 +159,21 @@ func (c *RunCommand) socketPath() string {
 	return paths.Runtime.JujuRunSocket
 }
 
-func (c *RunCommand) executeInUnitContext() (*exec.ExecResponse, error) {
+// checkUnitExists returns an error if the unit's agent
+// directory is not present on this machine.
+func (c *RunCommand) checkUnitExists() error {
 	unitDir := agent.Dir(cmdutil.DataDir, c.unit)
 	logger.Debugf("looking for unit dir %s", unitDir)
-	// make sure the unit exists
 	_, err := os.Stat(unitDir)
 	if os.IsNotExist(err) {
-		return nil, errors.Errorf("unit %q not found on this machine", c.unit.Id())
-	} else if err != nil {
-		return nil, errors.Trace(err)
+		return errors.Errorf("unit %q not found on this machine", c.unit.Id())
+	}
+	return errors.Trace(err)
+}
+
+func (c *RunCommand) executeInUnitContext() (*exec.ExecResponse, error) {
+	if err := c.checkUnitExists(); err != nil {
+		return nil, err
 	}
 
 	relationId, err := checkRelationId(c.relationId)
@@ -154,6 +201,78 @@ func (c *RunCommand) executeInUnitContext() (*exec.ExecResponse, error) {
 	return &result, errors.Trace(err)
 }
 
+// debugHooksAttempt determines how long juju-run waits for a hook
+// to be paused by a debug-hooks session.
+var debugHooksAttempt = utils.AttemptStrategy{
+	Total: 10 * time.Minute,
+	Delay: time.Second,
+}
+
+func (c *RunCommand) executeDebugHooks() (*exec.ExecResponse, error) {
+	if err := c.checkUnitExists(); err != nil {
+		return nil, err
+	}
+	debugctx := debug.NewHooksContext(c.unit.Id())
+	client := debug.NewRemoteClient(debugctx)
+	switch c.debugHooks {
+	case debug.RemoteStart:
+		if err := debugctx.StartRemoteSession(c.hooks); err != nil {
+			return nil, errors.Annotate(err, "cannot start debug-hooks session")
+		}
+		return waitForDebugHook(client, nil)
+	case debug.RemoteExec:
+		return client.Exec(c.commands)
+	case debug.RemoteStep:
+		result, err := client.Continue(false)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return waitForDebugHook(client, result)
+	}
+	// Resuming with no paused hook just ends the session.
+	if _, err := client.Hook(); err != nil {
+		return &exec.ExecResponse{}, debugctx.StopRemoteSession()
+	}
+	result, err := client.Continue(true)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return continueResponse(result), nil
+}
+
+// waitForDebugHook waits for a hook to be paused, and reports it
+// along with the outcome of any previously paused hook.
+func waitForDebugHook(client *debug.RemoteClient, previous *debug.ContinueResult) (*exec.ExecResponse, error) {
+	response := &exec.ExecResponse{}
+	if previous != nil {
+		response = continueResponse(previous)
+	}
+	var info *debug.HookInfo
+	var err error
+	for a := debugHooksAttempt.Start(); a.Next(); {
+		if info, err = client.Hook(); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, errors.Annotate(err, "timed out waiting for hook")
+	}
+	response.Stdout, err = goyaml.Marshal(info)
+	return response, errors.Trace(err)
+}
+
+func continueResponse(result *debug.ContinueResult) *exec.ExecResponse {
+	if result.Error == "" {
+		return &exec.ExecResponse{
+			Stderr: []byte(fmt.Sprintf("hook %q completed\n", result.Hook)),
+		}
+	}
+	return &exec.ExecResponse{
+		Code:   1,
+		Stderr: []byte(fmt.Sprintf("hook %q failed: %s\n", result.Hook, result.Error)),
+	}
+}
+
 // appendProxyToCommands activates proxy settings on platforms
 // that support this feature via the command line. Currently this
 // will work on most GNU/Linux systems, but has no use in Windows
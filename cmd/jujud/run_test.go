@@ -126,6 +126,69 @@ func (*RunTestSuite) TestArgParsing(c *gc.C) {
 	}
 }
 
+func (*RunTestSuite) TestDebugHooksArgParsing(c *gc.C) {
+	for i, test := range []struct {
+		title    string
+		args     []string
+		errMatch string
+		op       string
+		hooks    []string
+		commands string
+	}{{
+		title:    "no context",
+		args:     []string{"--debug-hooks=step", "--no-context"},
+		errMatch: "--debug-hooks cannot be used with --no-context",
+	}, {
+		title:    "unknown operation",
+		args:     []string{"--debug-hooks=pause", "foo/2"},
+		errMatch: `unknown debug-hooks operation "pause"`,
+	}, {
+		title: "start, all hooks",
+		args:  []string{"--debug-hooks=start", "foo/2"},
+		op:    "start",
+	}, {
+		title: "start, named hooks",
+		args:  []string{"--debug-hooks=start", "foo/2", "install", "start"},
+		op:    "start",
+		hooks: []string{"install", "start"},
+	}, {
+		title:    "exec",
+		args:     []string{"--debug-hooks=exec", "foo/2", "config-get"},
+		op:       "exec",
+		commands: "config-get",
+	}, {
+		title:    "exec without commands",
+		args:     []string{"--debug-hooks=exec", "foo/2"},
+		errMatch: "missing commands",
+	}, {
+		title: "step",
+		args:  []string{"--debug-hooks=step", "foo/2"},
+		op:    "step",
+	}, {
+		title:    "resume with extra args",
+		args:     []string{"--debug-hooks=resume", "foo/2", "bar"},
+		errMatch: `unrecognized args: \["bar"\]`,
+	}} {
+		c.Logf("%d: %s", i, test.title)
+		runCommand := &RunCommand{}
+		err := testing.InitCommand(runCommand, test.args)
+		if test.errMatch != "" {
+			c.Assert(err, gc.ErrorMatches, test.errMatch)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(runCommand.unit, gc.Equals, names.NewUnitTag("foo/2"))
+		c.Assert(runCommand.debugHooks, gc.Equals, test.op)
+		c.Assert(runCommand.hooks, jc.DeepEquals, test.hooks)
+		c.Assert(runCommand.commands, gc.Equals, test.commands)
+	}
+}
+
+func (s *RunTestSuite) TestDebugHooksMissingAgent(c *gc.C) {
+	_, err := testing.RunCommand(c, &RunCommand{}, "--debug-hooks=step", "foo/2")
+	c.Assert(err, gc.ErrorMatches, `unit "foo/2" not found on this machine`)
+}
+
 func (s *RunTestSuite) TestInsideContext(c *gc.C) {
 	s.PatchEnvironment("JUJU_CONTEXT_ID", "fake-id")
 	runCommand := &RunCommand{}
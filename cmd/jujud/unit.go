@@ -26,6 +26,7 @@ import (
 	"github.com/juju/juju/worker"
 	"github.com/juju/juju/worker/apiaddressupdater"
 	workerlogger "github.com/juju/juju/worker/logger"
+	"github.com/juju/juju/worker/migrationminion"
 	"github.com/juju/juju/worker/proxyupdater"
 	"github.com/juju/juju/worker/rsyslog"
 	"github.com/juju/juju/worker/uniter"
@@ -185,6 +186,9 @@ func (a *UnitAgent) APIWorkers() (worker.Worker, error) {
 		}
 		return apiaddressupdater.NewAPIAddressUpdater(uniterFacade, a), nil
 	})
+	runner.StartWorker("migrationminion", func() (worker.Worker, error) {
+		return migrationminion.New(st.MigrationMinion(), a), nil
+	})
 	runner.StartWorker("rsyslog", func() (worker.Worker, error) {
 		return cmdutil.NewRsyslogConfigWorker(st.Rsyslog(), agentConfig, rsyslog.RsyslogModeForwarding)
 	})
@@ -11,7 +11,6 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/juju/loggo"
-	"github.com/juju/utils/fslock"
 	"gopkg.in/natefinch/lumberjack.v2"
 
 	"github.com/juju/juju/agent"
@@ -20,8 +19,10 @@ import (
 	"github.com/juju/juju/juju/paths"
 	"github.com/juju/juju/mongo"
 	"github.com/juju/juju/state"
+	"github.com/juju/juju/utils/hooklock"
 	"github.com/juju/juju/version"
 	"github.com/juju/juju/worker"
+	"github.com/juju/juju/worker/migrationminion"
 	"github.com/juju/juju/worker/rsyslog"
 	"github.com/juju/juju/worker/upgrader"
 )
@@ -118,11 +119,11 @@ type Pinger interface {
 
 // ConnectionIsFatal returns a function suitable for passing as the
 // isFatal argument to worker.NewRunner, that diagnoses an error as
-// fatal if the connection has failed or if the error is otherwise
-// fatal.
+// fatal if the connection has failed, if the agent's environment has
+// moved to another controller, or if the error is otherwise fatal.
 func ConnectionIsFatal(logger loggo.Logger, conns ...Pinger) func(err error) bool {
 	return func(err error) bool {
-		if IsFatal(err) {
+		if IsFatal(err) || errors.Cause(err) == migrationminion.ErrMigrated {
 			return true
 		}
 		for _, conn := range conns {
@@ -228,12 +229,12 @@ func (c *CloseWorker) Wait() error {
 	return err
 }
 
-// HookExecutionLock returns an *fslock.Lock suitable for use as a
+// HookExecutionLock returns a *hooklock.Lock suitable for use as a
 // unit hook execution lock. Other workers may also use this lock if
 // they require isolation from hook execution.
-func HookExecutionLock(dataDir string) (*fslock.Lock, error) {
+func HookExecutionLock(dataDir string) (*hooklock.Lock, error) {
 	lockDir := filepath.Join(dataDir, "locks")
-	return fslock.NewLock(lockDir, "uniter-hook-execution")
+	return hooklock.New(lockDir)
 }
 
 // NewRsyslogConfigWorker creates and returns a new
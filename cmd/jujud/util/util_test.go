@@ -15,6 +15,7 @@ import (
 	"github.com/juju/juju/apiserver/params"
 	coretesting "github.com/juju/juju/testing"
 	"github.com/juju/juju/worker"
+	"github.com/juju/juju/worker/migrationminion"
 	"github.com/juju/juju/worker/upgrader"
 )
 
@@ -130,6 +131,14 @@ func (s *toolSuite) TestConnectionIsFatalWithMultipleConns(c *gc.C) {
 		jc.IsTrue)
 }
 
+func (s *toolSuite) TestConnectionIsFatalMigrated(c *gc.C) {
+	var okPinger testPinger = func() error {
+		return nil
+	}
+	c.Assert(ConnectionIsFatal(logger, okPinger)(migrationminion.ErrMigrated), jc.IsTrue)
+	c.Assert(IsFatal(migrationminion.ErrMigrated), jc.IsFalse)
+}
+
 func (*toolSuite) TestIsFatal(c *gc.C) {
 
 	for i, test := range isFatalTests {
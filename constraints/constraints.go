@@ -15,6 +15,7 @@ import (
 
 	"github.com/juju/juju/instance"
 	"github.com/juju/juju/juju/arch"
+	"github.com/juju/juju/network"
 )
 
 // The following constants list the supported constraint attribute names, as defined
@@ -29,6 +30,7 @@ const (
 	Tags         = "tags"
 	InstanceType = "instance-type"
 	Networks     = "networks"
+	Spaces       = "spaces"
 )
 
 // Value describes a user's requirements of the hardware on which units
@@ -78,6 +80,12 @@ type Value struct {
 	// negative values are accepted, and the difference is the latter
 	// have a "^" prefix to the name.
 	Networks *[]string `json:"networks,omitempty" yaml:"networks,omitempty"`
+
+	// Spaces, if not nil, holds a list of juju space names in which
+	// the machine must (or must not) have an address. Positive and
+	// negative values are accepted, and the difference is the latter
+	// have a "^" prefix to the name.
+	Spaces *[]string `json:"spaces,omitempty" yaml:"spaces,omitempty"`
 }
 
 // fieldNames records a mapping from the constraint tag to struct field name.
@@ -118,10 +126,16 @@ func (v *Value) HasInstanceType() bool {
 // extractNetworks returns the list of networks to include or exclude
 // (without the "^" prefixes).
 func (v *Value) extractNetworks() (include, exclude []string) {
-	if v.Networks == nil {
+	return extractItems(v.Networks)
+}
+
+// extractItems returns the items of a networks or spaces constraint
+// to include or exclude (without the "^" prefixes).
+func extractItems(items *[]string) (include, exclude []string) {
+	if items == nil {
 		return nil, nil
 	}
-	for _, name := range *v.Networks {
+	for _, name := range *items {
 		if strings.HasPrefix(name, "^") {
 			exclude = append(exclude, strings.TrimPrefix(name, "^"))
 		} else {
@@ -151,6 +165,27 @@ func (v *Value) HaveNetworks() bool {
 	return v.Networks != nil && len(*v.Networks) > 0
 }
 
+// IncludeSpaces returns a list of spaces in which a machine must
+// have an address, if specified.
+func (v *Value) IncludeSpaces() []string {
+	include, _ := extractItems(v.Spaces)
+	return include
+}
+
+// ExcludeSpaces returns a list of spaces in which a machine must not
+// have an address, if specified. They are given in the spaces
+// constraint with a "^" prefix to the name, which is stripped before
+// returning.
+func (v *Value) ExcludeSpaces() []string {
+	_, exclude := extractItems(v.Spaces)
+	return exclude
+}
+
+// HaveSpaces returns whether any space constraints were specified.
+func (v *Value) HaveSpaces() bool {
+	return v.Spaces != nil && len(*v.Spaces) > 0
+}
+
 // String expresses a constraints.Value in the language in which it was specified.
 func (v Value) String() string {
 	var strs []string
@@ -191,6 +226,10 @@ func (v Value) String() string {
 		s := strings.Join(*v.Networks, ",")
 		strs = append(strs, "networks="+s)
 	}
+	if v.Spaces != nil {
+		s := strings.Join(*v.Spaces, ",")
+		strs = append(strs, "spaces="+s)
+	}
 	return strings.Join(strs, " ")
 }
 
@@ -330,6 +369,8 @@ func (v *Value) setRaw(raw string) error {
 		err = v.setInstanceType(str)
 	case Networks:
 		err = v.setNetworks(str)
+	case Spaces:
+		err = v.setSpaces(str)
 	default:
 		return fmt.Errorf("unknown constraint %q", name)
 	}
@@ -376,6 +417,12 @@ func (v *Value) SetYAML(tag string, value interface{}) bool {
 			if err == nil {
 				err = v.validateNetworks(networks)
 			}
+		case Spaces:
+			var spaces *[]string
+			spaces, err = parseYamlStrings("spaces", val)
+			if err == nil {
+				err = v.validateSpaces(spaces)
+			}
 		default:
 			return false
 		}
@@ -494,6 +541,27 @@ func (v *Value) validateNetworks(networks *[]string) error {
 	return nil
 }
 
+func (v *Value) setSpaces(str string) error {
+	if v.Spaces != nil {
+		return fmt.Errorf("already set")
+	}
+	return v.validateSpaces(parseCommaDelimited(str))
+}
+
+func (v *Value) validateSpaces(spaces *[]string) error {
+	if spaces == nil {
+		return nil
+	}
+	for _, name := range *spaces {
+		name = strings.TrimPrefix(name, "^")
+		if !network.IsValidSpaceName(name) {
+			return fmt.Errorf("%q is not a valid space name", name)
+		}
+	}
+	v.Spaces = spaces
+	return nil
+}
+
 func parseUint64(str string) (*uint64, error) {
 	var value uint64
 	if str != "" {
@@ -525,7 +593,8 @@ func parseSize(str string) (*uint64, error) {
 }
 
 // parseCommaDelimited returns the items in the value s. We expect the
-// tags to be comma delimited strings. It is used for tags and networks.
+// tags to be comma delimited strings. It is used for tags, networks
+// and spaces.
 func parseCommaDelimited(s string) *[]string {
 	if s == "" {
 		return &[]string{}
@@ -280,6 +280,26 @@ var parseConstraintsTests = []struct {
 		args:    []string{"networks="},
 	},
 
+	// spaces
+	{
+		summary: "single space",
+		args:    []string{"spaces=db"},
+	}, {
+		summary: "multiple spaces - positive and negative",
+		args:    []string{"spaces=db,^dmz,internal-2"},
+	}, {
+		summary: "no spaces",
+		args:    []string{"spaces="},
+	}, {
+		summary: "invalid space name",
+		args:    []string{"spaces=Db"},
+		err:     `bad "spaces" constraint: "Db" is not a valid space name`,
+	}, {
+		summary: "spaces set twice",
+		args:    []string{"spaces=db", "spaces=dmz"},
+		err:     `bad "spaces" constraint: already set`,
+	},
+
 	// instance type
 	{
 		summary: "set instance type",
@@ -373,6 +393,16 @@ func (s *ConstraintsSuite) TestIncludeExcludeAndHaveNetworks(c *gc.C) {
 	c.Check(con.HaveNetworks(), jc.IsTrue)
 }
 
+func (s *ConstraintsSuite) TestIncludeExcludeAndHaveSpaces(c *gc.C) {
+	con := constraints.MustParse("spaces=db,^dmz,internal,^admin")
+	c.Check(con.IncludeSpaces(), jc.SameContents, []string{"db", "internal"})
+	c.Check(con.ExcludeSpaces(), jc.SameContents, []string{"dmz", "admin"})
+	c.Check(con.HaveSpaces(), jc.IsTrue)
+	con = constraints.MustParse("spaces=")
+	c.Check(con.HaveSpaces(), jc.IsFalse)
+	c.Check(&con, gc.Not(jc.Satisfies), constraints.IsEmpty)
+}
+
 func (s *ConstraintsSuite) TestInvalidNetworks(c *gc.C) {
 	invalidNames := []string{
 		"%ne$t", "^net#2", "_", "tcp:ip",
@@ -459,6 +489,9 @@ var constraintsRoundtripTests = []roundTrip{
 	{"Networks1", constraints.Value{Networks: nil}},
 	{"Networks2", constraints.Value{Networks: &[]string{}}},
 	{"Networks3", constraints.Value{Networks: &[]string{"net1", "^net2"}}},
+	{"Spaces1", constraints.Value{Spaces: nil}},
+	{"Spaces2", constraints.Value{Spaces: &[]string{}}},
+	{"Spaces3", constraints.Value{Spaces: &[]string{"db", "^dmz"}}},
 	{"InstanceType1", constraints.Value{InstanceType: strp("")}},
 	{"InstanceType2", constraints.Value{InstanceType: strp("foo")}},
 	{"All", constraints.Value{
@@ -470,6 +503,7 @@ var constraintsRoundtripTests = []roundTrip{
 		RootDisk:     uint64p(24000000000),
 		Tags:         &[]string{"foo", "bar"},
 		Networks:     &[]string{"net1", "^net2"},
+		Spaces:       &[]string{"db", "^dmz"},
 		InstanceType: strp("foo"),
 	}},
 }
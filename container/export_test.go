@@ -5,7 +5,6 @@ package container
 
 var (
 	NetworkInterfacesFile = &networkInterfacesFile
-	CloudInitUserData     = cloudInitUserData
 )
 
 // IsLocked is used just to see if the local lock instance is locked, and
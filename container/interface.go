@@ -49,6 +49,29 @@ type Manager interface {
 	IsInitialized() bool
 }
 
+// MigrationTarget is implemented by container managers that can create
+// a container from the root filesystem of a container migrated from
+// another host machine.
+type MigrationTarget interface {
+	// CreateMigratedContainer creates and starts a new container for
+	// the specified machine, as CreateContainer does, but from the
+	// root filesystem of the source container staged on the host
+	// under MigrationStagingName(source), rather than from an image.
+	CreateMigratedContainer(
+		source instance.Id,
+		machineConfig *cloudinit.MachineConfig,
+		series string,
+		network *NetworkConfig,
+		storage *StorageConfig) (instance.Instance, *instance.HardwareCharacteristics, error)
+}
+
+// MigrationStagingName returns the name under which the root
+// filesystem of the given container is staged on the host machine it
+// is being migrated to.
+func MigrationStagingName(source instance.Id) string {
+	return string(source) + "-migrating"
+}
+
 // Initialiser is responsible for performing the steps required to initialise
 // a host machine so it can run containers.
 type Initialiser interface {
@@ -55,6 +55,12 @@ func (c *kvmContainer) Start(params StartParams) error {
 	}); err != nil {
 		return err
 	}
+	if params.CpuPower != 0 {
+		logger.Debugf("Set machine %s cpu shares for cpu-power %d", c.name, params.CpuPower)
+		if err := SetMachineCpuShares(c.name, CpuShares(params.CpuPower)); err != nil {
+			return err
+		}
+	}
 
 	logger.Debugf("Set machine %s to autostart", c.name)
 	return AutostartMachine(c.name)
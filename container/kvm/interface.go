@@ -15,6 +15,7 @@ type StartParams struct {
 	Network          *container.NetworkConfig
 	Memory           uint64 // MB
 	CpuCores         uint64
+	CpuPower         uint64 // 100 is the power of one core
 	RootDisk         uint64 // GB
 	ImageDownloadUrl string
 }
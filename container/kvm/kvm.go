@@ -149,6 +149,10 @@ func (manager *containerManager) CreateContainer(
 	if err != nil {
 		logger.Warningf("failed to parse hardware: %v", err)
 	}
+	if startParams.CpuPower != 0 {
+		cpuPower := startParams.CpuPower
+		hardware.CpuPower = &cpuPower
+	}
 
 	logger.Tracef("create the container, constraints: %v", machineConfig.Constraints)
 	if err := kvmContainer.Start(startParams); err != nil {
@@ -204,7 +208,7 @@ func (manager *containerManager) ListContainers() (result []instance.Instance, e
 }
 
 // ParseConstraintsToStartParams takes a constrants object and returns a bare
-// StartParams object that has Memory, Cpu, and Disk populated.  If there are
+// StartParams object that has Memory, Cpu, CpuPower and Disk populated.  If there are
 // no defined values in the constraints for those fields, default values are
 // used.  Other constrains cause a warning to be emitted.
 func ParseConstraintsToStartParams(cons constraints.Value) StartParams {
@@ -230,6 +234,9 @@ func ParseConstraintsToStartParams(cons constraints.Value) StartParams {
 			params.CpuCores = cores
 		}
 	}
+	if cons.CpuPower != nil {
+		params.CpuPower = *cons.CpuPower
+	}
 	if cons.RootDisk != nil {
 		size := *cons.RootDisk / 1024
 		if size < MinDisk {
@@ -244,9 +251,6 @@ func ParseConstraintsToStartParams(cons constraints.Value) StartParams {
 	if cons.Container != nil {
 		logger.Infof("container constraint of %q being ignored as not supported", *cons.Container)
 	}
-	if cons.CpuPower != nil {
-		logger.Infof("cpu-power constraint of %v being ignored as not supported", *cons.CpuPower)
-	}
 	if cons.Tags != nil {
 		logger.Infof("tags constraint of %q being ignored as not supported", strings.Join(*cons.Tags, ","))
 	}
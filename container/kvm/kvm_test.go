@@ -101,6 +101,18 @@ func (s *KVMSuite) TestCreateContainer(c *gc.C) {
 	containertesting.AssertCloudInit(c, cloudInitFilename)
 }
 
+func (s *KVMSuite) TestCreateContainerWithConstraints(c *gc.C) {
+	machineConfig, err := containertesting.MockMachineConfig("1/kvm/0")
+	c.Assert(err, jc.ErrorIsNil)
+	machineConfig.Constraints = constraints.MustParse("mem=2G cpu-cores=2 cpu-power=200 root-disk=10G")
+	network := container.BridgeNetworkConfig("nic42", nil)
+	_, hardware, err := s.manager.CreateContainer(machineConfig, "quantal", network, &container.StorageConfig{})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(kvm.TestStartParams.CpuPower, gc.Equals, uint64(200))
+	c.Assert(hardware.String(), gc.Equals, fmt.Sprintf(
+		"arch=%s cpu-cores=2 cpu-power=200 mem=2048M root-disk=10240M", version.Current.Arch))
+}
+
 func (s *KVMSuite) TestDestroyContainer(c *gc.C) {
 	instance := containertesting.CreateContainer(c, s.manager, "1/lxc/0")
 
@@ -263,11 +275,9 @@ func (s *ConstraintsSuite) TestDefaults(c *gc.C) {
 		expected: kvm.StartParams{
 			Memory:   kvm.DefaultMemory,
 			CpuCores: kvm.DefaultCpu,
+			CpuPower: 100,
 			RootDisk: kvm.DefaultDisk,
 		},
-		infoLog: []string{
-			`cpu-power constraint of 100 being ignored as not supported`,
-		},
 	}, {
 		cons: "tags=foo,bar",
 		expected: kvm.StartParams{
@@ -283,12 +293,12 @@ func (s *ConstraintsSuite) TestDefaults(c *gc.C) {
 		expected: kvm.StartParams{
 			Memory:   4 * 1024,
 			CpuCores: 4,
+			CpuPower: 100,
 			RootDisk: 20,
 		},
 		infoLog: []string{
 			`arch constraint of "armhf" being ignored as not supported`,
 			`container constraint of "lxc" being ignored as not supported`,
-			`cpu-power constraint of 100 being ignored as not supported`,
 			`tags constraint of "foo,bar" being ignored as not supported`,
 		},
 	}} {
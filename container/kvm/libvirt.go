@@ -117,6 +117,20 @@ func AutostartMachine(hostname string) error {
 	return err
 }
 
+// CpuShares returns the scheduler weight that gives a virtual machine
+// the given cpu power, where 100 is the power of one core and 1024 is
+// the default weight.
+func CpuShares(cpuPower uint64) uint64 {
+	return cpuPower * 1024 / 100
+}
+
+// SetMachineCpuShares sets the scheduler weight of the virtual machine
+// identified by hostname, both now and when it is next started.
+func SetMachineCpuShares(hostname string, shares uint64) error {
+	_, err := run("virsh", "schedinfo", hostname, "--config", "--live", fmt.Sprintf("cpu_shares=%d", shares))
+	return err
+}
+
 // ListMachines returns a map of machine name to state, where state is one of:
 // running, idle, paused, shutdown, shut off, crashed, dying, pmsuspended.
 func ListMachines() (map[string]string, error) {
@@ -27,6 +27,7 @@ import (
 	"launchpad.net/golxc"
 
 	"github.com/juju/juju/agent"
+	"github.com/juju/juju/constraints"
 	"github.com/juju/juju/container"
 	"github.com/juju/juju/environs/cloudinit"
 	"github.com/juju/juju/instance"
@@ -54,6 +55,10 @@ const (
 	// etcNetworkInterfaces here is the path (inside the container's
 	// rootfs) where the network config is stored.
 	etcNetworkInterfaces = "/etc/network/interfaces"
+
+	// cpuPeriod is the cgroup scheduling period, in microseconds,
+	// over which a container's cpu-cores quota is measured.
+	cpuPeriod = 100000
 )
 
 // DefaultNetworkConfig returns a valid NetworkConfig to use the
@@ -124,8 +129,12 @@ type containerManager struct {
 	imageURLGetter    container.ImageURLGetter
 }
 
-// containerManager implements container.Manager.
-var _ container.Manager = (*containerManager)(nil)
+// containerManager implements container.Manager and
+// container.MigrationTarget.
+var (
+	_ container.Manager         = (*containerManager)(nil)
+	_ container.MigrationTarget = (*containerManager)(nil)
+)
 
 // NewContainerManager returns a manager object that can start and
 // stop lxc containers. The containers that are created are namespaced
@@ -196,6 +205,30 @@ func (manager *containerManager) CreateContainer(
 	series string,
 	networkConfig *container.NetworkConfig,
 	storageConfig *container.StorageConfig,
+) (instance.Instance, *instance.HardwareCharacteristics, error) {
+	return manager.create(machineConfig, series, networkConfig, storageConfig, "")
+}
+
+// CreateMigratedContainer clones an LXC container from the staged
+// root filesystem of a container migrated from another host.
+func (manager *containerManager) CreateMigratedContainer(
+	source instance.Id,
+	machineConfig *cloudinit.MachineConfig,
+	series string,
+	networkConfig *container.NetworkConfig,
+	storageConfig *container.StorageConfig,
+) (instance.Instance, *instance.HardwareCharacteristics, error) {
+	return manager.create(machineConfig, series, networkConfig, storageConfig, source)
+}
+
+// create creates and starts an LXC container, cloning it from the
+// staged migrated container source if that is set.
+func (manager *containerManager) create(
+	machineConfig *cloudinit.MachineConfig,
+	series string,
+	networkConfig *container.NetworkConfig,
+	storageConfig *container.StorageConfig,
+	source instance.Id,
 ) (inst instance.Instance, _ *instance.HardwareCharacteristics, err error) {
 	// Check our preconditions
 	if manager == nil {
@@ -232,7 +265,12 @@ func (manager *containerManager) CreateContainer(
 	}
 
 	var lxcContainer golxc.Container
-	if manager.createWithClone {
+	if source != "" {
+		lxcContainer, err = cloneMigratedContainer(name, source, userDataFilename)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+	} else if manager.createWithClone {
 		templateContainer, err := EnsureCloneTemplate(
 			manager.backingFilesystem,
 			series,
@@ -327,6 +365,14 @@ func (manager *containerManager) CreateContainer(
 			return nil, nil, errors.Annotate(err, "failed to configure the container for loopback devices")
 		}
 	}
+	hardware := &instance.HardwareCharacteristics{
+		Arch: &version.Current.Arch,
+	}
+	if limits := resourceLimitsConfig(machineConfig.Constraints, hardware); limits != "" {
+		if err := appendToContainerConfig(name, limits); err != nil {
+			return nil, nil, errors.Annotate(err, "failed to configure the container's resource limits")
+		}
+	}
 	// Update the network settings inside the run-time config of the
 	// container (e.g. /var/lib/lxc/<name>/config) before starting it.
 	netConfig := generateNetworkConfig(networkConfig)
@@ -395,13 +441,52 @@ func (manager *containerManager) CreateContainer(
 		return nil, nil, errors.Annotate(err, "container failed to start")
 	}
 
-	hardware := &instance.HardwareCharacteristics{
-		Arch: &version.Current.Arch,
-	}
-
 	return &lxcInstance{lxcContainer, name}, hardware, nil
 }
 
+// migratedConfigTemplate is the initial configuration of a staged
+// migrated container. It includes the ubuntu-cloud clone hook, so the
+// container can be cloned with new user data.
+const migratedConfigTemplate = `lxc.include = /usr/share/lxc/config/ubuntu-cloud.common.conf
+lxc.rootfs = %s
+lxc.utsname = %s
+`
+
+// cloneMigratedContainer creates the named container as a clone of the
+// root filesystem of the source container, staged on this host by a
+// container migration, with the given user data, and then removes the
+// staged container.
+func cloneMigratedContainer(name string, source instance.Id, userDataFilename string) (golxc.Container, error) {
+	stagingName := container.MigrationStagingName(source)
+	stagingDir := filepath.Join(LxcContainerDir, stagingName)
+	rootfs := filepath.Join(stagingDir, "rootfs")
+	if _, err := os.Stat(rootfs); os.IsNotExist(err) {
+		return nil, errors.NotFoundf("staged root filesystem of migrated container %q", source)
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// The staged root filesystem is copied without the source host's
+	// configuration, which refers to that host's directories.
+	config := fmt.Sprintf(migratedConfigTemplate, rootfs, stagingName)
+	if err := utils.AtomicWriteFile(containerConfigFilename(stagingName), []byte(config), 0644); err != nil {
+		return nil, errors.Annotate(err, "cannot write staged container config")
+	}
+	staged := LxcObjectFactory.New(stagingName)
+	templateParams := []string{
+		"--debug",                      // Debug errors in the cloud image
+		"--userdata", userDataFilename, // Our groovey cloud-init
+		"--hostid", name, // Use the container name as the hostid
+	}
+	lxcContainer, err := staged.Clone(name, nil, templateParams)
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot clone migrated container %q", source)
+	}
+	if err := staged.Destroy(); err != nil {
+		logger.Warningf("cannot remove staged container %q: %v", stagingName, err)
+	}
+	return lxcContainer, nil
+}
+
 func createContainer(
 	lxcContainer golxc.Container,
 	directory string,
@@ -772,6 +857,43 @@ lxc.cgroup.devices.allow = c 10:237 rwm
 	return appendToContainerConfig(name, allowLoopDevicesCfg)
 }
 
+// resourceLimitsConfig returns the cgroup settings that limit a
+// container to the resources requested by the given constraints, and
+// records the effective limits in hardware. The root-disk constraint
+// cannot be enforced on a container's filesystem, and is ignored.
+func resourceLimitsConfig(cons constraints.Value, hardware *instance.HardwareCharacteristics) string {
+	var lines []string
+	if cons.Mem != nil && *cons.Mem > 0 {
+		lines = append(lines, fmt.Sprintf("lxc.cgroup.memory.limit_in_bytes = %dM", *cons.Mem))
+		mem := *cons.Mem
+		hardware.Mem = &mem
+	}
+	if cons.CpuCores != nil && *cons.CpuCores > 0 {
+		// Allow the container a quota of cpu time in each
+		// scheduling period equal to that many whole cores.
+		lines = append(lines,
+			fmt.Sprintf("lxc.cgroup.cpu.cfs_period_us = %d", cpuPeriod),
+			fmt.Sprintf("lxc.cgroup.cpu.cfs_quota_us = %d", *cons.CpuCores*cpuPeriod),
+		)
+		cores := *cons.CpuCores
+		hardware.CpuCores = &cores
+	}
+	if cons.CpuPower != nil && *cons.CpuPower > 0 {
+		// A cpu-power of 100 is one core's worth, which is
+		// given the default weight of 1024 cpu shares.
+		lines = append(lines, fmt.Sprintf("lxc.cgroup.cpu.shares = %d", *cons.CpuPower*1024/100))
+		power := *cons.CpuPower
+		hardware.CpuPower = &power
+	}
+	if cons.RootDisk != nil {
+		logger.Infof("root-disk constraint of %vM being ignored as not supported", *cons.RootDisk)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "\n" + strings.Join(lines, "\n") + "\n"
+}
+
 func (manager *containerManager) DestroyContainer(id instance.Id) error {
 	start := time.Now()
 	name := string(id)
@@ -910,6 +1032,7 @@ func networkConfigTemplate(config container.NetworkConfig) string {
 		)
 		data.Type = "veth"
 	}
+	haveGateway := false
 	for _, iface := range config.Interfaces {
 		nic := nicData{
 			Type:        data.Type,
@@ -921,6 +1044,9 @@ func networkConfigTemplate(config container.NetworkConfig) string {
 			IPv4Address: iface.Address.Value,
 			IPv4Gateway: iface.GatewayAddress.Value,
 		}
+		if iface.ParentInterfaceName != "" {
+			nic.Link = iface.ParentInterfaceName
+		}
 		if iface.VLANTag > 0 {
 			nic.Type = "vlan"
 		}
@@ -941,6 +1067,18 @@ func networkConfigTemplate(config container.NetworkConfig) string {
 			)
 			nic.IPv4Gateway = ""
 		}
+		if nic.IPv4Gateway != "" {
+			if haveGateway {
+				// LXC adds a default route for each gateway,
+				// and a container can only have one.
+				logger.Infof(
+					"not setting IPv4 gateway %q for interface %q: already set for another interface",
+					nic.IPv4Gateway, nic.Name,
+				)
+				nic.IPv4Gateway = ""
+			}
+			haveGateway = true
+		}
 
 		data.Interfaces = append(data.Interfaces, nic)
 	}
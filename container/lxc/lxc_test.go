@@ -27,6 +27,7 @@ import (
 	"launchpad.net/golxc"
 
 	"github.com/juju/juju/agent"
+	"github.com/juju/juju/constraints"
 	"github.com/juju/juju/container"
 	"github.com/juju/juju/container/lxc"
 	"github.com/juju/juju/container/lxc/mock"
@@ -40,6 +41,7 @@ import (
 	"github.com/juju/juju/service"
 	"github.com/juju/juju/service/systemd"
 	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/version"
 )
 
 func Test(t *stdtesting.T) {
@@ -671,6 +673,64 @@ func (s *LxcSuite) TestCreateContainer(c *gc.C) {
 	c.Assert(location, gc.Equals, expectedTarget)
 }
 
+func (s *LxcSuite) TestCreateMigratedContainer(c *gc.C) {
+	source := instance.Id("test-machine-0-lxc-3")
+	stagingName := container.MigrationStagingName(source)
+	configFile := filepath.Join(c.MkDir(), "config")
+	err := ioutil.WriteFile(configFile, nil, 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	staged := s.ContainerFactory.New(stagingName)
+	err = staged.Create(configFile, "", nil, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	err = os.MkdirAll(filepath.Join(s.LxcDir, stagingName, "rootfs"), 0755)
+	c.Assert(err, jc.ErrorIsNil)
+
+	manager := s.makeManager(c, "test")
+	machineConfig, err := containertesting.MockMachineConfig("1/lxc/0")
+	c.Assert(err, jc.ErrorIsNil)
+	envConfig, err := config.New(config.NoDefaults, dummy.SampleConfig())
+	c.Assert(err, jc.ErrorIsNil)
+	machineConfig.Config = envConfig
+	inst, _, err := manager.(container.MigrationTarget).CreateMigratedContainer(
+		source,
+		machineConfig,
+		"quantal",
+		container.BridgeNetworkConfig("nic42", nil),
+		&container.StorageConfig{},
+	)
+	c.Assert(err, jc.ErrorIsNil)
+	name := string(inst.Id())
+	c.Assert(name, gc.Equals, "test-machine-1-lxc-0")
+
+	// The container is cloned from the staged one, which is removed.
+	s.AssertEvent(c, <-s.events, mock.Created, stagingName)
+	s.AssertEvent(c, <-s.events, mock.Cloned, stagingName)
+	s.AssertEvent(c, <-s.events, mock.Destroyed, stagingName)
+	s.AssertEvent(c, <-s.events, mock.Started, name)
+	c.Assert(staged.IsConstructed(), jc.IsFalse)
+
+	lxcConfContents, err := ioutil.ReadFile(lxc.ContainerConfigFilename(name))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(lxcConfContents), jc.Contains, "lxc.network.link = nic42")
+}
+
+func (s *LxcSuite) TestCreateMigratedContainerNotStaged(c *gc.C) {
+	manager := s.makeManager(c, "test")
+	machineConfig, err := containertesting.MockMachineConfig("1/lxc/0")
+	c.Assert(err, jc.ErrorIsNil)
+	envConfig, err := config.New(config.NoDefaults, dummy.SampleConfig())
+	c.Assert(err, jc.ErrorIsNil)
+	machineConfig.Config = envConfig
+	_, _, err = manager.(container.MigrationTarget).CreateMigratedContainer(
+		"test-machine-0-lxc-3",
+		machineConfig,
+		"quantal",
+		container.BridgeNetworkConfig("nic42", nil),
+		&container.StorageConfig{},
+	)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
 func (s *LxcSuite) TestCreateContainerFailsWithInjectedError(c *gc.C) {
 	errorChannel := make(chan error, 1)
 	cleanup := mock.PatchTransientErrorInjectionChannel(errorChannel)
@@ -1055,6 +1115,42 @@ lxc.cgroup.devices.allow = c 10:237 rwm
 	c.Assert(autostartLink, jc.DoesNotExist)
 }
 
+func (s *LxcSuite) TestCreateContainerWithConstraints(c *gc.C) {
+	err := os.Remove(s.RestartDir)
+	c.Assert(err, jc.ErrorIsNil)
+
+	manager := s.makeManager(c, "test")
+	machineConfig, err := containertesting.MockMachineConfig("1/lxc/0")
+	c.Assert(err, jc.ErrorIsNil)
+	machineConfig.Constraints = constraints.MustParse("mem=2G cpu-cores=2 cpu-power=50 root-disk=10G")
+	networkConfig := container.BridgeNetworkConfig("nic42", nil)
+	storageConfig := &container.StorageConfig{}
+	instance, hardware, err := manager.CreateContainer(machineConfig, "quantal", networkConfig, storageConfig)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(hardware.String(), gc.Equals, fmt.Sprintf(
+		"arch=%s cpu-cores=2 cpu-power=50 mem=2048M", version.Current.Arch))
+	config, err := ioutil.ReadFile(lxc.ContainerConfigFilename(string(instance.Id())))
+	c.Assert(err, jc.ErrorIsNil)
+	expected := fmt.Sprintf(`
+# network config
+# interface "eth0"
+lxc.network.type = veth
+lxc.network.link = nic42
+lxc.network.flags = up
+lxc.network.mtu = 4321
+
+lxc.start.auto = 1
+lxc.mount.entry = %s var/log/juju none defaults,bind 0 0
+
+lxc.cgroup.memory.limit_in_bytes = 2048M
+lxc.cgroup.cpu.cfs_period_us = 100000
+lxc.cgroup.cpu.cfs_quota_us = 200000
+lxc.cgroup.cpu.shares = 512
+`, s.logDir)
+	c.Assert(string(config), gc.Equals, expected)
+	c.Assert(c.GetTestLog(), jc.Contains, "root-disk constraint of 10240M being ignored as not supported")
+}
+
 func (s *LxcSuite) TestDestroyContainerRemovesAutostartLink(c *gc.C) {
 	manager := s.makeManager(c, "test")
 	instance := containertesting.CreateContainer(c, manager, "1/lxc/0")
@@ -1123,6 +1219,14 @@ func (*NetworkSuite) TestGenerateNetworkConfig(c *gc.C) {
 	// Test when NoAutoStart is true gateway is not added, even if there.
 	staticNICNoAutoWithGW := staticNIC
 	staticNICNoAutoWithGW.NoAutoStart = true
+	// Test a NIC attached to another bridge only gets a gateway when
+	// no other NIC has one.
+	otherBridgeNIC := staticNIC
+	otherBridgeNIC.DeviceIndex = 3
+	otherBridgeNIC.InterfaceName = "eth3"
+	otherBridgeNIC.MACAddress = "aa:bb:cc:dd:ee:f3"
+	otherBridgeNIC.Address = network.NewAddress("0.2.3.4")
+	otherBridgeNIC.ParentInterfaceName = "br-eth1"
 
 	allNICs := []network.InterfaceInfo{dhcpNIC, staticNIC, extraConfigNIC}
 	for _, test := range []struct {
@@ -1236,6 +1340,26 @@ func (*NetworkSuite) TestGenerateNetworkConfig(c *gc.C) {
 			"lxc.network.ipv4 = 0.1.2.3/32",
 		},
 		logContains: `WARNING juju.container.lxc not setting IPv4 gateway "0.1.2.1" for non-auto start interface "eth1"`,
+	}, {
+		config: container.BridgeNetworkConfig("foo", []network.InterfaceInfo{staticNIC, otherBridgeNIC}),
+		nics:   []network.InterfaceInfo{staticNIC, otherBridgeNIC},
+		rendered: []string{
+			"lxc.network.type = veth",
+			"lxc.network.link = foo",
+			"lxc.network.flags = up",
+			"lxc.network.name = eth1",
+			"lxc.network.hwaddr = aa:bb:cc:dd:ee:f1",
+			"lxc.network.ipv4 = 0.1.2.3/32",
+			"lxc.network.ipv4.gateway = 0.1.2.1",
+
+			"lxc.network.type = veth",
+			"lxc.network.link = br-eth1",
+			"lxc.network.flags = up",
+			"lxc.network.name = eth3",
+			"lxc.network.hwaddr = aa:bb:cc:dd:ee:f3",
+			"lxc.network.ipv4 = 0.2.3.4/32",
+		},
+		logContains: `INFO juju.container.lxc not setting IPv4 gateway "0.1.2.1" for interface "eth3": already set for another interface`,
 	}} {
 		restorer := gitjujutesting.PatchValue(lxc.DiscoverHostNIC, func() (net.Interface, error) {
 			return net.Interface{
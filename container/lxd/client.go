@@ -0,0 +1,291 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package lxd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// apiVersion is the version of the daemon's REST API used by Client.
+const apiVersion = "1.0"
+
+// Response types returned by the daemon.
+const (
+	responseSync  = "sync"
+	responseAsync = "async"
+	responseError = "error"
+)
+
+// requestTimeout bounds each request made to the daemon. It is long
+// because waiting for an operation is a single request, and creating
+// a container may first have to download its image.
+const requestTimeout = 30 * time.Minute
+
+// operationSuccess is the status of a
+// successfully completed operation.
+const operationSuccess = "Success"
+
+// Container statuses reported by the daemon.
+const (
+	StatusRunning = "Running"
+	StatusStopped = "Stopped"
+)
+
+// response holds a response from the daemon's REST API.
+type response struct {
+	Type       string          `json:"type"`
+	Status     string          `json:"status"`
+	StatusCode int             `json:"status_code"`
+	Operation  string          `json:"operation"`
+	ErrorCode  int             `json:"error_code"`
+	Error      string          `json:"error"`
+	Metadata   json.RawMessage `json:"metadata"`
+}
+
+// operation holds the metadata of an asynchronous operation.
+type operation struct {
+	Id         string `json:"id"`
+	Status     string `json:"status"`
+	StatusCode int    `json:"status_code"`
+	Err        string `json:"err"`
+}
+
+// ContainerSource describes the image a container is created from.
+type ContainerSource struct {
+	Type     string `json:"type"`
+	Mode     string `json:"mode,omitempty"`
+	Server   string `json:"server,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+	Alias    string `json:"alias,omitempty"`
+}
+
+// ContainerSpec holds the parameters used to create a container.
+type ContainerSpec struct {
+	Name    string                       `json:"name"`
+	Source  ContainerSource              `json:"source"`
+	Config  map[string]string            `json:"config,omitempty"`
+	Devices map[string]map[string]string `json:"devices,omitempty"`
+}
+
+// ContainerState holds the runtime state of a container.
+type ContainerState struct {
+	Status     string                      `json:"status"`
+	StatusCode int                         `json:"status_code"`
+	Network    map[string]ContainerNetwork `json:"network"`
+}
+
+// ContainerNetwork holds the state of one of a container's
+// network interfaces.
+type ContainerNetwork struct {
+	Addresses []ContainerAddress `json:"addresses"`
+}
+
+// ContainerAddress holds an address of a container's network interface.
+type ContainerAddress struct {
+	Family  string `json:"family"`
+	Address string `json:"address"`
+	Scope   string `json:"scope"`
+}
+
+// Container holds the configuration of a container.
+type Container struct {
+	Name    string                       `json:"name"`
+	Config  map[string]string            `json:"config"`
+	Devices map[string]map[string]string `json:"devices"`
+}
+
+// Client talks to a system container daemon through its REST API,
+// usually served on a unix socket.
+type Client struct {
+	http      *http.Client
+	transport *http.Transport
+}
+
+// NewClient returns a new Client talking to the daemon
+// listening on the given unix socket.
+func NewClient(socketPath string) *Client {
+	return NewClientWithDialer(func() (net.Conn, error) {
+		return net.Dial("unix", socketPath)
+	})
+}
+
+// NewClientWithDialer returns a new Client talking to the daemon
+// through connections made by the given function, which allows
+// the daemon on another machine to be reached through a relay.
+func NewClientWithDialer(dial func() (net.Conn, error)) *Client {
+	transport := &http.Transport{
+		Dial: func(_, _ string) (net.Conn, error) {
+			return dial()
+		},
+	}
+	return &Client{
+		http: &http.Client{
+			Transport: transport,
+			Timeout:   requestTimeout,
+		},
+		transport: transport,
+	}
+}
+
+// Close closes the client's idle connections to the daemon.
+func (c *Client) Close() {
+	c.transport.CloseIdleConnections()
+}
+
+// ContainerNames returns the names of all the containers
+// known to the daemon.
+func (c *Client) ContainerNames() ([]string, error) {
+	var urls []string
+	if err := c.query("GET", "containers", nil, &urls); err != nil {
+		return nil, errors.Trace(err)
+	}
+	names := make([]string, len(urls))
+	for i, u := range urls {
+		names[i] = path.Base(u)
+	}
+	return names, nil
+}
+
+// CreateContainer creates, but does not start, a container.
+func (c *Client) CreateContainer(spec ContainerSpec) error {
+	return errors.Trace(c.wait(c.query("POST", "containers", spec, nil)))
+}
+
+// Container returns the configuration of the named container.
+// An error satisfying errors.IsNotFound is returned if the container
+// does not exist.
+func (c *Client) Container(name string) (*Container, error) {
+	var ctr Container
+	if err := c.query("GET", containerPath(name), nil, &ctr); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &ctr, nil
+}
+
+// ContainerState returns the runtime state of the named container.
+// An error satisfying errors.IsNotFound is returned if the container
+// does not exist.
+func (c *Client) ContainerState(name string) (*ContainerState, error) {
+	var state ContainerState
+	if err := c.query("GET", containerPath(name, "state"), nil, &state); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &state, nil
+}
+
+// StartContainer starts the named container.
+func (c *Client) StartContainer(name string) error {
+	return errors.Trace(c.setState(name, "start"))
+}
+
+// StopContainer stops the named container, killing it
+// if it does not shut down cleanly.
+func (c *Client) StopContainer(name string) error {
+	return errors.Trace(c.setState(name, "stop"))
+}
+
+// DeleteContainer deletes the named container,
+// which must not be running.
+func (c *Client) DeleteContainer(name string) error {
+	return errors.Trace(c.wait(c.query("DELETE", containerPath(name), nil, nil)))
+}
+
+func (c *Client) setState(name, action string) error {
+	req := map[string]interface{}{
+		"action":  action,
+		"timeout": 30,
+		"force":   true,
+	}
+	return c.wait(c.query("PUT", containerPath(name, "state"), req, nil))
+}
+
+// asyncError is returned by query when the daemon has started an
+// asynchronous operation, so that wait can find the operation.
+type asyncError struct {
+	operation string
+}
+
+func (e *asyncError) Error() string {
+	return fmt.Sprintf("operation %s in progress", e.operation)
+}
+
+// wait waits for the asynchronous operation started by the query that
+// returned the given error, if any, and returns the operation's error.
+func (c *Client) wait(err error) error {
+	async, ok := err.(*asyncError)
+	if !ok {
+		return err
+	}
+	var op operation
+	if err := c.query("GET", path.Join(async.operation, "wait"), nil, &op); err != nil {
+		return errors.Annotate(err, "cannot wait for operation")
+	}
+	if op.Status != operationSuccess {
+		return errors.New(op.Err)
+	}
+	return nil
+}
+
+// query makes a request to the daemon, unmarshaling the metadata of a
+// synchronous response into result, if it is not nil. If the daemon
+// starts an asynchronous operation, an *asyncError is returned.
+func (c *Client) query(method, p string, body, result interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	if !path.IsAbs(p) {
+		p = "/" + path.Join(apiVersion, p)
+	}
+	u := url.URL{Scheme: "http", Host: "lxd", Path: p}
+	req, err := http.NewRequest(method, u.String(), reqBody)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return errors.Annotate(err, "cannot connect to daemon")
+	}
+	defer resp.Body.Close()
+	var r response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return errors.Annotatef(err, "cannot decode %s %s response", method, p)
+	}
+	switch r.Type {
+	case responseError:
+		if r.ErrorCode == http.StatusNotFound {
+			return errors.NewNotFound(nil, r.Error)
+		}
+		return errors.New(r.Error)
+	case responseAsync:
+		return &asyncError{operation: r.Operation}
+	case responseSync:
+		if result == nil || len(r.Metadata) == 0 {
+			return nil
+		}
+		return errors.Trace(json.Unmarshal(r.Metadata, result))
+	}
+	return errors.Errorf("unexpected response type %q", r.Type)
+}
+
+func containerPath(name string, elems ...string) string {
+	return path.Join(append([]string{"containers", name}, elems...)...)
+}
@@ -0,0 +1,231 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package lxd implements a container.Manager for system containers
+// run by an image-based container daemon, which is driven through
+// its REST API on a unix socket rather than with command line tools.
+package lxd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+	"github.com/juju/names"
+
+	"github.com/juju/juju/agent"
+	"github.com/juju/juju/container"
+	"github.com/juju/juju/environs/cloudinit"
+	"github.com/juju/juju/environs/imagemetadata"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/version"
+)
+
+const (
+	// ConfigSocketPath is the manager config key holding the
+	// path of the unix socket the daemon listens on.
+	ConfigSocketPath = "socket-path"
+
+	// DefaultSocketPath is the path of the daemon's
+	// unix socket when it is installed from packages.
+	DefaultSocketPath = "/var/lib/lxd/unix.socket"
+
+	// userDataKey is the container config key holding
+	// the cloud-init user-data read by the image.
+	userDataKey = "user.user-data"
+)
+
+var (
+	logger = loggo.GetLogger("juju.container.lxd")
+
+	// DefaultLxdBridge is the bridge used for container
+	// networking when none is configured.
+	DefaultLxdBridge = "lxcbr0"
+
+	runtimeGOOS = runtime.GOOS
+)
+
+// IsLXDSupported reports whether system containers can be run on this
+// machine, which requires Linux and an installed container daemon.
+// It is a variable to allow us to override behaviour in the tests.
+var IsLXDSupported = func() (bool, error) {
+	if runtimeGOOS != "linux" {
+		return false, nil
+	}
+	if _, err := exec.LookPath("lxd"); err != nil {
+		return false, nil
+	}
+	return true, nil
+}
+
+// NewContainerManager returns a manager object that can start and stop
+// system containers. The containers that are created are namespaced by
+// the name parameter.
+func NewContainerManager(conf container.ManagerConfig) (container.Manager, error) {
+	name := conf.PopValue(container.ConfigName)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	logDir := conf.PopValue(container.ConfigLogDir)
+	if logDir == "" {
+		logDir = agent.DefaultLogDir
+	}
+	socketPath := conf.PopValue(ConfigSocketPath)
+	if socketPath == "" {
+		socketPath = DefaultSocketPath
+	}
+	conf.WarnAboutUnused()
+	return &containerManager{
+		name:       name,
+		logdir:     logDir,
+		socketPath: socketPath,
+		client:     NewClient(socketPath),
+	}, nil
+}
+
+// containerManager creates containers by asking the daemon to
+// create them from cloud images, passing the cloud-init user-data
+// in the containers' config.
+type containerManager struct {
+	name       string
+	logdir     string
+	socketPath string
+	client     *Client
+}
+
+var _ container.Manager = (*containerManager)(nil)
+
+// CreateContainer is specified in the container.Manager interface.
+func (manager *containerManager) CreateContainer(
+	machineConfig *cloudinit.MachineConfig,
+	series string,
+	networkConfig *container.NetworkConfig,
+	storageConfig *container.StorageConfig,
+) (instance.Instance, *instance.HardwareCharacteristics, error) {
+
+	name := names.NewMachineTag(machineConfig.MachineId).String()
+	if manager.name != "" {
+		name = fmt.Sprintf("%s-%s", manager.name, name)
+	}
+
+	// Keep a copy of the cloud-init user-data on the host,
+	// as for other container types.
+	directory, err := container.NewDirectory(name)
+	if err != nil {
+		return nil, nil, errors.Annotate(err, "failed to create container directory")
+	}
+	userData, err := container.CloudInitUserData(machineConfig, networkConfig)
+	if err != nil {
+		return nil, nil, errors.Annotate(err, "failed to create user data")
+	}
+	if _, err := container.WriteCloudInitFile(directory, userData); err != nil {
+		return nil, nil, errors.Annotate(err, "failed to write user data")
+	}
+
+	spec := ContainerSpec{
+		Name:   name,
+		Source: ImageSource(series, machineConfig.ImageStream),
+		Config: map[string]string{
+			userDataKey: string(userData),
+		},
+		Devices: networkDevices(networkConfig),
+	}
+	logger.Tracef("create the container, constraints: %v", machineConfig.Constraints)
+	if err := manager.client.CreateContainer(spec); err != nil {
+		return nil, nil, errors.Annotate(err, "lxd container creation failed")
+	}
+	if err := manager.client.StartContainer(name); err != nil {
+		return nil, nil, errors.Annotate(err, "lxd container start failed")
+	}
+	logger.Tracef("lxd container created")
+
+	arch := version.Current.Arch
+	hardware := &instance.HardwareCharacteristics{
+		Arch: &arch,
+	}
+	return &lxdInstance{manager.client, name}, hardware, nil
+}
+
+// ImageSource returns the source of the image that containers of the
+// given series are created from, which is pulled from the cloud images
+// simplestreams server for the given image stream.
+func ImageSource(series, stream string) ContainerSource {
+	if stream == "" || stream == imagemetadata.ReleasedStream {
+		stream = "releases"
+	}
+	return ContainerSource{
+		Type:     "image",
+		Mode:     "pull",
+		Server:   imagemetadata.UbuntuCloudImagesURL + "/" + stream,
+		Protocol: "simplestreams",
+		Alias:    series,
+	}
+}
+
+// networkDevices returns the devices giving a container
+// the network described by the given config.
+func networkDevices(networkConfig *container.NetworkConfig) map[string]map[string]string {
+	nicType := "bridged"
+	device := DefaultLxdBridge
+	if networkConfig != nil {
+		if networkConfig.NetworkType == container.PhysicalNetwork {
+			nicType = "physical"
+		}
+		if networkConfig.Device != "" {
+			device = networkConfig.Device
+		}
+	}
+	return map[string]map[string]string{
+		"eth0": {
+			"type":    "nic",
+			"nictype": nicType,
+			"parent":  device,
+		},
+	}
+}
+
+// IsInitialized is specified in the container.Manager interface.
+func (manager *containerManager) IsInitialized() bool {
+	_, err := os.Stat(manager.socketPath)
+	return err == nil
+}
+
+// DestroyContainer is specified in the container.Manager interface.
+func (manager *containerManager) DestroyContainer(id instance.Id) error {
+	name := string(id)
+	state, err := manager.client.ContainerState(name)
+	if err != nil {
+		return errors.Annotatef(err, "cannot get state of lxd container %q", name)
+	}
+	if state.Status != StatusStopped {
+		if err := manager.client.StopContainer(name); err != nil {
+			return errors.Annotatef(err, "failed to stop lxd container %q", name)
+		}
+	}
+	if err := manager.client.DeleteContainer(name); err != nil {
+		return errors.Annotatef(err, "failed to delete lxd container %q", name)
+	}
+	return container.RemoveDirectory(name)
+}
+
+// ListContainers is specified in the container.Manager interface.
+func (manager *containerManager) ListContainers() ([]instance.Instance, error) {
+	containerNames, err := manager.client.ContainerNames()
+	if err != nil {
+		return nil, errors.Annotate(err, "failed getting all instances")
+	}
+	var result []instance.Instance
+	managerPrefix := fmt.Sprintf("%s-", manager.name)
+	for _, name := range containerNames {
+		// Filter out those not starting with our name.
+		if !strings.HasPrefix(name, managerPrefix) {
+			continue
+		}
+		result = append(result, &lxdInstance{manager.client, name})
+	}
+	return result, nil
+}
@@ -0,0 +1,185 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package lxd_test
+
+import (
+	"net"
+	"path/filepath"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/container"
+	"github.com/juju/juju/container/lxd"
+	lxdtesting "github.com/juju/juju/container/lxd/testing"
+	containertesting "github.com/juju/juju/container/testing"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/network"
+)
+
+type LXDSuite struct {
+	lxdtesting.TestSuite
+	manager container.Manager
+}
+
+var _ = gc.Suite(&LXDSuite{})
+
+func (s *LXDSuite) SetUpTest(c *gc.C) {
+	s.TestSuite.SetUpTest(c)
+	var err error
+	s.manager, err = lxd.NewContainerManager(s.ManagerConfig("test"))
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (*LXDSuite) TestManagerNameNeeded(c *gc.C) {
+	manager, err := lxd.NewContainerManager(container.ManagerConfig{container.ConfigName: ""})
+	c.Assert(err, gc.ErrorMatches, "name is required")
+	c.Assert(manager, gc.IsNil)
+}
+
+func (*LXDSuite) TestManagerWarnsAboutUnknownOption(c *gc.C) {
+	_, err := lxd.NewContainerManager(container.ManagerConfig{
+		container.ConfigName: "BillyBatson",
+		"shazam":             "Captain Marvel",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(c.GetTestLog(), jc.Contains, `WARNING juju.container unused config option: "shazam" -> "Captain Marvel"`)
+}
+
+func (s *LXDSuite) TestIsInitialized(c *gc.C) {
+	c.Assert(s.manager.IsInitialized(), jc.IsTrue)
+	manager, err := lxd.NewContainerManager(container.ManagerConfig{
+		container.ConfigName: "test",
+		lxd.ConfigSocketPath: filepath.Join(c.MkDir(), "missing.socket"),
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(manager.IsInitialized(), jc.IsFalse)
+}
+
+func (s *LXDSuite) TestListInitiallyEmpty(c *gc.C) {
+	containers, err := s.manager.ListContainers()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(containers, gc.HasLen, 0)
+}
+
+func (s *LXDSuite) TestCreateContainer(c *gc.C) {
+	inst := containertesting.CreateContainer(c, s.manager, "1/lxd/0")
+	name := string(inst.Id())
+	c.Assert(name, gc.Equals, "test-machine-1-lxd-0")
+	cloudInitFilename := filepath.Join(s.ContainerDir, name, "cloud-init")
+	userData := containertesting.AssertCloudInit(c, cloudInitFilename)
+
+	ctr, ok := s.Daemon.Container(name)
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(ctr.Status, gc.Equals, lxd.StatusRunning)
+	c.Assert(ctr.Spec.Config["user.user-data"], gc.Equals, string(userData))
+	c.Assert(ctr.Spec.Source, jc.DeepEquals, lxd.ContainerSource{
+		Type:     "image",
+		Mode:     "pull",
+		Server:   "http://cloud-images.ubuntu.com/releases",
+		Protocol: "simplestreams",
+		Alias:    "quantal",
+	})
+	c.Assert(ctr.Spec.Devices, jc.DeepEquals, map[string]map[string]string{
+		"eth0": {
+			"type":    "nic",
+			"nictype": "bridged",
+			"parent":  "nic42",
+		},
+	})
+	c.Assert(inst.Status(), gc.Equals, "running")
+}
+
+func (s *LXDSuite) TestCreateContainerExists(c *gc.C) {
+	containertesting.CreateContainer(c, s.manager, "1/lxd/0")
+	_, err := containertesting.CreateContainerTest(c, s.manager, "1/lxd/0")
+	c.Assert(err, gc.ErrorMatches, `lxd container creation failed: container "test-machine-1-lxd-0" already exists`)
+}
+
+func (s *LXDSuite) TestAddresses(c *gc.C) {
+	inst := containertesting.CreateContainer(c, s.manager, "1/lxd/0")
+	addresses, err := inst.Addresses()
+	c.Assert(err, jc.ErrorIsNil)
+	ctr, _ := s.Daemon.Container(string(inst.Id()))
+	c.Assert(addresses, jc.DeepEquals, []network.Address{network.NewAddress(ctr.Address)})
+}
+
+func (s *LXDSuite) TestListMatchesManagerName(c *gc.C) {
+	inst0 := containertesting.CreateContainer(c, s.manager, "1/lxd/0")
+	inst1 := containertesting.CreateContainer(c, s.manager, "1/lxd/1")
+	other, err := lxd.NewContainerManager(s.ManagerConfig("other"))
+	c.Assert(err, jc.ErrorIsNil)
+	containertesting.CreateContainer(c, other, "1/lxd/2")
+
+	containers, err := s.manager.ListContainers()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(containers, gc.HasLen, 2)
+	ids := []instance.Id{containers[0].Id(), containers[1].Id()}
+	c.Assert(ids, jc.SameContents, []instance.Id{inst0.Id(), inst1.Id()})
+}
+
+func (s *LXDSuite) TestDestroyContainer(c *gc.C) {
+	inst := containertesting.CreateContainer(c, s.manager, "1/lxd/0")
+
+	err := s.manager.DestroyContainer(inst.Id())
+	c.Assert(err, jc.ErrorIsNil)
+
+	name := string(inst.Id())
+	_, ok := s.Daemon.Container(name)
+	c.Assert(ok, jc.IsFalse)
+	// Check that the container dir is no longer in the container dir
+	c.Assert(filepath.Join(s.ContainerDir, name), jc.DoesNotExist)
+	// but instead, in the removed container dir
+	c.Assert(filepath.Join(s.RemovedDir, name), jc.IsDirectory)
+}
+
+func (s *LXDSuite) TestDestroyStoppedContainer(c *gc.C) {
+	inst := containertesting.CreateContainer(c, s.manager, "1/lxd/0")
+	s.Daemon.SetStatus(string(inst.Id()), lxd.StatusStopped)
+	c.Assert(inst.Status(), gc.Equals, "stopped")
+
+	err := s.manager.DestroyContainer(inst.Id())
+	c.Assert(err, jc.ErrorIsNil)
+	_, ok := s.Daemon.Container(string(inst.Id()))
+	c.Assert(ok, jc.IsFalse)
+}
+
+func (s *LXDSuite) TestDestroyMissingContainer(c *gc.C) {
+	err := s.manager.DestroyContainer("test-machine-1-lxd-9")
+	c.Assert(err, gc.ErrorMatches, `cannot get state of lxd container "test-machine-1-lxd-9": not found`)
+}
+
+func (s *LXDSuite) TestIsLXDSupportedNonLinuxSystem(c *gc.C) {
+	s.PatchValue(lxd.RuntimeGOOS, "windows")
+	supported, err := lxd.IsLXDSupported()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(supported, jc.IsFalse)
+}
+
+func (s *LXDSuite) TestIsLXDSupportedNotInstalled(c *gc.C) {
+	s.PatchEnvironment("PATH", c.MkDir())
+	supported, err := lxd.IsLXDSupported()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(supported, jc.IsFalse)
+}
+
+func (s *LXDSuite) TestClientContainer(c *gc.C) {
+	inst := containertesting.CreateContainer(c, s.manager, "1/lxd/0")
+	dials := 0
+	client := lxd.NewClientWithDialer(func() (net.Conn, error) {
+		dials++
+		return net.Dial("unix", s.Daemon.SocketPath)
+	})
+	defer client.Close()
+
+	ctr, err := client.Container(string(inst.Id()))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ctr.Name, gc.Equals, "test-machine-1-lxd-0")
+	c.Assert(ctr.Config["user.user-data"], gc.Not(gc.Equals), "")
+	c.Assert(dials, gc.Equals, 1)
+
+	_, err = client.Container("test-machine-2")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
@@ -0,0 +1,272 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package testing
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/container/lxd"
+)
+
+// FakeContainer records a container created in a FakeDaemon.
+type FakeContainer struct {
+	Spec    lxd.ContainerSpec
+	Status  string
+	Address string
+}
+
+// FakeDaemon serves the parts of the container daemon's REST API used
+// by lxd.Client on a unix socket, keeping its containers in memory.
+// Asynchronous operations complete before their responses are sent.
+type FakeDaemon struct {
+	SocketPath string
+
+	listener net.Listener
+
+	mu         sync.Mutex
+	containers map[string]*FakeContainer
+	operations map[string]string
+	nextId     int
+}
+
+// NewFakeDaemon returns a new FakeDaemon listening on the given
+// socket path. It must be closed after use.
+func NewFakeDaemon(socketPath string) (*FakeDaemon, error) {
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	d := &FakeDaemon{
+		SocketPath: socketPath,
+		listener:   listener,
+		containers: make(map[string]*FakeContainer),
+		operations: make(map[string]string),
+	}
+	go http.Serve(listener, d)
+	return d, nil
+}
+
+// Close stops the daemon listening.
+func (d *FakeDaemon) Close() error {
+	return d.listener.Close()
+}
+
+// Container returns the named container, and
+// whether the container exists.
+func (d *FakeDaemon) Container(name string) (FakeContainer, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ctr, ok := d.containers[name]
+	if !ok {
+		return FakeContainer{}, false
+	}
+	return *ctr, true
+}
+
+// AddContainer adds a container created from the given spec, with
+// the given status and, if it is running, address.
+func (d *FakeDaemon) AddContainer(spec lxd.ContainerSpec, status, address string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.containers[spec.Name] = &FakeContainer{
+		Spec:    spec,
+		Status:  status,
+		Address: address,
+	}
+}
+
+// SetStatus sets the status of the named container.
+func (d *FakeDaemon) SetStatus(name, status string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.containers[name].Status = status
+}
+
+// ServeHTTP implements http.Handler.
+func (d *FakeDaemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "1.0" {
+		sendError(w, http.StatusNotFound, "not found")
+		return
+	}
+	switch {
+	case parts[1] == "containers" && len(parts) == 2:
+		d.serveContainers(w, r)
+	case parts[1] == "containers" && len(parts) == 3 && r.Method == "GET":
+		d.getContainer(w, parts[2])
+	case parts[1] == "containers" && len(parts) == 3 && r.Method == "DELETE":
+		d.deleteContainer(w, parts[2])
+	case parts[1] == "containers" && len(parts) == 4 && parts[3] == "state":
+		d.serveState(w, r, parts[2])
+	case parts[1] == "operations" && len(parts) == 4 && parts[3] == "wait":
+		d.waitOperation(w, parts[2])
+	default:
+		sendError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (d *FakeDaemon) serveContainers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		urls := []string{}
+		for name := range d.containers {
+			urls = append(urls, "/1.0/containers/"+name)
+		}
+		sendSync(w, urls)
+	case "POST":
+		var spec lxd.ContainerSpec
+		if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+			sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if _, ok := d.containers[spec.Name]; ok {
+			d.sendOperation(w, fmt.Sprintf("container %q already exists", spec.Name))
+			return
+		}
+		d.containers[spec.Name] = &FakeContainer{
+			Spec:   spec,
+			Status: lxd.StatusStopped,
+		}
+		d.sendOperation(w, "")
+	default:
+		sendError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (d *FakeDaemon) getContainer(w http.ResponseWriter, name string) {
+	ctr, ok := d.containers[name]
+	if !ok {
+		sendError(w, http.StatusNotFound, "not found")
+		return
+	}
+	sendSync(w, lxd.Container{
+		Name:    ctr.Spec.Name,
+		Config:  ctr.Spec.Config,
+		Devices: ctr.Spec.Devices,
+	})
+}
+
+func (d *FakeDaemon) deleteContainer(w http.ResponseWriter, name string) {
+	ctr, ok := d.containers[name]
+	if !ok {
+		sendError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if ctr.Status != lxd.StatusStopped {
+		d.sendOperation(w, "container is running")
+		return
+	}
+	delete(d.containers, name)
+	d.sendOperation(w, "")
+}
+
+func (d *FakeDaemon) serveState(w http.ResponseWriter, r *http.Request, name string) {
+	ctr, ok := d.containers[name]
+	if !ok {
+		sendError(w, http.StatusNotFound, "not found")
+		return
+	}
+	switch r.Method {
+	case "GET":
+		state := lxd.ContainerState{
+			Status:  ctr.Status,
+			Network: make(map[string]lxd.ContainerNetwork),
+		}
+		if ctr.Status == lxd.StatusRunning {
+			state.Network["eth0"] = lxd.ContainerNetwork{
+				Addresses: []lxd.ContainerAddress{{
+					Family:  "inet",
+					Address: ctr.Address,
+					Scope:   "global",
+				}},
+			}
+		}
+		sendSync(w, state)
+	case "PUT":
+		var req struct {
+			Action string `json:"action"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		switch req.Action {
+		case "start":
+			ctr.Status = lxd.StatusRunning
+			d.nextId++
+			ctr.Address = fmt.Sprintf("10.0.3.%d", d.nextId)
+		case "stop":
+			ctr.Status = lxd.StatusStopped
+		default:
+			sendError(w, http.StatusBadRequest, "unknown action")
+			return
+		}
+		d.sendOperation(w, "")
+	default:
+		sendError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (d *FakeDaemon) waitOperation(w http.ResponseWriter, id string) {
+	opErr, ok := d.operations[id]
+	if !ok {
+		sendError(w, http.StatusNotFound, "not found")
+		return
+	}
+	status := "Success"
+	if opErr != "" {
+		status = "Failure"
+	}
+	sendSync(w, map[string]interface{}{
+		"id":     id,
+		"status": status,
+		"err":    opErr,
+	})
+}
+
+// sendOperation records a completed operation that
+// failed with the given error, if not empty.
+func (d *FakeDaemon) sendOperation(w http.ResponseWriter, opErr string) {
+	d.nextId++
+	id := fmt.Sprint(d.nextId)
+	d.operations[id] = opErr
+	send(w, http.StatusAccepted, map[string]interface{}{
+		"type":        "async",
+		"status":      "OK",
+		"status_code": 100,
+		"operation":   "/1.0/operations/" + id,
+	})
+}
+
+func sendSync(w http.ResponseWriter, metadata interface{}) {
+	send(w, http.StatusOK, map[string]interface{}{
+		"type":        "sync",
+		"status":      "Success",
+		"status_code": 200,
+		"metadata":    metadata,
+	})
+}
+
+func sendError(w http.ResponseWriter, code int, message string) {
+	send(w, code, map[string]interface{}{
+		"type":       "error",
+		"error_code": code,
+		"error":      message,
+	})
+}
+
+func send(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,56 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Functions defined in this file should *ONLY* be used for testing.  These
+// functions are exported for testing purposes only, and shouldn't be called
+// from code that isn't in a test file.
+
+package testing
+
+import (
+	"path/filepath"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/container"
+	"github.com/juju/juju/container/lxd"
+	"github.com/juju/juju/testing"
+)
+
+// TestSuite runs a fake container daemon for each test, and
+// redirects the container directories to temporary ones.
+type TestSuite struct {
+	testing.BaseSuite
+	Daemon       *FakeDaemon
+	ContainerDir string
+	RemovedDir   string
+}
+
+func (s *TestSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.ContainerDir = c.MkDir()
+	s.PatchValue(&container.ContainerDir, s.ContainerDir)
+	s.RemovedDir = c.MkDir()
+	s.PatchValue(&container.RemovedContainerDir, s.RemovedDir)
+	daemon, err := NewFakeDaemon(filepath.Join(c.MkDir(), "unix.socket"))
+	c.Assert(err, jc.ErrorIsNil)
+	s.Daemon = daemon
+}
+
+func (s *TestSuite) TearDownTest(c *gc.C) {
+	if s.Daemon != nil {
+		s.Daemon.Close()
+		s.Daemon = nil
+	}
+	s.BaseSuite.TearDownTest(c)
+}
+
+// ManagerConfig returns a manager config for the given
+// namespace that uses the fake daemon.
+func (s *TestSuite) ManagerConfig(name string) container.ManagerConfig {
+	return container.ManagerConfig{
+		container.ConfigName: name,
+		lxd.ConfigSocketPath: s.Daemon.SocketPath,
+	}
+}
@@ -14,6 +14,7 @@ import (
 
 	coreCloudinit "github.com/juju/juju/cloudinit"
 	"github.com/juju/juju/environs/cloudinit"
+	"github.com/juju/juju/network"
 )
 
 var (
@@ -29,7 +30,7 @@ func WriteUserData(
 	networkConfig *NetworkConfig,
 	directory string,
 ) (string, error) {
-	userData, err := cloudInitUserData(machineConfig, networkConfig)
+	userData, err := CloudInitUserData(machineConfig, networkConfig)
 	if err != nil {
 		logger.Errorf("failed to create user data: %v", err)
 		return "", err
@@ -49,7 +50,9 @@ func WriteCloudInitFile(directory string, userData []byte) (string, error) {
 }
 
 // networkConfigTemplate defines how to render /etc/network/interfaces
-// file for a container with one or more NICs.
+// file for a container with one or more NICs. Only the interface with
+// DefaultRoute set gets routes via its gateway, as a container has a
+// single default route.
 const networkConfigTemplate = `
 # loopback interface
 auto lo
@@ -58,11 +61,11 @@ iface lo inet loopback{{define "static"}}
 auto {{.InterfaceName}}{{end}}
 iface {{.InterfaceName}} inet manual{{if gt (len .DNSServers) 0}}
     dns-nameservers{{range $dns := .DNSServers}} {{$dns.Value}}{{end}}{{end}}
-    pre-up ip address add {{.Address.Value}}/32 dev {{.InterfaceName}} &> /dev/null || true
+    pre-up ip address add {{.Address.Value}}/32 dev {{.InterfaceName}} &> /dev/null || true{{if .DefaultRoute}}
     up ip route replace {{.GatewayAddress.Value}} dev {{.InterfaceName}}
     up ip route replace default via {{.GatewayAddress.Value}}
     down ip route del default via {{.GatewayAddress.Value}} &> /dev/null || true
-    down ip route del {{.GatewayAddress.Value}} dev {{.InterfaceName}} &> /dev/null || true
+    down ip route del {{.GatewayAddress.Value}} dev {{.InterfaceName}} &> /dev/null || true{{end}}
     post-down ip address del {{.Address.Value}}/32 dev {{.InterfaceName}} &> /dev/null || true
 {{end}}{{define "dhcp"}}
 {{.InterfaceName | printf "# interface %q"}}{{if not .NoAutoStart}}
@@ -73,6 +76,35 @@ iface {{.InterfaceName}} inet dhcp
 
 var networkInterfacesFile = "/etc/network/interfaces"
 
+// interfaceConfig holds the data used to render
+// the configuration of a network interface.
+type interfaceConfig struct {
+	network.InterfaceInfo
+
+	// DefaultRoute is true when the container's
+	// default route goes via the interface's gateway.
+	DefaultRoute bool
+}
+
+// interfaceConfigs returns the data to render for the given
+// interfaces, routing the default route via the first statically
+// configured, auto-started interface with a gateway.
+func interfaceConfigs(interfaces []network.InterfaceInfo) []interfaceConfig {
+	configs := make([]interfaceConfig, len(interfaces))
+	haveDefaultRoute := false
+	for i, iface := range interfaces {
+		configs[i].InterfaceInfo = iface
+		if haveDefaultRoute || iface.ConfigType != network.ConfigStatic || iface.NoAutoStart {
+			continue
+		}
+		if iface.GatewayAddress.Value != "" {
+			configs[i].DefaultRoute = true
+			haveDefaultRoute = true
+		}
+	}
+	return configs
+}
+
 // GenerateNetworkConfig renders a network config for one or more
 // network interfaces, using the given non-nil networkConfig
 // containing a non-empty Interfaces field.
@@ -90,7 +122,7 @@ func GenerateNetworkConfig(networkConfig *NetworkConfig) (string, error) {
 	}
 
 	var buf bytes.Buffer
-	if err = tmpl.Execute(&buf, networkConfig.Interfaces); err != nil {
+	if err = tmpl.Execute(&buf, interfaceConfigs(networkConfig.Interfaces)); err != nil {
 		return "", errors.Annotate(err, "cannot render network config")
 	}
 
@@ -115,7 +147,10 @@ func NewCloudInitConfigWithNetworks(series string, networkConfig *NetworkConfig)
 	return cloudConfig, nil
 }
 
-func cloudInitUserData(
+// CloudInitUserData generates the cloud-init user-data using the
+// specified machine and network config for a container, and returns
+// its serialized form.
+func CloudInitUserData(
 	machineConfig *cloudinit.MachineConfig,
 	networkConfig *NetworkConfig,
 ) ([]byte, error) {
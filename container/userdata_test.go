@@ -83,6 +83,42 @@ func (s *UserDataSuite) TestGenerateNetworkConfig(c *gc.C) {
 	c.Assert(data, gc.Equals, s.expectedNetConfig)
 }
 
+func (s *UserDataSuite) TestGenerateNetworkConfigMultipleStaticNICs(c *gc.C) {
+	// Only the first interface gets the default route.
+	interfaces := []network.InterfaceInfo{s.fakeInterfaces[0], {
+		InterfaceName:  "eth1",
+		CIDR:           "0.2.3.0/24",
+		ConfigType:     network.ConfigStatic,
+		Address:        network.NewAddress("0.2.3.4"),
+		GatewayAddress: network.NewAddress("0.1.2.1"),
+	}}
+	netConfig := container.BridgeNetworkConfig("foo", interfaces)
+	data, err := container.GenerateNetworkConfig(netConfig)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(data, gc.Equals, `
+# loopback interface
+auto lo
+iface lo inet loopback
+
+# interface "eth0"
+auto eth0
+iface eth0 inet manual
+    dns-nameservers ns1.invalid ns2.invalid
+    pre-up ip address add 0.1.2.3/32 dev eth0 &> /dev/null || true
+    up ip route replace 0.1.2.1 dev eth0
+    up ip route replace default via 0.1.2.1
+    down ip route del default via 0.1.2.1 &> /dev/null || true
+    down ip route del 0.1.2.1 dev eth0 &> /dev/null || true
+    post-down ip address del 0.1.2.3/32 dev eth0 &> /dev/null || true
+
+# interface "eth1"
+auto eth1
+iface eth1 inet manual
+    pre-up ip address add 0.2.3.4/32 dev eth1 &> /dev/null || true
+    post-down ip address del 0.2.3.4/32 dev eth1 &> /dev/null || true
+`)
+}
+
 func (s *UserDataSuite) TestNewCloudInitConfigWithNetworks(c *gc.C) {
 	netConfig := container.BridgeNetworkConfig("foo", s.fakeInterfaces)
 	cloudConf, err := container.NewCloudInitConfigWithNetworks("quantal", netConfig)
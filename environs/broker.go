@@ -49,6 +49,18 @@ type StartInstanceParams struct {
 	// NetworkInfo is an optional list of network interface details,
 	// necessary to configure on the instance.
 	NetworkInfo []network.InterfaceInfo
+
+	// SubnetsToZones is an optional map of provider-specific subnet
+	// ids to the availability zones they are in. If set, the instance
+	// must be started in one of these subnets, as the machine has been
+	// constrained to the spaces containing them.
+	SubnetsToZones map[network.Id][]string
+
+	// MigratedFrom, if set, holds the instance id of the container
+	// from whose staged root filesystem a container migrated from
+	// another host should be created. Only container brokers that
+	// support container migration use it.
+	MigratedFrom instance.Id
 }
 
 // StartInstanceResult holds the result of an
@@ -128,7 +128,9 @@ type MachineConfig struct {
 	// Config holds the initial environment configuration.
 	Config *config.Config
 
-	// Constraints holds the initial environment constraints.
+	// Constraints holds the initial environment constraints for a
+	// state server node, and the machine's own constraints for a
+	// container, which are used to limit its resources.
 	Constraints constraints.Value
 
 	// DisableSSLHostnameVerification can be set to true to tell cloud-init
@@ -6,6 +6,7 @@ package config
 import (
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -22,6 +23,7 @@ import (
 
 	"github.com/juju/juju/cert"
 	"github.com/juju/juju/juju/osenv"
+	"github.com/juju/juju/network"
 	"github.com/juju/juju/version"
 )
 
@@ -73,6 +75,18 @@ const (
 	// Only use numactl if user specifically requests it
 	DefaultNumaControlPolicy = false
 
+	// DefaultHookConcurrency is the number of hooks that may run
+	// concurrently on a machine; by default hooks are serialized.
+	DefaultHookConcurrency int = 1
+
+	// DefaultDNSTTL is the time to live of published DNS records.
+	DefaultDNSTTL = 5 * time.Minute
+
+	// TraceLoggingModule is the logging module of the API server's
+	// log messages about requests with a trace id. It logs at INFO
+	// unless logging-config specifies otherwise.
+	TraceLoggingModule = "juju.apiserver.trace"
+
 	// DefaultPreventDestroyEnvironment should not be used by default.
 	// Only prevent destroy-environment from running
 	// if user specifically requests it. Otherwise, let it run.
@@ -155,6 +169,44 @@ const (
 	// allowed by the user.
 	AllowLXCLoopMounts = "allow-lxc-loop-mounts"
 
+	// HookConcurrencyKey stores the key for the number of hooks that
+	// may run concurrently on a single machine.
+	HookConcurrencyKey = "hook-concurrency"
+
+	// APIRequestRateKey stores the key for the number of API requests
+	// per second that each authenticated entity may make.
+	APIRequestRateKey = "api-request-rate"
+
+	// APIMaxConcurrentRequestsKey stores the key for the number of
+	// API requests that each authenticated entity may have in
+	// progress at once.
+	APIMaxConcurrentRequestsKey = "api-max-concurrent-requests"
+
+	// RESTReadOnlyKey stores the key for whether the API server's
+	// REST gateway only allows calls that do not change the state
+	// of the environment.
+	RESTReadOnlyKey = "rest-api-read-only"
+
+	// AddressPolicyKey stores the key for the address family policy
+	// used when choosing addresses of machines.
+	AddressPolicyKey = "address-policy"
+
+	// PreferredSpacesKey stores the key for the comma-separated list
+	// of spaces whose addresses are chosen in preference to others.
+	PreferredSpacesKey = "preferred-spaces"
+
+	// DNSServerKey stores the key for the address of the DNS server
+	// to which the names of units and services are published.
+	DNSServerKey = "dns-server"
+
+	// DNSZoneKey stores the key for the DNS zone under which the
+	// names of units and services are published.
+	DNSZoneKey = "dns-zone"
+
+	// DNSTTLKey stores the key for the time to live, in seconds, of
+	// published DNS records.
+	DNSTTLKey = "dns-ttl"
+
 	//
 	// Deprecated Settings Attributes
 	//
@@ -227,6 +279,19 @@ var ProxyAttributes = []string{
 	AptFtpProxyKey,
 }
 
+// StateServerAttributes holds the names of the attributes which
+// describe the state server hosting an environment. An environment
+// takes them from its state server's environment, both when it is
+// created and when it is migrated to another controller.
+var StateServerAttributes = []string{
+	"ca-cert",
+	"state-port",
+	"api-port",
+	"syslog-port",
+	"rsyslog-ca-cert",
+	"rsyslog-ca-key",
+}
+
 // String returns the description of the harvesting mode.
 func (method HarvestMode) String() string {
 	if description, ok := harvestingMethodToFlag[method]; ok {
@@ -385,6 +450,11 @@ func (c *Config) ensureUnitLogging() error {
 	if _, ok := levels["unit"]; !ok {
 		loggingConfig = loggingConfig + ";unit=DEBUG"
 	}
+	// API requests with a trace id are always logged, unless
+	// the level of their logger is set explicitly.
+	if _, ok := levels[TraceLoggingModule]; !ok {
+		loggingConfig = loggingConfig + ";" + TraceLoggingModule + "=INFO"
+	}
 	c.defined["logging-config"] = loggingConfig
 	return nil
 }
@@ -589,6 +659,42 @@ func Validate(cfg, old *Config) error {
 		}
 	}
 
+	if v, ok := cfg.defined[HookConcurrencyKey].(int); ok && v < 1 {
+		return fmt.Errorf("%s must be at least 1, got %d", HookConcurrencyKey, v)
+	}
+	for _, key := range []string{APIRequestRateKey, APIMaxConcurrentRequestsKey} {
+		if v, ok := cfg.defined[key].(int); ok && v < 0 {
+			return fmt.Errorf("%s must not be negative, got %d", key, v)
+		}
+	}
+
+	if v, ok := cfg.defined[AddressPolicyKey].(string); ok && v != "" {
+		family, err := network.ParseAddressFamilyPolicy(v)
+		if err != nil {
+			return errors.Annotatef(err, "invalid %s", AddressPolicyKey)
+		}
+		if preferIPv6, _ := cfg.defined["prefer-ipv6"].(bool); preferIPv6 && !family.PreferIPv6() {
+			return fmt.Errorf("%s %q conflicts with prefer-ipv6", AddressPolicyKey, family)
+		}
+	}
+	for _, name := range cfg.PreferredSpaces() {
+		if !network.IsValidSpaceName(name) {
+			return fmt.Errorf("invalid %s: %q is not a valid space name", PreferredSpacesKey, name)
+		}
+	}
+
+	if server, ok := cfg.defined[DNSServerKey].(string); ok && server != "" {
+		if zone, _ := cfg.defined[DNSZoneKey].(string); zone == "" {
+			return fmt.Errorf("%s requires %s to be set", DNSServerKey, DNSZoneKey)
+		}
+		if _, _, err := net.SplitHostPort(cfg.DNSServer()); err != nil {
+			return errors.Annotatef(err, "invalid %s", DNSServerKey)
+		}
+	}
+	if v, ok := cfg.defined[DNSTTLKey].(int); ok && v < 0 {
+		return fmt.Errorf("%s must not be negative, got %d", DNSTTLKey, v)
+	}
+
 	// Check the immutable config values.  These can't change
 	if old != nil {
 		for _, attr := range immutableAttributes {
@@ -1006,8 +1112,36 @@ func (c *Config) Development() bool {
 // PreferIPv6 returns whether IPv6 addresses for API endpoints and
 // machines will be preferred (when available) over IPv4.
 func (c *Config) PreferIPv6() bool {
-	v, _ := c.defined["prefer-ipv6"].(bool)
-	return v
+	return c.AddressFamilyPolicy().PreferIPv6()
+}
+
+// AddressFamilyPolicy returns the policy determining which address
+// families may be chosen for API endpoints and machines, and in which
+// order. If address-policy is not set, the policy is derived from
+// prefer-ipv6.
+func (c *Config) AddressFamilyPolicy() network.AddressFamilyPolicy {
+	if v, ok := c.defined[AddressPolicyKey].(string); ok && v != "" {
+		if family, err := network.ParseAddressFamilyPolicy(v); err == nil {
+			return family
+		}
+	}
+	if v, _ := c.defined["prefer-ipv6"].(bool); v {
+		return network.IPv6First
+	}
+	return network.IPv4First
+}
+
+// PreferredSpaces returns the names of the spaces, most preferred
+// first, whose addresses are chosen in preference to any others.
+func (c *Config) PreferredSpaces() []string {
+	v, _ := c.defined[PreferredSpacesKey].(string)
+	var names []string
+	for _, name := range strings.Split(v, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
 }
 
 // EnableOSRefreshUpdate returns whether or not newly provisioned
@@ -1121,6 +1255,68 @@ func (c *Config) AllowLXCLoopMounts() (bool, bool) {
 	return v, ok
 }
 
+// HookConcurrency returns the number of hooks that may run
+// concurrently on a single machine.
+func (c *Config) HookConcurrency() int {
+	if v, ok := c.defined[HookConcurrencyKey].(int); ok && v > 0 {
+		return v
+	}
+	return DefaultHookConcurrency
+}
+
+// APIRequestRate returns the number of API requests per second that
+// each authenticated entity may make. Zero means no limit.
+func (c *Config) APIRequestRate() int {
+	v, _ := c.defined[APIRequestRateKey].(int)
+	return v
+}
+
+// APIMaxConcurrentRequests returns the number of API requests that
+// each authenticated entity may have in progress at once. Zero means
+// no limit.
+func (c *Config) APIMaxConcurrentRequests() int {
+	v, _ := c.defined[APIMaxConcurrentRequestsKey].(int)
+	return v
+}
+
+// RESTReadOnly returns whether the API server's REST gateway only
+// allows calls that do not change the state of the environment.
+func (c *Config) RESTReadOnly() bool {
+	if v, ok := c.defined[RESTReadOnlyKey].(bool); ok {
+		return v
+	}
+	return true
+}
+
+// DNSServer returns the address, as host:port, of the DNS server to
+// which the names of units and services are published, or the empty
+// string if they are not published. The port defaults to 53.
+func (c *Config) DNSServer() string {
+	server, _ := c.defined[DNSServerKey].(string)
+	if server == "" {
+		return ""
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		return net.JoinHostPort(strings.Trim(server, "[]"), "53")
+	}
+	return server
+}
+
+// DNSZone returns the DNS zone under which the names of units and
+// services are published.
+func (c *Config) DNSZone() string {
+	zone, _ := c.defined[DNSZoneKey].(string)
+	return strings.TrimSuffix(zone, ".")
+}
+
+// DNSTTL returns the time to live of published DNS records.
+func (c *Config) DNSTTL() time.Duration {
+	if v, ok := c.defined[DNSTTLKey].(int); ok && v > 0 {
+		return time.Duration(v) * time.Second
+	}
+	return DefaultDNSTTL
+}
+
 // UnknownAttrs returns a copy of the raw configuration attributes
 // that are supposedly specific to the environment type. They could
 // also be wrong attributes, though. Only the specific environment
@@ -1212,6 +1408,15 @@ var fields = schema.Fields{
 	PreventAllChangesKey:         schema.Bool(),
 	StorageDefaultBlockSourceKey: schema.String(),
 	AllowLXCLoopMounts:           schema.Bool(),
+	HookConcurrencyKey:           schema.ForceInt(),
+	APIRequestRateKey:            schema.ForceInt(),
+	APIMaxConcurrentRequestsKey:  schema.ForceInt(),
+	RESTReadOnlyKey:              schema.Bool(),
+	AddressPolicyKey:             schema.String(),
+	PreferredSpacesKey:           schema.String(),
+	DNSServerKey:                 schema.String(),
+	DNSZoneKey:                   schema.String(),
+	DNSTTLKey:                    schema.ForceInt(),
 
 	// Deprecated fields, retain for backwards compatibility.
 	ToolsMetadataURLKey:    schema.String(),
@@ -1255,6 +1460,15 @@ var alwaysOptional = schema.Defaults{
 	AgentStreamKey:               schema.Omit,
 	SetNumaControlPolicyKey:      DefaultNumaControlPolicy,
 	AllowLXCLoopMounts:           false,
+	HookConcurrencyKey:           schema.Omit,
+	APIRequestRateKey:            schema.Omit,
+	APIMaxConcurrentRequestsKey:  schema.Omit,
+	RESTReadOnlyKey:              schema.Omit,
+	AddressPolicyKey:             schema.Omit,
+	PreferredSpacesKey:           schema.Omit,
+	DNSServerKey:                 schema.Omit,
+	DNSZoneKey:                   schema.Omit,
+	DNSTTLKey:                    schema.Omit,
 
 	// Storage related config.
 	// Environ providers will specify their own defaults.
@@ -1365,6 +1579,7 @@ var immutableAttributes = []string{
 	"lxc-clone-aufs",
 	"syslog-port",
 	"prefer-ipv6",
+	AddressPolicyKey,
 }
 
 var (
@@ -22,6 +22,7 @@ import (
 	"github.com/juju/juju/cert"
 	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/juju/osenv"
+	"github.com/juju/juju/network"
 	"github.com/juju/juju/testing"
 	"github.com/juju/juju/version"
 )
@@ -456,6 +457,103 @@ var configTests = []configTest{
 			"name":        "my-name",
 			"prefer-ipv6": true,
 		},
+	}, {
+		about:       "address-policy",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":           "my-type",
+			"name":           "my-name",
+			"address-policy": "ipv6-only",
+		},
+	}, {
+		about:       "Invalid address-policy",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":           "my-type",
+			"name":           "my-name",
+			"address-policy": "ipv5-first",
+		},
+		err: `invalid address-policy: address family policy "ipv5-first" not valid`,
+	}, {
+		about:       "address-policy conflicting with prefer-ipv6",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":           "my-type",
+			"name":           "my-name",
+			"prefer-ipv6":    true,
+			"address-policy": "ipv4-only",
+		},
+		err: `address-policy "ipv4-only" conflicts with prefer-ipv6`,
+	}, {
+		about:       "preferred-spaces",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":             "my-type",
+			"name":             "my-name",
+			"preferred-spaces": "db, internal",
+		},
+	}, {
+		about:       "dns-server without dns-zone",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":       "my-type",
+			"name":       "my-name",
+			"dns-server": "10.0.0.53",
+		},
+		err: `dns-server requires dns-zone to be set`,
+	}, {
+		about:       "Negative dns-ttl",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":    "my-type",
+			"name":    "my-name",
+			"dns-ttl": -1,
+		},
+		err: `dns-ttl must not be negative, got -1`,
+	}, {
+		about:       "Invalid preferred-spaces",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":             "my-type",
+			"name":             "my-name",
+			"preferred-spaces": "db,Internal",
+		},
+		err: `invalid preferred-spaces: "Internal" is not a valid space name`,
+	}, {
+		about:       "hook-concurrency",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":             "my-type",
+			"name":             "my-name",
+			"hook-concurrency": 4,
+		},
+	}, {
+		about:       "Invalid hook-concurrency",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":             "my-type",
+			"name":             "my-name",
+			"hook-concurrency": -1,
+		},
+		err: "hook-concurrency must be at least 1, got -1",
+	}, {
+		about:       "API request limits",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":                        "my-type",
+			"name":                        "my-name",
+			"api-request-rate":            10,
+			"api-max-concurrent-requests": 5,
+		},
+	}, {
+		about:       "Invalid api-request-rate",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":             "my-type",
+			"name":             "my-name",
+			"api-request-rate": -1,
+		},
+		err: "api-request-rate must not be negative, got -1",
 	}, {
 		about:       "Invalid agent version",
 		useDefaults: config.UseDefaults,
@@ -1320,7 +1418,7 @@ func (s *ConfigSuite) TestConfigAttrs(c *gc.C) {
 
 	// These attributes are added if not set.
 	attrs["development"] = false
-	attrs["logging-config"] = "<root>=WARNING;unit=DEBUG"
+	attrs["logging-config"] = "<root>=WARNING;unit=DEBUG;juju.apiserver.trace=INFO"
 	attrs["ca-private-key"] = ""
 	attrs["image-metadata-url"] = ""
 	attrs["agent-metadata-url"] = ""
@@ -1442,6 +1540,11 @@ var validationTests = []validationTest{{
 	old:   testing.Attrs{"prefer-ipv6": false},
 	new:   testing.Attrs{"prefer-ipv6": true},
 	err:   `cannot change prefer-ipv6 from false to true`,
+}, {
+	about: "Cannot change address-policy",
+	old:   testing.Attrs{"address-policy": "ipv4-first"},
+	new:   testing.Attrs{"address-policy": "ipv4-only"},
+	err:   `cannot change address-policy from "ipv4-first" to "ipv4-only"`,
 }, {
 	about: "Can change uuid from unset to set",
 	new:   testing.Attrs{"uuid": "dcfbdb4a-bca2-49ad-aa7c-f011424e0fe4"},
@@ -1582,14 +1685,14 @@ func (s *ConfigSuite) TestLoggingConfig(c *gc.C) {
 	s.addJujuFiles(c)
 	config := newTestConfig(c, testing.Attrs{
 		"logging-config": "<root>=WARNING;juju=DEBUG"})
-	c.Assert(config.LoggingConfig(), gc.Equals, "<root>=WARNING;juju=DEBUG;unit=DEBUG")
+	c.Assert(config.LoggingConfig(), gc.Equals, "<root>=WARNING;juju=DEBUG;unit=DEBUG;juju.apiserver.trace=INFO")
 }
 
 func (s *ConfigSuite) TestLoggingConfigWithUnit(c *gc.C) {
 	s.addJujuFiles(c)
 	config := newTestConfig(c, testing.Attrs{
-		"logging-config": "<root>=WARNING;unit=INFO"})
-	c.Assert(config.LoggingConfig(), gc.Equals, "<root>=WARNING;unit=INFO")
+		"logging-config": "<root>=WARNING;unit=INFO;juju.apiserver.trace=WARNING"})
+	c.Assert(config.LoggingConfig(), gc.Equals, "<root>=WARNING;unit=INFO;juju.apiserver.trace=WARNING")
 }
 
 func (s *ConfigSuite) TestLoggingConfigFromEnvironment(c *gc.C) {
@@ -1597,7 +1700,7 @@ func (s *ConfigSuite) TestLoggingConfigFromEnvironment(c *gc.C) {
 	s.PatchEnvironment(osenv.JujuLoggingConfigEnvKey, "<root>=INFO")
 
 	config := newTestConfig(c, nil)
-	c.Assert(config.LoggingConfig(), gc.Equals, "<root>=INFO;unit=DEBUG")
+	c.Assert(config.LoggingConfig(), gc.Equals, "<root>=INFO;unit=DEBUG;juju.apiserver.trace=INFO")
 }
 
 func (s *ConfigSuite) TestProxyValuesWithFallback(c *gc.C) {
@@ -1703,6 +1806,82 @@ func (s *ConfigSuite) TestAptProxyConfigMap(c *gc.C) {
 	c.Assert(cfg.AptProxySettings(), gc.DeepEquals, proxySettings)
 }
 
+func (s *ConfigSuite) TestHookConcurrency(c *gc.C) {
+	cfg := newTestConfig(c, testing.Attrs{})
+	c.Assert(cfg.HookConcurrency(), gc.Equals, config.DefaultHookConcurrency)
+
+	cfg = newTestConfig(c, testing.Attrs{"hook-concurrency": 3})
+	c.Assert(cfg.HookConcurrency(), gc.Equals, 3)
+}
+
+func (s *ConfigSuite) TestAPIRequestLimits(c *gc.C) {
+	cfg := newTestConfig(c, testing.Attrs{})
+	c.Assert(cfg.APIRequestRate(), gc.Equals, 0)
+	c.Assert(cfg.APIMaxConcurrentRequests(), gc.Equals, 0)
+
+	cfg = newTestConfig(c, testing.Attrs{
+		"api-request-rate":            10,
+		"api-max-concurrent-requests": 5,
+	})
+	c.Assert(cfg.APIRequestRate(), gc.Equals, 10)
+	c.Assert(cfg.APIMaxConcurrentRequests(), gc.Equals, 5)
+}
+
+func (s *ConfigSuite) TestRESTReadOnly(c *gc.C) {
+	cfg := newTestConfig(c, testing.Attrs{})
+	c.Assert(cfg.RESTReadOnly(), jc.IsTrue)
+
+	cfg = newTestConfig(c, testing.Attrs{"rest-api-read-only": false})
+	c.Assert(cfg.RESTReadOnly(), jc.IsFalse)
+}
+
+func (s *ConfigSuite) TestAddressFamilyPolicy(c *gc.C) {
+	cfg := newTestConfig(c, testing.Attrs{})
+	c.Assert(cfg.AddressFamilyPolicy(), gc.Equals, network.IPv4First)
+	c.Assert(cfg.PreferIPv6(), jc.IsFalse)
+
+	cfg = newTestConfig(c, testing.Attrs{"prefer-ipv6": true})
+	c.Assert(cfg.AddressFamilyPolicy(), gc.Equals, network.IPv6First)
+	c.Assert(cfg.PreferIPv6(), jc.IsTrue)
+
+	cfg = newTestConfig(c, testing.Attrs{"address-policy": "ipv6-only"})
+	c.Assert(cfg.AddressFamilyPolicy(), gc.Equals, network.IPv6Only)
+	c.Assert(cfg.PreferIPv6(), jc.IsTrue)
+
+	cfg = newTestConfig(c, testing.Attrs{"address-policy": "ipv4-only"})
+	c.Assert(cfg.AddressFamilyPolicy(), gc.Equals, network.IPv4Only)
+	c.Assert(cfg.PreferIPv6(), jc.IsFalse)
+}
+
+func (s *ConfigSuite) TestPreferredSpaces(c *gc.C) {
+	cfg := newTestConfig(c, testing.Attrs{})
+	c.Assert(cfg.PreferredSpaces(), gc.HasLen, 0)
+
+	cfg = newTestConfig(c, testing.Attrs{"preferred-spaces": "db, internal,"})
+	c.Assert(cfg.PreferredSpaces(), jc.DeepEquals, []string{"db", "internal"})
+}
+
+func (s *ConfigSuite) TestDNSSettings(c *gc.C) {
+	cfg := newTestConfig(c, testing.Attrs{})
+	c.Assert(cfg.DNSServer(), gc.Equals, "")
+	c.Assert(cfg.DNSTTL(), gc.Equals, config.DefaultDNSTTL)
+
+	cfg = newTestConfig(c, testing.Attrs{
+		"dns-server": "10.0.0.53",
+		"dns-zone":   "example.com.",
+		"dns-ttl":    60,
+	})
+	c.Assert(cfg.DNSServer(), gc.Equals, "10.0.0.53:53")
+	c.Assert(cfg.DNSZone(), gc.Equals, "example.com")
+	c.Assert(cfg.DNSTTL(), gc.Equals, time.Minute)
+
+	cfg = newTestConfig(c, testing.Attrs{
+		"dns-server": "[2001:db8::53]:5353",
+		"dns-zone":   "example.com",
+	})
+	c.Assert(cfg.DNSServer(), gc.Equals, "[2001:db8::53]:5353")
+}
+
 func (s *ConfigSuite) TestGenerateStateServerCertAndKey(c *gc.C) {
 	// Add a cert.
 	s.FakeHomeSuite.Home.AddFiles(c, gitjujutesting.TestFile{".ssh/id_rsa.pub", "rsa\n"})
@@ -194,6 +194,22 @@ type Environ interface {
 	state.Prechecker
 }
 
+// SourceCIDRFirewaller is implemented by environments whose firewalls
+// restrict access to opened port ranges to their SourceCIDR. Other
+// environments open port ranges to any address.
+type SourceCIDRFirewaller interface {
+	// SupportsSourceCIDRs reports whether the environment's
+	// firewall honours the SourceCIDR of port ranges.
+	SupportsSourceCIDRs() bool
+}
+
+// SupportsSourceCIDRs is a convenience helper to check whether an
+// environment's firewall honours the SourceCIDR of port ranges.
+func SupportsSourceCIDRs(environ Environ) bool {
+	f, ok := environ.(SourceCIDRFirewaller)
+	return ok && f.SupportsSourceCIDRs()
+}
+
 // BootstrapContext is an interface that is passed to
 // Environ.Bootstrap, providing a means of obtaining
 // information about and manipulating the context in which
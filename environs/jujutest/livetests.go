@@ -246,72 +246,72 @@ func (t *LiveTests) TestPorts(c *gc.C) {
 	defer t.Env.StopInstances(inst2.Id())
 
 	// Open some ports and check they're there.
-	err = inst1.OpenPorts("1", []network.PortRange{{67, 67, "udp"}, {45, 45, "tcp"}, {80, 100, "tcp"}})
+	err = inst1.OpenPorts("1", []network.PortRange{{FromPort: 67, ToPort: 67, Protocol: "udp"}, {FromPort: 45, ToPort: 45, Protocol: "tcp"}, {FromPort: 80, ToPort: 100, Protocol: "tcp"}})
 	c.Assert(err, jc.ErrorIsNil)
 	ports, err = inst1.Ports("1")
 	c.Assert(err, jc.ErrorIsNil)
-	c.Assert(ports, gc.DeepEquals, []network.PortRange{{45, 45, "tcp"}, {80, 100, "tcp"}, {67, 67, "udp"}})
+	c.Assert(ports, gc.DeepEquals, []network.PortRange{{FromPort: 45, ToPort: 45, Protocol: "tcp"}, {FromPort: 80, ToPort: 100, Protocol: "tcp"}, {FromPort: 67, ToPort: 67, Protocol: "udp"}})
 	ports, err = inst2.Ports("2")
 	c.Assert(err, jc.ErrorIsNil)
 	c.Assert(ports, gc.HasLen, 0)
 
-	err = inst2.OpenPorts("2", []network.PortRange{{89, 89, "tcp"}, {45, 45, "tcp"}, {20, 30, "tcp"}})
+	err = inst2.OpenPorts("2", []network.PortRange{{FromPort: 89, ToPort: 89, Protocol: "tcp"}, {FromPort: 45, ToPort: 45, Protocol: "tcp"}, {FromPort: 20, ToPort: 30, Protocol: "tcp"}})
 	c.Assert(err, jc.ErrorIsNil)
 
 	// Check there's no crosstalk to another machine
 	ports, err = inst2.Ports("2")
 	c.Assert(err, jc.ErrorIsNil)
-	c.Assert(ports, gc.DeepEquals, []network.PortRange{{20, 30, "tcp"}, {45, 45, "tcp"}, {89, 89, "tcp"}})
+	c.Assert(ports, gc.DeepEquals, []network.PortRange{{FromPort: 20, ToPort: 30, Protocol: "tcp"}, {FromPort: 45, ToPort: 45, Protocol: "tcp"}, {FromPort: 89, ToPort: 89, Protocol: "tcp"}})
 	ports, err = inst1.Ports("1")
 	c.Assert(err, jc.ErrorIsNil)
-	c.Assert(ports, gc.DeepEquals, []network.PortRange{{45, 45, "tcp"}, {80, 100, "tcp"}, {67, 67, "udp"}})
+	c.Assert(ports, gc.DeepEquals, []network.PortRange{{FromPort: 45, ToPort: 45, Protocol: "tcp"}, {FromPort: 80, ToPort: 100, Protocol: "tcp"}, {FromPort: 67, ToPort: 67, Protocol: "udp"}})
 
 	// Check that opening the same port again is ok.
 	oldPorts, err := inst2.Ports("2")
 	c.Assert(err, jc.ErrorIsNil)
-	err = inst2.OpenPorts("2", []network.PortRange{{45, 45, "tcp"}})
+	err = inst2.OpenPorts("2", []network.PortRange{{FromPort: 45, ToPort: 45, Protocol: "tcp"}})
 	c.Assert(err, jc.ErrorIsNil)
-	err = inst2.OpenPorts("2", []network.PortRange{{20, 30, "tcp"}})
+	err = inst2.OpenPorts("2", []network.PortRange{{FromPort: 20, ToPort: 30, Protocol: "tcp"}})
 	c.Assert(err, jc.ErrorIsNil)
 	ports, err = inst2.Ports("2")
 	c.Assert(err, jc.ErrorIsNil)
 	c.Assert(ports, gc.DeepEquals, oldPorts)
 
 	// Check that opening the same port again and another port is ok.
-	err = inst2.OpenPorts("2", []network.PortRange{{45, 45, "tcp"}, {99, 99, "tcp"}})
+	err = inst2.OpenPorts("2", []network.PortRange{{FromPort: 45, ToPort: 45, Protocol: "tcp"}, {FromPort: 99, ToPort: 99, Protocol: "tcp"}})
 	c.Assert(err, jc.ErrorIsNil)
 	ports, err = inst2.Ports("2")
 	c.Assert(err, jc.ErrorIsNil)
-	c.Assert(ports, gc.DeepEquals, []network.PortRange{{20, 30, "tcp"}, {45, 45, "tcp"}, {89, 89, "tcp"}, {99, 99, "tcp"}})
+	c.Assert(ports, gc.DeepEquals, []network.PortRange{{FromPort: 20, ToPort: 30, Protocol: "tcp"}, {FromPort: 45, ToPort: 45, Protocol: "tcp"}, {FromPort: 89, ToPort: 89, Protocol: "tcp"}, {FromPort: 99, ToPort: 99, Protocol: "tcp"}})
 
-	err = inst2.ClosePorts("2", []network.PortRange{{45, 45, "tcp"}, {99, 99, "tcp"}, {20, 30, "tcp"}})
+	err = inst2.ClosePorts("2", []network.PortRange{{FromPort: 45, ToPort: 45, Protocol: "tcp"}, {FromPort: 99, ToPort: 99, Protocol: "tcp"}, {FromPort: 20, ToPort: 30, Protocol: "tcp"}})
 	c.Assert(err, jc.ErrorIsNil)
 
 	// Check that we can close ports and that there's no crosstalk.
 	ports, err = inst2.Ports("2")
 	c.Assert(err, jc.ErrorIsNil)
-	c.Assert(ports, gc.DeepEquals, []network.PortRange{{89, 89, "tcp"}})
+	c.Assert(ports, gc.DeepEquals, []network.PortRange{{FromPort: 89, ToPort: 89, Protocol: "tcp"}})
 	ports, err = inst1.Ports("1")
 	c.Assert(err, jc.ErrorIsNil)
-	c.Assert(ports, gc.DeepEquals, []network.PortRange{{45, 45, "tcp"}, {80, 100, "tcp"}, {67, 67, "udp"}})
+	c.Assert(ports, gc.DeepEquals, []network.PortRange{{FromPort: 45, ToPort: 45, Protocol: "tcp"}, {FromPort: 80, ToPort: 100, Protocol: "tcp"}, {FromPort: 67, ToPort: 67, Protocol: "udp"}})
 
 	// Check that we can close multiple ports.
-	err = inst1.ClosePorts("1", []network.PortRange{{45, 45, "tcp"}, {67, 67, "udp"}, {80, 100, "tcp"}})
+	err = inst1.ClosePorts("1", []network.PortRange{{FromPort: 45, ToPort: 45, Protocol: "tcp"}, {FromPort: 67, ToPort: 67, Protocol: "udp"}, {FromPort: 80, ToPort: 100, Protocol: "tcp"}})
 	c.Assert(err, jc.ErrorIsNil)
 	ports, err = inst1.Ports("1")
 	c.Assert(ports, gc.HasLen, 0)
 
 	// Check that we can close ports that aren't there.
-	err = inst2.ClosePorts("2", []network.PortRange{{111, 111, "tcp"}, {222, 222, "udp"}, {600, 700, "tcp"}})
+	err = inst2.ClosePorts("2", []network.PortRange{{FromPort: 111, ToPort: 111, Protocol: "tcp"}, {FromPort: 222, ToPort: 222, Protocol: "udp"}, {FromPort: 600, ToPort: 700, Protocol: "tcp"}})
 	c.Assert(err, jc.ErrorIsNil)
 	ports, err = inst2.Ports("2")
-	c.Assert(ports, gc.DeepEquals, []network.PortRange{{89, 89, "tcp"}})
+	c.Assert(ports, gc.DeepEquals, []network.PortRange{{FromPort: 89, ToPort: 89, Protocol: "tcp"}})
 
 	// Check errors when acting on environment.
-	err = t.Env.OpenPorts([]network.PortRange{{80, 80, "tcp"}})
+	err = t.Env.OpenPorts([]network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
 	c.Assert(err, gc.ErrorMatches, `invalid firewall mode "instance" for opening ports on environment`)
 
-	err = t.Env.ClosePorts([]network.PortRange{{80, 80, "tcp"}})
+	err = t.Env.ClosePorts([]network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
 	c.Assert(err, gc.ErrorMatches, `invalid firewall mode "instance" for closing ports on environment`)
 
 	_, err = t.Env.Ports()
@@ -348,34 +348,34 @@ func (t *LiveTests) TestGlobalPorts(c *gc.C) {
 	c.Assert(ports, gc.HasLen, 0)
 	defer t.Env.StopInstances(inst2.Id())
 
-	err = t.Env.OpenPorts([]network.PortRange{{67, 67, "udp"}, {45, 45, "tcp"}, {89, 89, "tcp"}, {99, 99, "tcp"}, {100, 110, "tcp"}})
+	err = t.Env.OpenPorts([]network.PortRange{{FromPort: 67, ToPort: 67, Protocol: "udp"}, {FromPort: 45, ToPort: 45, Protocol: "tcp"}, {FromPort: 89, ToPort: 89, Protocol: "tcp"}, {FromPort: 99, ToPort: 99, Protocol: "tcp"}, {FromPort: 100, ToPort: 110, Protocol: "tcp"}})
 	c.Assert(err, jc.ErrorIsNil)
 
 	ports, err = t.Env.Ports()
 	c.Assert(err, jc.ErrorIsNil)
-	c.Assert(ports, gc.DeepEquals, []network.PortRange{{45, 45, "tcp"}, {89, 89, "tcp"}, {99, 99, "tcp"}, {100, 110, "tcp"}, {67, 67, "udp"}})
+	c.Assert(ports, gc.DeepEquals, []network.PortRange{{FromPort: 45, ToPort: 45, Protocol: "tcp"}, {FromPort: 89, ToPort: 89, Protocol: "tcp"}, {FromPort: 99, ToPort: 99, Protocol: "tcp"}, {FromPort: 100, ToPort: 110, Protocol: "tcp"}, {FromPort: 67, ToPort: 67, Protocol: "udp"}})
 
 	// Check closing some ports.
-	err = t.Env.ClosePorts([]network.PortRange{{99, 99, "tcp"}, {67, 67, "udp"}})
+	err = t.Env.ClosePorts([]network.PortRange{{FromPort: 99, ToPort: 99, Protocol: "tcp"}, {FromPort: 67, ToPort: 67, Protocol: "udp"}})
 	c.Assert(err, jc.ErrorIsNil)
 
 	ports, err = t.Env.Ports()
 	c.Assert(err, jc.ErrorIsNil)
-	c.Assert(ports, gc.DeepEquals, []network.PortRange{{45, 45, "tcp"}, {89, 89, "tcp"}, {100, 110, "tcp"}})
+	c.Assert(ports, gc.DeepEquals, []network.PortRange{{FromPort: 45, ToPort: 45, Protocol: "tcp"}, {FromPort: 89, ToPort: 89, Protocol: "tcp"}, {FromPort: 100, ToPort: 110, Protocol: "tcp"}})
 
 	// Check that we can close ports that aren't there.
-	err = t.Env.ClosePorts([]network.PortRange{{111, 111, "tcp"}, {222, 222, "udp"}, {2000, 2500, "tcp"}})
+	err = t.Env.ClosePorts([]network.PortRange{{FromPort: 111, ToPort: 111, Protocol: "tcp"}, {FromPort: 222, ToPort: 222, Protocol: "udp"}, {FromPort: 2000, ToPort: 2500, Protocol: "tcp"}})
 	c.Assert(err, jc.ErrorIsNil)
 
 	ports, err = t.Env.Ports()
 	c.Assert(err, jc.ErrorIsNil)
-	c.Assert(ports, gc.DeepEquals, []network.PortRange{{45, 45, "tcp"}, {89, 89, "tcp"}, {100, 110, "tcp"}})
+	c.Assert(ports, gc.DeepEquals, []network.PortRange{{FromPort: 45, ToPort: 45, Protocol: "tcp"}, {FromPort: 89, ToPort: 89, Protocol: "tcp"}, {FromPort: 100, ToPort: 110, Protocol: "tcp"}})
 
 	// Check errors when acting on instances.
-	err = inst1.OpenPorts("1", []network.PortRange{{80, 80, "tcp"}})
+	err = inst1.OpenPorts("1", []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
 	c.Assert(err, gc.ErrorMatches, `invalid firewall mode "global" for opening ports on instance`)
 
-	err = inst1.ClosePorts("1", []network.PortRange{{80, 80, "tcp"}})
+	err = inst1.ClosePorts("1", []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
 	c.Assert(err, gc.ErrorMatches, `invalid firewall mode "global" for closing ports on instance`)
 
 	_, err = inst1.Ports("1")
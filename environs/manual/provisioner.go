@@ -58,6 +58,11 @@ type ProvisionMachineArgs struct {
 	// Stderr is required to present machine provisioning progress to the user.
 	Stderr io.Writer
 
+	// Zone, if not empty, is the availability zone recorded for the
+	// machine. Units of a service are spread across the zones of the
+	// machines they are assigned to.
+	Zone string
+
 	*params.UpdateBehavior
 }
 
@@ -96,6 +101,10 @@ func ProvisionMachine(args ProvisionMachineArgs) (machineId string, err error) {
 	if err != nil {
 		return "", err
 	}
+	if args.Zone != "" {
+		zone := args.Zone
+		machineParams.HardwareCharacteristics.AvailabilityZone = &zone
+	}
 
 	// Inform Juju that the machine exists.
 	machineId, err = recordMachineInState(args.Client, *machineParams)
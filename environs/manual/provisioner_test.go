@@ -121,6 +121,35 @@ func (s *provisionerSuite) TestProvisionMachine(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, "error checking if provisioned: subprocess encountered error code 255")
 }
 
+func (s *provisionerSuite) TestProvisionMachineWithZone(c *gc.C) {
+	const series = coretesting.FakeDefaultSeries
+	const arch = "amd64"
+
+	cfg := s.Environ.Config()
+	number, ok := cfg.AgentVersion()
+	c.Assert(ok, jc.IsTrue)
+	binVersion := version.Binary{number, series, arch, version.Ubuntu}
+	envtesting.AssertUploadFakeToolsVersions(c, s.DefaultToolsStorage, "released", "released", binVersion)
+
+	args := s.getArgs(c)
+	args.Host = "ubuntu@" + args.Host
+	args.Zone = "rack1"
+	defer fakeSSH{
+		Series:         series,
+		Arch:           arch,
+		InitUbuntuUser: true,
+	}.install(c).Restore()
+	machineId, err := manual.ProvisionMachine(args)
+	c.Assert(err, jc.ErrorIsNil)
+
+	m, err := s.State.Machine(machineId)
+	c.Assert(err, jc.ErrorIsNil)
+	hc, err := m.HardwareCharacteristics()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(hc.AvailabilityZone, gc.NotNil)
+	c.Assert(*hc.AvailabilityZone, gc.Equals, "rack1")
+}
+
 func (s *provisionerSuite) TestFinishMachineConfig(c *gc.C) {
 	const series = coretesting.FakeDefaultSeries
 	const arch = "amd64"
@@ -49,3 +49,7 @@ const DbLog = "db-log"
 // NewStatus is the name of the feature to enable the new
 // juju status output.
 const NewStatus = "new-status"
+
+// BinaryRPC is the name of the feature to have API clients offer
+// the compact BSON codec to the API server in place of JSON.
+const BinaryRPC = "binary-rpc"
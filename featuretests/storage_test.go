@@ -12,6 +12,7 @@ import (
 	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
 
+	"github.com/juju/juju/api"
 	"github.com/juju/juju/cmd/envcmd"
 	cmdstorage "github.com/juju/juju/cmd/juju/storage"
 	"github.com/juju/juju/feature"
@@ -136,6 +137,15 @@ func (s *cmdStorageSuite) TestStorageListEmpty(c *gc.C) {
 	c.Assert(testing.Stdout(context), gc.Equals, "")
 }
 
+func (s *cmdStorageSuite) TestStorageListNotSupported(c *gc.C) {
+	// Without the feature flag, the API server
+	// does not offer the Storage facade.
+	s.SetFeatureFlags()
+	_, err := testing.RunCommand(c, envcmd.Wrap(&cmdstorage.ListCommand{}))
+	c.Assert(err, gc.ErrorMatches, `Storage is not supported by this server version \(needs Storage facade version 1\)`)
+	c.Assert(api.IsNotSupportedError(err), jc.IsTrue)
+}
+
 func (s *cmdStorageSuite) TestStorageList(c *gc.C) {
 	createUnitWithStorage(c, &s.JujuConnSuite, testPool)
 
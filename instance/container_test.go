@@ -29,6 +29,10 @@ func (s *InstanceSuite) TestParseContainerType(c *gc.C) {
 	c.Assert(err, jc.ErrorIsNil)
 	c.Assert(ctype, gc.Equals, instance.KVM)
 
+	ctype, err = instance.ParseContainerType("lxd")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ctype, gc.Equals, instance.LXD)
+
 	ctype, err = instance.ParseContainerType("none")
 	c.Assert(err, gc.ErrorMatches, `invalid container type "none"`)
 
@@ -22,7 +22,7 @@ var ErrPlacementScopeMissing = fmt.Errorf("placement scope missing")
 // and a value that is scope-specific.
 type Placement struct {
 	// Scope is the scope of the placement directive. Scope may
-	// be a container type (lxc, kvm), instance.MachineScope, or
+	// be a container type (lxc, kvm, lxd), instance.MachineScope, or
 	// an environment name.
 	//
 	// If Scope is empty, then it must be inferred from the context.
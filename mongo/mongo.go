@@ -83,7 +83,14 @@ func SelectPeerAddress(addrs []network.Address) string {
 // SelectPeerHostPort returns the HostPort to use as the
 // mongo replica set peer by selecting it from the given hostPorts.
 func SelectPeerHostPort(hostPorts []network.HostPort) string {
-	return network.SelectInternalHostPort(hostPorts, false)
+	return SelectPeerHostPortByPolicy(network.CurrentAddressPolicy(), hostPorts)
+}
+
+// SelectPeerHostPortByPolicy returns the HostPort to use as the
+// mongo replica set peer by selecting it from the given hostPorts
+// according to the given address policy.
+func SelectPeerHostPortByPolicy(policy network.AddressPolicy, hostPorts []network.HostPort) string {
+	return policy.SelectInternalHostPort(hostPorts, false)
 }
 
 // GenerateSharedSecret generates a pseudo-random shared secret (keyfile)
@@ -22,20 +22,6 @@ var (
 	ipv6UniqueLocal = mustParseCIDR("fc00::/7")
 )
 
-// globalPreferIPv6 determines whether IPv6 addresses will be
-// preferred when selecting a public or internal addresses, using the
-// Select*() methods below. InitializeFromConfig() needs to be called
-// to set this flag globally at the earliest time possible (e.g. at
-// bootstrap, agent startup, before any CLI command).
-var globalPreferIPv6 bool = false
-
-// ResetGobalPreferIPv6 resets the global variable back to the default,
-// and is called only from the isolation test suite to make sure we have
-// a clean environment.
-func ResetGobalPreferIPv6() {
-	globalPreferIPv6 = false
-}
-
 func mustParseCIDR(s string) *net.IPNet {
 	_, net, err := net.ParseCIDR(s)
 	if err != nil {
@@ -204,56 +190,35 @@ func deriveScope(addr Address) Scope {
 }
 
 // SelectPublicAddress picks one address from a slice that would be
-// appropriate to display as a publicly accessible endpoint. If there
-// are no suitable addresses, the empty string is returned.
+// appropriate to display as a publicly accessible endpoint, according
+// to the global address policy. If there are no suitable addresses,
+// the empty string is returned.
 func SelectPublicAddress(addresses []Address) string {
-	index := bestAddressIndex(len(addresses), globalPreferIPv6, func(i int) Address {
-		return addresses[i]
-	}, publicMatch)
-	if index < 0 {
-		return ""
-	}
-	return addresses[index].Value
+	return CurrentAddressPolicy().SelectPublicAddress(addresses)
 }
 
 // SelectPublicHostPort picks one HostPort from a slice that would be
-// appropriate to display as a publicly accessible endpoint. If there
-// are no suitable candidates, the empty string is returned.
+// appropriate to display as a publicly accessible endpoint, according
+// to the global address policy. If there are no suitable candidates,
+// the empty string is returned.
 func SelectPublicHostPort(hps []HostPort) string {
-	index := bestAddressIndex(len(hps), globalPreferIPv6, func(i int) Address {
-		return hps[i].Address
-	}, publicMatch)
-	if index < 0 {
-		return ""
-	}
-	return hps[index].NetAddr()
+	return CurrentAddressPolicy().SelectPublicHostPort(hps)
 }
 
 // SelectInternalAddress picks one address from a slice that can be
-// used as an endpoint for juju internal communication. If there are
-// no suitable addresses, the empty string is returned.
+// used as an endpoint for juju internal communication, according to
+// the global address policy. If there are no suitable addresses, the
+// empty string is returned.
 func SelectInternalAddress(addresses []Address, machineLocal bool) string {
-	index := bestAddressIndex(len(addresses), globalPreferIPv6, func(i int) Address {
-		return addresses[i]
-	}, internalAddressMatcher(machineLocal))
-	if index < 0 {
-		return ""
-	}
-	return addresses[index].Value
+	return CurrentAddressPolicy().SelectInternalAddress(addresses, machineLocal)
 }
 
 // SelectInternalHostPort picks one HostPort from a slice that can be
 // used as an endpoint for juju internal communication and returns it
-// in its NetAddr form. If there are no suitable addresses, the empty
-// string is returned.
+// in its NetAddr form, according to the global address policy. If
+// there are no suitable addresses, the empty string is returned.
 func SelectInternalHostPort(hps []HostPort, machineLocal bool) string {
-	index := bestAddressIndex(len(hps), globalPreferIPv6, func(i int) Address {
-		return hps[i].Address
-	}, internalAddressMatcher(machineLocal))
-	if index < 0 {
-		return ""
-	}
-	return hps[index].NetAddr()
+	return CurrentAddressPolicy().SelectInternalHostPort(hps, machineLocal)
 }
 
 func publicMatch(addr Address, preferIPv6 bool) scopeMatch {
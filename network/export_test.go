@@ -6,9 +6,14 @@ package network
 var NetLookupIP = &netLookupIP
 
 func SetPreferIPv6(value bool) {
-	globalPreferIPv6 = value
+	globalAddressPolicyMutex.Lock()
+	defer globalAddressPolicyMutex.Unlock()
+	globalAddressPolicy.Family = IPv4First
+	if value {
+		globalAddressPolicy.Family = IPv6First
+	}
 }
 
 func GetPreferIPv6() bool {
-	return globalPreferIPv6
+	return CurrentAddressPolicy().Family.PreferIPv6()
 }
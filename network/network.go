@@ -138,6 +138,11 @@ type InterfaceInfo struct {
 	// inside an "iface" section of a interfaces(5) config file, e.g.
 	// "up", "down", "mtu", etc.
 	ExtraConfig map[string]string
+
+	// ParentInterfaceName is the name of the host device (usually a
+	// bridge) a container's network interface is attached to. When
+	// empty, the device of the container's network config is used.
+	ParentInterfaceName string
 }
 
 type interfaceInfoSlice []InterfaceInfo
@@ -182,12 +187,29 @@ type PreferIPv6Getter interface {
 	PreferIPv6() bool
 }
 
+// AddressFamilyPolicyGetter is implemented by configurations which
+// specify a complete address family policy rather than just whether
+// IPv6 is preferred.
+type AddressFamilyPolicyGetter interface {
+	AddressFamilyPolicy() AddressFamilyPolicy
+}
+
 // InitializeFromConfig needs to be called once after the environment
 // or agent configuration is available to configure networking
-// settings.
+// settings. The preferred subnets of the global address policy are
+// left unchanged.
 func InitializeFromConfig(config PreferIPv6Getter) {
-	globalPreferIPv6 = config.PreferIPv6()
-	logger.Infof("setting prefer-ipv6 to %v", globalPreferIPv6)
+	family := IPv4First
+	if config.PreferIPv6() {
+		family = IPv6First
+	}
+	if getter, ok := config.(AddressFamilyPolicyGetter); ok {
+		family = getter.AddressFamilyPolicy()
+	}
+	globalAddressPolicyMutex.Lock()
+	globalAddressPolicy.Family = family
+	globalAddressPolicyMutex.Unlock()
+	logger.Infof("setting address family policy to %v", family)
 }
 
 // LXCNetDefaultConfig is the location of the default network config
@@ -102,6 +102,13 @@ func (*NetworkSuite) TestInitializeFromConfig(c *gc.C) {
 	})
 	network.InitializeFromConfig(envConfig)
 	c.Check(network.GetPreferIPv6(), jc.IsFalse)
+
+	envConfig = testing.CustomEnvironConfig(c, testing.Attrs{
+		"address-policy": "ipv6-only",
+	})
+	network.InitializeFromConfig(envConfig)
+	c.Check(network.GetPreferIPv6(), jc.IsTrue)
+	c.Check(network.CurrentAddressPolicy().Family, gc.Equals, network.IPv6Only)
 }
 
 func (s *NetworkSuite) TestFilterLXCAddresses(c *gc.C) {
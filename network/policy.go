@@ -0,0 +1,253 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package network
+
+import (
+	"net"
+	"sort"
+	"sync"
+
+	"github.com/juju/errors"
+)
+
+// AddressFamilyPolicy determines which IP address families may be
+// selected, and which of them is preferred when both are available.
+type AddressFamilyPolicy string
+
+const (
+	// IPv4First selects IPv4 addresses in preference to IPv6 ones.
+	// This is the default.
+	IPv4First AddressFamilyPolicy = "ipv4-first"
+
+	// IPv6First selects IPv6 addresses in preference to IPv4 ones.
+	IPv6First AddressFamilyPolicy = "ipv6-first"
+
+	// IPv4Only never selects IPv6 addresses.
+	IPv4Only AddressFamilyPolicy = "ipv4-only"
+
+	// IPv6Only never selects IPv4 addresses.
+	IPv6Only AddressFamilyPolicy = "ipv6-only"
+)
+
+// ParseAddressFamilyPolicy returns the address family policy with
+// the given name.
+func ParseAddressFamilyPolicy(name string) (AddressFamilyPolicy, error) {
+	switch policy := AddressFamilyPolicy(name); policy {
+	case IPv4First, IPv6First, IPv4Only, IPv6Only:
+		return policy, nil
+	}
+	return "", errors.NotValidf("address family policy %q", name)
+}
+
+// PreferIPv6 reports whether the policy prefers IPv6 addresses.
+func (p AddressFamilyPolicy) PreferIPv6() bool {
+	return p == IPv6First || p == IPv6Only
+}
+
+// allows reports whether addresses of the given type may be selected
+// under the policy. Hostnames are always allowed.
+func (p AddressFamilyPolicy) allows(addrType AddressType) bool {
+	switch p {
+	case IPv4Only:
+		return addrType != IPv6Address
+	case IPv6Only:
+		return addrType != IPv4Address
+	}
+	return true
+}
+
+// AddressPolicy determines how addresses are chosen from the set of
+// addresses of a machine.
+type AddressPolicy struct {
+	// Family determines which address families may be chosen, and
+	// in which order. The zero value is equivalent to IPv4First.
+	Family AddressFamilyPolicy
+
+	// PreferredSubnets holds the CIDRs of subnets, most preferred
+	// first, whose addresses are chosen in preference to any other
+	// address of a suitable scope when choosing internal addresses.
+	PreferredSubnets []string
+}
+
+// globalAddressPolicy determines how public and internal addresses
+// are chosen by the Select*() functions. InitializeFromConfig() needs
+// to be called to set it at the earliest time possible (e.g. at
+// bootstrap, agent startup, before any CLI command). Agent workers
+// may change it while others are selecting addresses, so it is only
+// accessed with globalAddressPolicyMutex held.
+var (
+	globalAddressPolicyMutex sync.RWMutex
+	globalAddressPolicy      = AddressPolicy{Family: IPv4First}
+)
+
+// ResetGlobalAddressPolicy resets the global address policy back to
+// the default, and is called only from the isolation test suite to
+// make sure we have a clean environment.
+func ResetGlobalAddressPolicy() {
+	globalAddressPolicyMutex.Lock()
+	defer globalAddressPolicyMutex.Unlock()
+	globalAddressPolicy = AddressPolicy{Family: IPv4First}
+}
+
+// CurrentAddressPolicy returns the address policy used by the Select*()
+// functions.
+func CurrentAddressPolicy() AddressPolicy {
+	globalAddressPolicyMutex.RLock()
+	defer globalAddressPolicyMutex.RUnlock()
+	return globalAddressPolicy
+}
+
+// SetAddressPolicy sets the address policy used by the Select*()
+// functions.
+func SetAddressPolicy(policy AddressPolicy) {
+	globalAddressPolicyMutex.Lock()
+	globalAddressPolicy = policy
+	globalAddressPolicyMutex.Unlock()
+	logger.Infof("setting address policy to %v (preferred subnets: %v)", policy.Family, policy.PreferredSubnets)
+}
+
+// SelectPublicAddress picks one address from a slice that would be
+// appropriate to display as a publicly accessible endpoint. If there
+// are no suitable addresses, the empty string is returned.
+func (p AddressPolicy) SelectPublicAddress(addresses []Address) string {
+	index := p.bestAddressIndex(len(addresses), func(i int) Address {
+		return addresses[i]
+	}, publicMatch, false)
+	if index < 0 {
+		return ""
+	}
+	return addresses[index].Value
+}
+
+// SelectPublicHostPort picks one HostPort from a slice that would be
+// appropriate to display as a publicly accessible endpoint. If there
+// are no suitable candidates, the empty string is returned.
+func (p AddressPolicy) SelectPublicHostPort(hps []HostPort) string {
+	index := p.bestAddressIndex(len(hps), func(i int) Address {
+		return hps[i].Address
+	}, publicMatch, false)
+	if index < 0 {
+		return ""
+	}
+	return hps[index].NetAddr()
+}
+
+// SelectInternalAddress picks one address from a slice that can be
+// used as an endpoint for juju internal communication. If there are
+// no suitable addresses, the empty string is returned.
+func (p AddressPolicy) SelectInternalAddress(addresses []Address, machineLocal bool) string {
+	index := p.bestAddressIndex(len(addresses), func(i int) Address {
+		return addresses[i]
+	}, internalAddressMatcher(machineLocal), true)
+	if index < 0 {
+		return ""
+	}
+	return addresses[index].Value
+}
+
+// SelectInternalHostPort picks one HostPort from a slice that can be
+// used as an endpoint for juju internal communication and returns it
+// in its NetAddr form. If there are no suitable addresses, the empty
+// string is returned.
+func (p AddressPolicy) SelectInternalHostPort(hps []HostPort, machineLocal bool) string {
+	index := p.bestAddressIndex(len(hps), func(i int) Address {
+		return hps[i].Address
+	}, internalAddressMatcher(machineLocal), true)
+	if index < 0 {
+		return ""
+	}
+	return hps[index].NetAddr()
+}
+
+// FilterHostPorts returns the HostPorts from the given slice which
+// may be selected under the policy, sorted so that those in the
+// preferred subnets come first, in the order of the subnets, and
+// otherwise by their sortOrder. The given slice is not modified.
+func (p AddressPolicy) FilterHostPorts(hps []HostPort) []HostPort {
+	result := make([]HostPort, 0, len(hps))
+	for _, hp := range hps {
+		if p.Family.allows(hp.Type) {
+			result = append(result, hp)
+		}
+	}
+	SortHostPorts(result, p.Family.PreferIPv6())
+	if len(p.PreferredSubnets) > 0 {
+		subnets := parseSubnets(p.PreferredSubnets)
+		sort.Stable(hostPortsBySubnet{result, subnets})
+	}
+	return result
+}
+
+// bestAddressIndex returns the index of the best address as
+// determined by bestAddressIndex, considering only addresses allowed
+// by the policy's family. If preferSubnets is true, only addresses in
+// each of the preferred subnets are considered in turn before
+// considering all addresses.
+func (p AddressPolicy) bestAddressIndex(numAddr int, getAddr func(i int) Address, match func(addr Address, preferIPv6 bool) scopeMatch, preferSubnets bool) int {
+	allowedMatch := func(addr Address, preferIPv6 bool) scopeMatch {
+		if !p.Family.allows(addr.Type) {
+			return invalidScope
+		}
+		return match(addr, preferIPv6)
+	}
+	preferIPv6 := p.Family.PreferIPv6()
+	if !preferSubnets {
+		return bestAddressIndex(numAddr, preferIPv6, getAddr, allowedMatch)
+	}
+	for _, subnet := range parseSubnets(p.PreferredSubnets) {
+		subnet := subnet
+		index := bestAddressIndex(numAddr, preferIPv6, getAddr, func(addr Address, preferIPv6 bool) scopeMatch {
+			if !subnetContains(subnet, addr) {
+				return invalidScope
+			}
+			return allowedMatch(addr, preferIPv6)
+		})
+		if index >= 0 {
+			return index
+		}
+	}
+	return bestAddressIndex(numAddr, preferIPv6, getAddr, allowedMatch)
+}
+
+// parseSubnets parses the given CIDRs, ignoring any that are invalid.
+func parseSubnets(cidrs []string) []*net.IPNet {
+	subnets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, subnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			logger.Debugf("ignoring invalid preferred subnet %q: %v", cidr, err)
+			continue
+		}
+		subnets = append(subnets, subnet)
+	}
+	return subnets
+}
+
+func subnetContains(subnet *net.IPNet, addr Address) bool {
+	ip := net.ParseIP(addr.Value)
+	return ip != nil && subnet.Contains(ip)
+}
+
+// hostPortsBySubnet sorts HostPorts by the index of the first of the
+// subnets which contains them. Those not in any of the subnets come
+// last.
+type hostPortsBySubnet struct {
+	hps     []HostPort
+	subnets []*net.IPNet
+}
+
+func (s hostPortsBySubnet) Len() int      { return len(s.hps) }
+func (s hostPortsBySubnet) Swap(i, j int) { s.hps[i], s.hps[j] = s.hps[j], s.hps[i] }
+func (s hostPortsBySubnet) Less(i, j int) bool {
+	return s.rank(s.hps[i].Address) < s.rank(s.hps[j].Address)
+}
+
+func (s hostPortsBySubnet) rank(addr Address) int {
+	for i, subnet := range s.subnets {
+		if subnetContains(subnet, addr) {
+			return i
+		}
+	}
+	return len(s.subnets)
+}
@@ -0,0 +1,132 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package network_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/testing"
+)
+
+type PolicySuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&PolicySuite{})
+
+var policyTestAddresses = []network.Address{
+	network.NewScopedAddress("8.8.8.8", network.ScopePublic),
+	network.NewScopedAddress("2001:db8::1", network.ScopePublic),
+	network.NewScopedAddress("10.0.0.5", network.ScopeCloudLocal),
+	network.NewScopedAddress("10.20.0.5", network.ScopeCloudLocal),
+	network.NewScopedAddress("fc00::5", network.ScopeCloudLocal),
+}
+
+func (*PolicySuite) TestParseAddressFamilyPolicy(c *gc.C) {
+	for _, name := range []string{"ipv4-first", "ipv6-first", "ipv4-only", "ipv6-only"} {
+		family, err := network.ParseAddressFamilyPolicy(name)
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(family, gc.Equals, network.AddressFamilyPolicy(name))
+	}
+	_, err := network.ParseAddressFamilyPolicy("ipv6")
+	c.Assert(err, gc.ErrorMatches, `address family policy "ipv6" not valid`)
+	c.Assert(err, jc.Satisfies, errors.IsNotValid)
+}
+
+func (*PolicySuite) TestFamilyPreferIPv6(c *gc.C) {
+	c.Check(network.IPv4First.PreferIPv6(), jc.IsFalse)
+	c.Check(network.IPv4Only.PreferIPv6(), jc.IsFalse)
+	c.Check(network.IPv6First.PreferIPv6(), jc.IsTrue)
+	c.Check(network.IPv6Only.PreferIPv6(), jc.IsTrue)
+	c.Check(network.AddressFamilyPolicy("").PreferIPv6(), jc.IsFalse)
+}
+
+func (*PolicySuite) TestSelectByFamily(c *gc.C) {
+	for i, test := range []struct {
+		family   network.AddressFamilyPolicy
+		public   string
+		internal string
+	}{
+		{"", "8.8.8.8", "10.0.0.5"},
+		{network.IPv4First, "8.8.8.8", "10.0.0.5"},
+		{network.IPv6First, "2001:db8::1", "fc00::5"},
+		{network.IPv4Only, "8.8.8.8", "10.0.0.5"},
+		{network.IPv6Only, "2001:db8::1", "fc00::5"},
+	} {
+		c.Logf("test %d: %s", i, test.family)
+		policy := network.AddressPolicy{Family: test.family}
+		c.Check(policy.SelectPublicAddress(policyTestAddresses), gc.Equals, test.public)
+		c.Check(policy.SelectInternalAddress(policyTestAddresses, false), gc.Equals, test.internal)
+	}
+}
+
+func (*PolicySuite) TestSelectOnlyNeverFallsBackToOtherFamily(c *gc.C) {
+	ipv4 := network.NewAddresses("10.0.0.5")
+	ipv6 := network.NewAddresses("fc00::5")
+
+	policy := network.AddressPolicy{Family: network.IPv6First}
+	c.Check(policy.SelectInternalAddress(ipv4, false), gc.Equals, "10.0.0.5")
+
+	policy = network.AddressPolicy{Family: network.IPv6Only}
+	c.Check(policy.SelectInternalAddress(ipv4, false), gc.Equals, "")
+	c.Check(policy.SelectInternalHostPort(network.AddressesWithPort(ipv4, 17070), false), gc.Equals, "")
+
+	policy = network.AddressPolicy{Family: network.IPv4Only}
+	c.Check(policy.SelectInternalAddress(ipv6, false), gc.Equals, "")
+	c.Check(policy.SelectPublicAddress(ipv6), gc.Equals, "")
+}
+
+func (*PolicySuite) TestSelectPreferredSubnets(c *gc.C) {
+	policy := network.AddressPolicy{
+		Family:           network.IPv4First,
+		PreferredSubnets: []string{"192.168.0.0/24", "10.20.0.0/16"},
+	}
+	c.Check(policy.SelectInternalAddress(policyTestAddresses, false), gc.Equals, "10.20.0.5")
+	// Preferred subnets only apply to internal addresses.
+	c.Check(policy.SelectPublicAddress(policyTestAddresses), gc.Equals, "8.8.8.8")
+
+	// The family policy still applies to preferred subnets.
+	policy.Family = network.IPv6Only
+	c.Check(policy.SelectInternalAddress(policyTestAddresses, false), gc.Equals, "fc00::5")
+
+	// With no addresses in the preferred subnets, the usual
+	// selection applies.
+	policy = network.AddressPolicy{PreferredSubnets: []string{"172.16.0.0/12"}}
+	c.Check(policy.SelectInternalAddress(policyTestAddresses, false), gc.Equals, "10.0.0.5")
+}
+
+func (*PolicySuite) TestFilterHostPorts(c *gc.C) {
+	hps := network.NewHostPorts(1234, "testing.invalid", "10.0.0.1", "::1", "10.20.0.1")
+	original := append([]network.HostPort{}, hps...)
+
+	policy := network.AddressPolicy{Family: network.IPv4Only}
+	c.Check(policy.FilterHostPorts(hps), jc.DeepEquals,
+		network.NewHostPorts(1234, "testing.invalid", "10.0.0.1", "10.20.0.1"),
+	)
+	policy = network.AddressPolicy{Family: network.IPv6Only}
+	c.Check(policy.FilterHostPorts(hps), jc.DeepEquals,
+		network.NewHostPorts(1234, "testing.invalid", "::1"),
+	)
+	policy = network.AddressPolicy{
+		Family:           network.IPv4First,
+		PreferredSubnets: []string{"10.20.0.0/16"},
+	}
+	c.Check(policy.FilterHostPorts(hps), jc.DeepEquals,
+		network.NewHostPorts(1234, "10.20.0.1", "testing.invalid", "10.0.0.1", "::1"),
+	)
+	c.Check(hps, jc.DeepEquals, original)
+}
+
+func (*PolicySuite) TestGlobalAddressPolicy(c *gc.C) {
+	c.Check(network.CurrentAddressPolicy(), jc.DeepEquals, network.AddressPolicy{Family: network.IPv4First})
+
+	network.SetAddressPolicy(network.AddressPolicy{Family: network.IPv6Only})
+	c.Check(network.SelectInternalAddress(policyTestAddresses, false), gc.Equals, "fc00::5")
+
+	network.ResetGlobalAddressPolicy()
+	c.Check(network.SelectInternalAddress(policyTestAddresses, false), gc.Equals, "10.0.0.5")
+}
@@ -5,6 +5,7 @@ package network
 
 import (
 	"fmt"
+	"net"
 	"sort"
 	"strconv"
 	"strings"
@@ -17,6 +18,11 @@ type PortRange struct {
 	FromPort int
 	ToPort   int
 	Protocol string
+
+	// SourceCIDR, if set, restricts access to the port range to
+	// addresses within the given CIDR. An empty SourceCIDR means
+	// the port range may be accessed from any address.
+	SourceCIDR string
 }
 
 // IsValid determines if the port range is valid.
@@ -39,22 +45,33 @@ func (p PortRange) Validate() error {
 	case p.ToPort < 1 || p.ToPort > 65535:
 		return err
 	}
+	if p.SourceCIDR != "" {
+		if _, _, err := net.ParseCIDR(p.SourceCIDR); err != nil {
+			return errors.Errorf("invalid source CIDR %q", p.SourceCIDR)
+		}
+	}
 	return nil
 }
 
 // ConflictsWith determines if the two port ranges conflict.
 func (a PortRange) ConflictsWith(b PortRange) bool {
-	if a.Protocol != b.Protocol {
+	if a.Protocol != b.Protocol || a.SourceCIDR != b.SourceCIDR {
 		return false
 	}
 	return a.ToPort >= b.FromPort && b.ToPort >= a.FromPort
 }
 
 func (p PortRange) String() string {
+	var s string
 	if p.FromPort == p.ToPort {
-		return fmt.Sprintf("%d/%s", p.FromPort, strings.ToLower(p.Protocol))
+		s = fmt.Sprintf("%d/%s", p.FromPort, strings.ToLower(p.Protocol))
+	} else {
+		s = fmt.Sprintf("%d-%d/%s", p.FromPort, p.ToPort, strings.ToLower(p.Protocol))
+	}
+	if p.SourceCIDR != "" {
+		s += " from " + p.SourceCIDR
 	}
-	return fmt.Sprintf("%d-%d/%s", p.FromPort, p.ToPort, strings.ToLower(p.Protocol))
+	return s
 }
 
 func (p PortRange) GoString() string {
@@ -74,14 +91,35 @@ func (p portRangeSlice) Less(i, j int) bool {
 	if p1.FromPort != p2.FromPort {
 		return p1.FromPort < p2.FromPort
 	}
-	return p1.ToPort < p2.ToPort
+	if p1.ToPort != p2.ToPort {
+		return p1.ToPort < p2.ToPort
+	}
+	return p1.SourceCIDR < p2.SourceCIDR
 }
 
-// SortPortRanges sorts the given ports, first by protocol, then by number.
+// SortPortRanges sorts the given ports, first by protocol, then by
+// number, then by source CIDR.
 func SortPortRanges(portRanges []PortRange) {
 	sort.Sort(portRangeSlice(portRanges))
 }
 
+// RestrictPortRanges returns a copy of the given port ranges for each
+// of the supplied source CIDRs. If no CIDRs are supplied, the port
+// ranges are returned unrestricted.
+func RestrictPortRanges(portRanges []PortRange, cidrs []string) []PortRange {
+	if len(cidrs) == 0 {
+		return portRanges
+	}
+	result := make([]PortRange, 0, len(portRanges)*len(cidrs))
+	for _, portRange := range portRanges {
+		for _, cidr := range cidrs {
+			portRange.SourceCIDR = cidr
+			result = append(result, portRange)
+		}
+	}
+	return result
+}
+
 // CollapsePorts collapses a slice of ports into port ranges.
 //
 // NOTE(dimitern): This is deprecated and should be removed when
@@ -91,7 +129,7 @@ func CollapsePorts(ports []Port) (result []PortRange) {
 	// First, convert ports to ranges, then sort them.
 	var portRanges []PortRange
 	for _, p := range ports {
-		portRanges = append(portRanges, PortRange{p.Number, p.Number, p.Protocol})
+		portRanges = append(portRanges, PortRange{FromPort: p.Number, ToPort: p.Number, Protocol: p.Protocol})
 	}
 	SortPortRanges(portRanges)
 	fromPort := 0
@@ -25,44 +25,49 @@ func (*PortRangeSuite) TestConflictsWith(c *gc.C) {
 		expectConflict bool
 	}{{
 		"identical ports",
-		network.PortRange{80, 80, "TCP"},
-		network.PortRange{80, 80, "TCP"},
+		network.PortRange{FromPort: 80, ToPort: 80, Protocol: "TCP"},
+		network.PortRange{FromPort: 80, ToPort: 80, Protocol: "TCP"},
 		true,
 	}, {
 		"different ports",
-		network.PortRange{80, 80, "TCP"},
-		network.PortRange{90, 90, "TCP"},
+		network.PortRange{FromPort: 80, ToPort: 80, Protocol: "TCP"},
+		network.PortRange{FromPort: 90, ToPort: 90, Protocol: "TCP"},
 		false,
 	}, {
 		"touching ranges",
-		network.PortRange{100, 200, "TCP"},
-		network.PortRange{201, 240, "TCP"},
+		network.PortRange{FromPort: 100, ToPort: 200, Protocol: "TCP"},
+		network.PortRange{FromPort: 201, ToPort: 240, Protocol: "TCP"},
 		false,
 	}, {
 		"touching ranges with overlap",
-		network.PortRange{100, 200, "TCP"},
-		network.PortRange{200, 240, "TCP"},
+		network.PortRange{FromPort: 100, ToPort: 200, Protocol: "TCP"},
+		network.PortRange{FromPort: 200, ToPort: 240, Protocol: "TCP"},
 		true,
 	}, {
 		"different protocols",
-		network.PortRange{80, 80, "UDP"},
-		network.PortRange{80, 80, "TCP"},
+		network.PortRange{FromPort: 80, ToPort: 80, Protocol: "UDP"},
+		network.PortRange{FromPort: 80, ToPort: 80, Protocol: "TCP"},
 		false,
 	}, {
 		"outside range",
-		network.PortRange{100, 200, "TCP"},
-		network.PortRange{80, 80, "TCP"},
+		network.PortRange{FromPort: 100, ToPort: 200, Protocol: "TCP"},
+		network.PortRange{FromPort: 80, ToPort: 80, Protocol: "TCP"},
 		false,
 	}, {
 		"overlap end",
-		network.PortRange{100, 200, "TCP"},
-		network.PortRange{80, 120, "TCP"},
+		network.PortRange{FromPort: 100, ToPort: 200, Protocol: "TCP"},
+		network.PortRange{FromPort: 80, ToPort: 120, Protocol: "TCP"},
 		true,
 	}, {
 		"complete overlap",
-		network.PortRange{100, 200, "TCP"},
-		network.PortRange{120, 140, "TCP"},
+		network.PortRange{FromPort: 100, ToPort: 200, Protocol: "TCP"},
+		network.PortRange{FromPort: 120, ToPort: 140, Protocol: "TCP"},
 		true,
+	}, {
+		"different source CIDRs",
+		network.PortRange{FromPort: 80, ToPort: 80, Protocol: "TCP", SourceCIDR: "10.0.0.0/8"},
+		network.PortRange{FromPort: 80, ToPort: 80, Protocol: "TCP"},
+		false,
 	}}
 
 	for i, t := range testCases {
@@ -74,25 +79,30 @@ func (*PortRangeSuite) TestConflictsWith(c *gc.C) {
 
 func (*PortRangeSuite) TestStrings(c *gc.C) {
 	c.Assert(
-		network.PortRange{80, 80, "TCP"}.String(),
+		network.PortRange{FromPort: 80, ToPort: 80, Protocol: "TCP"}.String(),
 		gc.Equals,
 		"80/tcp",
 	)
 	c.Assert(
-		network.PortRange{80, 80, "TCP"}.GoString(),
+		network.PortRange{FromPort: 80, ToPort: 80, Protocol: "TCP"}.GoString(),
 		gc.Equals,
 		"80/tcp",
 	)
 	c.Assert(
-		network.PortRange{80, 100, "TCP"}.String(),
+		network.PortRange{FromPort: 80, ToPort: 100, Protocol: "TCP"}.String(),
 		gc.Equals,
 		"80-100/tcp",
 	)
 	c.Assert(
-		network.PortRange{80, 100, "TCP"}.GoString(),
+		network.PortRange{FromPort: 80, ToPort: 100, Protocol: "TCP"}.GoString(),
 		gc.Equals,
 		"80-100/tcp",
 	)
+	c.Assert(
+		network.PortRange{FromPort: 80, ToPort: 100, Protocol: "TCP", SourceCIDR: "10.0.0.0/8"}.String(),
+		gc.Equals,
+		"80-100/tcp from 10.0.0.0/8",
+	)
 }
 
 func (*PortRangeSuite) TestValidate(c *gc.C) {
@@ -102,44 +112,52 @@ func (*PortRangeSuite) TestValidate(c *gc.C) {
 		expected string
 	}{{
 		"single valid port",
-		network.PortRange{80, 80, "tcp"},
+		network.PortRange{FromPort: 80, ToPort: 80, Protocol: "tcp"},
 		"",
 	}, {
 		"valid port range",
-		network.PortRange{80, 90, "tcp"},
+		network.PortRange{FromPort: 80, ToPort: 90, Protocol: "tcp"},
 		"",
 	}, {
 		"valid udp port range",
-		network.PortRange{80, 90, "UDP"},
+		network.PortRange{FromPort: 80, ToPort: 90, Protocol: "UDP"},
 		"",
 	}, {
 		"invalid port range boundaries",
-		network.PortRange{90, 80, "tcp"},
+		network.PortRange{FromPort: 90, ToPort: 80, Protocol: "tcp"},
 		"invalid port range 90-80/tcp",
 	}, {
 		"both FromPort and ToPort too large",
-		network.PortRange{88888, 99999, "tcp"},
+		network.PortRange{FromPort: 88888, ToPort: 99999, Protocol: "tcp"},
 		"invalid port range 88888-99999/tcp",
 	}, {
 		"FromPort too large",
-		network.PortRange{88888, 65535, "tcp"},
+		network.PortRange{FromPort: 88888, ToPort: 65535, Protocol: "tcp"},
 		"invalid port range 88888-65535/tcp",
 	}, {
 		"FromPort too small",
-		network.PortRange{0, 80, "tcp"},
+		network.PortRange{FromPort: 0, ToPort: 80, Protocol: "tcp"},
 		"invalid port range 0-80/tcp",
 	}, {
 		"ToPort too large",
-		network.PortRange{1, 99999, "tcp"},
+		network.PortRange{FromPort: 1, ToPort: 99999, Protocol: "tcp"},
 		"invalid port range 1-99999/tcp",
 	}, {
 		"both ports 0",
-		network.PortRange{0, 0, "tcp"},
+		network.PortRange{FromPort: 0, ToPort: 0, Protocol: "tcp"},
 		"invalid port range 0-0/tcp",
 	}, {
 		"invalid protocol",
-		network.PortRange{80, 80, "some protocol"},
+		network.PortRange{FromPort: 80, ToPort: 80, Protocol: "some protocol"},
 		`invalid protocol "some protocol", expected "tcp" or "udp"`,
+	}, {
+		"valid source CIDR",
+		network.PortRange{FromPort: 80, ToPort: 80, Protocol: "tcp", SourceCIDR: "10.0.0.0/8"},
+		"",
+	}, {
+		"invalid source CIDR",
+		network.PortRange{FromPort: 80, ToPort: 80, Protocol: "tcp", SourceCIDR: "10.0.0.0"},
+		`invalid source CIDR "10.0.0.0"`,
 	}}
 
 	for i, t := range testCases {
@@ -154,19 +172,33 @@ func (*PortRangeSuite) TestValidate(c *gc.C) {
 
 func (*PortRangeSuite) TestSortPortRanges(c *gc.C) {
 	ranges := []network.PortRange{
-		{10, 100, "udp"},
-		{80, 90, "tcp"},
-		{80, 80, "tcp"},
+		{FromPort: 10, ToPort: 100, Protocol: "udp"},
+		{FromPort: 80, ToPort: 90, Protocol: "tcp"},
+		{FromPort: 80, ToPort: 80, Protocol: "tcp"},
 	}
 	expected := []network.PortRange{
-		{80, 80, "tcp"},
-		{80, 90, "tcp"},
-		{10, 100, "udp"},
+		{FromPort: 80, ToPort: 80, Protocol: "tcp"},
+		{FromPort: 80, ToPort: 90, Protocol: "tcp"},
+		{FromPort: 10, ToPort: 100, Protocol: "udp"},
 	}
 	network.SortPortRanges(ranges)
 	c.Assert(ranges, gc.DeepEquals, expected)
 }
 
+func (*PortRangeSuite) TestRestrictPortRanges(c *gc.C) {
+	ranges := []network.PortRange{
+		{FromPort: 80, ToPort: 80, Protocol: "tcp"},
+		{FromPort: 10, ToPort: 100, Protocol: "udp"},
+	}
+	c.Assert(network.RestrictPortRanges(ranges, nil), gc.DeepEquals, ranges)
+	c.Assert(network.RestrictPortRanges(ranges, []string{"10.0.0.0/8", "192.168.1.0/24"}), gc.DeepEquals, []network.PortRange{
+		{FromPort: 80, ToPort: 80, Protocol: "tcp", SourceCIDR: "10.0.0.0/8"},
+		{FromPort: 80, ToPort: 80, Protocol: "tcp", SourceCIDR: "192.168.1.0/24"},
+		{FromPort: 10, ToPort: 100, Protocol: "udp", SourceCIDR: "10.0.0.0/8"},
+		{FromPort: 10, ToPort: 100, Protocol: "udp", SourceCIDR: "192.168.1.0/24"},
+	})
+}
+
 func (*PortRangeSuite) TestCollapsePorts(c *gc.C) {
 	testCases := []struct {
 		about    string
@@ -175,39 +207,39 @@ func (*PortRangeSuite) TestCollapsePorts(c *gc.C) {
 	}{{
 		"single port",
 		[]network.Port{{"tcp", 80}},
-		[]network.PortRange{{80, 80, "tcp"}},
+		[]network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}},
 	}, {
 		"continuous port range (increasing)",
 		[]network.Port{{"tcp", 80}, {"tcp", 81}, {"tcp", 82}, {"tcp", 83}},
-		[]network.PortRange{{80, 83, "tcp"}},
+		[]network.PortRange{{FromPort: 80, ToPort: 83, Protocol: "tcp"}},
 	}, {
 		"continuous port range (decreasing)",
 		[]network.Port{{"tcp", 83}, {"tcp", 82}, {"tcp", 81}, {"tcp", 80}},
-		[]network.PortRange{{80, 83, "tcp"}},
+		[]network.PortRange{{FromPort: 80, ToPort: 83, Protocol: "tcp"}},
 	}, {
 		"non-continuous port range (increasing)",
 		[]network.Port{{"tcp", 80}, {"tcp", 81}, {"tcp", 82}, {"tcp", 84}, {"tcp", 85}},
-		[]network.PortRange{{80, 82, "tcp"}, {84, 85, "tcp"}},
+		[]network.PortRange{{FromPort: 80, ToPort: 82, Protocol: "tcp"}, {FromPort: 84, ToPort: 85, Protocol: "tcp"}},
 	}, {
 		"non-continuous port range (decreasing)",
 		[]network.Port{{"tcp", 85}, {"tcp", 84}, {"tcp", 82}, {"tcp", 81}, {"tcp", 80}},
-		[]network.PortRange{{80, 82, "tcp"}, {84, 85, "tcp"}},
+		[]network.PortRange{{FromPort: 80, ToPort: 82, Protocol: "tcp"}, {FromPort: 84, ToPort: 85, Protocol: "tcp"}},
 	}, {
 		"alternating tcp / udp ports (increasing)",
 		[]network.Port{{"tcp", 80}, {"udp", 81}, {"tcp", 82}, {"udp", 83}, {"tcp", 84}},
-		[]network.PortRange{{80, 80, "tcp"}, {82, 82, "tcp"}, {84, 84, "tcp"}, {81, 81, "udp"}, {83, 83, "udp"}},
+		[]network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}, {FromPort: 82, ToPort: 82, Protocol: "tcp"}, {FromPort: 84, ToPort: 84, Protocol: "tcp"}, {FromPort: 81, ToPort: 81, Protocol: "udp"}, {FromPort: 83, ToPort: 83, Protocol: "udp"}},
 	}, {
 		"alternating tcp / udp ports (decreasing)",
 		[]network.Port{{"tcp", 84}, {"udp", 83}, {"tcp", 82}, {"udp", 81}, {"tcp", 80}},
-		[]network.PortRange{{80, 80, "tcp"}, {82, 82, "tcp"}, {84, 84, "tcp"}, {81, 81, "udp"}, {83, 83, "udp"}},
+		[]network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}, {FromPort: 82, ToPort: 82, Protocol: "tcp"}, {FromPort: 84, ToPort: 84, Protocol: "tcp"}, {FromPort: 81, ToPort: 81, Protocol: "udp"}, {FromPort: 83, ToPort: 83, Protocol: "udp"}},
 	}, {
 		"non-continuous port range (udp vs tcp - increasing)",
 		[]network.Port{{"tcp", 80}, {"tcp", 81}, {"tcp", 82}, {"udp", 84}, {"tcp", 83}},
-		[]network.PortRange{{80, 83, "tcp"}, {84, 84, "udp"}},
+		[]network.PortRange{{FromPort: 80, ToPort: 83, Protocol: "tcp"}, {FromPort: 84, ToPort: 84, Protocol: "udp"}},
 	}, {
 		"non-continuous port range (udp vs tcp - decreasing)",
 		[]network.Port{{"tcp", 83}, {"udp", 84}, {"tcp", 82}, {"tcp", 81}, {"tcp", 80}},
-		[]network.PortRange{{80, 83, "tcp"}, {84, 84, "udp"}},
+		[]network.PortRange{{FromPort: 80, ToPort: 83, Protocol: "tcp"}, {FromPort: 84, ToPort: 84, Protocol: "udp"}},
 	}}
 	for i, t := range testCases {
 		c.Logf("test %d: %s", i, t.about)
@@ -167,9 +167,9 @@ func (s *PortSetSuite) TestPortSetRemoveRanges(c *gc.C) {
 	portSet := network.NewPortSet(s.portRange1)
 	portSet.RemoveRanges(
 		s.portRange2,
-		network.PortRange{7000, 8049, "tcp"},
-		network.PortRange{8051, 8074, "tcp"},
-		network.PortRange{8080, 9000, "tcp"},
+		network.PortRange{FromPort: 7000, ToPort: 8049, Protocol: "tcp"},
+		network.PortRange{FromPort: 8051, ToPort: 8074, Protocol: "tcp"},
+		network.PortRange{FromPort: 8080, ToPort: 9000, Protocol: "tcp"},
 	)
 
 	s.checkPortSetTCP(c, portSet, 8050, 8075, 8076, 8077, 8078, 8079)
@@ -191,7 +191,7 @@ func (s *PortSetSuite) TestPortSetContainsNotFound(c *gc.C) {
 
 func (s *PortSetSuite) TestPortSetContainsRangesSingleMatch(c *gc.C) {
 	portSet := network.NewPortSet(s.portRange1)
-	isfound := portSet.ContainsRanges(network.PortRange{8080, 8080, "tcp"})
+	isfound := portSet.ContainsRanges(network.PortRange{FromPort: 8080, ToPort: 8080, Protocol: "tcp"})
 
 	c.Assert(isfound, jc.IsTrue)
 }
@@ -205,7 +205,7 @@ func (s *PortSetSuite) TestPortSetContainsRangesSingleNoMatch(c *gc.C) {
 
 func (s *PortSetSuite) TestPortSetContainsRangesOverlapping(c *gc.C) {
 	portSet := network.NewPortSet(s.portRange1)
-	isfound := portSet.ContainsRanges(network.PortRange{7000, 8049, "tcp"})
+	isfound := portSet.ContainsRanges(network.PortRange{FromPort: 7000, ToPort: 8049, Protocol: "tcp"})
 
 	c.Assert(isfound, jc.IsFalse)
 }
@@ -0,0 +1,42 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package network
+
+import "regexp"
+
+var validSpaceName = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// IsValidSpaceName returns whether name is a valid space name. Space
+// names consist of lower case letters and digits, optionally
+// separated by single hyphens.
+func IsValidSpaceName(name string) bool {
+	return validSpaceName.MatchString(name)
+}
+
+// SelectAddressInSubnets picks one address from a slice that lies
+// within one of the given subnet CIDRs and can be used as an endpoint
+// for juju internal communication, according to the global address
+// policy. If there are no such addresses, the empty string is
+// returned.
+func SelectAddressInSubnets(addresses []Address, cidrs []string) string {
+	return CurrentAddressPolicy().SelectAddressInSubnets(addresses, cidrs)
+}
+
+// SelectAddressInSubnets picks one address from a slice that lies
+// within one of the given subnet CIDRs and can be used as an endpoint
+// for juju internal communication. If there are no such addresses,
+// the empty string is returned.
+func (p AddressPolicy) SelectAddressInSubnets(addresses []Address, cidrs []string) string {
+	subnets := parseSubnets(cidrs)
+	var inSubnets []Address
+	for _, addr := range addresses {
+		for _, subnet := range subnets {
+			if subnetContains(subnet, addr) {
+				inSubnets = append(inSubnets, addr)
+				break
+			}
+		}
+	}
+	return p.SelectInternalAddress(inSubnets, false)
+}
@@ -0,0 +1,40 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package network_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/testing"
+)
+
+type SpaceSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&SpaceSuite{})
+
+func (*SpaceSuite) TestIsValidSpaceName(c *gc.C) {
+	for _, name := range []string{"db", "internal", "dmz-2", "0-a-1"} {
+		c.Check(network.IsValidSpaceName(name), jc.IsTrue, gc.Commentf("%q", name))
+	}
+	for _, name := range []string{"", "DB", "-db", "db-", "db--internal", "db_internal", "db.internal"} {
+		c.Check(network.IsValidSpaceName(name), jc.IsFalse, gc.Commentf("%q", name))
+	}
+}
+
+func (*SpaceSuite) TestSelectAddressInSubnets(c *gc.C) {
+	addresses := []network.Address{
+		network.NewScopedAddress("8.8.8.8", network.ScopePublic),
+		network.NewScopedAddress("10.0.0.5", network.ScopeCloudLocal),
+		network.NewScopedAddress("10.20.0.5", network.ScopeCloudLocal),
+	}
+	cidrs := []string{"10.20.0.0/16", "192.168.1.0/24"}
+	c.Assert(network.SelectAddressInSubnets(addresses, cidrs), gc.Equals, "10.20.0.5")
+	c.Assert(network.SelectAddressInSubnets(addresses, []string{"8.8.8.0/24"}), gc.Equals, "8.8.8.8")
+	c.Assert(network.SelectAddressInSubnets(addresses, []string{"172.16.0.0/12"}), gc.Equals, "")
+	c.Assert(network.SelectAddressInSubnets(addresses, nil), gc.Equals, "")
+}
@@ -6,6 +6,8 @@ package all
 // Register all the available providers.
 import (
 	_ "github.com/juju/juju/provider/azure"
+	_ "github.com/juju/juju/provider/cloudstack"
+	_ "github.com/juju/juju/provider/containerhost"
 	_ "github.com/juju/juju/provider/ec2"
 	_ "github.com/juju/juju/provider/gce"
 	_ "github.com/juju/juju/provider/joyent"
@@ -566,6 +566,7 @@ func (env *azureEnviron) selectInstanceTypeAndImage(constraint *instances.Instan
 var unsupportedConstraints = []string{
 	constraints.CpuPower,
 	constraints.Tags,
+	constraints.Spaces,
 }
 
 // ConstraintsValidator is defined on the Environs interface.
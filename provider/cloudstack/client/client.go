@@ -0,0 +1,309 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package client implements a client for the subset of the Apache
+// CloudStack HTTP API used by the cloudstack provider.
+//
+// Every request is signed with the account's secret key as described
+// in the CloudStack developer's guide, and responses are requested in
+// JSON. Asynchronous commands are waited for by polling the result of
+// their job.
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+	"github.com/juju/utils"
+)
+
+var logger = loggo.GetLogger("juju.provider.cloudstack.client")
+
+// Job statuses, as reported by queryAsyncJobResult.
+const (
+	JobPending   = 0
+	JobSucceeded = 1
+	JobFailed    = 2
+)
+
+// Error codes returned by the API.
+const (
+	// ErrorParamError is returned when a request's parameters are
+	// not valid, including when they refer to an entity which does
+	// not exist.
+	ErrorParamError = 431
+
+	// ErrorInternal is returned when the server fails to perform
+	// a request.
+	ErrorInternal = 530
+)
+
+// JobPollStrategy determines how often, and for how long, the result
+// of an asynchronous job is polled for.
+var JobPollStrategy = utils.AttemptStrategy{
+	Total: 10 * time.Minute,
+	Delay: 2 * time.Second,
+}
+
+// Config holds the information needed to connect to a CloudStack API
+// endpoint.
+type Config struct {
+	// URL is the URL of the API endpoint, usually of the form
+	// http://<management-server>:8080/client/api.
+	URL string
+
+	// APIKey is the API key of the account to act as.
+	APIKey string
+
+	// SecretKey is the secret key of the account, used to sign
+	// requests.
+	SecretKey string
+
+	// HostnameVerification determines whether the endpoint's TLS
+	// certificate is checked against its hostname.
+	HostnameVerification utils.SSLHostnameVerification
+}
+
+// Validate checks that the configuration is complete and that the
+// URL is well formed.
+func (cfg Config) Validate() error {
+	if cfg.URL == "" {
+		return errors.NotValidf("empty API URL")
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return errors.NotValidf("API URL %q", cfg.URL)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return errors.NotValidf("API URL %q", cfg.URL)
+	}
+	if cfg.APIKey == "" {
+		return errors.NotValidf("empty API key")
+	}
+	if cfg.SecretKey == "" {
+		return errors.NotValidf("empty secret key")
+	}
+	return nil
+}
+
+// Error holds an error returned by the API.
+type Error struct {
+	// Command is the command which failed.
+	Command string
+
+	// Code is the CloudStack error code.
+	Code int
+
+	// Text is the error message returned by the server.
+	Text string
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s failed: %s (error code %d)", e.Command, e.Text, e.Code)
+}
+
+// IsParamError reports whether the cause of the given error is an
+// API error reporting invalid parameters, which is how the API
+// reports references to entities which do not exist.
+func IsParamError(err error) bool {
+	apiErr, ok := errors.Cause(err).(*Error)
+	return ok && apiErr.Code == ErrorParamError
+}
+
+// Client makes requests to a CloudStack API endpoint.
+type Client struct {
+	config Config
+	http   *http.Client
+}
+
+// New returns a client which makes requests to the endpoint in the
+// given configuration.
+func New(cfg Config) *Client {
+	return &Client{
+		config: cfg,
+		http:   utils.GetHTTPClient(cfg.HostnameVerification),
+	}
+}
+
+// Sign returns the signature of a request with the given parameters,
+// made by the account with the given secret key. The parameters must
+// not include the signature itself.
+func Sign(params url.Values, secretKey string) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Sort(byLowerCase(keys))
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key + "=" + queryEscape(params.Get(key))
+	}
+	mac := hmac.New(sha1.New, []byte(secretKey))
+	mac.Write([]byte(strings.ToLower(strings.Join(parts, "&"))))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// queryEscape escapes the given value as CloudStack does when
+// checking signatures, encoding spaces as %20 rather than +.
+func queryEscape(value string) string {
+	return strings.Replace(url.QueryEscape(value), "+", "%20", -1)
+}
+
+type byLowerCase []string
+
+func (b byLowerCase) Len() int           { return len(b) }
+func (b byLowerCase) Less(i, j int) bool { return strings.ToLower(b[i]) < strings.ToLower(b[j]) }
+func (b byLowerCase) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+// requestBody returns the signed, form-encoded parameters of a
+// request for the given command with the given parameters.
+func (c *Client) requestBody(command string, params url.Values) string {
+	signed := url.Values{}
+	for key, values := range params {
+		signed[key] = values
+	}
+	signed.Set("command", command)
+	signed.Set("response", "json")
+	signed.Set("apiKey", c.config.APIKey)
+	signature := Sign(signed, c.config.SecretKey)
+
+	keys := make([]string, 0, len(signed))
+	for key := range signed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys)+1)
+	for _, key := range keys {
+		parts = append(parts, key+"="+queryEscape(signed.Get(key)))
+	}
+	parts = append(parts, "signature="+queryEscape(signature))
+	return strings.Join(parts, "&")
+}
+
+// call makes a request for the given command and unmarshals the
+// response into result, if it is not nil.
+//
+// Requests are always POSTed: the API limits the size of user data
+// sent in a query string to 2KB, far less than cloud-init needs,
+// while it accepts up to 32KB in a request body.
+func (c *Client) call(command string, params url.Values, result interface{}) error {
+	logger.Tracef("calling %s %v", command, params)
+	resp, err := c.http.Post(
+		c.config.URL,
+		"application/x-www-form-urlencoded",
+		strings.NewReader(c.requestBody(command, params)),
+	)
+	if err != nil {
+		return errors.Annotatef(err, "cannot call %s", command)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Annotatef(err, "cannot read %s response", command)
+	}
+	content, err := unwrapResponse(command, body)
+	if resp.StatusCode != http.StatusOK {
+		return apiError(command, resp.StatusCode, content)
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(content, result); err != nil {
+		return errors.Annotatef(err, "cannot decode %s response", command)
+	}
+	return nil
+}
+
+// unwrapResponse returns the content of a response to the given
+// command, which the API wraps in an object with a single field
+// named after the command.
+func unwrapResponse(command string, body []byte) (json.RawMessage, error) {
+	var wrapper map[string]json.RawMessage
+	if err := json.Unmarshal(body, &wrapper); err != nil {
+		return nil, errors.Annotatef(err, "cannot decode %s response", command)
+	}
+	if content, ok := wrapper[strings.ToLower(command)+"response"]; ok {
+		return content, nil
+	}
+	// Some errors, such as authentication failures, are
+	// reported under a different name.
+	if len(wrapper) == 1 {
+		for _, content := range wrapper {
+			return content, nil
+		}
+	}
+	return nil, errors.Errorf("unexpected %s response: %s", command, body)
+}
+
+// apiError returns the error described by the given response content.
+func apiError(command string, status int, content []byte) error {
+	var errResp struct {
+		ErrorCode int    `json:"errorcode"`
+		ErrorText string `json:"errortext"`
+	}
+	if err := json.Unmarshal(content, &errResp); err != nil || errResp.ErrorText == "" {
+		return &Error{Command: command, Code: status, Text: http.StatusText(status)}
+	}
+	return &Error{Command: command, Code: errResp.ErrorCode, Text: errResp.ErrorText}
+}
+
+// asyncJob holds the response to an asynchronous command.
+type asyncJob struct {
+	JobID string `json:"jobid"`
+}
+
+// jobResult holds the response to queryAsyncJobResult.
+type jobResult struct {
+	JobID     string          `json:"jobid"`
+	JobStatus int             `json:"jobstatus"`
+	JobResult json.RawMessage `json:"jobresult"`
+}
+
+// callAsync makes a request for the given asynchronous command, waits
+// for its job to complete, and unmarshals the job result into result,
+// if it is not nil.
+func (c *Client) callAsync(command string, params url.Values, result interface{}) error {
+	var job asyncJob
+	if err := c.call(command, params, &job); err != nil {
+		return errors.Trace(err)
+	}
+	if job.JobID == "" {
+		return errors.Errorf("%s did not start a job", command)
+	}
+	for a := JobPollStrategy.Start(); a.Next(); {
+		var status jobResult
+		err := c.call("queryAsyncJobResult", url.Values{"jobid": {job.JobID}}, &status)
+		if err != nil {
+			return errors.Annotatef(err, "cannot query %s job %s", command, job.JobID)
+		}
+		switch status.JobStatus {
+		case JobPending:
+			continue
+		case JobFailed:
+			return apiError(command, ErrorInternal, status.JobResult)
+		}
+		if result == nil {
+			return nil
+		}
+		if err := json.Unmarshal(status.JobResult, result); err != nil {
+			return errors.Annotatef(err, "cannot decode %s result", command)
+		}
+		return nil
+	}
+	return errors.Errorf("timed out waiting for %s job %s", command, job.JobID)
+}
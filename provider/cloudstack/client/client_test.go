@@ -0,0 +1,281 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	gitjujutesting "github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/provider/cloudstack/client"
+	"github.com/juju/juju/provider/cloudstack/cloudstacktest"
+)
+
+const (
+	apiKey    = "test-api-key"
+	secretKey = "test-secret-key"
+)
+
+type clientSuite struct {
+	gitjujutesting.IsolationSuite
+
+	server *cloudstacktest.Server
+	client *client.Client
+	zone   client.Zone
+}
+
+var _ = gc.Suite(&clientSuite{})
+
+func (s *clientSuite) SetUpTest(c *gc.C) {
+	s.IsolationSuite.SetUpTest(c)
+	s.PatchValue(&client.JobPollStrategy, utils.AttemptStrategy{})
+	s.server = cloudstacktest.NewServer(apiKey, secretKey)
+	s.AddCleanup(func(*gc.C) { s.server.Close() })
+	s.client = client.New(client.Config{
+		URL:       s.server.URL(),
+		APIKey:    apiKey,
+		SecretKey: secretKey,
+	})
+	s.zone = s.server.AddZone(client.Zone{
+		Name:                  "zone-a",
+		AllocationState:       client.ZoneEnabled,
+		SecurityGroupsEnabled: true,
+	})
+}
+
+func (s *clientSuite) TestSign(c *gc.C) {
+	params := url.Values{
+		"apiKey":   {apiKey},
+		"command":  {"listZones"},
+		"name":     {"my zone"},
+		"response": {"json"},
+	}
+	c.Assert(client.Sign(params, secretKey), gc.Equals, "qyXn6QG2rduC9SGLXu5OYpr9XP4=")
+}
+
+func (s *clientSuite) TestConfigValidate(c *gc.C) {
+	valid := client.Config{
+		URL:       "https://cloud.example.com:8080/client/api",
+		APIKey:    apiKey,
+		SecretKey: secretKey,
+	}
+	c.Check(valid.Validate(), jc.ErrorIsNil)
+
+	for i, test := range []struct {
+		update func(*client.Config)
+		err    string
+	}{{
+		update: func(cfg *client.Config) { cfg.URL = "" },
+		err:    "empty API URL not valid",
+	}, {
+		update: func(cfg *client.Config) { cfg.URL = "cloud.example.com/client/api" },
+		err:    `API URL "cloud.example.com/client/api" not valid`,
+	}, {
+		update: func(cfg *client.Config) { cfg.APIKey = "" },
+		err:    "empty API key not valid",
+	}, {
+		update: func(cfg *client.Config) { cfg.SecretKey = "" },
+		err:    "empty secret key not valid",
+	}} {
+		c.Logf("test %d", i)
+		cfg := valid
+		test.update(&cfg)
+		c.Check(cfg.Validate(), gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *clientSuite) TestListZones(c *gc.C) {
+	zones, err := s.client.ListZones()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(zones, jc.DeepEquals, []client.Zone{s.zone})
+}
+
+func (s *clientSuite) TestBadCredentials(c *gc.C) {
+	cl := client.New(client.Config{
+		URL:       s.server.URL(),
+		APIKey:    apiKey,
+		SecretKey: "wrong",
+	})
+	_, err := cl.ListZones()
+	c.Assert(err, gc.ErrorMatches, "listZones failed: unable to verify user credentials and/or request signature \\(error code 401\\)")
+}
+
+func (s *clientSuite) TestCommandFailure(c *gc.C) {
+	s.server.FailCommand("listZones", "database unavailable")
+	_, err := s.client.ListZones()
+	c.Assert(err, gc.ErrorMatches, "listZones failed: database unavailable \\(error code 530\\)")
+	c.Assert(client.IsParamError(err), jc.IsFalse)
+}
+
+func (s *clientSuite) deploy(c *gc.C, name string) *client.VirtualMachine {
+	offering := s.server.AddServiceOffering(client.ServiceOffering{
+		Name:      "small",
+		CPUNumber: 1,
+		Memory:    1024,
+	})
+	template := s.server.AddTemplate(client.Template{
+		Name:       "ubuntu-trusty",
+		OSTypeName: "Ubuntu 14.04 (64-bit)",
+		ZoneID:     s.zone.ID,
+		IsReady:    true,
+		Hypervisor: "KVM",
+	})
+	vm, err := s.client.DeployVirtualMachine(client.DeployParams{
+		Name:              name,
+		ZoneID:            s.zone.ID,
+		ServiceOfferingID: offering.ID,
+		TemplateID:        template.ID,
+		UserData:          []byte("#cloud-config\n"),
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	return vm
+}
+
+func (s *clientSuite) TestDeployAndDestroy(c *gc.C) {
+	vm := s.deploy(c, "vm-0")
+	c.Assert(vm.Name, gc.Equals, "vm-0")
+	c.Assert(vm.State, gc.Equals, client.StateRunning)
+	c.Assert(vm.ZoneName, gc.Equals, "zone-a")
+	c.Assert(vm.NIC, gc.HasLen, 1)
+
+	vms, err := s.client.ListVirtualMachines(vm.ID)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(vms, jc.DeepEquals, []client.VirtualMachine{*vm})
+
+	err = s.client.DestroyVirtualMachine(vm.ID)
+	c.Assert(err, jc.ErrorIsNil)
+	vms, err = s.client.ListVirtualMachines("")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(vms, gc.HasLen, 0)
+}
+
+func (s *clientSuite) TestDeployLargeUserData(c *gc.C) {
+	offering := s.server.AddServiceOffering(client.ServiceOffering{
+		Name:      "small",
+		CPUNumber: 1,
+		Memory:    1024,
+	})
+	template := s.server.AddTemplate(client.Template{
+		Name:       "ubuntu-trusty",
+		OSTypeName: "Ubuntu 14.04 (64-bit)",
+		ZoneID:     s.zone.ID,
+		IsReady:    true,
+		Hypervisor: "KVM",
+	})
+	// User data of this size cannot be sent in a query string.
+	_, err := s.client.DeployVirtualMachine(client.DeployParams{
+		Name:              "vm-0",
+		ZoneID:            s.zone.ID,
+		ServiceOfferingID: offering.ID,
+		TemplateID:        template.ID,
+		UserData:          []byte(strings.Repeat("x", 16*1024)),
+	})
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *clientSuite) TestServerLimitsGETUserData(c *gc.C) {
+	params := url.Values{
+		"apiKey":   {apiKey},
+		"command":  {"deployVirtualMachine"},
+		"response": {"json"},
+		"userdata": {strings.Repeat("x", 2049)},
+	}
+	params.Set("signature", client.Sign(params, secretKey))
+	resp, err := http.Get(s.server.URL() + "?" + params.Encode())
+	c.Assert(err, jc.ErrorIsNil)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(resp.StatusCode, gc.Equals, http.StatusBadRequest)
+	c.Assert(string(body), jc.Contains, "user data is too long for an http GET request")
+}
+
+func (s *clientSuite) TestAsyncJobFailure(c *gc.C) {
+	_, err := s.client.DeployVirtualMachine(client.DeployParams{
+		Name:   "vm-0",
+		ZoneID: s.zone.ID,
+	})
+	c.Assert(err, gc.ErrorMatches, `deployVirtualMachine failed: unable to find service offering "" \(error code 431\)`)
+	c.Assert(client.IsParamError(err), jc.IsTrue)
+}
+
+func (s *clientSuite) TestSecurityGroupIngress(c *gc.C) {
+	group, err := s.client.CreateSecurityGroup("juju-group", "a group")
+	c.Assert(err, jc.ErrorIsNil)
+
+	group, err = s.client.AuthorizeSecurityGroupIngress(client.IngressParams{
+		SecurityGroupID: group.ID,
+		Protocol:        "tcp",
+		StartPort:       80,
+		EndPort:         81,
+		CIDR:            "0.0.0.0/0",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	group, err = s.client.AuthorizeSecurityGroupIngress(client.IngressParams{
+		SecurityGroupID: group.ID,
+		Protocol:        "icmp",
+		SourceGroup:     "juju-group",
+		SourceAccount:   group.Account,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(group.IngressRule, gc.HasLen, 2)
+	rule := group.IngressRule[0]
+	c.Check(rule.Protocol, gc.Equals, "tcp")
+	c.Check(rule.StartPort, gc.Equals, 80)
+	c.Check(rule.EndPort, gc.Equals, 81)
+	c.Check(rule.CIDR, gc.Equals, "0.0.0.0/0")
+	c.Check(group.IngressRule[1].SecurityGroupName, gc.Equals, "juju-group")
+
+	err = s.client.RevokeSecurityGroupIngress(rule.RuleID)
+	c.Assert(err, jc.ErrorIsNil)
+	groups, err := s.client.ListSecurityGroups("juju-group")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(groups, gc.HasLen, 1)
+	c.Assert(groups[0].IngressRule, gc.HasLen, 1)
+	c.Assert(groups[0].IngressRule[0].Protocol, gc.Equals, "icmp")
+
+	err = s.client.DeleteSecurityGroup("juju-group")
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.client.DeleteSecurityGroup("juju-group")
+	c.Assert(client.IsParamError(err), jc.IsTrue)
+}
+
+func (s *clientSuite) TestVolumes(c *gc.C) {
+	vm := s.deploy(c, "vm-0")
+	offering := s.server.AddDiskOffering(client.DiskOffering{
+		Name:         "custom",
+		IsCustomized: true,
+	})
+	vol, err := s.client.CreateVolume(client.VolumeParams{
+		Name:           "data",
+		ZoneID:         s.zone.ID,
+		DiskOfferingID: offering.ID,
+		Size:           10,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(vol.Size, gc.Equals, int64(10<<30))
+	c.Assert(vol.State, gc.Equals, client.VolumeAllocated)
+
+	vol, err = s.client.AttachVolume(vol.ID, vm.ID)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(vol.VirtualMachineID, gc.Equals, vm.ID)
+	c.Assert(vol.DeviceID, gc.Equals, 1)
+
+	err = s.client.DeleteVolume(vol.ID)
+	c.Assert(err, gc.ErrorMatches, "deleteVolume failed: volume data is attached .*")
+
+	err = s.client.DetachVolume(vol.ID)
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.client.DeleteVolume(vol.ID)
+	c.Assert(err, jc.ErrorIsNil)
+	vols, err := s.client.ListVolumes(vol.ID)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(vols, gc.HasLen, 0)
+}
@@ -0,0 +1,128 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// ListZones returns all the zones available to the account.
+func (c *Client) ListZones() ([]Zone, error) {
+	var resp struct {
+		Zone []Zone `json:"zone"`
+	}
+	if err := c.call("listZones", url.Values{}, &resp); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return resp.Zone, nil
+}
+
+// ListServiceOfferings returns all the service offerings available to
+// the account.
+func (c *Client) ListServiceOfferings() ([]ServiceOffering, error) {
+	var resp struct {
+		ServiceOffering []ServiceOffering `json:"serviceoffering"`
+	}
+	if err := c.call("listServiceOfferings", url.Values{}, &resp); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return resp.ServiceOffering, nil
+}
+
+// ListTemplates returns the executable templates available to the
+// account in the zone with the given id, or in all zones if zoneID is
+// empty.
+func (c *Client) ListTemplates(zoneID string) ([]Template, error) {
+	params := url.Values{"templatefilter": {"executable"}}
+	if zoneID != "" {
+		params.Set("zoneid", zoneID)
+	}
+	var resp struct {
+		Template []Template `json:"template"`
+	}
+	if err := c.call("listTemplates", params, &resp); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return resp.Template, nil
+}
+
+// DeployParams holds the parameters for deploying a virtual machine.
+type DeployParams struct {
+	Name              string
+	Group             string
+	ZoneID            string
+	ServiceOfferingID string
+	TemplateID        string
+	SecurityGroupIDs  []string
+
+	// RootDiskSize, if not zero, overrides the size of the root
+	// volume, in GB.
+	RootDiskSize uint64
+
+	// UserData holds the user data made available to cloud-init. The
+	// API accepts at most 32KB of it once base64-encoded.
+	UserData []byte
+}
+
+// DeployVirtualMachine deploys and starts a virtual machine, waiting
+// for it to be created.
+func (c *Client) DeployVirtualMachine(p DeployParams) (*VirtualMachine, error) {
+	params := url.Values{
+		"name":              {p.Name},
+		"displayname":       {p.Name},
+		"zoneid":            {p.ZoneID},
+		"serviceofferingid": {p.ServiceOfferingID},
+		"templateid":        {p.TemplateID},
+	}
+	if p.Group != "" {
+		params.Set("group", p.Group)
+	}
+	if len(p.SecurityGroupIDs) > 0 {
+		params.Set("securitygroupids", strings.Join(p.SecurityGroupIDs, ","))
+	}
+	if p.RootDiskSize > 0 {
+		params.Set("rootdisksize", strconv.FormatUint(p.RootDiskSize, 10))
+	}
+	if len(p.UserData) > 0 {
+		params.Set("userdata", base64.StdEncoding.EncodeToString(p.UserData))
+	}
+	var result struct {
+		VirtualMachine VirtualMachine `json:"virtualmachine"`
+	}
+	if err := c.callAsync("deployVirtualMachine", params, &result); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &result.VirtualMachine, nil
+}
+
+// ListVirtualMachines returns the virtual machine with the given id,
+// or all the account's virtual machines if id is empty.
+func (c *Client) ListVirtualMachines(id string) ([]VirtualMachine, error) {
+	params := url.Values{}
+	if id != "" {
+		params.Set("id", id)
+	}
+	var resp struct {
+		VirtualMachine []VirtualMachine `json:"virtualmachine"`
+	}
+	if err := c.call("listVirtualMachines", params, &resp); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return resp.VirtualMachine, nil
+}
+
+// DestroyVirtualMachine destroys and expunges the virtual machine with
+// the given id, waiting for it to be destroyed.
+func (c *Client) DestroyVirtualMachine(id string) error {
+	params := url.Values{
+		"id":      {id},
+		"expunge": {"true"},
+	}
+	return errors.Trace(c.callAsync("destroyVirtualMachine", params, nil))
+}
@@ -0,0 +1,101 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/juju/errors"
+)
+
+// ListSecurityGroups returns the security group with the given name,
+// or all the account's security groups if name is empty.
+func (c *Client) ListSecurityGroups(name string) ([]SecurityGroup, error) {
+	params := url.Values{}
+	if name != "" {
+		params.Set("securitygroupname", name)
+	}
+	var resp struct {
+		SecurityGroup []SecurityGroup `json:"securitygroup"`
+	}
+	if err := c.call("listSecurityGroups", params, &resp); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return resp.SecurityGroup, nil
+}
+
+// CreateSecurityGroup creates a security group with the given name
+// and description.
+func (c *Client) CreateSecurityGroup(name, description string) (*SecurityGroup, error) {
+	params := url.Values{
+		"name":        {name},
+		"description": {description},
+	}
+	var resp struct {
+		SecurityGroup SecurityGroup `json:"securitygroup"`
+	}
+	if err := c.call("createSecurityGroup", params, &resp); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &resp.SecurityGroup, nil
+}
+
+// DeleteSecurityGroup deletes the security group with the given name.
+func (c *Client) DeleteSecurityGroup(name string) error {
+	params := url.Values{"name": {name}}
+	return errors.Trace(c.call("deleteSecurityGroup", params, nil))
+}
+
+// IngressParams holds the parameters for admitting traffic to the
+// members of a security group. Traffic is admitted either from the
+// addresses in CIDR or from the members of SourceGroup.
+type IngressParams struct {
+	SecurityGroupID string
+	Protocol        string
+	StartPort       int
+	EndPort         int
+	CIDR            string
+
+	// SourceGroup and SourceAccount identify the group whose
+	// members are admitted.
+	SourceGroup   string
+	SourceAccount string
+}
+
+// AuthorizeSecurityGroupIngress adds a rule admitting the traffic
+// described by the given parameters, waiting for it to be added.
+func (c *Client) AuthorizeSecurityGroupIngress(p IngressParams) (*SecurityGroup, error) {
+	params := url.Values{
+		"securitygroupid": {p.SecurityGroupID},
+		"protocol":        {p.Protocol},
+	}
+	if p.Protocol == "icmp" {
+		params.Set("icmptype", "-1")
+		params.Set("icmpcode", "-1")
+	} else {
+		params.Set("startport", strconv.Itoa(p.StartPort))
+		params.Set("endport", strconv.Itoa(p.EndPort))
+	}
+	if p.SourceGroup != "" {
+		params.Set("usersecuritygrouplist[0].group", p.SourceGroup)
+		params.Set("usersecuritygrouplist[0].account", p.SourceAccount)
+	} else {
+		params.Set("cidrlist", p.CIDR)
+	}
+	var result struct {
+		SecurityGroup SecurityGroup `json:"securitygroup"`
+	}
+	if err := c.callAsync("authorizeSecurityGroupIngress", params, &result); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &result.SecurityGroup, nil
+}
+
+// RevokeSecurityGroupIngress removes the ingress rule with the given
+// id, waiting for it to be removed.
+func (c *Client) RevokeSecurityGroupIngress(ruleID string) error {
+	params := url.Values{"id": {ruleID}}
+	return errors.Trace(c.callAsync("revokeSecurityGroupIngress", params, nil))
+}
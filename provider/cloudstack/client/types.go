@@ -0,0 +1,140 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+// Zone states, as reported in Zone.AllocationState.
+const (
+	ZoneEnabled  = "Enabled"
+	ZoneDisabled = "Disabled"
+)
+
+// Virtual machine states, as reported in VirtualMachine.State.
+const (
+	StateStarting  = "Starting"
+	StateRunning   = "Running"
+	StateStopping  = "Stopping"
+	StateStopped   = "Stopped"
+	StateDestroyed = "Destroyed"
+	StateExpunging = "Expunging"
+	StateError     = "Error"
+)
+
+// Volume states, as reported in Volume.State.
+const (
+	VolumeAllocated = "Allocated"
+	VolumeReady     = "Ready"
+)
+
+// Zone describes an availability zone.
+type Zone struct {
+	ID                    string `json:"id"`
+	Name                  string `json:"name"`
+	AllocationState       string `json:"allocationstate"`
+	NetworkType           string `json:"networktype"`
+	SecurityGroupsEnabled bool   `json:"securitygroupsenabled"`
+}
+
+// ServiceOffering describes the compute resources with which virtual
+// machines may be deployed.
+type ServiceOffering struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	CPUNumber int    `json:"cpunumber"`
+	CPUSpeed  int    `json:"cpuspeed"` // MHz
+	Memory    int    `json:"memory"`   // MB
+}
+
+// DiskOffering describes the kind of data volumes which may be
+// created.
+type DiskOffering struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	DiskSize     int64  `json:"disksize"` // GB
+	IsCustomized bool   `json:"iscustomized"`
+}
+
+// Template describes an image from which virtual machines may be
+// deployed.
+type Template struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	OSTypeName string `json:"ostypename"`
+	ZoneID     string `json:"zoneid"`
+	IsReady    bool   `json:"isready"`
+	Hypervisor string `json:"hypervisor"`
+}
+
+// NIC describes a virtual machine's network interface.
+type NIC struct {
+	ID          string `json:"id"`
+	NetworkID   string `json:"networkid"`
+	IPAddress   string `json:"ipaddress,omitempty"`
+	IP6Address  string `json:"ip6address,omitempty"`
+	MACAddress  string `json:"macaddress,omitempty"`
+	IsDefault   bool   `json:"isdefault"`
+	NetworkName string `json:"networkname,omitempty"`
+}
+
+// SecurityGroupRef identifies a security group of which a virtual
+// machine is a member.
+type SecurityGroupRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// VirtualMachine describes a virtual machine.
+type VirtualMachine struct {
+	ID                string             `json:"id"`
+	Name              string             `json:"name"`
+	DisplayName       string             `json:"displayname,omitempty"`
+	Group             string             `json:"group,omitempty"`
+	State             string             `json:"state"`
+	ZoneID            string             `json:"zoneid"`
+	ZoneName          string             `json:"zonename"`
+	TemplateID        string             `json:"templateid"`
+	ServiceOfferingID string             `json:"serviceofferingid"`
+	CPUNumber         int                `json:"cpunumber"`
+	Memory            int                `json:"memory"`
+	Hypervisor        string             `json:"hypervisor,omitempty"`
+	PublicIP          string             `json:"publicip,omitempty"`
+	NIC               []NIC              `json:"nic"`
+	SecurityGroup     []SecurityGroupRef `json:"securitygroup"`
+}
+
+// IngressRule describes traffic admitted by a security group, either
+// from a range of addresses or from the members of another group.
+type IngressRule struct {
+	RuleID            string `json:"ruleid"`
+	Protocol          string `json:"protocol"`
+	StartPort         int    `json:"startport,omitempty"`
+	EndPort           int    `json:"endport,omitempty"`
+	ICMPType          int    `json:"icmptype,omitempty"`
+	ICMPCode          int    `json:"icmpcode,omitempty"`
+	CIDR              string `json:"cidr,omitempty"`
+	SecurityGroupName string `json:"securitygroupname,omitempty"`
+	Account           string `json:"account,omitempty"`
+}
+
+// SecurityGroup describes a security group.
+type SecurityGroup struct {
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Account     string        `json:"account,omitempty"`
+	IngressRule []IngressRule `json:"ingressrule"`
+}
+
+// Volume describes a volume.
+type Volume struct {
+	ID               string `json:"id"`
+	Name             string `json:"name"`
+	Type             string `json:"type"`
+	State            string `json:"state"`
+	Size             int64  `json:"size"` // bytes
+	ZoneID           string `json:"zoneid"`
+	DiskOfferingID   string `json:"diskofferingid,omitempty"`
+	VirtualMachineID string `json:"virtualmachineid,omitempty"`
+	DeviceID         int    `json:"deviceid,omitempty"`
+	Hypervisor       string `json:"hypervisor,omitempty"`
+}
@@ -0,0 +1,98 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/juju/errors"
+)
+
+// ListDiskOfferings returns all the disk offerings available to the
+// account.
+func (c *Client) ListDiskOfferings() ([]DiskOffering, error) {
+	var resp struct {
+		DiskOffering []DiskOffering `json:"diskoffering"`
+	}
+	if err := c.call("listDiskOfferings", url.Values{}, &resp); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return resp.DiskOffering, nil
+}
+
+// VolumeParams holds the parameters for creating a data volume.
+type VolumeParams struct {
+	Name           string
+	ZoneID         string
+	DiskOfferingID string
+
+	// Size is the size of the volume in GB. It is only used when
+	// the disk offering is customized.
+	Size uint64
+}
+
+// CreateVolume creates a data volume, waiting for it to be created.
+func (c *Client) CreateVolume(p VolumeParams) (*Volume, error) {
+	params := url.Values{
+		"name":           {p.Name},
+		"zoneid":         {p.ZoneID},
+		"diskofferingid": {p.DiskOfferingID},
+	}
+	if p.Size > 0 {
+		params.Set("size", strconv.FormatUint(p.Size, 10))
+	}
+	var result struct {
+		Volume Volume `json:"volume"`
+	}
+	if err := c.callAsync("createVolume", params, &result); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &result.Volume, nil
+}
+
+// ListVolumes returns the volume with the given id, or all the
+// account's volumes if id is empty.
+func (c *Client) ListVolumes(id string) ([]Volume, error) {
+	params := url.Values{}
+	if id != "" {
+		params.Set("id", id)
+	}
+	var resp struct {
+		Volume []Volume `json:"volume"`
+	}
+	if err := c.call("listVolumes", params, &resp); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return resp.Volume, nil
+}
+
+// AttachVolume attaches the volume with the given id to the virtual
+// machine with the given id, waiting for it to be attached.
+func (c *Client) AttachVolume(id, virtualMachineID string) (*Volume, error) {
+	params := url.Values{
+		"id":               {id},
+		"virtualmachineid": {virtualMachineID},
+	}
+	var result struct {
+		Volume Volume `json:"volume"`
+	}
+	if err := c.callAsync("attachVolume", params, &result); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &result.Volume, nil
+}
+
+// DetachVolume detaches the volume with the given id from its virtual
+// machine, waiting for it to be detached.
+func (c *Client) DetachVolume(id string) error {
+	params := url.Values{"id": {id}}
+	return errors.Trace(c.callAsync("detachVolume", params, nil))
+}
+
+// DeleteVolume deletes the detached volume with the given id.
+func (c *Client) DeleteVolume(id string) error {
+	params := url.Values{"id": {id}}
+	return errors.Trace(c.call("deleteVolume", params, nil))
+}
@@ -0,0 +1,31 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package cloudstack implements an environment provider for clouds
+// managed by Apache CloudStack.
+//
+// Instances are CloudStack virtual machines, deployed from templates
+// whose OS type names the Ubuntu release they contain (for example
+// "Ubuntu 14.04 (64-bit)"), with service offerings as instance types.
+// Availability zones are CloudStack zones, which must have security
+// groups enabled: the provider's firewall is implemented with a
+// security group for the environment and, in the "instance" firewall
+// mode, one for each machine. Volumes are provided by CloudStack data
+// volumes.
+package cloudstack
+
+import (
+	"github.com/juju/loggo"
+)
+
+// The groups given to virtual machines, used to identify the state
+// servers.
+const (
+	groupStateServer = "juju-state-server"
+	groupMachine     = "juju-machine"
+)
+
+// anyCIDR is the CIDR which admits traffic from any address.
+const anyCIDR = "0.0.0.0/0"
+
+var logger = loggo.GetLogger("juju.provider.cloudstack")
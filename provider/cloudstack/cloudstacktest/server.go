@@ -0,0 +1,796 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package cloudstacktest implements an in-memory stand-in for the
+// Apache CloudStack HTTP API, for use in tests.
+//
+// The server implements the commands used by the cloudstack provider.
+// Asynchronous commands complete before their job id is returned, so
+// the first query of a job's result reports its outcome.
+package cloudstacktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/juju/juju/provider/cloudstack/client"
+)
+
+// Server is a fake CloudStack API server.
+type Server struct {
+	srv       *httptest.Server
+	apiKey    string
+	secretKey string
+
+	mu               sync.Mutex
+	nextID           int
+	nextIP           int
+	account          string
+	zones            []client.Zone
+	serviceOfferings []client.ServiceOffering
+	diskOfferings    []client.DiskOffering
+	templates        []client.Template
+	vms              map[string]*client.VirtualMachine
+	groups           map[string]*client.SecurityGroup
+	volumes          map[string]*client.Volume
+	jobs             map[string]jobResult
+	failures         map[string]string
+	deployFailures   map[string]string
+}
+
+type jobResult struct {
+	status int
+	result interface{}
+}
+
+// apiError is an error to be returned by the API.
+type apiError struct {
+	code int
+	text string
+}
+
+func (e *apiError) Error() string {
+	return e.text
+}
+
+func paramErrorf(format string, args ...interface{}) error {
+	return &apiError{client.ErrorParamError, fmt.Sprintf(format, args...)}
+}
+
+// NewServer starts a new server which accepts requests signed with
+// the given keys.
+func NewServer(apiKey, secretKey string) *Server {
+	s := &Server{
+		apiKey:    apiKey,
+		secretKey: secretKey,
+		account:   "admin",
+		vms:       make(map[string]*client.VirtualMachine),
+		groups:    make(map[string]*client.SecurityGroup),
+		volumes:   make(map[string]*client.Volume),
+		jobs:      make(map[string]jobResult),
+		failures:  make(map[string]string),
+
+		deployFailures: make(map[string]string),
+	}
+	// Every account has a default security group.
+	s.groups["default"] = &client.SecurityGroup{
+		ID:      s.newID(),
+		Name:    "default",
+		Account: s.account,
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// URL returns the URL of the server's API endpoint.
+func (s *Server) URL() string {
+	return s.srv.URL + "/client/api"
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// AddZone adds a zone. Its id is assigned if not set.
+func (s *Server) AddZone(zone client.Zone) client.Zone {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if zone.ID == "" {
+		zone.ID = s.newID()
+	}
+	s.zones = append(s.zones, zone)
+	return zone
+}
+
+// AddServiceOffering adds a service offering. Its id is assigned if
+// not set.
+func (s *Server) AddServiceOffering(offering client.ServiceOffering) client.ServiceOffering {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if offering.ID == "" {
+		offering.ID = s.newID()
+	}
+	s.serviceOfferings = append(s.serviceOfferings, offering)
+	return offering
+}
+
+// AddDiskOffering adds a disk offering. Its id is assigned if not set.
+func (s *Server) AddDiskOffering(offering client.DiskOffering) client.DiskOffering {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if offering.ID == "" {
+		offering.ID = s.newID()
+	}
+	s.diskOfferings = append(s.diskOfferings, offering)
+	return offering
+}
+
+// AddTemplate adds a template. Its id is assigned if not set.
+func (s *Server) AddTemplate(template client.Template) client.Template {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if template.ID == "" {
+		template.ID = s.newID()
+	}
+	s.templates = append(s.templates, template)
+	return template
+}
+
+// FailCommand causes subsequent requests for the given command to
+// fail with the given message. An empty message clears the failure.
+func (s *Server) FailCommand(command, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if message == "" {
+		delete(s.failures, strings.ToLower(command))
+	} else {
+		s.failures[strings.ToLower(command)] = message
+	}
+}
+
+// FailDeployments causes the jobs of subsequent deployments to the
+// zone with the given name to fail with the given message, leaving
+// their virtual machines in the Error state as the API does when a
+// zone lacks capacity. An empty message clears the failure.
+func (s *Server) FailDeployments(zoneName, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if message == "" {
+		delete(s.deployFailures, zoneName)
+	} else {
+		s.deployFailures[zoneName] = message
+	}
+}
+
+// VirtualMachines returns the server's virtual machines, sorted by
+// name.
+func (s *Server) VirtualMachines() []client.VirtualMachine {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sortedVMs()
+}
+
+// SecurityGroup returns the security group with the given name, and
+// whether it exists.
+func (s *Server) SecurityGroup(name string) (client.SecurityGroup, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	group, ok := s.groups[name]
+	if !ok {
+		return client.SecurityGroup{}, false
+	}
+	return *group, true
+}
+
+// Volumes returns the server's data volumes, sorted by name.
+func (s *Server) Volumes() []client.Volume {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var volumes []client.Volume
+	for _, vol := range s.volumes {
+		volumes = append(volumes, *vol)
+	}
+	sort.Sort(volumesByName(volumes))
+	return volumes
+}
+
+type volumesByName []client.Volume
+
+func (v volumesByName) Len() int           { return len(v) }
+func (v volumesByName) Less(i, j int) bool { return v[i].Name < v[j].Name }
+func (v volumesByName) Swap(i, j int)      { v[i], v[j] = v[j], v[i] }
+
+type vmsByName []client.VirtualMachine
+
+func (v vmsByName) Len() int           { return len(v) }
+func (v vmsByName) Less(i, j int) bool { return v[i].Name < v[j].Name }
+func (v vmsByName) Swap(i, j int)      { v[i], v[j] = v[j], v[i] }
+
+func (s *Server) sortedVMs() []client.VirtualMachine {
+	var vms []client.VirtualMachine
+	for _, vm := range s.vms {
+		vms = append(vms, *vm)
+	}
+	sort.Sort(vmsByName(vms))
+	return vms
+}
+
+func (s *Server) newID() string {
+	s.nextID++
+	return fmt.Sprintf("00000000-0000-0000-0000-%012d", s.nextID)
+}
+
+func (s *Server) newIP() string {
+	s.nextIP++
+	return net.IPv4(10, 1, byte(s.nextIP>>8), byte(s.nextIP)).String()
+}
+
+// handlers maps lower-cased command names to their implementations.
+var handlers = map[string]func(*Server, url.Values) (interface{}, error){
+	"listzones":                     (*Server).listZones,
+	"listserviceofferings":          (*Server).listServiceOfferings,
+	"listdiskofferings":             (*Server).listDiskOfferings,
+	"listtemplates":                 (*Server).listTemplates,
+	"deployvirtualmachine":          (*Server).deployVirtualMachine,
+	"listvirtualmachines":           (*Server).listVirtualMachines,
+	"destroyvirtualmachine":         (*Server).destroyVirtualMachine,
+	"listsecuritygroups":            (*Server).listSecurityGroups,
+	"createsecuritygroup":           (*Server).createSecurityGroup,
+	"deletesecuritygroup":           (*Server).deleteSecurityGroup,
+	"authorizesecuritygroupingress": (*Server).authorizeSecurityGroupIngress,
+	"revokesecuritygroupingress":    (*Server).revokeSecurityGroupIngress,
+	"createvolume":                  (*Server).createVolume,
+	"listvolumes":                   (*Server).listVolumes,
+	"attachvolume":                  (*Server).attachVolume,
+	"detachvolume":                  (*Server).detachVolume,
+	"deletevolume":                  (*Server).deleteVolume,
+	"queryasyncjobresult":           (*Server).queryAsyncJobResult,
+}
+
+// asyncCommands holds the lower-cased names of the commands which
+// return a job id.
+var asyncCommands = map[string]bool{
+	"deployvirtualmachine":          true,
+	"destroyvirtualmachine":         true,
+	"authorizesecuritygroupingress": true,
+	"revokesecuritygroupingress":    true,
+	"createvolume":                  true,
+	"attachvolume":                  true,
+	"detachvolume":                  true,
+}
+
+// Limits on the size of base64-encoded user data, which depend on
+// whether it is sent in a query string or in a request body.
+const (
+	maxGETUserData  = 2048
+	maxPOSTUserData = 32768
+)
+
+func (s *Server) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	// Parameters may be sent in the query string of a GET, or as
+	// the form-encoded body of a POST.
+	if err := req.ParseForm(); err != nil {
+		writeError(w, "errorresponse", http.StatusBadRequest, client.ErrorParamError, err.Error())
+		return
+	}
+	params := req.Form
+	command := strings.ToLower(params.Get("command"))
+	responseName := command + "response"
+	if params.Get("response") != "json" {
+		writeError(w, responseName, http.StatusBadRequest, client.ErrorParamError, "only JSON responses are supported")
+		return
+	}
+	maxUserData := maxPOSTUserData
+	if req.Method == "GET" {
+		maxUserData = maxGETUserData
+	}
+	if len(params.Get("userdata")) > maxUserData {
+		writeError(w, responseName, http.StatusBadRequest, client.ErrorParamError,
+			fmt.Sprintf("user data is too long for an http %s request", req.Method))
+		return
+	}
+	signature := params.Get("signature")
+	params.Del("signature")
+	if params.Get("apiKey") != s.apiKey || client.Sign(params, s.secretKey) != signature {
+		writeError(w, "errorresponse", http.StatusUnauthorized, 401, "unable to verify user credentials and/or request signature")
+		return
+	}
+	handler, ok := handlers[command]
+	if !ok {
+		writeError(w, responseName, http.StatusBadRequest, client.ErrorParamError, "unknown command "+params.Get("command"))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if message, ok := s.failures[command]; ok {
+		writeError(w, responseName, http.StatusInternalServerError, client.ErrorInternal, message)
+		return
+	}
+	result, err := handler(s, params)
+	if asyncCommands[command] {
+		// Asynchronous commands always start a job, which
+		// reports any error.
+		jobID := s.newID()
+		if err != nil {
+			s.jobs[jobID] = jobResult{client.JobFailed, errorResult(err)}
+		} else {
+			s.jobs[jobID] = jobResult{client.JobSucceeded, result}
+		}
+		writeResponse(w, responseName, map[string]string{"jobid": jobID})
+		return
+	}
+	if err != nil {
+		result := errorResult(err)
+		writeError(w, responseName, errorStatus(err), result.ErrorCode, result.ErrorText)
+		return
+	}
+	writeResponse(w, responseName, result)
+}
+
+type errorResponse struct {
+	ErrorCode int    `json:"errorcode"`
+	ErrorText string `json:"errortext"`
+}
+
+func errorResult(err error) errorResponse {
+	if apiErr, ok := err.(*apiError); ok {
+		return errorResponse{apiErr.code, apiErr.text}
+	}
+	return errorResponse{client.ErrorInternal, err.Error()}
+}
+
+func errorStatus(err error) int {
+	if apiErr, ok := err.(*apiError); ok && apiErr.code == client.ErrorParamError {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func writeResponse(w http.ResponseWriter, name string, content interface{}) {
+	data, err := json.Marshal(map[string]interface{}{name: content})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, name string, status, code int, text string) {
+	data, _ := json.Marshal(map[string]interface{}{
+		name: errorResponse{code, text},
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func (s *Server) queryAsyncJobResult(params url.Values) (interface{}, error) {
+	jobID := params.Get("jobid")
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return nil, paramErrorf("unable to find job %q", jobID)
+	}
+	return map[string]interface{}{
+		"jobid":     jobID,
+		"jobstatus": job.status,
+		"jobresult": job.result,
+	}, nil
+}
+
+func (s *Server) zone(id string) (*client.Zone, error) {
+	for i := range s.zones {
+		if s.zones[i].ID == id {
+			return &s.zones[i], nil
+		}
+	}
+	return nil, paramErrorf("unable to find zone %q", id)
+}
+
+func (s *Server) listZones(params url.Values) (interface{}, error) {
+	return map[string]interface{}{
+		"count": len(s.zones),
+		"zone":  s.zones,
+	}, nil
+}
+
+func (s *Server) listServiceOfferings(params url.Values) (interface{}, error) {
+	return map[string]interface{}{
+		"count":           len(s.serviceOfferings),
+		"serviceoffering": s.serviceOfferings,
+	}, nil
+}
+
+func (s *Server) listDiskOfferings(params url.Values) (interface{}, error) {
+	return map[string]interface{}{
+		"count":        len(s.diskOfferings),
+		"diskoffering": s.diskOfferings,
+	}, nil
+}
+
+func (s *Server) listTemplates(params url.Values) (interface{}, error) {
+	if params.Get("templatefilter") == "" {
+		return nil, paramErrorf("templatefilter is required")
+	}
+	zoneID := params.Get("zoneid")
+	var templates []client.Template
+	for _, t := range s.templates {
+		if zoneID == "" || t.ZoneID == zoneID {
+			templates = append(templates, t)
+		}
+	}
+	return map[string]interface{}{
+		"count":    len(templates),
+		"template": templates,
+	}, nil
+}
+
+func (s *Server) deployVirtualMachine(params url.Values) (interface{}, error) {
+	zone, err := s.zone(params.Get("zoneid"))
+	if err != nil {
+		return nil, err
+	}
+	if zone.AllocationState != client.ZoneEnabled {
+		return nil, &apiError{client.ErrorInternal, fmt.Sprintf("zone %s is not enabled", zone.Name)}
+	}
+	var offering *client.ServiceOffering
+	for i := range s.serviceOfferings {
+		if s.serviceOfferings[i].ID == params.Get("serviceofferingid") {
+			offering = &s.serviceOfferings[i]
+		}
+	}
+	if offering == nil {
+		return nil, paramErrorf("unable to find service offering %q", params.Get("serviceofferingid"))
+	}
+	var template *client.Template
+	for i := range s.templates {
+		t := &s.templates[i]
+		if t.ID == params.Get("templateid") && t.ZoneID == zone.ID {
+			template = t
+		}
+	}
+	if template == nil {
+		return nil, paramErrorf("unable to find template %q in zone %s", params.Get("templateid"), zone.Name)
+	}
+	name := params.Get("name")
+	for _, vm := range s.vms {
+		if vm.Name == name {
+			return nil, paramErrorf("virtual machine %q already exists", name)
+		}
+	}
+	vm := &client.VirtualMachine{
+		ID:                s.newID(),
+		Name:              name,
+		DisplayName:       params.Get("displayname"),
+		Group:             params.Get("group"),
+		State:             client.StateRunning,
+		ZoneID:            zone.ID,
+		ZoneName:          zone.Name,
+		TemplateID:        template.ID,
+		ServiceOfferingID: offering.ID,
+		CPUNumber:         offering.CPUNumber,
+		Memory:            offering.Memory,
+		Hypervisor:        template.Hypervisor,
+		NIC: []client.NIC{{
+			ID:        s.newID(),
+			NetworkID: zone.ID,
+			IPAddress: s.newIP(),
+			IsDefault: true,
+		}},
+	}
+	if ids := params.Get("securitygroupids"); ids != "" {
+		for _, id := range strings.Split(ids, ",") {
+			group := s.securityGroupByID(id)
+			if group == nil {
+				return nil, paramErrorf("unable to find security group %q", id)
+			}
+			vm.SecurityGroup = append(vm.SecurityGroup, client.SecurityGroupRef{
+				ID:   group.ID,
+				Name: group.Name,
+			})
+		}
+	}
+	s.vms[vm.ID] = vm
+	// Every virtual machine has a root volume.
+	root := &client.Volume{
+		ID:               s.newID(),
+		Name:             "ROOT-" + vm.ID,
+		Type:             "ROOT",
+		State:            client.VolumeReady,
+		Size:             8 << 30,
+		ZoneID:           zone.ID,
+		VirtualMachineID: vm.ID,
+		Hypervisor:       vm.Hypervisor,
+	}
+	if size := params.Get("rootdisksize"); size != "" {
+		gb, err := strconv.ParseInt(size, 10, 64)
+		if err != nil {
+			return nil, paramErrorf("invalid rootdisksize %q", size)
+		}
+		root.Size = gb << 30
+	}
+	s.volumes[root.ID] = root
+	if message, ok := s.deployFailures[zone.Name]; ok {
+		vm.State = client.StateError
+		return nil, &apiError{client.ErrorInternal, message}
+	}
+	return map[string]interface{}{"virtualmachine": vm}, nil
+}
+
+func (s *Server) listVirtualMachines(params url.Values) (interface{}, error) {
+	id := params.Get("id")
+	var vms []client.VirtualMachine
+	for _, vm := range s.sortedVMs() {
+		if id == "" || vm.ID == id {
+			vms = append(vms, vm)
+		}
+	}
+	return map[string]interface{}{
+		"count":          len(vms),
+		"virtualmachine": vms,
+	}, nil
+}
+
+func (s *Server) destroyVirtualMachine(params url.Values) (interface{}, error) {
+	id := params.Get("id")
+	vm, ok := s.vms[id]
+	if !ok {
+		return nil, paramErrorf("unable to find virtual machine %q", id)
+	}
+	delete(s.vms, id)
+	// The root volume is destroyed with the virtual machine, but
+	// data volumes are only detached.
+	for volID, vol := range s.volumes {
+		if vol.VirtualMachineID != id {
+			continue
+		}
+		if vol.Type == "ROOT" {
+			delete(s.volumes, volID)
+		} else {
+			vol.VirtualMachineID = ""
+			vol.DeviceID = 0
+		}
+	}
+	vm.State = client.StateDestroyed
+	return map[string]interface{}{"virtualmachine": vm}, nil
+}
+
+func (s *Server) securityGroupByID(id string) *client.SecurityGroup {
+	for _, group := range s.groups {
+		if group.ID == id {
+			return group
+		}
+	}
+	return nil
+}
+
+func (s *Server) listSecurityGroups(params url.Values) (interface{}, error) {
+	name := params.Get("securitygroupname")
+	var groups []client.SecurityGroup
+	for _, group := range s.groups {
+		if name == "" || group.Name == name {
+			groups = append(groups, *group)
+		}
+	}
+	return map[string]interface{}{
+		"count":         len(groups),
+		"securitygroup": groups,
+	}, nil
+}
+
+func (s *Server) createSecurityGroup(params url.Values) (interface{}, error) {
+	name := params.Get("name")
+	if name == "" {
+		return nil, paramErrorf("name is required")
+	}
+	if _, ok := s.groups[name]; ok {
+		return nil, paramErrorf("security group %q already exists", name)
+	}
+	group := &client.SecurityGroup{
+		ID:          s.newID(),
+		Name:        name,
+		Description: params.Get("description"),
+		Account:     s.account,
+	}
+	s.groups[name] = group
+	return map[string]interface{}{"securitygroup": group}, nil
+}
+
+func (s *Server) deleteSecurityGroup(params url.Values) (interface{}, error) {
+	name := params.Get("name")
+	if _, ok := s.groups[name]; !ok {
+		return nil, paramErrorf("unable to find security group %q", name)
+	}
+	for _, vm := range s.vms {
+		for _, ref := range vm.SecurityGroup {
+			if ref.Name == name {
+				return nil, paramErrorf("security group %q is in use", name)
+			}
+		}
+	}
+	delete(s.groups, name)
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (s *Server) authorizeSecurityGroupIngress(params url.Values) (interface{}, error) {
+	group := s.securityGroupByID(params.Get("securitygroupid"))
+	if group == nil {
+		return nil, paramErrorf("unable to find security group %q", params.Get("securitygroupid"))
+	}
+	rule := client.IngressRule{
+		RuleID:   s.newID(),
+		Protocol: params.Get("protocol"),
+	}
+	switch rule.Protocol {
+	case "tcp", "udp":
+		var err error
+		if rule.StartPort, err = strconv.Atoi(params.Get("startport")); err != nil {
+			return nil, paramErrorf("invalid startport %q", params.Get("startport"))
+		}
+		if rule.EndPort, err = strconv.Atoi(params.Get("endport")); err != nil {
+			return nil, paramErrorf("invalid endport %q", params.Get("endport"))
+		}
+	case "icmp":
+		rule.ICMPType, _ = strconv.Atoi(params.Get("icmptype"))
+		rule.ICMPCode, _ = strconv.Atoi(params.Get("icmpcode"))
+	default:
+		return nil, paramErrorf("invalid protocol %q", rule.Protocol)
+	}
+	if source := params.Get("usersecuritygrouplist[0].group"); source != "" {
+		rule.SecurityGroupName = source
+		rule.Account = params.Get("usersecuritygrouplist[0].account")
+	} else {
+		rule.CIDR = params.Get("cidrlist")
+		if _, _, err := net.ParseCIDR(rule.CIDR); err != nil {
+			return nil, paramErrorf("invalid cidrlist %q", rule.CIDR)
+		}
+	}
+	for _, existing := range group.IngressRule {
+		existing.RuleID = rule.RuleID
+		if existing == rule {
+			return nil, paramErrorf("rule already exists")
+		}
+	}
+	group.IngressRule = append(group.IngressRule, rule)
+	return map[string]interface{}{"securitygroup": group}, nil
+}
+
+func (s *Server) revokeSecurityGroupIngress(params url.Values) (interface{}, error) {
+	id := params.Get("id")
+	for _, group := range s.groups {
+		for i, rule := range group.IngressRule {
+			if rule.RuleID == id {
+				group.IngressRule = append(group.IngressRule[:i], group.IngressRule[i+1:]...)
+				return map[string]interface{}{"success": true}, nil
+			}
+		}
+	}
+	return nil, paramErrorf("unable to find ingress rule %q", id)
+}
+
+func (s *Server) createVolume(params url.Values) (interface{}, error) {
+	zone, err := s.zone(params.Get("zoneid"))
+	if err != nil {
+		return nil, err
+	}
+	var offering *client.DiskOffering
+	for i := range s.diskOfferings {
+		if s.diskOfferings[i].ID == params.Get("diskofferingid") {
+			offering = &s.diskOfferings[i]
+		}
+	}
+	if offering == nil {
+		return nil, paramErrorf("unable to find disk offering %q", params.Get("diskofferingid"))
+	}
+	size := offering.DiskSize
+	if offering.IsCustomized {
+		gb, err := strconv.ParseInt(params.Get("size"), 10, 64)
+		if err != nil || gb <= 0 {
+			return nil, paramErrorf("a size is required for customized disk offering %q", offering.Name)
+		}
+		size = gb
+	}
+	vol := &client.Volume{
+		ID:             s.newID(),
+		Name:           params.Get("name"),
+		Type:           "DATADISK",
+		State:          client.VolumeAllocated,
+		Size:           size << 30,
+		ZoneID:         zone.ID,
+		DiskOfferingID: offering.ID,
+	}
+	s.volumes[vol.ID] = vol
+	return map[string]interface{}{"volume": vol}, nil
+}
+
+func (s *Server) listVolumes(params url.Values) (interface{}, error) {
+	id := params.Get("id")
+	var volumes []client.Volume
+	for _, vol := range s.volumes {
+		if id == "" || vol.ID == id {
+			volumes = append(volumes, *vol)
+		}
+	}
+	sort.Sort(volumesByName(volumes))
+	return map[string]interface{}{
+		"count":  len(volumes),
+		"volume": volumes,
+	}, nil
+}
+
+func (s *Server) volume(id string) (*client.Volume, error) {
+	vol, ok := s.volumes[id]
+	if !ok {
+		return nil, paramErrorf("unable to find volume %q", id)
+	}
+	return vol, nil
+}
+
+func (s *Server) attachVolume(params url.Values) (interface{}, error) {
+	vol, err := s.volume(params.Get("id"))
+	if err != nil {
+		return nil, err
+	}
+	vm, ok := s.vms[params.Get("virtualmachineid")]
+	if !ok {
+		return nil, paramErrorf("unable to find virtual machine %q", params.Get("virtualmachineid"))
+	}
+	if vol.VirtualMachineID != "" {
+		return nil, &apiError{client.ErrorInternal, fmt.Sprintf("volume %s is already attached", vol.Name)}
+	}
+	if vol.ZoneID != vm.ZoneID {
+		return nil, &apiError{client.ErrorInternal, fmt.Sprintf("volume %s is not in zone %s", vol.Name, vm.ZoneName)}
+	}
+	// Device ids are allocated in order, skipping the root
+	// volume's device id 0 and the CD-ROM's device id 3.
+	used := make(map[int]bool)
+	for _, other := range s.volumes {
+		if other.VirtualMachineID == vm.ID {
+			used[other.DeviceID] = true
+		}
+	}
+	deviceID := 1
+	for used[deviceID] || deviceID == 3 {
+		deviceID++
+	}
+	vol.VirtualMachineID = vm.ID
+	vol.DeviceID = deviceID
+	vol.State = client.VolumeReady
+	vol.Hypervisor = vm.Hypervisor
+	return map[string]interface{}{"volume": vol}, nil
+}
+
+func (s *Server) detachVolume(params url.Values) (interface{}, error) {
+	vol, err := s.volume(params.Get("id"))
+	if err != nil {
+		return nil, err
+	}
+	if vol.VirtualMachineID == "" {
+		return nil, &apiError{client.ErrorInternal, fmt.Sprintf("volume %s is not attached", vol.Name)}
+	}
+	vol.VirtualMachineID = ""
+	vol.DeviceID = 0
+	return map[string]interface{}{"volume": vol}, nil
+}
+
+func (s *Server) deleteVolume(params url.Values) (interface{}, error) {
+	vol, err := s.volume(params.Get("id"))
+	if err != nil {
+		return nil, err
+	}
+	if vol.VirtualMachineID != "" {
+		return nil, &apiError{client.ErrorInternal, fmt.Sprintf("volume %s is attached", vol.Name)}
+	}
+	delete(s.volumes, vol.ID)
+	return map[string]interface{}{"success": true}, nil
+}
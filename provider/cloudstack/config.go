@@ -0,0 +1,207 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cloudstack
+
+import (
+	"os"
+
+	"github.com/juju/errors"
+	"github.com/juju/schema"
+	"github.com/juju/utils"
+
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/provider/cloudstack/client"
+)
+
+// The CloudStack-specific config keys.
+const (
+	cfgAPIURL    = "api-url"
+	cfgAPIKey    = "api-key"
+	cfgSecretKey = "secret-key"
+)
+
+// boilerplateConfig will be shown in help output, so please keep it up to
+// date when you change environment configuration below.
+var boilerplateConfig = `
+cloudstack:
+  type: cloudstack
+
+  # api-url is the URL of the CloudStack API endpoint, usually of the
+  # form http://<management-server>:8080/client/api. It can also be
+  # specified by the CLOUDSTACK_API_URL environment variable.
+  api-url:
+
+  # The API key and secret key of the account with which to manage the
+  # environment, as shown in the account's user details. They can also
+  # be specified by the CLOUDSTACK_API_KEY and CLOUDSTACK_SECRET_KEY
+  # environment variables.
+  # api-key: <secret>
+  # secret-key: <secret>
+
+  # Instances are started in the zones which have security groups
+  # enabled. A zone may be chosen when adding a machine with the
+  # "zone=<name>" placement directive.
+`[1:]
+
+// configFields is the spec for each CloudStack config value's type.
+var configFields = schema.Fields{
+	cfgAPIURL:    schema.String(),
+	cfgAPIKey:    schema.String(),
+	cfgSecretKey: schema.String(),
+}
+
+var configDefaults = schema.Defaults{
+	cfgAPIURL:    "",
+	cfgAPIKey:    "",
+	cfgSecretKey: "",
+}
+
+var configSecretFields = []string{
+	cfgSecretKey,
+}
+
+var configImmutableFields = []string{
+	cfgAPIURL,
+}
+
+// osEnvFields is the mapping from CloudStack env vars to config keys.
+var osEnvFields = map[string]string{
+	"CLOUDSTACK_API_URL":    cfgAPIURL,
+	"CLOUDSTACK_API_KEY":    cfgAPIKey,
+	"CLOUDSTACK_SECRET_KEY": cfgSecretKey,
+}
+
+// parseOSEnv returns the config values taken from the environment
+// variables, for those keys not already set in the given config.
+func parseOSEnv(cfg *config.Config) map[string]interface{} {
+	attrs := cfg.UnknownAttrs()
+	updates := make(map[string]interface{})
+	for envVar, key := range osEnvFields {
+		if value, _ := attrs[key].(string); value != "" {
+			continue
+		}
+		if value := os.Getenv(envVar); value != "" {
+			updates[key] = value
+		}
+	}
+	return updates
+}
+
+type environConfig struct {
+	*config.Config
+	attrs map[string]interface{}
+}
+
+// newConfig builds a new environConfig from the provided Config and
+// returns it.
+func newConfig(cfg *config.Config) *environConfig {
+	return &environConfig{
+		Config: cfg,
+		attrs:  cfg.UnknownAttrs(),
+	}
+}
+
+// newValidConfig builds a new environConfig from the provided Config
+// and returns it. This includes applying the provided defaults
+// values, if any. The resulting config values are validated.
+func newValidConfig(cfg *config.Config, defaults map[string]interface{}) (*environConfig, error) {
+	// Ensure that the provided config is valid.
+	if err := config.Validate(cfg, nil); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	// Apply the defaults and coerce/validate the custom config attrs.
+	validated, err := cfg.ValidateUnknownAttrs(configFields, defaults)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	validCfg, err := cfg.Apply(validated)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	// Build the config.
+	ecfg := newConfig(validCfg)
+
+	// Do final validation.
+	if err := ecfg.validate(); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	return ecfg, nil
+}
+
+func (c *environConfig) apiURL() string {
+	return c.attrs[cfgAPIURL].(string)
+}
+
+func (c *environConfig) apiKey() string {
+	return c.attrs[cfgAPIKey].(string)
+}
+
+func (c *environConfig) secretKey() string {
+	return c.attrs[cfgSecretKey].(string)
+}
+
+// clientConfig builds a client.Config based on the config and
+// returns it.
+func (c *environConfig) clientConfig() client.Config {
+	return client.Config{
+		URL:                  c.apiURL(),
+		APIKey:               c.apiKey(),
+		SecretKey:            c.secretKey(),
+		HostnameVerification: utils.SSLHostnameVerification(c.SSLHostnameVerification()),
+	}
+}
+
+// secret gathers the "secret" config values and returns them.
+func (c *environConfig) secret() map[string]string {
+	secretAttrs := make(map[string]string, len(configSecretFields))
+	for _, key := range configSecretFields {
+		secretAttrs[key] = c.attrs[key].(string)
+	}
+	return secretAttrs
+}
+
+// validate checks CloudStack-specific config values.
+func (c environConfig) validate() error {
+	// All fields must be populated.
+	for field := range configFields {
+		if c.attrs[field].(string) == "" {
+			return errors.Errorf("%s: must not be empty", field)
+		}
+	}
+	if err := c.clientConfig().Validate(); err != nil {
+		return errors.Annotatef(err, "%s", cfgAPIURL)
+	}
+	return nil
+}
+
+// update applies changes from the provided config to the env config.
+// Changes to any immutable attributes result in an error.
+func (c *environConfig) update(cfg *config.Config) error {
+	// Validate the updates. newValidConfig does not modify the "known"
+	// config attributes so it is safe to call Validate here first.
+	if err := config.Validate(cfg, c.Config); err != nil {
+		return errors.Trace(err)
+	}
+
+	updates, err := newValidConfig(cfg, configDefaults)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	// Check that no immutable fields have changed.
+	attrs := updates.UnknownAttrs()
+	for _, field := range configImmutableFields {
+		if attrs[field] != c.attrs[field] {
+			return errors.Errorf("%s: cannot change from %v to %v", field, c.attrs[field], attrs[field])
+		}
+	}
+
+	// Apply the updates.
+	c.Config = updates.Config
+	c.attrs = updates.attrs
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cloudstack_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/provider/cloudstack"
+	"github.com/juju/juju/testing"
+)
+
+var configAttrs = testing.Attrs{
+	"type":       "cloudstack",
+	"api-url":    "https://cloud.example.com/client/api",
+	"api-key":    "api-key",
+	"secret-key": "secret-key",
+}
+
+type configSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&configSuite{})
+
+func newConfig(c *gc.C, attrs testing.Attrs) *config.Config {
+	return testing.CustomEnvironConfig(c, configAttrs.Merge(attrs))
+}
+
+var invalidConfigTests = []struct {
+	info   string
+	insert testing.Attrs
+	remove []string
+	err    string
+}{{
+	info:   "api-url is required",
+	remove: []string{"api-url"},
+	err:    "api-url: must not be empty",
+}, {
+	info:   "api-url must be an absolute URL",
+	insert: testing.Attrs{"api-url": "cloud.example.com"},
+	err:    `api-url: API URL "cloud.example.com" not valid`,
+}, {
+	info:   "api-key is required",
+	insert: testing.Attrs{"api-key": ""},
+	err:    "api-key: must not be empty",
+}, {
+	info:   "secret-key is required",
+	remove: []string{"secret-key"},
+	err:    "secret-key: must not be empty",
+}, {
+	info:   "api-key must be a string",
+	insert: testing.Attrs{"api-key": 42},
+	err:    "api-key: expected string, got int.*",
+}}
+
+func (s *configSuite) TestValidateNewConfig(c *gc.C) {
+	cfg := newConfig(c, nil)
+	valid, err := cloudstack.Provider.Validate(cfg, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	attrs := valid.UnknownAttrs()
+	c.Check(attrs["api-url"], gc.Equals, "https://cloud.example.com/client/api")
+	c.Check(attrs["api-key"], gc.Equals, "api-key")
+	c.Check(attrs["secret-key"], gc.Equals, "secret-key")
+}
+
+func (s *configSuite) TestValidateInvalidConfig(c *gc.C) {
+	for i, test := range invalidConfigTests {
+		c.Logf("test %d: %s", i, test.info)
+		attrs := testing.FakeConfig().Merge(configAttrs).Merge(test.insert).Delete(test.remove...)
+		cfg, err := config.New(config.NoDefaults, attrs)
+		c.Assert(err, jc.ErrorIsNil)
+		_, err = cloudstack.Provider.Validate(cfg, nil)
+		c.Check(err, gc.ErrorMatches, "invalid config: "+test.err)
+	}
+}
+
+func (s *configSuite) TestValidateChangeImmutable(c *gc.C) {
+	c.Assert(cloudstack.ConfigImmutable, jc.DeepEquals, []string{"api-url"})
+
+	old := newConfig(c, nil)
+	cfg := newConfig(c, testing.Attrs{"api-url": "https://other.example.com/client/api"})
+	_, err := cloudstack.Provider.Validate(cfg, old)
+	c.Assert(err, gc.ErrorMatches, "invalid config change: api-url: cannot change from https://cloud.example.com/client/api to https://other.example.com/client/api")
+}
+
+func (s *configSuite) TestValidateChangeKeys(c *gc.C) {
+	old := newConfig(c, nil)
+	cfg := newConfig(c, testing.Attrs{"api-key": "new-api-key", "secret-key": "new-secret-key"})
+	valid, err := cloudstack.Provider.Validate(cfg, old)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(valid.UnknownAttrs()["api-key"], gc.Equals, "new-api-key")
+	c.Check(valid.UnknownAttrs()["secret-key"], gc.Equals, "new-secret-key")
+}
+
+func (s *configSuite) TestSecretAttrs(c *gc.C) {
+	attrs, err := cloudstack.Provider.SecretAttrs(newConfig(c, nil))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(attrs, jc.DeepEquals, map[string]string{"secret-key": "secret-key"})
+}
+
+func (s *configSuite) TestPrepareForCreateEnvironmentFromOSEnv(c *gc.C) {
+	s.PatchEnvironment("CLOUDSTACK_API_KEY", "env-api-key")
+	s.PatchEnvironment("CLOUDSTACK_SECRET_KEY", "env-secret-key")
+	cfg := newConfig(c, testing.Attrs{"api-key": ""})
+	cfg, err := cloudstack.Provider.PrepareForCreateEnvironment(cfg)
+	c.Assert(err, jc.ErrorIsNil)
+	attrs := cfg.UnknownAttrs()
+	// Only unset keys are taken from the environment.
+	c.Check(attrs["api-key"], gc.Equals, "env-api-key")
+	c.Check(attrs["secret-key"], gc.Equals, "secret-key")
+}
+
+func (s *configSuite) TestBoilerplateConfig(c *gc.C) {
+	c.Assert(cloudstack.Provider.BoilerplateConfig(), gc.Matches, "(?s)cloudstack:\n  type: cloudstack\n.*")
+}
+
+func (s *configSuite) TestRegistered(c *gc.C) {
+	provider, err := environs.Provider("cloudstack")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(provider, gc.Equals, cloudstack.Provider)
+}
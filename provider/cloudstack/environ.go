@@ -0,0 +1,128 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cloudstack
+
+import (
+	"sync"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/provider/cloudstack/client"
+	"github.com/juju/juju/provider/common"
+)
+
+type environ struct {
+	common.SupportsUnitPlacementPolicy
+
+	name string
+
+	lock   sync.Mutex
+	ecfg   *environConfig
+	client *client.Client
+
+	archLock               sync.Mutex
+	supportedArchitectures []string
+}
+
+var _ environs.Environ = (*environ)(nil)
+var _ common.ZonedEnviron = (*environ)(nil)
+var _ environs.SourceCIDRFirewaller = (*environ)(nil)
+
+func newEnviron(cfg *config.Config) (*environ, error) {
+	ecfg, err := newValidConfig(cfg, configDefaults)
+	if err != nil {
+		return nil, errors.Annotate(err, "invalid config")
+	}
+
+	if _, ok := ecfg.UUID(); !ok {
+		return nil, errors.New("UUID not set")
+	}
+
+	env := &environ{
+		name:   ecfg.Name(),
+		ecfg:   ecfg,
+		client: newClient(ecfg),
+	}
+	return env, nil
+}
+
+var newClient = func(ecfg *environConfig) *client.Client {
+	return client.New(ecfg.clientConfig())
+}
+
+// Name returns the name of the environment.
+func (env *environ) Name() string {
+	return env.name
+}
+
+// Provider returns the environment provider that created this env.
+func (*environ) Provider() environs.EnvironProvider {
+	return providerInstance
+}
+
+// SetConfig updates the env's configuration.
+func (env *environ) SetConfig(cfg *config.Config) error {
+	env.lock.Lock()
+	defer env.lock.Unlock()
+
+	if env.ecfg == nil {
+		return errors.New("cannot set config on uninitialized env")
+	}
+
+	if err := env.ecfg.update(cfg); err != nil {
+		return errors.Annotate(err, "invalid config change")
+	}
+	// The account's keys may have changed.
+	env.client = newClient(env.ecfg)
+	return nil
+}
+
+// getSnapshot returns a copy of the environment. This is useful for
+// ensuring the env you are using does not get changed by other code
+// while you are using it.
+func (env *environ) getSnapshot() *environ {
+	env.lock.Lock()
+	defer env.lock.Unlock()
+	return &environ{
+		name:   env.name,
+		ecfg:   env.ecfg,
+		client: env.client,
+	}
+}
+
+// Config returns the configuration data with which the env was created.
+func (env *environ) Config() *config.Config {
+	return env.getSnapshot().ecfg.Config
+}
+
+var bootstrap = common.Bootstrap
+
+// Bootstrap creates a new instance, chosing the series and arch out of
+// available tools. The series and arch are returned along with a func
+// that must be called to finalize the bootstrap process by transferring
+// the tools and installing the initial juju state server.
+func (env *environ) Bootstrap(ctx environs.BootstrapContext, params environs.BootstrapParams) (arch, series string, _ environs.BootstrapFinalizer, _ error) {
+	return bootstrap(ctx, env, params)
+}
+
+var destroyEnv = common.Destroy
+
+// Destroy shuts down all known machines and destroys the rest of the
+// known environment.
+func (env *environ) Destroy() error {
+	if err := destroyEnv(env); err != nil {
+		return errors.Trace(err)
+	}
+	// The environment's security groups can only be deleted once
+	// all their members have gone.
+	env = env.getSnapshot()
+	for _, name := range []string{env.globalGroupName(), env.envGroupName()} {
+		if err := env.deleteSecurityGroup(name); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cloudstack
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/utils/set"
+
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/provider/cloudstack/client"
+	"github.com/juju/juju/provider/common"
+)
+
+// availabilityZone implements common.AvailabilityZone for a
+// CloudStack zone.
+type availabilityZone struct {
+	zone client.Zone
+}
+
+// Name implements common.AvailabilityZone.
+func (z *availabilityZone) Name() string {
+	return z.zone.Name
+}
+
+// Available implements common.AvailabilityZone. Zones without
+// security groups are never available, since the provider's firewall
+// depends on them.
+func (z *availabilityZone) Available() bool {
+	return z.zone.AllocationState == client.ZoneEnabled && z.zone.SecurityGroupsEnabled
+}
+
+// AvailabilityZones returns all availability zones in the environment.
+func (env *environ) AvailabilityZones() ([]common.AvailabilityZone, error) {
+	zones, err := env.getSnapshot().client.ListZones()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	var result []common.AvailabilityZone
+	for _, zone := range zones {
+		result = append(result, &availabilityZone{zone})
+	}
+	return result, nil
+}
+
+// InstanceAvailabilityZoneNames returns the names of the availability
+// zones for the specified instances. The error returned follows the same
+// rules as Environ.Instances.
+func (env *environ) InstanceAvailabilityZoneNames(ids []instance.Id) ([]string, error) {
+	instances, err := env.Instances(ids)
+	if err != nil && err != environs.ErrPartialInstances && err != environs.ErrNoInstances {
+		return nil, errors.Trace(err)
+	}
+	// We let the two environs errors pass on through. However, we do
+	// not use errors.Trace in that case since callers may not call
+	// errors.Cause.
+
+	results := make([]string, len(ids))
+	for i, inst := range instances {
+		if inst != nil {
+			results[i] = inst.(*environInstance).base.ZoneName
+		}
+	}
+
+	return results, err
+}
+
+// DistributeInstances implements the state.InstanceDistributor policy.
+func (env *environ) DistributeInstances(candidates, distributionGroup []instance.Id) ([]instance.Id, error) {
+	return common.DistributeInstances(env, candidates, distributionGroup)
+}
+
+func (env *environ) availZone(name string) (*client.Zone, error) {
+	zones, err := env.getSnapshot().client.ListZones()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, z := range zones {
+		if z.Name == name {
+			return &z, nil
+		}
+	}
+	return nil, errors.NotFoundf("invalid availability zone %q", name)
+}
+
+func (env *environ) availZoneUp(name string) (*client.Zone, error) {
+	zone, err := env.availZone(name)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if zone.AllocationState != client.ZoneEnabled {
+		return nil, errors.Errorf("availability zone %q is %s", zone.Name, zone.AllocationState)
+	}
+	if !zone.SecurityGroupsEnabled {
+		return nil, errors.Errorf("availability zone %q does not have security groups enabled", zone.Name)
+	}
+	return zone, nil
+}
+
+var availabilityZoneAllocations = common.AvailabilityZoneAllocations
+
+// parseAvailabilityZones returns the availability zones that should be
+// tried for the given instance spec. If a placement argument was
+// provided then only that one is returned. Otherwise the environment is
+// queried for available zones. In that case, the resulting list is
+// roughly ordered such that the environment's instances are spread
+// evenly across the zones.
+func (env *environ) parseAvailabilityZones(args environs.StartInstanceParams) ([]client.Zone, error) {
+	// The machine's spaces may restrict the zones it can be started in.
+	var allowed set.Strings
+	if len(args.SubnetsToZones) > 0 {
+		allowed = set.NewStrings()
+		for _, zones := range args.SubnetsToZones {
+			allowed = allowed.Union(set.NewStrings(zones...))
+		}
+	}
+
+	if args.Placement != "" {
+		// args.Placement will always be a zone name or empty.
+		placement, err := env.parsePlacement(args.Placement)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if allowed != nil && !allowed.Contains(placement.Zone.Name) {
+			return nil, errors.Errorf("availability zone %q does not contain any of the machine's subnets", placement.Zone.Name)
+		}
+		return []client.Zone{*placement.Zone}, nil
+	}
+
+	// If no availability zone is specified, then automatically spread across
+	// the known zones for optimal spread across the instance distribution
+	// group.
+	var group []instance.Id
+	var err error
+	if args.DistributionGroup != nil {
+		group, err = args.DistributionGroup()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	zoneInstances, err := availabilityZoneAllocations(env, group)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	logger.Infof("found %d zones: %v", len(zoneInstances), zoneInstances)
+
+	zones, err := env.getSnapshot().client.ListZones()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	byName := make(map[string]client.Zone)
+	for _, zone := range zones {
+		byName[zone.Name] = zone
+	}
+	var result []client.Zone
+	for _, z := range zoneInstances {
+		if allowed != nil && !allowed.Contains(z.ZoneName) {
+			continue
+		}
+		if zone, ok := byName[z.ZoneName]; ok {
+			result = append(result, zone)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, errors.NotFoundf("failed to determine availability zones")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,297 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cloudstack
+
+import (
+	"strings"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/cloudinit"
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/environs/instances"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/juju/arch"
+	"github.com/juju/juju/provider/cloudstack/client"
+	"github.com/juju/juju/provider/common"
+	"github.com/juju/juju/state/multiwatcher"
+	"github.com/juju/juju/tools"
+	"github.com/juju/juju/version"
+)
+
+func isStateServer(mcfg *cloudinit.MachineConfig) bool {
+	return multiwatcher.AnyJobNeedsState(mcfg.Jobs...)
+}
+
+// StartInstance implements environs.InstanceBroker.
+func (env *environ) StartInstance(args environs.StartInstanceParams) (*environs.StartInstanceResult, error) {
+	env = env.getSnapshot()
+
+	if args.MachineConfig.HasNetworks() {
+		return nil, errors.New("starting instances with networks is not supported yet")
+	}
+
+	zones, err := env.parseAvailabilityZones(args)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	instanceTypes, err := env.instanceTypes()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	group := groupMachine
+	if isStateServer(args.MachineConfig) {
+		group = groupStateServer
+	}
+	groupIDs, err := env.setUpGroups(args.MachineConfig.MachineId, env.Config().APIPort())
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot set up security groups")
+	}
+
+	// Try each of the zones in turn, since a zone may not have a
+	// suitable template or may not have the capacity for another
+	// instance.
+	var lastErr error
+	for _, zone := range zones {
+		spec, err := env.findInstanceSpec(zone, args, instanceTypes)
+		if err != nil {
+			logger.Infof("cannot find instance spec in zone %q: %v", zone.Name, err)
+			lastErr = err
+			continue
+		}
+		if err := env.finishMachineConfig(args, spec); err != nil {
+			return nil, errors.Trace(err)
+		}
+		userData, err := environs.ComposeUserData(args.MachineConfig, nil)
+		if err != nil {
+			return nil, errors.Annotate(err, "cannot make user data")
+		}
+		logger.Debugf("CloudStack user data; %d bytes", len(userData))
+
+		name := common.MachineFullName(env, args.MachineConfig.MachineId)
+		vm, err := env.client.DeployVirtualMachine(client.DeployParams{
+			Name:              name,
+			Group:             group,
+			ZoneID:            zone.ID,
+			ServiceOfferingID: spec.InstanceType.Id,
+			TemplateID:        spec.Image.Id,
+			SecurityGroupIDs:  groupIDs,
+			RootDiskSize:      rootDiskSize(args.Constraints),
+			UserData:          userData,
+		})
+		if err != nil {
+			logger.Warningf("cannot start instance in zone %q: %v", zone.Name, err)
+			// A failed deployment may leave a virtual machine in
+			// the Error state, which would prevent deploying one
+			// with the same name in another zone.
+			if err := env.destroyFailedInstance(name); err != nil {
+				return nil, errors.Trace(err)
+			}
+			lastErr = err
+			continue
+		}
+		logger.Infof("started instance %q in zone %q", vm.ID, vm.ZoneName)
+		inst := newInstance(vm, env)
+
+		// Build the result.
+		hwc := env.getHardwareCharacteristics(spec, inst, args.Constraints)
+		result := environs.StartInstanceResult{
+			Instance: inst,
+			Hardware: hwc,
+		}
+		return &result, nil
+	}
+	return nil, errors.Annotate(lastErr, "cannot start instance")
+}
+
+// destroyFailedInstance destroys any virtual machine with the given
+// name left behind by a failed deployment.
+func (env *environ) destroyFailedInstance(name string) error {
+	vms, err := env.client.ListVirtualMachines("")
+	if err != nil {
+		return errors.Annotate(err, "cannot list instances")
+	}
+	for _, vm := range vms {
+		if vm.Name != name {
+			continue
+		}
+		logger.Infof("destroying failed instance %q in zone %q", vm.ID, vm.ZoneName)
+		if err := env.client.DestroyVirtualMachine(vm.ID); err != nil {
+			return errors.Annotatef(err, "cannot destroy failed instance %q", vm.ID)
+		}
+	}
+	return nil
+}
+
+// finishMachineConfig updates args.MachineConfig in place. Setting up
+// the API, StateServing, and SSHkeys information.
+func (env *environ) finishMachineConfig(args environs.StartInstanceParams, spec *instances.InstanceSpec) error {
+	envTools, err := args.Tools.Match(tools.Filter{Arch: spec.Image.Arch})
+	if err != nil {
+		return errors.Errorf("chosen architecture %v not present in %v", spec.Image.Arch, args.Tools.Arches())
+	}
+
+	args.MachineConfig.Tools = envTools[0]
+	return environs.FinishMachineConfig(args.MachineConfig, env.Config())
+}
+
+// findInstanceSpec returns the instance spec which best satisfies the
+// given arguments in the given zone.
+func (env *environ) findInstanceSpec(zone client.Zone, args environs.StartInstanceParams, instanceTypes []instances.InstanceType) (*instances.InstanceSpec, error) {
+	series := args.Tools.OneSeries()
+	templates, err := env.client.ListTemplates(zone.ID)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	spec, err := instances.FindInstanceSpec(templateImages(templates, series), &instances.InstanceConstraint{
+		Region:      zone.Name,
+		Series:      series,
+		Arches:      args.Tools.Arches(),
+		Constraints: args.Constraints,
+	}, instanceTypes)
+	return spec, errors.Trace(err)
+}
+
+// templateImages returns the images for the ready templates which
+// contain the given series. The series and architecture of a
+// template are determined by its OS type name, for example
+// "Ubuntu 14.04 (64-bit)".
+func templateImages(templates []client.Template, series string) []instances.Image {
+	seriesVersion, err := version.SeriesVersion(series)
+	if err != nil {
+		return nil
+	}
+	var images []instances.Image
+	for _, template := range templates {
+		if !template.IsReady {
+			continue
+		}
+		if !strings.HasPrefix(template.OSTypeName, "Ubuntu "+seriesVersion) {
+			continue
+		}
+		if imageArch := templateArch(template); imageArch != "" {
+			images = append(images, instances.Image{
+				Id:   template.ID,
+				Arch: imageArch,
+			})
+		}
+	}
+	return images
+}
+
+// templateArch returns the architecture of the given template, or ""
+// if it cannot be determined.
+func templateArch(template client.Template) string {
+	switch {
+	case strings.HasSuffix(template.OSTypeName, "(64-bit)"):
+		return arch.AMD64
+	case strings.HasSuffix(template.OSTypeName, "(32-bit)"):
+		return arch.I386
+	}
+	return ""
+}
+
+// instanceTypes returns the instance types corresponding to the
+// account's service offerings. Customized offerings, whose resources
+// are chosen when deploying, are not supported.
+func (env *environ) instanceTypes() ([]instances.InstanceType, error) {
+	offerings, err := env.client.ListServiceOfferings()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var result []instances.InstanceType
+	for _, offering := range offerings {
+		if offering.CPUNumber <= 0 || offering.Memory <= 0 {
+			continue
+		}
+		itype := instances.InstanceType{
+			Id:       offering.ID,
+			Name:     offering.Name,
+			Arches:   []string{arch.AMD64, arch.I386},
+			CpuCores: uint64(offering.CPUNumber),
+			Mem:      uint64(offering.Memory),
+		}
+		if offering.CPUSpeed > 0 {
+			// A CPU power of 100 is roughly one 1GHz core.
+			itype.CpuPower = instances.CpuPower(uint64(offering.CPUNumber * offering.CPUSpeed / 10))
+		}
+		result = append(result, itype)
+	}
+	return result, nil
+}
+
+// rootDiskSize returns the size of the root volume, in GB, to request
+// for the given constraints, or zero to use the template's size.
+func rootDiskSize(cons constraints.Value) uint64 {
+	if cons.RootDisk == nil {
+		return 0
+	}
+	size := common.MiBToGiB(*cons.RootDisk)
+	if size < common.MinRootDiskSizeGiB {
+		size = common.MinRootDiskSizeGiB
+	}
+	return size
+}
+
+// getHardwareCharacteristics compiles hardware-related details about
+// the given instance and relative to the provided spec and returns it.
+func (env *environ) getHardwareCharacteristics(spec *instances.InstanceSpec, inst *environInstance, cons constraints.Value) *instance.HardwareCharacteristics {
+	hwc := instance.HardwareCharacteristics{
+		Arch:             &spec.Image.Arch,
+		Mem:              &spec.InstanceType.Mem,
+		CpuCores:         &spec.InstanceType.CpuCores,
+		CpuPower:         spec.InstanceType.CpuPower,
+		AvailabilityZone: &inst.base.ZoneName,
+	}
+	if size := rootDiskSize(cons); size > 0 {
+		rootDiskMB := size * 1024
+		hwc.RootDisk = &rootDiskMB
+	}
+	return &hwc
+}
+
+// AllInstances implements environs.InstanceBroker.
+func (env *environ) AllInstances() ([]instance.Instance, error) {
+	instances, err := env.instances()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	results := make([]instance.Instance, len(instances))
+	for i, inst := range instances {
+		results[i] = inst
+	}
+	return results, nil
+}
+
+// StopInstances implements environs.InstanceBroker.
+func (env *environ) StopInstances(ids ...instance.Id) error {
+	env = env.getSnapshot()
+
+	instances, err := env.instances()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, id := range ids {
+		inst, ok := findInst(id, instances).(*environInstance)
+		if !ok {
+			// Unknown instance IDs are ignored.
+			continue
+		}
+		if err := env.client.DestroyVirtualMachine(inst.base.ID); err != nil {
+			return errors.Annotatef(err, "cannot stop instance %q", id)
+		}
+		// The machine's security group is named after the
+		// instance; it is only used in the "instance"
+		// firewall mode.
+		if env.Config().FirewallMode() == config.FwInstance {
+			if err := env.deleteSecurityGroup(inst.base.Name); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	return nil
+}
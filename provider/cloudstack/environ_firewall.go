@@ -0,0 +1,309 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cloudstack
+
+import (
+	"strings"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/provider/cloudstack/client"
+	"github.com/juju/juju/provider/common"
+)
+
+// envGroupName returns the name of the security group of which all
+// the environment's instances are members. It admits SSH from
+// anywhere, connections to the API server, and all traffic between
+// its members.
+func (env *environ) envGroupName() string {
+	return common.EnvFullName(env)
+}
+
+// globalGroupName returns the name of the security group holding the
+// ports opened for the whole environment, in the "global" firewall
+// mode.
+func (env *environ) globalGroupName() string {
+	return common.EnvFullName(env) + "-global"
+}
+
+// machineGroupName returns the name of the security group holding the
+// ports opened on the given machine, in the "instance" firewall mode.
+func (env *environ) machineGroupName(machineID string) string {
+	return common.MachineFullName(env, machineID)
+}
+
+// setUpGroups ensures the security groups for a new instance of the
+// given machine exist, and returns their ids. The API server port is
+// opened for the whole environment.
+func (env *environ) setUpGroups(machineID string, apiPort int) ([]string, error) {
+	envGroup, err := env.ensureSecurityGroup(env.envGroupName())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	rules := []network.PortRange{{
+		FromPort: 22,
+		ToPort:   22,
+		Protocol: "tcp",
+	}, {
+		FromPort: apiPort,
+		ToPort:   apiPort,
+		Protocol: "tcp",
+	}}
+	if envGroup, err = env.authorizePorts(envGroup, rules); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := env.authorizeMembers(envGroup); err != nil {
+		return nil, errors.Trace(err)
+	}
+	groupIDs := []string{envGroup.ID}
+
+	var portsGroupName string
+	switch env.Config().FirewallMode() {
+	case config.FwGlobal:
+		portsGroupName = env.globalGroupName()
+	case config.FwInstance:
+		portsGroupName = env.machineGroupName(machineID)
+	}
+	if portsGroupName != "" {
+		portsGroup, err := env.ensureSecurityGroup(portsGroupName)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		groupIDs = append(groupIDs, portsGroup.ID)
+	}
+	return groupIDs, nil
+}
+
+// ensureSecurityGroup returns the security group with the given name,
+// creating it if necessary.
+func (env *environ) ensureSecurityGroup(name string) (*client.SecurityGroup, error) {
+	group, err := env.securityGroup(name)
+	if err == nil {
+		return group, nil
+	} else if !errors.IsNotFound(err) {
+		return nil, errors.Trace(err)
+	}
+	group, err = env.client.CreateSecurityGroup(name, "juju group "+name)
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot create security group %q", name)
+	}
+	return group, nil
+}
+
+// securityGroup returns the security group with the given name.
+func (env *environ) securityGroup(name string) (*client.SecurityGroup, error) {
+	groups, err := env.client.ListSecurityGroups(name)
+	if client.IsParamError(err) {
+		// Older versions of CloudStack report unknown
+		// group names as invalid parameters.
+		return nil, errors.NotFoundf("security group %q", name)
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, group := range groups {
+		if group.Name == name {
+			return &group, nil
+		}
+	}
+	return nil, errors.NotFoundf("security group %q", name)
+}
+
+// deleteSecurityGroup deletes the security group with the given name,
+// if it exists.
+func (env *environ) deleteSecurityGroup(name string) error {
+	err := env.client.DeleteSecurityGroup(name)
+	if client.IsParamError(err) {
+		if _, err := env.securityGroup(name); errors.IsNotFound(err) {
+			return nil
+		}
+	}
+	return errors.Annotatef(err, "cannot delete security group %q", name)
+}
+
+// authorizeMembers ensures the given group admits all traffic from
+// its members.
+func (env *environ) authorizeMembers(group *client.SecurityGroup) error {
+	for _, protocol := range []string{"tcp", "udp", "icmp"} {
+		found := false
+		for _, rule := range group.IngressRule {
+			if rule.SecurityGroupName == group.Name && rule.Protocol == protocol {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		_, err := env.client.AuthorizeSecurityGroupIngress(client.IngressParams{
+			SecurityGroupID: group.ID,
+			Protocol:        protocol,
+			StartPort:       1,
+			EndPort:         65535,
+			SourceGroup:     group.Name,
+			SourceAccount:   group.Account,
+		})
+		if err != nil {
+			return errors.Annotatef(err, "cannot authorize members of security group %q", group.Name)
+		}
+	}
+	return nil
+}
+
+// authorizePorts ensures the given group admits traffic to the given
+// port ranges, returning the updated group.
+func (env *environ) authorizePorts(group *client.SecurityGroup, ports []network.PortRange) (*client.SecurityGroup, error) {
+	for _, portRange := range ports {
+		if findRule(group, portRange) != nil {
+			continue
+		}
+		updated, err := env.client.AuthorizeSecurityGroupIngress(client.IngressParams{
+			SecurityGroupID: group.ID,
+			Protocol:        strings.ToLower(portRange.Protocol),
+			StartPort:       portRange.FromPort,
+			EndPort:         portRange.ToPort,
+			CIDR:            sourceCIDR(portRange),
+		})
+		if err != nil {
+			return nil, errors.Annotatef(err, "cannot open ports %v in security group %q", portRange, group.Name)
+		}
+		group = updated
+	}
+	return group, nil
+}
+
+// sourceCIDR returns the CIDR from which traffic to the given port
+// range is admitted.
+func sourceCIDR(ports network.PortRange) string {
+	if ports.SourceCIDR == "" {
+		return anyCIDR
+	}
+	return ports.SourceCIDR
+}
+
+// findRule returns the rule in the given group which admits traffic
+// to the given port range, or nil if there is none.
+func findRule(group *client.SecurityGroup, ports network.PortRange) *client.IngressRule {
+	for i, rule := range group.IngressRule {
+		if rule.SecurityGroupName == "" &&
+			rule.Protocol == strings.ToLower(ports.Protocol) &&
+			rule.StartPort == ports.FromPort &&
+			rule.EndPort == ports.ToPort &&
+			rule.CIDR == sourceCIDR(ports) {
+			return &group.IngressRule[i]
+		}
+	}
+	return nil
+}
+
+// openPortsInGroup opens the given port ranges in the security group
+// with the given name, creating it if necessary.
+func (env *environ) openPortsInGroup(name string, ports []network.PortRange) error {
+	group, err := env.ensureSecurityGroup(name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	_, err = env.authorizePorts(group, ports)
+	return errors.Trace(err)
+}
+
+// closePortsInGroup closes the given port ranges in the security
+// group with the given name. Port ranges which are not open are
+// ignored.
+func (env *environ) closePortsInGroup(name string, ports []network.PortRange) error {
+	group, err := env.securityGroup(name)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	for _, portRange := range ports {
+		rule := findRule(group, portRange)
+		if rule == nil {
+			continue
+		}
+		if err := env.client.RevokeSecurityGroupIngress(rule.RuleID); err != nil {
+			return errors.Annotatef(err, "cannot close ports %v in security group %q", portRange, name)
+		}
+	}
+	return nil
+}
+
+// portsInGroup returns the port ranges opened in the security group
+// with the given name.
+func (env *environ) portsInGroup(name string) ([]network.PortRange, error) {
+	group, err := env.securityGroup(name)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var ports []network.PortRange
+	for _, rule := range group.IngressRule {
+		if rule.SecurityGroupName != "" || rule.Protocol == "icmp" {
+			continue
+		}
+		portRange := network.PortRange{
+			FromPort: rule.StartPort,
+			ToPort:   rule.EndPort,
+			Protocol: rule.Protocol,
+		}
+		if rule.CIDR != anyCIDR {
+			portRange.SourceCIDR = rule.CIDR
+		}
+		ports = append(ports, portRange)
+	}
+	network.SortPortRanges(ports)
+	return ports, nil
+}
+
+func (env *environ) checkGlobalFirewallMode(action string) error {
+	if mode := env.Config().FirewallMode(); mode != config.FwGlobal {
+		return errors.Errorf("invalid firewall mode %q for %s ports on environment", mode, action)
+	}
+	return nil
+}
+
+// OpenPorts opens the given port ranges for the whole environment.
+// Must only be used if the environment was setup with the
+// FwGlobal firewall mode.
+func (env *environ) OpenPorts(ports []network.PortRange) error {
+	if err := env.checkGlobalFirewallMode("opening"); err != nil {
+		return errors.Trace(err)
+	}
+	env = env.getSnapshot()
+	err := env.openPortsInGroup(env.globalGroupName(), ports)
+	return errors.Trace(err)
+}
+
+// ClosePorts closes the given port ranges for the whole environment.
+// Must only be used if the environment was setup with the
+// FwGlobal firewall mode.
+func (env *environ) ClosePorts(ports []network.PortRange) error {
+	if err := env.checkGlobalFirewallMode("closing"); err != nil {
+		return errors.Trace(err)
+	}
+	env = env.getSnapshot()
+	err := env.closePortsInGroup(env.globalGroupName(), ports)
+	return errors.Trace(err)
+}
+
+// Ports returns the port ranges opened for the whole environment.
+// Must only be used if the environment was setup with the
+// FwGlobal firewall mode.
+func (env *environ) Ports() ([]network.PortRange, error) {
+	if err := env.checkGlobalFirewallMode("retrieving"); err != nil {
+		return nil, errors.Trace(err)
+	}
+	env = env.getSnapshot()
+	ports, err := env.portsInGroup(env.globalGroupName())
+	return ports, errors.Trace(err)
+}
+
+// SupportsSourceCIDRs is specified in the environs.SourceCIDRFirewaller
+// interface.
+func (env *environ) SupportsSourceCIDRs() bool {
+	return true
+}
@@ -0,0 +1,142 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cloudstack
+
+import (
+	"sort"
+
+	"github.com/juju/errors"
+	"github.com/juju/utils/set"
+
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/network"
+)
+
+// PrecheckInstance verifies that the provided series and constraints
+// are valid for use in creating an instance in this environment.
+func (env *environ) PrecheckInstance(series string, cons constraints.Value, placement string) error {
+	if _, err := env.parsePlacement(placement); err != nil {
+		return errors.Trace(err)
+	}
+
+	if cons.HasInstanceType() {
+		instanceTypes, err := env.getSnapshot().instanceTypes()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for _, itype := range instanceTypes {
+			if itype.Name == *cons.InstanceType {
+				return nil
+			}
+		}
+		return errors.Errorf("invalid CloudStack instance type %q", *cons.InstanceType)
+	}
+
+	return nil
+}
+
+// SupportedArchitectures returns the image architectures which can
+// be hosted by this environment.
+func (env *environ) SupportedArchitectures() ([]string, error) {
+	env.archLock.Lock()
+	defer env.archLock.Unlock()
+
+	if env.supportedArchitectures != nil {
+		return env.supportedArchitectures, nil
+	}
+
+	archList, err := env.lookupArchitectures()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	env.supportedArchitectures = archList
+	return archList, nil
+}
+
+// lookupArchitectures returns the architectures of the templates
+// available in any zone.
+func (env *environ) lookupArchitectures() ([]string, error) {
+	templates, err := env.getSnapshot().client.ListTemplates("")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	arches := set.NewStrings()
+	for _, template := range templates {
+		if template.IsReady {
+			if imageArch := templateArch(template); imageArch != "" {
+				arches.Add(imageArch)
+			}
+		}
+	}
+	return arches.SortedValues(), nil
+}
+
+var unsupportedConstraints = []string{
+	constraints.Tags,
+	constraints.Networks,
+	constraints.Spaces,
+}
+
+// instanceTypeConstraints defines the fields defined on each of the
+// instance types, which correspond to service offerings.
+var instanceTypeConstraints = []string{
+	constraints.CpuCores,
+	constraints.CpuPower,
+	constraints.Mem,
+}
+
+// ConstraintsValidator returns a Validator value which is used to
+// validate and merge constraints.
+func (env *environ) ConstraintsValidator() (constraints.Validator, error) {
+	validator := constraints.NewValidator()
+
+	// conflicts
+
+	validator.RegisterConflicts(
+		[]string{constraints.InstanceType},
+		instanceTypeConstraints,
+	)
+
+	// unsupported
+
+	validator.RegisterUnsupported(unsupportedConstraints)
+
+	// vocab
+
+	supportedArches, err := env.SupportedArchitectures()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	validator.RegisterVocabulary(constraints.Arch, supportedArches)
+
+	instanceTypes, err := env.getSnapshot().instanceTypes()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	instTypeNames := make([]string, len(instanceTypes))
+	for i, itype := range instanceTypes {
+		instTypeNames[i] = itype.Name
+	}
+	sort.Strings(instTypeNames)
+	validator.RegisterVocabulary(constraints.InstanceType, instTypeNames)
+
+	return validator, nil
+}
+
+// environ provides SupportsUnitPlacement (a method of the
+// state.EnvironCapatability interface) by embedding
+// common.SupportsUnitPlacementPolicy.
+
+// SupportNetworks returns whether the environment has support to
+// specify networks for services and machines.
+func (env *environ) SupportNetworks() bool {
+	return false
+}
+
+// SupportAddressAllocation takes a network.Id and returns a bool
+// and an error. The bool indicates whether that network supports
+// static ip address allocation.
+func (env *environ) SupportAddressAllocation(netID network.Id) (bool, error) {
+	return false, nil
+}
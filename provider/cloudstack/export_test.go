@@ -0,0 +1,14 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cloudstack
+
+import (
+	"github.com/juju/juju/environs"
+)
+
+var (
+	Provider        environs.EnvironProvider = providerInstance
+	ConfigImmutable                          = configImmutableFields
+	DeviceName                               = deviceName
+)
@@ -0,0 +1,23 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cloudstack
+
+import (
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/storage/provider/registry"
+)
+
+const (
+	providerType = "cloudstack"
+)
+
+func init() {
+	environs.RegisterProvider(providerType, providerInstance)
+
+	// Register the CloudStack specific storage providers.
+	registry.RegisterProvider(VolumeProviderType, &volumeProvider{})
+
+	// Inform the storage provider registry about the CloudStack providers.
+	registry.RegisterEnvironStorageProviders(providerType, VolumeProviderType)
+}
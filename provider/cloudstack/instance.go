@@ -0,0 +1,129 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cloudstack
+
+import (
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/provider/cloudstack/client"
+	"github.com/juju/juju/provider/common"
+)
+
+type environInstance struct {
+	base *client.VirtualMachine
+	env  *environ
+}
+
+var _ instance.Instance = (*environInstance)(nil)
+
+func newInstance(base *client.VirtualMachine, env *environ) *environInstance {
+	return &environInstance{
+		base: base,
+		env:  env,
+	}
+}
+
+// Id implements instance.Instance.
+func (inst *environInstance) Id() instance.Id {
+	return instance.Id(inst.base.ID)
+}
+
+// Status implements instance.Instance.
+func (inst *environInstance) Status() string {
+	return inst.base.State
+}
+
+// Refresh implements instance.Instance.
+func (inst *environInstance) Refresh() error {
+	env := inst.env.getSnapshot()
+	vms, err := env.client.ListVirtualMachines(inst.base.ID)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(vms) != 1 {
+		return errors.NotFoundf("instance %q", inst.base.ID)
+	}
+	inst.base = &vms[0]
+	return nil
+}
+
+// Addresses implements instance.Instance.
+func (inst *environInstance) Addresses() ([]network.Address, error) {
+	var addresses []network.Address
+	if inst.base.PublicIP != "" {
+		addresses = append(addresses, network.NewScopedAddress(inst.base.PublicIP, network.ScopePublic))
+	}
+	for _, nic := range inst.base.NIC {
+		for _, value := range []string{nic.IPAddress, nic.IP6Address} {
+			if value == "" {
+				continue
+			}
+			// The scope of NIC addresses is derived from their
+			// value, since basic zones give instances public
+			// addresses directly.
+			address := network.NewAddress(value)
+			address.NetworkName = nic.NetworkName
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses, nil
+}
+
+func findInst(id instance.Id, instances []*environInstance) instance.Instance {
+	for _, inst := range instances {
+		if id == inst.Id() {
+			return inst
+		}
+	}
+	return nil
+}
+
+// firewall stuff
+
+// OpenPorts opens the given ports on the instance, which
+// should have been started with the given machine id.
+func (inst *environInstance) OpenPorts(machineID string, ports []network.PortRange) error {
+	if err := inst.checkFirewallMode("opening"); err != nil {
+		return errors.Trace(err)
+	}
+	name := common.MachineFullName(inst.env, machineID)
+	env := inst.env.getSnapshot()
+	err := env.openPortsInGroup(name, ports)
+	return errors.Trace(err)
+}
+
+// ClosePorts closes the given ports on the instance, which
+// should have been started with the given machine id.
+func (inst *environInstance) ClosePorts(machineID string, ports []network.PortRange) error {
+	if err := inst.checkFirewallMode("closing"); err != nil {
+		return errors.Trace(err)
+	}
+	name := common.MachineFullName(inst.env, machineID)
+	env := inst.env.getSnapshot()
+	err := env.closePortsInGroup(name, ports)
+	return errors.Trace(err)
+}
+
+// Ports returns the set of ports open on the instance, which
+// should have been started with the given machine id.
+// The ports are returned as sorted by SortPorts.
+func (inst *environInstance) Ports(machineID string) ([]network.PortRange, error) {
+	if err := inst.checkFirewallMode("retrieving"); err != nil {
+		return nil, errors.Trace(err)
+	}
+	name := common.MachineFullName(inst.env, machineID)
+	env := inst.env.getSnapshot()
+	ports, err := env.portsInGroup(name)
+	return ports, errors.Trace(err)
+}
+
+func (inst *environInstance) checkFirewallMode(action string) error {
+	if mode := inst.env.Config().FirewallMode(); mode != config.FwInstance {
+		return errors.Errorf("invalid firewall mode %q for %s ports on instance", mode, action)
+	}
+	return nil
+}
@@ -0,0 +1,457 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cloudstack_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/environs/imagemetadata"
+	"github.com/juju/juju/instance"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/provider/cloudstack/client"
+	"github.com/juju/juju/provider/cloudstack/cloudstacktest"
+	"github.com/juju/juju/provider/common"
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/tools"
+	"github.com/juju/juju/version"
+)
+
+var testTools = tools.List{{
+	Version: version.MustParseBinary("1.24.0-trusty-amd64"),
+	URL:     "https://example.com/juju-1.24.0-trusty-amd64.tgz",
+}}
+
+// localServerSuite holds the state common to the tests which run
+// against a fake CloudStack API server.
+type localServerSuite struct {
+	testing.BaseSuite
+
+	srv   *cloudstacktest.Server
+	zone  client.Zone
+	small client.ServiceOffering
+	env   environs.Environ
+}
+
+func (s *localServerSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.PatchValue(&client.JobPollStrategy, utils.AttemptStrategy{})
+
+	s.srv = cloudstacktest.NewServer("api-key", "secret-key")
+	s.AddCleanup(func(*gc.C) { s.srv.Close() })
+	s.zone = s.addZone("zone-a")
+	s.small = s.srv.AddServiceOffering(client.ServiceOffering{
+		Name:      "small",
+		CPUNumber: 1,
+		CPUSpeed:  1000,
+		Memory:    1024,
+	})
+	s.srv.AddServiceOffering(client.ServiceOffering{
+		Name:      "large",
+		CPUNumber: 4,
+		CPUSpeed:  2000,
+		Memory:    8192,
+	})
+	s.env = s.newEnviron(c, nil)
+}
+
+// addZone adds an enabled zone with security groups and an Ubuntu
+// 14.04 template.
+func (s *localServerSuite) addZone(name string) client.Zone {
+	zone := s.srv.AddZone(client.Zone{
+		Name:                  name,
+		AllocationState:       client.ZoneEnabled,
+		NetworkType:           "Basic",
+		SecurityGroupsEnabled: true,
+	})
+	s.srv.AddTemplate(client.Template{
+		Name:       "trusty",
+		OSTypeName: "Ubuntu 14.04 (64-bit)",
+		ZoneID:     zone.ID,
+		IsReady:    true,
+		Hypervisor: "KVM",
+	})
+	return zone
+}
+
+func (s *localServerSuite) newEnviron(c *gc.C, attrs testing.Attrs) environs.Environ {
+	cfg := testing.CustomEnvironConfig(c, configAttrs.Merge(testing.Attrs{
+		"api-url": s.srv.URL(),
+	}).Merge(attrs))
+	env, err := environs.New(cfg)
+	c.Assert(err, jc.ErrorIsNil)
+	return env
+}
+
+// startInstance starts an instance of the machine with the given id.
+func (s *localServerSuite) startInstance(c *gc.C, machineId string, params environs.StartInstanceParams) (*environs.StartInstanceResult, error) {
+	mcfg, err := environs.NewMachineConfig(
+		machineId, "fake_nonce", imagemetadata.ReleasedStream, "trusty", true, nil,
+		jujutesting.FakeStateInfo(machineId), jujutesting.FakeAPIInfo(machineId),
+	)
+	c.Assert(err, jc.ErrorIsNil)
+	params.MachineConfig = mcfg
+	params.Tools = testTools
+	return s.env.StartInstance(params)
+}
+
+func (s *localServerSuite) assertStartInstance(c *gc.C, machineId string) *environs.StartInstanceResult {
+	result, err := s.startInstance(c, machineId, environs.StartInstanceParams{})
+	c.Assert(err, jc.ErrorIsNil)
+	return result
+}
+
+type environSuite struct {
+	localServerSuite
+}
+
+var _ = gc.Suite(&environSuite{})
+
+func (s *environSuite) TestStartInstance(c *gc.C) {
+	result := s.assertStartInstance(c, "1")
+
+	vms := s.srv.VirtualMachines()
+	c.Assert(vms, gc.HasLen, 1)
+	vm := vms[0]
+	c.Check(result.Instance.Id(), gc.Equals, instance.Id(vm.ID))
+	c.Check(vm.Name, gc.Equals, common.MachineFullName(s.env, "1"))
+	c.Check(vm.Group, gc.Equals, "juju-machine")
+	c.Check(vm.ServiceOfferingID, gc.Equals, s.small.ID)
+
+	envGroup := common.EnvFullName(s.env)
+	var groups []string
+	for _, ref := range vm.SecurityGroup {
+		groups = append(groups, ref.Name)
+	}
+	c.Check(groups, jc.DeepEquals, []string{envGroup, vm.Name})
+
+	arch := "amd64"
+	mem := uint64(1024)
+	cores := uint64(1)
+	power := uint64(100)
+	zone := "zone-a"
+	c.Check(*result.Hardware, jc.DeepEquals, instance.HardwareCharacteristics{
+		Arch:             &arch,
+		Mem:              &mem,
+		CpuCores:         &cores,
+		CpuPower:         &power,
+		AvailabilityZone: &zone,
+	})
+
+	addrs, err := result.Instance.Addresses()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(addrs, jc.DeepEquals, []network.Address{
+		network.NewAddress(vm.NIC[0].IPAddress),
+	})
+}
+
+func (s *environSuite) TestStartInstanceOpensEnvironmentPorts(c *gc.C) {
+	s.assertStartInstance(c, "1")
+
+	group, ok := s.srv.SecurityGroup(common.EnvFullName(s.env))
+	c.Assert(ok, jc.IsTrue)
+	var ports []int
+	sources := make(map[string]bool)
+	for _, rule := range group.IngressRule {
+		if rule.SecurityGroupName != "" {
+			c.Check(rule.SecurityGroupName, gc.Equals, group.Name)
+			sources[rule.Protocol] = true
+			continue
+		}
+		c.Check(rule.CIDR, gc.Equals, "0.0.0.0/0")
+		ports = append(ports, rule.StartPort)
+	}
+	c.Check(ports, jc.DeepEquals, []int{22, s.env.Config().APIPort()})
+	c.Check(sources, jc.DeepEquals, map[string]bool{"tcp": true, "udp": true, "icmp": true})
+
+	// Starting another instance reuses the environment's group.
+	s.assertStartInstance(c, "2")
+	again, _ := s.srv.SecurityGroup(common.EnvFullName(s.env))
+	c.Check(again.IngressRule, gc.HasLen, len(group.IngressRule))
+}
+
+func (s *environSuite) TestStartInstanceConstraints(c *gc.C) {
+	result, err := s.startInstance(c, "1", environs.StartInstanceParams{
+		Constraints: constraints.MustParse("mem=4G root-disk=20G"),
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(*result.Hardware.Mem, gc.Equals, uint64(8192))
+	c.Check(*result.Hardware.RootDisk, gc.Equals, uint64(20*1024))
+
+	vms := s.srv.VirtualMachines()
+	c.Assert(vms, gc.HasLen, 1)
+	c.Check(vms[0].CPUNumber, gc.Equals, 4)
+
+	var rootSize int64
+	for _, vol := range s.srv.Volumes() {
+		if vol.Type == "ROOT" {
+			rootSize = vol.Size
+		}
+	}
+	c.Check(rootSize, gc.Equals, int64(20<<30))
+}
+
+func (s *environSuite) TestStartInstanceNoMatchingTemplate(c *gc.C) {
+	s.srv.AddZone(client.Zone{
+		Name:                  "zone-b",
+		AllocationState:       client.ZoneEnabled,
+		SecurityGroupsEnabled: true,
+	})
+	_, err := s.startInstance(c, "1", environs.StartInstanceParams{
+		Placement: "zone=zone-b",
+	})
+	c.Assert(err, gc.ErrorMatches, `cannot start instance: no "trusty" images in zone-b with arches \[amd64\]`)
+	c.Assert(s.srv.VirtualMachines(), gc.HasLen, 0)
+}
+
+func (s *environSuite) TestStartInstanceFailsOverToNextZone(c *gc.C) {
+	s.addZone("zone-b")
+	s.srv.FailCommand("deployVirtualMachine", "insufficient capacity")
+	_, err := s.startInstance(c, "1", environs.StartInstanceParams{})
+	c.Assert(err, gc.ErrorMatches, "cannot start instance: deployVirtualMachine failed: insufficient capacity .*")
+}
+
+func (s *environSuite) TestStartInstanceDestroysFailedInstances(c *gc.C) {
+	s.addZone("zone-b")
+	s.srv.FailDeployments("zone-a", "insufficient capacity")
+	result, err := s.startInstance(c, "1", environs.StartInstanceParams{})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(*result.Hardware.AvailabilityZone, gc.Equals, "zone-b")
+	vms := s.srv.VirtualMachines()
+	c.Assert(vms, gc.HasLen, 1)
+	c.Check(vms[0].ZoneName, gc.Equals, "zone-b")
+	c.Check(vms[0].State, gc.Equals, client.StateRunning)
+
+	s.srv.FailDeployments("zone-b", "insufficient capacity")
+	_, err = s.startInstance(c, "2", environs.StartInstanceParams{})
+	c.Assert(err, gc.ErrorMatches, "cannot start instance: deployVirtualMachine failed: insufficient capacity .*")
+	c.Assert(s.srv.VirtualMachines(), gc.HasLen, 1)
+}
+
+func (s *environSuite) TestStartInstancePlacement(c *gc.C) {
+	s.addZone("zone-b")
+	result, err := s.startInstance(c, "1", environs.StartInstanceParams{
+		Placement: "zone=zone-b",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(*result.Hardware.AvailabilityZone, gc.Equals, "zone-b")
+}
+
+func (s *environSuite) TestStartInstanceSpreadsAcrossZones(c *gc.C) {
+	s.addZone("zone-b")
+	var zones []string
+	for _, id := range []string{"1", "2"} {
+		result, err := s.startInstance(c, id, environs.StartInstanceParams{
+			DistributionGroup: func() ([]instance.Id, error) {
+				return nil, nil
+			},
+		})
+		c.Assert(err, jc.ErrorIsNil)
+		zones = append(zones, *result.Hardware.AvailabilityZone)
+	}
+	c.Check(zones, jc.SameContents, []string{"zone-a", "zone-b"})
+}
+
+func (s *environSuite) TestAvailabilityZones(c *gc.C) {
+	s.srv.AddZone(client.Zone{
+		Name:            "advanced",
+		AllocationState: client.ZoneEnabled,
+	})
+	s.srv.AddZone(client.Zone{
+		Name:                  "disabled",
+		AllocationState:       client.ZoneDisabled,
+		SecurityGroupsEnabled: true,
+	})
+	zoned := s.env.(common.ZonedEnviron)
+	zones, err := zoned.AvailabilityZones()
+	c.Assert(err, jc.ErrorIsNil)
+	available := make(map[string]bool)
+	for _, zone := range zones {
+		available[zone.Name()] = zone.Available()
+	}
+	c.Check(available, jc.DeepEquals, map[string]bool{
+		"zone-a":   true,
+		"advanced": false,
+		"disabled": false,
+	})
+
+	err = s.env.PrecheckInstance("trusty", constraints.Value{}, "zone=advanced")
+	c.Check(err, gc.ErrorMatches, `availability zone "advanced" does not have security groups enabled`)
+	err = s.env.PrecheckInstance("trusty", constraints.Value{}, "zone=disabled")
+	c.Check(err, gc.ErrorMatches, `availability zone "disabled" is Disabled`)
+	err = s.env.PrecheckInstance("trusty", constraints.Value{}, "zone=unknown")
+	c.Check(err, gc.ErrorMatches, `invalid availability zone "unknown" not found`)
+}
+
+func (s *environSuite) TestInstances(c *gc.C) {
+	inst1 := s.assertStartInstance(c, "1").Instance
+	inst2 := s.assertStartInstance(c, "2").Instance
+
+	insts, err := s.env.Instances([]instance.Id{inst1.Id(), "unknown", inst2.Id()})
+	c.Assert(err, gc.Equals, environs.ErrPartialInstances)
+	c.Check(insts[0].Id(), gc.Equals, inst1.Id())
+	c.Check(insts[1], gc.IsNil)
+	c.Check(insts[2].Id(), gc.Equals, inst2.Id())
+
+	_, err = s.env.Instances([]instance.Id{"unknown"})
+	c.Assert(err, gc.Equals, environs.ErrNoInstances)
+
+	all, err := s.env.AllInstances()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(all, gc.HasLen, 2)
+
+	names, err := s.env.(common.ZonedEnviron).InstanceAvailabilityZoneNames([]instance.Id{inst2.Id()})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(names, jc.DeepEquals, []string{"zone-a"})
+}
+
+func (s *environSuite) TestStopInstances(c *gc.C) {
+	inst1 := s.assertStartInstance(c, "1").Instance
+	inst2 := s.assertStartInstance(c, "2").Instance
+
+	err := s.env.StopInstances(inst1.Id(), "unknown")
+	c.Assert(err, jc.ErrorIsNil)
+
+	vms := s.srv.VirtualMachines()
+	c.Assert(vms, gc.HasLen, 1)
+	c.Check(vms[0].ID, gc.Equals, string(inst2.Id()))
+	_, ok := s.srv.SecurityGroup(common.MachineFullName(s.env, "1"))
+	c.Check(ok, jc.IsFalse)
+	_, ok = s.srv.SecurityGroup(common.MachineFullName(s.env, "2"))
+	c.Check(ok, jc.IsTrue)
+}
+
+func (s *environSuite) TestStateServerInstances(c *gc.C) {
+	_, err := s.env.StateServerInstances()
+	c.Assert(err, gc.Equals, environs.ErrNotBootstrapped)
+
+	s.assertStartInstance(c, "1")
+	mcfg, err := environs.NewBootstrapMachineConfig(constraints.Value{}, "trusty")
+	c.Assert(err, jc.ErrorIsNil)
+	result, err := s.env.StartInstance(environs.StartInstanceParams{
+		MachineConfig: mcfg,
+		Tools:         testTools,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+
+	ids, err := s.env.StateServerInstances()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ids, jc.DeepEquals, []instance.Id{result.Instance.Id()})
+}
+
+func (s *environSuite) TestInstancePorts(c *gc.C) {
+	inst := s.assertStartInstance(c, "1").Instance
+	ports := []network.PortRange{{
+		FromPort: 80,
+		ToPort:   80,
+		Protocol: "tcp",
+	}, {
+		FromPort:   8000,
+		ToPort:     8010,
+		Protocol:   "udp",
+		SourceCIDR: "10.0.0.0/8",
+	}}
+	err := inst.OpenPorts("1", ports)
+	c.Assert(err, jc.ErrorIsNil)
+	// Opening ports twice is not an error.
+	err = inst.OpenPorts("1", ports[:1])
+	c.Assert(err, jc.ErrorIsNil)
+
+	opened, err := inst.Ports("1")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(opened, jc.DeepEquals, ports)
+
+	err = inst.ClosePorts("1", ports[:1])
+	c.Assert(err, jc.ErrorIsNil)
+	opened, err = inst.Ports("1")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(opened, jc.DeepEquals, ports[1:])
+
+	err = s.env.OpenPorts(ports)
+	c.Assert(err, gc.ErrorMatches, `invalid firewall mode "instance" for opening ports on environment`)
+}
+
+func (s *environSuite) TestGlobalPorts(c *gc.C) {
+	s.env = s.newEnviron(c, testing.Attrs{"firewall-mode": config.FwGlobal})
+	inst := s.assertStartInstance(c, "1").Instance
+
+	vms := s.srv.VirtualMachines()
+	c.Assert(vms, gc.HasLen, 1)
+	global := common.EnvFullName(s.env) + "-global"
+	c.Check(vms[0].SecurityGroup[1].Name, gc.Equals, global)
+
+	ports := []network.PortRange{{
+		FromPort: 80,
+		ToPort:   80,
+		Protocol: "tcp",
+	}}
+	err := s.env.OpenPorts(ports)
+	c.Assert(err, jc.ErrorIsNil)
+	opened, err := s.env.Ports()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(opened, jc.DeepEquals, ports)
+
+	err = s.env.ClosePorts(ports)
+	c.Assert(err, jc.ErrorIsNil)
+	opened, err = s.env.Ports()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(opened, gc.HasLen, 0)
+
+	err = inst.OpenPorts("1", ports)
+	c.Assert(err, gc.ErrorMatches, `invalid firewall mode "global" for opening ports on instance`)
+}
+
+func (s *environSuite) TestDestroy(c *gc.C) {
+	s.assertStartInstance(c, "1")
+	s.assertStartInstance(c, "2")
+
+	err := s.env.Destroy()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(s.srv.VirtualMachines(), gc.HasLen, 0)
+	for _, name := range []string{
+		common.EnvFullName(s.env),
+		common.MachineFullName(s.env, "1"),
+		common.MachineFullName(s.env, "2"),
+	} {
+		_, ok := s.srv.SecurityGroup(name)
+		c.Check(ok, jc.IsFalse, gc.Commentf("group %q", name))
+	}
+	_, ok := s.srv.SecurityGroup("default")
+	c.Check(ok, jc.IsTrue)
+}
+
+func (s *environSuite) TestPrecheckInstance(c *gc.C) {
+	err := s.env.PrecheckInstance("trusty", constraints.MustParse("instance-type=large"), "")
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.env.PrecheckInstance("trusty", constraints.MustParse("instance-type=huge"), "")
+	c.Assert(err, gc.ErrorMatches, `invalid CloudStack instance type "huge"`)
+	err = s.env.PrecheckInstance("trusty", constraints.Value{}, "host=foo")
+	c.Assert(err, gc.ErrorMatches, "unknown placement directive: host=foo")
+}
+
+func (s *environSuite) TestConstraintsValidator(c *gc.C) {
+	validator, err := s.env.ConstraintsValidator()
+	c.Assert(err, jc.ErrorIsNil)
+
+	unsupported, err := validator.Validate(constraints.MustParse("arch=amd64 tags=foo mem=1G"))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(unsupported, jc.SameContents, []string{"tags"})
+
+	_, err = validator.Validate(constraints.MustParse("arch=i386"))
+	c.Check(err, gc.ErrorMatches, "invalid constraint value: arch=i386\nvalid values are: \\[amd64\\]")
+	_, err = validator.Validate(constraints.MustParse("instance-type=huge"))
+	c.Check(err, gc.ErrorMatches, "invalid constraint value: instance-type=huge\nvalid values are: \\[large small\\]")
+
+	merged, err := validator.Merge(
+		constraints.MustParse("mem=2G"),
+		constraints.MustParse("instance-type=small"),
+	)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(merged, jc.DeepEquals, constraints.MustParse("instance-type=small"))
+}
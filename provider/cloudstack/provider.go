@@ -0,0 +1,102 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cloudstack
+
+import (
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/config"
+)
+
+type environProvider struct{}
+
+var providerInstance environProvider
+
+// Open implements environs.EnvironProvider.
+func (environProvider) Open(cfg *config.Config) (environs.Environ, error) {
+	env, err := newEnviron(cfg)
+	return env, errors.Trace(err)
+}
+
+// PrepareForBootstrap implements environs.EnvironProvider.
+func (p environProvider) PrepareForBootstrap(ctx environs.BootstrapContext, cfg *config.Config) (environs.Environ, error) {
+	cfg, err := p.PrepareForCreateEnvironment(cfg)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	env, err := newEnviron(cfg)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	if ctx.ShouldVerifyCredentials() {
+		// Listing the zones is the cheapest way to check
+		// that the account's keys are valid.
+		if _, err := env.client.ListZones(); err != nil {
+			return nil, errors.Annotate(err, "cannot verify CloudStack credentials")
+		}
+	}
+	return env, nil
+}
+
+// PrepareForCreateEnvironment is specified in the EnvironProvider interface.
+func (environProvider) PrepareForCreateEnvironment(cfg *config.Config) (*config.Config, error) {
+	// Make any necessary updates to the config. This needs to happen
+	// before any defaults are applied.
+	cfg, err := cfg.Apply(parseOSEnv(cfg))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return cfg, nil
+}
+
+// RestrictedConfigAttributes is specified in the EnvironProvider interface.
+func (environProvider) RestrictedConfigAttributes() []string {
+	return []string{
+		cfgAPIURL,
+		cfgAPIKey,
+		cfgSecretKey,
+	}
+}
+
+// Validate implements environs.EnvironProvider.
+func (environProvider) Validate(cfg, old *config.Config) (valid *config.Config, err error) {
+	if old == nil {
+		ecfg, err := newValidConfig(cfg, configDefaults)
+		if err != nil {
+			return nil, errors.Annotate(err, "invalid config")
+		}
+		return ecfg.Config, nil
+	}
+
+	// The defaults should be set already, so we pass nil.
+	ecfg, err := newValidConfig(old, nil)
+	if err != nil {
+		return nil, errors.Annotate(err, "invalid base config")
+	}
+
+	if err := ecfg.update(cfg); err != nil {
+		return nil, errors.Annotate(err, "invalid config change")
+	}
+
+	return ecfg.Config, nil
+}
+
+// SecretAttrs implements environs.EnvironProvider.
+func (environProvider) SecretAttrs(cfg *config.Config) (map[string]string, error) {
+	// The defaults should be set already, so we pass nil.
+	ecfg, err := newValidConfig(cfg, nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return ecfg.secret(), nil
+}
+
+// BoilerplateConfig implements environs.EnvironProvider.
+func (environProvider) BoilerplateConfig() string {
+	// boilerplateConfig is kept in config.go, in the hope that people editing
+	// config will keep it up to date.
+	return boilerplateConfig
+}
@@ -0,0 +1,356 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cloudstack
+
+import (
+	"fmt"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"github.com/juju/utils/set"
+
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/provider/cloudstack/client"
+	"github.com/juju/juju/provider/common"
+	"github.com/juju/juju/storage"
+)
+
+const (
+	// VolumeProviderType is the storage provider type of CloudStack
+	// data volumes.
+	VolumeProviderType = storage.ProviderType("cloudstack")
+
+	// VolumeDiskOffering is the storage pool config attribute
+	// holding the name of the disk offering with which volumes are
+	// created. If it is not set, the first customized disk offering
+	// is used.
+	VolumeDiskOffering = "disk-offering"
+)
+
+// devicePrefixes maps hypervisors to the prefix of the names of the
+// block devices they give to the volumes attached to their guests.
+// Device names are not predictable under other hypervisors.
+var devicePrefixes = map[string]string{
+	"KVM":       "vd",
+	"XenServer": "xvd",
+}
+
+// volumeProvider creates volume sources which use CloudStack data
+// volumes.
+type volumeProvider struct{}
+
+var _ storage.Provider = (*volumeProvider)(nil)
+
+var validVolumeConfigOptions = set.NewStrings(
+	storage.Persistent,
+	VolumeDiskOffering,
+)
+
+// ValidateConfig is defined on the Provider interface.
+func (p *volumeProvider) ValidateConfig(providerConfig *storage.Config) error {
+	for attr, value := range providerConfig.Attrs() {
+		if !validVolumeConfigOptions.Contains(attr) {
+			return errors.Errorf("unknown provider config option %q", attr)
+		}
+		if attr == VolumeDiskOffering {
+			if _, ok := value.(string); !ok {
+				return errors.Errorf("expected string for %q, got %T", attr, value)
+			}
+		}
+	}
+	return nil
+}
+
+// Supports is defined on the Provider interface.
+func (p *volumeProvider) Supports(k storage.StorageKind) bool {
+	return k == storage.StorageKindBlock
+}
+
+// Scope is defined on the Provider interface.
+func (p *volumeProvider) Scope() storage.Scope {
+	return storage.ScopeEnviron
+}
+
+// Dynamic is defined on the Provider interface.
+func (p *volumeProvider) Dynamic() bool {
+	return true
+}
+
+// VolumeSource is defined on the Provider interface.
+func (p *volumeProvider) VolumeSource(environConfig *config.Config, providerConfig *storage.Config) (storage.VolumeSource, error) {
+	if err := p.ValidateConfig(providerConfig); err != nil {
+		return nil, errors.Trace(err)
+	}
+	// The defaults should be set already, so we pass nil.
+	ecfg, err := newValidConfig(environConfig, nil)
+	if err != nil {
+		return nil, errors.Annotate(err, "invalid config")
+	}
+	uuid, _ := ecfg.UUID()
+	diskOffering, _ := providerConfig.Attrs()[VolumeDiskOffering].(string)
+	return &volumeSource{
+		client:       newClient(ecfg),
+		envUUID:      uuid,
+		diskOffering: diskOffering,
+	}, nil
+}
+
+// FilesystemSource is defined on the Provider interface.
+func (p *volumeProvider) FilesystemSource(environConfig *config.Config, providerConfig *storage.Config) (storage.FilesystemSource, error) {
+	return nil, errors.NotSupportedf("filesystems")
+}
+
+type volumeSource struct {
+	client       *client.Client
+	envUUID      string
+	diskOffering string
+}
+
+var _ storage.VolumeSource = (*volumeSource)(nil)
+
+// volumeName returns the name of the data volume for the volume with
+// the given tag.
+func (v *volumeSource) volumeName(tag names.VolumeTag) string {
+	return fmt.Sprintf("juju-%s-%s", v.envUUID, tag)
+}
+
+// CreateVolumes is specified on the storage.VolumeSource interface.
+//
+// Data volumes outlive the instances they are attached to, so the
+// volumes are always persistent.
+func (v *volumeSource) CreateVolumes(params []storage.VolumeParams) (_ []storage.Volume, _ []storage.VolumeAttachment, err error) {
+	volumes := make([]storage.Volume, 0, len(params))
+	attachments := make([]storage.VolumeAttachment, 0, len(params))
+
+	// If there's an error, we delete any ones that are created.
+	defer func() {
+		if err != nil && len(volumes) > 0 {
+			volIds := make([]string, len(volumes))
+			for i, vol := range volumes {
+				volIds[i] = vol.VolumeId
+			}
+			for i, volErr := range v.DestroyVolumes(volIds) {
+				if volErr != nil {
+					logger.Warningf("error cleaning up volume %v: %v", volumes[i].Tag, volErr)
+				}
+			}
+		}
+	}()
+
+	for _, p := range params {
+		if err := v.ValidateVolumeParams(p); err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+	}
+	offering, err := v.findDiskOffering()
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+
+	for _, p := range params {
+		vm, err := v.virtualMachine(p.Attachment.InstanceId)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		volumeParams := client.VolumeParams{
+			Name:           v.volumeName(p.Tag),
+			ZoneID:         vm.ZoneID,
+			DiskOfferingID: offering.ID,
+		}
+		if offering.IsCustomized {
+			volumeParams.Size = common.MiBToGiB(p.Size)
+		}
+		vol, err := v.client.CreateVolume(volumeParams)
+		if err != nil {
+			return nil, nil, errors.Annotatef(err, "creating volume %v", p.Tag)
+		}
+		volumes = append(volumes, storage.Volume{
+			Tag:        p.Tag,
+			VolumeId:   vol.ID,
+			Size:       uint64(vol.Size >> 20),
+			Persistent: true,
+		})
+
+		deviceName, err := v.attachVolume(vol.ID, vm)
+		if err != nil {
+			return nil, nil, errors.Annotatef(err, "attaching %v to %v", vol.ID, vm.ID)
+		}
+		attachments = append(attachments, storage.VolumeAttachment{
+			Volume:     p.Tag,
+			Machine:    p.Attachment.Machine,
+			DeviceName: deviceName,
+		})
+	}
+	return volumes, attachments, nil
+}
+
+// findDiskOffering returns the disk offering with which volumes are
+// created.
+func (v *volumeSource) findDiskOffering() (*client.DiskOffering, error) {
+	offerings, err := v.client.ListDiskOfferings()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, offering := range offerings {
+		if (v.diskOffering == "" && offering.IsCustomized) || offering.Name == v.diskOffering {
+			return &offering, nil
+		}
+	}
+	if v.diskOffering == "" {
+		return nil, errors.NotFoundf("customized disk offering")
+	}
+	return nil, errors.NotFoundf("disk offering %q", v.diskOffering)
+}
+
+// DescribeVolumes is specified on the storage.VolumeSource interface.
+func (v *volumeSource) DescribeVolumes(volIds []string) ([]storage.Volume, error) {
+	vols := make([]storage.Volume, len(volIds))
+	for i, volumeId := range volIds {
+		vol, err := v.volume(volumeId)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		vols[i] = storage.Volume{
+			VolumeId:   vol.ID,
+			Size:       uint64(vol.Size >> 20),
+			Persistent: true,
+		}
+	}
+	return vols, nil
+}
+
+// DestroyVolumes is specified on the storage.VolumeSource interface.
+func (v *volumeSource) DestroyVolumes(volIds []string) []error {
+	results := make([]error, len(volIds))
+	for i, volumeId := range volIds {
+		if err := v.destroyVolume(volumeId); err != nil {
+			results[i] = errors.Annotatef(err, "destroying %q", volumeId)
+		}
+	}
+	return results
+}
+
+func (v *volumeSource) destroyVolume(volumeId string) error {
+	vol, err := v.volume(volumeId)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	// Attached volumes cannot be deleted.
+	if vol.VirtualMachineID != "" {
+		if err := v.client.DetachVolume(vol.ID); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(v.client.DeleteVolume(vol.ID))
+}
+
+// ValidateVolumeParams is specified on the storage.VolumeSource interface.
+func (v *volumeSource) ValidateVolumeParams(params storage.VolumeParams) error {
+	if params.Attachment == nil || params.Attachment.InstanceId == "" {
+		// Volumes require an instance before they can be created,
+		// in order to create them in the instance's zone.
+		return storage.ErrVolumeNeedsInstance
+	}
+	return nil
+}
+
+// AttachVolumes is specified on the storage.VolumeSource interface.
+func (v *volumeSource) AttachVolumes(attachParams []storage.VolumeAttachmentParams) ([]storage.VolumeAttachment, error) {
+	attachments := make([]storage.VolumeAttachment, len(attachParams))
+	for i, params := range attachParams {
+		vm, err := v.virtualMachine(params.InstanceId)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		deviceName, err := v.attachVolume(params.VolumeId, vm)
+		if err != nil {
+			return nil, errors.Annotatef(err, "attaching %v to %v", params.VolumeId, params.InstanceId)
+		}
+		attachments[i] = storage.VolumeAttachment{
+			Volume:     params.Volume,
+			Machine:    params.Machine,
+			DeviceName: deviceName,
+		}
+	}
+	return attachments, nil
+}
+
+// attachVolume attaches the volume with the given id to the given
+// virtual machine, if it is not already, and returns its device name.
+func (v *volumeSource) attachVolume(volumeId string, vm *client.VirtualMachine) (string, error) {
+	vol, err := v.volume(volumeId)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	switch vol.VirtualMachineID {
+	case vm.ID:
+		// Already attached.
+	case "":
+		if vol, err = v.client.AttachVolume(volumeId, vm.ID); err != nil {
+			return "", errors.Trace(err)
+		}
+	default:
+		return "", errors.Errorf("volume %v is attached to %v", volumeId, vol.VirtualMachineID)
+	}
+	return deviceName(vm.Hypervisor, vol.DeviceID), nil
+}
+
+// DetachVolumes is specified on the storage.VolumeSource interface.
+func (v *volumeSource) DetachVolumes(attachParams []storage.VolumeAttachmentParams) error {
+	for _, params := range attachParams {
+		vol, err := v.volume(params.VolumeId)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if vol.VirtualMachineID != string(params.InstanceId) {
+			// Not attached to the instance.
+			continue
+		}
+		if err := v.client.DetachVolume(vol.ID); err != nil {
+			return errors.Annotatef(err, "detaching %v from %v", params.VolumeId, params.InstanceId)
+		}
+	}
+	return nil
+}
+
+// volume returns the volume with the given id.
+func (v *volumeSource) volume(volumeId string) (*client.Volume, error) {
+	vols, err := v.client.ListVolumes(volumeId)
+	if client.IsParamError(err) {
+		return nil, errors.NotFoundf("volume %q", volumeId)
+	} else if err != nil {
+		return nil, errors.Annotate(err, "querying volume")
+	}
+	if len(vols) != 1 {
+		return nil, errors.NotFoundf("volume %q", volumeId)
+	}
+	return &vols[0], nil
+}
+
+// virtualMachine returns the virtual machine of the instance with the
+// given id.
+func (v *volumeSource) virtualMachine(id instance.Id) (*client.VirtualMachine, error) {
+	vms, err := v.client.ListVirtualMachines(string(id))
+	if err != nil {
+		return nil, errors.Annotate(err, "querying instance details")
+	}
+	if len(vms) != 1 {
+		return nil, errors.NotFoundf("instance %q", id)
+	}
+	return &vms[0], nil
+}
+
+// deviceName returns the name of the block device of the volume with
+// the given device id, attached to a guest of the given hypervisor,
+// or "" if it cannot be determined.
+func deviceName(hypervisor string, deviceID int) string {
+	prefix, ok := devicePrefixes[hypervisor]
+	if !ok || deviceID < 0 || deviceID >= 26 {
+		return ""
+	}
+	return prefix + string(rune('a'+deviceID))
+}
@@ -0,0 +1,240 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cloudstack_test
+
+import (
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/provider/cloudstack"
+	"github.com/juju/juju/provider/cloudstack/client"
+	"github.com/juju/juju/storage"
+	"github.com/juju/juju/storage/provider/registry"
+)
+
+type volumeSuite struct {
+	localServerSuite
+
+	instanceId instance.Id
+	source     storage.VolumeSource
+}
+
+var _ = gc.Suite(&volumeSuite{})
+
+func (s *volumeSuite) SetUpTest(c *gc.C) {
+	s.localServerSuite.SetUpTest(c)
+	s.srv.AddDiskOffering(client.DiskOffering{
+		Name:     "small",
+		DiskSize: 5,
+	})
+	s.srv.AddDiskOffering(client.DiskOffering{
+		Name:         "custom",
+		IsCustomized: true,
+	})
+	s.instanceId = s.assertStartInstance(c, "1").Instance.Id()
+	s.source = s.volumeSource(c, nil)
+}
+
+func (s *volumeSuite) volumeSource(c *gc.C, attrs map[string]interface{}) storage.VolumeSource {
+	provider, err := registry.StorageProvider(cloudstack.VolumeProviderType)
+	c.Assert(err, jc.ErrorIsNil)
+	cfg, err := storage.NewConfig("cloudstack", cloudstack.VolumeProviderType, attrs)
+	c.Assert(err, jc.ErrorIsNil)
+	source, err := provider.VolumeSource(s.env.Config(), cfg)
+	c.Assert(err, jc.ErrorIsNil)
+	return source
+}
+
+func (s *volumeSuite) volumeParams(id string, size uint64) storage.VolumeParams {
+	return storage.VolumeParams{
+		Tag:      names.NewVolumeTag(id),
+		Size:     size,
+		Provider: cloudstack.VolumeProviderType,
+		Attachment: &storage.VolumeAttachmentParams{
+			AttachmentParams: storage.AttachmentParams{
+				Provider:   cloudstack.VolumeProviderType,
+				Machine:    names.NewMachineTag("1"),
+				InstanceId: s.instanceId,
+			},
+			Volume: names.NewVolumeTag(id),
+		},
+	}
+}
+
+// dataVolumes returns the server's data volumes.
+func (s *volumeSuite) dataVolumes() []client.Volume {
+	var volumes []client.Volume
+	for _, vol := range s.srv.Volumes() {
+		if vol.Type != "ROOT" {
+			volumes = append(volumes, vol)
+		}
+	}
+	return volumes
+}
+
+func (s *volumeSuite) TestProvider(c *gc.C) {
+	provider, err := registry.StorageProvider(cloudstack.VolumeProviderType)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(provider.Dynamic(), jc.IsTrue)
+	c.Check(provider.Scope(), gc.Equals, storage.ScopeEnviron)
+	c.Check(provider.Supports(storage.StorageKindBlock), jc.IsTrue)
+	c.Check(provider.Supports(storage.StorageKindFilesystem), jc.IsFalse)
+	c.Check(registry.IsProviderSupported("cloudstack", cloudstack.VolumeProviderType), jc.IsTrue)
+
+	cfg, err := storage.NewConfig("cloudstack", cloudstack.VolumeProviderType, map[string]interface{}{
+		"disk-offering": 42,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	err = provider.ValidateConfig(cfg)
+	c.Check(err, gc.ErrorMatches, `expected string for "disk-offering", got int`)
+
+	cfg, err = storage.NewConfig("cloudstack", cloudstack.VolumeProviderType, map[string]interface{}{
+		"volume-type": "ssd",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	err = provider.ValidateConfig(cfg)
+	c.Check(err, gc.ErrorMatches, `unknown provider config option "volume-type"`)
+}
+
+func (s *volumeSuite) TestValidateVolumeParams(c *gc.C) {
+	params := s.volumeParams("0", 1024)
+	c.Assert(s.source.ValidateVolumeParams(params), jc.ErrorIsNil)
+
+	params.Attachment = nil
+	c.Assert(s.source.ValidateVolumeParams(params), gc.Equals, storage.ErrVolumeNeedsInstance)
+}
+
+func (s *volumeSuite) TestCreateVolumes(c *gc.C) {
+	volumes, attachments, err := s.source.CreateVolumes([]storage.VolumeParams{
+		s.volumeParams("0", 1024),
+		s.volumeParams("1", 1536),
+	})
+	c.Assert(err, jc.ErrorIsNil)
+
+	created := s.dataVolumes()
+	c.Assert(created, gc.HasLen, 2)
+	uuid, _ := s.env.Config().UUID()
+	c.Check(created[0].Name, gc.Equals, "juju-"+uuid+"-volume-0")
+	c.Check(created[0].VirtualMachineID, gc.Equals, string(s.instanceId))
+	c.Check(created[1].Size, gc.Equals, int64(2<<30))
+
+	c.Check(volumes, jc.DeepEquals, []storage.Volume{{
+		Tag:        names.NewVolumeTag("0"),
+		VolumeId:   created[0].ID,
+		Size:       1024,
+		Persistent: true,
+	}, {
+		Tag:        names.NewVolumeTag("1"),
+		VolumeId:   created[1].ID,
+		Size:       2048,
+		Persistent: true,
+	}})
+	c.Check(attachments, jc.DeepEquals, []storage.VolumeAttachment{{
+		Volume:     names.NewVolumeTag("0"),
+		Machine:    names.NewMachineTag("1"),
+		DeviceName: "vdb",
+	}, {
+		Volume:     names.NewVolumeTag("1"),
+		Machine:    names.NewMachineTag("1"),
+		DeviceName: "vdc",
+	}})
+}
+
+func (s *volumeSuite) TestCreateVolumesDiskOffering(c *gc.C) {
+	source := s.volumeSource(c, map[string]interface{}{"disk-offering": "small"})
+	volumes, _, err := source.CreateVolumes([]storage.VolumeParams{
+		s.volumeParams("0", 1024),
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	// The size of volumes of fixed disk offerings cannot be chosen.
+	c.Assert(volumes[0].Size, gc.Equals, uint64(5*1024))
+
+	source = s.volumeSource(c, map[string]interface{}{"disk-offering": "huge"})
+	_, _, err = source.CreateVolumes([]storage.VolumeParams{
+		s.volumeParams("1", 1024),
+	})
+	c.Assert(err, gc.ErrorMatches, `disk offering "huge" not found`)
+}
+
+func (s *volumeSuite) TestCreateVolumesCleansUp(c *gc.C) {
+	s.srv.FailCommand("attachVolume", "no free slots")
+	_, _, err := s.source.CreateVolumes([]storage.VolumeParams{
+		s.volumeParams("0", 1024),
+	})
+	c.Assert(err, gc.ErrorMatches, "attaching .* to .*: attachVolume failed: no free slots .*")
+	c.Assert(s.dataVolumes(), gc.HasLen, 0)
+}
+
+func (s *volumeSuite) TestDescribeVolumes(c *gc.C) {
+	volumes, _, err := s.source.CreateVolumes([]storage.VolumeParams{
+		s.volumeParams("0", 1024),
+	})
+	c.Assert(err, jc.ErrorIsNil)
+
+	described, err := s.source.DescribeVolumes([]string{volumes[0].VolumeId})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(described, jc.DeepEquals, []storage.Volume{{
+		VolumeId:   volumes[0].VolumeId,
+		Size:       1024,
+		Persistent: true,
+	}})
+
+	_, err = s.source.DescribeVolumes([]string{"unknown"})
+	c.Assert(err, gc.ErrorMatches, `volume "unknown" not found`)
+}
+
+func (s *volumeSuite) TestDetachAndAttachVolumes(c *gc.C) {
+	volumes, _, err := s.source.CreateVolumes([]storage.VolumeParams{
+		s.volumeParams("0", 1024),
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	params := []storage.VolumeAttachmentParams{{
+		AttachmentParams: storage.AttachmentParams{
+			Provider:   cloudstack.VolumeProviderType,
+			Machine:    names.NewMachineTag("1"),
+			InstanceId: s.instanceId,
+		},
+		Volume:   names.NewVolumeTag("0"),
+		VolumeId: volumes[0].VolumeId,
+	}}
+
+	err = s.source.DetachVolumes(params)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(s.dataVolumes()[0].VirtualMachineID, gc.Equals, "")
+	// Detaching a detached volume is not an error.
+	err = s.source.DetachVolumes(params)
+	c.Assert(err, jc.ErrorIsNil)
+
+	for i := 0; i < 2; i++ {
+		// Attaching is idempotent.
+		attachments, err := s.source.AttachVolumes(params)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(attachments, jc.DeepEquals, []storage.VolumeAttachment{{
+			Volume:     names.NewVolumeTag("0"),
+			Machine:    names.NewMachineTag("1"),
+			DeviceName: "vdb",
+		}})
+	}
+	c.Check(s.dataVolumes()[0].VirtualMachineID, gc.Equals, string(s.instanceId))
+}
+
+func (s *volumeSuite) TestDestroyVolumes(c *gc.C) {
+	volumes, _, err := s.source.CreateVolumes([]storage.VolumeParams{
+		s.volumeParams("0", 1024),
+	})
+	c.Assert(err, jc.ErrorIsNil)
+
+	errs := s.source.DestroyVolumes([]string{volumes[0].VolumeId, "unknown"})
+	c.Assert(errs, jc.DeepEquals, []error{nil, nil})
+	c.Assert(s.dataVolumes(), gc.HasLen, 0)
+}
+
+func (s *volumeSuite) TestDeviceName(c *gc.C) {
+	c.Check(cloudstack.DeviceName("KVM", 1), gc.Equals, "vdb")
+	c.Check(cloudstack.DeviceName("XenServer", 4), gc.Equals, "xvde")
+	c.Check(cloudstack.DeviceName("VMware", 1), gc.Equals, "")
+	c.Check(cloudstack.DeviceName("KVM", 26), gc.Equals, "")
+}
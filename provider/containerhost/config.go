@@ -0,0 +1,166 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package containerhost
+
+import (
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/juju/schema"
+	"github.com/juju/utils/set"
+
+	"github.com/juju/juju/environs/config"
+)
+
+// The container-host specific config keys.
+const (
+	cfgHosts  = "hosts"
+	cfgBridge = "bridge"
+)
+
+// defaultBridge is the network bridge containers are attached to
+// when none is configured.
+const defaultBridge = "lxcbr0"
+
+// boilerplateConfig will be shown in help output, so please keep it up to
+// date when you change environment configuration below.
+var boilerplateConfig = `
+container-host:
+  type: container-host
+
+  # hosts lists the machines running the LXD daemon on which the
+  # environment's containers are started. They must be reachable over
+  # SSH as the "ubuntu" user, with passwordless sudo. Machines are
+  # spread across the hosts; a host may be chosen when adding a
+  # machine with the "zone=<host>" placement directive.
+  hosts:
+    - host-a.example.com
+
+  # bridge is the network bridge on each host to which containers are
+  # attached. Containers must be reachable from the client and from
+  # each other, so with more than one host this should bridge the
+  # hosts' own network rather than a private one such as lxcbr0.
+  # bridge: br0
+`[1:]
+
+// configFields is the spec for each container-host config value's type.
+var configFields = schema.Fields{
+	cfgHosts:  schema.List(schema.String()),
+	cfgBridge: schema.String(),
+}
+
+var configDefaults = schema.Defaults{
+	cfgHosts:  schema.Omit,
+	cfgBridge: defaultBridge,
+}
+
+type environConfig struct {
+	*config.Config
+	attrs map[string]interface{}
+}
+
+// newConfig builds a new environConfig from the provided Config and
+// returns it.
+func newConfig(cfg *config.Config) *environConfig {
+	return &environConfig{
+		Config: cfg,
+		attrs:  cfg.UnknownAttrs(),
+	}
+}
+
+// newValidConfig builds a new environConfig from the provided Config
+// and returns it. This includes applying the provided defaults
+// values, if any. The resulting config values are validated.
+func newValidConfig(cfg *config.Config, defaults map[string]interface{}) (*environConfig, error) {
+	// Ensure that the provided config is valid.
+	if err := config.Validate(cfg, nil); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	// Apply the defaults and coerce/validate the custom config attrs.
+	validated, err := cfg.ValidateUnknownAttrs(configFields, defaults)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	validCfg, err := cfg.Apply(validated)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	// Build the config.
+	ecfg := newConfig(validCfg)
+
+	// Do final validation.
+	if err := ecfg.validate(); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	return ecfg, nil
+}
+
+// hosts returns the addresses of the container hosts.
+func (c *environConfig) hosts() []string {
+	values, _ := c.attrs[cfgHosts].([]interface{})
+	hosts := make([]string, len(values))
+	for i, value := range values {
+		hosts[i] = value.(string)
+	}
+	return hosts
+}
+
+func (c *environConfig) bridge() string {
+	return c.attrs[cfgBridge].(string)
+}
+
+// validate checks container-host specific config values.
+func (c *environConfig) validate() error {
+	hosts := c.hosts()
+	if len(hosts) == 0 {
+		return errors.Errorf("%s: must not be empty", cfgHosts)
+	}
+	seen := set.NewStrings()
+	for _, host := range hosts {
+		switch {
+		case host == "":
+			return errors.Errorf("%s: host must not be empty", cfgHosts)
+		case strings.Contains(host, "@"):
+			return errors.Errorf("%s: host %q must not specify a user", cfgHosts, host)
+		case seen.Contains(host):
+			return errors.Errorf("%s: host %q specified more than once", cfgHosts, host)
+		}
+		seen.Add(host)
+	}
+	if c.bridge() == "" {
+		return errors.Errorf("%s: must not be empty", cfgBridge)
+	}
+	return nil
+}
+
+// update applies changes from the provided config to the env config.
+// Hosts may be added, but not removed, since the containers on them
+// would no longer be known to the environment.
+func (c *environConfig) update(cfg *config.Config) error {
+	// Validate the updates. newValidConfig does not modify the "known"
+	// config attributes so it is safe to call Validate here first.
+	if err := config.Validate(cfg, c.Config); err != nil {
+		return errors.Trace(err)
+	}
+
+	updates, err := newValidConfig(cfg, configDefaults)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	newHosts := set.NewStrings(updates.hosts()...)
+	for _, host := range c.hosts() {
+		if !newHosts.Contains(host) {
+			return errors.Errorf("%s: cannot remove host %q", cfgHosts, host)
+		}
+	}
+
+	// Apply the updates.
+	c.Config = updates.Config
+	c.attrs = updates.attrs
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package containerhost_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/provider/containerhost"
+	"github.com/juju/juju/testing"
+)
+
+var configAttrs = testing.Attrs{
+	"type":  "container-host",
+	"hosts": []interface{}{"host-a", "host-b"},
+}
+
+type configSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&configSuite{})
+
+func newConfig(c *gc.C, attrs testing.Attrs) *config.Config {
+	return testing.CustomEnvironConfig(c, configAttrs.Merge(attrs))
+}
+
+func (s *configSuite) TestValidateNewConfig(c *gc.C) {
+	cfg := newConfig(c, nil)
+	valid, err := containerhost.Provider.Validate(cfg, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	attrs := valid.UnknownAttrs()
+	c.Check(attrs["hosts"], jc.DeepEquals, []interface{}{"host-a", "host-b"})
+	c.Check(attrs["bridge"], gc.Equals, "lxcbr0")
+}
+
+var invalidConfigTests = []struct {
+	info   string
+	insert testing.Attrs
+	remove []string
+	err    string
+}{{
+	info:   "hosts is required",
+	remove: []string{"hosts"},
+	err:    "hosts: must not be empty",
+}, {
+	info:   "hosts must not be empty",
+	insert: testing.Attrs{"hosts": []interface{}{}},
+	err:    "hosts: must not be empty",
+}, {
+	info:   "hosts must not hold an empty host",
+	insert: testing.Attrs{"hosts": []interface{}{"host-a", ""}},
+	err:    "hosts: host must not be empty",
+}, {
+	info:   "hosts must not specify a user",
+	insert: testing.Attrs{"hosts": []interface{}{"admin@host-a"}},
+	err:    `hosts: host "admin@host-a" must not specify a user`,
+}, {
+	info:   "hosts must be unique",
+	insert: testing.Attrs{"hosts": []interface{}{"host-a", "host-a"}},
+	err:    `hosts: host "host-a" specified more than once`,
+}, {
+	info:   "bridge must not be empty",
+	insert: testing.Attrs{"bridge": ""},
+	err:    "bridge: must not be empty",
+}}
+
+func (s *configSuite) TestValidateInvalidConfig(c *gc.C) {
+	for i, test := range invalidConfigTests {
+		c.Logf("test %d: %s", i, test.info)
+		attrs := testing.FakeConfig().Merge(configAttrs).Merge(test.insert).Delete(test.remove...)
+		cfg, err := config.New(config.NoDefaults, attrs)
+		c.Assert(err, jc.ErrorIsNil)
+		_, err = containerhost.Provider.Validate(cfg, nil)
+		c.Check(err, gc.ErrorMatches, "invalid config: "+test.err)
+	}
+}
+
+func (s *configSuite) TestSetConfigAddHost(c *gc.C) {
+	env, err := environs.New(newConfig(c, nil))
+	c.Assert(err, jc.ErrorIsNil)
+
+	cfg, err := env.Config().Apply(map[string]interface{}{
+		"hosts": []interface{}{"host-a", "host-b", "host-c"},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	err = env.SetConfig(cfg)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(env.Config().UnknownAttrs()["hosts"], jc.DeepEquals, []interface{}{"host-a", "host-b", "host-c"})
+}
+
+func (s *configSuite) TestSetConfigRemoveHost(c *gc.C) {
+	env, err := environs.New(newConfig(c, nil))
+	c.Assert(err, jc.ErrorIsNil)
+
+	cfg, err := env.Config().Apply(map[string]interface{}{
+		"hosts": []interface{}{"host-b"},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	err = env.SetConfig(cfg)
+	c.Assert(err, gc.ErrorMatches, `invalid config change: hosts: cannot remove host "host-a"`)
+}
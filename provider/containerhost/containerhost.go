@@ -0,0 +1,21 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package containerhost implements a provider which treats a set of
+// machines running the LXD container daemon as a cloud. Instances are
+// system containers started on those hosts, which are driven through
+// the daemon's REST API with the container/lxd client. The client's
+// connections are relayed to the daemon's unix socket on each host
+// with netcat over SSH.
+//
+// The hosts must be reachable over SSH as the "ubuntu" user, with
+// passwordless sudo and the OpenBSD netcat installed, and the
+// containers must be reachable from the client and from each other
+// over the hosts' network bridge.
+package containerhost
+
+import (
+	"github.com/juju/loggo"
+)
+
+var logger = loggo.GetLogger("juju.provider.containerhost")
@@ -0,0 +1,118 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package containerhost
+
+import (
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/provider/common"
+)
+
+// hostZone implements common.AvailabilityZone for a container host.
+// Each host is its own availability zone.
+type hostZone string
+
+// Name implements common.AvailabilityZone.
+func (z hostZone) Name() string {
+	return string(z)
+}
+
+// Available implements common.AvailabilityZone. Whether a host is
+// reachable is only known when it is used, so hosts are always
+// considered available.
+func (z hostZone) Available() bool {
+	return true
+}
+
+// AvailabilityZones returns all availability zones in the environment.
+func (env *environ) AvailabilityZones() ([]common.AvailabilityZone, error) {
+	hosts := env.getSnapshot().ecfg.hosts()
+	zones := make([]common.AvailabilityZone, len(hosts))
+	for i, host := range hosts {
+		zones[i] = hostZone(host)
+	}
+	return zones, nil
+}
+
+// InstanceAvailabilityZoneNames returns the names of the availability
+// zones for the specified instances. The error returned follows the same
+// rules as Environ.Instances.
+func (env *environ) InstanceAvailabilityZoneNames(ids []instance.Id) ([]string, error) {
+	instances, err := env.Instances(ids)
+	if err != nil && err != environs.ErrPartialInstances && err != environs.ErrNoInstances {
+		return nil, errors.Trace(err)
+	}
+	// We let the two environs errors pass on through. However, we do
+	// not use errors.Trace in that case since callers may not call
+	// errors.Cause.
+
+	results := make([]string, len(ids))
+	for i, inst := range instances {
+		if inst != nil {
+			results[i] = inst.(*environInstance).host
+		}
+	}
+
+	return results, err
+}
+
+// DistributeInstances implements the state.InstanceDistributor policy.
+func (env *environ) DistributeInstances(candidates, distributionGroup []instance.Id) ([]instance.Id, error) {
+	return common.DistributeInstances(env, candidates, distributionGroup)
+}
+
+// availZone returns the host with the given name.
+func (env *environ) availZone(name string) (string, error) {
+	for _, host := range env.getSnapshot().ecfg.hosts() {
+		if host == name {
+			return host, nil
+		}
+	}
+	return "", errors.NotFoundf("invalid availability zone %q", name)
+}
+
+var availabilityZoneAllocations = common.AvailabilityZoneAllocations
+
+// parseAvailabilityZones returns the hosts that should be tried for
+// the given instance spec. If a placement argument was provided then
+// only that one is returned. Otherwise the resulting list is roughly
+// ordered such that the environment's instances are spread evenly
+// across the hosts.
+func (env *environ) parseAvailabilityZones(args environs.StartInstanceParams) ([]string, error) {
+	if args.Placement != "" {
+		placement, err := env.parsePlacement(args.Placement)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return []string{placement.host}, nil
+	}
+
+	// If no availability zone is specified, then automatically spread across
+	// the known zones for optimal spread across the instance distribution
+	// group.
+	var group []instance.Id
+	var err error
+	if args.DistributionGroup != nil {
+		group, err = args.DistributionGroup()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	zoneInstances, err := availabilityZoneAllocations(env, group)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	logger.Infof("found %d hosts: %v", len(zoneInstances), zoneInstances)
+
+	var hosts []string
+	for _, z := range zoneInstances {
+		hosts = append(hosts, z.ZoneName)
+	}
+	if len(hosts) == 0 {
+		return nil, errors.NotFoundf("failed to determine availability zones")
+	}
+	return hosts, nil
+}
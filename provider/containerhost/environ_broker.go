@@ -0,0 +1,167 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package containerhost
+
+import (
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/container"
+	"github.com/juju/juju/container/lxd"
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/cloudinit"
+	"github.com/juju/juju/environs/manual"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/provider/common"
+	"github.com/juju/juju/state/multiwatcher"
+	"github.com/juju/juju/tools"
+)
+
+// detectHardware returns the hardware characteristics of a host.
+var detectHardware = func(host string) (instance.HardwareCharacteristics, error) {
+	hc, _, err := manual.DetectSeriesAndHardwareCharacteristics(host)
+	return hc, errors.Trace(err)
+}
+
+func isStateServer(mcfg *cloudinit.MachineConfig) bool {
+	return multiwatcher.AnyJobNeedsState(mcfg.Jobs...)
+}
+
+// StartInstance implements environs.InstanceBroker.
+func (env *environ) StartInstance(args environs.StartInstanceParams) (*environs.StartInstanceResult, error) {
+	env = env.getSnapshot()
+
+	if args.MachineConfig.HasNetworks() {
+		return nil, errors.New("starting instances with networks is not supported")
+	}
+
+	hosts, err := env.parseAvailabilityZones(args)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	// Try each of the hosts in turn, since a host may be
+	// unreachable or have no suitable tools.
+	var lastErr error
+	for _, host := range hosts {
+		result, err := env.startInstance(host, args)
+		if err == nil {
+			return result, nil
+		}
+		logger.Warningf("cannot start instance on %q: %v", host, err)
+		lastErr = err
+	}
+	return nil, errors.Annotate(lastErr, "cannot start instance")
+}
+
+// startInstance starts a container for the given instance on the
+// given host.
+func (env *environ) startInstance(host string, args environs.StartInstanceParams) (*environs.StartInstanceResult, error) {
+	// Containers share the architecture of their host.
+	hostHardware, err := detectHardware(host)
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot detect hardware of %q", host)
+	}
+	if err := env.finishMachineConfig(args, *hostHardware.Arch); err != nil {
+		return nil, errors.Trace(err)
+	}
+	userData, err := container.CloudInitUserData(args.MachineConfig, nil)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot make user data")
+	}
+	logger.Debugf("container-host user data; %d bytes", len(userData))
+
+	params := launchParams{
+		name:        common.MachineFullName(env, args.MachineConfig.MachineId),
+		source:      lxd.ImageSource(args.MachineConfig.Series, args.MachineConfig.ImageStream),
+		bridge:      env.ecfg.bridge(),
+		stateServer: isStateServer(args.MachineConfig),
+		userData:    userData,
+	}
+	if args.Constraints.Mem != nil {
+		params.memory = *args.Constraints.Mem
+	}
+	if args.Constraints.CpuCores != nil {
+		params.cpus = *args.Constraints.CpuCores
+	}
+	if err := launchContainer(host, params); err != nil {
+		return nil, errors.Trace(err)
+	}
+	logger.Infof("started container %q on %q", params.name, host)
+
+	inst := newInstance(host, containerInfo{
+		name:        params.name,
+		status:      lxd.StatusRunning,
+		stateServer: params.stateServer,
+	}, env)
+	hwc := getHardwareCharacteristics(host, hostHardware, params)
+	return &environs.StartInstanceResult{
+		Instance: inst,
+		Hardware: hwc,
+	}, nil
+}
+
+// finishMachineConfig updates args.MachineConfig in place. Setting up
+// the API, StateServing, and SSHkeys information.
+func (env *environ) finishMachineConfig(args environs.StartInstanceParams, arch string) error {
+	envTools, err := args.Tools.Match(tools.Filter{Arch: arch})
+	if err != nil {
+		return errors.Errorf("host architecture %v not present in %v", arch, args.Tools.Arches())
+	}
+
+	args.MachineConfig.Tools = envTools[0]
+	return environs.FinishMachineConfig(args.MachineConfig, env.Config())
+}
+
+// getHardwareCharacteristics compiles hardware-related details about
+// a container started on the given host with the given parameters
+// and returns it.
+func getHardwareCharacteristics(host string, hostHardware instance.HardwareCharacteristics, params launchParams) *instance.HardwareCharacteristics {
+	hwc := instance.HardwareCharacteristics{
+		Arch:             hostHardware.Arch,
+		Mem:              hostHardware.Mem,
+		CpuCores:         hostHardware.CpuCores,
+		AvailabilityZone: &host,
+	}
+	if params.memory > 0 {
+		hwc.Mem = &params.memory
+	}
+	if params.cpus > 0 {
+		hwc.CpuCores = &params.cpus
+	}
+	return &hwc
+}
+
+// AllInstances implements environs.InstanceBroker.
+func (env *environ) AllInstances() ([]instance.Instance, error) {
+	instances, err := env.instances()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	results := make([]instance.Instance, len(instances))
+	for i, inst := range instances {
+		results[i] = inst
+	}
+	return results, nil
+}
+
+// StopInstances implements environs.InstanceBroker.
+func (env *environ) StopInstances(ids ...instance.Id) error {
+	env = env.getSnapshot()
+
+	instances, err := env.instances()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, id := range ids {
+		inst, ok := findInst(id, instances).(*environInstance)
+		if !ok {
+			// Unknown instance IDs are ignored.
+			continue
+		}
+		if err := destroyContainer(inst.host, inst.base.name); err != nil {
+			return errors.Annotatef(err, "cannot stop instance %q", id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package containerhost
+
+import (
+	"strings"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/provider/common"
+)
+
+// Instances returns the available instances in the environment that
+// match the provided instance IDs. For IDs that did not match any
+// instances, the result at the corresponding index will be nil. In that
+// case the error will be environs.ErrPartialInstances (or
+// ErrNoInstances if none of the IDs match an instance).
+func (env *environ) Instances(ids []instance.Id) ([]instance.Instance, error) {
+	if len(ids) == 0 {
+		return nil, environs.ErrNoInstances
+	}
+
+	instances, err := env.instances()
+	if err != nil {
+		// We don't return the error since we need to pack one instance
+		// for each ID into the result. If there is a problem then we
+		// will return either ErrPartialInstances or ErrNoInstances.
+		logger.Errorf("failed to get instances from the container hosts: %v", err)
+		err = errors.Trace(err)
+	}
+
+	// Build the result, matching the provided instance IDs.
+	numFound := 0 // This will never be greater than len(ids).
+	results := make([]instance.Instance, len(ids))
+	for i, id := range ids {
+		if inst := findInst(id, instances); inst != nil {
+			results[i] = inst
+			numFound++
+		}
+	}
+
+	if numFound == 0 {
+		if err == nil {
+			err = environs.ErrNoInstances
+		}
+	} else if numFound != len(ids) {
+		err = environs.ErrPartialInstances
+	}
+	return results, err
+}
+
+// instances returns a list of all the environment's containers on all
+// of its hosts. This means only containers whose names match
+// "juju-<env uuid>-machine-*". This is important because otherwise
+// juju will see they are not tracked in state, assume they're
+// stale/rogue, and shut them down.
+func (env *environ) instances() ([]*environInstance, error) {
+	env = env.getSnapshot()
+	prefix := common.MachineFullName(env, "")
+	var results []*environInstance
+	for _, host := range env.ecfg.hosts() {
+		containers, err := listContainers(host, prefix)
+		if err != nil {
+			return results, errors.Trace(err)
+		}
+		for _, container := range containers {
+			results = append(results, newInstance(host, container, env))
+		}
+	}
+	return results, nil
+}
+
+// StateServerInstances returns the IDs of the instances corresponding
+// to juju state servers.
+func (env *environ) StateServerInstances() ([]instance.Id, error) {
+	instances, err := env.instances()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	var results []instance.Id
+	for _, inst := range instances {
+		if inst.base.stateServer {
+			results = append(results, inst.Id())
+		}
+	}
+	if len(results) == 0 {
+		return nil, environs.ErrNotBootstrapped
+	}
+	return results, nil
+}
+
+type instPlacement struct {
+	host string
+}
+
+// parsePlacement extracts the host from the placement string and
+// returns it. If no host is found there then an error is returned.
+// Hosts are the environment's availability zones, so are chosen with
+// the "zone" directive.
+func (env *environ) parsePlacement(placement string) (*instPlacement, error) {
+	if placement == "" {
+		return nil, nil
+	}
+
+	pos := strings.IndexRune(placement, '=')
+	if pos == -1 {
+		return nil, errors.Errorf("unknown placement directive: %v", placement)
+	}
+
+	switch key, value := placement[:pos], placement[pos+1:]; key {
+	case "zone":
+		host, err := env.availZone(value)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return &instPlacement{host: host}, nil
+	}
+	return nil, errors.Errorf("unknown placement directive: %v", placement)
+}
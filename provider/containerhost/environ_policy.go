@@ -0,0 +1,78 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package containerhost
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/utils/set"
+
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/network"
+)
+
+// PrecheckInstance verifies that the provided series and constraints
+// are valid for use in creating an instance in this environment.
+func (env *environ) PrecheckInstance(series string, cons constraints.Value, placement string) error {
+	_, err := env.parsePlacement(placement)
+	return errors.Trace(err)
+}
+
+// SupportedArchitectures returns the image architectures which can
+// be hosted by this environment, which are those of its hosts.
+func (env *environ) SupportedArchitectures() ([]string, error) {
+	env.archLock.Lock()
+	defer env.archLock.Unlock()
+
+	if env.supportedArchitectures != nil {
+		return env.supportedArchitectures, nil
+	}
+
+	arches := set.NewStrings()
+	for _, host := range env.getSnapshot().ecfg.hosts() {
+		hc, err := detectHardware(host)
+		if err != nil {
+			return nil, errors.Annotatef(err, "cannot detect hardware of %q", host)
+		}
+		arches.Add(*hc.Arch)
+	}
+	env.supportedArchitectures = arches.SortedValues()
+	return env.supportedArchitectures, nil
+}
+
+var unsupportedConstraints = []string{
+	constraints.CpuPower,
+	constraints.InstanceType,
+	constraints.RootDisk,
+	constraints.Tags,
+	constraints.Networks,
+	constraints.Spaces,
+}
+
+// ConstraintsValidator returns a Validator value which is used to
+// validate and merge constraints.
+func (env *environ) ConstraintsValidator() (constraints.Validator, error) {
+	validator := constraints.NewValidator()
+	validator.RegisterUnsupported(unsupportedConstraints)
+
+	supportedArches, err := env.SupportedArchitectures()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	validator.RegisterVocabulary(constraints.Arch, supportedArches)
+
+	return validator, nil
+}
+
+// SupportNetworks returns whether the environment has support to
+// specify networks for services and machines.
+func (env *environ) SupportNetworks() bool {
+	return false
+}
+
+// SupportAddressAllocation takes a network.Id and returns a bool
+// and an error. The bool indicates whether that network supports
+// static ip address allocation.
+func (env *environ) SupportAddressAllocation(netID network.Id) (bool, error) {
+	return false, nil
+}
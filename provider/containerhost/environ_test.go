@@ -0,0 +1,244 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package containerhost_test
+
+import (
+	"path/filepath"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/container/lxd"
+	lxdtesting "github.com/juju/juju/container/lxd/testing"
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/imagemetadata"
+	"github.com/juju/juju/instance"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/provider/common"
+	"github.com/juju/juju/provider/containerhost"
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/tools"
+	"github.com/juju/juju/version"
+)
+
+var testTools = tools.List{{
+	Version: version.MustParseBinary("1.24.0-trusty-amd64"),
+	URL:     "https://example.com/tools/juju-1.24.0-trusty-amd64.tgz",
+}}
+
+type environSuite struct {
+	testing.BaseSuite
+	env     environs.Environ
+	daemons map[string]*lxdtesting.FakeDaemon
+}
+
+var _ = gc.Suite(&environSuite{})
+
+func (s *environSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	env, err := environs.New(newConfig(c, nil))
+	c.Assert(err, jc.ErrorIsNil)
+	s.env = env
+
+	// Each host runs a fake container daemon.
+	s.daemons = make(map[string]*lxdtesting.FakeDaemon)
+	for _, host := range []string{"host-a", "host-b"} {
+		daemon, err := lxdtesting.NewFakeDaemon(filepath.Join(c.MkDir(), "unix.socket"))
+		c.Assert(err, jc.ErrorIsNil)
+		s.AddCleanup(func(*gc.C) { daemon.Close() })
+		s.daemons[host] = daemon
+	}
+	s.PatchValue(containerhost.NewHostClient, func(host string) *lxd.Client {
+		daemon, ok := s.daemons[host]
+		c.Assert(ok, jc.IsTrue)
+		return lxd.NewClient(daemon.SocketPath)
+	})
+}
+
+func (s *environSuite) machineName(id string) string {
+	return common.MachineFullName(s.env, id)
+}
+
+// addContainer adds a container for the machine with the given id to
+// the given host.
+func (s *environSuite) addContainer(host, machineId, status string, stateServer bool, address string) {
+	config := map[string]string{"user.juju-state-server": "false"}
+	if stateServer {
+		config["user.juju-state-server"] = "true"
+	}
+	s.daemons[host].AddContainer(lxd.ContainerSpec{
+		Name:   s.machineName(machineId),
+		Config: config,
+	}, status, address)
+}
+
+func (s *environSuite) TestAvailabilityZones(c *gc.C) {
+	zoned := s.env.(common.ZonedEnviron)
+	zones, err := zoned.AvailabilityZones()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(zones, gc.HasLen, 2)
+	c.Check(zones[0].Name(), gc.Equals, "host-a")
+	c.Check(zones[0].Available(), jc.IsTrue)
+	c.Check(zones[1].Name(), gc.Equals, "host-b")
+	c.Check(zones[1].Available(), jc.IsTrue)
+}
+
+func (s *environSuite) TestInstances(c *gc.C) {
+	s.addContainer("host-a", "0", lxd.StatusRunning, true, "10.0.3.10")
+	s.addContainer("host-b", "1", lxd.StatusStopped, false, "")
+	// Containers of other environments are ignored.
+	s.daemons["host-a"].AddContainer(lxd.ContainerSpec{Name: "other"}, lxd.StatusRunning, "10.0.3.12")
+
+	ids := []instance.Id{
+		instance.Id(s.machineName("1")),
+		instance.Id(s.machineName("0")),
+		instance.Id(s.machineName("2")),
+	}
+	insts, err := s.env.Instances(ids)
+	c.Assert(err, gc.Equals, environs.ErrPartialInstances)
+	c.Assert(insts, gc.HasLen, 3)
+	c.Check(insts[0].Id(), gc.Equals, ids[0])
+	c.Check(insts[0].Status(), gc.Equals, "stopped")
+	addrs, err := insts[0].Addresses()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(addrs, gc.HasLen, 0)
+
+	c.Check(insts[1].Id(), gc.Equals, ids[1])
+	c.Check(insts[1].Status(), gc.Equals, "running")
+	addrs, err = insts[1].Addresses()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(addrs, gc.HasLen, 1)
+	c.Check(addrs[0].Value, gc.Equals, "10.0.3.10")
+
+	c.Check(insts[2], gc.IsNil)
+}
+
+func (s *environSuite) TestInstanceAvailabilityZoneNames(c *gc.C) {
+	s.addContainer("host-a", "0", lxd.StatusRunning, true, "10.0.3.10")
+	s.addContainer("host-b", "1", lxd.StatusRunning, false, "10.0.3.11")
+
+	zoned := s.env.(common.ZonedEnviron)
+	zones, err := zoned.InstanceAvailabilityZoneNames([]instance.Id{
+		instance.Id(s.machineName("0")),
+		instance.Id(s.machineName("1")),
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(zones, jc.DeepEquals, []string{"host-a", "host-b"})
+}
+
+func (s *environSuite) TestStateServerInstances(c *gc.C) {
+	s.addContainer("host-a", "0", lxd.StatusRunning, true, "10.0.3.10")
+	s.addContainer("host-b", "1", lxd.StatusRunning, false, "10.0.3.11")
+
+	ids, err := s.env.StateServerInstances()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(ids, jc.DeepEquals, []instance.Id{instance.Id(s.machineName("0"))})
+}
+
+func (s *environSuite) TestStateServerInstancesNotBootstrapped(c *gc.C) {
+	_, err := s.env.StateServerInstances()
+	c.Assert(err, gc.Equals, environs.ErrNotBootstrapped)
+}
+
+func (s *environSuite) startInstanceParams(c *gc.C, machineId string) environs.StartInstanceParams {
+	mcfg, err := environs.NewMachineConfig(
+		machineId, "fake_nonce", imagemetadata.ReleasedStream, "trusty", true, nil,
+		jujutesting.FakeStateInfo(machineId), jujutesting.FakeAPIInfo(machineId),
+	)
+	c.Assert(err, jc.ErrorIsNil)
+	return environs.StartInstanceParams{
+		MachineConfig: mcfg,
+		Tools:         testTools,
+	}
+}
+
+// patchDetectHardware makes each host's hardware detection report
+// the given architecture, and returns the hosts detected, in order.
+func (s *environSuite) patchDetectHardware(arches map[string]string) *[]string {
+	var detected []string
+	s.PatchValue(containerhost.DetectHardware, func(host string) (instance.HardwareCharacteristics, error) {
+		detected = append(detected, host)
+		arch, ok := arches[host]
+		if !ok {
+			return instance.HardwareCharacteristics{}, errors.Errorf("unexpected host %q", host)
+		}
+		return instance.MustParseHardware("arch=" + arch + " cpu-cores=1 mem=4M"), nil
+	})
+	return &detected
+}
+
+func (s *environSuite) TestStartInstanceWithPlacement(c *gc.C) {
+	s.patchDetectHardware(map[string]string{"host-b": "amd64"})
+
+	params := s.startInstanceParams(c, "1")
+	params.Placement = "zone=host-b"
+	params.Constraints = constraints.MustParse("mem=2G cpu-cores=2")
+	result, err := s.env.StartInstance(params)
+	c.Assert(err, jc.ErrorIsNil)
+	name := s.machineName("1")
+	c.Check(result.Instance.Id(), gc.Equals, instance.Id(name))
+	c.Check(*result.Hardware.Arch, gc.Equals, "amd64")
+	c.Check(*result.Hardware.Mem, gc.Equals, uint64(2048))
+	c.Check(*result.Hardware.AvailabilityZone, gc.Equals, "host-b")
+
+	_, ok := s.daemons["host-a"].Container(name)
+	c.Check(ok, jc.IsFalse)
+	ctr, ok := s.daemons["host-b"].Container(name)
+	c.Assert(ok, jc.IsTrue)
+	c.Check(ctr.Status, gc.Equals, lxd.StatusRunning)
+	c.Check(ctr.Spec.Source, jc.DeepEquals, lxd.ImageSource("trusty", imagemetadata.ReleasedStream))
+	c.Check(ctr.Spec.Devices["eth0"]["parent"], gc.Equals, "lxcbr0")
+	c.Check(ctr.Spec.Config["user.juju-state-server"], gc.Equals, "false")
+	c.Check(ctr.Spec.Config["user.user-data"], gc.Not(gc.Equals), "")
+	c.Check(ctr.Spec.Config["limits.memory"], gc.Equals, "2048MB")
+	c.Check(ctr.Spec.Config["limits.cpu"], gc.Equals, "2")
+}
+
+func (s *environSuite) TestStartInstanceUnknownHost(c *gc.C) {
+	params := s.startInstanceParams(c, "1")
+	params.Placement = "zone=host-c"
+	_, err := s.env.StartInstance(params)
+	c.Assert(err, gc.ErrorMatches, `invalid availability zone "host-c" not found`)
+}
+
+func (s *environSuite) TestStartInstanceNoMatchingTools(c *gc.C) {
+	s.patchDetectHardware(map[string]string{"host-a": "arm64"})
+
+	params := s.startInstanceParams(c, "1")
+	params.Placement = "zone=host-a"
+	_, err := s.env.StartInstance(params)
+	c.Assert(err, gc.ErrorMatches, `cannot start instance: host architecture arm64 not present in \[amd64\]`)
+}
+
+func (s *environSuite) TestStopInstances(c *gc.C) {
+	s.addContainer("host-a", "0", lxd.StatusRunning, true, "10.0.3.10")
+	s.addContainer("host-b", "1", lxd.StatusRunning, false, "10.0.3.11")
+
+	err := s.env.StopInstances(instance.Id(s.machineName("1")), instance.Id(s.machineName("2")))
+	c.Assert(err, jc.ErrorIsNil)
+	_, ok := s.daemons["host-a"].Container(s.machineName("0"))
+	c.Check(ok, jc.IsTrue)
+	_, ok = s.daemons["host-b"].Container(s.machineName("1"))
+	c.Check(ok, jc.IsFalse)
+}
+
+func (s *environSuite) TestSupportedArchitectures(c *gc.C) {
+	detected := s.patchDetectHardware(map[string]string{
+		"host-a": "amd64",
+		"host-b": "arm64",
+	})
+
+	arches, err := s.env.SupportedArchitectures()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(arches, jc.DeepEquals, []string{"amd64", "arm64"})
+	c.Check(*detected, jc.DeepEquals, []string{"host-a", "host-b"})
+
+	// The result is cached, so the hosts are not detected again.
+	arches, err = s.env.SupportedArchitectures()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(arches, jc.DeepEquals, []string{"amd64", "arm64"})
+	c.Check(*detected, gc.HasLen, 2)
+}
@@ -0,0 +1,351 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package containerhost
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/container/lxd"
+	"github.com/juju/juju/utils/ssh"
+)
+
+// sshUser is the user as which commands are run on the hosts.
+const sshUser = "ubuntu"
+
+const (
+	// stateServerKey is the container config key recording whether
+	// a container runs a state server.
+	stateServerKey = "user.juju-state-server"
+
+	// userDataKey is the container config key holding the
+	// cloud-init user-data read by the image.
+	userDataKey = "user.user-data"
+)
+
+// containerInfo describes a container on a host.
+type containerInfo struct {
+	name        string
+	status      string
+	stateServer bool
+	address     string
+}
+
+// launchParams holds the parameters for starting a container.
+type launchParams struct {
+	name        string
+	source      lxd.ContainerSource
+	bridge      string
+	stateServer bool
+	userData    []byte
+
+	// memory and cpus, if not zero, limit the container's memory
+	// in MB and the number of CPUs it may use.
+	memory uint64
+	cpus   uint64
+}
+
+// newHostClient returns a client for the container daemon on the
+// given host. The client must be closed after use.
+var newHostClient = func(host string) *lxd.Client {
+	return lxd.NewClientWithDialer(func() (net.Conn, error) {
+		return dialHost(host)
+	})
+}
+
+// dialHost connects to the container daemon's unix socket on the
+// given host, relaying the connection over SSH with netcat.
+func dialHost(host string) (net.Conn, error) {
+	cmd := ssh.Command(sshUser+"@"+host, []string{"sudo", "nc", "-U", lxd.DefaultSocketPath}, nil)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Annotatef(err, "cannot connect to %q", host)
+	}
+	return &sshConn{
+		host:   host,
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: stdout,
+	}, nil
+}
+
+// sshConn is a connection relayed through the standard input and
+// output of an SSH command.
+//
+// A read or write blocked on the command's pipes cannot be interrupted,
+// so a deadline is enforced by closing the connection when it passes;
+// the connection cannot be used again once one has.
+type sshConn struct {
+	host   string
+	cmd    *ssh.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+
+	closeOnce sync.Once
+
+	mu           sync.Mutex
+	readTimer    *time.Timer
+	writeTimer   *time.Timer
+	readExpired  bool
+	writeExpired bool
+}
+
+func (c *sshConn) Read(b []byte) (int, error) {
+	if c.expired(&c.readExpired) {
+		return 0, timeoutError{}
+	}
+	n, err := c.stdout.Read(b)
+	if err != nil && c.expired(&c.readExpired) {
+		err = timeoutError{}
+	}
+	return n, err
+}
+
+func (c *sshConn) Write(b []byte) (int, error) {
+	if c.expired(&c.writeExpired) {
+		return 0, timeoutError{}
+	}
+	n, err := c.stdin.Write(b)
+	if err != nil && c.expired(&c.writeExpired) {
+		err = timeoutError{}
+	}
+	return n, err
+}
+
+// Close closes the connection, stopping the SSH command.
+func (c *sshConn) Close() error {
+	c.mu.Lock()
+	stopTimer(c.readTimer)
+	stopTimer(c.writeTimer)
+	c.mu.Unlock()
+	c.closeOnce.Do(func() {
+		c.stdin.Close()
+		// The command may already have exited, so errors
+		// killing and waiting for it are not interesting.
+		c.cmd.Kill()
+		c.cmd.Wait()
+	})
+	return nil
+}
+
+func (c *sshConn) LocalAddr() net.Addr {
+	return sshAddr("localhost")
+}
+
+func (c *sshConn) RemoteAddr() net.Addr {
+	return sshAddr(c.host)
+}
+
+// SetDeadline implements net.Conn.
+func (c *sshConn) SetDeadline(t time.Time) error {
+	c.SetReadDeadline(t)
+	return c.SetWriteDeadline(t)
+}
+
+// SetReadDeadline implements net.Conn.
+func (c *sshConn) SetReadDeadline(t time.Time) error {
+	c.setDeadline(&c.readTimer, &c.readExpired, t)
+	return nil
+}
+
+// SetWriteDeadline implements net.Conn.
+func (c *sshConn) SetWriteDeadline(t time.Time) error {
+	c.setDeadline(&c.writeTimer, &c.writeExpired, t)
+	return nil
+}
+
+// setDeadline replaces the deadline kept by timer with t, at which
+// expired will be set and the connection closed. The zero time
+// removes the deadline.
+func (c *sshConn) setDeadline(timer **time.Timer, expired *bool, t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stopTimer(*timer)
+	*timer = nil
+	if t.IsZero() {
+		return
+	}
+	*timer = time.AfterFunc(t.Sub(time.Now()), func() {
+		c.mu.Lock()
+		*expired = true
+		c.mu.Unlock()
+		c.Close()
+	})
+}
+
+// expired reports whether the deadline recorded by the given
+// flag has passed.
+func (c *sshConn) expired(flag *bool) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return *flag
+}
+
+func stopTimer(timer *time.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
+
+// timeoutError is returned by reads and writes on an sshConn
+// whose deadline has passed.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// sshAddr is the address of one end of an sshConn.
+type sshAddr string
+
+func (a sshAddr) Network() string { return "ssh" }
+func (a sshAddr) String() string  { return string(a) }
+
+// listContainers returns the host's containers whose names start with
+// the given prefix.
+func listContainers(host, prefix string) ([]containerInfo, error) {
+	client := newHostClient(host)
+	defer client.Close()
+	names, err := client.ContainerNames()
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot list containers on %q", host)
+	}
+	var containers []containerInfo
+	for _, name := range names {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		info, err := getContainerInfo(client, name)
+		if errors.IsNotFound(err) {
+			// The container was deleted after it was listed.
+			continue
+		} else if err != nil {
+			return nil, errors.Annotatef(err, "cannot get container %q on %q", name, host)
+		}
+		containers = append(containers, info)
+	}
+	return containers, nil
+}
+
+// getContainer returns the named container on the given host. An
+// error satisfying errors.IsNotFound is returned if it does not exist.
+func getContainer(host, name string) (containerInfo, error) {
+	client := newHostClient(host)
+	defer client.Close()
+	info, err := getContainerInfo(client, name)
+	if errors.IsNotFound(err) {
+		return containerInfo{}, errors.NotFoundf("container %q on %q", name, host)
+	} else if err != nil {
+		return containerInfo{}, errors.Annotatef(err, "cannot get container %q on %q", name, host)
+	}
+	return info, nil
+}
+
+func getContainerInfo(client *lxd.Client, name string) (containerInfo, error) {
+	ctr, err := client.Container(name)
+	if err != nil {
+		return containerInfo{}, errors.Trace(err)
+	}
+	state, err := client.ContainerState(name)
+	if err != nil {
+		return containerInfo{}, errors.Trace(err)
+	}
+	return containerInfo{
+		name:        name,
+		status:      state.Status,
+		stateServer: ctr.Config[stateServerKey] == "true",
+		address:     containerAddress(state),
+	}, nil
+}
+
+// containerAddress returns the first global IPv4 address of the
+// container's eth0 interface, or "" if it has none.
+func containerAddress(state *lxd.ContainerState) string {
+	for _, addr := range state.Network["eth0"].Addresses {
+		if addr.Family == "inet" && addr.Scope == "global" {
+			return addr.Address
+		}
+	}
+	return ""
+}
+
+// launchContainer creates and starts a container on the given host.
+// If the container cannot be started, it is deleted.
+func launchContainer(host string, p launchParams) error {
+	config := map[string]string{
+		stateServerKey: strconv.FormatBool(p.stateServer),
+		userDataKey:    string(p.userData),
+	}
+	if p.memory > 0 {
+		config["limits.memory"] = fmt.Sprintf("%dMB", p.memory)
+	}
+	if p.cpus > 0 {
+		config["limits.cpu"] = strconv.FormatUint(p.cpus, 10)
+	}
+	spec := lxd.ContainerSpec{
+		Name:   p.name,
+		Source: p.source,
+		Config: config,
+		Devices: map[string]map[string]string{
+			"eth0": {
+				"type":    "nic",
+				"nictype": "bridged",
+				"parent":  p.bridge,
+			},
+		},
+	}
+
+	client := newHostClient(host)
+	defer client.Close()
+	if err := client.CreateContainer(spec); err != nil {
+		return errors.Annotatef(err, "cannot create container %q on %q", p.name, host)
+	}
+	if err := client.StartContainer(p.name); err != nil {
+		if err := deleteContainer(client, p.name); err != nil {
+			logger.Warningf("cannot delete container %q: %v", p.name, err)
+		}
+		return errors.Annotatef(err, "cannot start container %q on %q", p.name, host)
+	}
+	return nil
+}
+
+// destroyContainer stops and deletes the named container on the given
+// host, if it exists.
+func destroyContainer(host, name string) error {
+	client := newHostClient(host)
+	defer client.Close()
+	if err := deleteContainer(client, name); err != nil {
+		return errors.Annotatef(err, "cannot delete container %q on %q", name, host)
+	}
+	return nil
+}
+
+func deleteContainer(client *lxd.Client, name string) error {
+	state, err := client.ContainerState(name)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	if state.Status != lxd.StatusStopped {
+		if err := client.StopContainer(name); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(client.DeleteContainer(name))
+}
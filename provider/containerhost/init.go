@@ -0,0 +1,16 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package containerhost
+
+import (
+	"github.com/juju/juju/environs"
+)
+
+const (
+	providerType = "container-host"
+)
+
+func init() {
+	environs.RegisterProvider(providerType, providerInstance)
+}
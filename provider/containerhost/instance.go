@@ -0,0 +1,86 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package containerhost
+
+import (
+	"strings"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/network"
+)
+
+// environInstance is a container on one of the environment's hosts.
+type environInstance struct {
+	host string
+	base containerInfo
+	env  *environ
+}
+
+var _ instance.Instance = (*environInstance)(nil)
+
+func newInstance(host string, base containerInfo, env *environ) *environInstance {
+	return &environInstance{
+		host: host,
+		base: base,
+		env:  env,
+	}
+}
+
+// Id implements instance.Instance.
+func (inst *environInstance) Id() instance.Id {
+	return instance.Id(inst.base.name)
+}
+
+// Status implements instance.Instance.
+func (inst *environInstance) Status() string {
+	return strings.ToLower(inst.base.status)
+}
+
+// Refresh implements instance.Instance.
+func (inst *environInstance) Refresh() error {
+	info, err := getContainer(inst.host, inst.base.name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	inst.base = info
+	return nil
+}
+
+// Addresses implements instance.Instance.
+func (inst *environInstance) Addresses() ([]network.Address, error) {
+	if inst.base.address == "" {
+		return nil, nil
+	}
+	return []network.Address{network.NewAddress(inst.base.address)}, nil
+}
+
+func findInst(id instance.Id, instances []*environInstance) instance.Instance {
+	for _, inst := range instances {
+		if id == inst.Id() {
+			return inst
+		}
+	}
+	return nil
+}
+
+// OpenPorts opens the given ports on the instance, which should have
+// been started with the given machine id. Containers are attached
+// directly to the hosts' network, so all their ports are always open.
+func (inst *environInstance) OpenPorts(machineID string, ports []network.PortRange) error {
+	return nil
+}
+
+// ClosePorts closes the given ports on the instance, which
+// should have been started with the given machine id.
+func (inst *environInstance) ClosePorts(machineID string, ports []network.PortRange) error {
+	return nil
+}
+
+// Ports returns the set of ports open on the instance, which
+// should have been started with the given machine id.
+func (inst *environInstance) Ports(machineID string) ([]network.PortRange, error) {
+	return nil, nil
+}
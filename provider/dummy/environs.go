@@ -276,6 +276,7 @@ type environ struct {
 }
 
 var _ environs.Environ = (*environ)(nil)
+var _ environs.SourceCIDRFirewaller = (*environ)(nil)
 
 // discardOperations discards all Operations written to it.
 var discardOperations chan<- Operation
@@ -1136,6 +1137,13 @@ func (env *environ) NetworkInterfaces(instId instance.Id) ([]network.InterfaceIn
 	if strings.HasPrefix(string(instId), "i-no-nics-") {
 		// Simulate no NICs on instances with id prefix "i-no-nics-".
 		info = info[:0]
+	} else if strings.HasPrefix(string(instId), "i-all-nics-") {
+		// Simulate all NICs enabled and auto-started on instances
+		// with id prefix "i-all-nics-".
+		for i := range info {
+			info[i].Disabled = false
+			info[i].NoAutoStart = false
+		}
 	} else if strings.HasPrefix(string(instId), "i-nic-no-subnet-") {
 		// Simulate a nic with no subnet on instances with id prefix
 		// "i-nic-no-subnet-"
@@ -1276,6 +1284,12 @@ func (e *environ) AllInstances() ([]instance.Instance, error) {
 	return insts, nil
 }
 
+// SupportsSourceCIDRs is specified in the environs.SourceCIDRFirewaller
+// interface.
+func (e *environ) SupportsSourceCIDRs() bool {
+	return true
+}
+
 func (e *environ) OpenPorts(ports []network.PortRange) error {
 	if mode := e.ecfg().FirewallMode(); mode != config.FwGlobal {
 		return fmt.Errorf("invalid firewall mode %q for opening ports on environment", mode)
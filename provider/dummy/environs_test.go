@@ -287,6 +287,15 @@ func (s *suite) TestNetworkInterfaces(c *gc.C) {
 	c.Assert(info, gc.HasLen, 0)
 	assertInterfaces(c, e, opc, "i-no-nics-here", expectInfo[:0])
 
+	// Test that with instance id prefix "i-all-nics-" all NICs are
+	// enabled.
+	expectInfo[1].Disabled = false
+	expectInfo[1].NoAutoStart = false
+	info, err = e.NetworkInterfaces("i-all-nics-here")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(info, jc.DeepEquals, expectInfo)
+	assertInterfaces(c, e, opc, "i-all-nics-here", expectInfo)
+
 	// Test that with instance id prefix "i-nic-no-subnet-" we get a result
 	// with no associated subnet.
 	expectInfo = []network.InterfaceInfo{{
@@ -6,6 +6,7 @@ package ec2
 import (
 	"fmt"
 	"net"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -74,6 +75,7 @@ type environ struct {
 // Ensure EC2 provider supports environs.NetworkingEnviron.
 var _ environs.NetworkingEnviron = (*environ)(nil)
 var _ simplestreams.HasRegion = (*environ)(nil)
+var _ environs.SourceCIDRFirewaller = (*environ)(nil)
 var _ state.Prechecker = (*environ)(nil)
 var _ state.InstanceDistributor = (*environ)(nil)
 
@@ -419,6 +421,32 @@ func (e *environ) DistributeInstances(candidates, distributionGroup []instance.I
 
 var availabilityZoneAllocations = common.AvailabilityZoneAllocations
 
+// zonesWithSubnets returns those of the given availability zones that
+// contain one of the given subnets, keeping their order, along with
+// the subnet to start an instance in for each zone.
+func zonesWithSubnets(zones []string, subnetsToZones map[network.Id][]string) ([]string, map[string]network.Id) {
+	subnetIds := make([]string, 0, len(subnetsToZones))
+	for subnetId := range subnetsToZones {
+		subnetIds = append(subnetIds, string(subnetId))
+	}
+	sort.Strings(subnetIds)
+	zoneSubnets := make(map[string]network.Id)
+	for _, subnetId := range subnetIds {
+		for _, zone := range subnetsToZones[network.Id(subnetId)] {
+			if _, ok := zoneSubnets[zone]; !ok {
+				zoneSubnets[zone] = network.Id(subnetId)
+			}
+		}
+	}
+	var result []string
+	for _, zone := range zones {
+		if _, ok := zoneSubnets[zone]; ok {
+			result = append(result, zone)
+		}
+	}
+	return result, zoneSubnets
+}
+
 // StartInstance is specified in the InstanceBroker interface.
 func (e *environ) StartInstance(args environs.StartInstanceParams) (*environs.StartInstanceResult, error) {
 	var availabilityZones []string
@@ -457,6 +485,16 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (*environs.St
 		}
 	}
 
+	// If the machine is constrained to spaces, it must be started in
+	// one of their subnets, so only zones containing one will do.
+	var zoneSubnets map[string]network.Id
+	if len(args.SubnetsToZones) > 0 {
+		availabilityZones, zoneSubnets = zonesWithSubnets(availabilityZones, args.SubnetsToZones)
+		if len(availabilityZones) == 0 {
+			return nil, errors.New("no availability zone contains a subnet in the constrained spaces")
+		}
+	}
+
 	if args.MachineConfig.HasNetworks() {
 		return nil, errors.New("starting instances with networks is not supported yet")
 	}
@@ -508,6 +546,7 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (*environs.St
 	for _, availZone := range availabilityZones {
 		instResp, err = runInstances(e.ec2(), &ec2.RunInstances{
 			AvailZone:           availZone,
+			SubnetId:            string(zoneSubnets[availZone]),
 			ImageId:             spec.Image.Id,
 			MinCount:            1,
 			MaxCount:            1,
@@ -966,14 +1005,22 @@ func (e *environ) Destroy() error {
 	return e.Storage().RemoveAll()
 }
 
+// anyCIDR is the source CIDR used for port ranges that may be
+// accessed from any address.
+const anyCIDR = "0.0.0.0/0"
+
 func portsToIPPerms(ports []network.PortRange) []ec2.IPPerm {
 	ipPerms := make([]ec2.IPPerm, len(ports))
 	for i, p := range ports {
+		sourceCIDR := p.SourceCIDR
+		if sourceCIDR == "" {
+			sourceCIDR = anyCIDR
+		}
 		ipPerms[i] = ec2.IPPerm{
 			Protocol:  p.Protocol,
 			FromPort:  p.FromPort,
 			ToPort:    p.ToPort,
-			SourceIPs: []string{"0.0.0.0/0"},
+			SourceIPs: []string{sourceCIDR},
 		}
 	}
 	return ipPerms
@@ -983,7 +1030,8 @@ func (e *environ) openPortsInGroup(name string, ports []network.PortRange) error
 	if len(ports) == 0 {
 		return nil
 	}
-	// Give permissions for anyone to access the given ports.
+	// Give permissions for the given ports' source addresses
+	// (or anyone, if unrestricted) to access them.
 	g, err := e.groupByName(name)
 	if err != nil {
 		return err
@@ -1016,7 +1064,8 @@ func (e *environ) closePortsInGroup(name string, ports []network.PortRange) erro
 	if len(ports) == 0 {
 		return nil
 	}
-	// Revoke permissions for anyone to access the given ports.
+	// Revoke permissions for the given ports' source addresses to
+	// access them.
 	// Note that ec2 allows the revocation of permissions that aren't
 	// granted, so this is naturally idempotent.
 	g, err := e.groupByName(name)
@@ -1036,20 +1085,34 @@ func (e *environ) portsInGroup(name string) (ports []network.PortRange, err erro
 		return nil, err
 	}
 	for _, p := range group.IPPerms {
-		if len(p.SourceIPs) != 1 {
+		if len(p.SourceIPs) == 0 {
 			logger.Warningf("unexpected IP permission found: %v", p)
 			continue
 		}
-		ports = append(ports, network.PortRange{
-			Protocol: p.Protocol,
-			FromPort: p.FromPort,
-			ToPort:   p.ToPort,
-		})
+		// EC2 reports all the source addresses allowed to access
+		// a port range in a single permission.
+		for _, sourceIP := range p.SourceIPs {
+			portRange := network.PortRange{
+				Protocol: p.Protocol,
+				FromPort: p.FromPort,
+				ToPort:   p.ToPort,
+			}
+			if sourceIP != anyCIDR {
+				portRange.SourceCIDR = sourceIP
+			}
+			ports = append(ports, portRange)
+		}
 	}
 	network.SortPortRanges(ports)
 	return ports, nil
 }
 
+// SupportsSourceCIDRs is specified in the environs.SourceCIDRFirewaller
+// interface.
+func (e *environ) SupportsSourceCIDRs() bool {
+	return true
+}
+
 func (e *environ) OpenPorts(ports []network.PortRange) error {
 	if e.Config().FirewallMode() != config.FwGlobal {
 		return fmt.Errorf("invalid firewall mode %q for opening ports on environment",
@@ -132,6 +132,20 @@ func (*Suite) TestPortsToIPPerms(c *gc.C) {
 			ToPort:    120,
 			SourceIPs: []string{"0.0.0.0/0"},
 		}},
+	}, {
+		about: "restricted source CIDR",
+		ports: []network.PortRange{{
+			FromPort:   80,
+			ToPort:     80,
+			Protocol:   "tcp",
+			SourceCIDR: "10.0.0.0/8",
+		}},
+		expected: []amzec2.IPPerm{{
+			Protocol:  "tcp",
+			FromPort:  80,
+			ToPort:    80,
+			SourceIPs: []string{"10.0.0.0/8"},
+		}},
 	}}
 
 	for i, t := range testCases {
@@ -140,3 +154,20 @@ func (*Suite) TestPortsToIPPerms(c *gc.C) {
 		c.Assert(ipperms, gc.DeepEquals, t.expected)
 	}
 }
+
+func (*Suite) TestZonesWithSubnets(c *gc.C) {
+	zones := []string{"zone3", "zone1", "zone2"}
+	subnetsToZones := map[network.Id][]string{
+		"subnet-b": {"zone1"},
+		"subnet-a": {"zone1"},
+		"subnet-c": {"zone3"},
+		"subnet-d": {"zone4"},
+	}
+	result, zoneSubnets := zonesWithSubnets(zones, subnetsToZones)
+	c.Assert(result, jc.DeepEquals, []string{"zone3", "zone1"})
+	c.Assert(zoneSubnets["zone1"], gc.Equals, network.Id("subnet-a"))
+	c.Assert(zoneSubnets["zone3"], gc.Equals, network.Id("subnet-c"))
+
+	result, _ = zonesWithSubnets(zones, map[network.Id][]string{"subnet-d": {"zone4"}})
+	c.Assert(result, gc.HasLen, 0)
+}
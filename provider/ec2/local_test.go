@@ -718,6 +718,11 @@ func (t *localServerSuite) TestSupportsNetworking(c *gc.C) {
 	c.Assert(supported, jc.IsTrue)
 }
 
+func (t *localServerSuite) TestSupportsSourceCIDRs(c *gc.C) {
+	env := t.Prepare(c)
+	c.Assert(environs.SupportsSourceCIDRs(env), jc.IsTrue)
+}
+
 func (t *localServerSuite) TestAllocateAddressFailureToFindNetworkInterface(c *gc.C) {
 	env := t.prepareEnviron(c)
 	err := bootstrap.Bootstrap(envtesting.BootstrapContext(c), env, bootstrap.BootstrapParams{})
@@ -67,6 +67,7 @@ func (env *environ) lookupArchitectures() ([]string, error) {
 var unsupportedConstraints = []string{
 	constraints.Tags,
 	constraints.Networks,
+	constraints.Spaces,
 }
 
 // instanceTypeConstraints defines the fields defined on each of the
@@ -10,6 +10,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -821,12 +822,21 @@ func (environ *maasEnviron) StartInstance(args environs.StartInstanceParams) (
 	includeNetworks := append(args.Constraints.IncludeNetworks(), requestedNetworks...)
 	excludeNetworks := args.Constraints.ExcludeNetworks()
 
+	// MAAS subnets are named networks; a machine constrained to
+	// spaces must be connected to one of their subnets.
+	var subnets []string
+	for subnetId := range args.SubnetsToZones {
+		subnets = append(subnets, string(subnetId))
+	}
+	sort.Strings(subnets)
+
 	snArgs := selectNodeArgs{
 		Constraints:       args.Constraints,
 		AvailabilityZones: availabilityZones,
 		NodeName:          nodeName,
 		IncludeNetworks:   includeNetworks,
 		ExcludeNetworks:   excludeNetworks,
+		Subnets:           subnets,
 	}
 	node, err := environ.selectNode(snArgs)
 	if err != nil {
@@ -965,33 +975,49 @@ type selectNodeArgs struct {
 	Constraints       constraints.Value
 	IncludeNetworks   []string
 	ExcludeNetworks   []string
+	// Subnets, if set, holds the names of networks the node must be
+	// connected to one of, in order of preference.
+	Subnets []string
 }
 
 func (environ *maasEnviron) selectNode(args selectNodeArgs) (*gomaasapi.MAASObject, error) {
 	var err error
 	var node gomaasapi.MAASObject
 
-	for i, zoneName := range args.AvailabilityZones {
-		node, err = environ.acquireNode(
-			args.NodeName,
-			zoneName,
-			args.Constraints,
-			args.IncludeNetworks,
-			args.ExcludeNetworks,
-		)
-
-		if err, ok := err.(gomaasapi.ServerError); ok && err.StatusCode == http.StatusConflict {
-			if i+1 < len(args.AvailabilityZones) {
-				logger.Infof("could not acquire a node in zone %q, trying another zone", zoneName)
-				continue
+	// MAAS requires a node to be connected to all the networks it is
+	// asked for, so each of the subnets is tried in turn.
+	networkChoices := [][]string{args.IncludeNetworks}
+	if len(args.Subnets) > 0 {
+		networkChoices = make([][]string, len(args.Subnets))
+		for i, subnet := range args.Subnets {
+			networkChoices[i] = append(append([]string(nil), args.IncludeNetworks...), subnet)
+		}
+	}
+	for j, includeNetworks := range networkChoices {
+		for i, zoneName := range args.AvailabilityZones {
+			node, err = environ.acquireNode(
+				args.NodeName,
+				zoneName,
+				args.Constraints,
+				includeNetworks,
+				args.ExcludeNetworks,
+			)
+
+			if err, ok := err.(gomaasapi.ServerError); ok && err.StatusCode == http.StatusConflict {
+				if i+1 < len(args.AvailabilityZones) {
+					logger.Infof("could not acquire a node in zone %q, trying another zone", zoneName)
+					continue
+				}
+				if j+1 < len(networkChoices) {
+					logger.Infof("could not acquire a node in subnet %q, trying another subnet", args.Subnets[j])
+					continue
+				}
 			}
+			if err != nil {
+				return nil, errors.Errorf("cannot run instances: %v", err)
+			}
+			return &node, nil
 		}
-		if err != nil {
-			return nil, errors.Errorf("cannot run instances: %v", err)
-		}
-		// Since a return at the end of the function is required
-		// just break here.
-		break
 	}
 	return &node, nil
 }
@@ -301,6 +301,26 @@ func (suite *environSuite) TestSelectNodeInvalidZone(c *gc.C) {
 	c.Assert(fmt.Sprintf("%s", err), gc.Equals, "cannot run instances: gomaasapi: got error back from server: 409 Conflict ()")
 }
 
+func (suite *environSuite) TestSelectNodeInSubnet(c *gc.C) {
+	env := suite.makeEnviron()
+	suite.testMAASObject.TestServer.NewNode(`{"system_id": "node0", "hostname": "host0"}`)
+
+	snArgs := selectNodeArgs{
+		AvailabilityZones: []string{""},
+		Constraints:       constraints.Value{},
+		IncludeNetworks:   []string{"included_net_1"},
+		Subnets:           []string{"space_net_1", "space_net_2"},
+	}
+
+	node, err := env.selectNode(snArgs)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(node, gc.NotNil)
+	requestValues := suite.testMAASObject.TestServer.NodeOperationRequestValues()
+	nodeValues, found := requestValues["node0"]
+	c.Assert(found, jc.IsTrue)
+	c.Assert(nodeValues[0]["networks"], jc.DeepEquals, []string{"included_net_1", "space_net_1"})
+}
+
 func (suite *environSuite) TestAcquireNode(c *gc.C) {
 	env := suite.makeEnviron()
 	suite.testMAASObject.TestServer.NewNode(`{"system_id": "node0", "hostname": "host0"}`)
@@ -306,6 +306,7 @@ var unsupportedConstraints = []string{
 	constraints.CpuPower,
 	constraints.InstanceType,
 	constraints.Tags,
+	constraints.Spaces,
 }
 
 // ConstraintsValidator is defined on the Environs interface.
@@ -573,6 +573,7 @@ func (e *environ) SupportedArchitectures() ([]string, error) {
 var unsupportedConstraints = []string{
 	constraints.Tags,
 	constraints.CpuPower,
+	constraints.Spaces,
 }
 
 // ConstraintsValidator is defined on the Environs interface.
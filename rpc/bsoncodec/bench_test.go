@@ -0,0 +1,236 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package bsoncodec_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/api"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/rpc"
+	"github.com/juju/juju/rpc/bsoncodec"
+	"github.com/juju/juju/rpc/jsoncodec"
+	"github.com/juju/juju/state/multiwatcher"
+)
+
+// BenchmarkSuite compares the cost of sending a large FullStatus
+// response over the JSON and BSON codecs. Run with:
+//
+//	go test -check.b -check.bmem
+type BenchmarkSuite struct{}
+
+var _ = gc.Suite(&BenchmarkSuite{})
+
+func (*BenchmarkSuite) BenchmarkJSONFullStatus100(c *gc.C) {
+	benchmarkFullStatus(c, newJSONLoopback(), 100)
+}
+
+func (*BenchmarkSuite) BenchmarkBSONFullStatus100(c *gc.C) {
+	benchmarkFullStatus(c, newBSONLoopback(), 100)
+}
+
+func (*BenchmarkSuite) BenchmarkJSONFullStatus5000(c *gc.C) {
+	benchmarkFullStatus(c, newJSONLoopback(), 5000)
+}
+
+func (*BenchmarkSuite) BenchmarkBSONFullStatus5000(c *gc.C) {
+	benchmarkFullStatus(c, newBSONLoopback(), 5000)
+}
+
+func (*suite) TestFullStatusRoundTrip(c *gc.C) {
+	// BSON decodes times into the local time zone, so leave
+	// them out of the comparison.
+	status := makeFullStatus(20, nil)
+	for i, lb := range []loopback{newJSONLoopback(), newBSONLoopback()} {
+		c.Logf("test %d", i)
+		got := sendFullStatus(c, lb, &status)
+		c.Assert(got, jc.DeepEquals, status)
+	}
+}
+
+// loopback is an rpc.Codec that reads back the messages
+// it has written.
+type loopback interface {
+	rpc.Codec
+	// lastSize returns the size of the last message written.
+	lastSize() int
+}
+
+func benchmarkFullStatus(c *gc.C, lb loopback, numUnits int) {
+	since := time.Date(2015, 6, 1, 12, 0, 0, 0, time.UTC)
+	status := makeFullStatus(numUnits, &since)
+	c.ResetTimer()
+	for i := 0; i < c.N; i++ {
+		sendFullStatus(c, lb, &status)
+	}
+	c.StopTimer()
+	c.SetBytes(int64(lb.lastSize()))
+}
+
+// sendFullStatus writes status as an RPC response and reads it back
+// through the given codec.
+func sendFullStatus(c *gc.C, lb loopback, status *api.Status) api.Status {
+	hdr := rpc.Header{RequestId: 1}
+	err := lb.WriteMessage(&hdr, status)
+	c.Assert(err, jc.ErrorIsNil)
+	var gotHdr rpc.Header
+	err = lb.ReadHeader(&gotHdr)
+	c.Assert(err, jc.ErrorIsNil)
+	var result api.Status
+	err = lb.ReadBody(&result, false)
+	c.Assert(err, jc.ErrorIsNil)
+	return result
+}
+
+// makeFullStatus returns the status of an environment with the
+// given number of units spread over ten services, each unit on
+// its own machine. All agent statuses report the given since time.
+func makeFullStatus(numUnits int, since *time.Time) api.Status {
+	agent := func(status params.Status) api.AgentStatus {
+		return api.AgentStatus{
+			Status:  status,
+			Info:    "all good",
+			Since:   since,
+			Version: "1.24.0",
+			Life:    "alive",
+		}
+	}
+	status := api.Status{
+		EnvironmentName: "bench",
+		Machines:        make(map[string]api.MachineStatus),
+		Services:        make(map[string]api.ServiceStatus),
+	}
+	for i := 0; i < numUnits; i++ {
+		id := fmt.Sprint(i)
+		status.Machines[id] = api.MachineStatus{
+			Agent:          agent(params.StatusStarted),
+			AgentState:     params.StatusStarted,
+			AgentVersion:   "1.24.0",
+			DNSName:        fmt.Sprintf("10.0.%d.%d", i/256, i%256),
+			InstanceId:     instance.Id("i-" + id),
+			InstanceState:  "running",
+			Series:         "trusty",
+			Id:             id,
+			Hardware:       "arch=amd64 cpu-cores=1 mem=1740M",
+			Jobs:           []multiwatcher.MachineJob{multiwatcher.JobHostUnits},
+			Containers:     map[string]api.MachineStatus{},
+			AgentStateInfo: "",
+		}
+		serviceName := fmt.Sprintf("service-%d", i%10)
+		svc, ok := status.Services[serviceName]
+		if !ok {
+			svc = api.ServiceStatus{
+				Charm:   "cs:trusty/" + serviceName + "-1",
+				Exposed: true,
+				Life:    "alive",
+				Relations: map[string][]string{
+					"db": {"mysql"},
+				},
+				Units: make(map[string]api.UnitStatus),
+			}
+		}
+		svc.Units[fmt.Sprintf("%s/%d", serviceName, i)] = api.UnitStatus{
+			UnitAgent:     agent(params.StatusIdle),
+			Workload:      agent(params.StatusActive),
+			AgentState:    params.StatusStarted,
+			AgentVersion:  "1.24.0",
+			Machine:       id,
+			OpenedPorts:   []string{"80/tcp", "443/tcp"},
+			PublicAddress: fmt.Sprintf("54.0.%d.%d", i/256, i%256),
+		}
+		status.Services[serviceName] = svc
+	}
+	return status
+}
+
+// jsonLoopback implements jsoncodec.JSONConn by holding
+// the most recently sent message.
+type jsonLoopback struct {
+	data []byte
+	size int
+}
+
+func newJSONLoopback() loopback {
+	conn := &jsonLoopback{}
+	return jsonLoopbackCodec{jsoncodec.New(conn), conn}
+}
+
+func (c *jsonLoopback) Send(msg interface{}) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.data = data
+	c.size = len(data)
+	return nil
+}
+
+func (c *jsonLoopback) Receive(msg interface{}) error {
+	if c.data == nil {
+		return io.EOF
+	}
+	data := c.data
+	c.data = nil
+	return json.Unmarshal(data, msg)
+}
+
+func (c *jsonLoopback) Close() error {
+	return nil
+}
+
+type jsonLoopbackCodec struct {
+	*jsoncodec.Codec
+	conn *jsonLoopback
+}
+
+func (c jsonLoopbackCodec) lastSize() int {
+	return c.conn.size
+}
+
+// bsonLoopback implements bsoncodec.BSONConn by holding
+// the most recently sent message.
+type bsonLoopback struct {
+	data []byte
+	size int
+}
+
+func newBSONLoopback() loopback {
+	conn := &bsonLoopback{}
+	return bsonLoopbackCodec{bsoncodec.New(conn), conn}
+}
+
+func (c *bsonLoopback) Send(data []byte) error {
+	c.data = data
+	c.size = len(data)
+	return nil
+}
+
+func (c *bsonLoopback) Receive() ([]byte, error) {
+	if c.data == nil {
+		return nil, io.EOF
+	}
+	data := c.data
+	c.data = nil
+	return data, nil
+}
+
+func (c *bsonLoopback) Close() error {
+	return nil
+}
+
+type bsonLoopbackCodec struct {
+	*bsoncodec.Codec
+	conn *bsonLoopback
+}
+
+func (c bsonLoopbackCodec) lastSize() int {
+	return c.conn.size
+}
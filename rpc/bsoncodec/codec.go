@@ -0,0 +1,220 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The bsoncodec package provides a BSON codec for the rpc package.
+// It is a more compact and cheaper to decode alternative to the
+// jsoncodec package, and is selected during the websocket handshake
+// only when both ends of a connection support it.
+//
+// Values are encoded using the rules of the gopkg.in/mgo.v2/bson
+// package, so types with custom JSON marshalling must also implement
+// bson.Getter and bson.Setter if they are to be sent over this codec.
+// Note also that BSON holds times with millisecond precision only.
+package bsoncodec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/juju/loggo"
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/juju/juju/rpc"
+)
+
+var logger = loggo.GetLogger("juju.rpc.bsoncodec")
+
+// Protocol holds the websocket sub-protocol name used to
+// negotiate the use of this codec.
+const Protocol = "juju-rpc-bson"
+
+// BSONConn sends and receives BSON-encoded messages to an
+// underlying connection.
+type BSONConn interface {
+	// Send sends a single encoded message.
+	Send(data []byte) error
+	// Receive receives a single encoded message.
+	Receive() ([]byte, error)
+	Close() error
+}
+
+// Codec implements rpc.Codec for a connection.
+type Codec struct {
+	// msg holds the message that's just been read by ReadHeader, so
+	// that the body can be read by ReadBody.
+	msg         inMsg
+	conn        BSONConn
+	logMessages int32
+	mu          sync.Mutex
+	closing     bool
+}
+
+// New returns an rpc codec that uses conn to send and receive
+// messages.
+func New(conn BSONConn) *Codec {
+	return &Codec{
+		conn: conn,
+	}
+}
+
+// SetLogging sets whether messages will be logged
+// by the codec.
+func (c *Codec) SetLogging(on bool) {
+	val := int32(0)
+	if on {
+		val = 1
+	}
+	atomic.StoreInt32(&c.logMessages, val)
+}
+
+func (c *Codec) isLogging() bool {
+	return atomic.LoadInt32(&c.logMessages) != 0
+}
+
+// inMsg holds an incoming message.  We don't know the type of the
+// parameters or response yet, so we delay parsing by storing them
+// as raw BSON values.
+type inMsg struct {
+	RequestId uint64   `bson:"requestid"`
+	Type      string   `bson:"type"`
+	Version   int      `bson:"version"`
+	Id        string   `bson:"id"`
+	Request   string   `bson:"request"`
+	Params    bson.Raw `bson:"params"`
+	Error     string   `bson:"error"`
+	ErrorCode string   `bson:"errorcode"`
+	Response  bson.Raw `bson:"response"`
+	TraceId   string   `bson:"traceid"`
+}
+
+// outMsg holds an outgoing message.
+type outMsg struct {
+	RequestId uint64      `bson:"requestid"`
+	Type      string      `bson:"type,omitempty"`
+	Version   int         `bson:"version,omitempty"`
+	Id        string      `bson:"id,omitempty"`
+	Request   string      `bson:"request,omitempty"`
+	Params    interface{} `bson:"params,omitempty"`
+	Error     string      `bson:"error,omitempty"`
+	ErrorCode string      `bson:"errorcode,omitempty"`
+	Response  interface{} `bson:"response,omitempty"`
+	TraceId   string      `bson:"traceid,omitempty"`
+}
+
+// bsonNull is the BSON kind of a null value.
+const bsonNull = 0x0A
+
+func (c *Codec) Close() error {
+	c.mu.Lock()
+	c.closing = true
+	c.mu.Unlock()
+	return c.conn.Close()
+}
+
+func (c *Codec) isClosing() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closing
+}
+
+func (c *Codec) ReadHeader(hdr *rpc.Header) error {
+	c.msg = inMsg{} // avoid any potential cross-message contamination.
+	data, err := c.conn.Receive()
+	if err == nil {
+		if c.isLogging() {
+			logger.Tracef("<- %s", logString(data))
+		}
+		err = bson.Unmarshal(data, &c.msg)
+	} else if c.isLogging() {
+		logger.Tracef("<- error: %v (closing %v)", err, c.isClosing())
+	}
+	if err != nil {
+		// If we've closed the connection, we may get a spurious error,
+		// so ignore it.
+		if c.isClosing() || err == io.EOF {
+			return io.EOF
+		}
+		return fmt.Errorf("error receiving message: %v", err)
+	}
+	hdr.RequestId = c.msg.RequestId
+	hdr.Request = rpc.Request{
+		Type:    c.msg.Type,
+		Version: c.msg.Version,
+		Id:      c.msg.Id,
+		Action:  c.msg.Request,
+	}
+	hdr.Error = c.msg.Error
+	hdr.ErrorCode = c.msg.ErrorCode
+	hdr.TraceId = c.msg.TraceId
+	return nil
+}
+
+func (c *Codec) ReadBody(body interface{}, isRequest bool) error {
+	if body == nil {
+		return nil
+	}
+	var rawBody bson.Raw
+	if isRequest {
+		rawBody = c.msg.Params
+	} else {
+		rawBody = c.msg.Response
+	}
+	if rawBody.Kind == 0 || rawBody.Kind == bsonNull {
+		// If the response or params are omitted, it's
+		// equivalent to an empty object.
+		return nil
+	}
+	return rawBody.Unmarshal(body)
+}
+
+func (c *Codec) WriteMessage(hdr *rpc.Header, body interface{}) error {
+	var m outMsg
+	m.init(hdr, body)
+	data, err := bson.Marshal(&m)
+	if err != nil {
+		if c.isLogging() {
+			logger.Tracef("-> marshal error: %v", err)
+		}
+		return err
+	}
+	if c.isLogging() {
+		logger.Tracef("-> %s", logString(data))
+	}
+	return c.conn.Send(data)
+}
+
+// init fills out the receiving outMsg with information from the given
+// header and body.
+func (m *outMsg) init(hdr *rpc.Header, body interface{}) {
+	m.RequestId = hdr.RequestId
+	m.Type = hdr.Request.Type
+	m.Version = hdr.Request.Version
+	m.Id = hdr.Request.Id
+	m.Request = hdr.Request.Action
+	m.Error = hdr.Error
+	m.ErrorCode = hdr.ErrorCode
+	m.TraceId = hdr.TraceId
+	if hdr.IsRequest() {
+		m.Params = body
+	} else {
+		m.Response = body
+	}
+}
+
+// logString returns a human-readable rendering of the given
+// BSON-encoded message, so that trace logs look the same
+// regardless of the codec in use.
+func logString(data []byte) string {
+	var m bson.M
+	if err := bson.Unmarshal(data, &m); err != nil {
+		return fmt.Sprintf("<invalid message: %v>", err)
+	}
+	text, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Sprintf("%v", m)
+	}
+	return string(text)
+}
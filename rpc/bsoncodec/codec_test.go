@@ -0,0 +1,391 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package bsoncodec_test
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"regexp"
+	stdtesting "testing"
+
+	"github.com/juju/loggo"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/juju/juju/rpc"
+	"github.com/juju/juju/rpc/bsoncodec"
+	"github.com/juju/juju/testing"
+)
+
+type suite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&suite{})
+
+func TestPackage(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type value struct {
+	X string
+}
+
+var readTests = []struct {
+	msg        bson.D
+	expectHdr  rpc.Header
+	expectBody interface{}
+}{{
+	msg: bson.D{
+		{"requestid", 1},
+		{"type", "foo"},
+		{"id", "id"},
+		{"request", "frob"},
+		{"params", bson.M{"x": "param"}},
+	},
+	expectHdr: rpc.Header{
+		RequestId: 1,
+		Request: rpc.Request{
+			Type:   "foo",
+			Id:     "id",
+			Action: "frob",
+		},
+	},
+	expectBody: &value{X: "param"},
+}, {
+	msg: bson.D{
+		{"requestid", 2},
+		{"error", "an error"},
+		{"errorcode", "a code"},
+	},
+	expectHdr: rpc.Header{
+		RequestId: 2,
+		Error:     "an error",
+		ErrorCode: "a code",
+	},
+	expectBody: new(map[string]interface{}),
+}, {
+	msg: bson.D{
+		{"requestid", 3},
+		{"response", bson.M{"x": "result"}},
+	},
+	expectHdr: rpc.Header{
+		RequestId: 3,
+	},
+	expectBody: &value{X: "result"},
+}, {
+	msg: bson.D{
+		{"requestid", 4},
+		{"type", "foo"},
+		{"version", 2},
+		{"id", "id"},
+		{"request", "frob"},
+		{"params", bson.M{"x": "param"}},
+	},
+	expectHdr: rpc.Header{
+		RequestId: 4,
+		Request: rpc.Request{
+			Type:    "foo",
+			Version: 2,
+			Id:      "id",
+			Action:  "frob",
+		},
+	},
+	expectBody: &value{X: "param"},
+}, {
+	msg: bson.D{
+		{"requestid", 5},
+		{"response", nil},
+	},
+	expectHdr: rpc.Header{
+		RequestId: 5,
+	},
+	expectBody: new(map[string]interface{}),
+}}
+
+func (*suite) TestRead(c *gc.C) {
+	for i, test := range readTests {
+		c.Logf("test %d", i)
+		codec := bsoncodec.New(&testConn{
+			readMsgs: [][]byte{marshal(c, test.msg)},
+		})
+		var hdr rpc.Header
+		err := codec.ReadHeader(&hdr)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(hdr, gc.DeepEquals, test.expectHdr)
+
+		c.Assert(hdr.IsRequest(), gc.Equals, test.expectHdr.IsRequest())
+
+		body := reflect.New(reflect.ValueOf(test.expectBody).Type().Elem()).Interface()
+		err = codec.ReadBody(body, test.expectHdr.IsRequest())
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(body, gc.DeepEquals, test.expectBody)
+
+		err = codec.ReadHeader(&hdr)
+		c.Assert(err, gc.Equals, io.EOF)
+	}
+}
+
+func (*suite) TestReadBodyNil(c *gc.C) {
+	codec := bsoncodec.New(&testConn{
+		readMsgs: [][]byte{marshal(c, readTests[0].msg)},
+	})
+	var hdr rpc.Header
+	err := codec.ReadHeader(&hdr)
+	c.Assert(err, jc.ErrorIsNil)
+	err = codec.ReadBody(nil, true)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (*suite) TestReadHeaderLogsRequests(c *gc.C) {
+	codecLogger := loggo.GetLogger("juju.rpc.bsoncodec")
+	defer codecLogger.SetLogLevel(codecLogger.LogLevel())
+	codecLogger.SetLogLevel(loggo.TRACE)
+	msg := marshal(c, readTests[0].msg)
+	codec := bsoncodec.New(&testConn{
+		readMsgs: [][]byte{msg, msg, msg},
+	})
+	expectLog := `{"id":"id","params":{"x":"param"},"request":"frob","requestid":1,"type":"foo"}`
+
+	// Check that logging is off by default
+	var h rpc.Header
+	err := codec.ReadHeader(&h)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(c.GetTestLog(), gc.Matches, "")
+
+	// Check that we see a log message when we switch logging on.
+	codec.SetLogging(true)
+	err = codec.ReadHeader(&h)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(c.GetTestLog(), gc.Matches, ".*TRACE juju.rpc.bsoncodec <- "+regexp.QuoteMeta(expectLog)+`\n`)
+
+	// Check that we can switch it off again
+	codec.SetLogging(false)
+	err = codec.ReadHeader(&h)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(c.GetTestLog(), gc.Matches, ".*TRACE juju.rpc.bsoncodec <- "+regexp.QuoteMeta(expectLog)+`\n`)
+}
+
+func (*suite) TestWriteMessageLogsRequests(c *gc.C) {
+	codecLogger := loggo.GetLogger("juju.rpc.bsoncodec")
+	defer codecLogger.SetLogLevel(codecLogger.LogLevel())
+	codecLogger.SetLogLevel(loggo.TRACE)
+	codec := bsoncodec.New(&testConn{})
+	h := rpc.Header{
+		RequestId: 1,
+		Request: rpc.Request{
+			Type:   "foo",
+			Id:     "id",
+			Action: "frob",
+		},
+	}
+
+	// Check that logging is off by default
+	err := codec.WriteMessage(&h, value{X: "param"})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(c.GetTestLog(), gc.Matches, "")
+
+	// Check that we see a log message when we switch logging on.
+	codec.SetLogging(true)
+	err = codec.WriteMessage(&h, value{X: "param"})
+	c.Assert(err, jc.ErrorIsNil)
+	msg := `{"id":"id","params":{"x":"param"},"request":"frob","requestid":1,"type":"foo"}`
+	c.Assert(c.GetTestLog(), gc.Matches, `.*TRACE juju.rpc.bsoncodec -> `+regexp.QuoteMeta(msg)+`\n`)
+
+	// Check that we can switch it off again
+	codec.SetLogging(false)
+	err = codec.WriteMessage(&h, value{X: "param"})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(c.GetTestLog(), gc.Matches, `.*TRACE juju.rpc.bsoncodec -> `+regexp.QuoteMeta(msg)+`\n`)
+}
+
+func (*suite) TestConcurrentSetLoggingAndWrite(c *gc.C) {
+	// If log messages are not set atomically, this
+	// test will fail when run under the race detector.
+	codec := bsoncodec.New(&testConn{})
+	done := make(chan struct{})
+	go func() {
+		codec.SetLogging(true)
+		done <- struct{}{}
+	}()
+	h := rpc.Header{
+		RequestId: 1,
+		Request: rpc.Request{
+			Type:   "foo",
+			Id:     "id",
+			Action: "frob",
+		},
+	}
+	err := codec.WriteMessage(&h, value{X: "param"})
+	c.Assert(err, jc.ErrorIsNil)
+	<-done
+}
+
+func (*suite) TestErrorAfterClose(c *gc.C) {
+	conn := &testConn{
+		err: errors.New("some error"),
+	}
+	codec := bsoncodec.New(conn)
+	var hdr rpc.Header
+	err := codec.ReadHeader(&hdr)
+	c.Assert(err, gc.ErrorMatches, "error receiving message: some error")
+
+	err = codec.Close()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(conn.closed, jc.IsTrue)
+
+	err = codec.ReadHeader(&hdr)
+	c.Assert(err, gc.Equals, io.EOF)
+}
+
+var writeTests = []struct {
+	hdr    *rpc.Header
+	body   interface{}
+	expect bson.M
+}{{
+	hdr: &rpc.Header{
+		RequestId: 1,
+		Request: rpc.Request{
+			Type:   "foo",
+			Id:     "id",
+			Action: "frob",
+		},
+	},
+	body: &value{X: "param"},
+	expect: bson.M{
+		"requestid": 1,
+		"type":      "foo",
+		"id":        "id",
+		"request":   "frob",
+		"params":    bson.M{"x": "param"},
+	},
+}, {
+	hdr: &rpc.Header{
+		RequestId: 2,
+		Error:     "an error",
+		ErrorCode: "a code",
+	},
+	expect: bson.M{
+		"requestid": 2,
+		"error":     "an error",
+		"errorcode": "a code",
+	},
+}, {
+	hdr: &rpc.Header{
+		RequestId: 3,
+	},
+	body: &value{X: "result"},
+	expect: bson.M{
+		"requestid": 3,
+		"response":  bson.M{"x": "result"},
+	},
+}, {
+	hdr: &rpc.Header{
+		RequestId: 4,
+		Request: rpc.Request{
+			Type:    "foo",
+			Version: 2,
+			Action:  "frob",
+		},
+	},
+	body: &value{X: "param"},
+	expect: bson.M{
+		"requestid": 4,
+		"type":      "foo",
+		"version":   2,
+		"request":   "frob",
+		"params":    bson.M{"x": "param"},
+	},
+}, {
+	hdr: &rpc.Header{
+		RequestId: 5,
+		TraceId:   "deploy-1",
+	},
+	body: &value{X: "result"},
+	expect: bson.M{
+		"requestid": 5,
+		"response":  bson.M{"x": "result"},
+		"traceid":   "deploy-1",
+	},
+}}
+
+func (*suite) TestWrite(c *gc.C) {
+	for i, test := range writeTests {
+		c.Logf("test %d", i)
+		var conn testConn
+		codec := bsoncodec.New(&conn)
+		err := codec.WriteMessage(test.hdr, test.body)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(conn.writeMsgs, gc.HasLen, 1)
+
+		var got bson.M
+		err = bson.Unmarshal(conn.writeMsgs[0], &got)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(got, jc.DeepEquals, test.expect)
+	}
+}
+
+func (*suite) TestRoundTrip(c *gc.C) {
+	var conn testConn
+	codec := bsoncodec.New(&conn)
+	hdr := rpc.Header{
+		RequestId: 1,
+		Request: rpc.Request{
+			Type:    "foo",
+			Version: 1,
+			Id:      "id",
+			Action:  "frob",
+		},
+		TraceId: "deploy-1",
+	}
+	err := codec.WriteMessage(&hdr, value{X: "param"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	conn.readMsgs = conn.writeMsgs
+	var gotHdr rpc.Header
+	err = codec.ReadHeader(&gotHdr)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(gotHdr, gc.DeepEquals, hdr)
+	var body value
+	err = codec.ReadBody(&body, true)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(body, gc.Equals, value{X: "param"})
+}
+
+func marshal(c *gc.C, doc interface{}) []byte {
+	data, err := bson.Marshal(doc)
+	c.Assert(err, jc.ErrorIsNil)
+	return data
+}
+
+type testConn struct {
+	readMsgs  [][]byte
+	err       error
+	writeMsgs [][]byte
+	closed    bool
+}
+
+func (c *testConn) Receive() ([]byte, error) {
+	if len(c.readMsgs) > 0 {
+		data := c.readMsgs[0]
+		c.readMsgs = c.readMsgs[1:]
+		return data, nil
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	return nil, io.EOF
+}
+
+func (c *testConn) Send(data []byte) error {
+	c.writeMsgs = append(c.writeMsgs, data)
+	return nil
+}
+
+func (c *testConn) Close() error {
+	c.closed = true
+	return nil
+}
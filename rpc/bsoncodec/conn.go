@@ -0,0 +1,42 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package bsoncodec
+
+import (
+	"golang.org/x/net/websocket"
+)
+
+// NewWebsocket returns an rpc codec that uses the given websocket
+// connection to send and receive messages. Each message is sent
+// as a single binary frame.
+func NewWebsocket(conn *websocket.Conn) *Codec {
+	return New(wsBSONConn{conn})
+}
+
+type wsBSONConn struct {
+	conn *websocket.Conn
+}
+
+func (conn wsBSONConn) Send(data []byte) error {
+	return websocket.Message.Send(conn.conn, data)
+}
+
+func (conn wsBSONConn) Receive() ([]byte, error) {
+	var data []byte
+	if err := websocket.Message.Receive(conn.conn, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (conn wsBSONConn) Close() error {
+	return conn.conn.Close()
+}
+
+// Negotiated reports whether this codec was agreed upon
+// during the handshake of the given websocket connection.
+func Negotiated(conn *websocket.Conn) bool {
+	protocol := conn.Config().Protocol
+	return len(protocol) == 1 && protocol[0] == Protocol
+}
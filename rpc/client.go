@@ -5,7 +5,10 @@ package rpc
 
 import (
 	"errors"
+	"fmt"
 	"strings"
+
+	"github.com/juju/juju/trace"
 )
 
 var ErrShutdown = errors.New("connection is shut down")
@@ -55,12 +58,14 @@ func (conn *Conn) send(call *Call) {
 	conn.reqId++
 	reqId := conn.reqId
 	conn.clientPending[reqId] = call
+	traceId := conn.traceId
 	conn.mutex.Unlock()
 
 	// Encode and send the request.
 	hdr := &Header{
 		RequestId: reqId,
 		Request:   call.Request,
+		TraceId:   traceId,
 	}
 	params := call.Params
 	if params == nil {
@@ -149,6 +154,26 @@ func (conn *Conn) Call(req Request, params, response interface{}) error {
 	return call.Error
 }
 
+// SetTraceId sets the trace id that is sent with all subsequent
+// client requests made on the connection. An empty id means
+// requests are not traced.
+func (conn *Conn) SetTraceId(id string) error {
+	if id != "" && !trace.IsValidId(id) {
+		return fmt.Errorf("invalid trace id %q", id)
+	}
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+	conn.traceId = id
+	return nil
+}
+
+// TraceId returns the trace id sent with client requests.
+func (conn *Conn) TraceId() string {
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+	return conn.traceId
+}
+
 // Go invokes the request asynchronously.  It returns the Call structure representing
 // the invocation.  The done channel will signal when the call is complete by returning
 // the same Call object.  If done is nil, Go will allocate a new channel.
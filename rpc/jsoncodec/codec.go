@@ -15,6 +15,11 @@ import (
 
 var logger = loggo.GetLogger("juju.rpc.jsoncodec")
 
+// Protocol holds the websocket sub-protocol name used to
+// negotiate the use of this codec. Clients that offer no
+// sub-protocol also get this codec.
+const Protocol = "juju-rpc-json"
+
 // JSONConn sends and receives messages to an underlying connection
 // in JSON format.
 type JSONConn interface {
@@ -71,6 +76,7 @@ type inMsg struct {
 	Error     string
 	ErrorCode string
 	Response  json.RawMessage
+	TraceId   string
 }
 
 // outMsg holds an outgoing message.
@@ -84,6 +90,7 @@ type outMsg struct {
 	Error     string      `json:",omitempty"`
 	ErrorCode string      `json:",omitempty"`
 	Response  interface{} `json:",omitempty"`
+	TraceId   string      `json:",omitempty"`
 }
 
 func (c *Codec) Close() error {
@@ -131,6 +138,7 @@ func (c *Codec) ReadHeader(hdr *rpc.Header) error {
 	}
 	hdr.Error = c.msg.Error
 	hdr.ErrorCode = c.msg.ErrorCode
+	hdr.TraceId = c.msg.TraceId
 	return nil
 }
 
@@ -191,6 +199,7 @@ func (m *outMsg) init(hdr *rpc.Header, body interface{}) {
 	m.Request = hdr.Request.Action
 	m.Error = hdr.Error
 	m.ErrorCode = hdr.ErrorCode
+	m.TraceId = hdr.TraceId
 	if hdr.IsRequest() {
 		m.Params = body
 	} else {
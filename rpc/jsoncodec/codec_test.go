@@ -72,6 +72,17 @@ var readTests = []struct {
 		},
 	},
 	expectBody: &value{X: "param"},
+}, {
+	msg: `{"RequestId": 5, "Type": "foo", "Request": "frob", "Params": {"X": "param"}, "TraceId": "deploy-1"}`,
+	expectHdr: rpc.Header{
+		RequestId: 5,
+		Request: rpc.Request{
+			Type:   "foo",
+			Action: "frob",
+		},
+		TraceId: "deploy-1",
+	},
+	expectBody: &value{X: "param"},
 }}
 
 func (*suite) TestRead(c *gc.C) {
@@ -255,6 +266,13 @@ var writeTests = []struct {
 	},
 	body:   &value{X: "param"},
 	expect: `{"RequestId": 4, "Type": "foo", "Version": 2, "Request": "frob", "Params": {"X": "param"}}`,
+}, {
+	hdr: &rpc.Header{
+		RequestId: 5,
+		TraceId:   "deploy-1",
+	},
+	body:   &value{X: "result"},
+	expect: `{"RequestId": 5, "Response": {"X": "result"}, "TraceId": "deploy-1"}`,
 }}
 
 func (*suite) TestWrite(c *gc.C) {
@@ -0,0 +1,235 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The jsonschema package generates JSON Schema descriptions of the
+// values sent over the rpc package, so that clients not written in
+// Go can find out the shape of each method's parameters and results
+// without reading the Go source.
+//
+// The schemas follow the rules of encoding/json: exported struct
+// fields are described under their Go names (or the name given in a
+// json tag), pointers are described by the types they point to, and
+// named struct types are described once under "definitions" and
+// referred to with "$ref".
+package jsonschema
+
+import (
+	"encoding"
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/juju/juju/rpc/rpcreflect"
+)
+
+// Schema holds a JSON Schema. Only the parts of the specification
+// needed to describe Go types are supported.
+//
+// The bson tags ensure that a Schema has the same shape whichever
+// codec it is sent over.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty" bson:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty" bson:"type,omitempty"`
+	Format               string             `json:"format,omitempty" bson:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty" bson:"properties,omitempty"`
+	Items                *Schema            `json:"items,omitempty" bson:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty" bson:"additionalProperties,omitempty"`
+	Definitions          map[string]*Schema `json:"definitions,omitempty" bson:"definitions,omitempty"`
+}
+
+// definitionsPrefix is the prefix of all references to schemas
+// held in the definitions of the top level schema.
+const definitionsPrefix = "#/definitions/"
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Generator generates schemas for Go types. The named struct types
+// it encounters are collected so that they can be returned as the
+// definitions of a top level schema.
+type Generator struct {
+	overrides   map[reflect.Type]*Schema
+	definitions map[string]*Schema
+	names       map[reflect.Type]string
+}
+
+// NewGenerator returns a new Generator. The given overrides hold the
+// schemas of types whose JSON encoding cannot be derived from their
+// Go definition, such as those with a custom MarshalJSON method.
+// Types with a custom encoding and no override are described by the
+// empty schema, which allows any value.
+func NewGenerator(overrides map[reflect.Type]*Schema) *Generator {
+	return &Generator{
+		overrides:   overrides,
+		definitions: make(map[string]*Schema),
+		names:       make(map[reflect.Type]string),
+	}
+}
+
+// Definitions returns the schemas of all the named struct types
+// encountered so far, keyed by the names used to refer to them.
+func (g *Generator) Definitions() map[string]*Schema {
+	return g.definitions
+}
+
+// Reflect returns the schema describing the JSON encoding
+// of values of the given type.
+func (g *Generator) Reflect(t reflect.Type) *Schema {
+	if s, ok := g.overrides[t]; ok {
+		return s
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) {
+		return &Schema{}
+	}
+	if t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return &Schema{Type: "string"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Ptr:
+		return g.Reflect(t.Elem())
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// Byte slices are encoded as base64 strings.
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.Reflect(t.Elem())}
+	case reflect.Array:
+		return &Schema{Type: "array", Items: g.Reflect(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.Reflect(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.reflectStruct(t)
+		}
+		return g.reflectNamedStruct(t)
+	}
+	// Interfaces may hold any value.
+	return &Schema{}
+}
+
+// reflectNamedStruct returns a reference to the definition of the
+// given struct type, adding the definition if necessary.
+func (g *Generator) reflectNamedStruct(t reflect.Type) *Schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = g.definitionName(t)
+		g.names[t] = name
+		// Add a placeholder first so that recursive
+		// types refer back to this definition.
+		s := &Schema{}
+		g.definitions[name] = s
+		*s = *g.reflectStruct(t)
+	}
+	return &Schema{Ref: definitionsPrefix + name}
+}
+
+// definitionName returns the name under which the definition of the
+// given type is held. Types are known by their unqualified names
+// unless that would clash with a type from another package.
+func (g *Generator) definitionName(t reflect.Type) string {
+	if _, ok := g.definitions[t.Name()]; !ok {
+		return t.Name()
+	}
+	return path.Base(t.PkgPath()) + "." + t.Name()
+}
+
+func (g *Generator) reflectStruct(t reflect.Type) *Schema {
+	s := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+	g.addFields(s, t)
+	return s
+}
+
+// addFields adds the encoded fields of the given struct type
+// to the properties of s, including those of embedded structs.
+func (g *Generator) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := field.Name
+		if tagName := strings.Split(tag, ",")[0]; tagName != "" {
+			name = tagName
+		}
+		fieldType := field.Type
+		if field.Anonymous && name == field.Name {
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				g.addFields(s, fieldType)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			// Unexported fields are not encoded.
+			continue
+		}
+		switch fieldType.Kind() {
+		case reflect.Chan, reflect.Func, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+			// These cannot be encoded as JSON.
+			continue
+		}
+		if _, ok := s.Properties[name]; ok {
+			// Fields of the outer struct take precedence
+			// over those of embedded structs.
+			continue
+		}
+		s.Properties[name] = g.Reflect(fieldType)
+	}
+}
+
+// ForObjType returns the schema describing the RPC methods of the
+// given object type, as found with rpcreflect.ObjTypeOf. Each method
+// is described as a property whose own "Params" and "Result"
+// properties describe the method's parameters and results. Methods
+// without parameters or results omit the respective property.
+func ForObjType(objType *rpcreflect.ObjType, overrides map[reflect.Type]*Schema) *Schema {
+	g := NewGenerator(overrides)
+	methods := make(map[string]*Schema)
+	for _, name := range objType.MethodNames() {
+		m, err := objType.Method(name)
+		if err != nil {
+			// Cannot happen as the name was
+			// returned by MethodNames.
+			panic(err)
+		}
+		method := &Schema{
+			Type:       "object",
+			Properties: make(map[string]*Schema),
+		}
+		if m.Params != nil {
+			method.Properties["Params"] = g.Reflect(m.Params)
+		}
+		if m.Result != nil {
+			method.Properties["Result"] = g.Reflect(m.Result)
+		}
+		methods[name] = method
+	}
+	return &Schema{
+		Type:        "object",
+		Properties:  methods,
+		Definitions: g.Definitions(),
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"reflect"
+	stdtesting "testing"
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/rpc/jsonschema"
+	"github.com/juju/juju/rpc/rpcreflect"
+	"github.com/juju/juju/testing"
+)
+
+type suite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&suite{})
+
+func TestPackage(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type Inner struct {
+	Name string
+}
+
+type Embedded struct {
+	Promoted int
+}
+
+type Node struct {
+	Children []*Node
+}
+
+type Custom struct {
+	value string
+}
+
+func (c Custom) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.value)
+}
+
+type Outer struct {
+	Embedded
+	Bool      bool
+	Int       int64
+	Float     float64
+	String    string
+	Bytes     []byte
+	Strings   []string
+	Map       map[string]Inner
+	Ptr       *Inner
+	Any       interface{}
+	Time      time.Time
+	Custom    Custom
+	Anonymous struct{ X int }
+	Renamed   string `json:"renamed,omitempty"`
+	Ignored   string `json:"-"`
+	Chan      chan int
+	private   int
+}
+
+func (*suite) TestReflect(c *gc.C) {
+	g := jsonschema.NewGenerator(nil)
+	s := g.Reflect(reflect.TypeOf(Outer{}))
+	c.Assert(s, jc.DeepEquals, &jsonschema.Schema{Ref: "#/definitions/Outer"})
+	c.Assert(g.Definitions(), jc.DeepEquals, map[string]*jsonschema.Schema{
+		"Outer": {
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"Promoted": {Type: "integer"},
+				"Bool":     {Type: "boolean"},
+				"Int":      {Type: "integer"},
+				"Float":    {Type: "number"},
+				"String":   {Type: "string"},
+				"Bytes":    {Type: "string", Format: "byte"},
+				"Strings":  {Type: "array", Items: &jsonschema.Schema{Type: "string"}},
+				"Map": {
+					Type:                 "object",
+					AdditionalProperties: &jsonschema.Schema{Ref: "#/definitions/Inner"},
+				},
+				"Ptr":    {Ref: "#/definitions/Inner"},
+				"Any":    {},
+				"Time":   {Type: "string", Format: "date-time"},
+				"Custom": {},
+				"Anonymous": {
+					Type: "object",
+					Properties: map[string]*jsonschema.Schema{
+						"X": {Type: "integer"},
+					},
+				},
+				"renamed": {Type: "string"},
+			},
+		},
+		"Inner": {
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"Name": {Type: "string"},
+			},
+		},
+	})
+}
+
+func (*suite) TestReflectRecursiveType(c *gc.C) {
+	g := jsonschema.NewGenerator(nil)
+	s := g.Reflect(reflect.TypeOf(Node{}))
+	c.Assert(s, jc.DeepEquals, &jsonschema.Schema{Ref: "#/definitions/Node"})
+	c.Assert(g.Definitions(), jc.DeepEquals, map[string]*jsonschema.Schema{
+		"Node": {
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"Children": {
+					Type:  "array",
+					Items: &jsonschema.Schema{Ref: "#/definitions/Node"},
+				},
+			},
+		},
+	})
+}
+
+func (*suite) TestReflectOverrides(c *gc.C) {
+	g := jsonschema.NewGenerator(map[reflect.Type]*jsonschema.Schema{
+		reflect.TypeOf(Custom{}): {Type: "string"},
+	})
+	s := g.Reflect(reflect.TypeOf([]Custom{}))
+	c.Assert(s, jc.DeepEquals, &jsonschema.Schema{
+		Type:  "array",
+		Items: &jsonschema.Schema{Type: "string"},
+	})
+}
+
+func (*suite) TestReflectNameClash(c *gc.C) {
+	g := jsonschema.NewGenerator(nil)
+	s := g.Reflect(reflect.TypeOf(Inner{}))
+	c.Assert(s, jc.DeepEquals, &jsonschema.Schema{Ref: "#/definitions/Inner"})
+
+	// This Inner has the same name as the package level type.
+	type Inner struct {
+		Other bool
+	}
+	s = g.Reflect(reflect.TypeOf(Inner{}))
+	c.Assert(s, jc.DeepEquals, &jsonschema.Schema{Ref: "#/definitions/jsonschema_test.Inner"})
+	c.Assert(g.Definitions(), gc.HasLen, 2)
+	c.Assert(g.Definitions()["jsonschema_test.Inner"].Properties["Other"], gc.NotNil)
+}
+
+type Facade struct{}
+
+func (Facade) Frob(args Inner) (Node, error) {
+	return Node{}, nil
+}
+
+func (Facade) Ping() {}
+
+func (Facade) Status() (Inner, error) {
+	return Inner{}, nil
+}
+
+func (*suite) TestForObjType(c *gc.C) {
+	s := jsonschema.ForObjType(rpcreflect.ObjTypeOf(reflect.TypeOf(Facade{})), nil)
+	data, err := json.Marshal(s)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), jc.JSONEquals, map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"Frob": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"Params": map[string]interface{}{"$ref": "#/definitions/Inner"},
+					"Result": map[string]interface{}{"$ref": "#/definitions/Node"},
+				},
+			},
+			"Ping": map[string]interface{}{
+				"type": "object",
+			},
+			"Status": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"Result": map[string]interface{}{"$ref": "#/definitions/Inner"},
+				},
+			},
+		},
+		"definitions": map[string]interface{}{
+			"Inner": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"Name": map[string]interface{}{"type": "string"},
+				},
+			},
+			"Node": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"Children": map[string]interface{}{
+						"type":  "array",
+						"items": map[string]interface{}{"$ref": "#/definitions/Node"},
+					},
+				},
+			},
+		},
+	})
+}
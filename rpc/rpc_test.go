@@ -616,6 +616,78 @@ func (*rpcSuite) TestInterfaceMethods(c *gc.C) {
 	})
 }
 
+func (*rpcSuite) TestTraceId(c *gc.C) {
+	root := SimpleRoot()
+	client, srvDone, clientNotifier, serverNotifier := newRPCClientServer(c, root, nil, false)
+	defer closeClient(c, client, srvDone)
+	c.Assert(client.TraceId(), gc.Equals, "")
+	err := client.SetTraceId("deploy-1")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(client.TraceId(), gc.Equals, "deploy-1")
+
+	var r stringVal
+	err = client.Call(rpc.Request{"SimpleMethods", 0, "a99", "Call1r1"}, stringVal{"arg"}, &r)
+	c.Assert(err, jc.ErrorIsNil)
+
+	c.Assert(clientNotifier.clientRequests, gc.HasLen, 1)
+	c.Assert(clientNotifier.clientRequests[0].hdr.TraceId, gc.Equals, "deploy-1")
+	c.Assert(serverNotifier.serverRequests, gc.HasLen, 1)
+	c.Assert(serverNotifier.serverRequests[0].hdr.TraceId, gc.Equals, "deploy-1")
+	c.Assert(serverNotifier.serverReplies, gc.HasLen, 1)
+	c.Assert(serverNotifier.serverReplies[0].hdr.TraceId, gc.Equals, "deploy-1")
+	c.Assert(clientNotifier.clientReplies, gc.HasLen, 1)
+	c.Assert(clientNotifier.clientReplies[0].hdr.TraceId, gc.Equals, "deploy-1")
+}
+
+func (*rpcSuite) TestSetInvalidTraceId(c *gc.C) {
+	root := SimpleRoot()
+	client, srvDone, _, _ := newRPCClientServer(c, root, nil, false)
+	defer closeClient(c, client, srvDone)
+	err := client.SetTraceId("deploy 1")
+	c.Assert(err, gc.ErrorMatches, `invalid trace id "deploy 1"`)
+	c.Assert(client.TraceId(), gc.Equals, "")
+}
+
+// tracingMethodFinder is a CustomMethodFinder that records the trace
+// ids of the methods it finds.
+type tracingMethodFinder struct {
+	CustomMethodFinder
+	mu       sync.Mutex
+	traceIds []string
+}
+
+func (f *tracingMethodFinder) FindTracedMethod(
+	rootMethodName string, version int, objMethodName, traceId string,
+) (
+	rpcreflect.MethodCaller, error,
+) {
+	f.mu.Lock()
+	f.traceIds = append(f.traceIds, traceId)
+	f.mu.Unlock()
+	return f.FindMethod(rootMethodName, version, objMethodName)
+}
+
+func (*rpcSuite) TestTracedMethodFinder(c *gc.C) {
+	root := &tracingMethodFinder{CustomMethodFinder: CustomMethodFinder{SimpleRoot()}}
+	client, srvDone, _, _ := newRPCClientServer(c, root, nil, false)
+	defer closeClient(c, client, srvDone)
+
+	var r stringVal
+	err := client.Call(rpc.Request{"MultiVersion", 0, "a99", "Call0r1"}, nil, &r)
+	c.Assert(err, jc.ErrorIsNil)
+	root.mu.Lock()
+	c.Assert(root.traceIds, gc.HasLen, 0)
+	root.mu.Unlock()
+
+	err = client.SetTraceId("deploy-1")
+	c.Assert(err, jc.ErrorIsNil)
+	err = client.Call(rpc.Request{"MultiVersion", 0, "a99", "Call0r1"}, nil, &r)
+	c.Assert(err, jc.ErrorIsNil)
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	c.Assert(root.traceIds, jc.DeepEquals, []string{"deploy-1"})
+}
+
 func (*rpcSuite) TestCustomMethodFinderV0(c *gc.C) {
 	root := &CustomMethodFinder{SimpleRoot()}
 	client, srvDone, clientNotifier, serverNotifier := newRPCClientServer(c, root, nil, false)
@@ -1203,6 +1275,9 @@ func newRPCClientServer(c *gc.C, root interface{}, tfErr func(error) error, bidi
 		if custroot, ok := root.(*CustomMethodFinder); ok {
 			rpcConn.ServeFinder(custroot, tfErr)
 			custroot.root.conn = rpcConn
+		} else if traceroot, ok := root.(*tracingMethodFinder); ok {
+			rpcConn.ServeFinder(traceroot, tfErr)
+			traceroot.root.conn = rpcConn
 		} else {
 			rpcConn.Serve(root, tfErr)
 		}
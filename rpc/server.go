@@ -13,6 +13,7 @@ import (
 	"github.com/juju/loggo"
 
 	"github.com/juju/juju/rpc/rpcreflect"
+	"github.com/juju/juju/trace"
 )
 
 const CodeNotImplemented = "not implemented"
@@ -62,6 +63,11 @@ type Header struct {
 
 	// ErrorCode holds the code of the error, if any.
 	ErrorCode string
+
+	// TraceId holds an optional identifier used to correlate
+	// related requests across agents and log files. Replies
+	// carry the trace id of the request they reply to.
+	TraceId string
 }
 
 // Request represents an RPC to be performed, absent its parameters.
@@ -127,6 +133,9 @@ type Conn struct {
 	// reqId holds the latest client request id.
 	reqId uint64
 
+	// traceId holds the trace id sent with client requests.
+	traceId string
+
 	// clientPending holds all pending client requests.
 	clientPending map[uint64]*Call
 
@@ -363,6 +372,16 @@ type MethodFinder interface {
 	FindMethod(rootName string, version int, methodName string) (rpcreflect.MethodCaller, error)
 }
 
+// TracedMethodFinder represents a MethodFinder that can also look up
+// methods that act on behalf of requests with a given trace id, so
+// that the effects of those requests can be correlated with them.
+// If the root given to Serve or ServeFinder implements
+// TracedMethodFinder, it is used for requests with a trace id.
+type TracedMethodFinder interface {
+	MethodFinder
+	FindTracedMethod(rootName string, version int, methodName, traceId string) (rpcreflect.MethodCaller, error)
+}
+
 // Killer represents a type that can be asked to abort any outstanding
 // requests.  The Kill method should return immediately.
 type Killer interface {
@@ -429,6 +448,19 @@ func (conn *Conn) readBody(resp interface{}, isRequest bool) error {
 
 func (conn *Conn) handleRequest(hdr *Header) error {
 	startTime := time.Now()
+	if hdr.TraceId != "" && !trace.IsValidId(hdr.TraceId) {
+		// An invalid trace id is neither logged nor echoed,
+		// as it could corrupt the log lines that carry it.
+		invalidId := hdr.TraceId
+		hdr.TraceId = ""
+		if conn.notifier != nil {
+			conn.notifier.ServerRequest(hdr, nil)
+		}
+		if err := conn.readBody(nil, true); err != nil {
+			return err
+		}
+		return conn.writeErrorResponse(hdr, fmt.Errorf("invalid trace id %q", invalidId), startTime)
+	}
 	req, err := conn.bindRequest(hdr)
 	if err != nil {
 		if conn.notifier != nil {
@@ -493,6 +525,7 @@ func (conn *Conn) writeErrorResponse(reqHdr *Header, err error, startTime time.T
 	defer conn.sending.Unlock()
 	hdr := &Header{
 		RequestId: reqHdr.RequestId,
+		TraceId:   reqHdr.TraceId,
 	}
 	if err, ok := err.(ErrorCoder); ok {
 		hdr.ErrorCode = err.ErrorCode()
@@ -526,8 +559,15 @@ func (conn *Conn) bindRequest(hdr *Header) (boundRequest, error) {
 	if methodFinder == nil {
 		return boundRequest{}, fmt.Errorf("no service")
 	}
-	caller, err := methodFinder.FindMethod(
-		hdr.Request.Type, hdr.Request.Version, hdr.Request.Action)
+	var caller rpcreflect.MethodCaller
+	var err error
+	if tracedFinder, ok := methodFinder.(TracedMethodFinder); ok && hdr.TraceId != "" {
+		caller, err = tracedFinder.FindTracedMethod(
+			hdr.Request.Type, hdr.Request.Version, hdr.Request.Action, hdr.TraceId)
+	} else {
+		caller, err = methodFinder.FindMethod(
+			hdr.Request.Type, hdr.Request.Version, hdr.Request.Action)
+	}
 	if err != nil {
 		if _, ok := err.(*rpcreflect.CallNotImplementedError); ok {
 			err = &serverError{
@@ -555,6 +595,7 @@ func (conn *Conn) runRequest(req boundRequest, arg reflect.Value, startTime time
 	} else {
 		hdr := &Header{
 			RequestId: req.hdr.RequestId,
+			TraceId:   req.hdr.TraceId,
 		}
 		var rvi interface{}
 		if rv.IsValid() {
@@ -160,6 +160,8 @@ func (st *State) AddMachines(templates ...MachineTemplate) (_ []*Machine, err er
 		return nil, errors.Trace(err)
 	} else if env.Life() != Alive {
 		return nil, errors.New("environment is no longer alive")
+	} else if env.MigrationMode() != MigrationModeNone {
+		return nil, errors.New("environment is being migrated")
 	}
 	var ops []txn.Op
 	var mdocs []*machineDoc
@@ -6,12 +6,14 @@ package state
 import (
 	"fmt"
 	"reflect"
+	"sync"
 
 	"github.com/juju/errors"
 	"gopkg.in/mgo.v2/bson"
 	"gopkg.in/mgo.v2/txn"
 
 	"github.com/juju/juju/network"
+	"github.com/juju/juju/state/watcher"
 )
 
 // stateServerAddresses returns the list of internal addresses of the state
@@ -47,10 +49,11 @@ func (st *State) stateServerAddresses() ([]string, error) {
 	if len(allAddresses) == 0 {
 		return nil, errors.New("no state server machines found")
 	}
+	policy := ssState.addressPolicy()
 	apiAddrs := make([]string, 0, len(allAddresses))
 	for _, addrs := range allAddresses {
 		naddrs := networkAddresses(addrs.Addresses)
-		addr := network.SelectInternalAddress(naddrs, false)
+		addr := policy.SelectInternalAddress(naddrs, false)
 		if addr != "" {
 			apiAddrs = append(apiAddrs, addr)
 		}
@@ -301,3 +304,106 @@ func addressesEqual(a, b []network.Address) bool {
 func hostsPortsEqual(a, b [][]network.HostPort) bool {
 	return reflect.DeepEqual(a, b)
 }
+
+// AddressPolicy returns the policy determining how addresses of
+// machines are chosen, as configured by the environment's
+// address-policy and preferred-spaces settings. Preferred spaces
+// which do not exist are ignored. The policy is cached until the
+// environment's config, spaces or subnets change.
+func (st *State) AddressPolicy() (network.AddressPolicy, error) {
+	cache := st.addressPolicies
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.policy != nil {
+		return *cache.policy, nil
+	}
+	if !cache.watching {
+		// Start watching before reading the policy, so
+		// that no change made meanwhile is missed.
+		st.watchAddressPolicy()
+		cache.watching = true
+	}
+	policy, err := st.readAddressPolicy()
+	if err != nil {
+		return network.AddressPolicy{}, errors.Trace(err)
+	}
+	cache.policy = &policy
+	return policy, nil
+}
+
+// readAddressPolicy builds the environment's address policy from its
+// config and spaces.
+func (st *State) readAddressPolicy() (network.AddressPolicy, error) {
+	cfg, err := st.EnvironConfig()
+	if err != nil {
+		return network.AddressPolicy{}, errors.Trace(err)
+	}
+	policy := network.AddressPolicy{Family: cfg.AddressFamilyPolicy()}
+	for _, name := range cfg.PreferredSpaces() {
+		space, err := st.Space(name)
+		if errors.IsNotFound(err) {
+			logger.Warningf("ignoring unknown preferred space %q", name)
+			continue
+		} else if err != nil {
+			return network.AddressPolicy{}, errors.Trace(err)
+		}
+		cidrs, err := space.SubnetCIDRs()
+		if err != nil {
+			return network.AddressPolicy{}, errors.Trace(err)
+		}
+		policy.PreferredSubnets = append(policy.PreferredSubnets, cidrs...)
+	}
+	return policy, nil
+}
+
+// addressPolicyCache holds an environment's address policy, so that
+// it need not be rebuilt for every address lookup.
+type addressPolicyCache struct {
+	mu       sync.Mutex
+	policy   *network.AddressPolicy
+	watching bool
+}
+
+// invalidate discards the cached policy, if any.
+func (c *addressPolicyCache) invalidate() {
+	c.mu.Lock()
+	c.policy = nil
+	c.mu.Unlock()
+}
+
+// addressPolicy returns the environment's address policy, falling
+// back to the global one if it cannot be determined.
+func (st *State) addressPolicy() network.AddressPolicy {
+	policy, err := st.AddressPolicy()
+	if err != nil {
+		logger.Errorf("cannot get address policy: %v", err)
+		return network.CurrentAddressPolicy()
+	}
+	return policy
+}
+
+// watchAddressPolicy invalidates the cached address policy whenever
+// the environment's config, spaces or subnets change, until st's
+// watcher is stopped. Changes made through st itself invalidate the
+// cache directly, so they are seen without waiting for the watcher.
+func (st *State) watchAddressPolicy() {
+	in := make(chan watcher.Change)
+	settingsId := st.docID(environGlobalKey)
+	st.watcher.WatchCollectionWithFilter(settingsC, in, func(id interface{}) bool {
+		return id == settingsId
+	})
+	st.watcher.WatchCollectionWithFilter(spacesC, in, st.isForStateEnv)
+	st.watcher.WatchCollectionWithFilter(subnetsC, in, st.isForStateEnv)
+	cache := st.addressPolicies
+	dead := st.watcher.Dead()
+	go func() {
+		for {
+			select {
+			case <-dead:
+				return
+			case <-in:
+				cache.invalidate()
+			}
+		}
+	}()
+}
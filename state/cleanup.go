@@ -24,6 +24,7 @@ const (
 	cleanupServicesForDyingEnvironment cleanupKind = "services"
 	cleanupForceDestroyedMachine       cleanupKind = "machine"
 	cleanupAttachmentsForDyingStorage  cleanupKind = "storageAttachments"
+	cleanupOffersForRemovedService     cleanupKind = "serviceOffers"
 )
 
 // cleanupDoc represents a potentially large set of documents that should be
@@ -88,6 +89,8 @@ func (st *State) Cleanup() error {
 			err = st.cleanupForceDestroyedMachine(doc.Prefix)
 		case cleanupAttachmentsForDyingStorage:
 			err = st.cleanupAttachmentsForDyingStorage(doc.Prefix)
+		case cleanupOffersForRemovedService:
+			err = st.cleanupOffersForRemovedService(doc.Prefix)
 		default:
 			err = fmt.Errorf("unknown cleanup kind %q", doc.Kind)
 		}
@@ -110,6 +113,21 @@ func (st *State) Cleanup() error {
 	return nil
 }
 
+// cleanupOffersForRemovedService removes the offers of a service
+// which has been removed.
+func (st *State) cleanupOffersForRemovedService(serviceName string) error {
+	offers, err := serviceOffers(st, serviceName)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, offer := range offers {
+		if err := offer.Remove(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
 func (st *State) cleanupRelationSettings(prefix string) error {
 	// Documents marked for cleanup are not otherwise referenced in the
 	// system, and will not be under watch, and are therefore safe to
@@ -222,7 +240,7 @@ func (st *State) cleanupRemovedUnit(unitId string) error {
 			return err
 		}
 	}
-	return nil
+	return removeUnitHistory(st, unitId)
 }
 
 // cleanupForceDestroyedMachine systematically destroys and removes all entities
@@ -82,16 +82,20 @@ var multiEnvCollections = set.NewStrings(
 	rebootC,
 	relationScopesC,
 	relationsC,
+	remoteServicesC,
 	requestedNetworksC,
 	sequenceC,
+	serviceOffersC,
 	servicesC,
 	settingsC,
 	settingsrefsC,
+	spacesC,
 	statusesC,
 	storageAttachmentsC,
 	storageConstraintsC,
 	storageInstancesC,
 	subnetsC,
+	unitHistoryC,
 	unitsC,
 	volumesC,
 	volumeAttachmentsC,
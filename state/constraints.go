@@ -27,6 +27,7 @@ type constraintsDoc struct {
 	Container    *instance.ContainerType
 	Tags         *[]string `bson:",omitempty"`
 	Networks     *[]string `bson:",omitempty"`
+	Spaces       *[]string `bson:",omitempty"`
 }
 
 func (doc constraintsDoc) value() constraints.Value {
@@ -40,6 +41,7 @@ func (doc constraintsDoc) value() constraints.Value {
 		Container:    doc.Container,
 		Tags:         doc.Tags,
 		Networks:     doc.Networks,
+		Spaces:       doc.Spaces,
 	}
 }
 
@@ -55,6 +57,7 @@ func newConstraintsDoc(st *State, cons constraints.Value) constraintsDoc {
 		Container:    cons.Container,
 		Tags:         cons.Tags,
 		Networks:     cons.Networks,
+		Spaces:       cons.Spaces,
 	}
 }
 
@@ -0,0 +1,283 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"github.com/juju/errors"
+	jujutxn "github.com/juju/txn"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/instance"
+)
+
+// A container is migrated to another host machine in three steps.
+// StartMigration records the target host on the container's machine;
+// the container is then stopped and its root filesystem staged on the
+// target host, outside of state. CompleteMigration creates a new
+// container machine on the target host, moves the container's units
+// and opened ports to it, and marks the original machine dead, so
+// that its host's provisioner removes it. The new machine records
+// the original container's instance id, from which the target host's
+// provisioner creates its container. AbortMigration abandons a
+// migration that has been started but not completed.
+//
+// The container cannot keep its machine in the move: a container's
+// machine id is derived from its host's ("0/lxc/3" lives on machine
+// 0), and the id is also its agent's tag, the key of its host's
+// containers record and the name under which its host's provisioner
+// tracks it. Its addresses belong to the old host's network and are
+// replaced by those the target host's provisioner assigns.
+
+// StartMigration marks the container as being migrated to the host
+// machine with the given id. Only provisioned lxc containers without
+// containers, storage or state server jobs of their own can be
+// migrated.
+func (m *Machine) StartMigration(hostId string) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot migrate machine %s to machine %s", m, hostId)
+	if err := m.canMigrate(); err != nil {
+		return errors.Trace(err)
+	}
+	parentId, _ := m.ParentId()
+	if hostId == parentId {
+		return errors.Errorf("container is already on machine %s", hostId)
+	}
+	host, err := m.st.Machine(hostId)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if host.Life() != Alive {
+		return errors.Errorf("machine %s is not alive", hostId)
+	}
+	if !host.supportsContainerType(instance.LXC) {
+		return errors.Errorf("machine %s cannot host %s containers", hostId, instance.LXC)
+	}
+	ops := []txn.Op{{
+		C:  machinesC,
+		Id: m.doc.DocID,
+		Assert: append(bson.D{
+			{"migrationhost", bson.D{{"$exists", false}}},
+		}, isAliveDoc...),
+		Update: bson.D{{"$set", bson.D{{"migrationhost", hostId}}}},
+	}, {
+		C:      machinesC,
+		Id:     host.doc.DocID,
+		Assert: isAliveDoc,
+	}}
+	if err := m.st.runTransaction(ops); err == txn.ErrAborted {
+		return errors.New("machine or host changed, or migration already started")
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	m.doc.MigrationHost = hostId
+	return nil
+}
+
+// canMigrate returns an error if the machine cannot be migrated to
+// another host.
+func (m *Machine) canMigrate() error {
+	if m.ContainerType() != instance.LXC {
+		return errors.NotSupportedf("migrating machines other than %s containers", instance.LXC)
+	}
+	if m.Life() != Alive {
+		return errors.New("machine is not alive")
+	}
+	if host, ok := m.MigrationHost(); ok {
+		return errors.Errorf("machine is already being migrated to machine %s", host)
+	}
+	if hasJob(m.doc.Jobs, JobManageEnviron) {
+		return errors.New("machine is required by the environment")
+	}
+	if _, err := m.InstanceId(); err != nil {
+		return errors.Trace(err)
+	}
+	containers, err := m.Containers()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(containers) > 0 {
+		return errors.NotSupportedf("migrating machines hosting containers")
+	}
+	volumes, err := m.st.MachineVolumeAttachments(m.MachineTag())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	filesystems, err := m.st.MachineFilesystemAttachments(m.MachineTag())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(volumes) > 0 || len(filesystems) > 0 {
+		return errors.NotSupportedf("migrating machines with storage")
+	}
+	return nil
+}
+
+// MigrationHost returns the id of the machine the container is being
+// migrated to, and whether it is being migrated.
+func (m *Machine) MigrationHost() (string, bool) {
+	return m.doc.MigrationHost, m.doc.MigrationHost != ""
+}
+
+// MigratedFrom returns the instance id of the container the machine
+// was created from by a container migration, and whether it was.
+func (m *Machine) MigratedFrom() (instance.Id, bool) {
+	return m.doc.MigratedFrom, m.doc.MigratedFrom != ""
+}
+
+// AbortMigration abandons the migration of the container. It fails
+// if no migration has been started, or if it has been completed.
+func (m *Machine) AbortMigration() (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot abort migration of machine %s", m)
+	ops := []txn.Op{{
+		C:      machinesC,
+		Id:     m.doc.DocID,
+		Assert: bson.D{{"migrationhost", bson.D{{"$exists", true}}}},
+		Update: bson.D{{"$unset", bson.D{{"migrationhost", nil}}}},
+	}}
+	if err := m.st.runTransaction(ops); err == txn.ErrAborted {
+		if err := m.Refresh(); err != nil {
+			return errors.Trace(err)
+		}
+		if m.doc.Life == Dead {
+			return errors.New("machine is dead")
+		}
+		return errors.New("migration not started")
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	m.doc.MigrationHost = ""
+	return nil
+}
+
+// CompleteMigration completes the migration of the container to the
+// host recorded by StartMigration, and returns the machine that the
+// container becomes on that host. The container must have been
+// stopped before it is called; its units and opened ports are moved
+// to the new machine, and the container's machine is marked dead. A
+// new machine is needed, rather than the container's machine being
+// moved in place, because its id names its original host.
+func (m *Machine) CompleteMigration() (_ *Machine, err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot complete migration of machine %s", m)
+	var mdoc *machineDoc
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			if err := m.Refresh(); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		hostId, ok := m.MigrationHost()
+		if !ok {
+			return nil, errors.New("migration not started")
+		}
+		if m.doc.Life != Alive {
+			return nil, errors.New("machine is not alive")
+		}
+		instId, err := m.InstanceId()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		cons, err := m.Constraints()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		template := MachineTemplate{
+			Series:      m.doc.Series,
+			Constraints: cons,
+			Jobs:        m.doc.Jobs,
+			principals:  m.doc.Principals,
+		}
+		var ops []txn.Op
+		mdoc, ops, err = m.st.addMachineInsideMachineOps(template, hostId, instance.LXC)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		mdoc.MigratedFrom = instId
+		unitOps, err := m.migrateUnitsOps(mdoc.Id)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		ops = append(ops, unitOps...)
+		portsOps, err := m.migratePortsOps(mdoc.Id)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		ops = append(ops, portsOps...)
+		ops = append(ops, txn.Op{
+			C:  machinesC,
+			Id: m.doc.DocID,
+			Assert: append(bson.D{
+				{"migrationhost", hostId},
+				{"principals", m.doc.Principals},
+			}, isAliveDoc...),
+			Update: bson.D{
+				{"$set", bson.D{{"life", Dead}, {"principals", []string{}}}},
+				{"$unset", bson.D{{"migrationhost", nil}}},
+			},
+		})
+		return ops, nil
+	}
+	if err := m.st.run(buildTxn); err == jujutxn.ErrExcessiveContention {
+		return nil, errors.Annotate(err, "machine keeps changing")
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+	m.doc.Life = Dead
+	m.doc.Principals = nil
+	m.doc.MigrationHost = ""
+	return newMachine(m.st, mdoc), nil
+}
+
+// migrateUnitsOps returns the operations to assign the principal units
+// on the machine to the machine with the given id. Subordinate units
+// follow their principals.
+func (m *Machine) migrateUnitsOps(machineId string) ([]txn.Op, error) {
+	units, closer := m.st.getCollection(unitsC)
+	defer closer()
+
+	var docs []unitDoc
+	if err := units.Find(bson.D{{"machineid", m.doc.Id}}).All(&docs); err != nil {
+		return nil, errors.Trace(err)
+	}
+	ops := make([]txn.Op, len(docs))
+	for i, doc := range docs {
+		ops[i] = txn.Op{
+			C:      unitsC,
+			Id:     doc.DocID,
+			Assert: append(bson.D{{"machineid", m.doc.Id}}, notDeadDoc...),
+			Update: bson.D{{"$set", bson.D{{"machineid", machineId}}}},
+		}
+	}
+	return ops, nil
+}
+
+// migratePortsOps returns the operations to move the ports opened on
+// the machine to the machine with the given id.
+func (m *Machine) migratePortsOps(machineId string) ([]txn.Op, error) {
+	ports, err := m.AllPorts()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var ops []txn.Op
+	for _, p := range ports {
+		key := portsGlobalKey(machineId, p.doc.NetworkName)
+		ops = append(ops, txn.Op{
+			C:      openedPortsC,
+			Id:     p.doc.DocID,
+			Assert: bson.D{{"txn-revno", p.doc.TxnRevno}},
+			Remove: true,
+		}, txn.Op{
+			C:      openedPortsC,
+			Id:     m.st.docID(key),
+			Assert: txn.DocMissing,
+			Insert: &portsDoc{
+				DocID:       m.st.docID(key),
+				EnvUUID:     m.st.EnvironUUID(),
+				MachineID:   machineId,
+				NetworkName: p.doc.NetworkName,
+				Ports:       p.doc.Ports,
+			},
+		})
+	}
+	return ops, nil
+}
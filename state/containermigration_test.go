@@ -0,0 +1,133 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state"
+)
+
+type ContainerMigrationSuite struct {
+	ConnSuite
+	source    *state.Machine
+	target    *state.Machine
+	container *state.Machine
+	unit      *state.Unit
+}
+
+var _ = gc.Suite(&ContainerMigrationSuite{})
+
+func (s *ContainerMigrationSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	var err error
+	s.source, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	s.target, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	s.container, err = s.State.AddMachineInsideMachine(state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}, s.source.Id(), instance.LXC)
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.container.SetProvisioned("juju-machine-0-lxc-0", "fake_nonce", nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	s.unit, err = wordpress.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.unit.AssignToMachine(s.container)
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.unit.OpenPort("tcp", 80)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *ContainerMigrationSuite) TestCompleteMigration(c *gc.C) {
+	err := s.container.StartMigration(s.target.Id())
+	c.Assert(err, jc.ErrorIsNil)
+	hostId, ok := s.container.MigrationHost()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(hostId, gc.Equals, s.target.Id())
+
+	migrated, err := s.container.CompleteMigration()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(migrated.Id(), gc.Equals, "1/lxc/0")
+	c.Assert(s.container.Life(), gc.Equals, state.Dead)
+
+	migrated, err = s.State.Machine(migrated.Id())
+	c.Assert(err, jc.ErrorIsNil)
+	instId, ok := migrated.MigratedFrom()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(instId, gc.Equals, instance.Id("juju-machine-0-lxc-0"))
+	units, err := migrated.Units()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(units, gc.HasLen, 1)
+	c.Assert(units[0].Name(), gc.Equals, s.unit.Name())
+
+	err = s.container.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.container.Life(), gc.Equals, state.Dead)
+	units, err = s.container.Units()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(units, gc.HasLen, 0)
+	_, ok = s.container.MigrationHost()
+	c.Assert(ok, jc.IsFalse)
+
+	err = s.unit.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	machineId, err := s.unit.AssignedMachineId()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(machineId, gc.Equals, migrated.Id())
+	ports, err := s.unit.OpenedPorts()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ports, jc.DeepEquals, []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
+}
+
+func (s *ContainerMigrationSuite) TestAbortMigration(c *gc.C) {
+	err := s.container.StartMigration(s.target.Id())
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.container.AbortMigration()
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = s.container.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	_, ok := s.container.MigrationHost()
+	c.Assert(ok, jc.IsFalse)
+	_, err = s.container.CompleteMigration()
+	c.Assert(err, gc.ErrorMatches, `cannot complete migration of machine 0/lxc/0: migration not started`)
+
+	err = s.container.AbortMigration()
+	c.Assert(err, gc.ErrorMatches, `cannot abort migration of machine 0/lxc/0: migration not started`)
+}
+
+func (s *ContainerMigrationSuite) TestStartMigrationTwice(c *gc.C) {
+	err := s.container.StartMigration(s.target.Id())
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.container.StartMigration(s.target.Id())
+	c.Assert(err, gc.ErrorMatches, `cannot migrate machine 0/lxc/0 to machine 1: machine is already being migrated to machine 1`)
+}
+
+func (s *ContainerMigrationSuite) TestStartMigrationToSameHost(c *gc.C) {
+	err := s.container.StartMigration(s.source.Id())
+	c.Assert(err, gc.ErrorMatches, `cannot migrate machine 0/lxc/0 to machine 0: container is already on machine 0`)
+}
+
+func (s *ContainerMigrationSuite) TestStartMigrationNotContainer(c *gc.C) {
+	err := s.source.StartMigration(s.target.Id())
+	c.Assert(err, jc.Satisfies, errors.IsNotSupported)
+}
+
+func (s *ContainerMigrationSuite) TestStartMigrationUnprovisioned(c *gc.C) {
+	container, err := s.State.AddMachineInsideMachine(state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}, s.source.Id(), instance.LXC)
+	c.Assert(err, jc.ErrorIsNil)
+	err = container.StartMigration(s.target.Id())
+	c.Assert(err, jc.Satisfies, errors.IsNotProvisioned)
+}
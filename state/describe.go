@@ -0,0 +1,973 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"gopkg.in/juju/charm.v5-unstable"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state/description"
+)
+
+// secretConfigAttrs holds the environment settings which are never
+// included in an environment description.
+var secretConfigAttrs = []string{
+	"admin-secret",
+	"ca-private-key",
+	"rsyslog-ca-key",
+}
+
+// controllerConfigAttrs holds the environment settings which identify
+// an environment or describe the controller hosting it, and are thus
+// never taken from a description when importing it.
+var controllerConfigAttrs = []string{
+	"name",
+	"uuid",
+	"type",
+	"ca-cert",
+	"ca-private-key",
+	"state-port",
+	"api-port",
+	"syslog-port",
+	"rsyslog-ca-cert",
+	"rsyslog-ca-key",
+	"admin-secret",
+	"agent-version",
+}
+
+// DescribeEnvironment returns a provider-neutral description of the
+// model of the environment associated with st.
+func (st *State) DescribeEnvironment() (_ *description.Environment, err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot describe environment %q", st.EnvironUUID())
+
+	env, err := st.Environment()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	desc := &description.Environment{
+		Version: description.Version,
+		UUID:    env.UUID(),
+		Name:    env.Name(),
+		Owner:   env.Owner().String(),
+	}
+	cfg, err := st.EnvironConfig()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	desc.Config = describeConfig(cfg)
+	cons, err := st.EnvironConstraints()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	desc.Constraints = cons.String()
+
+	if desc.Machines, err = st.describeMachines(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if desc.Services, err = st.describeServices(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	relations, err := st.AllRelations()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, rel := range relations {
+		d, err := st.describeRelation(rel)
+		if err != nil {
+			return nil, errors.Annotatef(err, "relation %q", rel)
+		}
+		desc.Relations = append(desc.Relations, d)
+	}
+	if desc.Storage, err = st.describeStorage(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return desc, nil
+}
+
+// describeConfig returns the environment settings in cfg which are
+// neither secret nor specific to the environment's provider.
+func describeConfig(cfg *config.Config) map[string]interface{} {
+	attrs := cfg.AllAttrs()
+	for name := range cfg.UnknownAttrs() {
+		delete(attrs, name)
+	}
+	for _, name := range secretConfigAttrs {
+		delete(attrs, name)
+	}
+	for name, value := range attrs {
+		if value == nil {
+			delete(attrs, name)
+		}
+	}
+	return attrs
+}
+
+func (st *State) describeMachines() ([]description.Machine, error) {
+	machines, err := st.AllMachines()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	// Containers are described within their hosts, so we record the
+	// descriptions of all machines by id before nesting them.
+	described := make(map[string]*description.Machine)
+	var topLevel []string
+	for _, m := range machines {
+		d, err := describeMachine(m)
+		if err != nil {
+			return nil, errors.Annotatef(err, "machine %q", m.Id())
+		}
+		described[m.Id()] = d
+		if _, ok := m.ParentId(); !ok {
+			topLevel = append(topLevel, m.Id())
+		}
+	}
+	// AllMachines sorts machines by id, so every container follows its
+	// host; walk in reverse so that each container is complete before
+	// it is attached to its host.
+	for i := len(machines) - 1; i >= 0; i-- {
+		m := machines[i]
+		parentId, ok := m.ParentId()
+		if !ok {
+			continue
+		}
+		parent := described[parentId]
+		parent.Containers = append([]description.Machine{*described[m.Id()]}, parent.Containers...)
+	}
+	result := make([]description.Machine, len(topLevel))
+	for i, id := range topLevel {
+		result[i] = *described[id]
+	}
+	return result, nil
+}
+
+func describeMachine(m *Machine) (*description.Machine, error) {
+	d := &description.Machine{
+		Id:                m.Id(),
+		Series:            m.Series(),
+		ProviderAddresses: describeAddresses(m.Addresses()),
+		MachineAddresses:  describeAddresses(m.MachineAddresses()),
+	}
+	if ctype := m.ContainerType(); ctype != instance.NONE {
+		d.ContainerType = string(ctype)
+	}
+	for _, job := range m.Jobs() {
+		d.Jobs = append(d.Jobs, job.String())
+	}
+	cons, err := m.Constraints()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	d.Constraints = cons.String()
+
+	instId, err := m.InstanceId()
+	if err == nil {
+		hc, err := m.HardwareCharacteristics()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		d.InstanceId = string(instId)
+		d.Nonce = m.doc.Nonce
+		d.Hardware = hc.String()
+	} else if !errors.IsNotProvisioned(err) {
+		return nil, errors.Trace(err)
+	}
+
+	status, err := m.Status()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	d.Status = describeStatus(status)
+	return d, nil
+}
+
+func describeAddresses(addrs []network.Address) []description.Address {
+	var result []description.Address
+	for _, addr := range addrs {
+		result = append(result, description.Address{
+			Value: addr.Value,
+			Type:  string(addr.Type),
+			Scope: string(addr.Scope),
+		})
+	}
+	return result
+}
+
+func describeStatus(status StatusInfo) description.Status {
+	return description.Status{
+		Status:  string(status.Status),
+		Message: status.Message,
+		Data:    status.Data,
+	}
+}
+
+func (st *State) describeServices() ([]description.Service, error) {
+	services, err := st.AllServices()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var result []description.Service
+	for _, svc := range services {
+		d, err := describeService(svc)
+		if err != nil {
+			return nil, errors.Annotatef(err, "service %q", svc.Name())
+		}
+		result = append(result, *d)
+	}
+	return result, nil
+}
+
+func describeService(svc *Service) (*description.Service, error) {
+	curl, _ := svc.CharmURL()
+	d := &description.Service{
+		Name:        svc.Name(),
+		Charm:       curl.String(),
+		Owner:       svc.GetOwnerTag(),
+		Subordinate: !svc.IsPrincipal(),
+		Exposed:     svc.IsExposed(),
+		MinUnits:    svc.MinUnits(),
+	}
+	cons, err := svc.Constraints()
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, errors.Trace(err)
+	}
+	d.Constraints = cons.String()
+	settings, err := svc.ConfigSettings()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(settings) > 0 {
+		d.Settings = settings
+	}
+	storage, err := svc.StorageConstraints()
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, errors.Trace(err)
+	}
+	for name, cons := range storage {
+		if d.Storage == nil {
+			d.Storage = make(map[string]description.StorageConstraints)
+		}
+		d.Storage[name] = description.StorageConstraints{
+			Pool:  cons.Pool,
+			Size:  cons.Size,
+			Count: cons.Count,
+		}
+	}
+
+	units, err := svc.AllUnits()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	sort.Sort(unitsByName(units))
+	for _, u := range units {
+		du, err := describeUnit(u)
+		if err != nil {
+			return nil, errors.Annotatef(err, "unit %q", u.Name())
+		}
+		d.Units = append(d.Units, *du)
+	}
+	return d, nil
+}
+
+func describeUnit(u *Unit) (*description.Unit, error) {
+	d := &description.Unit{Name: u.Name()}
+	if principal, ok := u.PrincipalName(); ok {
+		d.Principal = principal
+	} else {
+		machineId, err := u.AssignedMachineId()
+		if err != nil && !errors.IsNotAssigned(err) {
+			return nil, errors.Trace(err)
+		}
+		d.Machine = machineId
+	}
+	if d.Machine != "" || d.Principal != "" {
+		ports, err := u.OpenedPorts()
+		if err != nil && !errors.IsNotAssigned(err) {
+			return nil, errors.Trace(err)
+		}
+		for _, port := range ports {
+			d.OpenedPorts = append(d.OpenedPorts, port.String())
+		}
+	}
+	status, err := u.Status()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	d.Status = describeStatus(status)
+	agentStatus, err := u.AgentStatus()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	d.AgentStatus = describeStatus(agentStatus)
+	return d, nil
+}
+
+func (st *State) describeRelation(rel *Relation) (description.Relation, error) {
+	d := description.Relation{
+		Id:  rel.Id(),
+		Key: rel.String(),
+	}
+	var units []*Unit
+	for _, ep := range rel.Endpoints() {
+		d.Endpoints = append(d.Endpoints, description.Endpoint{
+			Service:   ep.ServiceName,
+			Relation:  ep.Name,
+			Interface: ep.Interface,
+			Role:      string(ep.Role),
+			Scope:     string(ep.Scope),
+		})
+		svc, err := st.Service(ep.ServiceName)
+		if err != nil {
+			return d, errors.Trace(err)
+		}
+		svcUnits, err := svc.AllUnits()
+		if err != nil {
+			return d, errors.Trace(err)
+		}
+		units = append(units, svcUnits...)
+	}
+	sort.Sort(unitsByName(units))
+	for _, u := range units {
+		ru, err := rel.Unit(u)
+		if err != nil {
+			return d, errors.Trace(err)
+		}
+		if inScope, err := ru.InScope(); err != nil {
+			return d, errors.Trace(err)
+		} else if !inScope {
+			continue
+		}
+		settings, err := ru.Settings()
+		if err != nil {
+			return d, errors.Annotatef(err, "unit %q", u)
+		}
+		du := description.RelationUnit{Name: u.Name()}
+		if m := settings.Map(); len(m) > 0 {
+			du.Settings = m
+		}
+		d.Units = append(d.Units, du)
+	}
+	return d, nil
+}
+
+func (st *State) describeStorage() ([]description.StorageInstance, error) {
+	instances, err := st.AllStorageInstances()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var result []description.StorageInstance
+	for _, si := range instances {
+		d := description.StorageInstance{
+			Id:    si.StorageTag().Id(),
+			Kind:  storageKindName(si.Kind()),
+			Name:  si.StorageName(),
+			Owner: si.Owner().String(),
+		}
+		attachments, err := st.StorageAttachments(si.StorageTag())
+		if err != nil {
+			return nil, errors.Annotatef(err, "storage %q", d.Id)
+		}
+		for _, a := range attachments {
+			d.Attachments = append(d.Attachments, a.Unit().Id())
+		}
+		sort.Strings(d.Attachments)
+		result = append(result, d)
+	}
+	return result, nil
+}
+
+func storageKindName(kind StorageKind) string {
+	switch kind {
+	case StorageKindBlock:
+		return "block"
+	case StorageKindFilesystem:
+		return "filesystem"
+	}
+	return "unknown"
+}
+
+// ImportEnvironmentDescription creates a new environment, configured
+// by cfg and owned by owner, and recreates within it the model held
+// in desc. The description's settings take precedence over those in
+// cfg, except for those identifying the environment and its
+// controller. The addCharm function is called to add each charm used
+// by the described services to the new environment.
+//
+// Machine ids and unit names are preserved; relation ids are not.
+// Units are entered into the scopes of their relations with the
+// settings they had published there.
+// Storage instances are not recreated directly; they are created, as usual,
+// for units of services with storage constraints.
+//
+// If the import fails after the environment has been created, the
+// partially imported environment is removed.
+func (st *State) ImportEnvironmentDescription(
+	desc *description.Environment,
+	cfg *config.Config,
+	owner names.UserTag,
+	addCharm func(*State, *charm.URL) (*Charm, error),
+) (_ *Environment, _ *State, err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot import environment %q", desc.Name)
+
+	if desc.Version != description.Version {
+		return nil, nil, errors.NotSupportedf("environment description version %d", desc.Version)
+	}
+	attrs := stringKeyedMap(desc.Config)
+	for _, name := range controllerConfigAttrs {
+		delete(attrs, name)
+	}
+	cfg, err = cfg.Apply(attrs)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	env, newSt, err := st.NewEnvironment(cfg, owner)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		if removeErr := newSt.removeFailedImport(); removeErr != nil {
+			logger.Errorf("cannot remove partially imported environment %q: %v", newSt.EnvironUUID(), removeErr)
+		}
+		newSt.Close()
+	}()
+	importer := &environmentImporter{
+		st:        newSt,
+		desc:      desc,
+		addCharm:  addCharm,
+		sequences: make(map[string]int),
+	}
+	if err := importer.run(); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return env, newSt, nil
+}
+
+// removeFailedImport removes an environment partially recreated by
+// ImportEnvironmentDescription. The environment is first made Dying,
+// so that its workers stop. The instances recorded for its machines
+// belong to the described environment, and are left alone.
+func (st *State) removeFailedImport() error {
+	env, err := st.Environment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := env.startDestroy(); err != nil {
+		return errors.Trace(err)
+	}
+	if err := st.removeCharmArchives(); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(st.RemoveAllEnvironDocs())
+}
+
+// environmentImporter recreates a described model within an
+// environment.
+type environmentImporter struct {
+	st       *State
+	desc     *description.Environment
+	addCharm func(*State, *charm.URL) (*Charm, error)
+
+	// sequences holds, for each sequence from which imported machine
+	// ids were allocated, the value following the highest id used.
+	sequences map[string]int
+	statusOps []txn.Op
+}
+
+func (i *environmentImporter) run() error {
+	cons, err := constraints.Parse(i.desc.Constraints)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := i.st.SetEnvironConstraints(cons); err != nil {
+		return errors.Trace(err)
+	}
+	for _, m := range i.desc.Machines {
+		if err := i.machine(m, ""); err != nil {
+			return errors.Annotatef(err, "machine %q", m.Id)
+		}
+	}
+	for _, svc := range i.desc.Services {
+		if err := i.service(svc); err != nil {
+			return errors.Annotatef(err, "service %q", svc.Name)
+		}
+	}
+	if err := i.relations(); err != nil {
+		return errors.Trace(err)
+	}
+	if err := i.subordinates(); err != nil {
+		return errors.Trace(err)
+	}
+	if err := i.relationScopes(); err != nil {
+		return errors.Trace(err)
+	}
+	for name, value := range i.sequences {
+		if err := i.st.setSequence(name, value); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(i.st.runTransaction(i.statusOps))
+}
+
+func (i *environmentImporter) machine(d description.Machine, parentId string) error {
+	var jobs []MachineJob
+	for _, name := range d.Jobs {
+		job, err := machineJobFromName(name)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		jobs = append(jobs, job)
+	}
+	cons, err := constraints.Parse(d.Constraints)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	template := MachineTemplate{
+		Series:      d.Series,
+		Jobs:        jobs,
+		Constraints: cons,
+	}
+
+	// Preserve the machine's id by setting the sequence from which
+	// it will be allocated.
+	seqName, seq, err := machineSequence(d.Id, parentId, d.ContainerType)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := i.st.setSequence(seqName, seq); err != nil {
+		return errors.Trace(err)
+	}
+	if seq >= i.sequences[seqName] {
+		i.sequences[seqName] = seq + 1
+	}
+	var m *Machine
+	if parentId == "" {
+		m, err = i.st.AddOneMachine(template)
+	} else {
+		var ctype instance.ContainerType
+		ctype, err = instance.ParseContainerType(d.ContainerType)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		m, err = i.st.AddMachineInsideMachine(template, parentId, ctype)
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if m.Id() != d.Id {
+		return errors.Errorf("machine added with id %q", m.Id())
+	}
+
+	if d.InstanceId != "" {
+		hc, err := instance.ParseHardware(d.Hardware)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := m.SetProvisioned(instance.Id(d.InstanceId), d.Nonce, &hc); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if len(d.ProviderAddresses) > 0 {
+		if err := m.SetAddresses(networkAddressesFromDescription(d.ProviderAddresses)...); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if len(d.MachineAddresses) > 0 {
+		if err := m.SetMachineAddresses(networkAddressesFromDescription(d.MachineAddresses)...); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	i.addStatus(machineGlobalKey(m.Id()), d.Status)
+
+	for _, container := range d.Containers {
+		if err := i.machine(container, m.Id()); err != nil {
+			return errors.Annotatef(err, "machine %q", container.Id)
+		}
+	}
+	return nil
+}
+
+// machineSequence returns the name of the sequence from which the
+// machine with the given id is allocated, and the sequence value
+// which yields that id.
+func machineSequence(id, parentId, containerType string) (string, int, error) {
+	name := "machine"
+	if parentId != "" {
+		name = fmt.Sprintf("machine%s%sContainer", parentId, containerType)
+	}
+	seq, err := strconv.Atoi(id[strings.LastIndex(id, "/")+1:])
+	if err != nil {
+		return "", 0, errors.Errorf("invalid machine id %q", id)
+	}
+	return name, seq, nil
+}
+
+func machineJobFromName(name string) (MachineJob, error) {
+	for job := range jobNames {
+		if job.String() == name {
+			return job, nil
+		}
+	}
+	return 0, errors.NotValidf("machine job %q", name)
+}
+
+func networkAddressesFromDescription(addrs []description.Address) []network.Address {
+	result := make([]network.Address, len(addrs))
+	for i, addr := range addrs {
+		result[i] = network.Address{
+			Value: addr.Value,
+			Type:  network.AddressType(addr.Type),
+			Scope: network.Scope(addr.Scope),
+		}
+	}
+	return result
+}
+
+func (i *environmentImporter) service(d description.Service) error {
+	curl, err := charm.ParseURL(d.Charm)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ch, err := i.st.Charm(curl)
+	if errors.IsNotFound(err) {
+		ch, err = i.addCharm(i.st, curl)
+	}
+	if err != nil {
+		return errors.Annotatef(err, "charm %q", curl)
+	}
+	var storage map[string]StorageConstraints
+	for name, cons := range d.Storage {
+		if storage == nil {
+			storage = make(map[string]StorageConstraints)
+		}
+		storage[name] = StorageConstraints{
+			Pool:  cons.Pool,
+			Size:  cons.Size,
+			Count: cons.Count,
+		}
+	}
+	svc, err := i.st.AddService(d.Name, d.Owner, ch, nil, storage)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(d.Settings) > 0 {
+		if err := svc.UpdateConfigSettings(charm.Settings(stringKeyedMap(d.Settings))); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	cons, err := constraints.Parse(d.Constraints)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := svc.SetConstraints(cons); err != nil {
+		return errors.Trace(err)
+	}
+	if d.Exposed {
+		if err := svc.SetExposed(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if d.MinUnits > 0 {
+		if err := svc.SetMinUnits(d.MinUnits); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if d.Subordinate {
+		// Subordinate units are created as their principals enter
+		// scope, once relations have been added.
+		return nil
+	}
+	for _, du := range d.Units {
+		if err := i.unit(svc, du); err != nil {
+			return errors.Annotatef(err, "unit %q", du.Name)
+		}
+	}
+	return nil
+}
+
+func (i *environmentImporter) unit(svc *Service, d description.Unit) error {
+	if err := i.setUnitSequence(svc, d.Name); err != nil {
+		return errors.Trace(err)
+	}
+	u, err := svc.AddUnit()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if u.Name() != d.Name {
+		return errors.Errorf("unit added with name %q", u.Name())
+	}
+	if d.Machine != "" {
+		m, err := i.st.Machine(d.Machine)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := u.AssignToMachine(m); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return i.unitDetails(u, d)
+}
+
+// unitDetails records the opened ports and statuses of an imported unit.
+func (i *environmentImporter) unitDetails(u *Unit, d description.Unit) error {
+	for _, p := range d.OpenedPorts {
+		ports, err := network.ParsePortRange(p)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := u.OpenPorts(ports.Protocol, ports.FromPort, ports.ToPort); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	i.addStatus(unitGlobalKey(u.Name()), d.Status)
+	i.addStatus(unitAgentGlobalKey(u.Name()), d.AgentStatus)
+	return nil
+}
+
+func (i *environmentImporter) relations() error {
+	relations := append([]description.Relation(nil), i.desc.Relations...)
+	sort.Sort(relationsById(relations))
+	for _, d := range relations {
+		var eps []Endpoint
+		for _, dep := range d.Endpoints {
+			svc, err := i.st.Service(dep.Service)
+			if err != nil {
+				return errors.Annotatef(err, "relation %q", d.Key)
+			}
+			ep, err := svc.Endpoint(dep.Relation)
+			if err != nil {
+				return errors.Annotatef(err, "relation %q", d.Key)
+			}
+			eps = append(eps, ep)
+		}
+		// Peer relations are added along with their services.
+		if _, err := i.st.EndpointsRelation(eps...); err == nil {
+			continue
+		} else if !errors.IsNotFound(err) {
+			return errors.Annotatef(err, "relation %q", d.Key)
+		}
+		if _, err := i.st.AddRelation(eps...); err != nil {
+			return errors.Annotatef(err, "relation %q", d.Key)
+		}
+	}
+	return nil
+}
+
+// relationScopes enters each described unit into the scope of its
+// relation, with the settings it had published there. Principals of
+// subordinate units are already in scope, and only their settings are
+// recorded.
+func (i *environmentImporter) relationScopes() error {
+	for _, d := range i.desc.Relations {
+		if len(d.Units) == 0 {
+			continue
+		}
+		rel, err := i.st.KeyRelation(d.Key)
+		if err != nil {
+			return errors.Annotatef(err, "relation %q", d.Key)
+		}
+		for _, du := range d.Units {
+			if err := i.relationScope(rel, du); err != nil {
+				return errors.Annotatef(err, "relation %q unit %q", d.Key, du.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func (i *environmentImporter) relationScope(rel *Relation, d description.RelationUnit) error {
+	u, err := i.st.Unit(d.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ru, err := rel.Unit(u)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	settings := stringKeyedMap(d.Settings)
+	inScope, err := ru.InScope()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !inScope {
+		return errors.Trace(ru.EnterScope(settings))
+	}
+	if len(settings) == 0 {
+		return nil
+	}
+	node, err := ru.Settings()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	node.Update(settings)
+	_, err = node.Write()
+	return errors.Trace(err)
+}
+
+// subordinates creates each described subordinate unit, by entering
+// its principal into the scope of the container-scoped relation
+// between their services.
+func (i *environmentImporter) subordinates() error {
+	for _, svcDesc := range i.desc.Services {
+		if !svcDesc.Subordinate {
+			continue
+		}
+		svc, err := i.st.Service(svcDesc.Name)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for _, d := range svcDesc.Units {
+			if err := i.subordinate(svc, d); err != nil {
+				return errors.Annotatef(err, "unit %q", d.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func (i *environmentImporter) subordinate(svc *Service, d description.Unit) error {
+	principal, err := i.st.Unit(d.Principal)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	rel, err := i.containerRelation(svc.Name(), principal.ServiceName())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ru, err := rel.Unit(principal)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := i.setUnitSequence(svc, d.Name); err != nil {
+		return errors.Trace(err)
+	}
+	if err := ru.EnterScope(nil); err != nil {
+		return errors.Trace(err)
+	}
+	u, err := i.st.Unit(d.Name)
+	if err != nil {
+		return errors.Annotatef(err, "subordinate of %q", principal.Name())
+	}
+	return i.unitDetails(u, d)
+}
+
+func (i *environmentImporter) containerRelation(subordinate, principal string) (*Relation, error) {
+	relations, err := i.st.AllRelations()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, rel := range relations {
+		subEp, err := rel.Endpoint(subordinate)
+		if err != nil || subEp.Scope != charm.ScopeContainer {
+			continue
+		}
+		if _, err := rel.Endpoint(principal); err == nil {
+			return rel, nil
+		}
+	}
+	return nil, errors.NotFoundf("container-scoped relation between %q and %q", subordinate, principal)
+}
+
+// setUnitSequence sets the unit sequence of svc so that the next unit
+// added to it is given the supplied name.
+func (i *environmentImporter) setUnitSequence(svc *Service, unitName string) error {
+	seq, err := strconv.Atoi(unitName[strings.LastIndex(unitName, "/")+1:])
+	if err != nil || !strings.HasPrefix(unitName, svc.Name()+"/") {
+		return errors.NotValidf("unit name %q", unitName)
+	}
+	services, closer := i.st.getCollection(servicesC)
+	defer closer()
+	change := mgo.Change{Update: bson.D{{"$set", bson.D{{"unitseq", seq}}}}}
+	if _, err := services.FindId(svc.Name()).Apply(change, &serviceDoc{}); err != nil {
+		return errors.Annotatef(err, "cannot set unit sequence for service %q", svc)
+	}
+	return nil
+}
+
+func (i *environmentImporter) addStatus(globalKey string, d description.Status) {
+	now := nowToTheSecond()
+	i.statusOps = append(i.statusOps, updateStatusOp(i.st, globalKey, statusDoc{
+		EnvUUID:    i.st.EnvironUUID(),
+		Status:     Status(d.Status),
+		StatusInfo: d.Message,
+		StatusData: stringKeyedMap(d.Data),
+		Updated:    &now,
+	}))
+}
+
+// stringKeyedMap returns a copy of m in which any nested maps parsed
+// from YAML have string keys, as required for storage in MongoDB.
+func stringKeyedMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[k] = stringKeyed(v)
+	}
+	return result
+}
+
+func stringKeyed(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, v := range v {
+			result[fmt.Sprint(k)] = stringKeyed(v)
+		}
+		return result
+	case map[string]interface{}:
+		return stringKeyedMap(v)
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, v := range v {
+			result[i] = stringKeyed(v)
+		}
+		return result
+	}
+	return v
+}
+
+type unitsByName []*Unit
+
+func (u unitsByName) Len() int           { return len(u) }
+func (u unitsByName) Less(i, j int) bool { return unitLess(u[i].Name(), u[j].Name()) }
+func (u unitsByName) Swap(i, j int)      { u[i], u[j] = u[j], u[i] }
+
+// unitLess orders unit names by service, then by unit number.
+func unitLess(a, b string) bool {
+	aSvc, aNum := splitUnitName(a)
+	bSvc, bNum := splitUnitName(b)
+	if aSvc != bSvc {
+		return aSvc < bSvc
+	}
+	return aNum < bNum
+}
+
+func splitUnitName(name string) (string, int) {
+	i := strings.LastIndex(name, "/")
+	num, _ := strconv.Atoi(name[i+1:])
+	return name[:i], num
+}
+
+type relationsById []description.Relation
+
+func (r relationsById) Len() int           { return len(r) }
+func (r relationsById) Less(i, j int) bool { return r[i].Id < r[j].Id }
+func (r relationsById) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
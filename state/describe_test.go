@@ -0,0 +1,234 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/juju/charm.v5-unstable"
+
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/description"
+	"github.com/juju/juju/testcharms"
+	"github.com/juju/juju/testing"
+)
+
+type DescribeSuite struct {
+	ConnSuite
+	charmNames map[string]string
+}
+
+var _ = gc.Suite(&DescribeSuite{})
+
+func (s *DescribeSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.charmNames = make(map[string]string)
+
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	err = m.SetProvisioned("i-0", "fake_nonce", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	err = m.SetAddresses(network.NewAddress("10.0.0.1"))
+	c.Assert(err, jc.ErrorIsNil)
+	err = m.SetStatus(state.StatusStarted, "", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AddMachineInsideMachine(state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}, m.Id(), instance.LXC)
+	c.Assert(err, jc.ErrorIsNil)
+
+	wordpress := s.addService(c, "wordpress")
+	err = wordpress.SetExposed()
+	c.Assert(err, jc.ErrorIsNil)
+	err = wordpress.UpdateConfigSettings(charm.Settings{"blog-title": "Imported Blog"})
+	c.Assert(err, jc.ErrorIsNil)
+	s.addService(c, "mysql")
+	s.addService(c, "logging")
+	db := s.addRelation(c, "wordpress", "mysql")
+	logging := s.addRelation(c, "wordpress", "logging")
+
+	// Skip a unit, so that unit names must be preserved.
+	skipped, err := wordpress.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	err = skipped.Destroy()
+	c.Assert(err, jc.ErrorIsNil)
+	u, err := wordpress.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	err = u.AssignToMachine(m)
+	c.Assert(err, jc.ErrorIsNil)
+	err = u.OpenPort("tcp", 80)
+	c.Assert(err, jc.ErrorIsNil)
+	err = u.SetStatus(state.StatusActive, "ready", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	ru, err := logging.Unit(u)
+	c.Assert(err, jc.ErrorIsNil)
+	err = ru.EnterScope(nil)
+	c.Assert(err, jc.ErrorIsNil)
+	ru, err = db.Unit(u)
+	c.Assert(err, jc.ErrorIsNil)
+	err = ru.EnterScope(map[string]interface{}{"database": "blog"})
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *DescribeSuite) addService(c *gc.C, name string) *state.Service {
+	ch := s.AddTestingCharm(c, name)
+	s.charmNames[ch.URL().String()] = name
+	return s.AddTestingService(c, name, ch)
+}
+
+func (s *DescribeSuite) addRelation(c *gc.C, names ...string) *state.Relation {
+	eps, err := s.State.InferEndpoints(names...)
+	c.Assert(err, jc.ErrorIsNil)
+	rel, err := s.State.AddRelation(eps...)
+	c.Assert(err, jc.ErrorIsNil)
+	return rel
+}
+
+func (s *DescribeSuite) addCharm(st *state.State, curl *charm.URL) (*state.Charm, error) {
+	name, ok := s.charmNames[curl.String()]
+	if !ok {
+		return nil, errors.NotFoundf("charm %q", curl)
+	}
+	return st.AddCharm(testcharms.Repo.CharmDir(name), curl, "dummy-path", "dummy-sha256")
+}
+
+func (s *DescribeSuite) TestDescribe(c *gc.C) {
+	desc, err := s.State.DescribeEnvironment()
+	c.Assert(err, jc.ErrorIsNil)
+
+	c.Assert(desc.Version, gc.Equals, description.Version)
+	c.Assert(desc.UUID, gc.Equals, s.State.EnvironUUID())
+	c.Assert(desc.Config["name"], gc.Equals, "testenv")
+	_, ok := desc.Config["admin-secret"]
+	c.Assert(ok, jc.IsFalse)
+
+	c.Assert(desc.Machines, gc.HasLen, 1)
+	m := desc.Machines[0]
+	c.Assert(m.Id, gc.Equals, "0")
+	c.Assert(m.InstanceId, gc.Equals, "i-0")
+	c.Assert(m.Jobs, jc.DeepEquals, []string{"JobHostUnits"})
+	c.Assert(m.Status.Status, gc.Equals, "started")
+	c.Assert(m.ProviderAddresses, jc.DeepEquals, []description.Address{{
+		Value: "10.0.0.1",
+		Type:  "ipv4",
+		Scope: "local-cloud",
+	}})
+	c.Assert(m.Containers, gc.HasLen, 1)
+	c.Assert(m.Containers[0].Id, gc.Equals, "0/lxc/0")
+	c.Assert(m.Containers[0].ContainerType, gc.Equals, "lxc")
+
+	c.Assert(desc.Services, gc.HasLen, 3)
+	c.Assert(desc.Relations, gc.HasLen, 2)
+	for _, rel := range desc.Relations {
+		switch rel.Key {
+		case "wordpress:db mysql:server":
+			c.Assert(rel.Units, jc.DeepEquals, []description.RelationUnit{{
+				Name:     "wordpress/1",
+				Settings: map[string]interface{}{"database": "blog"},
+			}})
+		case "logging:logging-directory wordpress:logging-dir":
+			c.Assert(rel.Units, jc.DeepEquals, []description.RelationUnit{{
+				Name: "wordpress/1",
+			}})
+		default:
+			c.Fatalf("unexpected relation %q", rel.Key)
+		}
+	}
+	for _, svc := range desc.Services {
+		switch svc.Name {
+		case "wordpress":
+			c.Assert(svc.Exposed, jc.IsTrue)
+			c.Assert(svc.Settings, jc.DeepEquals, map[string]interface{}{"blog-title": "Imported Blog"})
+			c.Assert(svc.Units, jc.DeepEquals, []description.Unit{{
+				Name:        "wordpress/1",
+				Machine:     "0",
+				OpenedPorts: []string{"80/tcp"},
+				Status:      description.Status{Status: "active", Message: "ready"},
+				AgentStatus: svc.Units[0].AgentStatus,
+			}})
+		case "logging":
+			c.Assert(svc.Subordinate, jc.IsTrue)
+			c.Assert(svc.Units, gc.HasLen, 1)
+			c.Assert(svc.Units[0].Name, gc.Equals, "logging/0")
+			c.Assert(svc.Units[0].Principal, gc.Equals, "wordpress/1")
+		}
+	}
+}
+
+func (s *DescribeSuite) TestImport(c *gc.C) {
+	desc, err := s.State.DescribeEnvironment()
+	c.Assert(err, jc.ErrorIsNil)
+	data, err := description.MarshalYAML(desc)
+	c.Assert(err, jc.ErrorIsNil)
+	parsed, err := description.Unmarshal(data)
+	c.Assert(err, jc.ErrorIsNil)
+
+	uuid, err := utils.NewUUID()
+	c.Assert(err, jc.ErrorIsNil)
+	cfg := testing.CustomEnvironConfig(c, testing.Attrs{
+		"name": "imported",
+		"uuid": uuid.String(),
+	})
+	env, st, err := s.State.ImportEnvironmentDescription(parsed, cfg, s.Owner, s.addCharm)
+	c.Assert(err, jc.ErrorIsNil)
+	defer st.Close()
+	c.Assert(env.Name(), gc.Equals, "imported")
+	c.Assert(env.UUID(), gc.Equals, uuid.String())
+
+	imported, err := st.DescribeEnvironment()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(imported.UUID, gc.Equals, uuid.String())
+	c.Assert(imported.Config["name"], gc.Equals, "imported")
+
+	// Apart from the identity of the environment, the model is
+	// recreated exactly.
+	imported.UUID, imported.Name = desc.UUID, desc.Name
+	imported.Config["uuid"], imported.Config["name"] = desc.Config["uuid"], desc.Config["name"]
+	c.Assert(imported, jc.DeepEquals, desc)
+
+	// New machines and units do not reuse imported ids.
+	m, err := st.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(m.Id(), gc.Equals, "1")
+	wordpress, err := st.Service("wordpress")
+	c.Assert(err, jc.ErrorIsNil)
+	u, err := wordpress.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(u.Name(), gc.Equals, "wordpress/2")
+}
+
+func (s *DescribeSuite) TestImportFailureRemovesEnvironment(c *gc.C) {
+	desc, err := s.State.DescribeEnvironment()
+	c.Assert(err, jc.ErrorIsNil)
+	uuid, err := utils.NewUUID()
+	c.Assert(err, jc.ErrorIsNil)
+	cfg := testing.CustomEnvironConfig(c, testing.Attrs{
+		"name": "imported",
+		"uuid": uuid.String(),
+	})
+	failCharm := func(*state.State, *charm.URL) (*state.Charm, error) {
+		return nil, errors.New("no charms here")
+	}
+	_, _, err = s.State.ImportEnvironmentDescription(desc, cfg, s.Owner, failCharm)
+	c.Assert(err, gc.ErrorMatches, `cannot import environment "testenv": service ".*": charm ".*": no charms here`)
+	_, err = s.State.GetEnvironment(names.NewEnvironTag(uuid.String()))
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	// The environment's name may be used again.
+	_, st, err := s.State.ImportEnvironmentDescription(desc, cfg, s.Owner, s.addCharm)
+	c.Assert(err, jc.ErrorIsNil)
+	st.Close()
+}
+
+func (s *DescribeSuite) TestImportUnsupportedVersion(c *gc.C) {
+	desc := &description.Environment{Version: description.Version + 1}
+	_, _, err := s.State.ImportEnvironmentDescription(desc, testing.EnvironConfig(c), s.Owner, s.addCharm)
+	c.Assert(err, gc.ErrorMatches, "cannot import environment .*: environment description version 2 not supported")
+}
@@ -0,0 +1,184 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package description defines a versioned, provider-neutral description
+// of an environment's model: its machines, services, units, relations,
+// settings, constraints, storage and statuses. Descriptions may be
+// serialized as YAML or JSON, and are used to snapshot a single
+// environment for debugging and support, and to recreate it elsewhere.
+package description
+
+import (
+	"encoding/json"
+
+	"github.com/juju/errors"
+	"gopkg.in/yaml.v1"
+)
+
+// Version is the version of the description format written by this
+// package. Descriptions of other versions cannot be read.
+const Version = 1
+
+// Environment describes an environment's model.
+type Environment struct {
+	// Version is the version of the description format.
+	Version int `yaml:"version" json:"version"`
+
+	// UUID and Name identify the described environment.
+	UUID  string `yaml:"uuid" json:"uuid"`
+	Name  string `yaml:"name" json:"name"`
+	Owner string `yaml:"owner" json:"owner"`
+
+	// Config holds the environment's provider-neutral settings.
+	// Secrets and provider-specific settings are not included.
+	Config map[string]interface{} `yaml:"config,omitempty" json:"config,omitempty"`
+
+	// Constraints holds the environment's constraints.
+	Constraints string `yaml:"constraints,omitempty" json:"constraints,omitempty"`
+
+	// Machines holds the environment's top-level machines. Containers
+	// are described within their host machines.
+	Machines []Machine `yaml:"machines,omitempty" json:"machines,omitempty"`
+
+	Services  []Service         `yaml:"services,omitempty" json:"services,omitempty"`
+	Relations []Relation        `yaml:"relations,omitempty" json:"relations,omitempty"`
+	Storage   []StorageInstance `yaml:"storage,omitempty" json:"storage,omitempty"`
+}
+
+// Status describes the status of a machine, unit or unit agent.
+type Status struct {
+	Status  string                 `yaml:"status" json:"status"`
+	Message string                 `yaml:"message,omitempty" json:"message,omitempty"`
+	Data    map[string]interface{} `yaml:"data,omitempty" json:"data,omitempty"`
+}
+
+// Address describes a network address of a machine.
+type Address struct {
+	Value string `yaml:"value" json:"value"`
+	Type  string `yaml:"type" json:"type"`
+	Scope string `yaml:"scope,omitempty" json:"scope,omitempty"`
+}
+
+// Machine describes a machine, and the containers it hosts.
+type Machine struct {
+	Id            string   `yaml:"id" json:"id"`
+	Series        string   `yaml:"series" json:"series"`
+	ContainerType string   `yaml:"container-type,omitempty" json:"container-type,omitempty"`
+	Jobs          []string `yaml:"jobs" json:"jobs"`
+	Constraints   string   `yaml:"constraints,omitempty" json:"constraints,omitempty"`
+
+	// InstanceId, Nonce and Hardware are set if the machine has been
+	// provisioned.
+	InstanceId string `yaml:"instance-id,omitempty" json:"instance-id,omitempty"`
+	Nonce      string `yaml:"nonce,omitempty" json:"nonce,omitempty"`
+	Hardware   string `yaml:"hardware,omitempty" json:"hardware,omitempty"`
+
+	ProviderAddresses []Address `yaml:"provider-addresses,omitempty" json:"provider-addresses,omitempty"`
+	MachineAddresses  []Address `yaml:"machine-addresses,omitempty" json:"machine-addresses,omitempty"`
+
+	Status     Status    `yaml:"status" json:"status"`
+	Containers []Machine `yaml:"containers,omitempty" json:"containers,omitempty"`
+}
+
+// StorageConstraints describes the storage a service's units are
+// given for one of its charm's stores.
+type StorageConstraints struct {
+	Pool  string `yaml:"pool" json:"pool"`
+	Size  uint64 `yaml:"size" json:"size"` // MiB
+	Count uint64 `yaml:"count" json:"count"`
+}
+
+// Service describes a service and its units.
+type Service struct {
+	Name        string `yaml:"name" json:"name"`
+	Charm       string `yaml:"charm" json:"charm"`
+	Owner       string `yaml:"owner" json:"owner"`
+	Subordinate bool   `yaml:"subordinate,omitempty" json:"subordinate,omitempty"`
+	Exposed     bool   `yaml:"exposed,omitempty" json:"exposed,omitempty"`
+	MinUnits    int    `yaml:"min-units,omitempty" json:"min-units,omitempty"`
+	Constraints string `yaml:"constraints,omitempty" json:"constraints,omitempty"`
+
+	// Settings holds the charm settings which differ from the charm's
+	// defaults.
+	Settings map[string]interface{}        `yaml:"settings,omitempty" json:"settings,omitempty"`
+	Storage  map[string]StorageConstraints `yaml:"storage,omitempty" json:"storage,omitempty"`
+	Units    []Unit                        `yaml:"units,omitempty" json:"units,omitempty"`
+}
+
+// Unit describes a unit of a service.
+type Unit struct {
+	Name string `yaml:"name" json:"name"`
+
+	// Machine is the id of the machine to which a principal unit is
+	// assigned, and Principal is the name of the principal unit of a
+	// subordinate unit.
+	Machine   string `yaml:"machine,omitempty" json:"machine,omitempty"`
+	Principal string `yaml:"principal,omitempty" json:"principal,omitempty"`
+
+	// OpenedPorts holds the port ranges opened by the unit, as
+	// "<from>-<to>/<protocol>".
+	OpenedPorts []string `yaml:"opened-ports,omitempty" json:"opened-ports,omitempty"`
+
+	Status      Status `yaml:"status" json:"status"`
+	AgentStatus Status `yaml:"agent-status" json:"agent-status"`
+}
+
+// Endpoint describes one end of a relation.
+type Endpoint struct {
+	Service   string `yaml:"service" json:"service"`
+	Relation  string `yaml:"relation" json:"relation"`
+	Interface string `yaml:"interface" json:"interface"`
+	Role      string `yaml:"role" json:"role"`
+	Scope     string `yaml:"scope" json:"scope"`
+}
+
+// Relation describes a relation between services.
+type Relation struct {
+	Id        int        `yaml:"id" json:"id"`
+	Key       string     `yaml:"key" json:"key"`
+	Endpoints []Endpoint `yaml:"endpoints" json:"endpoints"`
+
+	// Units holds the units which have entered the relation's scope.
+	Units []RelationUnit `yaml:"units,omitempty" json:"units,omitempty"`
+}
+
+// RelationUnit describes a unit in the scope of a relation, and the
+// settings it has published there.
+type RelationUnit struct {
+	Name     string                 `yaml:"name" json:"name"`
+	Settings map[string]interface{} `yaml:"settings,omitempty" json:"settings,omitempty"`
+}
+
+// StorageInstance describes a storage instance and the units to which
+// it is attached.
+type StorageInstance struct {
+	Id          string   `yaml:"id" json:"id"`
+	Kind        string   `yaml:"kind" json:"kind"`
+	Name        string   `yaml:"name" json:"name"`
+	Owner       string   `yaml:"owner" json:"owner"`
+	Attachments []string `yaml:"attachments,omitempty" json:"attachments,omitempty"`
+}
+
+// MarshalYAML returns the description serialized as YAML.
+func MarshalYAML(env *Environment) ([]byte, error) {
+	data, err := yaml.Marshal(env)
+	return data, errors.Trace(err)
+}
+
+// MarshalJSON returns the description serialized as JSON.
+func MarshalJSON(env *Environment) ([]byte, error) {
+	data, err := json.MarshalIndent(env, "", "  ")
+	return data, errors.Trace(err)
+}
+
+// Unmarshal parses a description serialized as YAML or JSON.
+func Unmarshal(data []byte) (*Environment, error) {
+	var env Environment
+	if err := yaml.Unmarshal(data, &env); err != nil {
+		return nil, errors.Annotate(err, "cannot parse environment description")
+	}
+	if env.Version != Version {
+		return nil, errors.NotSupportedf("environment description version %d", env.Version)
+	}
+	return &env, nil
+}
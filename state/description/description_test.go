@@ -0,0 +1,116 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package description_test
+
+import (
+	"testing"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/state/description"
+)
+
+func TestPackage(t *testing.T) {
+	gc.TestingT(t)
+}
+
+type DescriptionSuite struct{}
+
+var _ = gc.Suite(&DescriptionSuite{})
+
+var testEnvironment = &description.Environment{
+	Version:     description.Version,
+	UUID:        "deadbeef-0bad-400d-8000-4b1d0d06f00d",
+	Name:        "testenv",
+	Owner:       "user-admin",
+	Config:      map[string]interface{}{"default-series": "trusty", "logging-config": "<root>=DEBUG"},
+	Constraints: "mem=4096M",
+	Machines: []description.Machine{{
+		Id:         "0",
+		Series:     "trusty",
+		Jobs:       []string{"JobHostUnits"},
+		InstanceId: "i-0",
+		Nonce:      "fake_nonce",
+		Hardware:   "arch=amd64 mem=4096M",
+		Status:     description.Status{Status: "started"},
+		Containers: []description.Machine{{
+			Id:            "0/lxc/0",
+			Series:        "trusty",
+			ContainerType: "lxc",
+			Jobs:          []string{"JobHostUnits"},
+			Status:        description.Status{Status: "pending"},
+		}},
+		ProviderAddresses: []description.Address{{
+			Value: "10.0.0.1",
+			Type:  "ipv4",
+			Scope: "local-cloud",
+		}},
+	}},
+	Services: []description.Service{{
+		Name:     "wordpress",
+		Charm:    "local:quantal/wordpress-3",
+		Owner:    "user-admin",
+		Exposed:  true,
+		Settings: map[string]interface{}{"blog-title": "My Title"},
+		Units: []description.Unit{{
+			Name:        "wordpress/0",
+			Machine:     "0",
+			OpenedPorts: []string{"80/tcp"},
+			Status:      description.Status{Status: "active", Message: "ready"},
+			AgentStatus: description.Status{Status: "idle"},
+		}},
+	}},
+	Relations: []description.Relation{{
+		Id:  0,
+		Key: "wordpress:db mysql:server",
+		Endpoints: []description.Endpoint{{
+			Service:   "wordpress",
+			Relation:  "db",
+			Interface: "mysql",
+			Role:      "requirer",
+			Scope:     "global",
+		}, {
+			Service:   "mysql",
+			Relation:  "server",
+			Interface: "mysql",
+			Role:      "provider",
+			Scope:     "global",
+		}},
+		Units: []description.RelationUnit{{
+			Name:     "mysql/0",
+			Settings: map[string]interface{}{"host": "10.0.0.2"},
+		}, {
+			Name: "wordpress/0",
+		}},
+	}},
+}
+
+func (*DescriptionSuite) TestYAMLRoundTrip(c *gc.C) {
+	data, err := description.MarshalYAML(testEnvironment)
+	c.Assert(err, jc.ErrorIsNil)
+	env, err := description.Unmarshal(data)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env, jc.DeepEquals, testEnvironment)
+}
+
+func (*DescriptionSuite) TestJSONRoundTrip(c *gc.C) {
+	data, err := description.MarshalJSON(testEnvironment)
+	c.Assert(err, jc.ErrorIsNil)
+	env, err := description.Unmarshal(data)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env, jc.DeepEquals, testEnvironment)
+}
+
+func (*DescriptionSuite) TestUnmarshalUnsupportedVersion(c *gc.C) {
+	_, err := description.Unmarshal([]byte("version: 99\nuuid: deadbeef\n"))
+	c.Assert(err, jc.Satisfies, errors.IsNotSupported)
+	c.Assert(err, gc.ErrorMatches, "environment description version 99 not supported")
+}
+
+func (*DescriptionSuite) TestUnmarshalInvalid(c *gc.C) {
+	_, err := description.Unmarshal([]byte("machines: 42"))
+	c.Assert(err, gc.ErrorMatches, "cannot parse environment description: .*")
+}
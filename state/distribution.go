@@ -7,14 +7,17 @@ import (
 	"fmt"
 
 	"github.com/juju/errors"
+	"gopkg.in/mgo.v2/bson"
 
 	"github.com/juju/juju/instance"
 )
 
 // distributeuUnit takes a unit and set of clean, possibly empty, instances
 // and asks the InstanceDistributor policy (if any) which ones are suitable
-// for assigning the unit to. If there is no InstanceDistributor, or the
-// distribution group is empty, then all of the candidates will be returned.
+// for assigning the unit to. If there is no policy, or the distribution
+// group is empty, then all of the candidates will be returned. If the
+// policy does not implement InstanceDistributor, the candidates are
+// spread across the availability zones recorded for their machines.
 func distributeUnit(u *Unit, candidates []instance.Id) ([]instance.Id, error) {
 	if len(candidates) == 0 {
 		return nil, nil
@@ -28,7 +31,7 @@ func distributeUnit(u *Unit, candidates []instance.Id) ([]instance.Id, error) {
 	}
 	distributor, err := u.st.policy.InstanceDistributor(cfg)
 	if errors.IsNotImplemented(err) {
-		return candidates, nil
+		distributor = recordedZoneDistributor{u.st}
 	} else if err != nil {
 		return nil, err
 	}
@@ -75,3 +78,85 @@ func ServiceInstances(st *State, service string) ([]instance.Id, error) {
 	}
 	return instanceIds, nil
 }
+
+// recordedZoneDistributor is an InstanceDistributor which spreads
+// instances across the availability zones recorded in their instance
+// data. It is used for environments which cannot report availability
+// zones themselves, such as those with manually provisioned machines,
+// whose zones are assigned by the user when they are added.
+type recordedZoneDistributor struct {
+	st *State
+}
+
+// DistributeInstances implements InstanceDistributor. It returns the
+// candidates in the zones holding the fewest of the distribution
+// group's instances, in the same way as the providers which support
+// availability zones. Candidates with no recorded zone are ignored,
+// unless none of the candidates has one, in which case all of them
+// are returned.
+func (d recordedZoneDistributor) DistributeInstances(candidates, distributionGroup []instance.Id) ([]instance.Id, error) {
+	ids := make([]instance.Id, 0, len(candidates)+len(distributionGroup))
+	ids = append(ids, candidates...)
+	ids = append(ids, distributionGroup...)
+	zones, err := instanceAvailZones(d.st, ids)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	// Tally the distribution group's instances in each of the
+	// candidates' zones.
+	population := make(map[string]int)
+	for _, id := range candidates {
+		if zone, ok := zones[id]; ok {
+			population[zone] = 0
+		}
+	}
+	if len(population) == 0 {
+		return candidates, nil
+	}
+	for _, id := range distributionGroup {
+		zone, ok := zones[id]
+		if !ok {
+			continue
+		}
+		if _, ok := population[zone]; ok {
+			population[zone]++
+		}
+	}
+
+	least := -1
+	for _, n := range population {
+		if least == -1 || n < least {
+			least = n
+		}
+	}
+	var eligible []instance.Id
+	for _, id := range candidates {
+		if zone, ok := zones[id]; ok && population[zone] == least {
+			eligible = append(eligible, id)
+		}
+	}
+	return eligible, nil
+}
+
+// instanceAvailZones returns the availability zones recorded for
+// the given instances, keyed by instance id. Instances with no
+// recorded zone are omitted.
+func instanceAvailZones(st *State, ids []instance.Id) (map[instance.Id]string, error) {
+	instanceDataCollection, closer := st.getCollection(instanceDataC)
+	defer closer()
+
+	var docs []instanceData
+	sel := bson.D{{"instanceid", bson.D{{"$in", ids}}}}
+	err := instanceDataCollection.Find(sel).Select(bson.D{{"instanceid", 1}, {"availzone", 1}}).All(&docs)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot get availability zones of instances")
+	}
+	zones := make(map[instance.Id]string)
+	for _, doc := range docs {
+		if doc.AvailZone != nil && *doc.AvailZone != "" {
+			zones[doc.InstanceId] = *doc.AvailZone
+		}
+	}
+	return zones, nil
+}
@@ -173,6 +173,31 @@ func (s *InstanceDistributorSuite) TestInstanceDistributorUnimplemented(c *gc.C)
 	c.Assert(err, jc.ErrorIsNil)
 }
 
+func (s *InstanceDistributorSuite) TestInstanceDistributorUnimplementedRecordedZones(c *gc.C) {
+	s.policy.GetInstanceDistributor = func(*config.Config) (state.InstanceDistributor, error) {
+		return nil, errors.NotImplementedf("InstanceDistributor")
+	}
+	unit, err := s.wordpress.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	err = unit.AssignToMachine(s.machines[0])
+	c.Assert(err, jc.ErrorIsNil)
+	for i, zone := range []string{"rack1", "rack1", "rack2"} {
+		zone := zone
+		instId := instance.Id(fmt.Sprintf("manual:host-%d", i))
+		hc := &instance.HardwareCharacteristics{AvailabilityZone: &zone}
+		err = s.machines[i].SetProvisioned(instId, "fake-nonce", hc)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+
+	// The unit is assigned to the machine in the zone which does
+	// not yet hold any of the service's units.
+	unit, err = s.wordpress.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	m, err := unit.AssignToCleanMachine()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(m.Id(), gc.Equals, s.machines[2].Id())
+}
+
 func (s *InstanceDistributorSuite) TestDistributeInstancesNoPolicy(c *gc.C) {
 	s.policy.GetInstanceDistributor = func(*config.Config) (state.InstanceDistributor, error) {
 		c.Errorf("should not have been invoked")
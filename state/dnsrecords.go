@@ -0,0 +1,65 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// dnsRecordsKey is the key of the settings holding the DNS records
+// published for the environment.
+const dnsRecordsKey = "dns-records"
+
+// PublishedDNSRecords returns the addresses of each fully qualified
+// name recorded by SetPublishedDNSRecords, so that records published
+// by an earlier run of the DNS publisher can be found and deleted
+// once they are no longer wanted.
+func (st *State) PublishedDNSRecords() (map[string][]string, error) {
+	settings, err := readSettings(st, dnsRecordsKey)
+	if errors.IsNotFound(err) {
+		return map[string][]string{}, nil
+	} else if err != nil {
+		return nil, errors.Annotate(err, "cannot read published DNS records")
+	}
+	records := make(map[string][]string)
+	for name, value := range settings.Map() {
+		addrs, _ := value.(string)
+		if addrs == "" {
+			records[name] = nil
+			continue
+		}
+		records[name] = strings.Split(addrs, ",")
+	}
+	return records, nil
+}
+
+// SetPublishedDNSRecords records the addresses of each fully qualified
+// name published for the environment, replacing any recorded before.
+func (st *State) SetPublishedDNSRecords(records map[string][]string) error {
+	// Settings values must be comparable, so addresses are
+	// stored joined.
+	values := make(map[string]interface{})
+	for name, addrs := range records {
+		values[name] = strings.Join(addrs, ",")
+	}
+	settings, err := readSettings(st, dnsRecordsKey)
+	if errors.IsNotFound(err) {
+		_, err = createSettings(st, dnsRecordsKey, values)
+		return errors.Annotate(err, "cannot record published DNS records")
+	} else if err != nil {
+		return errors.Annotate(err, "cannot read published DNS records")
+	}
+	for name := range settings.Map() {
+		if _, ok := values[name]; !ok {
+			settings.Delete(name)
+		}
+	}
+	settings.Update(values)
+	if _, err := settings.Write(); err != nil {
+		return errors.Annotate(err, "cannot record published DNS records")
+	}
+	return nil
+}
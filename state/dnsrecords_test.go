@@ -0,0 +1,46 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+)
+
+type DNSRecordsSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&DNSRecordsSuite{})
+
+func (s *DNSRecordsSuite) TestPublishedDNSRecordsNoneSet(c *gc.C) {
+	records, err := s.State.PublishedDNSRecords()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(records, gc.HasLen, 0)
+}
+
+func (s *DNSRecordsSuite) TestSetPublishedDNSRecords(c *gc.C) {
+	err := s.State.SetPublishedDNSRecords(map[string][]string{
+		"wordpress-0.wordpress.env.example.com": {"10.0.0.1"},
+		"wordpress.env.example.com":             {"10.0.0.1", "10.0.0.2"},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	records, err := s.State.PublishedDNSRecords()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(records, jc.DeepEquals, map[string][]string{
+		"wordpress-0.wordpress.env.example.com": {"10.0.0.1"},
+		"wordpress.env.example.com":             {"10.0.0.1", "10.0.0.2"},
+	})
+
+	// Records are replaced, not merged.
+	err = s.State.SetPublishedDNSRecords(map[string][]string{
+		"wordpress.env.example.com": {"10.0.0.2"},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	records, err = s.State.PublishedDNSRecords()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(records, jc.DeepEquals, map[string][]string{
+		"wordpress.env.example.com": {"10.0.0.2"},
+	})
+}
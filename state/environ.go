@@ -32,6 +32,14 @@ type environmentDoc struct {
 	Life       Life
 	Owner      string `bson:"owner"`
 	ServerUUID string `bson:"server-uuid"`
+
+	// MigrationMode records whether the environment is being moved to
+	// or from another controller.
+	MigrationMode MigrationMode `bson:"migration-mode,omitempty"`
+
+	// MigrationTarget is set when the environment has been copied to
+	// another controller and its agents are being moved there.
+	MigrationTarget *migrationTargetDoc `bson:"migration-target,omitempty"`
 }
 
 // StateServerEnvironment returns the environment that was bootstrapped.
@@ -81,6 +89,23 @@ func (st *State) GetEnvironment(tag names.EnvironTag) (*Environment, error) {
 	return env, nil
 }
 
+// AllEnvironments returns all the environments hosted by the state
+// server.
+func (st *State) AllEnvironments() ([]*Environment, error) {
+	environments, closer := st.getCollection(environmentsC)
+	defer closer()
+
+	var envDocs []environmentDoc
+	if err := environments.Find(nil).Sort("name", "owner").All(&envDocs); err != nil {
+		return nil, errors.Trace(err)
+	}
+	result := make([]*Environment, len(envDocs))
+	for i, doc := range envDocs {
+		result[i] = &Environment{st: st, doc: doc}
+	}
+	return result, nil
+}
+
 // NewEnvironment creates a new environment with its own UUID and
 // prepares it for use. Environment and State instances for the new
 // environment are returned.
@@ -261,6 +286,9 @@ func (e *Environment) Destroy() (err error) {
 	if e.Life() != Alive {
 		return nil
 	}
+	if e.doc.MigrationMode != MigrationModeNone {
+		return errors.New("environment is being migrated")
+	}
 
 	if err := e.ensureDestroyable(); err != nil {
 		return errors.Trace(err)
@@ -453,6 +481,10 @@ func (e *Environment) assertAliveOp() txn.Op {
 // Environment documents from versions of Juju prior to 1.17
 // do not have the life field; if it does not exist, it should
 // be considered to have the value Alive.
+//
+// Environments being migrated between controllers are not
+// considered alive, so that they are not changed.
 var isEnvAliveDoc = bson.D{
 	{"life", bson.D{{"$in", []interface{}{Alive, nil}}}},
+	{"migration-mode", bson.D{{"$exists", false}}},
 }
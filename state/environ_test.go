@@ -147,6 +147,22 @@ func (s *EnvironSuite) TestStateServerEnvironmentAccessibleFromOtherEnvironments
 	c.Assert(env.Life(), gc.Equals, state.Alive)
 }
 
+func (s *EnvironSuite) TestAllEnvironments(c *gc.C) {
+	cfg, uuid := s.createTestEnvConfig(c)
+	_, st, err := s.State.NewEnvironment(cfg, names.NewUserTag("test@remote"))
+	c.Assert(err, jc.ErrorIsNil)
+	defer st.Close()
+
+	envs, err := st.AllEnvironments()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(envs, gc.HasLen, 2)
+	c.Assert(envs[0].Name(), gc.Equals, "testenv")
+	c.Assert(envs[0].Tag(), gc.Equals, s.envTag)
+	c.Assert(envs[1].Name(), gc.Equals, "testing")
+	c.Assert(envs[1].UUID(), gc.Equals, uuid)
+	c.Assert(envs[1].Owner(), gc.Equals, names.NewUserTag("test@remote"))
+}
+
 // createTestEnvConfig returns a new environment config and its UUID for testing.
 func (s *EnvironSuite) createTestEnvConfig(c *gc.C) (*config.Config, string) {
 	uuid, err := utils.NewUUID()
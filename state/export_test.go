@@ -32,6 +32,8 @@ const (
 	UsersC             = usersC
 	BlockDevicesC      = blockDevicesC
 	StorageInstancesC  = storageInstancesC
+	UnitHistoryC       = unitHistoryC
+	MaxUnitHistory     = maxUnitHistory
 )
 
 var (
@@ -48,6 +50,7 @@ var (
 	PickAddress            = &pickAddress
 	AddVolumeOp            = (*State).addVolumeOp
 	CombineMeterStatus     = combineMeterStatus
+	ImportBatchSize        = &importBatchSize
 )
 
 type (
@@ -76,7 +79,7 @@ func SetRetryHooks(c *gc.C, st *State, block, check func()) txntesting.Transacti
 }
 
 func newMultiEnvRunnerForHooks(st *State) jujutxn.Runner {
-	runner := newMultiEnvRunner(st.EnvironUUID(), st.db, txnAssertEnvIsAlive)
+	runner := newMultiEnvRunner(st.EnvironUUID(), st.db, txnAssertEnvIsAlive, st.traceId)
 	st.transactionRunner = runner
 	return getRawRunner(runner)
 }
@@ -122,6 +122,15 @@ type machineDoc struct {
 	// Placement is the placement directive that should be used when provisioning
 	// an instance for the machine.
 	Placement string `bson:",omitempty"`
+	// TraceId holds the trace id of the last traced API request
+	// that changed the machine.
+	TraceId string `bson:"traceid,omitempty"`
+	// MigrationHost holds the id of the machine a container is
+	// being migrated to, while the migration is in progress.
+	MigrationHost string `bson:"migrationhost,omitempty"`
+	// MigratedFrom holds the instance id of the container the
+	// machine was created from by a container migration.
+	MigratedFrom instance.Id `bson:"migratedfrom,omitempty"`
 }
 
 func newMachine(st *State, doc *machineDoc) *Machine {
@@ -232,6 +241,12 @@ func (m *Machine) Life() Life {
 	return m.doc.Life
 }
 
+// TraceId returns the trace id of the last traced API request that
+// changed the machine, if any.
+func (m *Machine) TraceId() string {
+	return m.doc.TraceId
+}
+
 // Jobs returns the responsibilities that must be fulfilled by m's agent.
 func (m *Machine) Jobs() []MachineJob {
 	return m.doc.Jobs
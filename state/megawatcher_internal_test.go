@@ -1070,9 +1070,9 @@ func (s *storeManagerStateSuite) TestChangeUnits(c *gc.C) {
 							{"udp", 54321},
 						},
 						PortRanges: []network.PortRange{
-							{5555, 5558, "tcp"},
-							{12345, 12345, "tcp"},
-							{54321, 54321, "udp"},
+							{FromPort: 5555, ToPort: 5558, Protocol: "tcp"},
+							{FromPort: 12345, ToPort: 12345, Protocol: "tcp"},
+							{FromPort: 54321, ToPort: 54321, Protocol: "udp"},
 						},
 						Status:     multiwatcher.Status("error"),
 						StatusInfo: "failure",
@@ -1096,7 +1096,7 @@ func (s *storeManagerStateSuite) TestChangeUnits(c *gc.C) {
 					Status:     multiwatcher.Status("error"),
 					StatusInfo: "another failure",
 					Ports:      []network.Port{{"udp", 17070}},
-					PortRanges: []network.PortRange{{17070, 17070, "udp"}},
+					PortRanges: []network.PortRange{{FromPort: 17070, ToPort: 17070, Protocol: "udp"}},
 				}},
 				change: watcher.Change{
 					C:  "units",
@@ -1109,7 +1109,7 @@ func (s *storeManagerStateSuite) TestChangeUnits(c *gc.C) {
 						Series:     "quantal",
 						MachineId:  "0",
 						Ports:      []network.Port{{"udp", 17070}},
-						PortRanges: []network.PortRange{{17070, 17070, "udp"}},
+						PortRanges: []network.PortRange{{FromPort: 17070, ToPort: 17070, Protocol: "udp"}},
 						Status:     multiwatcher.Status("error"),
 						StatusInfo: "another failure",
 					}}}
@@ -1143,7 +1143,7 @@ func (s *storeManagerStateSuite) TestChangeUnits(c *gc.C) {
 					&multiwatcher.UnitInfo{
 						Name:       "wordpress/0",
 						Ports:      []network.Port{{"tcp", 4242}},
-						PortRanges: []network.PortRange{{4242, 4242, "tcp"}},
+						PortRanges: []network.PortRange{{FromPort: 4242, ToPort: 4242, Protocol: "tcp"}},
 					},
 					&multiwatcher.MachineInfo{
 						Id: "0",
@@ -1180,7 +1180,7 @@ func (s *storeManagerStateSuite) TestChangeUnits(c *gc.C) {
 						MachineId:  "0",
 						Status:     "allocating",
 						Ports:      []network.Port{{"tcp", 21}, {"tcp", 22}},
-						PortRanges: []network.PortRange{{21, 22, "tcp"}},
+						PortRanges: []network.PortRange{{FromPort: 21, ToPort: 22, Protocol: "tcp"}},
 					},
 					&multiwatcher.MachineInfo{
 						Id: "0",
@@ -1219,7 +1219,7 @@ func (s *storeManagerStateSuite) TestChangeUnits(c *gc.C) {
 						PrivateAddress: "private",
 						MachineId:      "0",
 						Ports:          []network.Port{{"tcp", 12345}},
-						PortRanges:     []network.PortRange{{12345, 12345, "tcp"}},
+						PortRanges:     []network.PortRange{{FromPort: 12345, ToPort: 12345, Protocol: "tcp"}},
 						Status:         multiwatcher.Status("error"),
 						StatusInfo:     "failure",
 					}}}
@@ -1395,7 +1395,7 @@ func (s *storeManagerStateSuite) TestChangeUnitsNonNilPorts(c *gc.C) {
 						PublicAddress:  "1.2.3.4",
 						PrivateAddress: "4.3.2.1",
 						Ports:          []network.Port{{"tcp", 12345}},
-						PortRanges:     []network.PortRange{{12345, 12345, "tcp"}},
+						PortRanges:     []network.PortRange{{FromPort: 12345, ToPort: 12345, Protocol: "tcp"}},
 						Status:         "allocating",
 					}}}
 		},
@@ -1479,7 +1479,7 @@ func (s *storeManagerStateSuite) TestClosingPorts(c *gc.C) {
 			PublicAddress:  "1.2.3.4",
 			PrivateAddress: "4.3.2.1",
 			Ports:          []network.Port{{"tcp", 12345}},
-			PortRanges:     []network.PortRange{{12345, 12345, "tcp"}},
+			PortRanges:     []network.PortRange{{FromPort: 12345, ToPort: 12345, Protocol: "tcp"}},
 			Status:         "allocating",
 		},
 	})
@@ -0,0 +1,660 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"github.com/juju/utils/set"
+	"gopkg.in/juju/charm.v5-unstable"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/state/storage"
+)
+
+// MigrationMode describes the part an environment plays in its
+// migration between controllers.
+type MigrationMode string
+
+const (
+	// MigrationModeNone is the mode of environments which are not
+	// being migrated.
+	MigrationModeNone MigrationMode = ""
+
+	// MigrationModeMigrating is the mode of an environment being
+	// moved to another controller. Its workers are stopped, and
+	// changes to it are blocked, so that it may be exported
+	// consistently.
+	MigrationModeMigrating MigrationMode = "migrating"
+
+	// MigrationModeImporting is the mode of an environment imported
+	// from another controller whose agents have yet to move. Its
+	// workers are not run until it is activated.
+	MigrationModeImporting MigrationMode = "importing"
+)
+
+// MigrationMode returns whether the environment is being moved to or
+// from another controller.
+func (e *Environment) MigrationMode() MigrationMode {
+	return e.doc.MigrationMode
+}
+
+// EnvironmentDocs holds the documents of an environment, exported
+// from one controller so that they may be imported into another.
+type EnvironmentDocs struct {
+	// EnvUUID is the UUID of the exported environment.
+	EnvUUID string
+
+	// Environment holds the environment's own document.
+	Environment bson.M
+
+	// Collections holds the environment's documents in each of the
+	// environment-scoped collections, keyed by collection name.
+	Collections map[string][]bson.M
+}
+
+// CharmURLs returns the URLs of the exported charms whose archives
+// must be copied to the importing controller with ImportCharmArchive.
+func (docs *EnvironmentDocs) CharmURLs() []string {
+	var urls []string
+	for _, doc := range docs.Collections[charmsC] {
+		if path, _ := doc["storagepath"].(string); path == "" {
+			continue
+		}
+		if url, _ := doc["url"].(string); url != "" {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// BeginMigration puts the environment into MigrationModeMigrating, so
+// that its workers stop and changes to it are blocked while it is
+// exported to another controller.
+func (e *Environment) BeginMigration() (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot migrate environment %q", e.Name())
+	if e.UUID() == e.ServerUUID() {
+		return errors.New("cannot migrate the state server environment")
+	}
+	ops := []txn.Op{{
+		C:      environmentsC,
+		Id:     e.UUID(),
+		Assert: isEnvAliveDoc,
+		Update: bson.D{{"$set", bson.D{{"migration-mode", MigrationModeMigrating}}}},
+	}}
+	if err := e.st.runTransaction(ops); err == txn.ErrAborted {
+		if err := e.Refresh(); err != nil {
+			return errors.Trace(err)
+		}
+		if e.doc.MigrationMode != MigrationModeNone {
+			return errors.New("environment is already being migrated")
+		}
+		return errors.New("environment is no longer alive")
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	e.doc.MigrationMode = MigrationModeMigrating
+	return nil
+}
+
+// ExportEnvironmentDocs returns all the documents of the environment,
+// so that it may be imported into another controller with
+// ImportEnvironmentDocs. The environment must first have been put into
+// MigrationModeMigrating with BeginMigration; otherwise the export may
+// only be used to validate an import. The state server environment
+// cannot be exported.
+func (st *State) ExportEnvironmentDocs() (*EnvironmentDocs, error) {
+	env, err := st.Environment()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if env.UUID() == env.ServerUUID() {
+		return nil, errors.New("cannot export the state server environment")
+	}
+	if env.Life() != Alive {
+		return nil, errors.Errorf("environment %q is not alive", env.Name())
+	}
+	docs := &EnvironmentDocs{
+		EnvUUID:     env.UUID(),
+		Collections: make(map[string][]bson.M),
+	}
+	environments, closer := st.getCollection(environmentsC)
+	defer closer()
+	if err := environments.FindId(env.UUID()).One(&docs.Environment); err != nil {
+		return nil, errors.Annotate(err, "cannot read environment document")
+	}
+	stripTxnFields(docs.Environment)
+
+	for collName := range multiEnvCollections {
+		coll, closer := st.getCollection(collName)
+		var collDocs []bson.M
+		err := coll.Find(nil).All(&collDocs)
+		closer()
+		if err != nil {
+			return nil, errors.Annotatef(err, "cannot read %s documents", collName)
+		}
+		for _, doc := range collDocs {
+			stripTxnFields(doc)
+		}
+		if len(collDocs) > 0 {
+			docs.Collections[collName] = collDocs
+		}
+	}
+	return docs, nil
+}
+
+// stripTxnFields removes the fields maintained by the transaction
+// runner from a raw document, so that it may be inserted afresh.
+func stripTxnFields(doc bson.M) {
+	delete(doc, "txn-revno")
+	delete(doc, "txn-queue")
+}
+
+// ValidateEnvironmentImport checks that the exported environment can
+// be imported into the controller, without changing anything.
+func (st *State) ValidateEnvironmentImport(docs *EnvironmentDocs) error {
+	_, _, err := st.environmentImportOps(docs)
+	return errors.Trace(err)
+}
+
+// importBatchSize holds the number of documents inserted by each
+// transaction when importing an environment.
+var importBatchSize = 100
+
+// ImportEnvironmentDocs recreates an environment exported from another
+// controller with ExportEnvironmentDocs, and returns a State for it.
+// The settings describing the hosting controller are replaced with
+// those of this controller's environment.
+//
+// The environment is imported in MigrationModeImporting, so that it is
+// not used until ActivateImportedEnvironment is called, and its
+// documents are inserted in batches. If any batch cannot be inserted,
+// the partially imported environment is removed. The archives of the
+// environment's charms must then be stored with ImportCharmArchive.
+func (st *State) ImportEnvironmentDocs(docs *EnvironmentDocs) (_ *Environment, _ *State, err error) {
+	if mode, _ := docs.Environment["migration-mode"].(string); MigrationMode(mode) != MigrationModeMigrating {
+		return nil, nil, errors.Errorf("environment %q was not exported for migration", docs.EnvUUID)
+	}
+	envOps, docOps, err := st.environmentImportOps(docs)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	newSt, err := st.ForEnviron(names.NewEnvironTag(docs.EnvUUID))
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	if err := st.runRawTransaction(envOps); err != nil {
+		newSt.Close()
+		if err == txn.ErrAborted {
+			return nil, nil, errors.AlreadyExistsf("environment %q", docs.EnvUUID)
+		}
+		return nil, nil, errors.Trace(err)
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		if removeErr := newSt.RemoveAllEnvironDocs(); removeErr != nil {
+			logger.Errorf("cannot remove partially imported environment %q: %v", docs.EnvUUID, removeErr)
+		}
+		newSt.Close()
+	}()
+
+	for len(docOps) > 0 {
+		n := importBatchSize
+		if n > len(docOps) {
+			n = len(docOps)
+		}
+		if err := st.runRawTransaction(docOps[:n]); err == txn.ErrAborted {
+			return nil, nil, errors.Errorf("some documents of environment %q already exist", docs.EnvUUID)
+		} else if err != nil {
+			return nil, nil, errors.Annotate(err, "cannot import environment documents")
+		}
+		docOps = docOps[n:]
+	}
+	env, err := newSt.Environment()
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return env, newSt, nil
+}
+
+// environmentImportOps validates the exported environment and returns
+// the operations needed to import it: those creating the environment
+// itself, and those inserting its documents.
+func (st *State) environmentImportOps(docs *EnvironmentDocs) (envOps, docOps []txn.Op, err error) {
+	if !names.IsValidEnvironment(docs.EnvUUID) {
+		return nil, nil, errors.NotValidf("environment UUID %q", docs.EnvUUID)
+	}
+	if _, err := st.GetEnvironment(names.NewEnvironTag(docs.EnvUUID)); err == nil {
+		return nil, nil, errors.AlreadyExistsf("environment %q", docs.EnvUUID)
+	} else if !errors.IsNotFound(err) {
+		return nil, nil, errors.Trace(err)
+	}
+	var envDoc environmentDoc
+	if err := remarshal(docs.Environment, &envDoc); err != nil {
+		return nil, nil, errors.Annotate(err, "invalid environment document")
+	}
+	if envDoc.UUID != docs.EnvUUID {
+		return nil, nil, errors.Errorf("environment document has UUID %q, expected %q", envDoc.UUID, docs.EnvUUID)
+	}
+	if !names.IsValidUser(envDoc.Owner) {
+		return nil, nil, errors.NotValidf("environment owner %q", envDoc.Owner)
+	}
+	owner := names.NewUserTag(envDoc.Owner)
+	userEnvNames, closer := st.getRawCollection(userenvnameC)
+	defer closer()
+	n, err := userEnvNames.FindId(userEnvNameIndex(owner.Username(), envDoc.Name)).Count()
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	} else if n > 0 {
+		return nil, nil, errors.AlreadyExistsf("environment %q for %s", envDoc.Name, owner.Username())
+	}
+	serverEnv, err := st.StateServerEnvironment()
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	serverCfg, err := serverEnv.Config()
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+
+	// All the environment's local users must already be known to
+	// this controller.
+	missing := set.NewStrings()
+	users := append([]bson.M{{"user": envDoc.Owner}}, docs.Collections[envUsersC]...)
+	for _, doc := range users {
+		name, _ := doc["user"].(string)
+		if !names.IsValidUser(name) {
+			return nil, nil, errors.NotValidf("environment user %q", name)
+		}
+		tag := names.NewUserTag(name)
+		if !tag.IsLocal() {
+			continue
+		}
+		if _, err := st.User(tag); errors.IsNotFound(err) {
+			missing.Add(tag.Username())
+		} else if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+	}
+	if !missing.IsEmpty() {
+		return nil, nil, errors.Errorf("users not known to this controller: %s", strings.Join(missing.SortedValues(), ", "))
+	}
+
+	envDoc.Life = Alive
+	envDoc.ServerUUID = serverEnv.UUID()
+	envDoc.MigrationMode = MigrationModeImporting
+	envDoc.MigrationTarget = nil
+	envOps = []txn.Op{{
+		C:      environmentsC,
+		Id:     envDoc.UUID,
+		Assert: txn.DocMissing,
+		Insert: &envDoc,
+	}, createUniqueOwnerEnvNameOp(owner, envDoc.Name)}
+
+	settingsID := docs.EnvUUID + ":" + environGlobalKey
+	prefix := docs.EnvUUID + ":"
+	for collName, collDocs := range docs.Collections {
+		if !multiEnvCollections.Contains(collName) {
+			return nil, nil, errors.Errorf("unknown collection %q", collName)
+		}
+		for _, doc := range collDocs {
+			if doc["env-uuid"] != docs.EnvUUID {
+				return nil, nil, errors.Errorf("%s document %v belongs to another environment", collName, doc["_id"])
+			}
+			// Most documents' ids are prefixed with the environment
+			// UUID; some are generated object ids.
+			id := doc["_id"]
+			switch id := id.(type) {
+			case string:
+				if !strings.HasPrefix(id, prefix) {
+					return nil, nil, errors.Errorf("%s document %q has an invalid id", collName, id)
+				}
+			case bson.ObjectId:
+			default:
+				return nil, nil, errors.Errorf("%s document %v has an invalid id", collName, id)
+			}
+			if collName == settingsC && id == settingsID {
+				doc = copyDoc(doc)
+				// Settings describing the state server are
+				// taken from the importing controller.
+				for _, attr := range config.StateServerAttributes {
+					if value, ok := serverCfg.AllAttrs()[attr]; ok {
+						doc[attr] = value
+					}
+				}
+			}
+			stripTxnFields(doc)
+			docOps = append(docOps, txn.Op{
+				C:      collName,
+				Id:     id,
+				Assert: txn.DocMissing,
+				Insert: doc,
+			})
+		}
+	}
+	return envOps, docOps, nil
+}
+
+// ImportCharmArchive stores the archive of a charm imported, with its
+// environment, from another controller. The archive must match the
+// SHA256 hash recorded for the charm, and the environment must not yet
+// have been activated.
+func (st *State) ImportCharmArchive(curl *charm.URL, archive io.Reader, size int64) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot import archive of charm %q", curl)
+	env, err := st.Environment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if env.MigrationMode() != MigrationModeImporting {
+		return errors.Errorf("environment %q is not being imported", env.Name())
+	}
+	ch, err := st.Charm(curl)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	path := ch.StoragePath()
+	if path == "" {
+		return errors.New("charm has no archive")
+	}
+	hash := sha256.New()
+	stor := storage.NewStorage(st.EnvironUUID(), st.MongoSession())
+	if err := stor.Put(path, io.TeeReader(archive, hash), size); err != nil {
+		return errors.Trace(err)
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != ch.BundleSha256() {
+		if err := stor.Remove(path); err != nil {
+			logger.Warningf("cannot remove archive of charm %q: %v", curl, err)
+		}
+		return errors.Errorf("archive has SHA256 hash %s, expected %s", sum, ch.BundleSha256())
+	}
+	return nil
+}
+
+// ActivateImportedEnvironment takes an environment imported from
+// another controller out of MigrationModeImporting, once its agents
+// have moved to this controller, so that its workers are started.
+func (st *State) ActivateImportedEnvironment() error {
+	env, err := st.Environment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ops := []txn.Op{{
+		C:      environmentsC,
+		Id:     env.UUID(),
+		Assert: bson.D{{"migration-mode", MigrationModeImporting}},
+		Update: bson.D{{"$unset", bson.D{{"migration-mode", nil}}}},
+	}}
+	if err := st.runTransaction(ops); err == txn.ErrAborted {
+		return errors.Errorf("environment %q is not being imported", env.Name())
+	} else if err != nil {
+		return errors.Annotatef(err, "cannot activate environment %q", env.Name())
+	}
+	return nil
+}
+
+// RemoveImportedEnvironment removes an environment imported from
+// another controller, along with its charm archives, when its
+// migration is aborted. It fails once the environment has been
+// activated.
+func (st *State) RemoveImportedEnvironment() error {
+	env, err := st.Environment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if env.MigrationMode() != MigrationModeImporting {
+		return errors.Errorf("environment %q is not being imported", env.Name())
+	}
+	if err := st.removeCharmArchives(); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(st.RemoveAllEnvironDocs())
+}
+
+// removeCharmArchives removes the stored archives of the environment's
+// charms, ignoring any which were never stored.
+func (st *State) removeCharmArchives() error {
+	charms, closer := st.getCollection(charmsC)
+	defer closer()
+	var charmDocs []charmDoc
+	if err := charms.Find(nil).Select(bson.D{{"storagepath", 1}}).All(&charmDocs); err != nil {
+		return errors.Annotate(err, "cannot read charms")
+	}
+	stor := storage.NewStorage(st.EnvironUUID(), st.MongoSession())
+	for _, doc := range charmDocs {
+		if doc.StoragePath == "" {
+			continue
+		}
+		if err := stor.Remove(doc.StoragePath); err != nil && !errors.IsNotFound(err) {
+			return errors.Annotatef(err, "cannot remove charm archive %q", doc.StoragePath)
+		}
+	}
+	return nil
+}
+
+// remarshal converts a raw document into the given document type.
+func remarshal(in bson.M, out interface{}) error {
+	data, err := bson.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(data, out)
+}
+
+func copyDoc(in bson.M) bson.M {
+	out := make(bson.M, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
+// MigrationTarget describes the controller to which an environment's
+// agents are being moved.
+type MigrationTarget struct {
+	// APIAddrs holds the addresses of the target controller's API
+	// servers.
+	APIAddrs []string
+
+	// CACert holds the target controller's CA certificate.
+	CACert string
+}
+
+type migrationTargetDoc struct {
+	APIAddrs []string `bson:"api-addrs"`
+	CACert   string   `bson:"ca-cert"`
+
+	// Acked holds the tags of the agents which have been told of
+	// the target controller.
+	Acked []string `bson:"acked,omitempty"`
+}
+
+// MigrationTarget returns the controller to which the environment's
+// agents are being moved, and whether the environment is being
+// migrated at all.
+func (e *Environment) MigrationTarget() (MigrationTarget, bool) {
+	if e.doc.MigrationTarget == nil {
+		return MigrationTarget{}, false
+	}
+	return MigrationTarget{
+		APIAddrs: e.doc.MigrationTarget.APIAddrs,
+		CACert:   e.doc.MigrationTarget.CACert,
+	}, true
+}
+
+// MigrationAcks returns the tags of the agents which have acknowledged
+// the environment's migration target.
+func (e *Environment) MigrationAcks() []string {
+	if e.doc.MigrationTarget == nil {
+		return nil
+	}
+	return e.doc.MigrationTarget.Acked
+}
+
+// SetMigrationTarget records that the environment has been imported
+// into another controller, so that its agents move there. The
+// environment must be in MigrationModeMigrating.
+func (e *Environment) SetMigrationTarget(target MigrationTarget) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot set migration target for environment %q", e.Name())
+	if len(target.APIAddrs) == 0 {
+		return errors.New("no API addresses specified")
+	}
+	if target.CACert == "" {
+		return errors.New("no CA certificate specified")
+	}
+	doc := &migrationTargetDoc{
+		APIAddrs: target.APIAddrs,
+		CACert:   target.CACert,
+	}
+	ops := []txn.Op{{
+		C:  environmentsC,
+		Id: e.UUID(),
+		Assert: bson.D{
+			{"migration-mode", MigrationModeMigrating},
+			{"migration-target", bson.D{{"$exists", false}}},
+		},
+		Update: bson.D{{"$set", bson.D{{"migration-target", doc}}}},
+	}}
+	if err := e.st.runTransaction(ops); err == txn.ErrAborted {
+		if err := e.Refresh(); err != nil {
+			return errors.Trace(err)
+		}
+		if e.doc.MigrationMode != MigrationModeMigrating {
+			return errors.New("environment is not being migrated")
+		}
+		return errors.New("migration target already set")
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	e.doc.MigrationTarget = doc
+	return nil
+}
+
+// AckMigration records that the agent with the given tag has been
+// told of the environment's migration target. Once any agent has
+// acknowledged the target, the migration can no longer be aborted.
+func (e *Environment) AckMigration(tag names.Tag) error {
+	ops := []txn.Op{{
+		C:      environmentsC,
+		Id:     e.UUID(),
+		Assert: bson.D{{"migration-target", bson.D{{"$exists", true}}}},
+		Update: bson.D{{"$addToSet", bson.D{{"migration-target.acked", tag.String()}}}},
+	}}
+	if err := e.st.runTransaction(ops); err == txn.ErrAborted {
+		return errors.Errorf("environment %q is not being migrated", e.Name())
+	} else if err != nil {
+		return errors.Annotatef(err, "cannot acknowledge migration of environment %q", e.Name())
+	}
+	return nil
+}
+
+// AbortMigration takes the environment out of MigrationModeMigrating
+// and clears its migration target, so that it is managed by this
+// controller again. It fails once any of the environment's agents
+// have acknowledged the migration target.
+func (e *Environment) AbortMigration() (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot abort migration of environment %q", e.Name())
+	ops := []txn.Op{{
+		C:  environmentsC,
+		Id: e.UUID(),
+		Assert: bson.D{
+			{"migration-mode", MigrationModeMigrating},
+			{"migration-target.acked.0", bson.D{{"$exists", false}}},
+		},
+		Update: bson.D{{"$unset", bson.D{
+			{"migration-mode", nil},
+			{"migration-target", nil},
+		}}},
+	}}
+	if err := e.st.runTransaction(ops); err == txn.ErrAborted {
+		if err := e.Refresh(); err != nil {
+			return errors.Trace(err)
+		}
+		if e.doc.MigrationMode != MigrationModeMigrating {
+			return errors.New("environment is not being migrated")
+		}
+		return errors.Errorf("agents have already moved: %s", strings.Join(e.MigrationAcks(), ", "))
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	e.doc.MigrationMode = MigrationModeNone
+	e.doc.MigrationTarget = nil
+	return nil
+}
+
+// PendingMigrationAgents returns the tags of the environment's machine
+// and unit agents which have not yet acknowledged its migration
+// target.
+func (st *State) PendingMigrationAgents() ([]names.Tag, error) {
+	env, err := st.Environment()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	acked := make(map[string]bool)
+	for _, tag := range env.MigrationAcks() {
+		acked[tag] = true
+	}
+	var pending []names.Tag
+	machines, err := st.AllMachines()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, m := range machines {
+		if m.Life() == Alive && !acked[m.Tag().String()] {
+			pending = append(pending, m.Tag())
+		}
+	}
+	units, closer := st.getCollection(unitsC)
+	defer closer()
+	var unitDocs []struct {
+		Name string `bson:"name"`
+		Life Life   `bson:"life"`
+	}
+	if err := units.Find(nil).All(&unitDocs); err != nil {
+		return nil, errors.Annotate(err, "cannot read units")
+	}
+	for _, doc := range unitDocs {
+		tag := names.NewUnitTag(doc.Name)
+		if doc.Life == Alive && !acked[tag.String()] {
+			pending = append(pending, tag)
+		}
+	}
+	return pending, nil
+}
+
+// RemoveMigratedEnvironment removes all of the environment's documents
+// and charm archives once all of its agents have moved to the
+// controller into which it has been imported.
+func (st *State) RemoveMigratedEnvironment() error {
+	env, err := st.Environment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if _, ok := env.MigrationTarget(); !ok {
+		return errors.Errorf("environment %q is not being migrated", env.Name())
+	}
+	pending, err := st.PendingMigrationAgents()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(pending) > 0 {
+		tags := make([]string, len(pending))
+		for i, tag := range pending {
+			tags[i] = tag.String()
+		}
+		return errors.Errorf("agents have yet to move: %s", strings.Join(tags, ", "))
+	}
+	if err := st.removeCharmArchives(); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(st.RemoveAllEnvironDocs())
+}
@@ -0,0 +1,335 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/juju/charm.v5-unstable"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/storage"
+	"github.com/juju/juju/testcharms"
+	"github.com/juju/juju/testing/factory"
+)
+
+type MigrationSuite struct {
+	ConnSuite
+	envState *state.State
+	charm    *state.Charm
+}
+
+var _ = gc.Suite(&MigrationSuite{})
+
+var charmArchive = []byte("charm archive")
+
+func (s *MigrationSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.envState = s.factory.MakeEnvironment(c, &factory.EnvParams{Name: "hosted"})
+	s.AddCleanup(func(*gc.C) { s.envState.Close() })
+
+	hash := sha256.Sum256(charmArchive)
+	ch, err := s.envState.AddCharm(
+		testcharms.Repo.CharmDir("mysql"),
+		charm.MustParseURL("cs:quantal/mysql-1"),
+		"charms/mysql-1",
+		hex.EncodeToString(hash[:]),
+	)
+	c.Assert(err, jc.ErrorIsNil)
+	s.charm = ch
+	stor := storage.NewStorage(s.envState.EnvironUUID(), s.envState.MongoSession())
+	err = stor.Put(ch.StoragePath(), bytes.NewReader(charmArchive), int64(len(charmArchive)))
+	c.Assert(err, jc.ErrorIsNil)
+
+	f := factory.NewFactory(s.envState)
+	svc := f.MakeService(c, &factory.ServiceParams{Charm: ch})
+	f.MakeUnit(c, &factory.UnitParams{Service: svc})
+}
+
+func (s *MigrationSuite) TestBeginMigration(c *gc.C) {
+	env, err := s.envState.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	err = env.BeginMigration()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env.MigrationMode(), gc.Equals, state.MigrationModeMigrating)
+	c.Assert(env.Life(), gc.Equals, state.Alive)
+
+	err = env.BeginMigration()
+	c.Assert(err, gc.ErrorMatches, `cannot migrate environment "hosted": environment is already being migrated`)
+}
+
+func (s *MigrationSuite) TestBeginMigrationStateServer(c *gc.C) {
+	env, err := s.State.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	err = env.BeginMigration()
+	c.Assert(err, gc.ErrorMatches, ".*: cannot migrate the state server environment")
+}
+
+func (s *MigrationSuite) TestBeginMigrationBlocksChanges(c *gc.C) {
+	s.beginMigration(c)
+	f := factory.NewFactory(s.envState)
+	_, err := s.envState.AddService("wordpress", f.MakeUser(c, nil).Tag().String(), s.charm, nil, nil)
+	c.Assert(err, gc.ErrorMatches, `cannot add service "wordpress": environment is being migrated`)
+	_, err = s.envState.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.ErrorMatches, "cannot add a new machine: environment is being migrated")
+}
+
+func (s *MigrationSuite) TestExportStateServerEnvironment(c *gc.C) {
+	_, err := s.State.ExportEnvironmentDocs()
+	c.Assert(err, gc.ErrorMatches, "cannot export the state server environment")
+}
+
+func (s *MigrationSuite) TestExport(c *gc.C) {
+	docs, err := s.envState.ExportEnvironmentDocs()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(docs.EnvUUID, gc.Equals, s.envState.EnvironUUID())
+	c.Assert(docs.Environment["name"], gc.Equals, "hosted")
+	c.Assert(docs.Collections["services"], gc.HasLen, 1)
+	c.Assert(docs.Collections["units"], gc.HasLen, 1)
+	for _, doc := range docs.Collections["services"] {
+		c.Assert(doc["env-uuid"], gc.Equals, docs.EnvUUID)
+		_, ok := doc["txn-revno"]
+		c.Assert(ok, jc.IsFalse)
+	}
+	c.Assert(docs.CharmURLs(), jc.DeepEquals, []string{"cs:quantal/mysql-1"})
+}
+
+func (s *MigrationSuite) TestValidateExistingEnvironment(c *gc.C) {
+	docs, err := s.envState.ExportEnvironmentDocs()
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.State.ValidateEnvironmentImport(docs)
+	c.Assert(err, gc.ErrorMatches, `environment ".*" already exists`)
+}
+
+func (s *MigrationSuite) TestValidateUnknownUser(c *gc.C) {
+	docs := s.exportAndRemoveSource(c)
+	docs.Collections["envusers"] = append(docs.Collections["envusers"], map[string]interface{}{
+		"_id":      docs.EnvUUID + ":bob@local",
+		"env-uuid": docs.EnvUUID,
+		"user":     "bob@local",
+	})
+	err := s.State.ValidateEnvironmentImport(docs)
+	c.Assert(err, gc.ErrorMatches, "users not known to this controller: bob@local")
+}
+
+func (s *MigrationSuite) TestValidateForeignDocument(c *gc.C) {
+	docs := s.exportAndRemoveSource(c)
+	docs.Collections["services"][0]["env-uuid"] = s.State.EnvironUUID()
+	err := s.State.ValidateEnvironmentImport(docs)
+	c.Assert(err, gc.ErrorMatches, "services document .* belongs to another environment")
+}
+
+func (s *MigrationSuite) TestImportRequiresMigratingExport(c *gc.C) {
+	docs, err := s.envState.ExportEnvironmentDocs()
+	c.Assert(err, jc.ErrorIsNil)
+	_, _, err = s.State.ImportEnvironmentDocs(docs)
+	c.Assert(err, gc.ErrorMatches, `environment ".*" was not exported for migration`)
+}
+
+func (s *MigrationSuite) TestImport(c *gc.C) {
+	s.PatchValue(state.ImportBatchSize, 2)
+	docs := s.exportAndRemoveSource(c)
+
+	err := s.State.ValidateEnvironmentImport(docs)
+	c.Assert(err, jc.ErrorIsNil)
+	env, st, err := s.State.ImportEnvironmentDocs(docs)
+	c.Assert(err, jc.ErrorIsNil)
+	defer st.Close()
+
+	c.Assert(env.UUID(), gc.Equals, docs.EnvUUID)
+	c.Assert(env.Name(), gc.Equals, "hosted")
+	c.Assert(env.Life(), gc.Equals, state.Alive)
+	c.Assert(env.MigrationMode(), gc.Equals, state.MigrationModeImporting)
+	_, migrating := env.MigrationTarget()
+	c.Assert(migrating, jc.IsFalse)
+	services, err := st.AllServices()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(services, gc.HasLen, 1)
+	units, err := services[0].AllUnits()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(units, gc.HasLen, 1)
+
+	err = st.ImportCharmArchive(s.charm.URL(), bytes.NewReader(charmArchive), int64(len(charmArchive)))
+	c.Assert(err, jc.ErrorIsNil)
+	err = st.ActivateImportedEnvironment()
+	c.Assert(err, jc.ErrorIsNil)
+	err = env.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env.MigrationMode(), gc.Equals, state.MigrationModeNone)
+
+	err = st.ActivateImportedEnvironment()
+	c.Assert(err, gc.ErrorMatches, `environment "hosted" is not being imported`)
+	err = st.ImportCharmArchive(s.charm.URL(), bytes.NewReader(charmArchive), int64(len(charmArchive)))
+	c.Assert(err, gc.ErrorMatches, `cannot import archive of charm ".*": environment "hosted" is not being imported`)
+}
+
+func (s *MigrationSuite) TestImportCharmArchiveHashMismatch(c *gc.C) {
+	st := s.importEnvironment(c)
+	data := []byte("not the charm archive")
+	err := st.ImportCharmArchive(s.charm.URL(), bytes.NewReader(data), int64(len(data)))
+	c.Assert(err, gc.ErrorMatches, `cannot import archive of charm ".*": archive has SHA256 hash .*, expected .*`)
+
+	stor := storage.NewStorage(st.EnvironUUID(), st.MongoSession())
+	_, _, err = stor.Get(s.charm.StoragePath())
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *MigrationSuite) TestRemoveImportedEnvironment(c *gc.C) {
+	st := s.importEnvironment(c)
+	err := st.RemoveImportedEnvironment()
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.GetEnvironment(names.NewEnvironTag(st.EnvironUUID()))
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *MigrationSuite) TestRemoveImportedEnvironmentActivated(c *gc.C) {
+	st := s.importEnvironment(c)
+	err := st.ActivateImportedEnvironment()
+	c.Assert(err, jc.ErrorIsNil)
+	err = st.RemoveImportedEnvironment()
+	c.Assert(err, gc.ErrorMatches, `environment "hosted" is not being imported`)
+}
+
+func (s *MigrationSuite) TestSetMigrationTarget(c *gc.C) {
+	env, err := s.envState.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	target := state.MigrationTarget{
+		APIAddrs: []string{"10.0.0.1:17070"},
+		CACert:   "cert",
+	}
+	err = env.SetMigrationTarget(target)
+	c.Assert(err, gc.ErrorMatches, `.*: environment is not being migrated`)
+
+	err = env.BeginMigration()
+	c.Assert(err, jc.ErrorIsNil)
+	err = env.SetMigrationTarget(target)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env.Life(), gc.Equals, state.Alive)
+
+	err = env.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	got, ok := env.MigrationTarget()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(got, jc.DeepEquals, target)
+
+	err = env.SetMigrationTarget(target)
+	c.Assert(err, gc.ErrorMatches, `.*: migration target already set`)
+}
+
+func (s *MigrationSuite) TestAckMigration(c *gc.C) {
+	env, err := s.envState.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	err = env.AckMigration(names.NewMachineTag("0"))
+	c.Assert(err, gc.ErrorMatches, `environment "hosted" is not being migrated`)
+
+	env = s.setMigrationTarget(c)
+	pending, err := s.envState.PendingMigrationAgents()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(pending, gc.HasLen, 2)
+
+	err = env.AckMigration(names.NewMachineTag("0"))
+	c.Assert(err, jc.ErrorIsNil)
+	pending, err = s.envState.PendingMigrationAgents()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(pending, jc.DeepEquals, []names.Tag{names.NewUnitTag("mysql/0")})
+}
+
+func (s *MigrationSuite) TestAbortMigration(c *gc.C) {
+	env, err := s.envState.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	err = env.AbortMigration()
+	c.Assert(err, gc.ErrorMatches, `cannot abort migration of environment "hosted": environment is not being migrated`)
+
+	env = s.setMigrationTarget(c)
+	err = env.AbortMigration()
+	c.Assert(err, jc.ErrorIsNil)
+	err = env.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env.MigrationMode(), gc.Equals, state.MigrationModeNone)
+	_, migrating := env.MigrationTarget()
+	c.Assert(migrating, jc.IsFalse)
+}
+
+func (s *MigrationSuite) TestAbortMigrationAfterAck(c *gc.C) {
+	env := s.setMigrationTarget(c)
+	err := env.AckMigration(names.NewMachineTag("0"))
+	c.Assert(err, jc.ErrorIsNil)
+	err = env.AbortMigration()
+	c.Assert(err, gc.ErrorMatches, `cannot abort migration of environment "hosted": agents have already moved: machine-0`)
+}
+
+func (s *MigrationSuite) TestRemoveMigratedEnvironment(c *gc.C) {
+	err := s.envState.RemoveMigratedEnvironment()
+	c.Assert(err, gc.ErrorMatches, `environment "hosted" is not being migrated`)
+	env := s.setMigrationTarget(c)
+	err = s.envState.RemoveMigratedEnvironment()
+	c.Assert(err, gc.ErrorMatches, "agents have yet to move: machine-0, unit-mysql-0")
+
+	s.ackAll(c, env)
+	err = s.envState.RemoveMigratedEnvironment()
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.GetEnvironment(names.NewEnvironTag(s.envState.EnvironUUID()))
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *MigrationSuite) beginMigration(c *gc.C) *state.Environment {
+	env, err := s.envState.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	err = env.BeginMigration()
+	c.Assert(err, jc.ErrorIsNil)
+	return env
+}
+
+func (s *MigrationSuite) setMigrationTarget(c *gc.C) *state.Environment {
+	env := s.beginMigration(c)
+	err := env.SetMigrationTarget(state.MigrationTarget{
+		APIAddrs: []string{"10.0.0.1:17070"},
+		CACert:   "cert",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	return env
+}
+
+func (s *MigrationSuite) ackAll(c *gc.C, env *state.Environment) {
+	pending, err := s.envState.PendingMigrationAgents()
+	c.Assert(err, jc.ErrorIsNil)
+	for _, tag := range pending {
+		err := env.AckMigration(tag)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+}
+
+// exportAndRemoveSource exports the hosted environment for migration
+// and then migrates it away, so that it may be imported back into the
+// same controller.
+func (s *MigrationSuite) exportAndRemoveSource(c *gc.C) *state.EnvironmentDocs {
+	env := s.beginMigration(c)
+	docs, err := s.envState.ExportEnvironmentDocs()
+	c.Assert(err, jc.ErrorIsNil)
+	err = env.SetMigrationTarget(state.MigrationTarget{
+		APIAddrs: []string{"10.0.0.1:17070"},
+		CACert:   "cert",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	s.ackAll(c, env)
+	err = s.envState.RemoveMigratedEnvironment()
+	c.Assert(err, jc.ErrorIsNil)
+	return docs
+}
+
+// importEnvironment migrates the hosted environment back into the same
+// controller, and returns a State for the imported environment.
+func (s *MigrationSuite) importEnvironment(c *gc.C) *state.State {
+	docs := s.exportAndRemoveSource(c)
+	_, st, err := s.State.ImportEnvironmentDocs(docs)
+	c.Assert(err, jc.ErrorIsNil)
+	s.AddCleanup(func(*gc.C) { st.Close() })
+	return st
+}
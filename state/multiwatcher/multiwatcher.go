@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"gopkg.in/juju/charm.v5-unstable"
+	"gopkg.in/mgo.v2/bson"
 
 	"github.com/juju/juju/constraints"
 	"github.com/juju/juju/instance"
@@ -86,23 +87,76 @@ func (d *Delta) UnmarshalJSON(data []byte) error {
 	} else if operation != "change" {
 		return fmt.Errorf("Unexpected operation %q", operation)
 	}
+	entity, err := newEntityInfo(entityKind)
+	if err != nil {
+		return err
+	}
+	d.Entity = entity
+	return json.Unmarshal(elements[2], &d.Entity)
+}
+
+// GetBSON implements bson.Getter, encoding the delta in the
+// same [kind, operation, entity] form that is used for JSON.
+func (d Delta) GetBSON() (interface{}, error) {
+	c := "change"
+	if d.Removed {
+		c = "remove"
+	}
+	return []interface{}{d.Entity.EntityId().Kind, c, d.Entity}, nil
+}
+
+// SetBSON implements bson.Setter.
+func (d *Delta) SetBSON(raw bson.Raw) error {
+	var elements []bson.Raw
+	if err := raw.Unmarshal(&elements); err != nil {
+		return err
+	}
+	if len(elements) != 3 {
+		return fmt.Errorf(
+			"Expected 3 elements in top-level of BSON but got %d",
+			len(elements))
+	}
+	var entityKind, operation string
+	if err := elements[0].Unmarshal(&entityKind); err != nil {
+		return err
+	}
+	if err := elements[1].Unmarshal(&operation); err != nil {
+		return err
+	}
+	if operation == "remove" {
+		d.Removed = true
+	} else if operation != "change" {
+		return fmt.Errorf("Unexpected operation %q", operation)
+	}
+	entity, err := newEntityInfo(entityKind)
+	if err != nil {
+		return err
+	}
+	if err := elements[2].Unmarshal(entity); err != nil {
+		return err
+	}
+	d.Entity = entity
+	return nil
+}
+
+// newEntityInfo returns a new, empty EntityInfo
+// of the given kind.
+func newEntityInfo(entityKind string) (EntityInfo, error) {
 	switch entityKind {
 	case "machine":
-		d.Entity = new(MachineInfo)
+		return new(MachineInfo), nil
 	case "service":
-		d.Entity = new(ServiceInfo)
+		return new(ServiceInfo), nil
 	case "unit":
-		d.Entity = new(UnitInfo)
+		return new(UnitInfo), nil
 	case "relation":
-		d.Entity = new(RelationInfo)
+		return new(RelationInfo), nil
 	case "annotation":
-		d.Entity = new(AnnotationInfo)
+		return new(AnnotationInfo), nil
 	case "block":
-		d.Entity = new(BlockInfo)
-	default:
-		return fmt.Errorf("Unexpected entity name %q", entityKind)
+		return new(BlockInfo), nil
 	}
-	return json.Unmarshal(elements[2], &d.Entity)
+	return nil, fmt.Errorf("Unexpected entity name %q", entityKind)
 }
 
 // When remote units leave scope, their ids will be noted in the
@@ -0,0 +1,244 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"gopkg.in/juju/charm.v5-unstable"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// ServiceOffer represents some of a service's endpoints, published so
+// that they may be related to from other environments. Another
+// environment consumes an offer by adding a RemoteService for it.
+type ServiceOffer struct {
+	st  *State
+	doc serviceOfferDoc
+}
+
+type serviceOfferDoc struct {
+	DocID       string   `bson:"_id"`
+	EnvUUID     string   `bson:"env-uuid"`
+	Name        string   `bson:"name"`
+	ServiceName string   `bson:"servicename"`
+	Endpoints   []string `bson:"endpoints"`
+	Description string   `bson:"description,omitempty"`
+}
+
+// Name returns the name of the offer, which is unique within its
+// environment.
+func (o *ServiceOffer) Name() string {
+	return o.doc.Name
+}
+
+// ServiceName returns the name of the offered service.
+func (o *ServiceOffer) ServiceName() string {
+	return o.doc.ServiceName
+}
+
+// EnvironUUID returns the UUID of the environment holding the
+// offered service.
+func (o *ServiceOffer) EnvironUUID() string {
+	return o.doc.EnvUUID
+}
+
+// Description returns the offer's description.
+func (o *ServiceOffer) Description() string {
+	return o.doc.Description
+}
+
+// String implements fmt.Stringer.
+func (o *ServiceOffer) String() string {
+	return o.doc.Name
+}
+
+// Endpoints returns the offered endpoints of the service.
+func (o *ServiceOffer) Endpoints() ([]Endpoint, error) {
+	svc, err := o.st.Service(o.doc.ServiceName)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	eps := make([]Endpoint, len(o.doc.Endpoints))
+	for i, name := range o.doc.Endpoints {
+		if eps[i], err = svc.Endpoint(name); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return eps, nil
+}
+
+// Remove removes the offer. Remote services which already consume
+// the offer are unaffected.
+func (o *ServiceOffer) Remove() error {
+	ops := []txn.Op{{
+		C:      serviceOffersC,
+		Id:     o.doc.DocID,
+		Remove: true,
+	}}
+	if err := o.st.runTransaction(ops); err != nil {
+		return errors.Annotatef(err, "cannot remove offer %q", o)
+	}
+	return nil
+}
+
+// AddServiceOfferArgs holds the parameters for publishing a service's
+// endpoints to other environments.
+type AddServiceOfferArgs struct {
+	// Name is the name of the offer. If it is empty, the offer is
+	// named after the service.
+	Name string
+
+	// ServiceName is the name of the offered service.
+	ServiceName string
+
+	// Endpoints holds the names of the offered relations. If it is
+	// empty, all of the service's provided and required relations
+	// are offered.
+	Endpoints []string
+
+	// Description describes the offer to its consumers.
+	Description string
+}
+
+// AddServiceOffer publishes the given endpoints of a service so that
+// they may be consumed by other environments. Peer relations, and
+// relations with container scope, cannot be offered.
+func (st *State) AddServiceOffer(args AddServiceOfferArgs) (offer *ServiceOffer, err error) {
+	if args.Name == "" {
+		args.Name = args.ServiceName
+	}
+	defer errors.DeferredAnnotatef(&err, "cannot add offer %q", args.Name)
+
+	if !names.IsValidService(args.Name) {
+		return nil, errors.NotValidf("offer name %q", args.Name)
+	}
+	svc, err := st.Service(args.ServiceName)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if svc.Life() != Alive {
+		return nil, errors.Errorf("service %q is not alive", svc)
+	}
+	eps, err := offerableEndpoints(svc, args.Endpoints)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	doc := serviceOfferDoc{
+		DocID:       st.docID(args.Name),
+		EnvUUID:     st.EnvironUUID(),
+		Name:        args.Name,
+		ServiceName: args.ServiceName,
+		Description: args.Description,
+	}
+	for _, ep := range eps {
+		doc.Endpoints = append(doc.Endpoints, ep.Name)
+	}
+	ops := []txn.Op{{
+		C:      servicesC,
+		Id:     st.docID(args.ServiceName),
+		Assert: isAliveDoc,
+	}, {
+		C:      serviceOffersC,
+		Id:     doc.DocID,
+		Assert: txn.DocMissing,
+		Insert: doc,
+	}}
+	if err := st.runTransaction(ops); err == txn.ErrAborted {
+		if _, err := st.ServiceOffer(args.Name); err == nil {
+			return nil, errors.AlreadyExistsf("offer %q", args.Name)
+		}
+		return nil, errors.Errorf("service %q is not alive", args.ServiceName)
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &ServiceOffer{st, doc}, nil
+}
+
+// offerableEndpoints returns the named endpoints of the service, or
+// all of its offerable endpoints if no names are given. It returns an
+// error if any named endpoint cannot be offered.
+func offerableEndpoints(svc *Service, endpointNames []string) ([]Endpoint, error) {
+	if len(endpointNames) == 0 {
+		all, err := svc.Endpoints()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		var eps []Endpoint
+		for _, ep := range all {
+			if ep.Role != charm.RolePeer && ep.Scope != charm.ScopeContainer && !ep.IsImplicit() {
+				eps = append(eps, ep)
+			}
+		}
+		if len(eps) == 0 {
+			return nil, errors.Errorf("service %q has no relations which can be offered", svc)
+		}
+		return eps, nil
+	}
+	eps := make([]Endpoint, len(endpointNames))
+	for i, name := range endpointNames {
+		ep, err := svc.Endpoint(name)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		switch {
+		case ep.Role == charm.RolePeer:
+			return nil, errors.Errorf("cannot offer peer relation %q", ep)
+		case ep.Scope == charm.ScopeContainer:
+			return nil, errors.Errorf("cannot offer container scoped relation %q", ep)
+		}
+		eps[i] = ep
+	}
+	return eps, nil
+}
+
+// ServiceOffer returns the offer with the given name.
+func (st *State) ServiceOffer(name string) (*ServiceOffer, error) {
+	offers, closer := st.getCollection(serviceOffersC)
+	defer closer()
+
+	var doc serviceOfferDoc
+	err := offers.FindId(name).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("offer %q", name)
+	}
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot get offer %q", name)
+	}
+	return &ServiceOffer{st, doc}, nil
+}
+
+// AllServiceOffers returns all the offers in the environment.
+func (st *State) AllServiceOffers() ([]*ServiceOffer, error) {
+	offers, closer := st.getCollection(serviceOffersC)
+	defer closer()
+
+	var docs []serviceOfferDoc
+	if err := offers.Find(nil).Sort("name").All(&docs); err != nil {
+		return nil, errors.Annotate(err, "cannot get all offers")
+	}
+	result := make([]*ServiceOffer, len(docs))
+	for i, doc := range docs {
+		result[i] = &ServiceOffer{st, doc}
+	}
+	return result, nil
+}
+
+// serviceOffers returns the offers of the named service.
+func serviceOffers(st *State, serviceName string) ([]*ServiceOffer, error) {
+	offers, closer := st.getCollection(serviceOffersC)
+	defer closer()
+
+	var docs []serviceOfferDoc
+	if err := offers.Find(bson.D{{"servicename", serviceName}}).All(&docs); err != nil {
+		return nil, errors.Annotatef(err, "cannot get offers of service %q", serviceName)
+	}
+	result := make([]*ServiceOffer, len(docs))
+	for i, doc := range docs {
+		result[i] = &ServiceOffer{st, doc}
+	}
+	return result, nil
+}
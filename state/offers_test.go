@@ -0,0 +1,179 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/state"
+	statetesting "github.com/juju/juju/state/testing"
+)
+
+type ServiceOfferSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&ServiceOfferSuite{})
+
+func (s *ServiceOfferSuite) TestAddServiceOffer(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	offer, err := s.State.AddServiceOffer(state.AddServiceOfferArgs{
+		Name:        "blog",
+		ServiceName: "wordpress",
+		Endpoints:   []string{"url"},
+		Description: "a blog",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(offer.Name(), gc.Equals, "blog")
+	c.Assert(offer.ServiceName(), gc.Equals, "wordpress")
+	c.Assert(offer.Description(), gc.Equals, "a blog")
+	c.Assert(offer.EnvironUUID(), gc.Equals, s.State.EnvironUUID())
+
+	offer, err = s.State.ServiceOffer("blog")
+	c.Assert(err, jc.ErrorIsNil)
+	eps, err := offer.Endpoints()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(eps, gc.HasLen, 1)
+	c.Assert(eps[0].Name, gc.Equals, "url")
+	c.Assert(eps[0].ServiceName, gc.Equals, "wordpress")
+}
+
+func (s *ServiceOfferSuite) TestAddServiceOfferDefaults(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	offer, err := s.State.AddServiceOffer(state.AddServiceOfferArgs{
+		ServiceName: "wordpress",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(offer.Name(), gc.Equals, "wordpress")
+
+	// Peer, container scoped and implicit relations are not offered.
+	eps, err := offer.Endpoints()
+	c.Assert(err, jc.ErrorIsNil)
+	var epNames []string
+	for _, ep := range eps {
+		epNames = append(epNames, ep.Name)
+	}
+	c.Assert(epNames, jc.SameContents, []string{"url", "db", "cache"})
+}
+
+func (s *ServiceOfferSuite) TestAddServiceOfferErrors(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	s.AddTestingService(c, "riak", s.AddTestingCharm(c, "riak"))
+
+	_, err := s.State.AddServiceOffer(state.AddServiceOfferArgs{
+		Name:        "Bad Name",
+		ServiceName: "wordpress",
+	})
+	c.Assert(err, gc.ErrorMatches, `cannot add offer "Bad Name": offer name "Bad Name" not valid`)
+	c.Assert(err, jc.Satisfies, errors.IsNotValid)
+
+	_, err = s.State.AddServiceOffer(state.AddServiceOfferArgs{
+		ServiceName: "mysql",
+	})
+	c.Assert(err, gc.ErrorMatches, `cannot add offer "mysql": service "mysql" not found`)
+
+	_, err = s.State.AddServiceOffer(state.AddServiceOfferArgs{
+		ServiceName: "riak",
+		Endpoints:   []string{"ring"},
+	})
+	c.Assert(err, gc.ErrorMatches, `cannot add offer "riak": cannot offer peer relation "riak:ring"`)
+
+	_, err = s.State.AddServiceOffer(state.AddServiceOfferArgs{
+		ServiceName: "wordpress",
+		Endpoints:   []string{"logging-dir"},
+	})
+	c.Assert(err, gc.ErrorMatches, `cannot add offer "wordpress": cannot offer container scoped relation "wordpress:logging-dir"`)
+
+	_, err = s.State.AddServiceOffer(state.AddServiceOfferArgs{
+		ServiceName: "wordpress",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AddServiceOffer(state.AddServiceOfferArgs{
+		ServiceName: "wordpress",
+	})
+	c.Assert(err, gc.ErrorMatches, `cannot add offer "wordpress": offer "wordpress" already exists`)
+	c.Assert(err, jc.Satisfies, errors.IsAlreadyExists)
+}
+
+func (s *ServiceOfferSuite) TestAllServiceOffers(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	for _, args := range []state.AddServiceOfferArgs{
+		{Name: "db", ServiceName: "mysql"},
+		{Name: "blog", ServiceName: "wordpress"},
+	} {
+		_, err := s.State.AddServiceOffer(args)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	offers, err := s.State.AllServiceOffers()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(offers, gc.HasLen, 2)
+	c.Assert(offers[0].Name(), gc.Equals, "blog")
+	c.Assert(offers[1].Name(), gc.Equals, "db")
+}
+
+func (s *ServiceOfferSuite) TestRemove(c *gc.C) {
+	s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	offer, err := s.State.AddServiceOffer(state.AddServiceOfferArgs{ServiceName: "mysql"})
+	c.Assert(err, jc.ErrorIsNil)
+	err = offer.Remove()
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.ServiceOffer("mysql")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *ServiceOfferSuite) TestOffersRemovedWithService(c *gc.C) {
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	_, err := s.State.AddServiceOffer(state.AddServiceOfferArgs{ServiceName: "mysql"})
+	c.Assert(err, jc.ErrorIsNil)
+	err = mysql.Destroy()
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.State.Cleanup()
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.ServiceOffer("mysql")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *ServiceOfferSuite) TestWatchRemoteRelations(c *gc.C) {
+	w := s.State.WatchRemoteRelations()
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewNotifyWatcherC(c, s.State, w)
+	wc.AssertOneChange()
+
+	// Adding a service is not reported, but offering it is.
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	wc.AssertNoChange()
+	_, err := s.State.AddServiceOffer(state.AddServiceOfferArgs{
+		Name:        "blog",
+		ServiceName: "wordpress",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	wc.AssertOneChange()
+
+	// Relations, the units in their scope and those units'
+	// settings are reported.
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	wc.AssertNoChange()
+	eps, err := s.State.InferEndpoints("wordpress", "mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	rel, err := s.State.AddRelation(eps...)
+	c.Assert(err, jc.ErrorIsNil)
+	wc.AssertOneChange()
+	unit, err := mysql.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	wc.AssertNoChange()
+	ru, err := rel.Unit(unit)
+	c.Assert(err, jc.ErrorIsNil)
+	err = ru.EnterScope(map[string]interface{}{"host": "db.example.com"})
+	c.Assert(err, jc.ErrorIsNil)
+	wc.AssertOneChange()
+	node, err := ru.Settings()
+	c.Assert(err, jc.ErrorIsNil)
+	node.Set("host", "db2.example.com")
+	_, err = node.Write()
+	c.Assert(err, jc.ErrorIsNil)
+	wc.AssertOneChange()
+}
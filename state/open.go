@@ -174,6 +174,8 @@ var indexes = []struct {
 	{networkInterfacesC, []string{"env-uuid", "machineid"}, false, false},
 	{blockDevicesC, []string{"env-uuid", "machineid"}, false, false},
 	{subnetsC, []string{"providerid"}, true, true},
+	{subnetsC, []string{"env-uuid", "spacename"}, false, false},
+	{serviceOffersC, []string{"env-uuid", "servicename"}, false, false},
 	{ipaddressesC, []string{"env-uuid", "state"}, false, false},
 	{ipaddressesC, []string{"env-uuid", "subnetid"}, false, false},
 	{storageInstancesC, []string{"env-uuid", "owner"}, false, false},
@@ -181,6 +183,7 @@ var indexes = []struct {
 	{storageAttachmentsC, []string{"env-uuid", "unitid"}, false, false},
 	{volumesC, []string{"env-uuid", "storageid"}, false, false},
 	{filesystemsC, []string{"env-uuid", "storageid"}, false, false},
+	{unitHistoryC, []string{"env-uuid", "unit", "started"}, false, false},
 }
 
 // The capped collection used for transaction logs defaults to 10MB.
@@ -248,10 +251,11 @@ func newState(session *mgo.Session, mongoInfo *mongo.MongoInfo, policy Policy) (
 
 	// Create and set up State.
 	st := &State{
-		mongoInfo: mongoInfo,
-		policy:    policy,
-		db:        db,
-		watcher:   watcher.New(txnLog),
+		mongoInfo:       mongoInfo,
+		policy:          policy,
+		db:              db,
+		watcher:         watcher.New(txnLog),
+		addressPolicies: &addressPolicyCache{},
 	}
 	defer func() {
 		if resultErr != nil {
@@ -294,6 +298,11 @@ func (st *State) CACert() string {
 }
 
 func (st *State) Close() (err error) {
+	if st.untraced != nil {
+		// A State returned by WithTraceId shares the
+		// resources of the State it was derived from.
+		return nil
+	}
 	defer errors.DeferredAnnotatef(&err, "closing state failed")
 	err1 := st.watcher.Stop()
 	var err2 error
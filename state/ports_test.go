@@ -279,7 +279,7 @@ func (s *PortsDocSuite) TestAllPortRanges(c *gc.C) {
 	ranges := s.ports.AllPortRanges()
 	c.Assert(ranges, gc.HasLen, 1)
 
-	c.Assert(ranges[network.PortRange{100, 200, "TCP"}], gc.Equals, s.unit1.Name())
+	c.Assert(ranges[network.PortRange{FromPort: 100, ToPort: 200, Protocol: "TCP"}], gc.Equals, s.unit1.Name())
 }
 
 func (s *PortsDocSuite) TestOpenInvalidRange(c *gc.C) {
@@ -147,7 +147,7 @@ func (r *Relation) destroyOps(ignoreService string) (ops []txn.Op, isRemove bool
 		return nil, false, errAlreadyDying
 	}
 	if r.doc.UnitCount == 0 {
-		removeOps, err := r.removeOps(ignoreService, nil)
+		removeOps, err := r.removeOps(ignoreService, "")
 		if err != nil {
 			return nil, false, err
 		}
@@ -163,16 +163,17 @@ func (r *Relation) destroyOps(ignoreService string) (ops []txn.Op, isRemove bool
 
 // removeOps returns the operations necessary to remove the relation. If
 // ignoreService is not empty, no operations affecting that service will be
-// included; if departingUnit is not nil, this implies that the relation's
-// services may be Dying and otherwise unreferenced, and may thus require
-// removal themselves.
-func (r *Relation) removeOps(ignoreService string, departingUnit *Unit) ([]txn.Op, error) {
+// included; if departingService is not empty, this implies that a unit of
+// that service is departing the relation, and that the relation's services
+// may be Dying and otherwise unreferenced, and may thus require removal
+// themselves.
+func (r *Relation) removeOps(ignoreService, departingService string) ([]txn.Op, error) {
 	relOp := txn.Op{
 		C:      relationsC,
 		Id:     r.doc.DocID,
 		Remove: true,
 	}
-	if departingUnit != nil {
+	if departingService != "" {
 		relOp.Assert = bson.D{{"life", Dying}, {"unitcount", 1}}
 	} else {
 		relOp.Assert = bson.D{{"life", Alive}, {"unitcount", 0}}
@@ -182,14 +183,24 @@ func (r *Relation) removeOps(ignoreService string, departingUnit *Unit) ([]txn.O
 		if ep.ServiceName == ignoreService {
 			continue
 		}
+		if remote, err := isRemoteService(r.st, ep.ServiceName); err != nil {
+			return nil, err
+		} else if remote {
+			remoteOps, err := r.removeRemoteServiceRefOps(ep.ServiceName)
+			if err != nil {
+				return nil, err
+			}
+			ops = append(ops, remoteOps...)
+			continue
+		}
 		var asserts bson.D
 		hasRelation := bson.D{{"relationcount", bson.D{{"$gt", 0}}}}
-		if departingUnit == nil {
+		if departingService == "" {
 			// We're constructing a destroy operation, either of the relation
 			// or one of its services, and can therefore be assured that both
 			// services are Alive.
 			asserts = append(hasRelation, isAliveDoc...)
-		} else if ep.ServiceName == departingUnit.ServiceName() {
+		} else if ep.ServiceName == departingService {
 			// This service must have at least one unit -- the one that's
 			// departing the relation -- so it cannot be ready for removal.
 			cannotDieYet := bson.D{{"unitcount", bson.D{{"$gt", 0}}}}
@@ -227,6 +238,34 @@ func (r *Relation) removeOps(ignoreService string, departingUnit *Unit) ([]txn.O
 	return append(ops, cleanupOp), nil
 }
 
+// removeRemoteServiceRefOps returns the operations necessary to drop
+// the relation's reference to the named remote service. The remote
+// service is removed too if it is Dying and this is its last relation.
+func (r *Relation) removeRemoteServiceRefOps(serviceName string) ([]txn.Op, error) {
+	remoteServices, closer := r.st.getCollection(remoteServicesC)
+	defer closer()
+
+	svc := &RemoteService{st: r.st}
+	hasLastRef := bson.D{{"life", Dying}, {"relationcount", 1}}
+	removable := append(bson.D{{"_id", serviceName}}, hasLastRef...)
+	if err := remoteServices.Find(removable).One(&svc.doc); err == nil {
+		return svc.removeOps(hasLastRef), nil
+	} else if err != mgo.ErrNotFound {
+		return nil, err
+	}
+	// If not, we must check that this is still the case when the
+	// transaction is applied.
+	return []txn.Op{{
+		C:  remoteServicesC,
+		Id: r.st.docID(serviceName),
+		Assert: bson.D{{"$or", []bson.D{
+			{{"life", Alive}},
+			{{"relationcount", bson.D{{"$gt", 1}}}},
+		}}},
+		Update: bson.D{{"$inc", bson.D{{"relationcount", -1}}}},
+	}}, nil
+}
+
 // Id returns the integer internal relation key. This is exposed
 // because the unit agent needs to expose a value derived from this
 // (as JUJU_RELATION_ID) to allow relation hooks to differentiate
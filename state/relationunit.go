@@ -39,10 +39,48 @@ func (ru *RelationUnit) Endpoint() Endpoint {
 }
 
 // PrivateAddress returns the private address of the unit and whether it is valid.
+// If the unit's service binds the relation's endpoint to a space, the address
+// is chosen from the unit's machine addresses in that space's subnets; the
+// unit's usual private address is returned if there is no such address.
 func (ru *RelationUnit) PrivateAddress() (string, bool) {
+	if address := ru.boundAddress(); address != "" {
+		return address, true
+	}
 	return ru.unit.PrivateAddress()
 }
 
+// boundAddress returns the address of the unit in the space its
+// service binds the relation's endpoint to, if any.
+func (ru *RelationUnit) boundAddress() string {
+	service, err := ru.unit.Service()
+	if err != nil {
+		unitLogger.Errorf("%v", err)
+		return ""
+	}
+	spaceName, ok := service.EndpointBindings()[ru.endpoint.Name]
+	if !ok {
+		return ""
+	}
+	space, err := ru.st.Space(spaceName)
+	if err != nil {
+		unitLogger.Errorf("%v", err)
+		return ""
+	}
+	cidrs, err := space.SubnetCIDRs()
+	if err != nil {
+		unitLogger.Errorf("%v", err)
+		return ""
+	}
+	address := ru.st.addressPolicy().SelectAddressInSubnets(ru.unit.addressesOfMachine(), cidrs)
+	if address == "" {
+		unitLogger.Warningf(
+			"unit %q has no address in space %q bound to endpoint %q",
+			ru.unit, spaceName, ru.endpoint.Name,
+		)
+	}
+	return address
+}
+
 // ErrCannotEnterScope indicates that a relation unit failed to enter its scope
 // due to either the unit or the relation not being Alive.
 var ErrCannotEnterScope = stderrors.New("cannot enter scope: unit or relation is not alive")
@@ -335,7 +373,7 @@ func (ru *RelationUnit) LeaveScope() error {
 				Update: bson.D{{"$inc", bson.D{{"unitcount", -1}}}},
 			})
 		} else {
-			relOps, err := ru.relation.removeOps("", ru.unit)
+			relOps, err := ru.relation.removeOps("", ru.unit.ServiceName())
 			if err != nil {
 				return nil, err
 			}
@@ -180,6 +180,42 @@ func (s *RelationUnitSuite) TestProReqSettings(c *gc.C) {
 	}
 }
 
+func (s *RelationUnitSuite) TestPrivateAddressFromBoundSpace(c *gc.C) {
+	prr := NewProReqRelation(c, &s.ConnSuite, charm.ScopeGlobal)
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	err = prr.pu0.AssignToMachine(machine)
+	c.Assert(err, jc.ErrorIsNil)
+	err = machine.SetAddresses(
+		network.NewScopedAddress("10.0.0.5", network.ScopeCloudLocal),
+		network.NewScopedAddress("10.20.0.5", network.ScopeCloudLocal),
+	)
+	c.Assert(err, jc.ErrorIsNil)
+
+	address, ok := prr.pru0.PrivateAddress()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(address, gc.Equals, "10.0.0.5")
+
+	_, err = s.State.AddSubnet(state.SubnetInfo{CIDR: "10.20.0.0/16"})
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AddSpace("db", []string{"10.20.0.0/16"})
+	c.Assert(err, jc.ErrorIsNil)
+	err = prr.psvc.SetEndpointBindings(map[string]string{"server": "db"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	address, ok = prr.pru0.PrivateAddress()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(address, gc.Equals, "10.20.0.5")
+
+	// With no address in the bound space, the unit's
+	// private address is used.
+	err = machine.SetAddresses(network.NewScopedAddress("10.0.0.5", network.ScopeCloudLocal))
+	c.Assert(err, jc.ErrorIsNil)
+	address, ok = prr.pru0.PrivateAddress()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(address, gc.Equals, "10.0.0.5")
+}
+
 func (s *RelationUnitSuite) TestContainerSettings(c *gc.C) {
 	prr := NewProReqRelation(c, &s.ConnSuite, charm.ScopeContainer)
 	rus := RUs{prr.pru0, prr.pru1, prr.rru0, prr.rru1}
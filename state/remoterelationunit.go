@@ -0,0 +1,250 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	jujutxn "github.com/juju/txn"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// RemoteRelationUnit represents a unit of a service in another
+// environment within a relation with a remote service. Units of the
+// relation's other service see it as they would any other counterpart
+// unit; its settings are those of the unit it stands for.
+type RemoteRelationUnit struct {
+	st       *State
+	relation *Relation
+	unitName string
+	endpoint Endpoint
+	scope    string
+}
+
+// RemoteUnit returns a RemoteRelationUnit for the named unit of the
+// relation's remote service.
+func (r *Relation) RemoteUnit(unitName string) (*RemoteRelationUnit, error) {
+	if !names.IsValidUnit(unitName) {
+		return nil, errors.NotValidf("unit name %q", unitName)
+	}
+	serviceName, err := names.UnitService(unitName)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	ep, err := r.Endpoint(serviceName)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if remote, err := isRemoteService(r.st, serviceName); err != nil {
+		return nil, errors.Trace(err)
+	} else if !remote {
+		return nil, errors.Errorf("service %q is not a remote service", serviceName)
+	}
+	return &RemoteRelationUnit{
+		st:       r.st,
+		relation: r,
+		unitName: unitName,
+		endpoint: ep,
+		scope:    fmt.Sprintf("r#%d", r.doc.Id),
+	}, nil
+}
+
+// UnitName returns the name of the remote unit.
+func (ru *RemoteRelationUnit) UnitName() string {
+	return ru.unitName
+}
+
+// key returns the key of the remote unit within the relation in the
+// settings and relationScopes collections.
+func (ru *RemoteRelationUnit) key() string {
+	return strings.Join([]string{ru.scope, string(ru.endpoint.Role), ru.unitName}, "#")
+}
+
+// EnterScope ensures that the remote unit has entered its scope in
+// the relation, with the given settings. If the unit is already in
+// scope, EnterScope reports success but makes no changes to state.
+func (ru *RemoteRelationUnit) EnterScope(settings map[string]interface{}) error {
+	relationScopes, closer := ru.st.getCollection(relationScopesC)
+	defer closer()
+	settingsColl, closer2 := ru.st.getCollection(settingsC)
+	defer closer2()
+
+	key := ru.key()
+	rsDocID := ru.st.docID(key)
+	desc := fmt.Sprintf("remote unit %q in relation %q", ru.unitName, ru.relation)
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if count, err := relationScopes.FindId(key).Count(); err != nil {
+			return nil, err
+		} else if count != 0 {
+			return nil, jujutxn.ErrNoOperations
+		}
+		if attempt > 0 {
+			if err := ru.relation.Refresh(); err != nil {
+				return nil, err
+			}
+			if ru.relation.Life() != Alive {
+				return nil, ErrCannotEnterScope
+			}
+		}
+		ops := []txn.Op{{
+			C:      remoteServicesC,
+			Id:     ru.st.docID(ru.endpoint.ServiceName),
+			Assert: isAliveDoc,
+		}, {
+			C:      relationsC,
+			Id:     ru.relation.doc.DocID,
+			Assert: isAliveDoc,
+			Update: bson.D{{"$inc", bson.D{{"unitcount", 1}}}},
+		}}
+		// The settings must exist before the scope doc does.
+		if count, err := settingsColl.FindId(key).Count(); err != nil {
+			return nil, err
+		} else if count == 0 {
+			ops = append(ops, createSettingsOp(ru.st, key, settings))
+		} else {
+			rop, _, err := replaceSettingsOp(ru.st, key, settings)
+			if err != nil {
+				return nil, err
+			}
+			ops = append(ops, rop)
+		}
+		return append(ops, txn.Op{
+			C:      relationScopesC,
+			Id:     rsDocID,
+			Assert: txn.DocMissing,
+			Insert: relationScopeDoc{
+				DocID:   rsDocID,
+				Key:     key,
+				EnvUUID: ru.st.EnvironUUID(),
+			},
+		}), nil
+	}
+	if err := ru.st.run(buildTxn); err == ErrCannotEnterScope {
+		return err
+	} else if err != nil {
+		return errors.Annotatef(err, "cannot enter scope for %s", desc)
+	}
+	return nil
+}
+
+// ReplaceSettings replaces the remote unit's settings in the relation.
+func (ru *RemoteRelationUnit) ReplaceSettings(settings map[string]interface{}) error {
+	op, _, err := replaceSettingsOp(ru.st, ru.key(), settings)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := ru.st.runTransaction([]txn.Op{op}); err != nil {
+		return errors.Annotatef(err, "cannot replace settings of remote unit %q in relation %q", ru.unitName, ru.relation)
+	}
+	return nil
+}
+
+// LeaveScope signals that the remote unit has left its scope in the
+// relation. If the relation is dying when its last member unit leaves,
+// it is removed. It is not an error to leave a scope that the unit is
+// not, or never was, a member of.
+func (ru *RemoteRelationUnit) LeaveScope() error {
+	relationScopes, closer := ru.st.getCollection(relationScopesC)
+	defer closer()
+
+	key := ru.key()
+	desc := fmt.Sprintf("remote unit %q in relation %q", ru.unitName, ru.relation)
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			if err := ru.relation.Refresh(); errors.IsNotFound(err) {
+				return nil, jujutxn.ErrNoOperations
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		count, err := relationScopes.FindId(key).Count()
+		if err != nil {
+			return nil, fmt.Errorf("cannot examine scope for %s: %v", desc, err)
+		} else if count == 0 {
+			return nil, jujutxn.ErrNoOperations
+		}
+		ops := []txn.Op{{
+			C:      relationScopesC,
+			Id:     ru.st.docID(key),
+			Assert: txn.DocExists,
+			Remove: true,
+		}}
+		if ru.relation.doc.Life == Alive {
+			ops = append(ops, txn.Op{
+				C:      relationsC,
+				Id:     ru.relation.doc.DocID,
+				Assert: bson.D{{"life", Alive}},
+				Update: bson.D{{"$inc", bson.D{{"unitcount", -1}}}},
+			})
+		} else if ru.relation.doc.UnitCount > 1 {
+			ops = append(ops, txn.Op{
+				C:      relationsC,
+				Id:     ru.relation.doc.DocID,
+				Assert: bson.D{{"unitcount", bson.D{{"$gt", 1}}}},
+				Update: bson.D{{"$inc", bson.D{{"unitcount", -1}}}},
+			})
+		} else {
+			relOps, err := ru.relation.removeOps("", ru.endpoint.ServiceName)
+			if err != nil {
+				return nil, err
+			}
+			ops = append(ops, relOps...)
+		}
+		return ops, nil
+	}
+	if err := ru.st.run(buildTxn); err != nil {
+		return fmt.Errorf("cannot leave scope for %s: %v", desc, err)
+	}
+	return nil
+}
+
+// InScope returns whether the remote unit has entered scope and not
+// left it.
+func (ru *RemoteRelationUnit) InScope() (bool, error) {
+	relationScopes, closer := ru.st.getCollection(relationScopesC)
+	defer closer()
+
+	count, err := relationScopes.FindId(ru.key()).Count()
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return count > 0, nil
+}
+
+// ServiceUnitSettings returns the settings of the named service's
+// units which have joined the relation's scope and not prepared to
+// leave it, keyed by unit name. Both units of services in the
+// environment and remote units are included.
+func (r *Relation) ServiceUnitSettings(serviceName string) (map[string]map[string]interface{}, error) {
+	ep, err := r.Endpoint(serviceName)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	relationScopes, closer := r.st.getCollection(relationScopesC)
+	defer closer()
+
+	prefix := fmt.Sprintf("r#%d#%s#%s/", r.doc.Id, ep.Role, serviceName)
+	sel := bson.D{
+		{"key", bson.D{{"$regex", "^" + regexp.QuoteMeta(prefix)}}},
+		{"departing", bson.D{{"$ne", true}}},
+	}
+	var docs []relationScopeDoc
+	if err := relationScopes.Find(sel).All(&docs); err != nil {
+		return nil, errors.Annotatef(err, "cannot get units of %q in relation %q", serviceName, r)
+	}
+	result := make(map[string]map[string]interface{})
+	for _, doc := range docs {
+		settings, err := readSettings(r.st, doc.Key)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		result[doc.unitName()] = settings.Map()
+	}
+	return result, nil
+}
@@ -0,0 +1,336 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	jujutxn "github.com/juju/txn"
+	"gopkg.in/juju/charm.v5-unstable"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// RemoteService represents a service in another environment, which
+// services in this environment may be related to. It has no units of
+// its own in this environment; the units of the service it stands for
+// are instead represented in its relations by remote relation units,
+// whose settings are kept in step with those of the real units.
+type RemoteService struct {
+	st  *State
+	doc remoteServiceDoc
+}
+
+type remoteServiceDoc struct {
+	DocID         string           `bson:"_id"`
+	EnvUUID       string           `bson:"env-uuid"`
+	Name          string           `bson:"name"`
+	SourceEnvUUID string           `bson:"source-env-uuid"`
+	OfferName     string           `bson:"offer-name,omitempty"`
+	Endpoints     []charm.Relation `bson:"endpoints"`
+	Life          Life             `bson:"life"`
+	RelationCount int              `bson:"relationcount"`
+}
+
+// Name returns the name of the remote service in this environment.
+func (s *RemoteService) Name() string {
+	return s.doc.Name
+}
+
+// String implements fmt.Stringer.
+func (s *RemoteService) String() string {
+	return s.doc.Name
+}
+
+// SourceEnvUUID returns the UUID of the environment holding the
+// service which the remote service stands for.
+func (s *RemoteService) SourceEnvUUID() string {
+	return s.doc.SourceEnvUUID
+}
+
+// OfferName returns the name of the offer in the source environment
+// which the remote service consumes. It is empty for a remote service
+// which stands for the consumer of one of this environment's offers.
+func (s *RemoteService) OfferName() string {
+	return s.doc.OfferName
+}
+
+// Life returns whether the remote service is Alive, Dying or Dead.
+func (s *RemoteService) Life() Life {
+	return s.doc.Life
+}
+
+// Endpoints returns the remote service's endpoints.
+func (s *RemoteService) Endpoints() []Endpoint {
+	eps := make([]Endpoint, len(s.doc.Endpoints))
+	for i, rel := range s.doc.Endpoints {
+		eps[i] = Endpoint{
+			ServiceName: s.doc.Name,
+			Relation:    rel,
+		}
+	}
+	return eps
+}
+
+// Endpoint returns the remote service's endpoint with the given
+// relation name.
+func (s *RemoteService) Endpoint(relationName string) (Endpoint, error) {
+	for _, ep := range s.Endpoints() {
+		if ep.Name == relationName {
+			return ep, nil
+		}
+	}
+	return Endpoint{}, fmt.Errorf("remote service %q has no %q relation", s, relationName)
+}
+
+// Relations returns the relations of the remote service.
+func (s *RemoteService) Relations() ([]*Relation, error) {
+	return serviceRelations(s.st, s.doc.Name)
+}
+
+// Refresh refreshes the contents of the remote service from the
+// underlying state. It returns an error that satisfies
+// errors.IsNotFound if the remote service has been removed.
+func (s *RemoteService) Refresh() error {
+	remoteServices, closer := s.st.getCollection(remoteServicesC)
+	defer closer()
+
+	err := remoteServices.FindId(s.doc.DocID).One(&s.doc)
+	if err == mgo.ErrNotFound {
+		return errors.NotFoundf("remote service %q", s)
+	}
+	if err != nil {
+		return errors.Annotatef(err, "cannot refresh remote service %q", s)
+	}
+	return nil
+}
+
+// Destroy ensures that the remote service and its relations will be
+// removed at some point; if it has no relations, it is removed
+// immediately.
+func (s *RemoteService) Destroy() (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot destroy remote service %q", s)
+	defer func() {
+		if err == nil {
+			// This is a white lie; the document might actually be removed.
+			s.doc.Life = Dying
+		}
+	}()
+	svc := &RemoteService{st: s.st, doc: s.doc}
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			if err := svc.Refresh(); errors.IsNotFound(err) {
+				return nil, jujutxn.ErrNoOperations
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		switch ops, err := svc.destroyOps(); err {
+		case errRefresh:
+		case errAlreadyDying:
+			return nil, jujutxn.ErrNoOperations
+		case nil:
+			return ops, nil
+		default:
+			return nil, err
+		}
+		return nil, jujutxn.ErrTransientFailure
+	}
+	return s.st.run(buildTxn)
+}
+
+// destroyOps returns the operations required to destroy the remote
+// service. If it returns errRefresh, the remote service should be
+// refreshed and the destruction operations recalculated.
+func (s *RemoteService) destroyOps() ([]txn.Op, error) {
+	if s.doc.Life == Dying {
+		return nil, errAlreadyDying
+	}
+	rels, err := s.Relations()
+	if err != nil {
+		return nil, err
+	}
+	if len(rels) != s.doc.RelationCount {
+		// This is just an early bail out. The relations obtained may still
+		// be wrong, but that situation will be caught by a combination of
+		// asserts on relationcount and on each known relation, below.
+		return nil, errRefresh
+	}
+	var ops []txn.Op
+	removeCount := 0
+	for _, rel := range rels {
+		relOps, isRemove, err := rel.destroyOps(s.doc.Name)
+		if err == errAlreadyDying {
+			relOps = []txn.Op{{
+				C:      relationsC,
+				Id:     rel.doc.DocID,
+				Assert: bson.D{{"life", Dying}},
+			}}
+		} else if err != nil {
+			return nil, err
+		}
+		if isRemove {
+			removeCount++
+		}
+		ops = append(ops, relOps...)
+	}
+	// If all of the remote service's known relations will be
+	// removed, the remote service can also be removed.
+	if removeCount == len(rels) {
+		hasLastRefs := bson.D{{"life", Alive}, {"relationcount", removeCount}}
+		return append(ops, s.removeOps(hasLastRefs)...), nil
+	}
+	// In all other cases, the remote service must be set to Dying.
+	return append(ops, txn.Op{
+		C:      remoteServicesC,
+		Id:     s.doc.DocID,
+		Assert: bson.D{{"life", Alive}, {"relationcount", len(rels)}},
+		Update: bson.D{{"$set", bson.D{{"life", Dying}}}},
+	}), nil
+}
+
+// removeOps returns the operations required to remove the remote
+// service, asserting the given conditions.
+func (s *RemoteService) removeOps(asserts bson.D) []txn.Op {
+	return []txn.Op{{
+		C:      remoteServicesC,
+		Id:     s.doc.DocID,
+		Assert: asserts,
+		Remove: true,
+	}}
+}
+
+// AddRemoteServiceArgs holds the parameters for adding a remote
+// service.
+type AddRemoteServiceArgs struct {
+	// Name is the name of the remote service in this environment.
+	Name string
+
+	// SourceEnvUUID is the UUID of the environment holding the
+	// service which the remote service stands for.
+	SourceEnvUUID string
+
+	// OfferName is the name of the consumed offer in the source
+	// environment, if any.
+	OfferName string
+
+	// Endpoints holds the relations of the remote service.
+	Endpoints []charm.Relation
+}
+
+// AddRemoteService creates a remote service, which stands for a
+// service in another environment. Its name may not be shared with
+// a service in this environment.
+func (st *State) AddRemoteService(args AddRemoteServiceArgs) (service *RemoteService, err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot add remote service %q", args.Name)
+
+	if !names.IsValidService(args.Name) {
+		return nil, errors.NotValidf("name %q", args.Name)
+	}
+	if !names.IsValidEnvironment(args.SourceEnvUUID) {
+		return nil, errors.NotValidf("source environment UUID %q", args.SourceEnvUUID)
+	}
+	if args.SourceEnvUUID == st.EnvironUUID() {
+		return nil, errors.New("source environment cannot be this environment")
+	}
+	if len(args.Endpoints) == 0 {
+		return nil, errors.New("no endpoints specified")
+	}
+	for _, ep := range args.Endpoints {
+		if ep.Role == charm.RolePeer || ep.Scope == charm.ScopeContainer {
+			return nil, errors.Errorf("endpoint %q cannot be related to from another environment", ep.Name)
+		}
+	}
+	env, err := st.Environment()
+	if err != nil {
+		return nil, errors.Trace(err)
+	} else if env.Life() != Alive {
+		return nil, errors.Errorf("environment is no longer alive")
+	}
+	doc := remoteServiceDoc{
+		DocID:         st.docID(args.Name),
+		EnvUUID:       st.EnvironUUID(),
+		Name:          args.Name,
+		SourceEnvUUID: args.SourceEnvUUID,
+		OfferName:     args.OfferName,
+		Endpoints:     args.Endpoints,
+		Life:          Alive,
+	}
+	ops := []txn.Op{
+		env.assertAliveOp(),
+		{
+			C:      servicesC,
+			Id:     doc.DocID,
+			Assert: txn.DocMissing,
+		}, {
+			C:      remoteServicesC,
+			Id:     doc.DocID,
+			Assert: txn.DocMissing,
+			Insert: doc,
+		},
+	}
+	if err := st.runTransaction(ops); err == txn.ErrAborted {
+		if err := env.Refresh(); err != nil {
+			return nil, errors.Trace(err)
+		} else if env.Life() != Alive {
+			return nil, errors.Errorf("environment is no longer alive")
+		}
+		return nil, errors.AlreadyExistsf("service %q", args.Name)
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &RemoteService{st, doc}, nil
+}
+
+// RemoteService returns the remote service with the given name.
+func (st *State) RemoteService(name string) (*RemoteService, error) {
+	remoteServices, closer := st.getCollection(remoteServicesC)
+	defer closer()
+
+	if !names.IsValidService(name) {
+		return nil, errors.NotValidf("remote service name %q", name)
+	}
+	var doc remoteServiceDoc
+	err := remoteServices.FindId(name).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("remote service %q", name)
+	}
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot get remote service %q", name)
+	}
+	return &RemoteService{st, doc}, nil
+}
+
+// AllRemoteServices returns all the remote services in the
+// environment.
+func (st *State) AllRemoteServices() ([]*RemoteService, error) {
+	remoteServices, closer := st.getCollection(remoteServicesC)
+	defer closer()
+
+	var docs []remoteServiceDoc
+	if err := remoteServices.Find(nil).Sort("name").All(&docs); err != nil {
+		return nil, errors.Annotate(err, "cannot get all remote services")
+	}
+	result := make([]*RemoteService, len(docs))
+	for i, doc := range docs {
+		result[i] = &RemoteService{st, doc}
+	}
+	return result, nil
+}
+
+// isRemoteService reports whether the named service is a remote
+// service.
+func isRemoteService(st *State, name string) (bool, error) {
+	remoteServices, closer := st.getCollection(remoteServicesC)
+	defer closer()
+
+	count, err := remoteServices.FindId(name).Count()
+	if err != nil {
+		return false, errors.Annotatef(err, "cannot get remote service %q", name)
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,204 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/juju/charm.v5-unstable"
+
+	"github.com/juju/juju/state"
+)
+
+type RemoteServiceSuite struct {
+	ConnSuite
+	sourceEnvUUID string
+	remote        *state.RemoteService
+}
+
+var _ = gc.Suite(&RemoteServiceSuite{})
+
+var mysqlRelation = charm.Relation{
+	Name:      "server",
+	Role:      charm.RoleProvider,
+	Interface: "mysql",
+	Scope:     charm.ScopeGlobal,
+}
+
+func (s *RemoteServiceSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.sourceEnvUUID = utils.MustNewUUID().String()
+	var err error
+	s.remote, err = s.State.AddRemoteService(state.AddRemoteServiceArgs{
+		Name:          "mysql",
+		SourceEnvUUID: s.sourceEnvUUID,
+		OfferName:     "db",
+		Endpoints:     []charm.Relation{mysqlRelation},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *RemoteServiceSuite) TestAddRemoteService(c *gc.C) {
+	remote, err := s.State.RemoteService("mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(remote.Name(), gc.Equals, "mysql")
+	c.Assert(remote.SourceEnvUUID(), gc.Equals, s.sourceEnvUUID)
+	c.Assert(remote.OfferName(), gc.Equals, "db")
+	c.Assert(remote.Life(), gc.Equals, state.Alive)
+	c.Assert(remote.Endpoints(), jc.DeepEquals, []state.Endpoint{{
+		ServiceName: "mysql",
+		Relation:    mysqlRelation,
+	}})
+
+	all, err := s.State.AllRemoteServices()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(all, gc.HasLen, 1)
+	c.Assert(all[0].Name(), gc.Equals, "mysql")
+}
+
+func (s *RemoteServiceSuite) TestAddRemoteServiceErrors(c *gc.C) {
+	for i, test := range []struct {
+		args state.AddRemoteServiceArgs
+		err  string
+	}{{
+		args: state.AddRemoteServiceArgs{Name: "Bad", SourceEnvUUID: s.sourceEnvUUID},
+		err:  `cannot add remote service "Bad": name "Bad" not valid`,
+	}, {
+		args: state.AddRemoteServiceArgs{Name: "db", SourceEnvUUID: "foo"},
+		err:  `cannot add remote service "db": source environment UUID "foo" not valid`,
+	}, {
+		args: state.AddRemoteServiceArgs{Name: "db", SourceEnvUUID: s.State.EnvironUUID()},
+		err:  `cannot add remote service "db": source environment cannot be this environment`,
+	}, {
+		args: state.AddRemoteServiceArgs{Name: "db", SourceEnvUUID: s.sourceEnvUUID},
+		err:  `cannot add remote service "db": no endpoints specified`,
+	}, {
+		args: state.AddRemoteServiceArgs{
+			Name:          "db",
+			SourceEnvUUID: s.sourceEnvUUID,
+			Endpoints: []charm.Relation{{
+				Name:      "ring",
+				Role:      charm.RolePeer,
+				Interface: "riak",
+			}},
+		},
+		err: `cannot add remote service "db": endpoint "ring" cannot be related to from another environment`,
+	}, {
+		args: state.AddRemoteServiceArgs{
+			Name:          "mysql",
+			SourceEnvUUID: s.sourceEnvUUID,
+			Endpoints:     []charm.Relation{mysqlRelation},
+		},
+		err: `cannot add remote service "mysql": service "mysql" already exists`,
+	}} {
+		c.Logf("test %d: %s", i, test.err)
+		_, err := s.State.AddRemoteService(test.args)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *RemoteServiceSuite) TestNamesShared(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	_, err := s.State.AddRemoteService(state.AddRemoteServiceArgs{
+		Name:          "wordpress",
+		SourceEnvUUID: s.sourceEnvUUID,
+		Endpoints:     []charm.Relation{mysqlRelation},
+	})
+	c.Assert(err, jc.Satisfies, errors.IsAlreadyExists)
+
+	_, err = s.State.AddService("mysql", s.Owner.String(), s.AddTestingCharm(c, "mysql"), nil, nil)
+	c.Assert(err, gc.ErrorMatches, `cannot add service "mysql": service already exists`)
+}
+
+func (s *RemoteServiceSuite) TestAddRelation(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	eps, err := s.State.InferEndpoints("wordpress", "mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	rel, err := s.State.AddRelation(eps...)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(rel.String(), gc.Equals, "wordpress:db mysql:server")
+
+	rels, err := s.remote.Relations()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(rels, gc.HasLen, 1)
+	c.Assert(rels[0].Id(), gc.Equals, rel.Id())
+}
+
+func (s *RemoteServiceSuite) TestAddRelationTwoRemotes(c *gc.C) {
+	_, err := s.State.AddRemoteService(state.AddRemoteServiceArgs{
+		Name:          "wordpress",
+		SourceEnvUUID: s.sourceEnvUUID,
+		Endpoints: []charm.Relation{{
+			Name:      "db",
+			Role:      charm.RoleRequirer,
+			Interface: "mysql",
+			Scope:     charm.ScopeGlobal,
+		}},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	eps, err := s.State.InferEndpoints("wordpress", "mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AddRelation(eps...)
+	c.Assert(err, gc.ErrorMatches, `cannot add relation "wordpress:db mysql:server": cannot relate two remote services`)
+}
+
+func (s *RemoteServiceSuite) TestDestroyWithoutRelations(c *gc.C) {
+	err := s.remote.Destroy()
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.remote.Refresh()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *RemoteServiceSuite) TestRemoteUnits(c *gc.C) {
+	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	unit, err := wordpress.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	eps, err := s.State.InferEndpoints("wordpress", "mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	rel, err := s.State.AddRelation(eps...)
+	c.Assert(err, jc.ErrorIsNil)
+
+	_, err = rel.RemoteUnit("wordpress/0")
+	c.Assert(err, gc.ErrorMatches, `service "wordpress" is not a remote service`)
+
+	remoteUnit, err := rel.RemoteUnit("mysql/0")
+	c.Assert(err, jc.ErrorIsNil)
+	err = remoteUnit.EnterScope(map[string]interface{}{"host": "10.0.0.1"})
+	c.Assert(err, jc.ErrorIsNil)
+	inScope, err := remoteUnit.InScope()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(inScope, jc.IsTrue)
+
+	// Local units see the remote unit's settings.
+	ru, err := rel.Unit(unit)
+	c.Assert(err, jc.ErrorIsNil)
+	settings, err := ru.ReadSettings("mysql/0")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(settings, gc.DeepEquals, map[string]interface{}{"host": "10.0.0.1"})
+
+	err = remoteUnit.ReplaceSettings(map[string]interface{}{"host": "10.0.0.2"})
+	c.Assert(err, jc.ErrorIsNil)
+	all, err := rel.ServiceUnitSettings("mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(all, gc.DeepEquals, map[string]map[string]interface{}{
+		"mysql/0": {"host": "10.0.0.2"},
+	})
+
+	// Destroying the remote service leaves the relation, and the
+	// remote service, alive until the remote unit leaves.
+	err = s.remote.Destroy()
+	c.Assert(err, jc.ErrorIsNil)
+	err = rel.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(rel.Life(), gc.Equals, state.Dying)
+
+	err = remoteUnit.LeaveScope()
+	c.Assert(err, jc.ErrorIsNil)
+	err = rel.Refresh()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	err = s.remote.Refresh()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
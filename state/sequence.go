@@ -36,3 +36,19 @@ func (s *State) sequence(name string) (int, error) {
 	}
 	return result.Counter, nil
 }
+
+// setSequence sets the next value to be returned from the named
+// sequence.
+func (s *State) setSequence(name string, value int) error {
+	_, err := s.db.C(sequenceC).UpsertId(s.docID(name), bson.M{
+		"$set": bson.M{
+			"name":     name,
+			"env-uuid": s.EnvironUUID(),
+			"counter":  value,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot set %q sequence number: %v", name, err)
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ package state
 import (
 	stderrors "errors"
 	"fmt"
+	"net"
 	"sort"
 	"strconv"
 	"strings"
@@ -14,6 +15,7 @@ import (
 	"github.com/juju/errors"
 	"github.com/juju/names"
 	jujutxn "github.com/juju/txn"
+	"github.com/juju/utils/set"
 	"gopkg.in/juju/charm.v5-unstable"
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
@@ -43,10 +45,19 @@ type serviceDoc struct {
 	UnitCount         int        `bson:"unitcount"`
 	RelationCount     int        `bson:"relationcount"`
 	Exposed           bool       `bson:"exposed"`
+	ExposedCIDRs      []string   `bson:"exposedcidrs,omitempty"`
 	MinUnits          int        `bson:"minunits"`
 	OwnerTag          string     `bson:"ownertag"`
 	TxnRevno          int64      `bson:"txn-revno"`
 	MetricCredentials []byte     `bson:"metric-credentials"`
+
+	// EndpointBindings maps the names of the service's endpoints
+	// to the names of the spaces they are bound to.
+	EndpointBindings map[string]string `bson:"endpointbindings,omitempty"`
+
+	// TraceId holds the trace id of the last traced API request
+	// that changed the service.
+	TraceId string `bson:"traceid,omitempty"`
 }
 
 func newService(st *State, doc *serviceDoc) *Service {
@@ -95,6 +106,12 @@ func (s *Service) Life() Life {
 	return s.doc.Life
 }
 
+// TraceId returns the trace id of the last traced API request that
+// changed the service, if any.
+func (s *Service) TraceId() string {
+	return s.doc.TraceId
+}
+
 var errRefresh = stderrors.New("state seems inconsistent, refresh and try again")
 
 // Destroy ensures that the service and all its relations will be removed at
@@ -230,6 +247,9 @@ func (s *Service) removeOps(asserts bson.D) []txn.Op {
 		removeConstraintsOp(s.st, s.globalKey()),
 		annotationRemoveOp(s.st, s.globalKey()),
 		removeLeadershipSettingsOp(s.Tag().Id()),
+		// Offers can only be added to a live service,
+		// so the cleanup finds all there will be.
+		s.st.newCleanupOp(cleanupOffersForRemovedService, s.doc.Name),
 	}
 	return ops
 }
@@ -241,29 +261,117 @@ func (s *Service) IsExposed() bool {
 	return s.doc.Exposed
 }
 
-// SetExposed marks the service as exposed.
+// ExposedCIDRs returns the CIDRs to which access to an exposed service
+// is restricted. If none are returned, an exposed service may be
+// accessed from any address. See SetExposedCIDRs.
+func (s *Service) ExposedCIDRs() []string {
+	if len(s.doc.ExposedCIDRs) == 0 {
+		return nil
+	}
+	cidrs := make([]string, len(s.doc.ExposedCIDRs))
+	copy(cidrs, s.doc.ExposedCIDRs)
+	return cidrs
+}
+
+// SetExposed marks the service as exposed to any address.
 // See ClearExposed and IsExposed.
 func (s *Service) SetExposed() error {
-	return s.setExposed(true)
+	return s.setExposed(true, nil)
+}
+
+// SetExposedCIDRs marks the service as exposed, restricting access
+// to addresses within the given CIDRs. If no CIDRs are given, the
+// service is exposed to any address.
+// See SetExposed and ExposedCIDRs.
+func (s *Service) SetExposedCIDRs(cidrs []string) error {
+	for _, cidr := range cidrs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return errors.NotValidf("CIDR %q", cidr)
+		}
+	}
+	return s.setExposed(true, cidrs)
 }
 
 // ClearExposed removes the exposed flag from the service.
 // See SetExposed and IsExposed.
 func (s *Service) ClearExposed() error {
-	return s.setExposed(false)
+	return s.setExposed(false, nil)
 }
 
-func (s *Service) setExposed(exposed bool) (err error) {
+func (s *Service) setExposed(exposed bool, cidrs []string) (err error) {
+	var update bson.D
+	if len(cidrs) > 0 {
+		update = bson.D{{"$set", bson.D{{"exposed", exposed}, {"exposedcidrs", cidrs}}}}
+	} else {
+		update = bson.D{
+			{"$set", bson.D{{"exposed", exposed}}},
+			{"$unset", bson.D{{"exposedcidrs", nil}}},
+		}
+	}
 	ops := []txn.Op{{
 		C:      servicesC,
 		Id:     s.doc.DocID,
 		Assert: isAliveDoc,
-		Update: bson.D{{"$set", bson.D{{"exposed", exposed}}}},
+		Update: update,
 	}}
 	if err := s.st.runTransaction(ops); err != nil {
 		return fmt.Errorf("cannot set exposed flag for service %q to %v: %v", s, exposed, onAbort(err, errNotAlive))
 	}
 	s.doc.Exposed = exposed
+	s.doc.ExposedCIDRs = cidrs
+	return nil
+}
+
+// EndpointBindings returns the names of the spaces the service's
+// endpoints are bound to, keyed by endpoint name. Endpoints that are
+// not bound to a space are not included. See SetEndpointBindings.
+func (s *Service) EndpointBindings() map[string]string {
+	bindings := make(map[string]string, len(s.doc.EndpointBindings))
+	for endpoint, space := range s.doc.EndpointBindings {
+		bindings[endpoint] = space
+	}
+	return bindings
+}
+
+// SetEndpointBindings binds the named endpoints of the service to
+// the named spaces, replacing any existing bindings, so that units'
+// addresses in those relations are chosen from the spaces' subnets.
+// Each endpoint must be defined by the service's charm, and each
+// space must exist.
+func (s *Service) SetEndpointBindings(bindings map[string]string) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot set endpoint bindings for service %q", s)
+	ops := []txn.Op{{
+		C:      servicesC,
+		Id:     s.doc.DocID,
+		Assert: isAliveDoc,
+	}}
+	if len(bindings) > 0 {
+		ops[0].Update = bson.D{{"$set", bson.D{{"endpointbindings", bindings}}}}
+	} else {
+		ops[0].Update = bson.D{{"$unset", bson.D{{"endpointbindings", nil}}}}
+	}
+	spaces := make(map[string]bool)
+	for endpoint, space := range bindings {
+		if _, err := s.Endpoint(endpoint); err != nil {
+			return errors.Trace(err)
+		}
+		if spaces[space] {
+			continue
+		}
+		if _, err := s.st.Space(space); err != nil {
+			return errors.Trace(err)
+		}
+		spaces[space] = true
+		ops = append(ops, txn.Op{
+			C:      spacesC,
+			Id:     s.st.docID(space),
+			Assert: isAliveDoc,
+		})
+	}
+	if err := s.st.runTransaction(ops); err != nil {
+		return onAbort(err, errors.New("service or space is no longer alive"))
+	}
+	s.doc.EndpointBindings = bindings
 	return nil
 }
 
@@ -823,6 +931,73 @@ func serviceRelations(st *State, name string) (relations []*Relation, err error)
 	return relations, nil
 }
 
+// relatedUnits holds the units of the services related to a service,
+// as used to determine the addresses those units use to reach it.
+type relatedUnits struct {
+	// services holds the names of the related services.
+	services set.Strings
+
+	// machines holds the ids of the machines hosting the
+	// related services' units.
+	machines set.Strings
+
+	// addresses holds the private addresses of the units.
+	addresses set.Strings
+}
+
+// RelatedPrivateAddresses returns the private addresses of the units of
+// all services related to s by alive relations, in sorted order.
+func (s *Service) RelatedPrivateAddresses() ([]string, error) {
+	related, err := s.relatedUnits()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return related.addresses.SortedValues(), nil
+}
+
+func (s *Service) relatedUnits() (*relatedUnits, error) {
+	relations, err := s.Relations()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	related := &relatedUnits{
+		services:  make(set.Strings),
+		machines:  make(set.Strings),
+		addresses: make(set.Strings),
+	}
+	for _, relation := range relations {
+		if relation.Life() != Alive {
+			continue
+		}
+		endpoints, err := relation.RelatedEndpoints(s.doc.Name)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, endpoint := range endpoints {
+			related.services.Add(endpoint.ServiceName)
+		}
+	}
+	for _, name := range related.services.SortedValues() {
+		units, err := allUnits(s.st, name)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, unit := range units {
+			machineId, err := unit.AssignedMachineId()
+			if errors.IsNotAssigned(err) || errors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return nil, errors.Trace(err)
+			}
+			related.machines.Add(machineId)
+			if address, ok := unit.PrivateAddress(); ok {
+				related.addresses.Add(address)
+			}
+		}
+	}
+	return related, nil
+}
+
 // ConfigSettings returns the raw user configuration for the service's charm.
 // Unset values are omitted.
 func (s *Service) ConfigSettings() (charm.Settings, error) {
@@ -19,6 +19,7 @@ import (
 
 	"github.com/juju/juju/constraints"
 	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/network"
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/testing"
 )
@@ -1017,6 +1018,77 @@ func (s *ServiceSuite) TestServiceExposed(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, notAliveErr)
 }
 
+func (s *ServiceSuite) TestServiceExposedCIDRs(c *gc.C) {
+	c.Assert(s.mysql.ExposedCIDRs(), gc.HasLen, 0)
+
+	cidrs := []string{"10.0.0.0/8", "192.168.1.0/24"}
+	err := s.mysql.SetExposedCIDRs(cidrs)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.mysql.IsExposed(), jc.IsTrue)
+	c.Assert(s.mysql.ExposedCIDRs(), jc.DeepEquals, cidrs)
+	err = s.mysql.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.mysql.IsExposed(), jc.IsTrue)
+	c.Assert(s.mysql.ExposedCIDRs(), jc.DeepEquals, cidrs)
+
+	// Exposing without CIDRs lifts the restriction.
+	err = s.mysql.SetExposed()
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.mysql.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.mysql.IsExposed(), jc.IsTrue)
+	c.Assert(s.mysql.ExposedCIDRs(), gc.HasLen, 0)
+
+	err = s.mysql.SetExposedCIDRs(cidrs)
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.mysql.ClearExposed()
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.mysql.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.mysql.IsExposed(), jc.IsFalse)
+	c.Assert(s.mysql.ExposedCIDRs(), gc.HasLen, 0)
+}
+
+func (s *ServiceSuite) TestSetExposedCIDRsInvalid(c *gc.C) {
+	err := s.mysql.SetExposedCIDRs([]string{"10.0.0.0/8", "nonsense"})
+	c.Assert(err, gc.ErrorMatches, `CIDR "nonsense" not valid`)
+	c.Assert(err, jc.Satisfies, errors.IsNotValid)
+	c.Assert(s.mysql.IsExposed(), jc.IsFalse)
+}
+
+func (s *ServiceSuite) TestSetEndpointBindings(c *gc.C) {
+	c.Assert(s.mysql.EndpointBindings(), gc.HasLen, 0)
+	_, err := s.State.AddSubnet(state.SubnetInfo{CIDR: "10.20.0.0/16"})
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AddSpace("db", []string{"10.20.0.0/16"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = s.mysql.SetEndpointBindings(map[string]string{"server": "db"})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.mysql.EndpointBindings(), jc.DeepEquals, map[string]string{"server": "db"})
+	err = s.mysql.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.mysql.EndpointBindings(), jc.DeepEquals, map[string]string{"server": "db"})
+
+	err = s.mysql.SetEndpointBindings(nil)
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.mysql.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.mysql.EndpointBindings(), gc.HasLen, 0)
+}
+
+func (s *ServiceSuite) TestSetEndpointBindingsErrors(c *gc.C) {
+	err := s.mysql.SetEndpointBindings(map[string]string{"server": "db"})
+	c.Assert(err, gc.ErrorMatches, `cannot set endpoint bindings for service "mysql": space "db" not found`)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	_, err = s.State.AddSpace("db", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.mysql.SetEndpointBindings(map[string]string{"cache": "db"})
+	c.Assert(err, gc.ErrorMatches, `cannot set endpoint bindings for service "mysql": service "mysql" has no "cache" relation`)
+	c.Assert(s.mysql.EndpointBindings(), gc.HasLen, 0)
+}
+
 func (s *ServiceSuite) TestAddUnit(c *gc.C) {
 	// Check that principal units can be added on their own.
 	unitZero, err := s.mysql.AddUnit()
@@ -1705,6 +1777,74 @@ func (s *ServiceSuite) TestWatchService(c *gc.C) {
 	testing.NewNotifyWatcherC(c, s.State, w).AssertOneChange()
 }
 
+func (s *ServiceSuite) TestWatchRelatedAddresses(c *gc.C) {
+	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	unit, err := wordpress.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	err = unit.AssignToMachine(machine)
+	c.Assert(err, jc.ErrorIsNil)
+	err = machine.SetAddresses(network.NewScopedAddress("10.0.0.1", network.ScopeCloudLocal))
+	c.Assert(err, jc.ErrorIsNil)
+
+	w := s.mysql.WatchRelatedAddresses()
+	defer testing.AssertStop(c, w)
+	wc := testing.NewNotifyWatcherC(c, s.State, w)
+	wc.AssertOneChange()
+	addresses, err := s.mysql.RelatedPrivateAddresses()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(addresses, gc.HasLen, 0)
+
+	// Relating the services makes the unit's address relevant.
+	eps, err := s.State.InferEndpoints("wordpress", "mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	rel, err := s.State.AddRelation(eps...)
+	c.Assert(err, jc.ErrorIsNil)
+	wc.AssertOneChange()
+	addresses, err = s.mysql.RelatedPrivateAddresses()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(addresses, jc.DeepEquals, []string{"10.0.0.1"})
+
+	// Changes to unrelated machines, or which leave the addresses
+	// unchanged, are not reported.
+	other, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	err = other.SetAddresses(network.NewScopedAddress("10.0.0.2", network.ScopeCloudLocal))
+	c.Assert(err, jc.ErrorIsNil)
+	err = machine.SetStatus(state.StatusStarted, "", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	wc.AssertNoChange()
+
+	// A change to the address of a related unit's machine is reported.
+	err = machine.SetAddresses(network.NewScopedAddress("10.0.0.3", network.ScopeCloudLocal))
+	c.Assert(err, jc.ErrorIsNil)
+	wc.AssertOneChange()
+
+	// A new related unit with an address is reported.
+	unit2, err := wordpress.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	err = unit2.AssignToMachine(other)
+	c.Assert(err, jc.ErrorIsNil)
+	wc.AssertOneChange()
+
+	// As is the removal of a related unit.
+	err = unit2.UnassignFromMachine()
+	c.Assert(err, jc.ErrorIsNil)
+	wc.AssertOneChange()
+
+	// Destroying the relation removes the remaining address.
+	err = rel.Destroy()
+	c.Assert(err, jc.ErrorIsNil)
+	wc.AssertOneChange()
+	addresses, err = s.mysql.RelatedPrivateAddresses()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(addresses, gc.HasLen, 0)
+
+	testing.AssertStop(c, w)
+	wc.AssertClosed()
+}
+
 // SCHEMACHANGE
 // TODO(mattyw) remove when schema upgrades are possible
 // Check that GetOwnerTag returns user-admin even
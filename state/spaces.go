@@ -0,0 +1,165 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"github.com/juju/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/network"
+)
+
+// Space represents a named set of subnets. Services can bind their
+// endpoints to a space, and machines can be constrained to have
+// addresses in particular spaces.
+type Space struct {
+	st  *State
+	doc spaceDoc
+}
+
+type spaceDoc struct {
+	DocID   string `bson:"_id"`
+	EnvUUID string `bson:"env-uuid"`
+	Life    Life   `bson:"life"`
+	Name    string `bson:"name"`
+}
+
+// Name returns the name of the space.
+func (s *Space) Name() string {
+	return s.doc.Name
+}
+
+// Life returns whether the space is Alive, Dying or Dead.
+func (s *Space) Life() Life {
+	return s.doc.Life
+}
+
+// String implements fmt.Stringer.
+func (s *Space) String() string {
+	return s.doc.Name
+}
+
+// Subnets returns the subnets in the space.
+func (s *Space) Subnets() ([]*Subnet, error) {
+	subnets, closer := s.st.getCollection(subnetsC)
+	defer closer()
+
+	var docs []subnetDoc
+	if err := subnets.Find(bson.D{{"spacename", s.doc.Name}}).All(&docs); err != nil {
+		return nil, errors.Annotatef(err, "cannot get subnets of space %q", s)
+	}
+	result := make([]*Subnet, len(docs))
+	for i, doc := range docs {
+		result[i] = &Subnet{s.st, doc}
+	}
+	return result, nil
+}
+
+// SubnetCIDRs returns the CIDRs of the subnets in the space.
+func (s *Space) SubnetCIDRs() ([]string, error) {
+	subnets, err := s.Subnets()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	cidrs := make([]string, len(subnets))
+	for i, subnet := range subnets {
+		cidrs[i] = subnet.CIDR()
+	}
+	return cidrs, nil
+}
+
+// AddSpace creates and returns a new space containing the subnets
+// with the given CIDRs. Each subnet must already exist, and may only
+// be part of a single space.
+func (st *State) AddSpace(name string, subnetCIDRs []string) (space *Space, err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot add space %q", name)
+
+	if !network.IsValidSpaceName(name) {
+		return nil, errors.NotValidf("space name %q", name)
+	}
+	doc := spaceDoc{
+		DocID:   st.docID(name),
+		EnvUUID: st.EnvironUUID(),
+		Life:    Alive,
+		Name:    name,
+	}
+	ops := []txn.Op{{
+		C:      spacesC,
+		Id:     doc.DocID,
+		Assert: txn.DocMissing,
+		Insert: doc,
+	}}
+	for _, cidr := range subnetCIDRs {
+		ops = append(ops, txn.Op{
+			C:  subnetsC,
+			Id: st.docID(cidr),
+			Assert: bson.D{
+				{"life", Alive},
+				{"spacename", bson.D{{"$exists", false}}},
+			},
+			Update: bson.D{{"$set", bson.D{{"spacename", name}}}},
+		})
+	}
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			if _, err := st.Space(name); err == nil {
+				return nil, errors.AlreadyExistsf("space %q", name)
+			} else if !errors.IsNotFound(err) {
+				return nil, errors.Trace(err)
+			}
+			for _, cidr := range subnetCIDRs {
+				subnet, err := st.Subnet(cidr)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+				if subnet.Life() != Alive {
+					return nil, errors.Errorf("subnet %q is not alive", cidr)
+				}
+				if subnet.SpaceName() != "" {
+					return nil, errors.Errorf("subnet %q already in space %q", cidr, subnet.SpaceName())
+				}
+			}
+		}
+		return ops, nil
+	}
+	if err := st.run(buildTxn); err != nil {
+		return nil, errors.Trace(err)
+	}
+	st.addressPolicies.invalidate()
+	return &Space{st, doc}, nil
+}
+
+// Space returns the space with the given name.
+func (st *State) Space(name string) (*Space, error) {
+	spaces, closer := st.getCollection(spacesC)
+	defer closer()
+
+	var doc spaceDoc
+	err := spaces.FindId(name).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("space %q", name)
+	}
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot get space %q", name)
+	}
+	return &Space{st, doc}, nil
+}
+
+// AllSpaces returns all spaces in the environment.
+func (st *State) AllSpaces() ([]*Space, error) {
+	spaces, closer := st.getCollection(spacesC)
+	defer closer()
+
+	var docs []spaceDoc
+	if err := spaces.Find(nil).Sort("name").All(&docs); err != nil {
+		return nil, errors.Annotate(err, "cannot get all spaces")
+	}
+	result := make([]*Space, len(docs))
+	for i, doc := range docs {
+		result[i] = &Space{st, doc}
+	}
+	return result, nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state"
+)
+
+type SpacesSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&SpacesSuite{})
+
+func (s *SpacesSuite) addSubnets(c *gc.C, cidrs ...string) {
+	for _, cidr := range cidrs {
+		_, err := s.State.AddSubnet(state.SubnetInfo{CIDR: cidr})
+		c.Assert(err, jc.ErrorIsNil)
+	}
+}
+
+func (s *SpacesSuite) TestAddSpace(c *gc.C) {
+	s.addSubnets(c, "10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24")
+
+	space, err := s.State.AddSpace("db", []string{"10.0.0.0/24", "10.0.1.0/24"})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(space.Name(), gc.Equals, "db")
+	c.Assert(space.Life(), gc.Equals, state.Alive)
+
+	space, err = s.State.Space("db")
+	c.Assert(err, jc.ErrorIsNil)
+	cidrs, err := space.SubnetCIDRs()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cidrs, jc.SameContents, []string{"10.0.0.0/24", "10.0.1.0/24"})
+
+	subnet, err := s.State.Subnet("10.0.1.0/24")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(subnet.SpaceName(), gc.Equals, "db")
+	subnet, err = s.State.Subnet("10.0.2.0/24")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(subnet.SpaceName(), gc.Equals, "")
+}
+
+func (s *SpacesSuite) TestAddSpaceInvalidName(c *gc.C) {
+	_, err := s.State.AddSpace("Db_1", nil)
+	c.Assert(err, gc.ErrorMatches, `cannot add space "Db_1": space name "Db_1" not valid`)
+	c.Assert(err, jc.Satisfies, errors.IsNotValid)
+}
+
+func (s *SpacesSuite) TestAddSpaceAlreadyExists(c *gc.C) {
+	_, err := s.State.AddSpace("db", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AddSpace("db", nil)
+	c.Assert(err, gc.ErrorMatches, `cannot add space "db": space "db" already exists`)
+	c.Assert(err, jc.Satisfies, errors.IsAlreadyExists)
+}
+
+func (s *SpacesSuite) TestAddSpaceMissingSubnet(c *gc.C) {
+	_, err := s.State.AddSpace("db", []string{"10.0.0.0/24"})
+	c.Assert(err, gc.ErrorMatches, `cannot add space "db": subnet "10.0.0.0/24" not found`)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	_, err = s.State.Space("db")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *SpacesSuite) TestAddSpaceSubnetInAnotherSpace(c *gc.C) {
+	s.addSubnets(c, "10.0.0.0/24")
+	_, err := s.State.AddSpace("db", []string{"10.0.0.0/24"})
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AddSpace("internal", []string{"10.0.0.0/24"})
+	c.Assert(err, gc.ErrorMatches, `cannot add space "internal": subnet "10.0.0.0/24" already in space "db"`)
+}
+
+func (s *SpacesSuite) TestAllSpaces(c *gc.C) {
+	spaces, err := s.State.AllSpaces()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(spaces, gc.HasLen, 0)
+
+	for _, name := range []string{"internal", "db", "dmz"} {
+		_, err := s.State.AddSpace(name, nil)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	spaces, err = s.State.AllSpaces()
+	c.Assert(err, jc.ErrorIsNil)
+	var names []string
+	for _, space := range spaces {
+		names = append(names, space.Name())
+	}
+	c.Assert(names, jc.DeepEquals, []string{"db", "dmz", "internal"})
+}
+
+func (s *SpacesSuite) TestAddressPolicy(c *gc.C) {
+	s.addSubnets(c, "10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24")
+	_, err := s.State.AddSpace("db", []string{"10.0.1.0/24"})
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AddSpace("internal", []string{"10.0.0.0/24"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	policy, err := s.State.AddressPolicy()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(policy, jc.DeepEquals, network.AddressPolicy{Family: network.IPv4First})
+
+	err = s.State.UpdateEnvironConfig(map[string]interface{}{
+		"preferred-spaces": "internal,missing,db",
+	}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	policy, err = s.State.AddressPolicy()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(policy, jc.DeepEquals, network.AddressPolicy{
+		Family:           network.IPv4First,
+		PreferredSubnets: []string{"10.0.0.0/24", "10.0.1.0/24"},
+	})
+}
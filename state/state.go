@@ -56,6 +56,9 @@ const (
 	unitsC             = "units"
 	subnetsC           = "subnets"
 	ipaddressesC       = "ipaddresses"
+	spacesC            = "spaces"
+	serviceOffersC     = "serviceoffers"
+	remoteServicesC    = "remoteservices"
 
 	// actionsC and related collections store state of Actions that
 	// have been enqueued.
@@ -97,6 +100,10 @@ const (
 	// meterStatusC is the collection used to store meter status information.
 	meterStatusC = "meterStatus"
 
+	// unitHistoryC is the collection used to store the bounded history
+	// of hook and action executions of each unit.
+	unitHistoryC = "unithistory"
+
 	// toolsmetadataC is the collection used to store tools metadata.
 	toolsmetadataC = "toolsmetadata"
 
@@ -139,6 +146,13 @@ type State struct {
 	allManager *storeManager
 	environTag names.EnvironTag
 	serverTag  names.EnvironTag
+	// traceId holds the trace id recorded with the transactions
+	// run by a State returned by WithTraceId, and untraced holds
+	// the State it was derived from.
+	traceId  string
+	untraced *State
+	// addressPolicies caches the environment's address policy.
+	addressPolicies *addressPolicyCache
 }
 
 // StateServingInfo holds information needed by a state server.
@@ -217,6 +231,42 @@ func (st *State) ForEnviron(env names.EnvironTag) (*State, error) {
 	return newState, nil
 }
 
+// WithTraceId returns a State that shares st's connection, watchers
+// and environment, and records the given trace id with each
+// transaction it runs, and on the machine, service and unit documents
+// those transactions change, so that the agents acting on the changes
+// can correlate their work with the originating request. The returned
+// State must not be closed, and is valid only while st is open.
+func (st *State) WithTraceId(id string) *State {
+	if id == "" {
+		return st
+	}
+	untraced := st
+	if st.untraced != nil {
+		untraced = st.untraced
+	}
+	return &State{
+		LeasePersistor:    untraced.LeasePersistor,
+		transactionRunner: untraced.transactionRunner,
+		mongoInfo:         untraced.mongoInfo,
+		policy:            untraced.policy,
+		db:                untraced.db,
+		watcher:           untraced.watcher,
+		pwatcher:          untraced.pwatcher,
+		environTag:        untraced.environTag,
+		serverTag:         untraced.serverTag,
+		traceId:           id,
+		untraced:          untraced,
+		addressPolicies:   untraced.addressPolicies,
+	}
+}
+
+// TraceId returns the trace id recorded with the transactions run by
+// st, if any.
+func (st *State) TraceId() string {
+	return st.traceId
+}
+
 // EnvironTag() returns the environment tag for the environment controlled by
 // this state instance.
 func (st *State) EnvironTag() names.EnvironTag {
@@ -305,6 +355,9 @@ func (st *State) MongoSession() *mgo.Session {
 type closeFunc func()
 
 func (st *State) Watch() *Multiwatcher {
+	if st.untraced != nil {
+		return st.untraced.Watch()
+	}
 	st.mu.Lock()
 	if st.allManager == nil {
 		st.allManager = newStoreManager(newAllWatcherStateBacking(st))
@@ -531,8 +584,11 @@ func (st *State) UpdateEnvironConfig(updateAttrs map[string]interface{}, removeA
 		}
 	}
 	settings.Update(validAttrs)
-	_, err = settings.Write()
-	return errors.Trace(err)
+	if _, err = settings.Write(); err != nil {
+		return errors.Trace(err)
+	}
+	st.addressPolicies.invalidate()
+	return nil
 }
 
 // EnvironConstraints returns the current environment constraints.
@@ -1248,6 +1304,8 @@ func (st *State) AddService(
 		return nil, errors.Trace(err)
 	} else if env.Life() != Alive {
 		return nil, errors.Errorf("environment is no longer alive")
+	} else if env.MigrationMode() != MigrationModeNone {
+		return nil, errors.Errorf("environment is being migrated")
 	}
 	if _, err := st.EnvironmentUser(ownerTag); err != nil {
 		return nil, errors.Trace(err)
@@ -1292,6 +1350,11 @@ func (st *State) AddService(
 				RefCount: 1,
 				EnvUUID:  st.EnvironUUID()},
 		},
+		{
+			C:      remoteServicesC,
+			Id:     serviceID,
+			Assert: txn.DocMissing,
+		},
 		{
 			C:      servicesC,
 			Id:     serviceID,
@@ -1734,23 +1797,10 @@ func (st *State) endpoints(name string, filter func(ep Endpoint) bool) ([]Endpoi
 	} else {
 		return nil, errors.Errorf("invalid endpoint %q", name)
 	}
-	svc, err := st.Service(svcName)
+	eps, err := st.serviceEndpoints(svcName, relName)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	eps := []Endpoint{}
-	if relName != "" {
-		ep, err := svc.Endpoint(relName)
-		if err != nil {
-			return nil, errors.Trace(err)
-		}
-		eps = append(eps, ep)
-	} else {
-		eps, err = svc.Endpoints()
-		if err != nil {
-			return nil, errors.Trace(err)
-		}
-	}
 	final := []Endpoint{}
 	for _, ep := range eps {
 		if filter(ep) {
@@ -1760,7 +1810,42 @@ func (st *State) endpoints(name string, filter func(ep Endpoint) bool) ([]Endpoi
 	return final, nil
 }
 
-// AddRelation creates a new relation with the given endpoints.
+// serviceEndpoints returns the named endpoint of the named service, or
+// all its endpoints if relName is empty. The service may be a remote
+// service.
+func (st *State) serviceEndpoints(svcName, relName string) ([]Endpoint, error) {
+	svc, err := st.Service(svcName)
+	if errors.IsNotFound(err) {
+		remote, remoteErr := st.RemoteService(svcName)
+		if errors.IsNotFound(remoteErr) {
+			return nil, errors.Trace(err)
+		} else if remoteErr != nil {
+			return nil, errors.Trace(remoteErr)
+		}
+		if relName == "" {
+			return remote.Endpoints(), nil
+		}
+		ep, err := remote.Endpoint(relName)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return []Endpoint{ep}, nil
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if relName == "" {
+		return svc.Endpoints()
+	}
+	ep, err := svc.Endpoint(relName)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return []Endpoint{ep}, nil
+}
+
+// AddRelation creates a new relation with the given endpoints. At most
+// one of the endpoints may belong to a remote service, in which case
+// the relation may not have container scope.
 func (st *State) AddRelation(eps ...Endpoint) (r *Relation, err error) {
 	key := relationKey(eps)
 	defer errors.DeferredAnnotatef(&err, "cannot add relation %q", key)
@@ -1799,9 +1884,28 @@ func (st *State) AddRelation(eps ...Endpoint) (r *Relation, err error) {
 		}
 		// Collect per-service operations, checking sanity as we go.
 		var ops []txn.Op
-		var subordinateCount int
+		var subordinateCount, remoteCount int
 		series := map[string]bool{}
 		for _, ep := range eps {
+			remote, err := st.RemoteService(ep.ServiceName)
+			if err == nil {
+				if remote.doc.Life != Alive {
+					return nil, errors.Errorf("service %q is not alive", ep.ServiceName)
+				}
+				if _, err := remote.Endpoint(ep.Name); err != nil {
+					return nil, errors.Errorf("%q does not implement %q", ep.ServiceName, ep)
+				}
+				remoteCount++
+				ops = append(ops, txn.Op{
+					C:      remoteServicesC,
+					Id:     st.docID(ep.ServiceName),
+					Assert: isAliveDoc,
+					Update: bson.D{{"$inc", bson.D{{"relationcount", 1}}}},
+				})
+				continue
+			} else if !errors.IsNotFound(err) {
+				return nil, errors.Trace(err)
+			}
 			svc, err := st.Service(ep.ServiceName)
 			if errors.IsNotFound(err) {
 				return nil, errors.Errorf("service %q does not exist", ep.ServiceName)
@@ -1828,6 +1932,12 @@ func (st *State) AddRelation(eps ...Endpoint) (r *Relation, err error) {
 				Update: bson.D{{"$inc", bson.D{{"relationcount", 1}}}},
 			})
 		}
+		if remoteCount > 1 {
+			return nil, errors.Errorf("cannot relate two remote services")
+		}
+		if remoteCount > 0 && eps[0].Scope == charm.ScopeContainer {
+			return nil, errors.Errorf("cross-environment relations cannot be container scoped")
+		}
 		if matchSeries && len(series) != 1 {
 			return nil, errors.Errorf("principal and subordinate services' series must match")
 		}
@@ -1057,6 +1057,45 @@ func (s *StateSuite) TestAddMachineErrors(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, "cannot add a new machine: duplicate job: .*")
 }
 
+func (s *StateSuite) TestWithTraceId(c *gc.C) {
+	c.Assert(s.State.WithTraceId(""), gc.Equals, s.State)
+	st := s.State.WithTraceId("deploy-1")
+	c.Assert(st.TraceId(), gc.Equals, "deploy-1")
+	c.Assert(s.State.TraceId(), gc.Equals, "")
+
+	// Inserted documents record the trace id.
+	m, err := st.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(m.TraceId(), gc.Equals, "deploy-1")
+	m, err = s.State.Machine(m.Id())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(m.TraceId(), gc.Equals, "deploy-1")
+
+	// So do updated ones.
+	svc := s.Factory.MakeService(c, nil)
+	c.Assert(svc.TraceId(), gc.Equals, "")
+	svc, err = st.Service(svc.Name())
+	c.Assert(err, jc.ErrorIsNil)
+	err = svc.SetExposed()
+	c.Assert(err, jc.ErrorIsNil)
+	svc, err = s.State.Service(svc.Name())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(svc.TraceId(), gc.Equals, "deploy-1")
+
+	// Untraced changes leave the last trace id in place.
+	err = svc.ClearExposed()
+	c.Assert(err, jc.ErrorIsNil)
+	err = svc.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(svc.TraceId(), gc.Equals, "deploy-1")
+
+	// Closing a traced State leaves the original open.
+	err = st.Close()
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.Machine(m.Id())
+	c.Assert(err, jc.ErrorIsNil)
+}
+
 func (s *StateSuite) TestAddMachine(c *gc.C) {
 	allJobs := []state.MachineJob{
 		state.JobHostUnits,
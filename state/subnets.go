@@ -55,6 +55,7 @@ type subnetDoc struct {
 	AllocatableIPLow  string `bson:"allocatableiplow,omitempty"`
 	VLANTag           int    `bson:"vlantag,omitempty"`
 	AvailabilityZone  string `bson:"availabilityzone,omitempty"`
+	SpaceName         string `bson:"spacename,omitempty"`
 }
 
 // Life returns whether the subnet is Alive, Dying or Dead.
@@ -133,7 +134,11 @@ func (s *Subnet) Remove() (err error) {
 		Id:     s.doc.DocID,
 		Remove: true,
 	})
-	return s.st.runTransaction(ops)
+	if err := s.st.runTransaction(ops); err != nil {
+		return err
+	}
+	s.st.addressPolicies.invalidate()
+	return nil
 }
 
 // ProviderId returns the provider-specific id of the subnet.
@@ -168,6 +173,12 @@ func (s *Subnet) AvailabilityZone() string {
 	return s.doc.AvailabilityZone
 }
 
+// SpaceName returns the name of the space the subnet is part of, or
+// the empty string if the subnet is not in a space.
+func (s *Subnet) SpaceName() string {
+	return s.doc.SpaceName
+}
+
 // Validate validates the subnet, checking the CIDR, VLANTag and
 // AllocatableIPHigh and Low, if present.
 func (s *Subnet) Validate() error {
@@ -6,12 +6,15 @@ package state
 import (
 	"fmt"
 	"reflect"
+	"strings"
 
 	jujutxn "github.com/juju/txn"
 	"github.com/juju/utils/set"
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/trace"
 )
 
 const (
@@ -33,7 +36,7 @@ func (st *State) txnRunner(session *mgo.Session) jujutxn.Runner {
 	if st.transactionRunner != nil {
 		return st.transactionRunner
 	}
-	return newMultiEnvRunner(st.EnvironUUID(), st.db.With(session), txnAssertEnvIsAlive)
+	return newMultiEnvRunner(st.EnvironUUID(), st.db.With(session), txnAssertEnvIsAlive, st.traceId)
 }
 
 // txnRunnerNoEnvAliveAssert returns a jujutxn.Runner instance that does not
@@ -44,7 +47,7 @@ func (st *State) txnRunnerNoEnvAliveAssert(session *mgo.Session) jujutxn.Runner
 	if st.transactionRunner != nil {
 		return st.transactionRunner
 	}
-	return newMultiEnvRunner(st.EnvironUUID(), st.db.With(session), txnAssertEnvIsNotAlive)
+	return newMultiEnvRunner(st.EnvironUUID(), st.db.With(session), txnAssertEnvIsNotAlive, st.traceId)
 }
 
 // runTransactionNoEnvAliveAssert is a convenience method delegating to txnRunnerNoEnvAliveAssert.
@@ -75,11 +78,12 @@ func (st *State) ResumeTransactions() error {
 	return st.txnRunner(session).ResumeTransactions()
 }
 
-func newMultiEnvRunner(envUUID string, db *mgo.Database, assertEnvAlive bool) jujutxn.Runner {
+func newMultiEnvRunner(envUUID string, db *mgo.Database, assertEnvAlive bool, traceId string) jujutxn.Runner {
 	return &multiEnvRunner{
 		rawRunner:      jujutxn.NewRunner(jujutxn.RunnerParams{Database: db}),
 		envUUID:        envUUID,
 		assertEnvAlive: assertEnvAlive,
+		traceId:        traceId,
 	}
 }
 
@@ -87,6 +91,10 @@ type multiEnvRunner struct {
 	rawRunner      jujutxn.Runner
 	envUUID        string
 	assertEnvAlive bool
+	// traceId, if set, is logged with each transaction and
+	// recorded on the documents of tracedCollections that
+	// the transaction inserts or updates.
+	traceId string
 }
 
 // RunTransaction is part of the jujutxn.Runner interface. Operations
@@ -94,6 +102,7 @@ type multiEnvRunner struct {
 // to ensure correct interaction with these collections.
 func (r *multiEnvRunner) RunTransaction(ops []txn.Op) error {
 	ops = r.updateOps(ops)
+	r.traceOps(ops)
 	return r.rawRunner.RunTransaction(ops)
 }
 
@@ -110,6 +119,7 @@ func (r *multiEnvRunner) Run(transactions jujutxn.TransactionSource) error {
 			return nil, err
 		}
 		ops = r.updateOps(ops)
+		r.traceOps(ops)
 		return ops, nil
 	})
 }
@@ -153,6 +163,130 @@ func (r *multiEnvRunner) updateOps(ops []txn.Op) []txn.Op {
 	return ops
 }
 
+// tracedCollections holds the collections whose documents record the
+// trace id of the last traced transaction that inserted or updated
+// them, so that the agents acting on them can report it.
+var tracedCollections = set.NewStrings(machinesC, servicesC, unitsC)
+
+// traceOps logs the given operations with the runner's trace id, and
+// records the id on the documents of tracedCollections they insert
+// or update. It does nothing if the runner has no trace id.
+func (r *multiEnvRunner) traceOps(ops []txn.Op) {
+	if r.traceId == "" {
+		return
+	}
+	descs := make([]string, len(ops))
+	for i, op := range ops {
+		kind := "assert"
+		switch {
+		case op.Insert != nil:
+			kind = "insert"
+		case op.Update != nil:
+			kind = "update"
+		case op.Remove:
+			kind = "remove"
+		}
+		descs[i] = fmt.Sprintf("%s %s %v", kind, op.C, op.Id)
+		if !tracedCollections.Contains(op.C) {
+			continue
+		}
+		switch {
+		case op.Insert != nil:
+			ops[i].Insert = insertWithTraceId(op.Insert, r.traceId)
+		case op.Update != nil:
+			ops[i].Update = updateWithTraceId(op.Update, r.traceId)
+		}
+	}
+	trace.NewLogger(logger, r.traceId).Debugf("running transaction: %s", strings.Join(descs, ", "))
+}
+
+// insertWithTraceId returns the given document to insert, with its
+// "traceid" field set to id. Structs without a "traceid" field are
+// returned unchanged.
+func insertWithTraceId(doc interface{}, id string) interface{} {
+	switch doc := doc.(type) {
+	case bson.D:
+		return append(doc, bson.DocElem{"traceid", id})
+	case bson.M:
+		doc["traceid"] = id
+		return doc
+	}
+	v := reflect.ValueOf(doc)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return doc
+	}
+	v = v.Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Split(t.Field(i).Tag.Get("bson"), ",")[0] == "traceid" {
+			v.Field(i).SetString(id)
+		}
+	}
+	return doc
+}
+
+// updateWithTraceId returns the given update operation, extended to
+// also set the "traceid" field of the updated document to id. Updates
+// it cannot extend are returned unchanged.
+func updateWithTraceId(update interface{}, id string) interface{} {
+	traceSet := bson.D{{"traceid", id}}
+	switch update := update.(type) {
+	case bson.D:
+		result := make(bson.D, 0, len(update)+1)
+		found := false
+		for _, elem := range update {
+			if elem.Name == "$set" {
+				setDoc, ok := withTraceIdSet(elem.Value, id)
+				if !ok {
+					return update
+				}
+				elem.Value = setDoc
+				found = true
+			}
+			result = append(result, elem)
+		}
+		if !found {
+			result = append(result, bson.DocElem{"$set", traceSet})
+		}
+		return result
+	case bson.M:
+		result := make(bson.M, len(update)+1)
+		for key, value := range update {
+			result[key] = value
+		}
+		if value, ok := update["$set"]; ok {
+			setDoc, ok := withTraceIdSet(value, id)
+			if !ok {
+				return update
+			}
+			result["$set"] = setDoc
+		} else {
+			result["$set"] = traceSet
+		}
+		return result
+	}
+	return update
+}
+
+// withTraceIdSet returns a copy of the given $set document with the
+// "traceid" field added, and whether that was possible.
+func withTraceIdSet(setDoc interface{}, id string) (interface{}, bool) {
+	switch setDoc := setDoc.(type) {
+	case bson.D:
+		result := make(bson.D, len(setDoc), len(setDoc)+1)
+		copy(result, setDoc)
+		return append(result, bson.DocElem{"traceid", id}), true
+	case bson.M:
+		result := make(bson.M, len(setDoc)+1)
+		for key, value := range setDoc {
+			result[key] = value
+		}
+		result["traceid"] = id
+		return result, true
+	}
+	return nil, false
+}
+
 func assertEnvAliveOp(envUUID string) txn.Op {
 	return txn.Op{
 		C:      environmentsC,
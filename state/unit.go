@@ -104,6 +104,9 @@ type unitDoc struct {
 	Ports          []port `bson:"ports"`
 	PublicAddress  string `bson:"publicaddress"`
 	PrivateAddress string `bson:"privateaddress"`
+	// TraceId holds the trace id of the last traced API request
+	// that changed the unit.
+	TraceId string `bson:"traceid,omitempty"`
 }
 
 // Unit represents the state of a service unit.
@@ -189,6 +192,12 @@ func (u *Unit) Life() Life {
 	return u.doc.Life
 }
 
+// TraceId returns the trace id of the last traced API request that
+// changed the unit, if any.
+func (u *Unit) TraceId() string {
+	return u.doc.TraceId
+}
+
 // AgentTools returns the tools that the agent is currently running.
 // It an error that satisfies errors.IsNotFound if the tools have not
 // yet been set.
@@ -739,7 +748,7 @@ func (u *Unit) PublicAddress() (string, bool) {
 	var publicAddress string
 	addresses := u.addressesOfMachine()
 	if len(addresses) > 0 {
-		publicAddress = network.SelectPublicAddress(addresses)
+		publicAddress = u.st.addressPolicy().SelectPublicAddress(addresses)
 	}
 	return publicAddress, publicAddress != ""
 }
@@ -749,7 +758,7 @@ func (u *Unit) PrivateAddress() (string, bool) {
 	var privateAddress string
 	addresses := u.addressesOfMachine()
 	if len(addresses) > 0 {
-		privateAddress = network.SelectInternalAddress(addresses, false)
+		privateAddress = u.st.addressPolicy().SelectInternalAddress(addresses, false)
 	}
 	return privateAddress, privateAddress != ""
 }
@@ -283,6 +283,74 @@ func (s *UnitSuite) TestPrivateAddress(c *gc.C) {
 	c.Assert(ok, jc.IsTrue)
 }
 
+func (s *UnitSuite) TestPrivateAddressPreferredSpaces(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.unit.AssignToMachine(machine)
+	c.Assert(err, jc.ErrorIsNil)
+	err = machine.SetAddresses(
+		network.NewScopedAddress("10.0.0.5", network.ScopeCloudLocal),
+		network.NewScopedAddress("10.20.0.5", network.ScopeCloudLocal),
+	)
+	c.Assert(err, jc.ErrorIsNil)
+
+	_, err = s.State.AddSubnet(state.SubnetInfo{CIDR: "10.20.0.0/16"})
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AddSpace("db", []string{"10.20.0.0/16"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	address, ok := s.unit.PrivateAddress()
+	c.Check(address, gc.Equals, "10.0.0.5")
+	c.Assert(ok, jc.IsTrue)
+
+	err = s.State.UpdateEnvironConfig(map[string]interface{}{
+		"preferred-spaces": "db",
+	}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	address, ok = s.unit.PrivateAddress()
+	c.Check(address, gc.Equals, "10.20.0.5")
+	c.Assert(ok, jc.IsTrue)
+}
+
+func (s *UnitSuite) TestPrivateAddressFollowsConfigChangedElsewhere(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.unit.AssignToMachine(machine)
+	c.Assert(err, jc.ErrorIsNil)
+	err = machine.SetAddresses(
+		network.NewScopedAddress("10.0.0.5", network.ScopeCloudLocal),
+		network.NewScopedAddress("10.20.0.5", network.ScopeCloudLocal),
+	)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AddSubnet(state.SubnetInfo{CIDR: "10.20.0.0/16"})
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AddSpace("db", []string{"10.20.0.0/16"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	// Cache the address policy.
+	address, ok := s.unit.PrivateAddress()
+	c.Check(address, gc.Equals, "10.0.0.5")
+	c.Assert(ok, jc.IsTrue)
+
+	// Change the config through another connection, so only
+	// the watcher can tell s.State about it.
+	otherState, err := s.State.ForEnviron(s.State.EnvironTag())
+	c.Assert(err, jc.ErrorIsNil)
+	defer otherState.Close()
+	err = otherState.UpdateEnvironConfig(map[string]interface{}{
+		"preferred-spaces": "db",
+	}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		s.State.StartSync()
+		if address, _ = s.unit.PrivateAddress(); address == "10.20.0.5" {
+			break
+		}
+	}
+	c.Assert(address, gc.Equals, "10.20.0.5")
+}
+
 type destroyMachineTestCase struct {
 	target    *state.Unit
 	host      *state.Machine
@@ -1145,8 +1213,8 @@ func (s *UnitSuite) TestOpenedPorts(c *gc.C) {
 	open, err = s.unit.OpenedPorts()
 	c.Assert(err, jc.ErrorIsNil)
 	c.Assert(open, gc.DeepEquals, []network.PortRange{
-		{80, 80, "tcp"},
-		{100, 200, "udp"},
+		{FromPort: 80, ToPort: 80, Protocol: "tcp"},
+		{FromPort: 100, ToPort: 200, Protocol: "udp"},
 	})
 
 	err = s.unit.OpenPort("udp", 53)
@@ -1154,9 +1222,9 @@ func (s *UnitSuite) TestOpenedPorts(c *gc.C) {
 	open, err = s.unit.OpenedPorts()
 	c.Assert(err, jc.ErrorIsNil)
 	c.Assert(open, gc.DeepEquals, []network.PortRange{
-		{80, 80, "tcp"},
-		{53, 53, "udp"},
-		{100, 200, "udp"},
+		{FromPort: 80, ToPort: 80, Protocol: "tcp"},
+		{FromPort: 53, ToPort: 53, Protocol: "udp"},
+		{FromPort: 100, ToPort: 200, Protocol: "udp"},
 	})
 
 	err = s.unit.OpenPorts("tcp", 53, 55)
@@ -1164,10 +1232,10 @@ func (s *UnitSuite) TestOpenedPorts(c *gc.C) {
 	open, err = s.unit.OpenedPorts()
 	c.Assert(err, jc.ErrorIsNil)
 	c.Assert(open, gc.DeepEquals, []network.PortRange{
-		{53, 55, "tcp"},
-		{80, 80, "tcp"},
-		{53, 53, "udp"},
-		{100, 200, "udp"},
+		{FromPort: 53, ToPort: 55, Protocol: "tcp"},
+		{FromPort: 80, ToPort: 80, Protocol: "tcp"},
+		{FromPort: 53, ToPort: 53, Protocol: "udp"},
+		{FromPort: 100, ToPort: 200, Protocol: "udp"},
 	})
 
 	err = s.unit.OpenPort("tcp", 443)
@@ -1175,11 +1243,11 @@ func (s *UnitSuite) TestOpenedPorts(c *gc.C) {
 	open, err = s.unit.OpenedPorts()
 	c.Assert(err, jc.ErrorIsNil)
 	c.Assert(open, gc.DeepEquals, []network.PortRange{
-		{53, 55, "tcp"},
-		{80, 80, "tcp"},
-		{443, 443, "tcp"},
-		{53, 53, "udp"},
-		{100, 200, "udp"},
+		{FromPort: 53, ToPort: 55, Protocol: "tcp"},
+		{FromPort: 80, ToPort: 80, Protocol: "tcp"},
+		{FromPort: 443, ToPort: 443, Protocol: "tcp"},
+		{FromPort: 53, ToPort: 53, Protocol: "udp"},
+		{FromPort: 100, ToPort: 200, Protocol: "udp"},
 	})
 
 	err = s.unit.ClosePort("tcp", 80)
@@ -1187,10 +1255,10 @@ func (s *UnitSuite) TestOpenedPorts(c *gc.C) {
 	open, err = s.unit.OpenedPorts()
 	c.Assert(err, jc.ErrorIsNil)
 	c.Assert(open, gc.DeepEquals, []network.PortRange{
-		{53, 55, "tcp"},
-		{443, 443, "tcp"},
-		{53, 53, "udp"},
-		{100, 200, "udp"},
+		{FromPort: 53, ToPort: 55, Protocol: "tcp"},
+		{FromPort: 443, ToPort: 443, Protocol: "tcp"},
+		{FromPort: 53, ToPort: 53, Protocol: "udp"},
+		{FromPort: 100, ToPort: 200, Protocol: "udp"},
 	})
 
 	err = s.unit.ClosePorts("udp", 100, 200)
@@ -1198,9 +1266,9 @@ func (s *UnitSuite) TestOpenedPorts(c *gc.C) {
 	open, err = s.unit.OpenedPorts()
 	c.Assert(err, jc.ErrorIsNil)
 	c.Assert(open, gc.DeepEquals, []network.PortRange{
-		{53, 55, "tcp"},
-		{443, 443, "tcp"},
-		{53, 53, "udp"},
+		{FromPort: 53, ToPort: 55, Protocol: "tcp"},
+		{FromPort: 443, ToPort: 443, Protocol: "tcp"},
+		{FromPort: 53, ToPort: 53, Protocol: "udp"},
 	})
 }
 
@@ -0,0 +1,200 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juju/errors"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// maxUnitHistory is the number of history entries retained for
+// each unit; older entries are discarded as new ones are added.
+const maxUnitHistory = 100
+
+// UnitHistoryKind identifies what a unit executed.
+type UnitHistoryKind string
+
+const (
+	UnitHistoryHook   UnitHistoryKind = "hook"
+	UnitHistoryAction UnitHistoryKind = "action"
+)
+
+// UnitHistoryEntry records a single hook or action execution by a unit.
+type UnitHistoryEntry struct {
+	// Kind records whether a hook or an action was executed.
+	Kind UnitHistoryKind
+
+	// Name is the name of the hook or action.
+	Name string
+
+	// Relation identifies the relation for a relation hook,
+	// in the form "<endpoint>:<relation id>".
+	Relation string
+
+	// RemoteUnit is the name of the remote unit for a relation
+	// hook, if any.
+	RemoteUnit string
+
+	// Started and Finished record when execution started and
+	// completed.
+	Started  time.Time
+	Finished time.Time
+
+	// ExitStatus is the exit status of the process executed.
+	ExitStatus int
+
+	// Error describes why the execution failed, if it did.
+	Error string
+
+	// StderrTail holds the last part of the standard error output
+	// of the execution.
+	StderrTail string
+}
+
+// unitHistoryDoc is the persistent representation of a UnitHistoryEntry.
+type unitHistoryDoc struct {
+	DocID      string    `bson:"_id"`
+	EnvUUID    string    `bson:"env-uuid"`
+	Unit       string    `bson:"unit"`
+	Kind       string    `bson:"kind"`
+	Name       string    `bson:"name"`
+	Relation   string    `bson:"relation,omitempty"`
+	RemoteUnit string    `bson:"remoteunit,omitempty"`
+	Started    time.Time `bson:"started"`
+	Finished   time.Time `bson:"finished"`
+	ExitStatus int       `bson:"exitstatus"`
+	Error      string    `bson:"error,omitempty"`
+	StderrTail string    `bson:"stderrtail,omitempty"`
+}
+
+func (doc *unitHistoryDoc) entry() UnitHistoryEntry {
+	return UnitHistoryEntry{
+		Kind:       UnitHistoryKind(doc.Kind),
+		Name:       doc.Name,
+		Relation:   doc.Relation,
+		RemoteUnit: doc.RemoteUnit,
+		Started:    doc.Started.UTC(),
+		Finished:   doc.Finished.UTC(),
+		ExitStatus: doc.ExitStatus,
+		Error:      doc.Error,
+		StderrTail: doc.StderrTail,
+	}
+}
+
+// unitHistoryId returns a new, unique local id for an entry in the
+// named unit's history.
+func unitHistoryId(unitName string) string {
+	return fmt.Sprintf("%s#%s", unitName, bson.NewObjectId().Hex())
+}
+
+// AddHistory records the supplied hook and action executions in the
+// unit's history. Only the most recent entries are retained.
+func (u *Unit) AddHistory(entries ...UnitHistoryEntry) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot add history for unit %q", u)
+	if len(entries) == 0 {
+		return nil
+	}
+	ops := []txn.Op{{
+		C:      unitsC,
+		Id:     u.doc.DocID,
+		Assert: txn.DocExists,
+	}}
+	for _, entry := range entries {
+		switch entry.Kind {
+		case UnitHistoryHook, UnitHistoryAction:
+		default:
+			return errors.NotValidf("history entry kind %q", entry.Kind)
+		}
+		doc := &unitHistoryDoc{
+			DocID:      u.st.docID(unitHistoryId(u.Name())),
+			EnvUUID:    u.st.EnvironUUID(),
+			Unit:       u.Name(),
+			Kind:       string(entry.Kind),
+			Name:       entry.Name,
+			Relation:   entry.Relation,
+			RemoteUnit: entry.RemoteUnit,
+			Started:    entry.Started.UTC(),
+			Finished:   entry.Finished.UTC(),
+			ExitStatus: entry.ExitStatus,
+			Error:      entry.Error,
+			StderrTail: entry.StderrTail,
+		}
+		ops = append(ops, txn.Op{
+			C:      unitHistoryC,
+			Id:     doc.DocID,
+			Assert: txn.DocMissing,
+			Insert: doc,
+		})
+	}
+	// Asserting the unit's existence ensures that no history can be
+	// added once the unit has been removed, and so the history is
+	// never orphaned.
+	if err := u.st.runTransaction(ops); err == txn.ErrAborted {
+		return errors.NotFoundf("unit")
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Annotate(
+		pruneUnitHistory(u.st, u.Name(), maxUnitHistory),
+		"cannot prune history",
+	)
+}
+
+// History returns the recorded hook and action executions of the unit,
+// oldest first.
+func (u *Unit) History() ([]UnitHistoryEntry, error) {
+	history, closer := u.st.getCollection(unitHistoryC)
+	defer closer()
+
+	var docs []unitHistoryDoc
+	err := history.Find(bson.D{{"unit", u.Name()}}).Sort("started", "_id").All(&docs)
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot get history for unit %q", u)
+	}
+	entries := make([]UnitHistoryEntry, len(docs))
+	for i, doc := range docs {
+		entries[i] = doc.entry()
+	}
+	return entries, nil
+}
+
+// pruneUnitHistory removes all but the most recent keep entries
+// from the named unit's history.
+func pruneUnitHistory(st *State, unitName string, keep int) error {
+	history, closer := st.getCollection(unitHistoryC)
+	defer closer()
+
+	var docs []struct {
+		DocID string `bson:"_id"`
+	}
+	query := history.Find(bson.D{{"unit", unitName}})
+	err := query.Sort("-started", "-_id").Skip(keep).Select(bson.D{{"_id", 1}}).All(&docs)
+	if err != nil || len(docs) == 0 {
+		return errors.Trace(err)
+	}
+	ops := make([]txn.Op, len(docs))
+	for i, doc := range docs {
+		ops[i] = txn.Op{
+			C:      unitHistoryC,
+			Id:     doc.DocID,
+			Remove: true,
+		}
+	}
+	return errors.Trace(st.runTransaction(ops))
+}
+
+// removeUnitHistory removes all history recorded for the named unit.
+// It is run by the cleanup queued when the unit is removed; as history
+// cannot be added to a unit that no longer exists, nothing will be
+// added after it has run.
+func removeUnitHistory(st *State, unitName string) error {
+	return errors.Annotatef(
+		pruneUnitHistory(st, unitName, 0),
+		"cannot remove history for unit %q", unitName,
+	)
+}
@@ -0,0 +1,134 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/testing/factory"
+)
+
+type UnitHistorySuite struct {
+	ConnSuite
+	unit *state.Unit
+}
+
+var _ = gc.Suite(&UnitHistorySuite{})
+
+func (s *UnitHistorySuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.unit = s.factory.MakeUnit(c, nil)
+}
+
+func (s *UnitHistorySuite) TestAddHistory(c *gc.C) {
+	t0 := time.Date(2015, 5, 1, 12, 0, 0, 0, time.UTC)
+	entries := []state.UnitHistoryEntry{{
+		Kind:     state.UnitHistoryHook,
+		Name:     "install",
+		Started:  t0,
+		Finished: t0.Add(time.Second),
+	}, {
+		Kind:       state.UnitHistoryHook,
+		Name:       "db-relation-joined",
+		Relation:   "db:2",
+		RemoteUnit: "mysql/0",
+		Started:    t0.Add(2 * time.Second),
+		Finished:   t0.Add(3 * time.Second),
+		ExitStatus: 1,
+		Error:      "exit status 1",
+		StderrTail: "oops\n",
+	}}
+	err := s.unit.AddHistory(entries[1])
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.unit.AddHistory(entries[0])
+	c.Assert(err, jc.ErrorIsNil)
+
+	history, err := s.unit.History()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, jc.DeepEquals, entries)
+}
+
+func (s *UnitHistorySuite) TestAddHistoryInvalidKind(c *gc.C) {
+	err := s.unit.AddHistory(state.UnitHistoryEntry{Kind: "magic", Name: "foo"})
+	c.Assert(err, gc.ErrorMatches, `history entry kind "magic" not valid`)
+}
+
+func (s *UnitHistorySuite) TestHistoryIsPerUnit(c *gc.C) {
+	other := s.factory.MakeUnit(c, &factory.UnitParams{Service: s.unit.ServiceName()})
+	err := s.unit.AddHistory(state.UnitHistoryEntry{Kind: state.UnitHistoryHook, Name: "install"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	history, err := other.History()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 0)
+}
+
+func (s *UnitHistorySuite) TestHistoryIsBounded(c *gc.C) {
+	t0 := time.Date(2015, 5, 1, 12, 0, 0, 0, time.UTC)
+	var entries []state.UnitHistoryEntry
+	for i := 0; i < state.MaxUnitHistory+10; i++ {
+		entries = append(entries, state.UnitHistoryEntry{
+			Kind:    state.UnitHistoryAction,
+			Name:    fmt.Sprintf("action-%d", i),
+			Started: t0.Add(time.Duration(i) * time.Second),
+		})
+	}
+	err := s.unit.AddHistory(entries...)
+	c.Assert(err, jc.ErrorIsNil)
+
+	history, err := s.unit.History()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, state.MaxUnitHistory)
+	c.Assert(history[0].Name, gc.Equals, "action-10")
+	c.Assert(history[len(history)-1].Name, gc.Equals, fmt.Sprintf("action-%d", state.MaxUnitHistory+9))
+}
+
+func (s *UnitHistorySuite) TestHistoryIncludesEnvUUID(c *gc.C) {
+	err := s.unit.AddHistory(state.UnitHistoryEntry{Kind: state.UnitHistoryHook, Name: "install"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	var docs []bson.M
+	err = s.MgoSuite.Session.DB("juju").C(state.UnitHistoryC).Find(nil).All(&docs)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(docs, gc.HasLen, 1)
+	c.Assert(docs[0]["env-uuid"], gc.Equals, s.State.EnvironUUID())
+	c.Assert(docs[0]["_id"], gc.Matches, s.State.EnvironUUID()+":"+s.unit.Name()+"#.*")
+}
+
+func (s *UnitHistorySuite) TestRemoveUnitRemovesHistory(c *gc.C) {
+	err := s.unit.AddHistory(state.UnitHistoryEntry{Kind: state.UnitHistoryHook, Name: "install"})
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.unit.EnsureDead()
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.unit.Remove()
+	c.Assert(err, jc.ErrorIsNil)
+
+	history, err := s.unit.History()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 1)
+
+	err = s.State.Cleanup()
+	c.Assert(err, jc.ErrorIsNil)
+	history, err = s.unit.History()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 0)
+}
+
+func (s *UnitHistorySuite) TestAddHistoryRemovedUnit(c *gc.C) {
+	err := s.unit.EnsureDead()
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.unit.Remove()
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = s.unit.AddHistory(state.UnitHistoryEntry{Kind: state.UnitHistoryHook, Name: "install"})
+	c.Assert(err, gc.ErrorMatches, `cannot add history for unit "[^"]*": unit not found`)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
@@ -1593,7 +1593,7 @@ func (s *upgradesSuite) setUpPortsMigration(c *gc.C) ([]*Machine, map[int][]*Uni
 }
 
 func (s *upgradesSuite) newRange(from, to int, proto string) network.PortRange {
-	return network.PortRange{from, to, proto}
+	return network.PortRange{FromPort: from, ToPort: to, Protocol: proto}
 }
 
 func (s *upgradesSuite) assertInitialMachinePorts(c *gc.C, machines []*Machine, units map[int][]*Unit) {
@@ -169,6 +169,133 @@ func (st *State) WatchEnvironments() StringsWatcher {
 	return newLifecycleWatcher(st, environmentsC, nil, nil, nil)
 }
 
+// WatchEnvironmentMigrations returns a StringsWatcher that notifies of
+// changes to the migration modes of all environments, as when they
+// start or finish moving between controllers. The first event holds
+// the UUIDs of the environments being migrated.
+func (st *State) WatchEnvironmentMigrations() StringsWatcher {
+	return newMigrationModeWatcher(st)
+}
+
+// migrationModeWatcher notifies of changes to the migration modes of
+// environments.
+type migrationModeWatcher struct {
+	commonWatcher
+	out chan []string
+
+	// modes holds the most recent known migration modes of the
+	// environments.
+	modes map[string]MigrationMode
+}
+
+var _ Watcher = (*migrationModeWatcher)(nil)
+
+func newMigrationModeWatcher(st *State) StringsWatcher {
+	w := &migrationModeWatcher{
+		commonWatcher: commonWatcher{st: st},
+		out:           make(chan []string),
+		modes:         make(map[string]MigrationMode),
+	}
+	go func() {
+		defer w.tomb.Done()
+		defer close(w.out)
+		w.tomb.Kill(w.loop())
+	}()
+	return w
+}
+
+type migrationModeDoc struct {
+	UUID          string        `bson:"_id"`
+	MigrationMode MigrationMode `bson:"migration-mode"`
+}
+
+var migrationModeFields = bson.D{{"_id", 1}, {"migration-mode", 1}}
+
+// Changes returns the event channel for the migrationModeWatcher.
+func (w *migrationModeWatcher) Changes() <-chan []string {
+	return w.out
+}
+
+func (w *migrationModeWatcher) initial() (set.Strings, error) {
+	environments, closer := w.st.getCollection(environmentsC)
+	defer closer()
+
+	ids := make(set.Strings)
+	var doc migrationModeDoc
+	iter := environments.Find(nil).Select(migrationModeFields).Iter()
+	for iter.Next(&doc) {
+		w.modes[doc.UUID] = doc.MigrationMode
+		if doc.MigrationMode != MigrationModeNone {
+			ids.Add(doc.UUID)
+		}
+	}
+	return ids, iter.Close()
+}
+
+func (w *migrationModeWatcher) merge(ids set.Strings, updates map[interface{}]bool) error {
+	environments, closer := w.st.getCollection(environmentsC)
+	defer closer()
+
+	for id, exists := range updates {
+		uuid, ok := id.(string)
+		if !ok {
+			return errors.Errorf("id is not of type string, got %T", id)
+		}
+		// Removed environments are reported by WatchEnvironments.
+		var doc migrationModeDoc
+		if exists {
+			err := environments.FindId(uuid).Select(migrationModeFields).One(&doc)
+			if err == mgo.ErrNotFound {
+				exists = false
+			} else if err != nil {
+				return err
+			}
+		}
+		if !exists {
+			delete(w.modes, uuid)
+			continue
+		}
+		if w.modes[uuid] != doc.MigrationMode {
+			w.modes[uuid] = doc.MigrationMode
+			ids.Add(uuid)
+		}
+	}
+	return nil
+}
+
+func (w *migrationModeWatcher) loop() error {
+	in := make(chan watcher.Change)
+	w.st.watcher.WatchCollection(environmentsC, in)
+	defer w.st.watcher.UnwatchCollection(environmentsC, in)
+	ids, err := w.initial()
+	if err != nil {
+		return err
+	}
+	out := w.out
+	for {
+		select {
+		case <-w.tomb.Dying():
+			return tomb.ErrDying
+		case <-w.st.watcher.Dead():
+			return stateWatcherDeadError(w.st.watcher.Err())
+		case ch := <-in:
+			updates, ok := collect(ch, in, w.tomb.Dying())
+			if !ok {
+				return tomb.ErrDying
+			}
+			if err := w.merge(ids, updates); err != nil {
+				return err
+			}
+			if !ids.IsEmpty() {
+				out = w.out
+			}
+		case out <- ids.Values():
+			ids = make(set.Strings)
+			out = nil
+		}
+	}
+}
+
 // WatchIPAddresses returns a StringsWatcher that notifies of changes to the
 // lifecycles of IP addresses.
 func (st *State) WatchIPAddresses() StringsWatcher {
@@ -550,6 +677,194 @@ func (w *lifecycleWatcher) loop() error {
 	}
 }
 
+// relatedAddressesWatcher notifies of changes to the private addresses
+// of the units of the services related to a service.
+type relatedAddressesWatcher struct {
+	commonWatcher
+	service *Service
+	out     chan struct{}
+}
+
+var _ NotifyWatcher = (*relatedAddressesWatcher)(nil)
+
+// WatchRelatedAddresses returns a NotifyWatcher that notifies of changes
+// to the result of RelatedPrivateAddresses. The addresses are recomputed
+// only when a relation of the service, a unit of a related service, or
+// a machine hosting such a unit changes.
+func (s *Service) WatchRelatedAddresses() NotifyWatcher {
+	w := &relatedAddressesWatcher{
+		commonWatcher: commonWatcher{st: s.st},
+		service:       &Service{st: s.st, doc: s.doc},
+		out:           make(chan struct{}),
+	}
+	go func() {
+		defer w.tomb.Done()
+		defer close(w.out)
+		w.tomb.Kill(w.loop())
+	}()
+	return w
+}
+
+// Changes returns the event channel for w.
+func (w *relatedAddressesWatcher) Changes() <-chan struct{} {
+	return w.out
+}
+
+// relationInvolves returns whether the relation with the given key has
+// an endpoint of the named service.
+func relationInvolves(key, serviceName string) bool {
+	for _, endpoint := range strings.Fields(key) {
+		if strings.SplitN(endpoint, ":", 2)[0] == serviceName {
+			return true
+		}
+	}
+	return false
+}
+
+// affected returns whether any of the changed documents, with ids taken
+// from the named collection, may change the related units' addresses.
+func (w *relatedAddressesWatcher) affected(related *relatedUnits, collName string, ids map[interface{}]bool) bool {
+	for id := range ids {
+		localID := w.st.localID(id.(string))
+		switch collName {
+		case relationsC:
+			if relationInvolves(localID, w.service.doc.Name) {
+				return true
+			}
+		case unitsC:
+			if serviceName, err := names.UnitService(localID); err == nil && related.services.Contains(serviceName) {
+				return true
+			}
+		case machinesC:
+			if related.machines.Contains(localID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (w *relatedAddressesWatcher) loop() error {
+	collNames := []string{relationsC, unitsC, machinesC}
+	ins := make([]chan watcher.Change, len(collNames))
+	for i, collName := range collNames {
+		ins[i] = make(chan watcher.Change)
+		w.st.watcher.WatchCollectionWithFilter(collName, ins[i], w.st.isForStateEnv)
+		defer w.st.watcher.UnwatchCollection(collName, ins[i])
+	}
+	related, err := w.service.relatedUnits()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	out := w.out
+	for {
+		var collName string
+		var ids map[interface{}]bool
+		var ok bool
+		select {
+		case <-w.tomb.Dying():
+			return tomb.ErrDying
+		case <-w.st.watcher.Dead():
+			return stateWatcherDeadError(w.st.watcher.Err())
+		case ch := <-ins[0]:
+			collName = collNames[0]
+			ids, ok = collect(ch, ins[0], w.tomb.Dying())
+		case ch := <-ins[1]:
+			collName = collNames[1]
+			ids, ok = collect(ch, ins[1], w.tomb.Dying())
+		case ch := <-ins[2]:
+			collName = collNames[2]
+			ids, ok = collect(ch, ins[2], w.tomb.Dying())
+		case out <- struct{}{}:
+			out = nil
+			continue
+		}
+		if !ok {
+			return tomb.ErrDying
+		}
+		if !w.affected(related, collName, ids) {
+			continue
+		}
+		latest, err := w.service.relatedUnits()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !latest.addresses.Difference(related.addresses).IsEmpty() ||
+			!related.addresses.Difference(latest.addresses).IsEmpty() {
+			out = w.out
+		}
+		related = latest
+	}
+}
+
+// remoteRelationsWatcher notifies of changes that may require the
+// relations of an environment's remote services to be synchronised
+// with the environments on the other side of them.
+type remoteRelationsWatcher struct {
+	commonWatcher
+	out chan struct{}
+}
+
+var _ NotifyWatcher = (*remoteRelationsWatcher)(nil)
+
+// WatchRemoteRelations returns a NotifyWatcher that notifies when the
+// environment's remote services or service offers change, or when a
+// relation, the units in the scope of a relation, or their relation
+// settings change.
+func (st *State) WatchRemoteRelations() NotifyWatcher {
+	w := &remoteRelationsWatcher{
+		commonWatcher: commonWatcher{st: st},
+		out:           make(chan struct{}),
+	}
+	go func() {
+		defer w.tomb.Done()
+		defer close(w.out)
+		w.tomb.Kill(w.loop())
+	}()
+	return w
+}
+
+// Changes returns the event channel for w.
+func (w *remoteRelationsWatcher) Changes() <-chan struct{} {
+	return w.out
+}
+
+// isRelationSettings returns whether the settings document with the
+// given id holds the settings of a unit in a relation of the
+// watcher's environment.
+func (w *remoteRelationsWatcher) isRelationSettings(id interface{}) bool {
+	localID, err := w.st.strictLocalID(id.(string))
+	return err == nil && strings.HasPrefix(localID, "r#")
+}
+
+func (w *remoteRelationsWatcher) loop() error {
+	filters := map[string]func(interface{}) bool{
+		remoteServicesC: w.st.isForStateEnv,
+		serviceOffersC:  w.st.isForStateEnv,
+		relationsC:      w.st.isForStateEnv,
+		relationScopesC: w.st.isForStateEnv,
+		settingsC:       w.isRelationSettings,
+	}
+	in := make(chan watcher.Change)
+	for collName, filter := range filters {
+		w.st.watcher.WatchCollectionWithFilter(collName, in, filter)
+		defer w.st.watcher.UnwatchCollection(collName, in)
+	}
+	out := w.out
+	for {
+		select {
+		case <-w.tomb.Dying():
+			return tomb.ErrDying
+		case <-w.st.watcher.Dead():
+			return stateWatcherDeadError(w.st.watcher.Err())
+		case <-in:
+			out = w.out
+		case out <- struct{}{}:
+			out = nil
+		}
+	}
+}
+
 // minUnitsWatcher notifies about MinUnits changes of the services requiring
 // a minimum number of units to be alive. The first event returned by the
 // watcher is the set of service names requiring a minimum number of units.
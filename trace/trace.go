@@ -0,0 +1,112 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package trace correlates the log messages and state changes that
+// result from a single operation, such as a CLI command, across the
+// API server and the agents that act on its results.
+package trace
+
+import (
+	"regexp"
+
+	"github.com/juju/loggo"
+)
+
+// LabelPrefix prefixes the trace labels in log messages.
+const LabelPrefix = "trace:"
+
+// MaxIdLength holds the maximum length of a trace id.
+const MaxIdLength = 64
+
+// validId matches valid trace ids. The charset is restricted so that
+// a label never contains whitespace or formatting directives, and
+// always forms a single field of a log line.
+var validId = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// IsValidId reports whether id is a valid trace id.
+func IsValidId(id string) bool {
+	return len(id) <= MaxIdLength && validId.MatchString(id)
+}
+
+// Label returns the label that marks log messages relating to the
+// given trace id, so that they can be found with juju debug-log. It
+// returns the empty string if id is empty.
+func Label(id string) string {
+	if id == "" {
+		return ""
+	}
+	return LabelPrefix + id
+}
+
+// Logger wraps a loggo.Logger, marking every message it logs with
+// the label of a trace id.
+type Logger struct {
+	logger loggo.Logger
+	id     string
+}
+
+// NewLogger returns a Logger that logs to logger, marking messages
+// with the label of the given trace id. If id is empty, messages are
+// logged unchanged.
+func NewLogger(logger loggo.Logger, id string) Logger {
+	return Logger{logger: logger, id: id}
+}
+
+// Id returns the logger's trace id.
+func (l Logger) Id() string {
+	return l.id
+}
+
+func (l Logger) format(format string) string {
+	if l.id == "" {
+		return format
+	}
+	// The label cannot contain formatting directives.
+	return Label(l.id) + " " + format
+}
+
+// LogCallf logs a message at the given level, attributing it to the
+// caller at the given depth, as loggo.Logger.LogCallf does.
+func (l Logger) LogCallf(calldepth int, level loggo.Level, format string, args ...interface{}) {
+	l.logger.LogCallf(calldepth+1, level, l.format(format), args...)
+}
+
+// Criticalf logs a message at the critical level.
+func (l Logger) Criticalf(format string, args ...interface{}) {
+	l.logger.LogCallf(1, loggo.CRITICAL, l.format(format), args...)
+}
+
+// Errorf logs a message at the error level.
+func (l Logger) Errorf(format string, args ...interface{}) {
+	l.logger.LogCallf(1, loggo.ERROR, l.format(format), args...)
+}
+
+// Warningf logs a message at the warning level.
+func (l Logger) Warningf(format string, args ...interface{}) {
+	l.logger.LogCallf(1, loggo.WARNING, l.format(format), args...)
+}
+
+// Infof logs a message at the info level.
+func (l Logger) Infof(format string, args ...interface{}) {
+	l.logger.LogCallf(1, loggo.INFO, l.format(format), args...)
+}
+
+// Debugf logs a message at the debug level.
+func (l Logger) Debugf(format string, args ...interface{}) {
+	l.logger.LogCallf(1, loggo.DEBUG, l.format(format), args...)
+}
+
+// Tracef logs a message at the trace level.
+func (l Logger) Tracef(format string, args ...interface{}) {
+	l.logger.LogCallf(1, loggo.TRACE, l.format(format), args...)
+}
+
+// IsTraceEnabled reports whether the trace level is enabled.
+func (l Logger) IsTraceEnabled() bool {
+	return l.logger.IsTraceEnabled()
+}
+
+// IsDebugEnabled reports whether the debug level is enabled.
+func (l Logger) IsDebugEnabled() bool {
+	return l.logger.IsDebugEnabled()
+}
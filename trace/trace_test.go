@@ -0,0 +1,73 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package trace_test
+
+import (
+	"strings"
+	stdtesting "testing"
+
+	"github.com/juju/loggo"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/trace"
+)
+
+func Test(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type traceSuite struct{}
+
+var _ = gc.Suite(&traceSuite{})
+
+func (*traceSuite) SetUpTest(c *gc.C) {
+	loggo.ResetLoggers()
+	loggo.ResetWriters()
+	err := loggo.ConfigureLoggers(`<root>=ERROR; trace.test=DEBUG`)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (*traceSuite) TestIsValidId(c *gc.C) {
+	for i, test := range []struct {
+		id    string
+		valid bool
+	}{
+		{"deploy-1", true},
+		{"2015.06.01_a", true},
+		{"A", true},
+		{strings.Repeat("a", trace.MaxIdLength), true},
+		{"", false},
+		{"-leading-dash", false},
+		{"with space", false},
+		{"tab\there", false},
+		{"new\nline", false},
+		{"percent%d", false},
+		{"colon:id", false},
+		{strings.Repeat("a", trace.MaxIdLength+1), false},
+	} {
+		c.Logf("test %d: %q", i, test.id)
+		c.Check(trace.IsValidId(test.id), gc.Equals, test.valid)
+	}
+}
+
+func (*traceSuite) TestLabel(c *gc.C) {
+	c.Assert(trace.Label("deploy-1"), gc.Equals, "trace:deploy-1")
+	c.Assert(trace.Label(""), gc.Equals, "")
+}
+
+func (*traceSuite) TestLogger(c *gc.C) {
+	var tw loggo.TestWriter
+	c.Assert(loggo.RegisterWriter("trace-log", &tw, loggo.DEBUG), gc.IsNil)
+
+	logger := loggo.GetLogger("trace.test")
+	trace.NewLogger(logger, "deploy-1").Infof("started %d", 1)
+	trace.NewLogger(logger, "").Debugf("started %d", 2)
+	trace.NewLogger(logger, "deploy-1").Tracef("not logged")
+
+	c.Check(tw.Log(), jc.LogMatches, []jc.SimpleMessage{
+		{loggo.INFO, `trace:deploy-1 started 1`},
+		{loggo.DEBUG, `started 2`},
+	})
+}
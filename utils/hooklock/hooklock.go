@@ -0,0 +1,214 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package hooklock provides the machine-wide lock that guards the
+// execution of hooks by the unit agents on a machine.
+//
+// The lock behaves as a semaphore. Any number of shared holders, up to
+// the concurrency they request, may hold it at once; an exclusive
+// holder waits for all shared holders to release it, and prevents any
+// others from acquiring it until it is itself released.
+//
+// It is implemented with a "gate" fslock, which is held by exclusive
+// holders for the duration and by shared holders only while they
+// acquire one of a number of "slot" fslocks.
+package hooklock
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+	"github.com/juju/utils/fslock"
+)
+
+var logger = loggo.GetLogger("juju.utils.hooklock")
+
+const (
+	// gateName is the name of the fslock held by exclusive holders.
+	// It is the name of the lock used before hooks could be run
+	// concurrently, so an agent from an older version excludes
+	// exclusive holders; shared holders do not hold it while their
+	// hooks run, and are not excluded.
+	gateName = "uniter-hook-execution"
+
+	// slotPrefix prefixes the names of the fslocks held by shared
+	// holders.
+	slotPrefix = gateName + "-slot-"
+)
+
+// errBusy is returned by a continue func to abandon an attempt to
+// acquire a slot that is already held.
+var errBusy = errors.New("slot busy")
+
+// Lock is a machine-wide hook execution lock.
+type Lock struct {
+	lockDir string
+	gate    *fslock.Lock
+
+	mu    sync.Mutex
+	slots []*fslock.Lock
+}
+
+// New returns a new Lock whose fslocks are kept in lockDir.
+func New(lockDir string) (*Lock, error) {
+	gate, err := fslock.NewLock(lockDir, gateName)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &Lock{
+		lockDir: lockDir,
+		gate:    gate,
+	}, nil
+}
+
+// Lock acquires the lock exclusively, blocking until it is acquired.
+func (l *Lock) Lock(message string) error {
+	return l.LockWithFunc(message, func() error { return nil })
+}
+
+// LockWithFunc acquires the lock exclusively. While waiting, it calls
+// continueFunc periodically, and gives up, returning the error, if the
+// call fails.
+func (l *Lock) LockWithFunc(message string, continueFunc func() error) error {
+	if err := l.gate.LockWithFunc(message, continueFunc); err != nil {
+		return err
+	}
+	// With the gate held no more slots can be acquired, so we wait
+	// only for the current shared holders to finish.
+	names, err := l.slotNames()
+	if err != nil {
+		l.gate.Unlock()
+		return errors.Trace(err)
+	}
+	var slots []*fslock.Lock
+	for _, name := range names {
+		slot, err := fslock.NewLock(l.lockDir, name)
+		if err == nil {
+			err = slot.LockWithFunc(message, continueFunc)
+		}
+		if err != nil {
+			unlockAll(slots)
+			l.gate.Unlock()
+			return err
+		}
+		slots = append(slots, slot)
+	}
+	l.mu.Lock()
+	l.slots = slots
+	l.mu.Unlock()
+	return nil
+}
+
+// Unlock releases an exclusively held lock.
+func (l *Lock) Unlock() error {
+	l.mu.Lock()
+	slots := l.slots
+	l.slots = nil
+	l.mu.Unlock()
+	unlockAll(slots)
+	return l.gate.Unlock()
+}
+
+// LockShared acquires the lock shared with at most concurrency-1 other
+// shared holders. While waiting, it calls continueFunc periodically,
+// and gives up, returning the error, if the call fails. On success, it
+// returns a function that releases the lock.
+func (l *Lock) LockShared(message string, concurrency int, continueFunc func() error) (func() error, error) {
+	if concurrency < 1 {
+		return nil, errors.NotValidf("hook concurrency %d", concurrency)
+	}
+	if err := l.gate.LockWithFunc(message, continueFunc); err != nil {
+		return nil, err
+	}
+	defer l.gate.Unlock()
+	for {
+		for i := 0; i < concurrency; i++ {
+			slot, err := fslock.NewLock(l.lockDir, fmt.Sprintf("%s%d", slotPrefix, i))
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			err = slot.LockWithFunc(message, func() error { return errBusy })
+			if err == nil {
+				return slot.Unlock, nil
+			} else if errors.Cause(err) != errBusy {
+				return nil, errors.Trace(err)
+			}
+		}
+		if err := continueFunc(); err != nil {
+			return nil, err
+		}
+		time.Sleep(fslock.LockWaitDelay)
+	}
+}
+
+// IsLocked returns whether the lock is held exclusively, or is being
+// acquired exclusively.
+func (l *Lock) IsLocked() bool {
+	return l.gate.IsLocked()
+}
+
+// Message returns the message recorded by the exclusive holder, if any.
+func (l *Lock) Message() string {
+	return l.gate.Message()
+}
+
+// BreakLocks forcibly releases every part of the lock held with a
+// message for which match returns true. It is intended for use by
+// an agent restarting after dying while holding the lock.
+func (l *Lock) BreakLocks(match func(message string) bool) error {
+	names, err := l.slotNames()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	locks := []*fslock.Lock{l.gate}
+	for _, name := range names {
+		slot, err := fslock.NewLock(l.lockDir, name)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		locks = append(locks, slot)
+	}
+	for _, lock := range locks {
+		if message := lock.Message(); lock.IsLocked() && match(message) {
+			logger.Infof("breaking hook execution lock held with message %q", message)
+			if err := lock.BreakLock(); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	return nil
+}
+
+// slotNames returns the names of all slot fslocks that currently
+// exist in the lock directory; an fslock exists only while held.
+func (l *Lock) slotNames() ([]string, error) {
+	dir, err := os.Open(l.lockDir)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer dir.Close()
+	entries, err := dir.Readdirnames(-1)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry, slotPrefix) {
+			names = append(names, entry)
+		}
+	}
+	return names, nil
+}
+
+func unlockAll(locks []*fslock.Lock) {
+	for _, lock := range locks {
+		if err := lock.Unlock(); err != nil {
+			logger.Warningf("cannot release hook execution lock: %v", err)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package hooklock_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils/fslock"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/utils/hooklock"
+)
+
+type lockSuite struct {
+	testing.IsolationSuite
+	lockDir string
+}
+
+var _ = gc.Suite(&lockSuite{})
+
+func (s *lockSuite) SetUpTest(c *gc.C) {
+	s.IsolationSuite.SetUpTest(c)
+	s.lockDir = c.MkDir()
+	s.PatchValue(&fslock.LockWaitDelay, time.Millisecond)
+}
+
+func (s *lockSuite) newLock(c *gc.C) *hooklock.Lock {
+	lock, err := hooklock.New(s.lockDir)
+	c.Assert(err, jc.ErrorIsNil)
+	return lock
+}
+
+var errGiveUp = errors.New("give up")
+
+// giveUpAfter returns a continue func that fails once d has elapsed.
+func giveUpAfter(d time.Duration) func() error {
+	deadline := time.Now().Add(d)
+	return func() error {
+		if time.Now().After(deadline) {
+			return errGiveUp
+		}
+		return nil
+	}
+}
+
+func (s *lockSuite) TestSharedUpToConcurrency(c *gc.C) {
+	lock1, lock2, lock3 := s.newLock(c), s.newLock(c), s.newLock(c)
+	unlock1, err := lock1.LockShared("one", 2, giveUpAfter(0))
+	c.Assert(err, jc.ErrorIsNil)
+	unlock2, err := lock2.LockShared("two", 2, giveUpAfter(0))
+	c.Assert(err, jc.ErrorIsNil)
+
+	_, err = lock3.LockShared("three", 2, giveUpAfter(10*time.Millisecond))
+	c.Assert(err, gc.Equals, errGiveUp)
+
+	c.Assert(unlock1(), jc.ErrorIsNil)
+	unlock3, err := lock3.LockShared("three", 2, giveUpAfter(0))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(unlock2(), jc.ErrorIsNil)
+	c.Assert(unlock3(), jc.ErrorIsNil)
+}
+
+func (s *lockSuite) TestSharedInvalidConcurrency(c *gc.C) {
+	_, err := s.newLock(c).LockShared("one", 0, giveUpAfter(0))
+	c.Assert(err, gc.ErrorMatches, "hook concurrency 0 not valid")
+}
+
+func (s *lockSuite) TestExclusiveWaitsForShared(c *gc.C) {
+	shared, exclusive := s.newLock(c), s.newLock(c)
+	unlock, err := shared.LockShared("shared", 3, giveUpAfter(0))
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = exclusive.LockWithFunc("exclusive", giveUpAfter(10*time.Millisecond))
+	c.Assert(err, gc.Equals, errGiveUp)
+	// Giving up leaves nothing held by the would-be exclusive holder.
+	c.Assert(exclusive.IsLocked(), jc.IsFalse)
+
+	c.Assert(unlock(), jc.ErrorIsNil)
+	err = exclusive.LockWithFunc("exclusive", giveUpAfter(0))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(exclusive.IsLocked(), jc.IsTrue)
+	c.Assert(exclusive.Message(), gc.Equals, "exclusive")
+	c.Assert(exclusive.Unlock(), jc.ErrorIsNil)
+	c.Assert(exclusive.IsLocked(), jc.IsFalse)
+}
+
+func (s *lockSuite) TestExclusiveExcludesShared(c *gc.C) {
+	exclusive, shared := s.newLock(c), s.newLock(c)
+	err := exclusive.Lock("exclusive")
+	c.Assert(err, jc.ErrorIsNil)
+
+	_, err = shared.LockShared("shared", 3, giveUpAfter(10*time.Millisecond))
+	c.Assert(err, gc.Equals, errGiveUp)
+
+	c.Assert(exclusive.Unlock(), jc.ErrorIsNil)
+	unlock, err := shared.LockShared("shared", 3, giveUpAfter(0))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(unlock(), jc.ErrorIsNil)
+}
+
+func (s *lockSuite) TestBreakLocks(c *gc.C) {
+	lock1, lock2, lock3 := s.newLock(c), s.newLock(c), s.newLock(c)
+	_, err := lock1.LockShared("u/0: one", 2, giveUpAfter(0))
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = lock2.LockShared("u/1: two", 2, giveUpAfter(0))
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = lock3.BreakLocks(func(message string) bool {
+		return message == "u/0: one"
+	})
+	c.Assert(err, jc.ErrorIsNil)
+
+	// Only the broken slot is available.
+	unlock, err := lock3.LockShared("three", 2, giveUpAfter(0))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(unlock(), jc.ErrorIsNil)
+	_, err = lock3.LockShared("three", 1, giveUpAfter(0))
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = lock3.LockShared("four", 2, giveUpAfter(0))
+	c.Assert(err, gc.Equals, errGiveUp)
+}
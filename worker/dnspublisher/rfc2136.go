@@ -0,0 +1,292 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package dnspublisher
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math/rand"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// Updater publishes the addresses of names in a DNS zone.
+type Updater interface {
+	// Replace replaces all the address records of each of the given
+	// names with records for the given addresses. The records of a
+	// name with no addresses are deleted.
+	Replace(names map[string][]string) error
+}
+
+// DNS protocol values used in update messages. See RFC 1035 and
+// RFC 2136.
+const (
+	opcodeUpdate = 5
+
+	typeA    = 1
+	typeSOA  = 6
+	typeAAAA = 28
+
+	classIN  = 1
+	classANY = 255
+)
+
+// maxMessageSize is the largest message that may be sent over TCP,
+// whose messages are prefixed with a 16 bit length. Updates too large
+// for one message are split across several.
+var maxMessageSize = 0xFFFF
+
+// rcodeNames holds the names of the response codes defined by
+// RFC 1035 and RFC 2136.
+var rcodeNames = map[int]string{
+	1:  "FORMERR",
+	2:  "SERVFAIL",
+	3:  "NXDOMAIN",
+	4:  "NOTIMP",
+	5:  "REFUSED",
+	6:  "YXDOMAIN",
+	7:  "YXRRSET",
+	8:  "NXRRSET",
+	9:  "NOTAUTH",
+	10: "NOTZONE",
+}
+
+// dialTimeout holds how long to wait when connecting to and
+// exchanging messages with the DNS server.
+var dialTimeout = 10 * time.Second
+
+// rfc2136Updater is an Updater which sends dynamic update messages,
+// as described in RFC 2136, to a DNS server over TCP. Updates are not
+// authenticated, so the server must be configured to accept updates
+// to the zone from the state servers' addresses.
+type rfc2136Updater struct {
+	server string
+	zone   string
+	ttl    time.Duration
+}
+
+// NewRFC2136Updater returns an Updater which publishes names in the
+// given zone by sending dynamic updates to the DNS server at the given
+// address (host:port). Published records have the given time to live.
+func NewRFC2136Updater(server, zone string, ttl time.Duration) Updater {
+	return &rfc2136Updater{
+		server: server,
+		zone:   strings.TrimSuffix(zone, "."),
+		ttl:    ttl,
+	}
+}
+
+// Replace is part of the Updater interface. Each name's records are
+// replaced atomically, but updates too large for a single message are
+// sent in several, so a failure may leave some names updated.
+func (u *rfc2136Updater) Replace(names map[string][]string) error {
+	batches, err := u.updateBatches(names)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, batch := range batches {
+		id := uint16(rand.Intn(0x10000))
+		msg := u.updateMessage(id, batch)
+		reply, err := u.exchange(msg)
+		if err != nil {
+			return errors.Annotatef(err, "cannot update zone %q on %s", u.zone, u.server)
+		}
+		if err := checkReply(id, reply); err != nil {
+			return errors.Annotatef(err, "cannot update zone %q on %s", u.zone, u.server)
+		}
+	}
+	return nil
+}
+
+// updateBatch holds the update section of an update message, and the
+// number of records in it.
+type updateBatch struct {
+	updates bytes.Buffer
+	count   int
+}
+
+// updateBatches returns the update sections of the messages which
+// delete the address records of each of the names and add records for
+// their addresses, each small enough to be sent in one message.
+func (u *rfc2136Updater) updateBatches(names map[string][]string) ([]*updateBatch, error) {
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var zone bytes.Buffer
+	if err := writeName(&zone, u.zone); err != nil {
+		return nil, errors.Trace(err)
+	}
+	// The header is 12 bytes, and the zone section
+	// holds the zone name, its type and class.
+	maxUpdatesSize := maxMessageSize - 12 - zone.Len() - 4
+
+	var batches []*updateBatch
+	batch := &updateBatch{}
+	for _, name := range sorted {
+		next, err := u.nameUpdates(name, names[name])
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if next.updates.Len() > maxUpdatesSize {
+			return nil, errors.Errorf("too many records for %q (%d bytes)", name+"."+u.zone, next.updates.Len())
+		}
+		if batch.updates.Len()+next.updates.Len() > maxUpdatesSize || batch.count+next.count > 0xFFFF {
+			batches = append(batches, batch)
+			batch = &updateBatch{}
+		}
+		batch.updates.Write(next.updates.Bytes())
+		batch.count += next.count
+	}
+	if batch.count > 0 {
+		batches = append(batches, batch)
+	}
+	return batches, nil
+}
+
+// nameUpdates returns the updates which delete the address records of
+// the given name and add records for the given addresses.
+func (u *rfc2136Updater) nameUpdates(name string, addrs []string) (*updateBatch, error) {
+	batch := &updateBatch{}
+	fqdn := name + "." + u.zone
+	for _, rrType := range []uint16{typeA, typeAAAA} {
+		// Delete the RRset: class ANY with no data.
+		if err := writeRR(&batch.updates, fqdn, rrType, classANY, 0, nil); err != nil {
+			return nil, errors.Trace(err)
+		}
+		batch.count++
+	}
+	for _, addr := range addrs {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return nil, errors.Errorf("cannot publish %q for %q: not an IP address", addr, fqdn)
+		}
+		rrType, data := uint16(typeAAAA), []byte(ip.To16())
+		if ip4 := ip.To4(); ip4 != nil {
+			rrType, data = typeA, []byte(ip4)
+		}
+		if err := writeRR(&batch.updates, fqdn, rrType, classIN, uint32(u.ttl/time.Second), data); err != nil {
+			return nil, errors.Trace(err)
+		}
+		batch.count++
+	}
+	return batch, nil
+}
+
+// updateMessage returns an update message with the given id and
+// update section.
+func (u *rfc2136Updater) updateMessage(id uint16, batch *updateBatch) []byte {
+	var msg bytes.Buffer
+	// Header: the zone count is always 1, and there are
+	// no prerequisites or additional records.
+	writeUint16(&msg, id)
+	writeUint16(&msg, opcodeUpdate<<11)
+	writeUint16(&msg, 1)
+	writeUint16(&msg, 0)
+	writeUint16(&msg, uint16(batch.count))
+	writeUint16(&msg, 0)
+	// Zone section; the zone name was validated by updateBatches.
+	writeName(&msg, u.zone)
+	writeUint16(&msg, typeSOA)
+	writeUint16(&msg, classIN)
+	// Update section.
+	msg.Write(batch.updates.Bytes())
+	return msg.Bytes()
+}
+
+// exchange sends the given message to the server and returns its
+// reply.
+func (u *rfc2136Updater) exchange(msg []byte) ([]byte, error) {
+	conn, err := net.DialTimeout("tcp", u.server, dialTimeout)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(dialTimeout)); err != nil {
+		return nil, errors.Trace(err)
+	}
+	var buf bytes.Buffer
+	writeUint16(&buf, uint16(len(msg)))
+	buf.Write(msg)
+	if _, err := conn.Write(buf.Bytes()); err != nil {
+		return nil, errors.Trace(err)
+	}
+	var size uint16
+	if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+		return nil, errors.Annotate(err, "cannot read reply")
+	}
+	reply := make([]byte, size)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return nil, errors.Annotate(err, "cannot read reply")
+	}
+	return reply, nil
+}
+
+// checkReply returns an error if the given reply is not a successful
+// response to the update message with the given id.
+func checkReply(id uint16, reply []byte) error {
+	if len(reply) < 12 {
+		return errors.Errorf("short reply (%d bytes)", len(reply))
+	}
+	if replyId := binary.BigEndian.Uint16(reply); replyId != id {
+		return errors.Errorf("reply id %d does not match update id %d", replyId, id)
+	}
+	flags := binary.BigEndian.Uint16(reply[2:])
+	if flags&0x8000 == 0 {
+		return errors.New("reply is not a response")
+	}
+	if rcode := int(flags & 0xF); rcode != 0 {
+		name, ok := rcodeNames[rcode]
+		if !ok {
+			return errors.Errorf("server returned error code %d", rcode)
+		}
+		return errors.Errorf("server returned %s", name)
+	}
+	return nil
+}
+
+// writeRR writes a resource record with the given owner name, type,
+// class, time to live and data.
+func writeRR(buf *bytes.Buffer, name string, rrType, class uint16, ttl uint32, data []byte) error {
+	if err := writeName(buf, name); err != nil {
+		return errors.Trace(err)
+	}
+	writeUint16(buf, rrType)
+	writeUint16(buf, class)
+	binary.Write(buf, binary.BigEndian, ttl)
+	writeUint16(buf, uint16(len(data)))
+	buf.Write(data)
+	return nil
+}
+
+// writeName writes the given domain name in its uncompressed wire
+// format.
+func writeName(buf *bytes.Buffer, name string) error {
+	name = strings.TrimSuffix(name, ".")
+	if len(name) > 253 {
+		return errors.NotValidf("domain name %q", name)
+	}
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			if len(label) == 0 || len(label) > 63 {
+				return errors.NotValidf("domain name %q", name)
+			}
+			buf.WriteByte(byte(len(label)))
+			buf.WriteString(label)
+		}
+	}
+	buf.WriteByte(0)
+	return nil
+}
+
+func writeUint16(buf *bytes.Buffer, v uint16) {
+	binary.Write(buf, binary.BigEndian, v)
+}
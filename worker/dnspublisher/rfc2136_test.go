@@ -0,0 +1,283 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package dnspublisher
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	coretesting "github.com/juju/juju/testing"
+)
+
+// fakeDNSServer is an in-process stand-in for a DNS server which
+// accepts dynamic updates over TCP and records the resulting address
+// records.
+type fakeDNSServer struct {
+	listener net.Listener
+
+	mu      sync.Mutex
+	zones   []string
+	records map[string][]string
+	rcode   int
+}
+
+func newFakeDNSServer(c *gc.C) *fakeDNSServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, jc.ErrorIsNil)
+	s := &fakeDNSServer{
+		listener: listener,
+		records:  make(map[string][]string),
+	}
+	go s.serve()
+	return s
+}
+
+func (s *fakeDNSServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeDNSServer) Close() {
+	s.listener.Close()
+}
+
+// Records returns the addresses of each name known to the server.
+func (s *fakeDNSServer) Records() map[string][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make(map[string][]string)
+	for name, addrs := range s.records {
+		result[name] = append([]string(nil), addrs...)
+		sort.Strings(result[name])
+	}
+	return result
+}
+
+// Zones returns the zones named by each update received.
+func (s *fakeDNSServer) Zones() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.zones...)
+}
+
+// SetRcode sets the response code returned for subsequent updates.
+// Updates are not applied if it is non-zero.
+func (s *fakeDNSServer) SetRcode(rcode int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rcode = rcode
+}
+
+func (s *fakeDNSServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeDNSServer) handle(conn net.Conn) {
+	defer conn.Close()
+	var size uint16
+	if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+		return
+	}
+	msg := make([]byte, size)
+	if _, err := io.ReadFull(conn, msg); err != nil {
+		return
+	}
+	rcode := s.apply(msg)
+
+	var reply bytes.Buffer
+	writeUint16(&reply, 12)
+	reply.Write(msg[:2])
+	writeUint16(&reply, 0x8000|opcodeUpdate<<11|uint16(rcode))
+	reply.Write(make([]byte, 8))
+	conn.Write(reply.Bytes())
+}
+
+// apply applies the given update message, returning the response
+// code.
+func (s *fakeDNSServer) apply(msg []byte) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rcode != 0 {
+		return s.rcode
+	}
+	if len(msg) < 12 || binary.BigEndian.Uint16(msg[2:])>>11 != opcodeUpdate {
+		return 4 // NOTIMP
+	}
+	r := bytes.NewReader(msg[12:])
+	updates := binary.BigEndian.Uint16(msg[8:])
+	zone, err := readName(r)
+	if err != nil {
+		return 1 // FORMERR
+	}
+	r.Seek(4, 1)
+	s.zones = append(s.zones, zone)
+	for i := 0; i < int(updates); i++ {
+		name, err := readName(r)
+		if err != nil {
+			return 1
+		}
+		var rr struct {
+			Type, Class uint16
+			TTL         uint32
+			Length      uint16
+		}
+		if err := binary.Read(r, binary.BigEndian, &rr); err != nil {
+			return 1
+		}
+		data := make([]byte, rr.Length)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return 1
+		}
+		if !strings.HasSuffix(name, "."+zone) {
+			return 10 // NOTZONE
+		}
+		switch rr.Class {
+		case classANY:
+			var kept []string
+			for _, addr := range s.records[name] {
+				isIPv4 := net.ParseIP(addr).To4() != nil
+				if isIPv4 != (rr.Type == typeA) {
+					kept = append(kept, addr)
+				}
+			}
+			if len(kept) == 0 {
+				delete(s.records, name)
+			} else {
+				s.records[name] = kept
+			}
+		case classIN:
+			s.records[name] = append(s.records[name], net.IP(data).String())
+		default:
+			return 1
+		}
+	}
+	return 0
+}
+
+func readName(r *bytes.Reader) (string, error) {
+	var labels []string
+	for {
+		size, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if size == 0 {
+			return strings.Join(labels, "."), nil
+		}
+		label := make([]byte, size)
+		if _, err := io.ReadFull(r, label); err != nil {
+			return "", err
+		}
+		labels = append(labels, string(label))
+	}
+}
+
+type rfc2136Suite struct {
+	coretesting.BaseSuite
+	server *fakeDNSServer
+}
+
+var _ = gc.Suite(&rfc2136Suite{})
+
+func (s *rfc2136Suite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.server = newFakeDNSServer(c)
+	s.AddCleanup(func(*gc.C) { s.server.Close() })
+}
+
+func (s *rfc2136Suite) TestReplace(c *gc.C) {
+	updater := NewRFC2136Updater(s.server.Addr(), "example.com.", time.Minute)
+	err := updater.Replace(map[string][]string{
+		"wordpress-0.wordpress.env": {"10.0.0.1"},
+		"wordpress.env":             {"10.0.0.1", "2001:db8::1"},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.server.Zones(), jc.DeepEquals, []string{"example.com"})
+	c.Assert(s.server.Records(), jc.DeepEquals, map[string][]string{
+		"wordpress-0.wordpress.env.example.com": {"10.0.0.1"},
+		"wordpress.env.example.com":             {"10.0.0.1", "2001:db8::1"},
+	})
+
+	err = updater.Replace(map[string][]string{
+		"wordpress-0.wordpress.env": nil,
+		"wordpress.env":             {"10.0.0.2"},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.server.Records(), jc.DeepEquals, map[string][]string{
+		"wordpress.env.example.com": {"10.0.0.2"},
+	})
+}
+
+func (s *rfc2136Suite) TestReplaceSplitsLargeUpdates(c *gc.C) {
+	s.PatchValue(&maxMessageSize, 300)
+	names := make(map[string][]string)
+	expect := make(map[string][]string)
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("wordpress-%d.wordpress.env", i)
+		addr := fmt.Sprintf("10.0.0.%d", i)
+		names[name] = []string{addr}
+		expect[name+".example.com"] = []string{addr}
+	}
+	updater := NewRFC2136Updater(s.server.Addr(), "example.com", time.Minute)
+	err := updater.Replace(names)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(len(s.server.Zones()) > 1, jc.IsTrue)
+	c.Assert(s.server.Records(), jc.DeepEquals, expect)
+}
+
+func (s *rfc2136Suite) TestReplaceNameTooLarge(c *gc.C) {
+	s.PatchValue(&maxMessageSize, 100)
+	updater := NewRFC2136Updater(s.server.Addr(), "example.com", time.Minute)
+	err := updater.Replace(map[string][]string{"wordpress.env": {"10.0.0.1", "10.0.0.2"}})
+	c.Assert(err, gc.ErrorMatches, `too many records for "wordpress.env.example.com" \(\d+ bytes\)`)
+	c.Assert(s.server.Zones(), gc.HasLen, 0)
+}
+
+func (s *rfc2136Suite) TestReplaceNothing(c *gc.C) {
+	updater := NewRFC2136Updater(s.server.Addr(), "example.com", time.Minute)
+	err := updater.Replace(nil)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.server.Zones(), gc.HasLen, 0)
+}
+
+func (s *rfc2136Suite) TestReplaceRefused(c *gc.C) {
+	s.server.SetRcode(5)
+	updater := NewRFC2136Updater(s.server.Addr(), "example.com", time.Minute)
+	err := updater.Replace(map[string][]string{"wordpress.env": {"10.0.0.1"}})
+	c.Assert(err, gc.ErrorMatches, `cannot update zone "example.com" on .*: server returned REFUSED`)
+}
+
+func (s *rfc2136Suite) TestReplaceInvalidAddress(c *gc.C) {
+	updater := NewRFC2136Updater(s.server.Addr(), "example.com", time.Minute)
+	err := updater.Replace(map[string][]string{"wordpress.env": {"wordpress.example.com"}})
+	c.Assert(err, gc.ErrorMatches, `cannot publish "wordpress.example.com" for "wordpress.env.example.com": not an IP address`)
+}
+
+func (s *rfc2136Suite) TestReplaceInvalidName(c *gc.C) {
+	updater := NewRFC2136Updater(s.server.Addr(), "example.com", time.Minute)
+	err := updater.Replace(map[string][]string{strings.Repeat("x", 64): {"10.0.0.1"}})
+	c.Assert(err, gc.ErrorMatches, `domain name "x+.example.com" not valid`)
+}
+
+func (s *rfc2136Suite) TestReplaceServerUnavailable(c *gc.C) {
+	s.server.Close()
+	updater := NewRFC2136Updater(s.server.Addr(), "example.com", time.Minute)
+	err := updater.Replace(map[string][]string{"wordpress.env": {"10.0.0.1"}})
+	c.Assert(err, gc.ErrorMatches, `cannot update zone "example.com" on .*: .*`)
+}
@@ -0,0 +1,314 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package dnspublisher implements a worker which publishes the names
+// of an environment's units and services to a DNS server.
+//
+// Each unit is published as <unit>.<service>.<environment>.<zone>,
+// where <unit> is the unit's name with the "/" replaced by "-" (for
+// example wordpress-0.wordpress.myenv.example.com), and each service
+// as <service>.<environment>.<zone>, with a record for each of its
+// units so that clients are given the units' addresses in turn.
+package dnspublisher
+
+import (
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+	"launchpad.net/tomb"
+
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/multiwatcher"
+	"github.com/juju/juju/state/watcher"
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.dnspublisher")
+
+// dnsSettings holds the environment settings which determine where
+// and how records are published.
+type dnsSettings struct {
+	server  string
+	zone    string
+	ttl     time.Duration
+	envName string
+}
+
+// unitInfo holds what the publisher needs to know about a unit.
+type unitInfo struct {
+	service   string
+	machineId string
+}
+
+type publisher struct {
+	tomb tomb.Tomb
+	st   *state.State
+
+	settings dnsSettings
+	updater  Updater
+
+	machines map[string][]network.Address
+	units    map[string]unitInfo
+
+	// published holds the addresses of each name, relative to the
+	// zone, as last published.
+	published map[string][]string
+
+	// stale holds the names, relative to the zone, published by an
+	// earlier run of the publisher. Those no longer wanted, such as
+	// the names of units removed while it was not running, are
+	// deleted when the records are next published.
+	stale []string
+}
+
+// New returns a worker which publishes the names of the units and
+// services in the given state's environment to the DNS server named
+// by the environment's dns-server setting. Nothing is published while
+// dns-server is not set.
+func New(st *state.State) worker.Worker {
+	p := &publisher{
+		st:       st,
+		machines: make(map[string][]network.Address),
+		units:    make(map[string]unitInfo),
+	}
+	go func() {
+		defer p.tomb.Done()
+		p.tomb.Kill(p.loop())
+	}()
+	return p
+}
+
+// Kill is part of the worker.Worker interface.
+func (p *publisher) Kill() {
+	p.tomb.Kill(nil)
+}
+
+// Wait is part of the worker.Worker interface.
+func (p *publisher) Wait() error {
+	return p.tomb.Wait()
+}
+
+func (p *publisher) loop() error {
+	configw := p.st.WatchForEnvironConfigChanges()
+	defer watcher.Stop(configw, &p.tomb)
+
+	allw := p.st.Watch()
+	defer allw.Stop()
+	deltasc := make(chan []multiwatcher.Delta)
+	errc := make(chan error, 1)
+	go func() {
+		for {
+			deltas, err := allw.Next()
+			if err != nil {
+				errc <- err
+				return
+			}
+			select {
+			case deltasc <- deltas:
+			case <-p.tomb.Dying():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-p.tomb.Dying():
+			return tomb.ErrDying
+		case _, ok := <-configw.Changes():
+			if !ok {
+				return watcher.EnsureErr(configw)
+			}
+			if err := p.updateSettings(); err != nil {
+				return errors.Trace(err)
+			}
+		case deltas := <-deltasc:
+			p.applyDeltas(deltas)
+		case err := <-errc:
+			return errors.Annotate(err, "cannot watch environment")
+		}
+		if err := p.publish(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+}
+
+// updateSettings reads the environment's DNS settings, and arranges
+// for all records to be published again if they have changed.
+func (p *publisher) updateSettings() error {
+	cfg, err := p.st.EnvironConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	settings := dnsSettings{
+		server:  cfg.DNSServer(),
+		zone:    cfg.DNSZone(),
+		ttl:     cfg.DNSTTL(),
+		envName: cfg.Name(),
+	}
+	if settings == p.settings {
+		return nil
+	}
+	p.settings = settings
+	p.published = nil
+	if settings.server == "" {
+		logger.Infof("not publishing DNS records: dns-server not set")
+		p.updater = nil
+		return nil
+	}
+	logger.Infof("publishing DNS records in zone %q to %s", settings.zone, settings.server)
+	p.updater = NewRFC2136Updater(settings.server, settings.zone, settings.ttl)
+	recorded, err := p.st.PublishedDNSRecords()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	p.stale = nil
+	suffix := "." + p.zone()
+	for fqdn := range recorded {
+		if strings.HasSuffix(fqdn, suffix) {
+			p.stale = append(p.stale, strings.TrimSuffix(fqdn, suffix))
+		}
+	}
+	return nil
+}
+
+// zone returns the zone records are published in, without any
+// trailing dot.
+func (p *publisher) zone() string {
+	return strings.TrimSuffix(p.settings.zone, ".")
+}
+
+// applyDeltas records the machine addresses and unit placements
+// described by the given deltas.
+func (p *publisher) applyDeltas(deltas []multiwatcher.Delta) {
+	for _, delta := range deltas {
+		switch info := delta.Entity.(type) {
+		case *multiwatcher.MachineInfo:
+			if delta.Removed {
+				delete(p.machines, info.Id)
+			} else {
+				p.machines[info.Id] = info.Addresses
+			}
+		case *multiwatcher.UnitInfo:
+			if delta.Removed {
+				delete(p.units, info.Name)
+			} else {
+				p.units[info.Name] = unitInfo{
+					service:   info.Service,
+					machineId: info.MachineId,
+				}
+			}
+		}
+	}
+}
+
+// publish sends the records which have changed since they were last
+// published to the DNS server.
+func (p *publisher) publish() error {
+	if p.updater == nil {
+		return nil
+	}
+	policy, err := p.st.AddressPolicy()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	desired := p.records(policy)
+	changes := make(map[string][]string)
+	for name, addrs := range desired {
+		if !stringsEqual(addrs, p.published[name]) {
+			changes[name] = addrs
+		}
+	}
+	for name := range p.published {
+		if _, ok := desired[name]; !ok {
+			changes[name] = nil
+		}
+	}
+	for _, name := range p.stale {
+		if _, ok := desired[name]; !ok {
+			changes[name] = nil
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	// Record every name which may be in the zone before updating it,
+	// so that none is forgotten if the update fails part way.
+	if err := p.recordPublished(desired, p.published, changes); err != nil {
+		return errors.Trace(err)
+	}
+	logger.Debugf("publishing DNS records: %v", changes)
+	if err := p.updater.Replace(changes); err != nil {
+		return errors.Trace(err)
+	}
+	p.published = desired
+	p.stale = nil
+	return errors.Trace(p.recordPublished(desired))
+}
+
+// recordPublished records the fully qualified names in the given
+// records in state, with their addresses, so that they can be found
+// by later runs of the publisher. Names are recorded with the
+// addresses given first.
+func (p *publisher) recordPublished(records ...map[string][]string) error {
+	fqdns := make(map[string][]string)
+	for _, names := range records {
+		for name, addrs := range names {
+			fqdn := name + "." + p.zone()
+			if _, ok := fqdns[fqdn]; !ok {
+				fqdns[fqdn] = addrs
+			}
+		}
+	}
+	return p.st.SetPublishedDNSRecords(fqdns)
+}
+
+// records returns the addresses of each name to publish, relative to
+// the zone, choosing each unit's address with the given policy. Units
+// without an IP address are not published.
+func (p *publisher) records(policy network.AddressPolicy) map[string][]string {
+	records := make(map[string][]string)
+	envName := p.settings.envName
+	for name, unit := range p.units {
+		addr := policy.SelectInternalAddress(p.machines[unit.machineId], false)
+		if net.ParseIP(addr) == nil {
+			continue
+		}
+		unitName := strings.Replace(name, "/", "-", -1)
+		records[unitName+"."+unit.service+"."+envName] = []string{addr}
+		serviceName := unit.service + "." + envName
+		records[serviceName] = append(records[serviceName], addr)
+	}
+	for name, addrs := range records {
+		records[name] = uniqueSorted(addrs)
+	}
+	return records
+}
+
+func uniqueSorted(values []string) []string {
+	sort.Strings(values)
+	result := values[:0]
+	for i, value := range values {
+		if i == 0 || value != values[i-1] {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func stringsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
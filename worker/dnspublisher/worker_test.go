@@ -0,0 +1,173 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package dnspublisher
+
+import (
+	"reflect"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker"
+)
+
+type workerSuite struct {
+	jujutesting.JujuConnSuite
+	server *fakeDNSServer
+}
+
+var _ = gc.Suite(&workerSuite{})
+
+func (s *workerSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.server = newFakeDNSServer(c)
+	s.AddCleanup(func(*gc.C) { s.server.Close() })
+}
+
+func (s *workerSuite) startWorker(c *gc.C) {
+	w := New(s.State)
+	s.AddCleanup(func(c *gc.C) { c.Check(worker.Stop(w), jc.ErrorIsNil) })
+}
+
+func (s *workerSuite) enablePublishing(c *gc.C) {
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{
+		"dns-server": s.server.Addr(),
+		"dns-zone":   "example.com",
+	}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *workerSuite) addUnitWithAddress(c *gc.C, service *state.Service, addr string) *state.Unit {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	err = machine.SetAddresses(network.NewScopedAddress(addr, network.ScopeCloudLocal))
+	c.Assert(err, jc.ErrorIsNil)
+	unit, err := service.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	err = unit.AssignToMachine(machine)
+	c.Assert(err, jc.ErrorIsNil)
+	return unit
+}
+
+func (s *workerSuite) waitForRecords(c *gc.C, expect map[string][]string) {
+	s.State.StartSync()
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		if reflect.DeepEqual(s.server.Records(), expect) {
+			return
+		}
+		s.State.StartSync()
+	}
+	c.Fatalf("timed out waiting for records %v; got %v", expect, s.server.Records())
+}
+
+func (s *workerSuite) envName(c *gc.C) string {
+	cfg, err := s.State.EnvironConfig()
+	c.Assert(err, jc.ErrorIsNil)
+	return cfg.Name()
+}
+
+func (s *workerSuite) TestPublishesUnitsAndServices(c *gc.C) {
+	s.enablePublishing(c)
+	s.startWorker(c)
+
+	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	unit0 := s.addUnitWithAddress(c, wordpress, "10.0.0.1")
+	s.addUnitWithAddress(c, wordpress, "10.0.0.2")
+
+	env := s.envName(c)
+	s.waitForRecords(c, map[string][]string{
+		"wordpress-0.wordpress." + env + ".example.com": {"10.0.0.1"},
+		"wordpress-1.wordpress." + env + ".example.com": {"10.0.0.2"},
+		"wordpress." + env + ".example.com":             {"10.0.0.1", "10.0.0.2"},
+	})
+
+	err := unit0.Destroy()
+	c.Assert(err, jc.ErrorIsNil)
+	s.waitForRecords(c, map[string][]string{
+		"wordpress-1.wordpress." + env + ".example.com": {"10.0.0.2"},
+		"wordpress." + env + ".example.com":             {"10.0.0.2"},
+	})
+}
+
+func (s *workerSuite) TestPublishesWhenEnabled(c *gc.C) {
+	s.startWorker(c)
+	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	s.addUnitWithAddress(c, wordpress, "10.0.0.1")
+
+	s.enablePublishing(c)
+	env := s.envName(c)
+	s.waitForRecords(c, map[string][]string{
+		"wordpress-0.wordpress." + env + ".example.com": {"10.0.0.1"},
+		"wordpress." + env + ".example.com":             {"10.0.0.1"},
+	})
+}
+
+func (s *workerSuite) TestPublishesAddressInPreferredSpace(c *gc.C) {
+	_, err := s.State.AddSubnet(state.SubnetInfo{CIDR: "10.20.0.0/16"})
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AddSpace("db", []string{"10.20.0.0/16"})
+	c.Assert(err, jc.ErrorIsNil)
+	s.enablePublishing(c)
+	s.startWorker(c)
+
+	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	unit := s.addUnitWithAddress(c, wordpress, "10.0.0.1")
+	machineId, err := unit.AssignedMachineId()
+	c.Assert(err, jc.ErrorIsNil)
+	machine, err := s.State.Machine(machineId)
+	c.Assert(err, jc.ErrorIsNil)
+	err = machine.SetAddresses(
+		network.NewScopedAddress("10.0.0.1", network.ScopeCloudLocal),
+		network.NewScopedAddress("10.20.0.1", network.ScopeCloudLocal),
+	)
+	c.Assert(err, jc.ErrorIsNil)
+
+	env := s.envName(c)
+	s.waitForRecords(c, map[string][]string{
+		"wordpress-0.wordpress." + env + ".example.com": {"10.0.0.1"},
+		"wordpress." + env + ".example.com":             {"10.0.0.1"},
+	})
+
+	err = s.State.UpdateEnvironConfig(map[string]interface{}{
+		"preferred-spaces": "db",
+	}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	s.waitForRecords(c, map[string][]string{
+		"wordpress-0.wordpress." + env + ".example.com": {"10.20.0.1"},
+		"wordpress." + env + ".example.com":             {"10.20.0.1"},
+	})
+}
+
+func (s *workerSuite) TestDeletesRecordsOfUnitsRemovedWhileStopped(c *gc.C) {
+	s.enablePublishing(c)
+	w := New(s.State)
+	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	s.addUnitWithAddress(c, wordpress, "10.0.0.1")
+	unit1 := s.addUnitWithAddress(c, wordpress, "10.0.0.2")
+	env := s.envName(c)
+	s.waitForRecords(c, map[string][]string{
+		"wordpress-0.wordpress." + env + ".example.com": {"10.0.0.1"},
+		"wordpress-1.wordpress." + env + ".example.com": {"10.0.0.2"},
+		"wordpress." + env + ".example.com":             {"10.0.0.1", "10.0.0.2"},
+	})
+	c.Assert(worker.Stop(w), jc.ErrorIsNil)
+
+	err := unit1.Destroy()
+	c.Assert(err, jc.ErrorIsNil)
+	s.startWorker(c)
+	s.waitForRecords(c, map[string][]string{
+		"wordpress-0.wordpress." + env + ".example.com": {"10.0.0.1"},
+		"wordpress." + env + ".example.com":             {"10.0.0.1"},
+	})
+	records, err := s.State.PublishedDNSRecords()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(records, jc.DeepEquals, map[string][]string{
+		"wordpress-0.wordpress." + env + ".example.com": {"10.0.0.1"},
+		"wordpress." + env + ".example.com":             {"10.0.0.1"},
+	})
+}
@@ -20,7 +20,8 @@ var logger = loggo.GetLogger("juju.worker.envworkermanager")
 // NewEnvWorkerManager returns a Worker which manages the workers which
 // need to run on a per environment basis. It takes a function which will
 // be called to start workers for a new environment. These workers
-// will be killed when an environment goes away.
+// will be killed when an environment goes away, or while it is being
+// migrated between controllers.
 func NewEnvWorkerManager(
 	st InitialState,
 	startEnvWorkers func(InitialState, *state.State) (worker.Runner, error),
@@ -43,6 +44,7 @@ func NewEnvWorkerManager(
 // funcs. It mainly exists to support testing.
 type InitialState interface {
 	WatchEnvironments() state.StringsWatcher
+	WatchEnvironmentMigrations() state.StringsWatcher
 	ForEnviron(names.EnvironTag) (*state.State, error)
 	GetEnvironment(names.EnvironTag) (*state.Environment, error)
 	EnvironUUID() string
@@ -74,14 +76,20 @@ func (m *envWorkerManager) loop() error {
 	}()
 	w := m.st.WatchEnvironments()
 	defer w.Stop()
+	mw := m.st.WatchEnvironmentMigrations()
+	defer mw.Stop()
 	for {
 		select {
 		case uuids := <-w.Changes():
 			// One or more environments have changed.
-			for _, uuid := range uuids {
-				if err := m.envHasChanged(uuid); err != nil {
-					return errors.Trace(err)
-				}
+			if err := m.envsHaveChanged(uuids); err != nil {
+				return errors.Trace(err)
+			}
+		case uuids := <-mw.Changes():
+			// One or more environments have started or
+			// finished migrating.
+			if err := m.envsHaveChanged(uuids); err != nil {
+				return errors.Trace(err)
 			}
 		case <-m.tomb.Dying():
 			// The envWorkerManager has been asked to die: kill the
@@ -93,13 +101,22 @@ func (m *envWorkerManager) loop() error {
 	}
 }
 
+func (m *envWorkerManager) envsHaveChanged(uuids []string) error {
+	for _, uuid := range uuids {
+		if err := m.envHasChanged(uuid); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
 func (m *envWorkerManager) envHasChanged(uuid string) error {
 	envTag := names.NewEnvironTag(uuid)
-	envAlive, err := m.isEnvAlive(envTag)
+	envActive, err := m.isEnvActive(envTag)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	if envAlive {
+	if envActive {
 		err = m.envIsAlive(envTag)
 	} else {
 		err = m.envIsDead(envTag)
@@ -141,12 +158,18 @@ func (m *envWorkerManager) envIsDead(envTag names.EnvironTag) error {
 	return errors.Trace(err)
 }
 
-func (m *envWorkerManager) isEnvAlive(tag names.EnvironTag) (bool, error) {
+// isEnvActive returns whether the environment's workers should run:
+// it must be alive, and not being migrated to or from another
+// controller.
+func (m *envWorkerManager) isEnvActive(tag names.EnvironTag) (bool, error) {
 	env, err := m.st.GetEnvironment(tag)
 	if errors.IsNotFound(err) {
 		return false, nil
 	} else if err != nil {
 		return false, errors.Annotatef(err, "error loading environment %s", tag.Id())
 	}
-	return env.Life() == state.Alive, nil
+	if env.Life() != state.Alive {
+		return false, nil
+	}
+	return env.MigrationMode() == state.MigrationModeNone, nil
 }
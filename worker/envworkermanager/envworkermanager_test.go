@@ -108,6 +108,34 @@ func (s *suite) TestStopsWorkersWhenEnvGoesAway(c *gc.C) {
 	}
 }
 
+func (s *suite) TestStopsWorkersWhileEnvMigrates(c *gc.C) {
+	m := envworkermanager.NewEnvWorkerManager(s.State, s.startEnvWorkers)
+	defer m.Kill()
+	s.seeRunnersStart(c, 1)
+
+	otherState := s.makeEnvironment(c)
+	runner := s.seeRunnersStart(c, 1)[0]
+
+	// Begin migrating the new environment, and see its runner stop.
+	env, err := otherState.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	err = env.BeginMigration()
+	c.Assert(err, jc.ErrorIsNil)
+	s.State.StartSync()
+	select {
+	case <-runner.tomb.Dying():
+	case <-time.After(testing.LongWait):
+		c.Fatal("timed out waiting for runner to die")
+	}
+
+	// Abort the migration, and see a runner start again.
+	err = env.AbortMigration()
+	c.Assert(err, jc.ErrorIsNil)
+	s.State.StartSync()
+	runner = s.seeRunnersStart(c, 1)[0]
+	c.Assert(runner.envUUID, gc.Equals, otherState.EnvironUUID())
+}
+
 func (s *suite) TestKillPropogates(c *gc.C) {
 	s.makeEnvironment(c)
 
@@ -8,6 +8,7 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/juju/names"
+	"github.com/juju/utils/set"
 	"launchpad.net/tomb"
 
 	apifirewaller "github.com/juju/juju/api/firewaller"
@@ -18,6 +19,7 @@ import (
 	"github.com/juju/juju/instance"
 	"github.com/juju/juju/network"
 	"github.com/juju/juju/state/watcher"
+	"github.com/juju/juju/trace"
 	"github.com/juju/juju/worker"
 )
 
@@ -25,7 +27,7 @@ type machineRanges map[network.PortRange]bool
 
 // Firewaller watches the state for port ranges opened or closed on
 // machines and reflects those changes onto the backing environment.
-// Uses Firewaller API V1.
+// Uses Firewaller API V2.
 type Firewaller struct {
 	tomb            tomb.Tomb
 	st              *apifirewaller.State
@@ -40,6 +42,7 @@ type Firewaller struct {
 	exposedChange   chan *exposedChange
 	globalMode      bool
 	globalPortRef   map[network.PortRange]int
+	sourceCIDRs     bool
 	machinePorts    map[names.MachineTag]machineRanges
 }
 
@@ -85,6 +88,11 @@ func NewFirewaller(st *apifirewaller.State) (_ worker.Worker, err error) {
 		return nil, err
 	}
 
+	// Relation-scoped access, and restricted access to exposed
+	// services, can only be provided if the environment's firewall
+	// honours the source CIDRs of the port ranges it opens.
+	fw.sourceCIDRs = supportsSourceCIDRs(fw.environ)
+
 	switch fw.environ.Config().FirewallMode() {
 	case config.FwGlobal:
 		fw.globalMode = true
@@ -103,6 +111,9 @@ func NewFirewaller(st *apifirewaller.State) (_ worker.Worker, err error) {
 
 var _ worker.Worker = (*Firewaller)(nil)
 
+// supportsSourceCIDRs is patched by tests.
+var supportsSourceCIDRs = environs.SupportsSourceCIDRs
+
 func (fw *Firewaller) loop() error {
 	defer fw.stopWatchers()
 
@@ -162,14 +173,18 @@ func (fw *Firewaller) loop() error {
 				return err
 			}
 		case change := <-fw.exposedChange:
-			change.serviced.exposed = change.exposed
+			change.serviced.exposure = change.exposure
 			unitds := []*unitData{}
 			for _, unitd := range change.serviced.unitds {
 				unitds = append(unitds, unitd)
 			}
+			tlogger := trace.NewLogger(logger, change.exposure.traceId)
+			tlogger.Debugf("exposure of service %q changed", change.serviced.service.Name())
 			if err := fw.flushUnits(unitds); err != nil {
+				tlogger.Errorf("cannot change firewall ports for service %q: %v", change.serviced.service.Name(), err)
 				return errors.Annotate(err, "cannot change firewall ports")
 			}
+			tlogger.Debugf("firewall ports for service %q updated", change.serviced.service.Name())
 		}
 	}
 }
@@ -268,18 +283,18 @@ func (fw *Firewaller) startUnit(unit *apifirewaller.Unit, machineTag names.Machi
 // startService creates a new data value for tracking details of the
 // service and starts watching the service for exposure changes.
 func (fw *Firewaller) startService(service *apifirewaller.Service) error {
-	exposed, err := service.IsExposed()
-	if err != nil {
-		return err
-	}
 	serviced := &serviceData{
 		fw:      fw,
 		service: service,
-		exposed: exposed,
 		unitds:  make(map[names.UnitTag]*unitData),
 	}
+	exposure, err := serviced.fetchExposure()
+	if err != nil {
+		return err
+	}
+	serviced.exposure = exposure
 	fw.serviceds[service.Tag()] = serviced
-	go serviced.watchLoop(serviced.exposed)
+	go serviced.watchLoop(serviced.exposure)
 	return nil
 }
 
@@ -299,7 +314,7 @@ func (fw *Firewaller) reconcileGlobal() error {
 				delete(machined.unitds, unitTag)
 				continue
 			}
-			if unitd.serviced.exposed {
+			for _, portRange := range unitd.serviced.wantedRanges(portRange) {
 				collector[portRange] = true
 			}
 		}
@@ -506,9 +521,7 @@ func (fw *Firewaller) flushMachine(machined *machineData) error {
 			delete(machined.unitds, unitTag)
 			continue
 		}
-		if unitd.serviced.exposed {
-			want = append(want, portRange)
-		}
+		want = append(want, unitd.serviced.wantedRanges(portRange)...)
 	}
 	toOpen := diffRanges(want, machined.openedPorts)
 	toClose := diffRanges(machined.openedPorts, want)
@@ -762,23 +775,102 @@ type unitData struct {
 	machined *machineData
 }
 
-// exposedChange contains the changed exposed flag for one specific service.
+// serviceExposure holds the details that determine the addresses from
+// which the ports opened by a service's units may be accessed.
+type serviceExposure struct {
+	// exposed holds whether the service is exposed.
+	exposed bool
+
+	// cidrs holds the CIDRs to which access to an exposed service
+	// is restricted, if any.
+	cidrs []string
+
+	// relatedCIDRs holds the CIDRs covering the addresses of the
+	// units of related services.
+	relatedCIDRs []string
+
+	// traceId holds the trace id of the last traced API request
+	// that changed the service. It is only used for logging.
+	traceId string
+}
+
+func (e serviceExposure) equals(other serviceExposure) bool {
+	return e.exposed == other.exposed &&
+		sameCIDRs(e.cidrs, other.cidrs) &&
+		sameCIDRs(e.relatedCIDRs, other.relatedCIDRs)
+}
+
+// exposedChange contains the changed exposure for one specific service.
 type exposedChange struct {
 	serviced *serviceData
-	exposed  bool
+	exposure serviceExposure
 }
 
 // serviceData holds service details and watches exposure changes.
 type serviceData struct {
-	tomb    tomb.Tomb
-	fw      *Firewaller
-	service *apifirewaller.Service
-	exposed bool
-	unitds  map[names.UnitTag]*unitData
+	tomb     tomb.Tomb
+	fw       *Firewaller
+	service  *apifirewaller.Service
+	exposure serviceExposure
+	unitds   map[names.UnitTag]*unitData
+}
+
+// fetchExposure returns the current exposure of the service.
+func (sd *serviceData) fetchExposure() (serviceExposure, error) {
+	var exposure serviceExposure
+	var err error
+	if exposure.exposed, err = sd.service.IsExposed(); err != nil {
+		return serviceExposure{}, err
+	}
+	if exposure.cidrs, err = sd.service.ExposedCIDRs(); err != nil {
+		return serviceExposure{}, err
+	}
+	if exposure.traceId, err = sd.service.TraceId(); err != nil {
+		return serviceExposure{}, err
+	}
+	if exposure.exposed && len(exposure.cidrs) > 0 && !sd.fw.sourceCIDRs {
+		logger.Errorf(
+			"service %q is exposed to %v, but the environment cannot restrict access by source address; leaving its ports closed",
+			sd.service.Name(), exposure.cidrs,
+		)
+	}
+	if sd.fw.sourceCIDRs {
+		if exposure.relatedCIDRs, err = sd.service.RelatedCIDRs(); err != nil {
+			return serviceExposure{}, err
+		}
+	}
+	return exposure, nil
 }
 
-// watchLoop watches the service's exposed flag for changes.
-func (sd *serviceData) watchLoop(exposed bool) {
+// wantedRanges returns the port ranges that must be opened in the
+// firewall for the given port range opened by one of the service's
+// units. An exposed service's port ranges are opened to any address,
+// or to the CIDRs it is exposed to; those of any service are opened
+// to the addresses of the units of related services.
+//
+// A service exposed to CIDRs is never opened to any address, even if
+// the environment cannot restrict access by source address.
+func (sd *serviceData) wantedRanges(portRange network.PortRange) []network.PortRange {
+	exposure := sd.exposure
+	if exposure.exposed && len(exposure.cidrs) > 0 && !sd.fw.sourceCIDRs {
+		return nil
+	}
+	if exposure.exposed && len(exposure.cidrs) == 0 {
+		return []network.PortRange{portRange}
+	}
+	cidrs := set.NewStrings(exposure.relatedCIDRs...)
+	if exposure.exposed {
+		cidrs = cidrs.Union(set.NewStrings(exposure.cidrs...))
+	}
+	if cidrs.IsEmpty() {
+		return nil
+	}
+	return network.RestrictPortRanges([]network.PortRange{portRange}, cidrs.SortedValues())
+}
+
+// watchLoop watches the service's exposure, and the addresses of the
+// units of related services, for changes.
+func (sd *serviceData) watchLoop(exposure serviceExposure) {
 	defer sd.tomb.Done()
 	w, err := sd.service.Watch()
 	if err != nil {
@@ -786,6 +878,21 @@ func (sd *serviceData) watchLoop(exposed bool) {
 		return
 	}
 	defer watcher.Stop(w, &sd.tomb)
+	var aw apiwatcher.NotifyWatcher
+	var addressChanges <-chan struct{}
+	if sd.fw.sourceCIDRs {
+		aw, err = sd.service.WatchRelatedAddresses()
+		if params.IsCodeNotImplemented(err) {
+			// Older state servers cannot report related addresses.
+			logger.Warningf("cannot watch addresses related to service %q: %v", sd.service.Name(), err)
+		} else if err != nil {
+			sd.fw.tomb.Kill(err)
+			return
+		} else {
+			defer watcher.Stop(aw, &sd.tomb)
+			addressChanges = aw.Changes()
+		}
+	}
 	for {
 		select {
 		case <-sd.tomb.Dying():
@@ -801,21 +908,28 @@ func (sd *serviceData) watchLoop(exposed bool) {
 				}
 				return
 			}
-			change, err := sd.service.IsExposed()
-			if err != nil {
-				sd.fw.tomb.Kill(err)
-				return
-			}
-			if change == exposed {
-				continue
-			}
-			exposed = change
-			select {
-			case sd.fw.exposedChange <- &exposedChange{sd, change}:
-			case <-sd.tomb.Dying():
+		case _, ok := <-addressChanges:
+			if !ok {
+				sd.fw.tomb.Kill(watcher.EnsureErr(aw))
 				return
 			}
 		}
+		change, err := sd.fetchExposure()
+		if params.IsCodeNotFound(err) {
+			return
+		} else if err != nil {
+			sd.fw.tomb.Kill(err)
+			return
+		}
+		if change.equals(exposure) {
+			continue
+		}
+		exposure = change
+		select {
+		case sd.fw.exposedChange <- &exposedChange{sd, change}:
+		case <-sd.tomb.Dying():
+			return
+		}
 	}
 }
 
@@ -825,6 +939,14 @@ func (sd *serviceData) Stop() error {
 	return sd.tomb.Wait()
 }
 
+// sameCIDRs returns whether a and b hold the same CIDRs, in any order.
+func sameCIDRs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	return set.NewStrings(a...).Difference(set.NewStrings(b...)).IsEmpty()
+}
+
 // diffRanges returns all the port rangess that exist in A but not B.
 func diffRanges(A, B []network.PortRange) (missing []network.PortRange) {
 next:
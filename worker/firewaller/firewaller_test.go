@@ -13,6 +13,7 @@ import (
 
 	"github.com/juju/juju/api"
 	apifirewaller "github.com/juju/juju/api/firewaller"
+	"github.com/juju/juju/environs"
 	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/instance"
 	"github.com/juju/juju/juju"
@@ -191,12 +192,12 @@ func (s *InstanceModeSuite) TestExposedService(c *gc.C) {
 	err = u.OpenPort("tcp", 8080)
 	c.Assert(err, jc.ErrorIsNil)
 
-	s.assertPorts(c, inst, m.Id(), []network.PortRange{{80, 90, "tcp"}, {8080, 8080, "tcp"}})
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{FromPort: 80, ToPort: 90, Protocol: "tcp"}, {FromPort: 8080, ToPort: 8080, Protocol: "tcp"}})
 
 	err = u.ClosePorts("tcp", 80, 90)
 	c.Assert(err, jc.ErrorIsNil)
 
-	s.assertPorts(c, inst, m.Id(), []network.PortRange{{8080, 8080, "tcp"}})
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{FromPort: 8080, ToPort: 8080, Protocol: "tcp"}})
 }
 
 func (s *InstanceModeSuite) TestMultipleExposedServices(c *gc.C) {
@@ -225,15 +226,15 @@ func (s *InstanceModeSuite) TestMultipleExposedServices(c *gc.C) {
 	err = u2.OpenPort("tcp", 3306)
 	c.Assert(err, jc.ErrorIsNil)
 
-	s.assertPorts(c, inst1, m1.Id(), []network.PortRange{{80, 80, "tcp"}, {8080, 8080, "tcp"}})
-	s.assertPorts(c, inst2, m2.Id(), []network.PortRange{{3306, 3306, "tcp"}})
+	s.assertPorts(c, inst1, m1.Id(), []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}, {FromPort: 8080, ToPort: 8080, Protocol: "tcp"}})
+	s.assertPorts(c, inst2, m2.Id(), []network.PortRange{{FromPort: 3306, ToPort: 3306, Protocol: "tcp"}})
 
 	err = u1.ClosePort("tcp", 80)
 	c.Assert(err, jc.ErrorIsNil)
 	err = u2.ClosePort("tcp", 3306)
 	c.Assert(err, jc.ErrorIsNil)
 
-	s.assertPorts(c, inst1, m1.Id(), []network.PortRange{{8080, 8080, "tcp"}})
+	s.assertPorts(c, inst1, m1.Id(), []network.PortRange{{FromPort: 8080, ToPort: 8080, Protocol: "tcp"}})
 	s.assertPorts(c, inst2, m2.Id(), nil)
 }
 
@@ -254,12 +255,12 @@ func (s *InstanceModeSuite) TestMachineWithoutInstanceId(c *gc.C) {
 	inst2 := s.startInstance(c, m2)
 	err = u2.OpenPort("tcp", 80)
 	c.Assert(err, jc.ErrorIsNil)
-	s.assertPorts(c, inst2, m2.Id(), []network.PortRange{{80, 80, "tcp"}})
+	s.assertPorts(c, inst2, m2.Id(), []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
 
 	inst1 := s.startInstance(c, m1)
 	err = u1.OpenPort("tcp", 8080)
 	c.Assert(err, jc.ErrorIsNil)
-	s.assertPorts(c, inst1, m1.Id(), []network.PortRange{{8080, 8080, "tcp"}})
+	s.assertPorts(c, inst1, m1.Id(), []network.PortRange{{FromPort: 8080, ToPort: 8080, Protocol: "tcp"}})
 }
 
 func (s *InstanceModeSuite) TestMultipleUnits(c *gc.C) {
@@ -281,8 +282,8 @@ func (s *InstanceModeSuite) TestMultipleUnits(c *gc.C) {
 	err = u2.OpenPort("tcp", 80)
 	c.Assert(err, jc.ErrorIsNil)
 
-	s.assertPorts(c, inst1, m1.Id(), []network.PortRange{{80, 80, "tcp"}})
-	s.assertPorts(c, inst2, m2.Id(), []network.PortRange{{80, 80, "tcp"}})
+	s.assertPorts(c, inst1, m1.Id(), []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
+	s.assertPorts(c, inst2, m2.Id(), []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
 
 	err = u1.ClosePort("tcp", 80)
 	c.Assert(err, jc.ErrorIsNil)
@@ -313,7 +314,7 @@ func (s *InstanceModeSuite) TestStartWithState(c *gc.C) {
 	c.Assert(err, jc.ErrorIsNil)
 	defer statetesting.AssertKillAndWait(c, fw)
 
-	s.assertPorts(c, inst, m.Id(), []network.PortRange{{80, 80, "tcp"}, {8080, 8080, "tcp"}})
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}, {FromPort: 8080, ToPort: 8080, Protocol: "tcp"}})
 
 	err = svc.SetExposed()
 	c.Assert(err, jc.ErrorIsNil)
@@ -343,7 +344,7 @@ func (s *InstanceModeSuite) TestStartWithPartialState(c *gc.C) {
 	err = u.OpenPort("tcp", 80)
 	c.Assert(err, jc.ErrorIsNil)
 
-	s.assertPorts(c, inst, m.Id(), []network.PortRange{{80, 80, "tcp"}})
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
 }
 
 func (s *InstanceModeSuite) TestStartWithUnexposedService(c *gc.C) {
@@ -369,7 +370,7 @@ func (s *InstanceModeSuite) TestStartWithUnexposedService(c *gc.C) {
 	// Expose service.
 	err = svc.SetExposed()
 	c.Assert(err, jc.ErrorIsNil)
-	s.assertPorts(c, inst, m.Id(), []network.PortRange{{80, 80, "tcp"}})
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
 }
 
 func (s *InstanceModeSuite) TestSetClearExposedService(c *gc.C) {
@@ -393,7 +394,7 @@ func (s *InstanceModeSuite) TestSetClearExposedService(c *gc.C) {
 	err = svc.SetExposed()
 	c.Assert(err, jc.ErrorIsNil)
 
-	s.assertPorts(c, inst, m.Id(), []network.PortRange{{80, 80, "tcp"}, {8080, 8080, "tcp"}})
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}, {FromPort: 8080, ToPort: 8080, Protocol: "tcp"}})
 
 	// ClearExposed closes the ports again.
 	err = svc.ClearExposed()
@@ -402,6 +403,111 @@ func (s *InstanceModeSuite) TestSetClearExposedService(c *gc.C) {
 	s.assertPorts(c, inst, m.Id(), nil)
 }
 
+func (s *InstanceModeSuite) TestExposedServiceCIDRs(c *gc.C) {
+	fw, err := firewaller.NewFirewaller(s.firewaller)
+	c.Assert(err, jc.ErrorIsNil)
+	defer statetesting.AssertKillAndWait(c, fw)
+
+	svc := s.AddTestingService(c, "wordpress", s.charm)
+	err = svc.SetExposedCIDRs([]string{"10.0.0.0/8", "192.168.1.0/24"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	u, m := s.addUnit(c, svc)
+	inst := s.startInstance(c, m)
+	err = u.OpenPort("tcp", 80)
+	c.Assert(err, jc.ErrorIsNil)
+
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{
+		{FromPort: 80, ToPort: 80, Protocol: "tcp", SourceCIDR: "10.0.0.0/8"},
+		{FromPort: 80, ToPort: 80, Protocol: "tcp", SourceCIDR: "192.168.1.0/24"},
+	})
+
+	// Changing the CIDRs replaces the restricted ports.
+	err = svc.SetExposedCIDRs([]string{"10.0.0.0/8"})
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{
+		{FromPort: 80, ToPort: 80, Protocol: "tcp", SourceCIDR: "10.0.0.0/8"},
+	})
+
+	// Exposing to any address lifts the restriction.
+	err = svc.SetExposed()
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
+
+	err = svc.ClearExposed()
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertPorts(c, inst, m.Id(), nil)
+}
+
+func (s *InstanceModeSuite) TestExposedServiceCIDRsNotSupported(c *gc.C) {
+	s.PatchValue(firewaller.SupportsSourceCIDRs, func(environs.Environ) bool { return false })
+	fw, err := firewaller.NewFirewaller(s.firewaller)
+	c.Assert(err, jc.ErrorIsNil)
+	defer statetesting.AssertKillAndWait(c, fw)
+
+	svc := s.AddTestingService(c, "wordpress", s.charm)
+	err = svc.SetExposedCIDRs([]string{"10.0.0.0/8"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	u, m := s.addUnit(c, svc)
+	inst := s.startInstance(c, m)
+	err = u.OpenPort("tcp", 80)
+	c.Assert(err, jc.ErrorIsNil)
+
+	// The restriction cannot be applied, so the port stays closed
+	// rather than being opened to any address.
+	s.assertPorts(c, inst, m.Id(), nil)
+
+	err = svc.SetExposed()
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
+}
+
+func (s *InstanceModeSuite) TestRelatedServicePorts(c *gc.C) {
+	fw, err := firewaller.NewFirewaller(s.firewaller)
+	c.Assert(err, jc.ErrorIsNil)
+	defer statetesting.AssertKillAndWait(c, fw)
+
+	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	_, wm := s.addUnit(c, wordpress)
+	s.startInstance(c, wm)
+	err = wm.SetAddresses(network.NewScopedAddress("10.0.0.1", network.ScopeCloudLocal))
+	c.Assert(err, jc.ErrorIsNil)
+
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	mu, mm := s.addUnit(c, mysql)
+	inst := s.startInstance(c, mm)
+	err = mu.OpenPort("tcp", 3306)
+	c.Assert(err, jc.ErrorIsNil)
+
+	// Neither exposed nor related, so no open port.
+	s.assertPorts(c, inst, mm.Id(), nil)
+
+	// Relating the services opens the port to the related unit.
+	eps, err := s.State.InferEndpoints("wordpress", "mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	rel, err := s.State.AddRelation(eps...)
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertPorts(c, inst, mm.Id(), []network.PortRange{
+		{FromPort: 3306, ToPort: 3306, Protocol: "tcp", SourceCIDR: "10.0.0.1/32"},
+	})
+
+	// Exposing the service opens the port to any address.
+	err = mysql.SetExposed()
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertPorts(c, inst, mm.Id(), []network.PortRange{{FromPort: 3306, ToPort: 3306, Protocol: "tcp"}})
+	err = mysql.ClearExposed()
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertPorts(c, inst, mm.Id(), []network.PortRange{
+		{FromPort: 3306, ToPort: 3306, Protocol: "tcp", SourceCIDR: "10.0.0.1/32"},
+	})
+
+	// Removing the relation closes the port again.
+	err = rel.Destroy()
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertPorts(c, inst, mm.Id(), nil)
+}
+
 func (s *InstanceModeSuite) TestRemoveUnit(c *gc.C) {
 	fw, err := firewaller.NewFirewaller(s.firewaller)
 	c.Assert(err, jc.ErrorIsNil)
@@ -421,8 +527,8 @@ func (s *InstanceModeSuite) TestRemoveUnit(c *gc.C) {
 	err = u2.OpenPort("tcp", 80)
 	c.Assert(err, jc.ErrorIsNil)
 
-	s.assertPorts(c, inst1, m1.Id(), []network.PortRange{{80, 80, "tcp"}})
-	s.assertPorts(c, inst2, m2.Id(), []network.PortRange{{80, 80, "tcp"}})
+	s.assertPorts(c, inst1, m1.Id(), []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
+	s.assertPorts(c, inst2, m2.Id(), []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
 
 	// Remove unit.
 	err = u1.EnsureDead()
@@ -431,7 +537,7 @@ func (s *InstanceModeSuite) TestRemoveUnit(c *gc.C) {
 	c.Assert(err, jc.ErrorIsNil)
 
 	s.assertPorts(c, inst1, m1.Id(), nil)
-	s.assertPorts(c, inst2, m2.Id(), []network.PortRange{{80, 80, "tcp"}})
+	s.assertPorts(c, inst2, m2.Id(), []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
 }
 
 func (s *InstanceModeSuite) TestRemoveService(c *gc.C) {
@@ -448,7 +554,7 @@ func (s *InstanceModeSuite) TestRemoveService(c *gc.C) {
 	err = u.OpenPort("tcp", 80)
 	c.Assert(err, jc.ErrorIsNil)
 
-	s.assertPorts(c, inst, m.Id(), []network.PortRange{{80, 80, "tcp"}})
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
 
 	// Remove service.
 	err = u.EnsureDead()
@@ -483,8 +589,8 @@ func (s *InstanceModeSuite) TestRemoveMultipleServices(c *gc.C) {
 	err = u2.OpenPort("tcp", 3306)
 	c.Assert(err, jc.ErrorIsNil)
 
-	s.assertPorts(c, inst1, m1.Id(), []network.PortRange{{80, 80, "tcp"}})
-	s.assertPorts(c, inst2, m2.Id(), []network.PortRange{{3306, 3306, "tcp"}})
+	s.assertPorts(c, inst1, m1.Id(), []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
+	s.assertPorts(c, inst2, m2.Id(), []network.PortRange{{FromPort: 3306, ToPort: 3306, Protocol: "tcp"}})
 
 	// Remove services.
 	err = u2.EnsureDead()
@@ -519,7 +625,7 @@ func (s *InstanceModeSuite) TestDeadMachine(c *gc.C) {
 	err = u.OpenPort("tcp", 80)
 	c.Assert(err, jc.ErrorIsNil)
 
-	s.assertPorts(c, inst, m.Id(), []network.PortRange{{80, 80, "tcp"}})
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
 
 	// Remove unit and service, also tested without. Has no effect.
 	err = u.EnsureDead()
@@ -552,7 +658,7 @@ func (s *InstanceModeSuite) TestRemoveMachine(c *gc.C) {
 	err = u.OpenPort("tcp", 80)
 	c.Assert(err, jc.ErrorIsNil)
 
-	s.assertPorts(c, inst, m.Id(), []network.PortRange{{80, 80, "tcp"}})
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
 
 	// Remove unit.
 	err = u.EnsureDead()
@@ -618,17 +724,17 @@ func (s *GlobalModeSuite) TestGlobalMode(c *gc.C) {
 	err = u2.OpenPorts("tcp", 80, 90)
 	c.Assert(err, jc.ErrorIsNil)
 
-	s.assertEnvironPorts(c, []network.PortRange{{80, 90, "tcp"}, {8080, 8080, "tcp"}})
+	s.assertEnvironPorts(c, []network.PortRange{{FromPort: 80, ToPort: 90, Protocol: "tcp"}, {FromPort: 8080, ToPort: 8080, Protocol: "tcp"}})
 
 	// Closing a port opened by a different unit won't touch the environment.
 	err = u1.ClosePorts("tcp", 80, 90)
 	c.Assert(err, jc.ErrorIsNil)
-	s.assertEnvironPorts(c, []network.PortRange{{80, 90, "tcp"}, {8080, 8080, "tcp"}})
+	s.assertEnvironPorts(c, []network.PortRange{{FromPort: 80, ToPort: 90, Protocol: "tcp"}, {FromPort: 8080, ToPort: 8080, Protocol: "tcp"}})
 
 	// Closing a port used just once changes the environment.
 	err = u1.ClosePort("tcp", 8080)
 	c.Assert(err, jc.ErrorIsNil)
-	s.assertEnvironPorts(c, []network.PortRange{{80, 90, "tcp"}})
+	s.assertEnvironPorts(c, []network.PortRange{{FromPort: 80, ToPort: 90, Protocol: "tcp"}})
 
 	// Closing the last port also modifies the environment.
 	err = u2.ClosePorts("tcp", 80, 90)
@@ -636,6 +742,38 @@ func (s *GlobalModeSuite) TestGlobalMode(c *gc.C) {
 	s.assertEnvironPorts(c, nil)
 }
 
+func (s *GlobalModeSuite) TestGlobalModeCIDRs(c *gc.C) {
+	fw, err := firewaller.NewFirewaller(s.firewaller)
+	c.Assert(err, jc.ErrorIsNil)
+	defer statetesting.AssertKillAndWait(c, fw)
+
+	svc1 := s.AddTestingService(c, "wordpress", s.charm)
+	err = svc1.SetExposedCIDRs([]string{"10.0.0.0/8"})
+	c.Assert(err, jc.ErrorIsNil)
+	u1, m1 := s.addUnit(c, svc1)
+	s.startInstance(c, m1)
+	err = u1.OpenPort("tcp", 80)
+	c.Assert(err, jc.ErrorIsNil)
+
+	svc2 := s.AddTestingService(c, "moinmoin", s.charm)
+	err = svc2.SetExposed()
+	c.Assert(err, jc.ErrorIsNil)
+	u2, m2 := s.addUnit(c, svc2)
+	s.startInstance(c, m2)
+	err = u2.OpenPort("tcp", 80)
+	c.Assert(err, jc.ErrorIsNil)
+
+	s.assertEnvironPorts(c, []network.PortRange{
+		{FromPort: 80, ToPort: 80, Protocol: "tcp"},
+		{FromPort: 80, ToPort: 80, Protocol: "tcp", SourceCIDR: "10.0.0.0/8"},
+	})
+
+	// Unexposing one service leaves the other's rule in place.
+	err = svc1.ClearExposed()
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertEnvironPorts(c, []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
+}
+
 func (s *GlobalModeSuite) TestStartWithUnexposedService(c *gc.C) {
 	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
 	c.Assert(err, jc.ErrorIsNil)
@@ -659,7 +797,7 @@ func (s *GlobalModeSuite) TestStartWithUnexposedService(c *gc.C) {
 	// Expose service.
 	err = svc.SetExposed()
 	c.Assert(err, jc.ErrorIsNil)
-	s.assertEnvironPorts(c, []network.PortRange{{80, 80, "tcp"}})
+	s.assertEnvironPorts(c, []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
 }
 
 func (s *GlobalModeSuite) TestRestart(c *gc.C) {
@@ -678,7 +816,7 @@ func (s *GlobalModeSuite) TestRestart(c *gc.C) {
 	err = u.OpenPort("tcp", 8080)
 	c.Assert(err, jc.ErrorIsNil)
 
-	s.assertEnvironPorts(c, []network.PortRange{{80, 90, "tcp"}, {8080, 8080, "tcp"}})
+	s.assertEnvironPorts(c, []network.PortRange{{FromPort: 80, ToPort: 90, Protocol: "tcp"}, {FromPort: 8080, ToPort: 8080, Protocol: "tcp"}})
 
 	// Stop firewaller and close one and open a different port.
 	err = worker.Stop(fw)
@@ -694,7 +832,7 @@ func (s *GlobalModeSuite) TestRestart(c *gc.C) {
 	c.Assert(err, jc.ErrorIsNil)
 	defer statetesting.AssertKillAndWait(c, fw)
 
-	s.assertEnvironPorts(c, []network.PortRange{{80, 90, "tcp"}, {8888, 8888, "tcp"}})
+	s.assertEnvironPorts(c, []network.PortRange{{FromPort: 80, ToPort: 90, Protocol: "tcp"}, {FromPort: 8888, ToPort: 8888, Protocol: "tcp"}})
 }
 
 func (s *GlobalModeSuite) TestRestartUnexposedService(c *gc.C) {
@@ -713,7 +851,7 @@ func (s *GlobalModeSuite) TestRestartUnexposedService(c *gc.C) {
 	err = u.OpenPort("tcp", 8080)
 	c.Assert(err, jc.ErrorIsNil)
 
-	s.assertEnvironPorts(c, []network.PortRange{{80, 80, "tcp"}, {8080, 8080, "tcp"}})
+	s.assertEnvironPorts(c, []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}, {FromPort: 8080, ToPort: 8080, Protocol: "tcp"}})
 
 	// Stop firewaller and clear exposed flag on service.
 	err = worker.Stop(fw)
@@ -746,7 +884,7 @@ func (s *GlobalModeSuite) TestRestartPortCount(c *gc.C) {
 	err = u1.OpenPort("tcp", 8080)
 	c.Assert(err, jc.ErrorIsNil)
 
-	s.assertEnvironPorts(c, []network.PortRange{{80, 80, "tcp"}, {8080, 8080, "tcp"}})
+	s.assertEnvironPorts(c, []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}, {FromPort: 8080, ToPort: 8080, Protocol: "tcp"}})
 
 	// Stop firewaller and add another service using the port.
 	err = worker.Stop(fw)
@@ -766,17 +904,17 @@ func (s *GlobalModeSuite) TestRestartPortCount(c *gc.C) {
 	c.Assert(err, jc.ErrorIsNil)
 	defer statetesting.AssertKillAndWait(c, fw)
 
-	s.assertEnvironPorts(c, []network.PortRange{{80, 80, "tcp"}, {8080, 8080, "tcp"}})
+	s.assertEnvironPorts(c, []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}, {FromPort: 8080, ToPort: 8080, Protocol: "tcp"}})
 
 	// Closing a port opened by a different unit won't touch the environment.
 	err = u1.ClosePort("tcp", 80)
 	c.Assert(err, jc.ErrorIsNil)
-	s.assertEnvironPorts(c, []network.PortRange{{80, 80, "tcp"}, {8080, 8080, "tcp"}})
+	s.assertEnvironPorts(c, []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}, {FromPort: 8080, ToPort: 8080, Protocol: "tcp"}})
 
 	// Closing a port used just once changes the environment.
 	err = u1.ClosePort("tcp", 8080)
 	c.Assert(err, jc.ErrorIsNil)
-	s.assertEnvironPorts(c, []network.PortRange{{80, 80, "tcp"}})
+	s.assertEnvironPorts(c, []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
 
 	// Closing the last port also modifies the environment.
 	err = u2.ClosePort("tcp", 80)
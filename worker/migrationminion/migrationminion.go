@@ -0,0 +1,79 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package migrationminion defines a worker which moves an agent to
+// another controller when its environment is migrated there.
+package migrationminion
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+
+	"github.com/juju/juju/api/watcher"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.migrationminion")
+
+// ErrMigrated is returned by the worker once the agent's config has
+// been updated to connect to the controller to which its environment
+// has moved. The agent's API connection should be reopened.
+var ErrMigrated = errors.New("environment migrated to another controller")
+
+// Facade is used by the worker to learn of its environment's
+// migration target.
+type Facade interface {
+	Watch() (watcher.NotifyWatcher, error)
+	Target() (*params.MigrationTarget, error)
+	Ack() error
+}
+
+// TargetSetter is used by the worker to point the agent at the
+// controller to which its environment has moved.
+type TargetSetter interface {
+	SetMigrationTarget(apiAddrs []string, caCert string) error
+}
+
+// New returns a worker which waits for the agent's environment to be
+// given a migration target, then acknowledges the target, records it
+// in the agent's config and stops with ErrMigrated.
+func New(facade Facade, setter TargetSetter) worker.Worker {
+	return worker.NewNotifyWorker(&minion{
+		facade: facade,
+		setter: setter,
+	})
+}
+
+type minion struct {
+	facade Facade
+	setter TargetSetter
+}
+
+func (m *minion) SetUp() (watcher.NotifyWatcher, error) {
+	return m.facade.Watch()
+}
+
+func (m *minion) Handle() error {
+	target, err := m.facade.Target()
+	if err != nil {
+		return errors.Annotate(err, "cannot get migration target")
+	}
+	if target == nil {
+		return nil
+	}
+	// The migration cannot be aborted once acknowledged, so the
+	// agent only moves once its acknowledgement is recorded.
+	if err := m.facade.Ack(); err != nil {
+		return errors.Annotate(err, "cannot acknowledge migration")
+	}
+	logger.Infof("environment migrated; moving to API servers %q", target.APIAddrs)
+	if err := m.setter.SetMigrationTarget(target.APIAddrs, target.CACert); err != nil {
+		return errors.Annotate(err, "cannot update agent config")
+	}
+	return ErrMigrated
+}
+
+func (m *minion) TearDown() error {
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package migrationminion_test
+
+import (
+	stdtesting "testing"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/api/watcher"
+	"github.com/juju/juju/apiserver/params"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker/migrationminion"
+)
+
+func TestPackage(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type minionSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&minionSuite{})
+
+func (s *minionSuite) TestNoTarget(c *gc.C) {
+	facade := newFakeFacade(nil)
+	setter := &fakeSetter{}
+	w := migrationminion.New(facade, setter)
+	facade.changes <- struct{}{}
+	w.Kill()
+	c.Assert(w.Wait(), jc.ErrorIsNil)
+	c.Assert(setter.apiAddrs, gc.IsNil)
+	c.Assert(facade.acked, jc.IsFalse)
+}
+
+func (s *minionSuite) TestTarget(c *gc.C) {
+	facade := newFakeFacade(&params.MigrationTarget{
+		APIAddrs: []string{"10.0.0.1:17070"},
+		CACert:   "cert",
+	})
+	setter := &fakeSetter{}
+	w := migrationminion.New(facade, setter)
+	facade.changes <- struct{}{}
+	c.Assert(w.Wait(), gc.Equals, migrationminion.ErrMigrated)
+	c.Assert(setter.apiAddrs, jc.DeepEquals, []string{"10.0.0.1:17070"})
+	c.Assert(setter.caCert, gc.Equals, "cert")
+	c.Assert(facade.acked, jc.IsTrue)
+}
+
+func (s *minionSuite) TestAckFails(c *gc.C) {
+	facade := newFakeFacade(&params.MigrationTarget{
+		APIAddrs: []string{"10.0.0.1:17070"},
+		CACert:   "cert",
+	})
+	facade.ackErr = errors.New("migration aborted")
+	setter := &fakeSetter{}
+	w := migrationminion.New(facade, setter)
+	facade.changes <- struct{}{}
+	c.Assert(w.Wait(), gc.ErrorMatches, "cannot acknowledge migration: migration aborted")
+	c.Assert(setter.apiAddrs, gc.IsNil)
+}
+
+type fakeFacade struct {
+	changes chan struct{}
+	target  *params.MigrationTarget
+	acked   bool
+	ackErr  error
+}
+
+func newFakeFacade(target *params.MigrationTarget) *fakeFacade {
+	return &fakeFacade{
+		changes: make(chan struct{}),
+		target:  target,
+	}
+}
+
+func (f *fakeFacade) Watch() (watcher.NotifyWatcher, error) {
+	return &fakeWatcher{f.changes}, nil
+}
+
+func (f *fakeFacade) Target() (*params.MigrationTarget, error) {
+	return f.target, nil
+}
+
+func (f *fakeFacade) Ack() error {
+	if f.ackErr != nil {
+		return f.ackErr
+	}
+	f.acked = true
+	return nil
+}
+
+type fakeWatcher struct {
+	changes chan struct{}
+}
+
+func (w *fakeWatcher) Changes() <-chan struct{} { return w.changes }
+func (w *fakeWatcher) Stop() error              { return nil }
+func (w *fakeWatcher) Err() error               { return nil }
+
+type fakeSetter struct {
+	apiAddrs []string
+	caCert   string
+}
+
+func (s *fakeSetter) SetMigrationTarget(apiAddrs []string, caCert string) error {
+	s.apiAddrs = apiAddrs
+	s.caCert = caCert
+	return nil
+}
@@ -230,6 +230,13 @@ func (st *fakeState) WatchStateServerInfo() state.NotifyWatcher {
 	return WatchValue(&st.stateServers)
 }
 
+func (st *fakeState) AddressPolicy() (network.AddressPolicy, error) {
+	if err := errorFor("State.AddressPolicy"); err != nil {
+		return network.AddressPolicy{}, err
+	}
+	return network.CurrentAddressPolicy(), nil
+}
+
 type fakeMachine struct {
 	mu      sync.Mutex
 	val     voyeur.Value // of machineDoc
@@ -14,20 +14,19 @@ import (
 
 type apiHostPortsSetter interface {
 	SetAPIHostPorts([][]network.HostPort) error
+	AddressPolicy() (network.AddressPolicy, error)
 }
 
 type publisher struct {
-	st         apiHostPortsSetter
-	preferIPv6 bool
+	st apiHostPortsSetter
 
 	mu             sync.Mutex
 	lastAPIServers [][]network.HostPort
 }
 
-func newPublisher(st apiHostPortsSetter, preferIPv6 bool) *publisher {
+func newPublisher(st apiHostPortsSetter) *publisher {
 	return &publisher{
-		st:         st,
-		preferIPv6: preferIPv6,
+		st: st,
 	}
 }
 
@@ -38,10 +37,19 @@ func (pub *publisher) publishAPIServers(apiServers [][]network.HostPort, instanc
 	pub.mu.Lock()
 	defer pub.mu.Unlock()
 
-	sortedAPIServers := make([][]network.HostPort, len(apiServers))
-	for i, hostPorts := range apiServers {
-		sortedAPIServers[i] = append([]network.HostPort{}, hostPorts...)
-		network.SortHostPorts(sortedAPIServers[i], pub.preferIPv6)
+	policy, err := pub.st.AddressPolicy()
+	if err != nil {
+		return errors.Annotate(err, "cannot get address policy")
+	}
+	sortedAPIServers := make([][]network.HostPort, 0, len(apiServers))
+	for _, hostPorts := range apiServers {
+		// Drop any server left with no addresses the policy allows.
+		if hostPorts = policy.FilterHostPorts(hostPorts); len(hostPorts) > 0 {
+			sortedAPIServers = append(sortedAPIServers, hostPorts)
+		}
+	}
+	if len(sortedAPIServers) == 0 {
+		return errors.Errorf("no api server addresses allowed by address policy %q", policy.Family)
 	}
 	if apiServersEqual(sortedAPIServers, pub.lastAPIServers) {
 		logger.Debugf("API host ports have not changed")
@@ -49,7 +57,7 @@ func (pub *publisher) publishAPIServers(apiServers [][]network.HostPort, instanc
 	}
 
 	// TODO(rog) publish instanceIds in environment storage.
-	err := pub.st.SetAPIHostPorts(sortedAPIServers)
+	err = pub.st.SetAPIHostPorts(sortedAPIServers)
 	if err != nil {
 		return err
 	}
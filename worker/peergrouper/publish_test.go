@@ -20,6 +20,7 @@ var _ = gc.Suite(&publishSuite{})
 type mockAPIHostPortsSetter struct {
 	calls        int
 	apiHostPorts [][]network.HostPort
+	policy       network.AddressPolicy
 }
 
 func (s *mockAPIHostPortsSetter) SetAPIHostPorts(apiHostPorts [][]network.HostPort) error {
@@ -28,9 +29,13 @@ func (s *mockAPIHostPortsSetter) SetAPIHostPorts(apiHostPorts [][]network.HostPo
 	return nil
 }
 
+func (s *mockAPIHostPortsSetter) AddressPolicy() (network.AddressPolicy, error) {
+	return s.policy, nil
+}
+
 func (s *publishSuite) TestPublisherSetsAPIHostPortsOnce(c *gc.C) {
 	var mock mockAPIHostPortsSetter
-	statePublish := newPublisher(&mock, false)
+	statePublish := newPublisher(&mock)
 
 	hostPorts1 := network.NewHostPorts(1234, "testing1.invalid", "127.0.0.1")
 	hostPorts2 := network.NewHostPorts(1234, "testing2.invalid", "127.0.0.2")
@@ -58,9 +63,11 @@ func (s *publishSuite) TestPublisherSortsHostPorts(c *gc.C) {
 	ipV4First := network.NewHostPorts(1234, "testing1.invalid", "127.0.0.1", "::1")
 	ipV6First := network.NewHostPorts(1234, "testing1.invalid", "::1", "127.0.0.1")
 
-	check := func(preferIPv6 bool, publish, expect []network.HostPort) {
-		var mock mockAPIHostPortsSetter
-		statePublish := newPublisher(&mock, preferIPv6)
+	check := func(family network.AddressFamilyPolicy, publish, expect []network.HostPort) {
+		mock := mockAPIHostPortsSetter{
+			policy: network.AddressPolicy{Family: family},
+		}
+		statePublish := newPublisher(&mock)
 		for i := 0; i < 2; i++ {
 			err := statePublish.publishAPIServers([][]network.HostPort{publish}, nil)
 			c.Assert(err, jc.ErrorIsNil)
@@ -69,8 +76,37 @@ func (s *publishSuite) TestPublisherSortsHostPorts(c *gc.C) {
 		c.Assert(mock.apiHostPorts, gc.DeepEquals, [][]network.HostPort{expect})
 	}
 
-	check(false, ipV6First, ipV4First)
-	check(false, ipV4First, ipV4First)
-	check(true, ipV4First, ipV6First)
-	check(true, ipV6First, ipV6First)
+	check(network.IPv4First, ipV6First, ipV4First)
+	check(network.IPv4First, ipV4First, ipV4First)
+	check(network.IPv6First, ipV4First, ipV6First)
+	check(network.IPv6First, ipV6First, ipV6First)
+	check(network.IPv4Only, ipV6First, network.NewHostPorts(1234, "testing1.invalid", "127.0.0.1"))
+	check(network.IPv6Only, ipV4First, network.NewHostPorts(1234, "testing1.invalid", "::1"))
+}
+
+func (s *publishSuite) TestPublisherPrefersSubnets(c *gc.C) {
+	mock := mockAPIHostPortsSetter{
+		policy: network.AddressPolicy{
+			Family:           network.IPv4First,
+			PreferredSubnets: []string{"10.20.0.0/16"},
+		},
+	}
+	statePublish := newPublisher(&mock)
+	hostPorts := network.NewHostPorts(1234, "10.0.0.1", "10.20.0.1", "testing1.invalid")
+	err := statePublish.publishAPIServers([][]network.HostPort{hostPorts}, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(mock.apiHostPorts, gc.DeepEquals, [][]network.HostPort{
+		network.NewHostPorts(1234, "10.20.0.1", "testing1.invalid", "10.0.0.1"),
+	})
+}
+
+func (s *publishSuite) TestPublisherRejectsDisallowedServers(c *gc.C) {
+	mock := mockAPIHostPortsSetter{
+		policy: network.AddressPolicy{Family: network.IPv6Only},
+	}
+	statePublish := newPublisher(&mock)
+	hostPorts := network.NewHostPorts(1234, "10.0.0.1")
+	err := statePublish.publishAPIServers([][]network.HostPort{hostPorts}, nil)
+	c.Assert(err, gc.ErrorMatches, `no api server addresses allowed by address policy "ipv6-only"`)
+	c.Assert(mock.calls, gc.Equals, 0)
 }
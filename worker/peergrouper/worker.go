@@ -24,6 +24,7 @@ type stateInterface interface {
 	WatchStateServerInfo() state.NotifyWatcher
 	StateServerInfo() (*state.StateServerInfo, error)
 	MongoSession() mongoSession
+	AddressPolicy() (network.AddressPolicy, error)
 }
 
 type stateMachine interface {
@@ -109,6 +110,12 @@ type pgWorker struct {
 	// publisher holds the implementation of the API
 	// address publisher.
 	publisher publisherInterface
+
+	// addressPolicy holds the policy used to choose the
+	// address of each machine in the peer group. It is
+	// refreshed from State each time the peer group is
+	// updated.
+	addressPolicy network.AddressPolicy
 }
 
 // New returns a new worker that maintains the mongo replica set
@@ -122,15 +129,16 @@ func New(st *state.State) (worker.Worker, error) {
 		State:     st,
 		mongoPort: cfg.StatePort(),
 		apiPort:   cfg.APIPort(),
-	}, newPublisher(st, cfg.PreferIPv6())), nil
+	}, newPublisher(st)), nil
 }
 
 func newWorker(st stateInterface, pub publisherInterface) worker.Worker {
 	w := &pgWorker{
-		st:        st,
-		notifyCh:  make(chan notifyFunc),
-		machines:  make(map[string]*machine),
-		publisher: pub,
+		st:            st,
+		notifyCh:      make(chan notifyFunc),
+		machines:      make(map[string]*machine),
+		publisher:     pub,
+		addressPolicy: network.CurrentAddressPolicy(),
 	}
 	go func() {
 		defer w.tomb.Done()
@@ -177,6 +185,11 @@ func (w *pgWorker) loop() error {
 			retry.Reset(0)
 		case <-retry.C:
 			ok := true
+			if policy, err := w.st.AddressPolicy(); err != nil {
+				logger.Errorf("cannot get address policy: %v", err)
+			} else {
+				w.addressPolicy = policy
+			}
 			servers, instanceIds, err := w.apiPublishInfo()
 			if err != nil {
 				return fmt.Errorf("cannot get API server info: %v", err)
@@ -451,7 +464,7 @@ type machine struct {
 }
 
 func (m *machine) mongoHostPort() string {
-	return mongo.SelectPeerHostPort(m.mongoHostPorts)
+	return mongo.SelectPeerHostPortByPolicy(m.worker.addressPolicy, m.mongoHostPorts)
 }
 
 func (m *machine) String() string {
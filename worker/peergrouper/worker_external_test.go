@@ -37,7 +37,7 @@ func (s *workerJujuConnSuite) TestPublisherSetsAPIHostPorts(c *gc.C) {
 		cwatch := statetesting.NewNotifyWatcherC(c, s.State, watcher)
 		cwatch.AssertOneChange()
 
-		statePublish := peergrouper.NewPublisher(s.State, false)
+		statePublish := peergrouper.NewPublisher(s.State)
 
 		// Wrap the publisher so that we can call StartSync immediately
 		// after the publishAPIServers method is called.
@@ -63,7 +63,7 @@ func (s *workerJujuConnSuite) TestPublisherRejectsNoServers(c *gc.C) {
 	peergrouper.DoTestForIPv4AndIPv6(func(ipVersion peergrouper.TestIPVersion) {
 		st := peergrouper.NewFakeState()
 		peergrouper.InitState(c, st, 3, ipVersion)
-		statePublish := peergrouper.NewPublisher(s.State, false)
+		statePublish := peergrouper.NewPublisher(s.State)
 		err := statePublish.PublishAPIServers(nil, nil)
 		c.Assert(err, gc.ErrorMatches, "no api servers specified")
 	})
@@ -10,7 +10,6 @@ import (
 	"github.com/juju/errors"
 	"github.com/juju/utils"
 	"github.com/juju/utils/exec"
-	"github.com/juju/utils/fslock"
 
 	"github.com/juju/juju/agent"
 	apiprovisioner "github.com/juju/juju/api/provisioner"
@@ -19,9 +18,11 @@ import (
 	"github.com/juju/juju/container"
 	"github.com/juju/juju/container/kvm"
 	"github.com/juju/juju/container/lxc"
+	"github.com/juju/juju/container/lxd"
 	"github.com/juju/juju/environs"
 	"github.com/juju/juju/instance"
 	"github.com/juju/juju/state"
+	"github.com/juju/juju/utils/hooklock"
 	"github.com/juju/juju/worker"
 )
 
@@ -35,7 +36,7 @@ type ContainerSetup struct {
 	provisioner           *apiprovisioner.State
 	machine               *apiprovisioner.Machine
 	config                agent.Config
-	initLock              *fslock.Lock
+	initLock              *hooklock.Lock
 	addressableContainers bool
 
 	// Save the workerName so the worker thread can be stopped.
@@ -57,7 +58,7 @@ type ContainerSetupParams struct {
 	Machine             *apiprovisioner.Machine
 	Provisioner         *apiprovisioner.State
 	Config              agent.Config
-	InitLock            *fslock.Lock
+	InitLock            *hooklock.Lock
 }
 
 // NewContainerSetupHandler returns a StringsWatchHandler which is notified when
@@ -273,6 +274,18 @@ func (cs *ContainerSetup) getContainerArtifacts(
 			logger.Errorf("failed to create new kvm broker")
 			return nil, nil, nil, err
 		}
+
+	case instance.LXD:
+		initialiser = lxd.NewContainerInitialiser()
+		broker, err = NewLxdBroker(cs.provisioner, cs.config, managerConfig)
+		if err != nil {
+			logger.Errorf("failed to create new lxd broker")
+			return nil, nil, nil, err
+		}
+
+		// System containers share the host's kernel, so they
+		// must have the same architecture as the host.
+		toolsFinder = hostArchToolsFinder{toolsFinder}
 	default:
 		return nil, nil, nil, fmt.Errorf("unknown container type: %v", containerType)
 	}
@@ -15,7 +15,6 @@ import (
 	"github.com/juju/testing"
 	jc "github.com/juju/testing/checkers"
 	"github.com/juju/utils/apt"
-	"github.com/juju/utils/fslock"
 	gc "gopkg.in/check.v1"
 
 	"github.com/juju/juju/agent"
@@ -28,6 +27,7 @@ import (
 	"github.com/juju/juju/state"
 	coretesting "github.com/juju/juju/testing"
 	"github.com/juju/juju/tools"
+	"github.com/juju/juju/utils/hooklock"
 	"github.com/juju/juju/version"
 	"github.com/juju/juju/worker"
 	"github.com/juju/juju/worker/provisioner"
@@ -40,7 +40,7 @@ type ContainerSetupSuite struct {
 	// Record the apt commands issued as part of container initialisation
 	aptCmdChan  <-chan *exec.Cmd
 	initLockDir string
-	initLock    *fslock.Lock
+	initLock    *hooklock.Lock
 	fakeLXCNet  string
 }
 
@@ -77,7 +77,7 @@ func (s *ContainerSetupSuite) SetUpTest(c *gc.C) {
 
 	// Create a new container initialisation lock.
 	s.initLockDir = c.MkDir()
-	initLock, err := fslock.NewLock(s.initLockDir, "container-init")
+	initLock, err := hooklock.New(s.initLockDir)
 	c.Assert(err, jc.ErrorIsNil)
 	s.initLock = initLock
 
@@ -174,7 +174,7 @@ func (s *ContainerSetupSuite) TestContainerProvisionerStarted(c *gc.C) {
 			Constraints: s.defaultConstraints,
 		})
 		c.Assert(err, jc.ErrorIsNil)
-		err = m.SetSupportedContainers([]instance.ContainerType{instance.LXC, instance.KVM})
+		err = m.SetSupportedContainers(instance.ContainerTypes)
 		c.Assert(err, jc.ErrorIsNil)
 		err = m.SetAgentVersion(version.Current)
 		c.Assert(err, jc.ErrorIsNil)
@@ -189,6 +189,13 @@ func (s *ContainerSetupSuite) TestLxcContainerUsesConstraintsArch(c *gc.C) {
 	s.testContainerConstraintsArch(c, instance.LXC, arch.PPC64EL)
 }
 
+func (s *ContainerSetupSuite) TestLxdContainerUsesConstraintsArch(c *gc.C) {
+	// LXD should override the architecture in constraints with the
+	// host's architecture, as LXC does.
+	s.PatchValue(&version.Current.Arch, arch.PPC64EL)
+	s.testContainerConstraintsArch(c, instance.LXD, arch.PPC64EL)
+}
+
 func (s *ContainerSetupSuite) TestKvmContainerUsesHostArch(c *gc.C) {
 	// KVM should do what it's told, and use the architecture in
 	// constraints.
@@ -293,7 +300,7 @@ func (s *ContainerSetupSuite) assertContainerInitialised(c *gc.C, ctype instance
 		Constraints: s.defaultConstraints,
 	})
 	c.Assert(err, jc.ErrorIsNil)
-	err = m.SetSupportedContainers([]instance.ContainerType{instance.LXC, instance.KVM})
+	err = m.SetSupportedContainers(instance.ContainerTypes)
 	c.Assert(err, jc.ErrorIsNil)
 	err = m.SetAgentVersion(version.Current)
 	c.Assert(err, jc.ErrorIsNil)
@@ -330,6 +337,7 @@ func (s *ContainerSetupSuite) TestContainerInitialised(c *gc.C) {
 	}{
 		{instance.LXC, []string{"--target-release", "precise-updates/cloud-tools", "lxc", "cloud-image-utils"}},
 		{instance.KVM, []string{"uvtool-libvirt", "uvtool"}},
+		{instance.LXD, []string{"lxd"}},
 	} {
 		s.assertContainerInitialised(c, test.ctype, test.packages)
 	}
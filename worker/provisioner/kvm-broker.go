@@ -99,6 +99,9 @@ func (broker *kvmBroker) StartInstance(args environs.StartInstanceParams) (*envi
 	storageConfig := &container.StorageConfig{
 		AllowMount: true,
 	}
+	// The container manager limits the container's
+	// resources according to the machine's constraints.
+	args.MachineConfig.Constraints = args.Constraints
 	inst, hardware, err := broker.manager.CreateContainer(args.MachineConfig, series, network, storageConfig)
 	if err != nil {
 		kvmLogger.Errorf("failed to start container: %v", err)
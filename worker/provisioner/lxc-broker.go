@@ -134,7 +134,21 @@ func (broker *lxcBroker) StartInstance(args environs.StartInstanceParams) (*envi
 		return nil, err
 	}
 
-	inst, hardware, err := broker.manager.CreateContainer(args.MachineConfig, series, network, storageConfig)
+	// The container manager limits the container's
+	// resources according to the machine's constraints.
+	args.MachineConfig.Constraints = args.Constraints
+	var inst instance.Instance
+	var hardware *instance.HardwareCharacteristics
+	if args.MigratedFrom != "" {
+		migrationTarget, ok := broker.manager.(container.MigrationTarget)
+		if !ok {
+			return nil, errors.NotSupportedf("creating migrated containers")
+		}
+		lxcLogger.Infof("creating container for machineId %s from migrated container %s", machineId, args.MigratedFrom)
+		inst, hardware, err = migrationTarget.CreateMigratedContainer(args.MigratedFrom, args.MachineConfig, series, network, storageConfig)
+	} else {
+		inst, hardware, err = broker.manager.CreateContainer(args.MachineConfig, series, network, storageConfig)
+	}
 	if err != nil {
 		lxcLogger.Errorf("failed to start container: %v", err)
 		return nil, err
@@ -312,6 +326,8 @@ func runTemplateCommand(t *template.Template, exitNonZeroOK bool, data interface
 
 // setupRoutesAndIPTables sets up on the host machine the needed
 // iptables rules and static routes for an addressable container.
+// Each interface is routed via its ParentInterfaceName, or bridgeName
+// if not set.
 var setupRoutesAndIPTables = func(
 	primaryNIC string,
 	primaryAddr network.Address,
@@ -328,13 +344,17 @@ var setupRoutesAndIPTables = func(
 		if containerIP == "" {
 			return errors.Errorf("container IP %q must be set", containerIP)
 		}
+		hostBridge := bridgeName
+		if iface.ParentInterfaceName != "" {
+			hostBridge = iface.ParentInterfaceName
+		}
 		data := struct {
 			HostIF        string
 			HostIP        string
 			HostBridge    string
 			ContainerIP   string
 			ContainerCIDR string
-		}{primaryNIC, primaryAddr.Value, bridgeName, containerIP, iface.CIDR}
+		}{primaryNIC, primaryAddr.Value, hostBridge, containerIP, iface.CIDR}
 
 		for name, rule := range iptablesRules {
 			check := mustExecTemplate("rule", "iptables -t {{.Table}} -C {{.Chain}} {{.Rule}}", rule)
@@ -422,6 +442,28 @@ func discoverPrimaryNIC() (string, network.Address, error) {
 	return "", network.Address{}, errors.Errorf("cannot detect the primary network interface")
 }
 
+// containerBridge returns the name of the host device to attach a
+// container's interface to, when the interface is on the subnet of
+// the host NIC named hostNIC. This is the bridge named "br-<hostNIC>"
+// if the host has one, or defaultBridge otherwise.
+func containerBridge(hostNIC, defaultBridge string) string {
+	if hostNIC == "" {
+		return defaultBridge
+	}
+	interfaces, err := netInterfaces()
+	if err != nil {
+		logger.Warningf("cannot get network interfaces, using bridge %q: %v", defaultBridge, err)
+		return defaultBridge
+	}
+	bridge := "br-" + hostNIC
+	for _, iface := range interfaces {
+		if iface.Name == bridge {
+			return bridge
+		}
+	}
+	return defaultBridge
+}
+
 // MACAddressTemplate is used to generate a unique MAC address for a
 // container. Every 'x' is replaced by a random hexadecimal digit,
 // while the rest is kept as-is.
@@ -472,6 +514,11 @@ func maybeAllocateStaticIP(
 	}
 	// Generate the final configuration for each container interface.
 	for i, _ := range finalIfaceInfo {
+		// Each container interface is on the subnet of the host NIC
+		// it was prepared for, so attach it to that NIC's bridge.
+		finalIfaceInfo[i].ParentInterfaceName = containerBridge(
+			finalIfaceInfo[i].InterfaceName, bridgeDevice,
+		)
 		// Always start at the first device index and generate the
 		// interface name based on that. We need to do this otherwise
 		// the container will inherit the host's device index and
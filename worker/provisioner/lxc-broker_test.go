@@ -153,6 +153,25 @@ func (s *lxcBrokerSuite) TestStartInstance(c *gc.C) {
 	c.Assert(string(containerConfigContents), gc.Not(jc.Contains), "lxc.aa_profile = lxc-container-default-with-mounting")
 }
 
+func (s *lxcBrokerSuite) TestStartInstanceWithConstraints(c *gc.C) {
+	machineConfig := s.machineConfig(c, "1/lxc/0")
+	possibleTools := coretools.List{&coretools.Tools{
+		Version: version.MustParseBinary("2.3.4-quantal-amd64"),
+		URL:     "http://tools.testing.invalid/2.3.4-quantal-amd64.tgz",
+	}}
+	result, err := s.broker.StartInstance(environs.StartInstanceParams{
+		Constraints:   constraints.MustParse("mem=1G cpu-cores=1"),
+		Tools:         possibleTools,
+		MachineConfig: machineConfig,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Hardware.String(), gc.Equals, "arch=amd64 cpu-cores=1 mem=1024M")
+	containerConfigContents, err := ioutil.ReadFile(filepath.Join(s.LxcDir, string(result.Instance.Id()), "config"))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(containerConfigContents), jc.Contains, "lxc.cgroup.memory.limit_in_bytes = 1024M")
+	c.Assert(string(containerConfigContents), jc.Contains, "lxc.cgroup.cpu.cfs_quota_us = 100000")
+}
+
 func (s *lxcBrokerSuite) TestStartInstanceWithStorage(c *gc.C) {
 	s.allowLXCLoopMounts = true
 	machineId := "1/lxc/0"
@@ -236,6 +255,20 @@ func (s *lxcBrokerSuite) TestStartInstanceWithBridgeEnviron(c *gc.C) {
 	c.Assert(string(lxcConfContents), jc.Contains, "lxc.network.link = br0")
 }
 
+func (s *lxcBrokerSuite) TestStartInstanceMigratedNotStaged(c *gc.C) {
+	possibleTools := coretools.List{&coretools.Tools{
+		Version: version.MustParseBinary("2.3.4-quantal-amd64"),
+		URL:     "http://tools.testing.invalid/2.3.4-quantal-amd64.tgz",
+	}}
+	_, err := s.broker.StartInstance(environs.StartInstanceParams{
+		Tools:         possibleTools,
+		MachineConfig: s.machineConfig(c, "1/lxc/0"),
+		MigratedFrom:  "juju-machine-0-lxc-3",
+	})
+	c.Assert(err, gc.ErrorMatches, `staged root filesystem of migrated container "juju-machine-0-lxc-3" not found`)
+	s.assertInstances(c)
+}
+
 func (s *lxcBrokerSuite) TestStopInstance(c *gc.C) {
 	lxc0 := s.startInstance(c, "1/lxc/0", nil)
 	lxc1 := s.startInstance(c, "1/lxc/1", nil)
@@ -606,6 +639,21 @@ func (s *lxcBrokerSuite) TestSetupRoutesAndIPTablesAddsRuleIfMissing(c *gc.C) {
 	gitjujutesting.AssertEchoArgs(c, "ip", "route", "add", "0.1.2.3", "dev", "bridge")
 }
 
+func (s *lxcBrokerSuite) TestSetupRoutesAndIPTablesUsesParentInterface(c *gc.C) {
+	s.PatchValue(provisioner.IptablesRules, map[string]provisioner.IptablesRule{})
+	gitjujutesting.PatchExecutableAsEchoArgs(c, s, "ip")
+
+	ifaceInfo := []network.InterfaceInfo{{
+		Address:             network.NewAddress("0.2.3.4"),
+		ParentInterfaceName: "br-eth1",
+	}}
+
+	addr := network.NewAddress("0.1.2.1")
+	err := provisioner.SetupRoutesAndIPTables("nic", addr, "bridge", ifaceInfo)
+	c.Assert(err, jc.ErrorIsNil)
+	gitjujutesting.AssertEchoArgs(c, "ip", "route", "add", "0.2.3.4", "dev", "br-eth1")
+}
+
 func (s *lxcBrokerSuite) TestDiscoverPrimaryNICNetInterfacesError(c *gc.C) {
 	s.PatchValue(provisioner.NetInterfaces, func() ([]net.Interface, error) {
 		return nil, errors.New("boom!")
@@ -752,6 +800,51 @@ func (s *lxcBrokerSuite) TestMaybeAllocateStaticIP(c *gc.C) {
 		DNSServers:     network.NewAddresses("ns1.dummy"),
 		Address:        network.NewAddress("0.1.2.3"),
 		GatewayAddress: network.NewAddress("0.1.2.1"),
+
+		ParentInterfaceName: "bridge", // no bridge for "dummy0" on the host.
+	}})
+}
+
+func (s *lxcBrokerSuite) TestMaybeAllocateStaticIPMultipleNICs(c *gc.C) {
+	s.PatchValue(provisioner.NetInterfaces, func() ([]net.Interface, error) {
+		return []net.Interface{{
+			Index: 0,
+			Name:  "fake0",
+			Flags: net.FlagUp,
+		}, {
+			Index: 1,
+			Name:  "br-eth1",
+			Flags: net.FlagUp,
+		}}, nil
+	})
+	s.PatchValue(provisioner.InterfaceAddrs, func(i *net.Interface) ([]net.Addr, error) {
+		return []net.Addr{&fakeAddr{"0.1.2.1/24"}}, nil
+	})
+	s.PatchValue(provisioner.ResolvConf, filepath.Join(c.MkDir(), "resolv.conf"))
+
+	// Each container NIC is attached to the bridge of the host NIC
+	// it was prepared for, when there is one.
+	api := &multiNICAPI{fakeAPI{c, nil}}
+	result, err := provisioner.MaybeAllocateStaticIP("42", "bridge", api, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, []network.InterfaceInfo{{
+		DeviceIndex:         0,
+		CIDR:                "0.1.2.0/24",
+		ConfigType:          network.ConfigStatic,
+		InterfaceName:       "eth0",
+		MACAddress:          provisioner.MACAddressTemplate,
+		Address:             network.NewAddress("0.1.2.3"),
+		GatewayAddress:      network.NewAddress("0.1.2.1"),
+		ParentInterfaceName: "bridge",
+	}, {
+		DeviceIndex:         1,
+		CIDR:                "0.2.3.0/24",
+		ConfigType:          network.ConfigStatic,
+		InterfaceName:       "eth1",
+		MACAddress:          provisioner.MACAddressTemplate,
+		Address:             network.NewAddress("0.2.3.4"),
+		GatewayAddress:      network.NewAddress("0.1.2.1"),
+		ParentInterfaceName: "br-eth1",
 	}})
 }
 
@@ -916,6 +1009,28 @@ func (s *lxcProvisionerSuite) TestContainerStartedAndStopped(c *gc.C) {
 	s.waitRemoved(c, container)
 }
 
+// multiNICAPI prepares a container NIC for each of two host NICs.
+type multiNICAPI struct {
+	fakeAPI
+}
+
+func (f *multiNICAPI) PrepareContainerInterfaceInfo(tag names.MachineTag) ([]network.InterfaceInfo, error) {
+	f.c.Assert(tag.String(), gc.Equals, "machine-42")
+	return []network.InterfaceInfo{{
+		DeviceIndex:    0,
+		CIDR:           "0.1.2.0/24",
+		InterfaceName:  "eth0",
+		Address:        network.NewAddress("0.1.2.3"),
+		GatewayAddress: network.NewAddress("0.1.2.1"),
+	}, {
+		DeviceIndex:    1,
+		CIDR:           "0.2.3.0/24",
+		InterfaceName:  "eth1",
+		Address:        network.NewAddress("0.2.3.4"),
+		GatewayAddress: network.NewAddress("0.2.3.1"),
+	}}, nil
+}
+
 type fakeAPI struct {
 	c     *gc.C
 	suite *lxcBrokerSuite
@@ -0,0 +1,118 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package provisioner_test
+
+import (
+	"path/filepath"
+	"runtime"
+
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/agent"
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/container/lxd"
+	lxdtesting "github.com/juju/juju/container/lxd/testing"
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/instance"
+	instancetest "github.com/juju/juju/instance/testing"
+	jujutesting "github.com/juju/juju/juju/testing"
+	coretesting "github.com/juju/juju/testing"
+	coretools "github.com/juju/juju/tools"
+	"github.com/juju/juju/version"
+	"github.com/juju/juju/worker/provisioner"
+)
+
+type lxdBrokerSuite struct {
+	lxdtesting.TestSuite
+	broker      environs.InstanceBroker
+	agentConfig agent.Config
+}
+
+var _ = gc.Suite(&lxdBrokerSuite{})
+
+func (s *lxdBrokerSuite) SetUpTest(c *gc.C) {
+	if runtime.GOOS == "windows" {
+		c.Skip("Skipping lxd tests on windows")
+	}
+	s.TestSuite.SetUpTest(c)
+	var err error
+	s.agentConfig, err = agent.NewAgentConfig(
+		agent.AgentConfigParams{
+			DataDir:           "/not/used/here",
+			Tag:               names.NewMachineTag("1"),
+			UpgradedToVersion: version.Current.Number,
+			Password:          "dummy-secret",
+			Nonce:             "nonce",
+			APIAddresses:      []string{"10.0.0.1:1234"},
+			CACert:            coretesting.CACert,
+			Environment:       coretesting.EnvironmentTag,
+		})
+	c.Assert(err, jc.ErrorIsNil)
+	s.broker, err = provisioner.NewLxdBroker(&fakeAPI{}, s.agentConfig, s.ManagerConfig("juju"))
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *lxdBrokerSuite) startInstance(c *gc.C, machineId string) instance.Instance {
+	machineNonce := "fake-nonce"
+	stateInfo := jujutesting.FakeStateInfo(machineId)
+	apiInfo := jujutesting.FakeAPIInfo(machineId)
+	machineConfig, err := environs.NewMachineConfig(machineId, machineNonce, "released", "quantal", true, nil, stateInfo, apiInfo)
+	c.Assert(err, jc.ErrorIsNil)
+	possibleTools := coretools.List{&coretools.Tools{
+		Version: version.MustParseBinary("2.3.4-quantal-amd64"),
+		URL:     "http://tools.testing.invalid/2.3.4-quantal-amd64.tgz",
+	}}
+	result, err := s.broker.StartInstance(environs.StartInstanceParams{
+		Constraints:   constraints.Value{},
+		Tools:         possibleTools,
+		MachineConfig: machineConfig,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	return result.Instance
+}
+
+func (s *lxdBrokerSuite) TestStartInstance(c *gc.C) {
+	inst := s.startInstance(c, "1/lxd/0")
+	c.Assert(inst.Id(), gc.Equals, instance.Id("juju-machine-1-lxd-0"))
+	ctr, ok := s.Daemon.Container(string(inst.Id()))
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(ctr.Status, gc.Equals, lxd.StatusRunning)
+	c.Assert(ctr.Spec.Devices["eth0"]["parent"], gc.Equals, lxd.DefaultLxdBridge)
+	c.Assert(filepath.Join(s.ContainerDir, string(inst.Id()), "cloud-init"), jc.IsNonEmptyFile)
+}
+
+func (s *lxdBrokerSuite) TestStopInstance(c *gc.C) {
+	lxd0 := s.startInstance(c, "1/lxd/0")
+	lxd1 := s.startInstance(c, "1/lxd/1")
+	lxd2 := s.startInstance(c, "1/lxd/2")
+
+	err := s.broker.StopInstances(lxd0.Id())
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertInstances(c, lxd1, lxd2)
+	c.Assert(filepath.Join(s.ContainerDir, string(lxd0.Id())), jc.DoesNotExist)
+	c.Assert(filepath.Join(s.RemovedDir, string(lxd0.Id())), jc.IsDirectory)
+
+	err = s.broker.StopInstances(lxd1.Id(), lxd2.Id())
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertInstances(c)
+}
+
+func (s *lxdBrokerSuite) TestAllInstances(c *gc.C) {
+	lxd0 := s.startInstance(c, "1/lxd/0")
+	lxd1 := s.startInstance(c, "1/lxd/1")
+	s.assertInstances(c, lxd0, lxd1)
+
+	err := s.broker.StopInstances(lxd1.Id())
+	c.Assert(err, jc.ErrorIsNil)
+	lxd2 := s.startInstance(c, "1/lxd/2")
+	s.assertInstances(c, lxd0, lxd2)
+}
+
+func (s *lxdBrokerSuite) assertInstances(c *gc.C, inst ...instance.Instance) {
+	results, err := s.broker.AllInstances()
+	c.Assert(err, jc.ErrorIsNil)
+	instancetest.MatchInstances(c, results, inst...)
+}
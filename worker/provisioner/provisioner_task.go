@@ -26,6 +26,7 @@ import (
 	"github.com/juju/juju/state/watcher"
 	"github.com/juju/juju/storage"
 	coretools "github.com/juju/juju/tools"
+	"github.com/juju/juju/trace"
 	"github.com/juju/juju/version"
 	"github.com/juju/juju/worker"
 )
@@ -515,6 +516,14 @@ func constructStartInstanceParams(
 		}
 	}
 
+	var subnetsToZones map[network.Id][]string
+	if len(provisioningInfo.SubnetsToZones) > 0 {
+		subnetsToZones = make(map[network.Id][]string)
+		for subnetId, zones := range provisioningInfo.SubnetsToZones {
+			subnetsToZones[network.Id(subnetId)] = zones
+		}
+	}
+
 	return environs.StartInstanceParams{
 		Constraints:       provisioningInfo.Constraints,
 		Tools:             possibleTools,
@@ -522,6 +531,8 @@ func constructStartInstanceParams(
 		Placement:         provisioningInfo.Placement,
 		DistributionGroup: machine.DistributionGroup,
 		Volumes:           volumes,
+		SubnetsToZones:    subnetsToZones,
+		MigratedFrom:      provisioningInfo.MigratedFrom,
 	}, nil
 }
 
@@ -611,12 +622,15 @@ func (task *provisionerTask) startMachine(
 	provisioningInfo *params.ProvisioningInfo,
 	startInstanceParams environs.StartInstanceParams,
 ) error {
-
+	// Mark the log messages about the machine with the trace id
+	// of the request that added it, if any.
+	tlogger := trace.NewLogger(logger, provisioningInfo.TraceId)
+	tlogger.Debugf("starting instance for machine %q", machine)
 	result, err := task.broker.StartInstance(startInstanceParams)
 	if err != nil {
 		// If this is a retryable error, we retry once
 		if instance.IsRetryableCreationError(errors.Cause(err)) {
-			logger.Infof("retryable error received on start instance - retrying instance creation")
+			tlogger.Infof("retryable error received on start instance - retrying instance creation")
 			result, err = task.broker.StartInstance(startInstanceParams)
 			if err != nil {
 				return task.setErrorStatus("cannot start instance for machine after a retry %q: %v", machine, err)
@@ -648,7 +662,7 @@ func (task *provisionerTask) startMachine(
 	if err != nil && params.IsCodeNotImplemented(err) {
 		return fmt.Errorf("cannot provision instance %v for machine %q with networks: not implemented", inst.Id(), machine)
 	} else if err == nil {
-		logger.Infof(
+		tlogger.Infof(
 			"started machine %s as instance %s with hardware %q, networks %v, interfaces %v, volumes %v, volume attachments %v",
 			machine, inst.Id(), hardware, networks, ifaces, volumes, volumeAttachments,
 		)
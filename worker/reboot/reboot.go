@@ -4,13 +4,13 @@ import (
 	"github.com/juju/errors"
 	"github.com/juju/loggo"
 	"github.com/juju/names"
-	"github.com/juju/utils/fslock"
 	"launchpad.net/tomb"
 
 	"github.com/juju/juju/agent"
 	"github.com/juju/juju/api/reboot"
 	"github.com/juju/juju/api/watcher"
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/utils/hooklock"
 	"github.com/juju/juju/worker"
 )
 
@@ -29,10 +29,10 @@ type Reboot struct {
 	tomb        tomb.Tomb
 	st          *reboot.State
 	tag         names.MachineTag
-	machineLock *fslock.Lock
+	machineLock *hooklock.Lock
 }
 
-func NewReboot(st *reboot.State, agentConfig agent.Config, machineLock *fslock.Lock) (worker.Worker, error) {
+func NewReboot(st *reboot.State, agentConfig agent.Config, machineLock *hooklock.Lock) (worker.Worker, error) {
 	tag, ok := agentConfig.Tag().(names.MachineTag)
 	if !ok {
 		return nil, errors.Errorf("Expected names.MachineTag, got %T: %v", agentConfig.Tag(), agentConfig.Tag())
@@ -46,19 +46,11 @@ func NewReboot(st *reboot.State, agentConfig agent.Config, machineLock *fslock.L
 }
 
 func (r *Reboot) checkForRebootState() error {
-	var err error
-	if r.machineLock.IsLocked() == false {
-		return nil
-	}
-
-	if r.machineLock.Message() == RebootMessage {
-		// Not a lock held by the machne agent in order to reboot
-		err = r.machineLock.BreakLock()
-		if err != nil {
-			return errors.Trace(err)
-		}
-	}
-	return nil
+	// Break any lock held by the machine agent in order to reboot.
+	err := r.machineLock.BreakLocks(func(message string) bool {
+		return message == RebootMessage
+	})
+	return errors.Trace(err)
 }
 
 func (r *Reboot) SetUp() (watcher.NotifyWatcher, error) {
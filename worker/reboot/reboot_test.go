@@ -5,7 +5,6 @@ import (
 
 	jc "github.com/juju/testing/checkers"
 	"github.com/juju/utils"
-	"github.com/juju/utils/fslock"
 	gc "gopkg.in/check.v1"
 
 	"github.com/juju/juju/api"
@@ -14,6 +13,7 @@ import (
 	jujutesting "github.com/juju/juju/juju/testing"
 	"github.com/juju/juju/state"
 	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/utils/hooklock"
 	"github.com/juju/juju/worker"
 	"github.com/juju/juju/worker/reboot"
 )
@@ -38,8 +38,7 @@ type rebootSuite struct {
 	ct            *state.Machine
 	ctRebootState *apireboot.State
 
-	lock       *fslock.Lock
-	lockReboot *fslock.Lock
+	lock *hooklock.Lock
 }
 
 var _ = gc.Suite(&rebootSuite{})
@@ -75,7 +74,7 @@ func (s *rebootSuite) SetUpTest(c *gc.C) {
 	c.Assert(err, jc.ErrorIsNil)
 	c.Assert(s.ctRebootState, gc.NotNil)
 
-	lock, err := fslock.NewLock(c.MkDir(), "fake")
+	lock, err := hooklock.New(c.MkDir())
 	c.Assert(err, jc.ErrorIsNil)
 	s.lock = lock
 }
@@ -0,0 +1,420 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package remoterelations implements a worker which synchronises the
+// relations between an environment's services and the remote services
+// consuming offers from other environments on the same controller.
+//
+// For each relation of a remote service, the worker ensures that the
+// offering environment holds a matching relation between the offered
+// service and a remote service standing for the consuming service. The
+// settings of the units on each side are then mirrored into the other
+// environment's relation as remote units.
+package remoterelations
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+	"github.com/juju/names"
+	"gopkg.in/juju/charm.v5-unstable"
+	"launchpad.net/tomb"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/watcher"
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.remoterelations")
+
+// retryDelay is the time after which a failed synchronisation is
+// retried, if no change has prompted another one first.
+var retryDelay = 10 * time.Second
+
+type syncer struct {
+	st *state.State
+
+	// sources holds a State for each environment holding offers
+	// consumed by the remote services, keyed by environment UUID.
+	// They are opened when first needed, and kept open until the
+	// worker stops or the environment is no longer consumed from.
+	sources map[string]*state.State
+
+	// watchers holds a watcher for each environment in sources,
+	// whose changes are forwarded to changes.
+	watchers map[string]*sourceWatcher
+
+	// changes receives a value when a source environment changes.
+	changes chan struct{}
+
+	// failed records whether the last pass failed to synchronise
+	// any remote service.
+	failed bool
+}
+
+// sourceWatcher watches a source environment for changes to its
+// relations with remote services.
+type sourceWatcher struct {
+	watcher state.NotifyWatcher
+
+	// done is closed when the watcher's changes channel is.
+	done chan struct{}
+}
+
+func newSyncer(st *state.State) *syncer {
+	return &syncer{
+		st:       st,
+		sources:  make(map[string]*state.State),
+		watchers: make(map[string]*sourceWatcher),
+		changes:  make(chan struct{}, 1),
+	}
+}
+
+// New returns a worker which synchronises the relations of the remote
+// services in the given state's environment with the environments
+// holding the offers they consume, whenever the relations, the units
+// in them or their settings change on either side.
+func New(st *state.State) worker.Worker {
+	return worker.NewSimpleWorker(newSyncer(st).loop)
+}
+
+func (s *syncer) loop(stop <-chan struct{}) error {
+	defer s.closeSources()
+	w := s.st.WatchRemoteRelations()
+	defer w.Stop()
+	var retry <-chan time.Time
+	for {
+		select {
+		case <-stop:
+			return tomb.ErrDying
+		case _, ok := <-w.Changes():
+			if !ok {
+				return watcher.EnsureErr(w)
+			}
+		case <-s.changes:
+		case <-retry:
+		}
+		if err := s.sync(stop); err == worker.ErrKilled {
+			return tomb.ErrDying
+		} else if err != nil {
+			return errors.Trace(err)
+		}
+		retry = nil
+		if s.failed {
+			retry = time.After(retryDelay)
+		}
+	}
+}
+
+// sync synchronises the relations of all the environment's remote
+// services which consume offers. A failure to synchronise one remote
+// service is logged, and does not prevent the others being
+// synchronised.
+func (s *syncer) sync(stop <-chan struct{}) error {
+	remotes, err := s.st.AllRemoteServices()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	s.failed = false
+	consumed := make(map[string]bool)
+	for _, remote := range remotes {
+		// Remote services without an offer stand for services
+		// consuming offers from this environment; they are
+		// maintained by the consuming environment's worker.
+		if remote.OfferName() == "" {
+			continue
+		}
+		select {
+		case <-stop:
+			return worker.ErrKilled
+		default:
+		}
+		consumed[remote.SourceEnvUUID()] = true
+		if err := s.syncRemoteService(remote); err != nil {
+			logger.Errorf("cannot synchronise remote service %q: %v", remote, err)
+			// The source environment's State is reopened on
+			// the next pass, in case it is the cause.
+			s.closeSource(remote.SourceEnvUUID())
+			s.failed = true
+		}
+	}
+	for uuid := range s.sources {
+		if !consumed[uuid] {
+			s.closeSource(uuid)
+		}
+	}
+	return nil
+}
+
+// source returns a State for the environment with the given UUID,
+// opening it and starting to watch it if it is not already open.
+func (s *syncer) source(uuid string) (*state.State, error) {
+	if st, ok := s.sources[uuid]; ok {
+		select {
+		case <-s.watchers[uuid].done:
+			// The watcher failed, so changes to the environment
+			// would go unnoticed; start again.
+			err := watcher.EnsureErr(s.watchers[uuid].watcher)
+			logger.Warningf("watcher for environment %q stopped: %v", uuid, err)
+			s.closeSource(uuid)
+		default:
+			return st, nil
+		}
+	}
+	// ForEnviron does not check that the environment exists.
+	tag := names.NewEnvironTag(uuid)
+	if _, err := s.st.GetEnvironment(tag); err != nil {
+		return nil, errors.Annotate(err, "cannot get source environment")
+	}
+	st, err := s.st.ForEnviron(tag)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	s.sources[uuid] = st
+	s.watchers[uuid] = s.watchSource(st)
+	return st, nil
+}
+
+// watchSource starts watching the given source environment, and
+// forwarding its changes to the syncer. A final change is forwarded
+// when the watcher stops, so that a failed watcher is soon replaced.
+func (s *syncer) watchSource(st *state.State) *sourceWatcher {
+	sw := &sourceWatcher{
+		watcher: st.WatchRemoteRelations(),
+		done:    make(chan struct{}),
+	}
+	go func() {
+		defer close(sw.done)
+		for _ = range sw.watcher.Changes() {
+			s.notify()
+		}
+		s.notify()
+	}()
+	return sw
+}
+
+// notify records that a source environment has changed, without
+// blocking if a change is already recorded.
+func (s *syncer) notify() {
+	select {
+	case s.changes <- struct{}{}:
+	default:
+	}
+}
+
+// closeSource stops watching and closes the State for the environment
+// with the given UUID, if it is open.
+func (s *syncer) closeSource(uuid string) {
+	st, ok := s.sources[uuid]
+	if !ok {
+		return
+	}
+	sw := s.watchers[uuid]
+	delete(s.sources, uuid)
+	delete(s.watchers, uuid)
+	if err := sw.watcher.Stop(); err != nil {
+		logger.Warningf("error watching environment %q: %v", uuid, err)
+	}
+	<-sw.done
+	if err := st.Close(); err != nil {
+		logger.Warningf("cannot close state for environment %q: %v", uuid, err)
+	}
+}
+
+// closeSources closes the States of all the source environments.
+func (s *syncer) closeSources() {
+	for uuid := range s.sources {
+		s.closeSource(uuid)
+	}
+}
+
+// syncRemoteService synchronises the relations of a remote service
+// with the environment holding its offer.
+func (s *syncer) syncRemoteService(remote *state.RemoteService) error {
+	sourceSt, err := s.source(remote.SourceEnvUUID())
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	offer, err := sourceSt.ServiceOffer(remote.OfferName())
+	if errors.IsNotFound(err) {
+		logger.Warningf("offer %q for remote service %q no longer exists", remote.OfferName(), remote)
+		return nil
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	rels, err := remote.Relations()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, rel := range rels {
+		if err := s.syncRelation(sourceSt, offer, remote, rel); err != nil {
+			return errors.Annotatef(err, "relation %q", rel)
+		}
+	}
+	return nil
+}
+
+// syncRelation ensures that the offering environment holds a relation
+// matching rel, and mirrors the units of each side into the other.
+func (s *syncer) syncRelation(sourceSt *state.State, offer *state.ServiceOffer, remote *state.RemoteService, rel *state.Relation) error {
+	remoteEp, err := rel.Endpoint(remote.Name())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	localEps, err := rel.RelatedEndpoints(remote.Name())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	localEp := localEps[0]
+	sourceEps := []state.Endpoint{{
+		ServiceName: offer.ServiceName(),
+		Relation:    remoteEp.Relation,
+	}, {
+		ServiceName: localEp.ServiceName,
+		Relation:    localEp.Relation,
+	}}
+
+	if rel.Life() != state.Alive {
+		// The relation is going away: break the offering
+		// environment's side of it, and withdraw the units.
+		sourceRel, err := sourceSt.EndpointsRelation(sourceEps...)
+		if err == nil {
+			if err := sourceRel.Destroy(); err != nil {
+				return errors.Trace(err)
+			}
+			if err := leaveAll(sourceRel, localEp.ServiceName); err != nil {
+				return errors.Trace(err)
+			}
+		} else if !errors.IsNotFound(err) {
+			return errors.Trace(err)
+		}
+		return leaveAll(rel, remote.Name())
+	}
+
+	if err := ensureProxy(sourceSt, s.st.EnvironUUID(), localEp); errors.IsAlreadyExists(err) {
+		// The offering environment has a service of the same
+		// name; the relation cannot be made until it is gone.
+		logger.Errorf("cannot relate %q to offer %q: %v", localEp.ServiceName, offer, err)
+		return nil
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	sourceRel, err := sourceSt.EndpointsRelation(sourceEps...)
+	if errors.IsNotFound(err) {
+		sourceRel, err = sourceSt.AddRelation(sourceEps...)
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if sourceRel.Life() != state.Alive {
+		// The offering environment is breaking the relation.
+		if err := rel.Destroy(); err != nil {
+			return errors.Trace(err)
+		}
+		if err := leaveAll(rel, remote.Name()); err != nil {
+			return errors.Trace(err)
+		}
+		return leaveAll(sourceRel, localEp.ServiceName)
+	}
+	if err := mirrorUnits(rel, localEp.ServiceName, sourceRel, localEp.ServiceName); err != nil {
+		return errors.Trace(err)
+	}
+	return mirrorUnits(sourceRel, offer.ServiceName(), rel, remote.Name())
+}
+
+// ensureProxy ensures that the offering environment holds a remote
+// service standing for the consuming service with the given endpoint.
+func ensureProxy(sourceSt *state.State, envUUID string, ep state.Endpoint) error {
+	proxy, err := sourceSt.RemoteService(ep.ServiceName)
+	if errors.IsNotFound(err) {
+		_, err = sourceSt.AddRemoteService(state.AddRemoteServiceArgs{
+			Name:          ep.ServiceName,
+			SourceEnvUUID: envUUID,
+			Endpoints:     []charm.Relation{ep.Relation},
+		})
+		return errors.Trace(err)
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	if proxy.SourceEnvUUID() != envUUID {
+		return errors.AlreadyExistsf("remote service %q for another environment", proxy)
+	}
+	if _, err := proxy.Endpoint(ep.Name); err != nil {
+		return errors.Errorf("remote service %q does not have relation %q", proxy, ep.Name)
+	}
+	return nil
+}
+
+// mirrorUnits ensures that the units of fromService in scope in the
+// from relation are in scope in the to relation, as remote units of
+// toService with the same settings, and that no other remote units of
+// toService are.
+func mirrorUnits(from *state.Relation, fromService string, to *state.Relation, toService string) error {
+	source, err := from.ServiceUnitSettings(fromService)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	target, err := to.ServiceUnitSettings(toService)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	seen := make(map[string]bool)
+	for unitName, settings := range source {
+		name := toService + strings.TrimPrefix(unitName, fromService)
+		seen[name] = true
+		ru, err := to.RemoteUnit(name)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		current, ok := target[name]
+		switch {
+		case !ok:
+			err = ru.EnterScope(settings)
+		case !reflect.DeepEqual(current, settings):
+			err = ru.ReplaceSettings(settings)
+		}
+		if err == state.ErrCannotEnterScope {
+			// The relation is dying; the next pass will
+			// withdraw the units.
+			return nil
+		} else if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	for name := range target {
+		if seen[name] {
+			continue
+		}
+		ru, err := to.RemoteUnit(name)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := ru.LeaveScope(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// leaveAll makes all the remote units of the named service leave the
+// relation's scope.
+func leaveAll(rel *state.Relation, serviceName string) error {
+	units, err := rel.ServiceUnitSettings(serviceName)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for name := range units {
+		ru, err := rel.RemoteUnit(name)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := ru.LeaveScope(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
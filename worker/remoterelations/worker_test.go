@@ -0,0 +1,265 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package remoterelations
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/juju/charm.v5-unstable"
+
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/testing/factory"
+	"github.com/juju/juju/worker"
+)
+
+type workerSuite struct {
+	jujutesting.JujuConnSuite
+	sourceSt *state.State
+	mysql    *state.Service
+	rel      *state.Relation
+}
+
+var _ = gc.Suite(&workerSuite{})
+
+func (s *workerSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.sourceSt = s.Factory.MakeEnvironment(c, nil)
+	s.AddCleanup(func(*gc.C) { s.sourceSt.Close() })
+
+	// Offer mysql from the source environment, and relate wordpress
+	// to it in this one.
+	sourceFactory := factory.NewFactory(s.sourceSt)
+	s.mysql = sourceFactory.MakeService(c, &factory.ServiceParams{
+		Name:  "mysql",
+		Charm: sourceFactory.MakeCharm(c, &factory.CharmParams{Name: "mysql"}),
+	})
+	offer, err := s.sourceSt.AddServiceOffer(state.AddServiceOfferArgs{
+		Name:        "db",
+		ServiceName: "mysql",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	eps, err := offer.Endpoints()
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AddRemoteService(state.AddRemoteServiceArgs{
+		Name:          "shareddb",
+		SourceEnvUUID: s.sourceSt.EnvironUUID(),
+		OfferName:     "db",
+		Endpoints:     []charm.Relation{eps[0].Relation},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	eps, err = s.State.InferEndpoints("wordpress", "shareddb")
+	c.Assert(err, jc.ErrorIsNil)
+	s.rel, err = s.State.AddRelation(eps...)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *workerSuite) sync(c *gc.C) {
+	syncer := newSyncer(s.State)
+	defer syncer.closeSources()
+	err := syncer.sync(nil)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *workerSuite) sourceRelation(c *gc.C) *state.Relation {
+	eps, err := s.sourceSt.InferEndpoints("wordpress", "mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	rel, err := s.sourceSt.EndpointsRelation(eps...)
+	c.Assert(err, jc.ErrorIsNil)
+	return rel
+}
+
+func (s *workerSuite) enterScope(c *gc.C, rel *state.Relation, svc *state.Service, settings map[string]interface{}) {
+	unit, err := svc.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	ru, err := rel.Unit(unit)
+	c.Assert(err, jc.ErrorIsNil)
+	err = ru.EnterScope(settings)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *workerSuite) TestSyncAddsProxyAndRelation(c *gc.C) {
+	s.sync(c)
+
+	proxy, err := s.sourceSt.RemoteService("wordpress")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(proxy.SourceEnvUUID(), gc.Equals, s.State.EnvironUUID())
+	c.Assert(proxy.OfferName(), gc.Equals, "")
+	rel := s.sourceRelation(c)
+	c.Assert(rel.String(), gc.Equals, "wordpress:db mysql:server")
+
+	// Synchronising again changes nothing.
+	s.sync(c)
+	c.Assert(s.sourceRelation(c).Id(), gc.Equals, rel.Id())
+}
+
+func (s *workerSuite) TestSyncMirrorsUnits(c *gc.C) {
+	s.sync(c)
+	sourceRel := s.sourceRelation(c)
+
+	wordpress, err := s.State.Service("wordpress")
+	c.Assert(err, jc.ErrorIsNil)
+	s.enterScope(c, s.rel, wordpress, map[string]interface{}{"user": "wp"})
+	s.enterScope(c, sourceRel, s.mysql, map[string]interface{}{"host": "db.example.com"})
+	s.sync(c)
+
+	settings, err := s.rel.ServiceUnitSettings("shareddb")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(settings, gc.DeepEquals, map[string]map[string]interface{}{
+		"shareddb/0": {"host": "db.example.com"},
+	})
+	settings, err = sourceRel.ServiceUnitSettings("wordpress")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(settings, gc.DeepEquals, map[string]map[string]interface{}{
+		"wordpress/0": {"user": "wp"},
+	})
+
+	// Changed settings are mirrored too.
+	ru, err := sourceRel.RemoteUnit("wordpress/0")
+	c.Assert(err, jc.ErrorIsNil)
+	units, err := s.mysql.AllUnits()
+	c.Assert(err, jc.ErrorIsNil)
+	mysqlRU, err := sourceRel.Unit(units[0])
+	c.Assert(err, jc.ErrorIsNil)
+	node, err := mysqlRU.Settings()
+	c.Assert(err, jc.ErrorIsNil)
+	node.Set("host", "db2.example.com")
+	_, err = node.Write()
+	c.Assert(err, jc.ErrorIsNil)
+	s.sync(c)
+	settings, err = s.rel.ServiceUnitSettings("shareddb")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(settings["shareddb/0"], gc.DeepEquals, map[string]interface{}{"host": "db2.example.com"})
+
+	// Departing units are withdrawn.
+	err = mysqlRU.LeaveScope()
+	c.Assert(err, jc.ErrorIsNil)
+	s.sync(c)
+	settings, err = s.rel.ServiceUnitSettings("shareddb")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(settings, gc.HasLen, 0)
+	inScope, err := ru.InScope()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(inScope, jc.IsTrue)
+}
+
+func (s *workerSuite) TestSyncBreaksRelation(c *gc.C) {
+	s.sync(c)
+	sourceRel := s.sourceRelation(c)
+	s.enterScope(c, sourceRel, s.mysql, map[string]interface{}{"host": "db.example.com"})
+	s.sync(c)
+
+	err := s.rel.Destroy()
+	c.Assert(err, jc.ErrorIsNil)
+	s.sync(c)
+
+	// The mirrored unit left, so the relation has gone; the source
+	// relation awaits the departure of mysql/0.
+	err = s.rel.Refresh()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	err = sourceRel.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(sourceRel.Life(), gc.Equals, state.Dying)
+}
+
+func (s *workerSuite) TestSyncIgnoresMissingOffer(c *gc.C) {
+	offer, err := s.sourceSt.ServiceOffer("db")
+	c.Assert(err, jc.ErrorIsNil)
+	err = offer.Remove()
+	c.Assert(err, jc.ErrorIsNil)
+	s.sync(c)
+	_, err = s.sourceSt.RemoteService("wordpress")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *workerSuite) TestSyncContinuesAfterFailure(c *gc.C) {
+	// The environment holding lostdb's offer does not exist.
+	_, err := s.State.AddRemoteService(state.AddRemoteServiceArgs{
+		Name:          "lostdb",
+		SourceEnvUUID: utils.MustNewUUID().String(),
+		OfferName:     "db",
+		Endpoints: []charm.Relation{{
+			Name:      "server",
+			Role:      charm.RoleProvider,
+			Interface: "mysql",
+			Scope:     charm.ScopeGlobal,
+		}},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	s.sync(c)
+
+	c.Assert(c.GetTestLog(), jc.Contains, `cannot synchronise remote service "lostdb"`)
+	_, err = s.sourceSt.RemoteService("wordpress")
+	c.Assert(err, jc.ErrorIsNil)
+	s.sourceRelation(c)
+}
+
+func (s *workerSuite) TestSyncKeepsSourceStates(c *gc.C) {
+	syncer := newSyncer(s.State)
+	defer syncer.closeSources()
+	err := syncer.sync(nil)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(syncer.sources, gc.HasLen, 1)
+	sourceSt := syncer.sources[s.sourceSt.EnvironUUID()]
+	c.Assert(sourceSt, gc.NotNil)
+
+	// The same State is used for the next pass.
+	err = syncer.sync(nil)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(syncer.sources[s.sourceSt.EnvironUUID()], gc.Equals, sourceSt)
+
+	// Once nothing consumes the environment's offers, its State
+	// is closed.
+	remote, err := s.State.RemoteService("shareddb")
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.rel.Destroy()
+	c.Assert(err, jc.ErrorIsNil)
+	err = remote.Destroy()
+	c.Assert(err, jc.ErrorIsNil)
+	err = syncer.sync(nil)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(syncer.sources, gc.HasLen, 0)
+}
+
+func (s *workerSuite) TestWorkerFollowsChanges(c *gc.C) {
+	// Changes must be acted on as they happen, not on retry.
+	s.PatchValue(&retryDelay, time.Hour)
+	w := New(s.State)
+	defer func() { c.Assert(worker.Stop(w), jc.ErrorIsNil) }()
+
+	var sourceRel *state.Relation
+	for a := coretesting.LongAttempt.Start(); sourceRel == nil; {
+		c.Assert(a.Next(), jc.IsTrue, gc.Commentf("relation not made in source environment"))
+		eps, err := s.sourceSt.InferEndpoints("wordpress", "mysql")
+		if errors.IsNotFound(err) {
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		sourceRel, err = s.sourceSt.EndpointsRelation(eps...)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+	}
+
+	// A unit entering scope in the offering environment is mirrored.
+	s.enterScope(c, sourceRel, s.mysql, map[string]interface{}{"host": "db.example.com"})
+	for a := coretesting.LongAttempt.Start(); ; {
+		c.Assert(a.Next(), jc.IsTrue, gc.Commentf("unit not mirrored"))
+		settings, err := s.rel.ServiceUnitSettings("shareddb")
+		c.Assert(err, jc.ErrorIsNil)
+		if len(settings) > 0 {
+			c.Assert(settings, gc.DeepEquals, map[string]map[string]interface{}{
+				"shareddb/0": {"host": "db.example.com"},
+			})
+			break
+		}
+	}
+}
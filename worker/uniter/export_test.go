@@ -12,9 +12,14 @@ func SetUniterObserver(u *Uniter, observer UniterExecutionObserver) {
 	u.observer = observer
 }
 
+func HookConcurrency(u *Uniter) int {
+	return u.currentHookConcurrency()
+}
+
 var (
-	ActiveMetricsTimer = &activeMetricsTimer
-	IdleWaitTime       = &idleWaitTime
+	ActiveMetricsTimer       = &activeMetricsTimer
+	IdleWaitTime             = &idleWaitTime
+	CharmNeedsExclusiveHooks = charmNeedsExclusiveHooks
 )
 
 // manualTicker will be used to generate collect-metrics events
@@ -0,0 +1,83 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package uniter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+
+	corecharm "gopkg.in/juju/charm.v5-unstable"
+	goyaml "gopkg.in/yaml.v1"
+	"launchpad.net/tomb"
+
+	apiwatcher "github.com/juju/juju/api/watcher"
+	"github.com/juju/juju/state/watcher"
+)
+
+// exclusiveHooksKey is the charm metadata key with which a charm
+// declares that its hooks must not run concurrently with any other
+// hooks on the machine; for example, because they install packages.
+const exclusiveHooksKey = "exclusive-hooks"
+
+// charmNeedsExclusiveHooks reports whether the charm deployed in
+// charmDir requires exclusive hook execution. A charm whose metadata
+// cannot be read is assumed to require it.
+func charmNeedsExclusiveHooks(charmDir string) bool {
+	data, err := ioutil.ReadFile(filepath.Join(charmDir, "metadata.yaml"))
+	if err != nil {
+		logger.Debugf("cannot read charm metadata, assuming exclusive hooks: %v", err)
+		return true
+	}
+	if _, err := corecharm.ReadMeta(bytes.NewReader(data)); err != nil {
+		logger.Debugf("cannot parse charm metadata, assuming exclusive hooks: %v", err)
+		return true
+	}
+	// The metadata is valid as far as charm.Meta is concerned, but the
+	// pinned charm package does not yet carry exclusive-hooks, so that
+	// one key is decoded here.
+	var meta struct {
+		ExclusiveHooks bool `yaml:"exclusive-hooks"`
+	}
+	if err := goyaml.Unmarshal(data, &meta); err != nil {
+		logger.Debugf("cannot parse %s, assuming exclusive hooks: %v", exclusiveHooksKey, err)
+		return true
+	}
+	return meta.ExclusiveHooks
+}
+
+// currentHookConcurrency returns the number of hooks that may currently
+// run concurrently on the machine.
+func (u *Uniter) currentHookConcurrency() int {
+	u.hookConcurrencyMutex.Lock()
+	defer u.hookConcurrencyMutex.Unlock()
+	return u.hookConcurrency
+}
+
+// watchHookConcurrency updates the uniter's hook concurrency whenever
+// the environment configuration changes, until the uniter is stopped.
+// Hooks already holding the lock are unaffected by a change.
+func (u *Uniter) watchHookConcurrency(configw apiwatcher.NotifyWatcher) error {
+	for {
+		select {
+		case <-u.tomb.Dying():
+			return tomb.ErrDying
+		case _, ok := <-configw.Changes():
+			if !ok {
+				return watcher.EnsureErr(configw)
+			}
+			cfg, err := u.st.EnvironConfig()
+			if err != nil {
+				return err
+			}
+			concurrency := cfg.HookConcurrency()
+			u.hookConcurrencyMutex.Lock()
+			if concurrency != u.hookConcurrency {
+				logger.Infof("hook concurrency changed to %d", concurrency)
+				u.hookConcurrency = concurrency
+			}
+			u.hookConcurrencyMutex.Unlock()
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package uniter_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/worker/uniter"
+)
+
+type HookLockSuite struct {
+	testing.IsolationSuite
+}
+
+var _ = gc.Suite(&HookLockSuite{})
+
+func (s *HookLockSuite) TestCharmNeedsExclusiveHooks(c *gc.C) {
+	for i, t := range []struct {
+		about     string
+		metadata  string
+		exclusive bool
+	}{{
+		about:     "missing metadata",
+		exclusive: true,
+	}, {
+		about:     "unparseable metadata",
+		metadata:  "{",
+		exclusive: true,
+	}, {
+		about:     "invalid metadata",
+		metadata:  "name: foo\nexclusive-hooks: false\n",
+		exclusive: true,
+	}, {
+		about:    "unspecified",
+		metadata: "name: foo\nsummary: bar\ndescription: baz\n",
+	}, {
+		about:    "not exclusive",
+		metadata: "name: foo\nsummary: bar\ndescription: baz\nexclusive-hooks: false\n",
+	}, {
+		about:     "exclusive",
+		metadata:  "name: foo\nsummary: bar\ndescription: baz\nexclusive-hooks: true\n",
+		exclusive: true,
+	}} {
+		c.Logf("test %d: %s", i, t.about)
+		charmDir := c.MkDir()
+		if t.metadata != "" {
+			err := ioutil.WriteFile(filepath.Join(charmDir, "metadata.yaml"), []byte(t.metadata), 0644)
+			c.Assert(err, jc.ErrorIsNil)
+		}
+		c.Check(uniter.CharmNeedsExclusiveHooks(charmDir), gc.Equals, t.exclusive)
+	}
+}
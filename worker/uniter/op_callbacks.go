@@ -38,6 +38,14 @@ func (opc *operationCallbacks) AcquireExecutionLock(message string) (func(), err
 		return nil
 	}
 	message = fmt.Sprintf("%s: %s", opc.u.unit.Name(), message)
+	concurrency := opc.u.currentHookConcurrency()
+	if concurrency > 1 && !charmNeedsExclusiveHooks(opc.u.paths.GetCharmDir()) {
+		unlock, err := opc.u.hookLock.LockShared(message, concurrency, checkTomb)
+		if err != nil {
+			return nil, err
+		}
+		return func() { unlock() }, nil
+	}
 	if err := opc.u.hookLock.LockWithFunc(message, checkTomb); err != nil {
 		return nil, err
 	}
@@ -371,6 +371,16 @@ func (ctx *HookContext) RelationIds() []int {
 	return ids
 }
 
+// RecordHistory records the execution of a hook or action in the
+// unit's history, noting the relation and remote unit, if any.
+func (ctx *HookContext) RecordHistory(entry params.UnitHistoryEntry) error {
+	if r, found := ctx.HookRelation(); found {
+		entry.Relation = r.FakeId()
+	}
+	entry.RemoteUnit = ctx.remoteUnitName
+	return ctx.unit.AddHistory(entry)
+}
+
 // AddMetrics adds metrics to the hook context.
 func (ctx *HookContext) AddMetric(key, value string, created time.Time) error {
 	if ctx.metricsRecorder == nil || ctx.definedMetrics == nil {
@@ -0,0 +1,361 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package debug
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+	"github.com/juju/names"
+	utilexec "github.com/juju/utils/exec"
+	"github.com/juju/utils/set"
+	goyaml "gopkg.in/yaml.v1"
+
+	"github.com/juju/juju/juju/sockets"
+)
+
+var logger = loggo.GetLogger("juju.worker.uniter.runner.debug")
+
+// The operations understood by a remote (non-interactive) debug-hooks
+// session. They are used by juju-run to drive a session on behalf of
+// "juju debug-hooks --dap".
+const (
+	// RemoteStart enables a session and waits for a matching hook.
+	RemoteStart = "start"
+
+	// RemoteExec runs commands in the context of the paused hook.
+	RemoteExec = "exec"
+
+	// RemoteStep runs the paused hook, and waits for the next
+	// matching hook.
+	RemoteStep = "step"
+
+	// RemoteResume runs the paused hook, if any, and ends the session.
+	RemoteResume = "resume"
+)
+
+const (
+	remoteHookEndpoint = "DebugHooksServer.Hook"
+	remoteExecEndpoint = "DebugHooksServer.Exec"
+	remoteContEndpoint = "DebugHooksServer.Continue"
+)
+
+// HookInfo describes a hook paused by a remote debug-hooks session.
+type HookInfo struct {
+	Hook string   `yaml:"hook"`
+	Env  []string `yaml:"env"`
+}
+
+// ExecArgs holds the arguments for running commands in the context
+// of a paused hook.
+type ExecArgs struct {
+	Commands string
+}
+
+// ContinueArgs holds the arguments for letting a paused hook run.
+type ContinueArgs struct {
+	// Resume, if true, ends the session once the hook has run, so
+	// that subsequent hooks are not paused.
+	Resume bool
+}
+
+// ContinueResult holds the outcome of running a paused hook.
+type ContinueResult struct {
+	Hook  string
+	Error string
+}
+
+// RemoteSessionFile returns the path of the file whose existence
+// indicates that a remote debug-hooks session is active for the unit.
+func (c *HooksContext) RemoteSessionFile() string {
+	return c.ClientFileLock() + "-remote"
+}
+
+// RemoteSocket returns the path of the socket on which a hook paused
+// by a remote debug-hooks session accepts requests.
+func (c *HooksContext) RemoteSocket() string {
+	basename := fmt.Sprintf("juju-%s-debug-hooks.socket", names.NewUnitTag(c.Unit))
+	return filepath.Join(c.FlockDir, basename)
+}
+
+// StartRemoteSession enables a remote debug-hooks session for the unit,
+// matching the specified hooks; if no hooks are specified, or any hook
+// is "*", all hooks will match.
+func (c *HooksContext) StartRemoteSession(hooks []string) error {
+	for _, hook := range hooks {
+		if hook == "*" {
+			hooks = nil
+			break
+		}
+	}
+	return ioutil.WriteFile(c.RemoteSessionFile(), encodeArgs(hooks), 0600)
+}
+
+// StopRemoteSession disables any remote debug-hooks session for the
+// unit. Any hook that is already paused remains so until continued.
+func (c *HooksContext) StopRemoteSession() error {
+	err := os.Remove(c.RemoteSessionFile())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// FindRemoteSession attempts to find a remote debug-hooks session for
+// the unit specified in the context, and returns a new RemoteSession
+// structure for it.
+func (c *HooksContext) FindRemoteSession() (*RemoteSession, error) {
+	data, err := ioutil.ReadFile(c.RemoteSessionFile())
+	if err != nil {
+		return nil, err
+	}
+	var args hookArgs
+	if err := goyaml.Unmarshal(data, &args); err != nil {
+		return nil, err
+	}
+	return &RemoteSession{c, set.NewStrings(args.Hooks...)}, nil
+}
+
+// RemoteSession represents a non-interactive "juju debug-hooks --dap"
+// session. Rather than handing the hook to a tmux window, a matching
+// hook is paused and exposed over a socket, through which the client
+// can run commands in the hook's context before letting it continue.
+type RemoteSession struct {
+	*HooksContext
+	hooks set.Strings
+}
+
+// MatchHook returns true if the specified hook name matches
+// the hooks specified by the debug-hooks client.
+func (s *RemoteSession) MatchHook(hookName string) bool {
+	return s.hooks.IsEmpty() || s.hooks.Contains(hookName)
+}
+
+// RemoteIdleTimeout is how long a hook stays paused without any
+// request from the debug-hooks client before the client is assumed to
+// have gone away, at which point the hook runs and the session ends.
+var RemoteIdleTimeout = 30 * time.Minute
+
+// RunHook pauses the hook with the specified name until the debug-hooks
+// client continues it, serving requests to run commands in the hook's
+// environment in the meantime. Once continued, the hook is executed by
+// calling runHook, and its result is returned.
+//
+// If abort is closed, or the client makes no request for
+// RemoteIdleTimeout, the session ends and the hook runs without
+// waiting any longer, so that a departed client cannot leave the
+// unit stuck in the hook.
+func (s *RemoteSession) RunHook(hookName, charmDir string, env []string, abort <-chan struct{}, runHook func() error) error {
+	env = append(env, "JUJU_HOOK_NAME="+hookName)
+	server := rpc.NewServer()
+	hookServer := &DebugHooksServer{
+		info:     HookInfo{Hook: hookName, Env: env},
+		charmDir: charmDir,
+		cont:     make(chan continueRequest),
+		active:   make(chan struct{}, 1),
+	}
+	if err := server.Register(hookServer); err != nil {
+		return errors.Trace(err)
+	}
+	listener, err := sockets.Listen(s.RemoteSocket())
+	if err != nil {
+		return errors.Annotate(err, "cannot listen for debug-hooks client")
+	}
+	go serve(server, listener)
+
+	req, ok := s.waitContinue(hookServer, abort)
+	if !ok {
+		// Nobody will continue the hook, so end the session and
+		// refuse any late requests before running it.
+		hookServer.markContinued()
+		listener.Close()
+		if err := s.StopRemoteSession(); err != nil {
+			logger.Warningf("cannot stop debug-hooks session: %v", err)
+		}
+		return runHook()
+	}
+	err = runHook()
+	// Stop accepting connections before replying, so that a client
+	// waiting for the next hook cannot mistake this one for it.
+	listener.Close()
+	if req.resume {
+		if err := s.StopRemoteSession(); err != nil {
+			logger.Warningf("cannot stop debug-hooks session: %v", err)
+		}
+	}
+	req.done <- err
+	return err
+}
+
+// waitContinue waits for the debug-hooks client to continue the paused
+// hook. It returns false if abort is closed, or if the client makes no
+// request for RemoteIdleTimeout.
+func (s *RemoteSession) waitContinue(hookServer *DebugHooksServer, abort <-chan struct{}) (continueRequest, bool) {
+	idle := time.NewTimer(RemoteIdleTimeout)
+	defer idle.Stop()
+	for {
+		select {
+		case req := <-hookServer.cont:
+			return req, true
+		case <-hookServer.active:
+			idle.Reset(RemoteIdleTimeout)
+		case <-idle.C:
+			logger.Warningf("debug-hooks client inactive for %v; running %s", RemoteIdleTimeout, hookServer.info.Hook)
+			return continueRequest{}, false
+		case <-abort:
+			logger.Infof("uniter stopping; running %s without waiting for debug-hooks client", hookServer.info.Hook)
+			return continueRequest{}, false
+		}
+	}
+}
+
+func serve(server *rpc.Server, listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go server.ServeConn(conn)
+	}
+}
+
+type continueRequest struct {
+	resume bool
+	done   chan error
+}
+
+// DebugHooksServer holds the methods called over the rpc connection by
+// a remote debug-hooks client.
+type DebugHooksServer struct {
+	info     HookInfo
+	charmDir string
+	cont     chan continueRequest
+	active   chan struct{}
+
+	mu        sync.Mutex
+	continued bool
+}
+
+// touch records that the client made a request.
+func (s *DebugHooksServer) touch() {
+	select {
+	case s.active <- struct{}{}:
+	default:
+	}
+}
+
+// markContinued prevents any further requests for the paused hook.
+func (s *DebugHooksServer) markContinued() {
+	s.mu.Lock()
+	s.continued = true
+	s.mu.Unlock()
+}
+
+// Hook returns the name and environment of the paused hook.
+func (s *DebugHooksServer) Hook(_ struct{}, result *HookInfo) error {
+	s.touch()
+	*result = s.info
+	return nil
+}
+
+// Exec runs the supplied commands in the environment of the paused hook.
+func (s *DebugHooksServer) Exec(args ExecArgs, result *utilexec.ExecResponse) error {
+	s.touch()
+	defer s.touch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.continued {
+		return errors.Errorf("hook %q is no longer paused", s.info.Hook)
+	}
+	command := utilexec.RunParams{
+		Commands:    args.Commands,
+		WorkingDir:  s.charmDir,
+		Environment: s.info.Env,
+	}
+	if err := command.Run(); err != nil {
+		return errors.Trace(err)
+	}
+	response, err := command.Wait()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	*result = *response
+	return nil
+}
+
+// Continue lets the paused hook run, and reports its outcome.
+func (s *DebugHooksServer) Continue(args ContinueArgs, result *ContinueResult) error {
+	s.mu.Lock()
+	if s.continued {
+		s.mu.Unlock()
+		return errors.Errorf("hook %q is no longer paused", s.info.Hook)
+	}
+	s.continued = true
+	s.mu.Unlock()
+
+	done := make(chan error, 1)
+	s.cont <- continueRequest{resume: args.Resume, done: done}
+	result.Hook = s.info.Hook
+	if err := <-done; err != nil {
+		result.Error = err.Error()
+	}
+	return nil
+}
+
+// RemoteClient drives a remote debug-hooks session from the unit's
+// machine.
+type RemoteClient struct {
+	*HooksContext
+}
+
+// NewRemoteClient returns a RemoteClient for the unit in the context.
+func NewRemoteClient(c *HooksContext) *RemoteClient {
+	return &RemoteClient{c}
+}
+
+func (c *RemoteClient) call(method string, args, result interface{}) error {
+	client, err := sockets.Dial(c.RemoteSocket())
+	if err != nil {
+		return errors.Annotatef(err, "no hook paused for %q", c.Unit)
+	}
+	defer client.Close()
+	return client.Call(method, args, result)
+}
+
+// Hook returns the hook currently paused for the unit. An error
+// is returned if no hook is paused.
+func (c *RemoteClient) Hook() (*HookInfo, error) {
+	var info HookInfo
+	if err := c.call(remoteHookEndpoint, struct{}{}, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Exec runs commands in the context of the paused hook.
+func (c *RemoteClient) Exec(commands string) (*utilexec.ExecResponse, error) {
+	var result utilexec.ExecResponse
+	if err := c.call(remoteExecEndpoint, ExecArgs{commands}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Continue runs the paused hook, ending the session once it
+// completes if resume is true.
+func (c *RemoteClient) Continue(resume bool) (*ContinueResult, error) {
+	var result ContinueResult
+	if err := c.call(remoteContEndpoint, ContinueArgs{resume}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,188 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package debug
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/testing"
+)
+
+type DebugHooksRemoteSuite struct {
+	testing.BaseSuite
+	ctx    *HooksContext
+	tmpdir string
+}
+
+var _ = gc.Suite(&DebugHooksRemoteSuite{})
+
+func (s *DebugHooksRemoteSuite) SetUpTest(c *gc.C) {
+	if runtime.GOOS == "windows" {
+		c.Skip("bug 1403084: Currently debug does not work on windows")
+	}
+	s.BaseSuite.SetUpTest(c)
+	s.tmpdir = c.MkDir()
+	s.ctx = NewHooksContext("foo/8")
+	s.ctx.FlockDir = s.tmpdir
+}
+
+func (s *DebugHooksRemoteSuite) TestPaths(c *gc.C) {
+	s.ctx.FlockDir = "/var/lib/juju"
+	c.Assert(s.ctx.RemoteSessionFile(), jc.SamePath, "/var/lib/juju/juju-unit-foo-8-debug-hooks-remote")
+	c.Assert(s.ctx.RemoteSocket(), jc.SamePath, "/var/lib/juju/juju-unit-foo-8-debug-hooks.socket")
+}
+
+func (s *DebugHooksRemoteSuite) TestFindRemoteSession(c *gc.C) {
+	session, err := s.ctx.FindRemoteSession()
+	c.Assert(session, gc.IsNil)
+	c.Assert(err, jc.Satisfies, os.IsNotExist)
+
+	err = s.ctx.StartRemoteSession(nil)
+	c.Assert(err, jc.ErrorIsNil)
+	session, err = s.ctx.FindRemoteSession()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(session.MatchHook("anything"), jc.IsTrue)
+
+	err = s.ctx.StartRemoteSession([]string{"foo", "*"})
+	c.Assert(err, jc.ErrorIsNil)
+	session, err = s.ctx.FindRemoteSession()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(session.MatchHook("anything"), jc.IsTrue)
+
+	err = s.ctx.StartRemoteSession([]string{"foo", "bar"})
+	c.Assert(err, jc.ErrorIsNil)
+	session, err = s.ctx.FindRemoteSession()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(session.MatchHook("foo"), jc.IsTrue)
+	c.Assert(session.MatchHook("bar"), jc.IsTrue)
+	c.Assert(session.MatchHook("baz"), jc.IsFalse)
+
+	err = s.ctx.StopRemoteSession()
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.ctx.FindRemoteSession()
+	c.Assert(err, jc.Satisfies, os.IsNotExist)
+
+	// Stopping a stopped session is not an error.
+	err = s.ctx.StopRemoteSession()
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *DebugHooksRemoteSuite) startHook(c *gc.C, hookErr error) (<-chan error, <-chan struct{}) {
+	return s.startHookWithAbort(c, hookErr, nil)
+}
+
+func (s *DebugHooksRemoteSuite) startHookWithAbort(c *gc.C, hookErr error, abort <-chan struct{}) (<-chan error, <-chan struct{}) {
+	err := s.ctx.StartRemoteSession(nil)
+	c.Assert(err, jc.ErrorIsNil)
+	session, err := s.ctx.FindRemoteSession()
+	c.Assert(err, jc.ErrorIsNil)
+
+	ran := make(chan struct{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- session.RunHook("myhook", s.tmpdir, []string{"FOO=bar"}, abort, func() error {
+			ran <- struct{}{}
+			return hookErr
+		})
+	}()
+	return done, ran
+}
+
+func (s *DebugHooksRemoteSuite) waitForHook(c *gc.C, client *RemoteClient) *HookInfo {
+	timeout := time.After(testing.LongWait)
+	for {
+		info, err := client.Hook()
+		if err == nil {
+			return info
+		}
+		select {
+		case <-timeout:
+			c.Fatalf("timed out waiting for paused hook: %v", err)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func (s *DebugHooksRemoteSuite) TestExecAndStep(c *gc.C) {
+	done, ran := s.startHook(c, nil)
+	client := NewRemoteClient(s.ctx)
+	info := s.waitForHook(c, client)
+	c.Assert(info.Hook, gc.Equals, "myhook")
+	c.Assert(info.Env, jc.DeepEquals, []string{"FOO=bar", "JUJU_HOOK_NAME=myhook"})
+
+	response, err := client.Exec("echo $FOO $JUJU_HOOK_NAME; pwd; exit 3")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(response.Code, gc.Equals, 3)
+	c.Assert(string(response.Stdout), gc.Equals, "bar myhook\n"+s.tmpdir+"\n")
+
+	select {
+	case <-ran:
+		c.Fatalf("hook ran before being continued")
+	default:
+	}
+
+	result, err := client.Continue(false)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, &ContinueResult{Hook: "myhook"})
+	c.Assert(<-done, jc.ErrorIsNil)
+	<-ran
+
+	// The session is still active, but nothing is paused.
+	_, err = s.ctx.FindRemoteSession()
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = client.Hook()
+	c.Assert(err, gc.ErrorMatches, `no hook paused for "foo/8": .*`)
+}
+
+func (s *DebugHooksRemoteSuite) TestResume(c *gc.C) {
+	done, _ := s.startHook(c, errors.New("blam"))
+	client := NewRemoteClient(s.ctx)
+	s.waitForHook(c, client)
+
+	result, err := client.Continue(true)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, &ContinueResult{Hook: "myhook", Error: "blam"})
+	c.Assert(<-done, gc.ErrorMatches, "blam")
+
+	_, err = s.ctx.FindRemoteSession()
+	c.Assert(err, jc.Satisfies, os.IsNotExist)
+}
+
+func (s *DebugHooksRemoteSuite) TestAbort(c *gc.C) {
+	abort := make(chan struct{})
+	done, ran := s.startHookWithAbort(c, errors.New("blam"), abort)
+	client := NewRemoteClient(s.ctx)
+	s.waitForHook(c, client)
+
+	close(abort)
+	c.Assert(<-done, gc.ErrorMatches, "blam")
+	<-ran
+
+	// The session is over; the hook cannot be continued.
+	_, err := s.ctx.FindRemoteSession()
+	c.Assert(err, jc.Satisfies, os.IsNotExist)
+	_, err = client.Continue(false)
+	c.Assert(err, gc.ErrorMatches, `no hook paused for "foo/8": .*`)
+}
+
+func (s *DebugHooksRemoteSuite) TestIdleTimeout(c *gc.C) {
+	s.PatchValue(&RemoteIdleTimeout, 100*time.Millisecond)
+	done, ran := s.startHook(c, nil)
+
+	select {
+	case err := <-done:
+		c.Assert(err, jc.ErrorIsNil)
+	case <-time.After(testing.LongWait):
+		c.Fatalf("hook still paused after client went away")
+	}
+	<-ran
+	_, err := s.ctx.FindRemoteSession()
+	c.Assert(err, jc.Satisfies, os.IsNotExist)
+}
@@ -24,6 +24,7 @@ var (
 	TryOpenPorts      = tryOpenPorts
 	TryClosePorts     = tryClosePorts
 	LockTimeout       = lockTimeout
+	NewTailBuffer     = newTailBuffer
 )
 
 func RunnerPaths(rnr Runner) Paths {
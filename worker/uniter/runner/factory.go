@@ -62,7 +62,8 @@ type StorageContextAccessor interface {
 type RelationsFunc func() map[int]*RelationInfo
 
 // NewFactory returns a Factory capable of creating execution contexts backed
-// by the supplied unit's supplied API connection.
+// by the supplied unit's supplied API connection. Hooks paused by a remote
+// debug-hooks session stop waiting for the client when abort is closed.
 func NewFactory(
 	state *uniter.State,
 	unitTag names.UnitTag,
@@ -70,6 +71,7 @@ func NewFactory(
 	getRelationInfos RelationsFunc,
 	storage StorageContextAccessor,
 	paths Paths,
+	abort <-chan struct{},
 ) (
 	Factory, error,
 ) {
@@ -106,6 +108,7 @@ func NewFactory(
 		relationCaches:   map[int]*RelationCache{},
 		storage:          storage,
 		rand:             rand.New(rand.NewSource(time.Now().Unix())),
+		abort:            abort,
 	}, nil
 }
 
@@ -129,6 +132,9 @@ type factory struct {
 
 	// For generating "unique" context ids.
 	rand *rand.Rand
+
+	// abort is passed to the runners created by the factory.
+	abort <-chan struct{}
 }
 
 // NewCommandRunner exists to satisfy the Factory interface.
@@ -144,8 +150,7 @@ func (f *factory) NewCommandRunner(commandInfo CommandInfo) (Runner, error) {
 	ctx.relationId = relationId
 	ctx.remoteUnitName = remoteUnitName
 	ctx.id = f.newId("run-commands")
-	runner := NewRunner(ctx, f.paths)
-	return runner, nil
+	return &runner{ctx, f.paths, f.abort}, nil
 }
 
 // NewHookRunner exists to satisfy the Factory interface.
@@ -209,8 +214,7 @@ func (f *factory) NewHookRunner(hookInfo hook.Info) (Runner, error) {
 		}
 	}
 	ctx.id = f.newId(hookName)
-	runner := NewRunner(ctx, f.paths)
-	return runner, nil
+	return &runner{ctx, f.paths, f.abort}, nil
 }
 
 // NewActionRunner exists to satisfy the Factory interface.
@@ -255,8 +259,7 @@ func (f *factory) NewActionRunner(actionId string) (Runner, error) {
 	}
 	ctx.actionData = newActionData(name, &tag, params)
 	ctx.id = f.newId(name)
-	runner := NewRunner(ctx, f.paths)
-	return runner, nil
+	return &runner{ctx, f.paths, f.abort}, nil
 }
 
 // newId returns a probably-unique identifier for a new context, containing the
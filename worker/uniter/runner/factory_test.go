@@ -59,6 +59,7 @@ func (s *FactorySuite) SetUpTest(c *gc.C) {
 		s.getRelationInfos,
 		s.storage,
 		s.paths,
+		nil,
 	)
 	c.Assert(err, jc.ErrorIsNil)
 	s.factory = factory
@@ -332,6 +333,7 @@ func (s *FactorySuite) TestNewHookRunnerWithStorage(c *gc.C) {
 		s.getRelationInfos,
 		s.storage,
 		s.paths,
+		nil,
 	)
 	c.Assert(err, jc.ErrorIsNil)
 
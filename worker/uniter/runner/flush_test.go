@@ -186,7 +186,7 @@ func (s *FlushContextSuite) TestRunHookOpensAndClosesPendingPorts(c *gc.C) {
 	unitRanges, err = s.unit.OpenedPorts()
 	c.Assert(err, jc.ErrorIsNil)
 	c.Assert(unitRanges, jc.DeepEquals, []network.PortRange{
-		{100, 200, "tcp"},
+		{FromPort: 100, ToPort: 200, Protocol: "tcp"},
 	})
 
 	// Get the context.
@@ -226,7 +226,7 @@ func (s *FlushContextSuite) TestRunHookOpensAndClosesPendingPorts(c *gc.C) {
 	unitRanges, err = s.unit.OpenedPorts()
 	c.Assert(err, jc.ErrorIsNil)
 	c.Assert(unitRanges, jc.DeepEquals, []network.PortRange{
-		{100, 200, "tcp"},
+		{FromPort: 100, ToPort: 200, Protocol: "tcp"},
 	})
 
 	// Flush the context with a success.
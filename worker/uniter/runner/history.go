@@ -0,0 +1,71 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package runner
+
+import (
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/apiserver/params"
+)
+
+// maxStderrTail is the number of bytes of a hook's standard error
+// output that is recorded in the unit's history.
+const maxStderrTail = 1024
+
+// tailBuffer is an io.Writer that retains only the last max bytes
+// written to it.
+type tailBuffer struct {
+	mu   sync.Mutex
+	max  int
+	data []byte
+}
+
+func newTailBuffer(max int) *tailBuffer {
+	return &tailBuffer{max: max}
+}
+
+// Write is part of the io.Writer interface.
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append(b.data, p...)
+	if excess := len(b.data) - b.max; excess > 0 {
+		b.data = append(b.data[:0], b.data[excess:]...)
+	}
+	return len(p), nil
+}
+
+// String returns the retained data.
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.data)
+}
+
+// historyEntry returns a history entry describing the execution of
+// the named hook or action, which started at the given time and
+// failed with err, if not nil.
+func historyEntry(kind, name string, started time.Time, err error, stderr *tailBuffer) params.UnitHistoryEntry {
+	entry := params.UnitHistoryEntry{
+		Kind:       kind,
+		Name:       name,
+		Started:    started,
+		Finished:   time.Now(),
+		StderrTail: stderr.String(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		if exitErr, ok := errors.Cause(err).(*exec.ExitError); ok {
+			if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+				entry.ExitStatus = status.ExitStatus()
+			}
+		}
+	}
+	return entry
+}
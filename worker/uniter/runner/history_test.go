@@ -0,0 +1,31 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package runner_test
+
+import (
+	"fmt"
+
+	envtesting "github.com/juju/testing"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/worker/uniter/runner"
+)
+
+type TailBufferSuite struct {
+	envtesting.IsolationSuite
+}
+
+var _ = gc.Suite(&TailBufferSuite{})
+
+func (s *TailBufferSuite) TestWrite(c *gc.C) {
+	buf := runner.NewTailBuffer(8)
+	fmt.Fprint(buf, "abc")
+	c.Assert(buf.String(), gc.Equals, "abc")
+	fmt.Fprint(buf, "defgh")
+	c.Assert(buf.String(), gc.Equals, "abcdefgh")
+	fmt.Fprint(buf, "ij")
+	c.Assert(buf.String(), gc.Equals, "cdefghij")
+	fmt.Fprint(buf, "0123456789")
+	c.Assert(buf.String(), gc.Equals, "23456789")
+}
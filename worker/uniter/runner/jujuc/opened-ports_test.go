@@ -23,10 +23,10 @@ var _ = gc.Suite(&OpenedPortsSuite{})
 
 func (s *OpenedPortsSuite) TestRunAllFormats(c *gc.C) {
 	expectedPorts := []network.PortRange{
-		{10, 20, "tcp"},
-		{80, 80, "tcp"},
-		{53, 55, "udp"},
-		{63, 63, "udp"},
+		{FromPort: 10, ToPort: 20, Protocol: "tcp"},
+		{FromPort: 80, ToPort: 80, Protocol: "tcp"},
+		{FromPort: 53, ToPort: 55, Protocol: "udp"},
+		{FromPort: 63, ToPort: 63, Protocol: "udp"},
 	}
 	network.SortPortRanges(expectedPorts)
 	portsAsStrings := make([]string, len(expectedPorts))
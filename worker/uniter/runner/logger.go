@@ -18,6 +18,9 @@ type hookLogger struct {
 	mu      sync.Mutex
 	stopped bool
 	logger  loggo.Logger
+
+	// tail, if not nil, receives a copy of each line logged.
+	tail io.Writer
 }
 
 func (l *hookLogger) run() {
@@ -38,6 +41,9 @@ func (l *hookLogger) run() {
 			return
 		}
 		l.logger.Infof("%s", line)
+		if l.tail != nil {
+			l.tail.Write(append(line, '\n'))
+		}
 		l.mu.Unlock()
 	}
 }
@@ -5,15 +5,18 @@ package runner
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"time"
 
 	"github.com/juju/cmd"
 	"github.com/juju/errors"
 	"github.com/juju/loggo"
 	utilexec "github.com/juju/utils/exec"
 
+	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/version"
 	"github.com/juju/juju/worker/uniter/runner/debug"
 	"github.com/juju/juju/worker/uniter/runner/jujuc"
@@ -42,6 +45,7 @@ type Context interface {
 	HookVars(paths Paths) []string
 	ActionData() (*ActionData, error)
 	SetProcess(process *os.Process)
+	RecordHistory(entry params.UnitHistoryEntry) error
 	FlushContext(badge string, failure error) error
 	HasExecutionSetUnitStatus() bool
 }
@@ -69,13 +73,14 @@ type Paths interface {
 
 // NewRunner returns a Runner backed by the supplied context and paths.
 func NewRunner(context Context, paths Paths) Runner {
-	return &runner{context, paths}
+	return &runner{context, paths, nil}
 }
 
 // runner implements Runner.
 type runner struct {
 	context Context
 	paths   Paths
+	abort   <-chan struct{}
 }
 
 func (runner *runner) Context() Context {
@@ -135,17 +140,38 @@ func (runner *runner) runCharmHookWithLocation(hookName, charmLocation string) e
 		env = mergeEnvironment(env)
 	}
 
+	started := time.Now()
+	stderr := newTailBuffer(maxStderrTail)
 	debugctx := debug.NewHooksContext(runner.context.UnitName())
 	if session, _ := debugctx.FindSession(); session != nil && session.MatchHook(hookName) {
 		logger.Infof("executing %s via debug-hooks", hookName)
 		err = session.RunHook(hookName, runner.paths.GetCharmDir(), env)
+	} else if session, _ := debugctx.FindRemoteSession(); session != nil && session.MatchHook(hookName) {
+		logger.Infof("pausing %s for debug-hooks", hookName)
+		err = session.RunHook(hookName, runner.paths.GetCharmDir(), env, runner.abort, func() error {
+			return runner.runCharmHook(hookName, env, charmLocation, stderr)
+		})
 	} else {
-		err = runner.runCharmHook(hookName, env, charmLocation)
+		err = runner.runCharmHook(hookName, env, charmLocation, stderr)
+	}
+	if !IsMissingHookError(err) {
+		kind := "hook"
+		if charmLocation == "actions" {
+			kind = "action"
+		}
+		entry := historyEntry(kind, hookName, started, err, stderr)
+		if historyErr := runner.context.RecordHistory(entry); historyErr != nil {
+			// History is informational only; failing to record
+			// it must not affect the outcome of the hook.
+			logger.Warningf("cannot record history of %s: %v", hookName, historyErr)
+		}
 	}
 	return runner.context.FlushContext(hookName, err)
 }
 
-func (runner *runner) runCharmHook(hookName string, env []string, charmLocation string) error {
+// runCharmHook executes the named hook, copying the tail of its
+// standard error output to stderr.
+func (runner *runner) runCharmHook(hookName string, env []string, charmLocation string, stderr io.Writer) error {
 	charmDir := runner.paths.GetCharmDir()
 	hook, err := searchHook(charmDir, filepath.Join(charmLocation, hookName))
 	if err != nil {
@@ -163,23 +189,38 @@ func (runner *runner) runCharmHook(hookName string, env []string, charmLocation
 	if err != nil {
 		return errors.Errorf("cannot make logging pipe: %v", err)
 	}
+	errReader, errWriter, err := os.Pipe()
+	if err != nil {
+		outReader.Close()
+		outWriter.Close()
+		return errors.Errorf("cannot make logging pipe: %v", err)
+	}
 	ps.Stdout = outWriter
-	ps.Stderr = outWriter
-	hookLogger := &hookLogger{
+	ps.Stderr = errWriter
+	outLogger := &hookLogger{
 		r:      outReader,
 		done:   make(chan struct{}),
 		logger: runner.getLogger(hookName),
 	}
-	go hookLogger.run()
+	errLogger := &hookLogger{
+		r:      errReader,
+		done:   make(chan struct{}),
+		logger: runner.getLogger(hookName),
+		tail:   stderr,
+	}
+	go outLogger.run()
+	go errLogger.run()
 	err = ps.Start()
 	outWriter.Close()
+	errWriter.Close()
 	if err == nil {
 		// Record the *os.Process of the hook
 		runner.context.SetProcess(ps.Process)
 		// Block until execution finishes
 		err = ps.Wait()
 	}
-	hookLogger.stop()
+	outLogger.stop()
+	errLogger.stop()
 	return errors.Trace(err)
 }
 
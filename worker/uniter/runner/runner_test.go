@@ -18,6 +18,7 @@ import (
 	"github.com/juju/utils"
 	gc "gopkg.in/check.v1"
 
+	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/worker/uniter/runner"
 )
 
@@ -151,6 +152,7 @@ type MockContext struct {
 	flushBadge   string
 	flushFailure error
 	flushResult  error
+	history      []params.UnitHistoryEntry
 }
 
 func (ctx *MockContext) UnitName() string {
@@ -172,6 +174,11 @@ func (ctx *MockContext) SetProcess(process *os.Process) {
 	ctx.expectPid = process.Pid
 }
 
+func (ctx *MockContext) RecordHistory(entry params.UnitHistoryEntry) error {
+	ctx.history = append(ctx.history, entry)
+	return nil
+}
+
 func (ctx *MockContext) FlushContext(badge string, failure error) error {
 	ctx.flushBadge = badge
 	ctx.flushFailure = failure
@@ -293,3 +300,52 @@ func (s *RunMockContextSuite) TestRunCommandsFlushFailure(c *gc.C) {
 	c.Assert(ctx.flushFailure, gc.IsNil) // exit code in _ result, as tested elsewhere
 	s.assertRecordedPid(c, ctx.expectPid)
 }
+
+func (s *RunMockContextSuite) TestRunHookRecordsHistory(c *gc.C) {
+	if runtime.GOOS == "windows" {
+		c.Skip("bug 1403084: Have to figure out a good way to output to stderr from powershell")
+	}
+	ctx := &MockContext{}
+	makeCharm(c, hookSpec{
+		dir:    "hooks",
+		name:   hookName,
+		perm:   0700,
+		code:   123,
+		stdout: "not-recorded",
+		stderr: "recorded",
+	}, s.paths.charm)
+	t0 := time.Now()
+	err := runner.NewRunner(ctx, s.paths).RunHook("something-happened")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ctx.history, gc.HasLen, 1)
+	entry := ctx.history[0]
+	c.Assert(entry.Kind, gc.Equals, "hook")
+	c.Assert(entry.Name, gc.Equals, "something-happened")
+	c.Assert(entry.ExitStatus, gc.Equals, 123)
+	c.Assert(entry.Error, gc.Equals, "exit status 123")
+	c.Assert(entry.StderrTail, gc.Equals, "recorded\n")
+	c.Assert(entry.Started.Before(t0), jc.IsFalse)
+	c.Assert(entry.Finished.Before(entry.Started), jc.IsFalse)
+}
+
+func (s *RunMockContextSuite) TestRunActionRecordsHistory(c *gc.C) {
+	ctx := &MockContext{actionData: &runner.ActionData{}}
+	makeCharm(c, hookSpec{
+		dir:  "actions",
+		name: hookName,
+		perm: 0700,
+	}, s.paths.charm)
+	err := runner.NewRunner(ctx, s.paths).RunAction("something-happened")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ctx.history, gc.HasLen, 1)
+	c.Assert(ctx.history[0].Kind, gc.Equals, "action")
+	c.Assert(ctx.history[0].Name, gc.Equals, "something-happened")
+	c.Assert(ctx.history[0].Error, gc.Equals, "")
+}
+
+func (s *RunMockContextSuite) TestRunMissingHookRecordsNoHistory(c *gc.C) {
+	ctx := &MockContext{}
+	err := runner.NewRunner(ctx, s.paths).RunHook("something-happened")
+	c.Assert(runner.IsMissingHookError(err), jc.IsTrue)
+	c.Assert(ctx.history, gc.HasLen, 0)
+}
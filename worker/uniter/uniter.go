@@ -14,13 +14,15 @@ import (
 	"github.com/juju/loggo"
 	"github.com/juju/names"
 	"github.com/juju/utils/exec"
-	"github.com/juju/utils/fslock"
 	corecharm "gopkg.in/juju/charm.v5-unstable"
 	"launchpad.net/tomb"
 
 	"github.com/juju/juju/api/uniter"
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/environs/config"
 	coreleadership "github.com/juju/juju/leadership"
+	"github.com/juju/juju/trace"
+	"github.com/juju/juju/utils/hooklock"
 	"github.com/juju/juju/version"
 	"github.com/juju/juju/worker"
 	"github.com/juju/juju/worker/leadership"
@@ -73,9 +75,15 @@ type Uniter struct {
 	leadershipManager coreleadership.LeadershipManager
 	leadershipTracker leadership.Tracker
 
-	hookLock    *fslock.Lock
+	hookLock    *hooklock.Lock
 	runListener *RunListener
 
+	// hookConcurrency is the number of hooks that may run
+	// concurrently on the machine, as last read from the
+	// environment configuration.
+	hookConcurrencyMutex sync.Mutex
+	hookConcurrency      int
+
 	ranConfigChanged bool
 
 	// The execution observer is only used in tests at this stage. Should this
@@ -89,18 +97,21 @@ type Uniter struct {
 
 // NewUniter creates a new Uniter which will install, run, and upgrade
 // a charm on behalf of the unit with the given unitTag, by executing
-// hooks and operations provoked by changes in st.
+// hooks and operations provoked by changes in st. As many hooks may run
+// at once on the machine as the environment's hook-concurrency setting
+// allows, unless the charm requires its hooks to run exclusively.
 func NewUniter(
 	st *uniter.State,
 	unitTag names.UnitTag,
 	leadershipManager coreleadership.LeadershipManager,
 	dataDir string,
-	hookLock *fslock.Lock,
+	hookLock *hooklock.Lock,
 ) *Uniter {
 	u := &Uniter{
 		st:                st,
 		paths:             NewPaths(dataDir, unitTag),
 		hookLock:          hookLock,
+		hookConcurrency:   config.DefaultHookConcurrency,
 		leadershipManager: leadershipManager,
 		collectMetricsAt:  inactiveMetricsTimer,
 	}
@@ -153,9 +164,17 @@ func (u *Uniter) loop(unitTag names.UnitTag) (err error) {
 	}
 	u.addCleanup(u.f.Stop)
 
-	// Stop the uniter if either of these components fails.
+	// Follow changes to the hook-concurrency setting.
+	configw, err := u.st.WatchForEnvironConfigChanges()
+	if err != nil {
+		return err
+	}
+	u.addCleanup(configw.Stop)
+
+	// Stop the uniter if any of these components fails.
 	go func() { u.tomb.Kill(leadershipTracker.Wait()) }()
 	go func() { u.tomb.Kill(u.f.Wait()) }()
+	go func() { u.tomb.Kill(u.watchHookConcurrency(configw)) }()
 
 	// Run modes until we encounter an error.
 	mode := ModeContinue
@@ -185,18 +204,13 @@ func (u *Uniter) loop(unitTag names.UnitTag) (err error) {
 }
 
 func (u *Uniter) setupLocks() (err error) {
-	if message := u.hookLock.Message(); u.hookLock.IsLocked() && message != "" {
-		// Look to see if it was us that held the lock before.  If it was, we
-		// should be safe enough to break it, as it is likely that we died
-		// before unlocking, and have been restarted by the init system.
+	// Look to see if it was us that held the lock before.  If it was, we
+	// should be safe enough to break it, as it is likely that we died
+	// before unlocking, and have been restarted by the init system.
+	return u.hookLock.BreakLocks(func(message string) bool {
 		parts := strings.SplitN(message, ":", 2)
-		if len(parts) > 1 && parts[0] == u.unit.Name() {
-			if err := u.hookLock.BreakLock(); err != nil {
-				return err
-			}
-		}
-	}
-	return nil
+		return len(parts) > 1 && parts[0] == u.unit.Name()
+	})
 }
 
 func (u *Uniter) init(unitTag names.UnitTag) (err error) {
@@ -245,6 +259,7 @@ func (u *Uniter) init(unitTag names.UnitTag) (err error) {
 	u.deployer = &deployerProxy{deployer}
 	runnerFactory, err := runner.NewFactory(
 		u.st, unitTag, u.leadershipTracker, u.relations.GetInfo, u.storage, u.paths,
+		u.tomb.Dying(),
 	)
 	if err != nil {
 		return err
@@ -391,5 +406,17 @@ func (u *Uniter) runOperation(creator creator) error {
 	if err != nil {
 		return errors.Annotatef(err, "cannot create operation")
 	}
-	return u.operationExecutor.Run(op)
+	// Label the operation with the trace id of the request that last
+	// changed the unit, so it can be followed from the API server.
+	traceId, err := u.unit.TraceId()
+	if err != nil {
+		logger.Warningf("cannot get trace id for unit %q: %v", u.unit, err)
+	}
+	tlogger := trace.NewLogger(logger, traceId)
+	tlogger.Debugf("running operation %v", op)
+	if err := u.operationExecutor.Run(op); err != nil {
+		tlogger.Debugf("operation %v failed: %v", op, err)
+		return err
+	}
+	return nil
 }
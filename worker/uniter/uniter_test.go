@@ -390,6 +390,18 @@ func (s *UniterSuite) TestUniterHookSynchronisation(c *gc.C) {
 	})
 }
 
+func (s *UniterSuite) TestUniterHookConcurrencyChange(c *gc.C) {
+	s.runUniterTests(c, []uniterTest{
+		ut(
+			"hook concurrency follows environment config",
+			quickStart{},
+			verifyHookConcurrency{1},
+			changeHookConcurrency{4},
+			verifyHookConcurrency{4},
+		),
+	})
+}
+
 func (s *UniterSuite) TestUniterDyingReaction(c *gc.C) {
 	s.runUniterTests(c, []uniterTest{
 		// Reaction to entity deaths.
@@ -41,6 +41,7 @@ import (
 	"github.com/juju/juju/state/storage"
 	"github.com/juju/juju/testcharms"
 	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/utils/hooklock"
 	"github.com/juju/juju/worker"
 	"github.com/juju/juju/worker/uniter"
 	"github.com/juju/juju/worker/uniter/charm"
@@ -423,7 +424,7 @@ func (s startUniter) step(c *gc.C, ctx *context) {
 		panic(err.Error())
 	}
 	locksDir := filepath.Join(ctx.dataDir, "locks")
-	lock, err := fslock.NewLock(locksDir, "uniter-hook-execution")
+	lock, err := hooklock.New(locksDir)
 	c.Assert(err, jc.ErrorIsNil)
 	ctx.uniter = uniter.NewUniter(ctx.api, tag, ctx.leader, ctx.dataDir, lock)
 	uniter.SetUniterObserver(ctx.uniter, ctx)
@@ -1167,6 +1168,30 @@ func (s chmod) step(c *gc.C, ctx *context) {
 	c.Assert(err, jc.ErrorIsNil)
 }
 
+type changeHookConcurrency struct {
+	concurrency int
+}
+
+func (s changeHookConcurrency) step(c *gc.C, ctx *context) {
+	err := ctx.st.UpdateEnvironConfig(map[string]interface{}{
+		"hook-concurrency": s.concurrency,
+	}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+type verifyHookConcurrency struct {
+	concurrency int
+}
+
+func (s verifyHookConcurrency) step(c *gc.C, ctx *context) {
+	for attempt := coretesting.LongAttempt.Start(); attempt.Next(); {
+		if uniter.HookConcurrency(ctx.uniter) == s.concurrency {
+			return
+		}
+	}
+	c.Fatalf("hook concurrency never became %d", s.concurrency)
+}
+
 type custom struct {
 	f func(*gc.C, *context)
 }